@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// headerFlags collects repeated -H "Name: value" flags.
+type headerFlags http.Header
+
+func (h headerFlags) String() string { return "" }
+
+func (h headerFlags) Set(v string) error {
+	name, value, ok := strings.Cut(v, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("header %q must be in Name: value form", v)
+	}
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	return nil
+}
+
+// headerTransport adds fixed headers to every request.
+type headerTransport struct {
+	header http.Header
+	next   http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.header {
+		req.Header[name] = values
+	}
+	return t.next.RoundTrip(req)
+}
+
+// call implements "mcp-server call", a client that connects to another MCP
+// server, initializes a session, and invokes one tool.
+func call(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("call", flag.ContinueOnError)
+	url := fs.String("url", "", "streamable HTTP endpoint of the server, e.g. http://localhost:8080/mcp")
+	command := fs.String("command", "", "command line that starts a stdio server, e.g. \"./mcp-server serve\"")
+	apiKey := fs.String("api-key", os.Getenv("MCP_API_KEY"), "API key sent as X-API-Key (default $MCP_API_KEY)")
+	timeout := fs.Duration("timeout", 30*time.Second, "overall timeout")
+	list := fs.Bool("list", false, "list the server's tools instead of calling one")
+	headers := headerFlags{}
+	fs.Var(headers, "H", "extra HTTP header as \"Name: value\"; may be repeated")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: mcp-server call (-url URL | -command CMD) [flags] <tool> [json-arguments]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	transport, err := clientTransport(*url, *command, *apiKey, http.Header(headers))
+	if err != nil {
+		return err
+	}
+
+	var name string
+	arguments := map[string]any{}
+	if !*list {
+		switch fs.NArg() {
+		case 2:
+			if err := json.Unmarshal([]byte(fs.Arg(1)), &arguments); err != nil {
+				return fmt.Errorf("arguments must be a JSON object: %w", err)
+			}
+			fallthrough
+		case 1:
+			name = fs.Arg(0)
+		default:
+			fs.Usage()
+			return errors.New("expected a tool name and optional JSON arguments")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "mcp-server-call", Version: version}, nil)
+	session, err := client.Connect(ctx, transport, nil)
+	if err != nil {
+		return fmt.Errorf("connecting: %w", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	if *list {
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		for tool, err := range session.Tools(ctx, nil) {
+			if err != nil {
+				return fmt.Errorf("listing tools: %w", err)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", tool.Name, tool.Description)
+		}
+		return tw.Flush()
+	}
+
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: name, Arguments: arguments})
+	if err != nil {
+		return fmt.Errorf("calling %s: %w", name, err)
+	}
+	if err := printResult(stdout, res); err != nil {
+		return err
+	}
+	if res.IsError {
+		return fmt.Errorf("tool %s reported an error", name)
+	}
+	return nil
+}
+
+// clientTransport builds the transport for exactly one of url or command.
+func clientTransport(url, command, apiKey string, header http.Header) (mcp.Transport, error) {
+	switch {
+	case url != "" && command != "":
+		return nil, errors.New("use either -url or -command, not both")
+	case url != "":
+		if apiKey != "" {
+			header.Set("X-API-Key", apiKey)
+		}
+		httpClient := &http.Client{Transport: &headerTransport{header: header, next: http.DefaultTransport}}
+		return &mcp.StreamableClientTransport{Endpoint: url, HTTPClient: httpClient, MaxRetries: -1}, nil
+	case command != "":
+		fields := strings.Fields(command)
+		cmd := exec.Command(fields[0], fields[1:]...)
+		cmd.Stderr = os.Stderr
+		return &mcp.CommandTransport{Command: cmd}, nil
+	default:
+		return nil, errors.New("one of -url or -command is required")
+	}
+}
+
+// printResult writes the structured result as indented JSON when present,
+// and otherwise each content block.
+func printResult(w io.Writer, res *mcp.CallToolResult) error {
+	if res.StructuredContent != nil && !res.IsError {
+		data, err := json.MarshalIndent(res.StructuredContent, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+	for _, c := range res.Content {
+		if text, ok := c.(*mcp.TextContent); ok {
+			if _, err := fmt.Fprintln(w, text.Text); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
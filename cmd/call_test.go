@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestCall(t *testing.T) {
+	server := mcp.NewServer(implementation, nil)
+	tools.RegisterAll(server)
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)
+
+	var gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("X-API-Key")
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "structured result",
+			args:       []string{"call", "-url", srv.URL, "-api-key", "secret", "hash", `{"data":"abc","algorithm":"sha256"}`},
+			wantStdout: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+		{
+			name:       "list tools",
+			args:       []string{"call", "-url", srv.URL, "-list"},
+			wantStdout: "generate_uuid",
+		},
+		{
+			name:       "tool error",
+			args:       []string{"call", "-url", srv.URL, "calculate", `{"expression":"1/0"}`},
+			wantCode:   1,
+			wantStdout: "division by zero",
+			wantStderr: "reported an error",
+		},
+		{
+			name:       "invalid arguments",
+			args:       []string{"call", "-url", srv.URL, "hash", "[1]"},
+			wantCode:   1,
+			wantStderr: "arguments must be a JSON object",
+		},
+		{
+			name:       "no transport",
+			args:       []string{"call", "hash"},
+			wantCode:   1,
+			wantStderr: "one of -url or -command is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+
+	if gotKey != "" {
+		// The last request came from a call without -api-key.
+		t.Errorf("X-API-Key leaked between calls: %q", gotKey)
+	}
+}
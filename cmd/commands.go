@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// command is a CLI subcommand. Names may contain spaces ("tools list"); the
+// longest name matching the leading arguments wins.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+// commands lists the subcommands. Running with no arguments serves.
+var commands = []command{
+	{name: "serve", summary: "Run the MCP server (default)", run: serve},
+	{name: "tools list", summary: "List the registered tools", run: toolsList},
+	{name: "tools export", summary: "Print a JSON or YAML manifest of the registered tools", run: toolsExport},
+	{name: "config validate", summary: "Check environment configuration for invalid values", run: configValidate},
+	{name: "config dump", summary: "Print the effective configuration with secrets masked", run: configDump},
+	{name: "config hash-key", summary: "Print the API_KEYS_HASHED form of an API key read from stdin", run: configHashKey},
+	{name: "call", summary: "Call a tool on another MCP server over HTTP or stdio", run: call},
+	{name: "replay", summary: "Replay requests recorded with CAPTURE_DIR against a server", run: replay},
+	{name: "version", summary: "Print version information", run: printVersion},
+}
+
+// run dispatches args to a subcommand and returns the process exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && !isHelp(args[0]) {
+		// Flags without a subcommand go to serve.
+		args = append([]string{"serve"}, args...)
+	}
+	if isHelp(args[0]) || args[0] == "help" {
+		usage(stdout)
+		return 0
+	}
+
+	cmd, rest := lookup(args)
+	if cmd == nil {
+		_, _ = fmt.Fprintf(stderr, "mcp-server: unknown command %q\n\n", strings.Join(args, " "))
+		usage(stderr)
+		return 2
+	}
+	if err := cmd.run(rest, stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		_, _ = fmt.Fprintf(stderr, "mcp-server %s: %v\n", cmd.name, err)
+		return 1
+	}
+	return 0
+}
+
+// lookup finds the command with the longest name matching the leading
+// words of args and returns it with the remaining arguments.
+func lookup(args []string) (*command, []string) {
+	var best *command
+	var rest []string
+	for i := range commands {
+		words := strings.Fields(commands[i].name)
+		if len(words) > len(args) || (best != nil && len(words) <= len(strings.Fields(best.name))) {
+			continue
+		}
+		if strings.Join(args[:len(words)], " ") == commands[i].name {
+			best, rest = &commands[i], args[len(words):]
+		}
+	}
+	return best, rest
+}
+
+func isHelp(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+func usage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "Usage: mcp-server [command] [flags]")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	_ = tw.Flush()
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Run 'mcp-server <command> -h' for command flags.")
+}
+
+// toolsList implements "mcp-server tools list".
+func toolsList(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("tools list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	server := mcp.NewServer(implementation, nil)
+	if err := tools.RegisterAll(server); err != nil {
+		return err
+	}
+	manifest, err := tools.BuildManifest(context.Background(), implementation, server)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, t := range manifest.Tools {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\n", t.Name, t.Description)
+	}
+	return tw.Flush()
+}
+
+// printVersion implements "mcp-server version".
+func printVersion(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(stdout, "mcp-server %s (bundle %s, %s %s/%s)\n", version, tools.Bundle, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return err
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantName string
+		wantRest []string
+	}{
+		{args: []string{"serve", "-port", "9000"}, wantName: "serve", wantRest: []string{"-port", "9000"}},
+		{args: []string{"tools", "export", "--format", "yaml"}, wantName: "tools export", wantRest: []string{"--format", "yaml"}},
+		{args: []string{"tools", "list"}, wantName: "tools list", wantRest: []string{}},
+		{args: []string{"tools"}, wantName: ""},
+		{args: []string{"bogus"}, wantName: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			cmd, rest := lookup(tt.args)
+			if tt.wantName == "" {
+				if cmd != nil {
+					t.Fatalf("lookup() = %q, want no command", cmd.name)
+				}
+				return
+			}
+			if cmd == nil || cmd.name != tt.wantName {
+				t.Fatalf("lookup() = %v, want %q", cmd, tt.wantName)
+			}
+			if strings.Join(rest, " ") != strings.Join(tt.wantRest, " ") {
+				t.Errorf("rest = %v, want %v", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestConfigValidateInvalid(t *testing.T) {
+	t.Setenv("FETCH_MAX_BYTES", "lots")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"config", "validate"}, &stdout, &stderr); code != 1 {
+		t.Errorf("run() = %d, want 1", code)
+	}
+	if want := `FETCH_MAX_BYTES="lots" is not a valid integer`; !strings.Contains(stderr.String(), want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr.String(), want)
+	}
+}
+
+func TestConfigValidateHTTPSettings(t *testing.T) {
+	t.Setenv("MCP_TRANSPORT", "http")
+	t.Setenv("EVENTS_RATE_LIMIT_THRESHOLD", "lots")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"config", "validate"}, &stdout, &stderr); code != 1 {
+		t.Errorf("run() = %d, want 1", code)
+	}
+	if want := `EVENTS_RATE_LIMIT_THRESHOLD="lots" is not a valid integer`; !strings.Contains(stderr.String(), want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr.String(), want)
+	}
+}
+
+func TestConfigValidateSecurity(t *testing.T) {
+	t.Setenv("MCP_TRANSPORT", "http")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"config", "validate"}, &stdout, &stderr); code != 0 {
+		t.Errorf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if want := "warning: auth_disabled"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+	}
+
+	t.Setenv("SECURITY_STRICT", "true")
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"config", "validate"}, &stdout, &stderr); code != 1 {
+		t.Errorf("run() with SECURITY_STRICT = %d, want 1", code)
+	}
+}
+
+func TestConfigDumpWithoutSideEffects(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MCP_TRANSPORT", "http")
+	t.Setenv("CAPTURE_DIR", filepath.Join(dir, "capture"))
+	t.Setenv("SESSION_STORE", "dir")
+	t.Setenv("SESSION_STORE_DIR", filepath.Join(dir, "sessions"))
+	t.Setenv("FETCH_MAX_BYTES", "2048")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"config", "dump"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	// Tool and http settings are dumped without building anything
+	for _, key := range []string{"CAPTURE_DIR", "SESSION_STORE_DIR", "FETCH_MAX_BYTES"} {
+		if !strings.Contains(stdout.String(), key) {
+			t.Errorf("dump lacks %s", key)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("config dump created %v", entries)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "version", args: []string{"version"}, wantCode: 0, wantStdout: "mcp-server " + version},
+		{name: "help", args: []string{"help"}, wantCode: 0, wantStdout: "Commands:"},
+		{name: "unknown command", args: []string{"bogus"}, wantCode: 2, wantStderr: `unknown command "bogus"`},
+		{name: "bad flag", args: []string{"version", "-x"}, wantCode: 1, wantStderr: "flag provided but not defined"},
+		{name: "tools list", args: []string{"tools", "list"}, wantCode: 0, wantStdout: "generate_uuid"},
+		{name: "config validate", args: []string{"config", "validate"}, wantCode: 0, wantStdout: "configuration is valid"},
+		{name: "config dump", args: []string{"config", "dump"}, wantCode: 0, wantStdout: "MCP_TRANSPORT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Errorf("run() = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestConfigHashKey(t *testing.T) {
+	old := hashKeyInput
+	t.Cleanup(func() { hashKeyInput = old })
+
+	hashKeyInput = strings.NewReader("secret\n")
+	var stdout bytes.Buffer
+	if err := configHashKey(nil, &stdout); err != nil {
+		t.Fatalf("configHashKey() error = %v", err)
+	}
+	want := "sha256:2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b\n"
+	if stdout.String() != want {
+		t.Errorf("configHashKey() = %q, want %q", stdout.String(), want)
+	}
+
+	hashKeyInput = strings.NewReader("\n")
+	if err := configHashKey(nil, &stdout); err == nil {
+		t.Error("configHashKey() with no key: error = nil, want error")
+	}
+}
+
+func TestToolsAnnotated(t *testing.T) {
+	server := mcp.NewServer(implementation, nil)
+	tools.RegisterAll(server)
+	manifest, err := tools.BuildManifest(context.Background(), implementation, server)
+	if err != nil {
+		t.Fatalf("BuildManifest() error = %v", err)
+	}
+	for _, tool := range manifest.Tools {
+		if tool.Annotations == nil {
+			t.Errorf("tool %s has no annotations", tool.Name)
+		}
+	}
+}
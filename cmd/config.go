@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/pkg/server"
+)
+
+// configValidate implements "mcp-server config validate", reporting every
+// environment value that could not be parsed and the security check
+// findings, which fail validation when SECURITY_STRICT is set.
+func configValidate(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	findings, err := server.ReadEnv()
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	for _, f := range findings {
+		_, _ = fmt.Fprintf(stdout, "warning: %s: %s\n", f.Check, f.Message)
+	}
+	if len(findings) > 0 && config.GetEnvBool("SECURITY_STRICT", false) {
+		return fmt.Errorf("security check failed (SECURITY_STRICT is set)")
+	}
+	_, err = fmt.Fprintln(stdout, "configuration is valid")
+	return err
+}
+
+// configDump implements "mcp-server config dump", printing every setting
+// with its resolved value, default, and source. Secrets are masked.
+func configDump(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("config dump", flag.ContinueOnError)
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if _, err := server.ReadEnv(); err != nil {
+		return err
+	}
+	settings := config.Effective()
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]any{"settings": settings})
+	case "table":
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE\tDEFAULT")
+		for _, s := range settings {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Key, s.Value, s.Source, s.Default)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported format %q: use table or json", *format)
+	}
+}
+
+// hashKeyInput is where configHashKey reads keys from.
+var hashKeyInput io.Reader = os.Stdin
+
+// configHashKey implements "mcp-server config hash-key", printing the
+// API_KEYS_HASHED entry for each key read from stdin, one per line. Keys
+// are not taken as arguments so they stay out of shell history.
+func configHashKey(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("config hash-key", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(hashKeyInput)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, key := range strings.Split(string(data), "\n") {
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(stdout, config.HashAPIKey(key)); err != nil {
+			return err
+		}
+		n++
+	}
+	if n == 0 {
+		return fmt.Errorf("no key on stdin")
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import (
+	"context"
+
+	"github.com/lkendrickd/mcp-server/pkg/server"
+)
+
+// drainOnSignal does nothing where SIGUSR1 does not exist; use
+// /admin/drain instead.
+func drainOnSignal(context.Context, *server.Server) {}
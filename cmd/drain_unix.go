@@ -0,0 +1,27 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lkendrickd/mcp-server/pkg/server"
+)
+
+// drainOnSignal drains srv on SIGUSR1, for preStop hooks that signal the
+// process rather than calling /admin/drain.
+func drainOnSignal(ctx context.Context, srv *server.Server) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR1)
+	go func() {
+		defer signal.Stop(sig)
+		select {
+		case <-sig:
+			srv.Drain()
+		case <-ctx.Done():
+		}
+	}()
+}
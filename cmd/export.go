@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// toolsExport implements "mcp-server tools export", printing a manifest of
+// the registered tools. Tools that depend on configuration are only listed
+// when the environment enables them, as at runtime.
+func toolsExport(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("tools export", flag.ContinueOnError)
+	format := fs.String("format", "json", "manifest format: json or yaml")
+	output := fs.String("o", "", "write the manifest to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	server := mcp.NewServer(implementation, nil)
+	if err := tools.RegisterAll(server); err != nil {
+		return err
+	}
+	manifest, err := tools.BuildManifest(context.Background(), implementation, server)
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	if err := manifest.Encode(w, *format); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	return nil
+}
@@ -2,94 +2,52 @@ package main
 
 import (
 	"context"
-	"log/slog"
-	"net/http"
+	"flag"
+	"io"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 
-	"github.com/lkendrickd/mcp-server/internal/config"
-	"github.com/lkendrickd/mcp-server/internal/handlers"
-	"github.com/lkendrickd/mcp-server/internal/middleware"
-	"github.com/lkendrickd/mcp-server/internal/tools"
-	_ "github.com/lkendrickd/mcp-server/internal/tools/uuid"
+	"github.com/lkendrickd/mcp-server/pkg/server"
 )
 
-func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
-
-	// Load configuration from environment
-	cfg := config.New()
-
-	// Register prometheus metrics
-	prometheus.MustRegister(middleware.RequestDuration, middleware.EndpointCount)
-
-	// Create MCP server with capabilities
-	server := mcp.NewServer(&mcp.Implementation{
-		Name:    "mcp-server",
-		Version: "0.0.1",
-	}, nil)
-	tools.RegisterAll(server)
-
-	// Determine transport mode from environment
-	transport := getEnv("MCP_TRANSPORT", "stdio")
-
-	switch transport {
-	case "sse", "http":
-		// HTTP transport - Streamable HTTP handler for MCP
-		mux := http.NewServeMux()
-		mux.HandleFunc("GET /health", handlers.HealthHandler)
-		mux.Handle("GET /metrics", promhttp.Handler())
-
-		// Streamable HTTP handler for MCP
-		httpHandler := mcp.NewStreamableHTTPHandler(func(_ *http.Request) *mcp.Server {
-			return server
-		}, nil)
-		mux.Handle("/mcp", httpHandler)
-		mux.Handle("/mcp/", httpHandler)
+// version is the release version, overridable at build time with
+// -ldflags "-X main.version=...".
+var version = "0.0.1"
 
-		// Build handler chain: metrics -> auth (if enabled) -> mux
-		var handler http.Handler = mux
-		if cfg.AuthEnabled {
-			// Protect /mcp endpoints with API key authentication
-			protectedPrefixes := []string{"/mcp"}
-			handler = middleware.AuthMiddleware(cfg, protectedPrefixes)(handler)
-			logger.Info("API key authentication enabled", "key_count", cfg.APIKeyCount())
-		}
-		handler = middleware.MetricsMiddleware(handler)
-
-		logger.Info("mcp server starting with HTTP transport", "port", cfg.Port)
-		if err := http.ListenAndServe(":"+cfg.Port, handler); err != nil {
-			logger.Error("http server error", "error", err)
-			os.Exit(1)
-		}
+// implementation identifies this server to MCP clients.
+var implementation = &mcp.Implementation{
+	Name:    "mcp-server",
+	Version: version,
+}
 
-	default:
-		// Stdio transport (default) - for CLI usage
-		// Start HTTP server for health/metrics in background
-		go func() {
-			mux := http.NewServeMux()
-			mux.HandleFunc("GET /health", handlers.HealthHandler)
-			mux.Handle("GET /metrics", promhttp.Handler())
-			logger.Info("http server starting", "port", cfg.Port)
-			if err := http.ListenAndServe(":"+cfg.Port, middleware.MetricsMiddleware(mux)); err != nil {
-				logger.Error("http server error", "error", err)
-			}
-		}()
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-		logger.Info("mcp server running with stdio transport")
-		if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
-			logger.Error("mcp server error", "error", err)
-			os.Exit(1)
-		}
+// serve implements "mcp-server serve", the default command, running the
+// MCP server over the configured transport.
+func serve(args []string, _ io.Writer) error {
+	cfg := server.ConfigFromEnv()
+	cfg.Name, cfg.Version = implementation.Name, implementation.Version
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.StringVar(&cfg.Transport, "transport", cfg.Transport, "MCP transport: stdio or http")
+	fs.StringVar(&cfg.Port, "port", "", "HTTP port (default $PORT or 8080)")
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
-}
 
-func getEnv(key, defaultValue string) string {
-	if v, ok := os.LookupEnv(key); ok {
-		return v
+	srv, err := server.New(cfg)
+	if err != nil {
+		return err
 	}
-	return defaultValue
+
+	// SIGTERM drains the HTTP listeners within SHUTDOWN_TIMEOUT, so a new
+	// process sharing the port takes over without dropping requests
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	drainOnSignal(ctx, srv)
+	return srv.Run(ctx)
 }
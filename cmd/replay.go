@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/capture"
+	"github.com/lkendrickd/mcp-server/pkg/server"
+)
+
+// replayer sends recorded requests and tallies the results.
+type replayer struct {
+	client   *http.Client
+	endpoint string
+	header   http.Header
+	compare  bool
+	verbose  bool
+	out      io.Writer
+
+	// sessions maps recorded session pseudonyms to live session IDs
+	sessions map[string]string
+
+	sent, failed, statusDiffs, responseDiffs int
+	latencies                                []time.Duration
+}
+
+// replay implements "mcp-server replay", which sends the exchanges recorded
+// with CAPTURE_DIR to a server again and reports responses that differ from
+// the recording, and the latency of each request.
+func replay(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	url := fs.String("url", "", "streamable HTTP endpoint to replay against (default: a server started in-process from the environment)")
+	apiKey := fs.String("api-key", os.Getenv("MCP_API_KEY"), "API key sent as X-API-Key (default $MCP_API_KEY)")
+	repeat := fs.Int("repeat", 1, "number of times to replay the recording")
+	compare := fs.Bool("compare", true, "report responses that differ from the recording")
+	verbose := fs.Bool("v", false, "print each differing response")
+	headers := headerFlags{}
+	fs.Var(headers, "H", "extra HTTP header as \"Name: value\"; may be repeated")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: mcp-server replay [flags] <capture-dir>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected the directory of a recording")
+	}
+	exchanges, err := capture.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(exchanges) == 0 {
+		return fmt.Errorf("no exchanges recorded in %s", fs.Arg(0))
+	}
+
+	endpoint := *url
+	if endpoint == "" {
+		stop, err := replayServer(&endpoint)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	r := &replayer{
+		client:   &http.Client{Timeout: time.Minute},
+		endpoint: endpoint,
+		header:   http.Header(headers),
+		compare:  *compare,
+		verbose:  *verbose,
+		out:      stdout,
+	}
+	if *apiKey != "" {
+		r.header.Set("X-API-Key", *apiKey)
+	}
+	for range *repeat {
+		// Each pass opens its own sessions
+		r.sessions = make(map[string]string)
+		for _, x := range exchanges {
+			if x.Truncated || len(x.Request) == 0 {
+				continue
+			}
+			if err := r.send(x); err != nil {
+				r.failed++
+				_, _ = fmt.Fprintf(r.out, "#%d %s: %v\n", x.Seq, x.Method, err)
+			}
+		}
+	}
+	return r.report()
+}
+
+// replayServer serves a server configured from the environment on a
+// loopback port and points endpoint at it.
+func replayServer(endpoint *string) (func(), error) {
+	cfg := server.ConfigFromEnv()
+	cfg.Name, cfg.Version, cfg.Transport = implementation.Name, implementation.Version, "http"
+	// Replayed traffic must not be recorded over the recording
+	_ = os.Unsetenv("CAPTURE_DIR")
+	// Replayed requests go straight to the local endpoint, not through a proxy
+	_ = os.Unsetenv("BASE_PATH")
+	srv, err := server.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	hs := &http.Server{Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = hs.Serve(ln) }()
+	*endpoint = "http://" + ln.Addr().String() + "/mcp"
+	return func() { _ = hs.Shutdown(context.Background()) }, nil
+}
+
+// send sends the request of x, within the live session that stands in for
+// the recorded one, and compares the response.
+func (r *replayer) send(x capture.Exchange) error {
+	req, err := http.NewRequest(http.MethodPost, r.endpoint, bytes.NewReader(x.Request))
+	if err != nil {
+		return err
+	}
+	for name, values := range r.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if x.ProtocolVersion != "" {
+		req.Header.Set("Mcp-Protocol-Version", x.ProtocolVersion)
+	}
+	if id, ok := r.sessions[x.Session]; ok {
+		req.Header.Set("Mcp-Session-Id", id)
+	}
+
+	start := time.Now()
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	r.sent++
+	r.latencies = append(r.latencies, time.Since(start))
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" && x.Session != "" {
+		r.sessions[x.Session] = id
+	}
+	if !r.compare {
+		return nil
+	}
+
+	if resp.StatusCode != x.Status {
+		r.statusDiffs++
+		_, _ = fmt.Fprintf(r.out, "#%d %s: status %d, recorded %d\n", x.Seq, x.Method, resp.StatusCode, x.Status)
+		return nil
+	}
+	got := capture.Messages(resp.Header.Get("Content-Type"), body)
+	if !sameMessages(got, x.Response) {
+		r.responseDiffs++
+		_, _ = fmt.Fprintf(r.out, "#%d %s: response differs from the recording\n", x.Seq, x.Method)
+		if r.verbose {
+			_, _ = fmt.Fprintf(r.out, "  recorded: %s\n  replayed: %s\n", bytes.Join(rawBytes(x.Response), []byte(" ")), bytes.Join(rawBytes(got), []byte(" ")))
+		}
+	}
+	return nil
+}
+
+// sameMessages compares JSON messages by value, ignoring formatting and
+// member order. Secret fields redacted in the recording match any value.
+func sameMessages(got, want []json.RawMessage) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		var g, w any
+		if json.Unmarshal(got[i], &g) != nil || json.Unmarshal(want[i], &w) != nil || !sameValue(g, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameValue(got, want any) bool {
+	if want == capture.Redacted {
+		return true
+	}
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for k, wv := range w {
+			if gv, ok := g[k]; !ok || !sameValue(gv, wv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !sameValue(g[i], w[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(got, want)
+	}
+}
+
+func rawBytes(msgs []json.RawMessage) [][]byte {
+	out := make([][]byte, len(msgs))
+	for i, m := range msgs {
+		out[i] = m
+	}
+	return out
+}
+
+// report prints the summary and fails the command when responses differ.
+func (r *replayer) report() error {
+	slices.Sort(r.latencies)
+	var total time.Duration
+	for _, d := range r.latencies {
+		total += d
+	}
+	percentile := func(p int) time.Duration {
+		if len(r.latencies) == 0 {
+			return 0
+		}
+		return r.latencies[(len(r.latencies)-1)*p/100]
+	}
+	var mean time.Duration
+	if n := len(r.latencies); n > 0 {
+		mean = total / time.Duration(n)
+	}
+	_, _ = fmt.Fprintf(r.out, "replayed %d requests, %d failed\n", r.sent, r.failed)
+	_, _ = fmt.Fprintf(r.out, "latency mean %v p50 %v p95 %v p99 %v max %v\n", mean, percentile(50), percentile(95), percentile(99), percentile(100))
+	if !r.compare {
+		return nil
+	}
+	_, _ = fmt.Fprintf(r.out, "%d status differences, %d response differences\n", r.statusDiffs, r.responseDiffs)
+	if r.failed > 0 || r.statusDiffs > 0 || r.responseDiffs > 0 {
+		return errors.New("replay differs from the recording")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/capture"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// toolServer serves the built-in tools over streamable HTTP, through wrap.
+func toolServer(wrap func(http.Handler) http.Handler) *httptest.Server {
+	server := mcp.NewServer(implementation, nil)
+	tools.RegisterAll(server)
+	return httptest.NewServer(wrap(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)))
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := capture.New(capture.Config{Dir: dir, MaxBytes: 1 << 20}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := toolServer(recorder.Middleware)
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(context.Background(), &mcp.StreamableClientTransport{Endpoint: recorded.URL}, nil)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if _, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "hash", Arguments: map[string]any{"data": "abc", "algorithm": "sha256"}}); err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	_ = session.Close()
+	recorded.Close()
+
+	target := toolServer(func(h http.Handler) http.Handler { return h })
+	defer target.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"replay", "-url", target.URL, dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("replay exit code = %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+	if out := stdout.String(); !strings.Contains(out, "0 status differences, 0 response differences") {
+		t.Errorf("stdout = %q, want no differences", out)
+	}
+
+	// Tamper with the recorded hash so the replay no longer matches it
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, f := range files {
+		data, _ := os.ReadFile(f)
+		_ = os.WriteFile(f, bytes.ReplaceAll(data, []byte("ba7816bf"), []byte("00000000")), 0o600)
+	}
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"replay", "-url", target.URL, "-v", dir}, &stdout, &stderr); code != 1 {
+		t.Errorf("replay of a changed response exit code = %d, want 1", code)
+	}
+	if out := stdout.String(); !strings.Contains(out, "tools/call: response differs") || !strings.Contains(out, "ba7816bf") {
+		t.Errorf("stdout = %q, want the differing tools/call", out)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !bundle_minimal && !bundle_standard
+
+package main
+
+// The full bundle is the default and adds filesystem, process, database,
+// git, Kubernetes, and Prometheus access to the standard bundle.
+import (
+	"github.com/lkendrickd/mcp-server/internal/tools"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/calculate"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/command"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/convert"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/dataformat"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/dns"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/encoding"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/filesystem"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/git"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/hash"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/httpfetch"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/jobs"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/jsontool"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/jwt"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/k8s"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/memory"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/prometheus"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/random"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/sqlquery"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/template"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/text"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/timeutil"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/uuid"
+)
+
+func init() {
+	tools.Bundle = "full"
+}
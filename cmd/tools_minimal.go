@@ -0,0 +1,21 @@
+//go:build bundle_minimal
+
+package main
+
+// The minimal bundle contains self-contained tools with no network,
+// filesystem, or process access.
+import (
+	"github.com/lkendrickd/mcp-server/internal/tools"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/calculate"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/encoding"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/hash"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/jsontool"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/random"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/text"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/timeutil"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/uuid"
+)
+
+func init() {
+	tools.Bundle = "minimal"
+}
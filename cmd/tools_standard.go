@@ -0,0 +1,29 @@
+//go:build bundle_standard
+
+package main
+
+// The standard bundle adds network clients, data conversion, and the
+// memory store and background jobs to the minimal bundle.
+import (
+	"github.com/lkendrickd/mcp-server/internal/tools"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/calculate"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/convert"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/dataformat"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/dns"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/encoding"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/hash"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/httpfetch"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/jobs"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/jsontool"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/jwt"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/memory"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/random"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/template"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/text"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/timeutil"
+	_ "github.com/lkendrickd/mcp-server/internal/tools/uuid"
+)
+
+func init() {
+	tools.Bundle = "standard"
+}
@@ -0,0 +1,245 @@
+// Package approval holds calls to designated tools until a human approves
+// them out of band. A pending call is announced to an optional webhook,
+// which may decide it in its response, and listed on the admin API, where
+// an operator approves or denies it; calls left undecided fail when the
+// approval timeout passes.
+package approval
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+// ErrNotFound is returned by Decide for a call that is not pending.
+var ErrNotFound = errors.New("no pending call with that ID")
+
+// Config selects the tools that need approval.
+type Config struct {
+	// Tools are path.Match globs of tool names needing approval.
+	Tools []string
+	// Timeout is how long a call waits for a decision.
+	Timeout time.Duration
+	// WebhookURL, when set, receives each pending call.
+	WebhookURL string
+}
+
+// LoadConfig reads the APPROVAL_* settings.
+func LoadConfig() Config {
+	return Config{
+		Tools:      config.GetEnvList("APPROVAL_TOOLS"),
+		Timeout:    config.GetEnvDuration("APPROVAL_TIMEOUT", 5*time.Minute),
+		WebhookURL: config.GetEnv("APPROVAL_WEBHOOK_URL", ""),
+	}
+}
+
+// Call is a tool call awaiting approval.
+type Call struct {
+	ID        string          `json:"id"`
+	Tool      string          `json:"tool"`
+	Caller    string          `json:"caller,omitempty"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Requested time.Time       `json:"requested"`
+	Expires   time.Time       `json:"expires"`
+}
+
+// Decision settles a pending call.
+type Decision struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+	// By names who decided: "webhook", "admin", or "timeout".
+	By string `json:"by,omitempty"`
+}
+
+// Approver queues calls awaiting approval.
+type Approver struct {
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	pending map[string]*pending
+}
+
+type pending struct {
+	call    Call
+	decided chan Decision
+}
+
+// webhookTimeout bounds each webhook request.
+const webhookTimeout = 10 * time.Second
+
+// New returns an Approver for cfg, or nil when no tool needs approval.
+func New(cfg Config, logger *slog.Logger) (*Approver, error) {
+	if len(cfg.Tools) == 0 {
+		return nil, nil
+	}
+	for _, g := range cfg.Tools {
+		if _, err := path.Match(g, ""); err != nil {
+			return nil, fmt.Errorf("APPROVAL_TOOLS: pattern %q: %w", g, err)
+		}
+	}
+	if cfg.Timeout <= 0 {
+		return nil, fmt.Errorf("APPROVAL_TIMEOUT must be positive")
+	}
+	return &Approver{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: webhookTimeout},
+		logger:  logger,
+		pending: make(map[string]*pending),
+	}, nil
+}
+
+// Required reports whether calls to tool need approval.
+func (a *Approver) Required(tool string) bool {
+	for _, g := range a.cfg.Tools {
+		if ok, _ := path.Match(g, tool); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Request queues call and waits for a decision. It returns a timeout
+// denial when none arrives in time, and ctx's error when the caller
+// gives up first.
+func (a *Approver) Request(ctx context.Context, call Call) (Decision, error) {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	call.ID = hex.EncodeToString(id)
+	call.Requested = time.Now().UTC()
+	call.Expires = call.Requested.Add(a.cfg.Timeout)
+
+	p := &pending{call: call, decided: make(chan Decision, 1)}
+	a.mu.Lock()
+	a.pending[call.ID] = p
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.pending, call.ID)
+		a.mu.Unlock()
+	}()
+	a.logger.Info("tool call awaiting approval", "id", call.ID, "tool", call.Tool, "caller", call.Caller)
+
+	if a.cfg.WebhookURL != "" {
+		go a.notify(call)
+	}
+
+	timer := time.NewTimer(a.cfg.Timeout)
+	defer timer.Stop()
+	select {
+	case d := <-p.decided:
+		a.logger.Info("tool call decided", "id", call.ID, "tool", call.Tool, "approved", d.Approved, "by", d.By)
+		return d, nil
+	case <-timer.C:
+		a.logger.Warn("tool call approval timed out", "id", call.ID, "tool", call.Tool)
+		return Decision{Reason: "no decision within " + a.cfg.Timeout.String(), By: "timeout"}, nil
+	case <-ctx.Done():
+		return Decision{}, ctx.Err()
+	}
+}
+
+// Decide settles the pending call id.
+func (a *Approver) Decide(id string, d Decision) error {
+	a.mu.Lock()
+	p, ok := a.pending[id]
+	a.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+	select {
+	case p.decided <- d:
+		return nil
+	default:
+		// Already decided; the first decision stands
+		return ErrNotFound
+	}
+}
+
+// Pending lists the calls awaiting a decision, oldest first.
+func (a *Approver) Pending() []Call {
+	a.mu.Lock()
+	calls := make([]Call, 0, len(a.pending))
+	for _, p := range a.pending {
+		calls = append(calls, p.call)
+	}
+	a.mu.Unlock()
+	slices.SortFunc(calls, func(x, y Call) int { return x.Requested.Compare(y.Requested) })
+	return calls
+}
+
+// notify posts call to the webhook. A 200 response carrying a Decision
+// settles the call; any other success leaves it to the admin API.
+func (a *Approver) notify(call Call) {
+	body, _ := json.Marshal(call)
+	resp, err := a.client.Post(a.cfg.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		a.logger.Warn("approval webhook failed", "id", call.ID, "error", err)
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		var d Decision
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&d); err != nil {
+			a.logger.Warn("approval webhook returned an invalid decision", "id", call.ID, "error", err)
+			return
+		}
+		d.By = "webhook"
+		_ = a.Decide(call.ID, d)
+	case resp.StatusCode >= 300:
+		a.logger.Warn("approval webhook failed", "id", call.ID, "status", resp.StatusCode)
+	}
+}
+
+// ListHandler serves the pending calls.
+func (a *Approver) ListHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{"pending": a.Pending()})
+}
+
+// DecideHandler approves or denies the call named by the {id} path value
+// as {decision} says: "approve" or "deny". An optional JSON body may give
+// a reason.
+func (a *Approver) DecideHandler(w http.ResponseWriter, r *http.Request) {
+	d := Decision{By: "admin"}
+	switch r.PathValue("decision") {
+	case "approve":
+		d.Approved = true
+	case "deny":
+	default:
+		http.Error(w, "decision must be approve or deny", http.StatusNotFound)
+		return
+	}
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	d.Reason = body.Reason
+
+	if err := a.Decide(r.PathValue("id"), d); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(d)
+}
@@ -0,0 +1,250 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "no tools", cfg: Config{Timeout: time.Minute}, wantNil: true},
+		{name: "tools", cfg: Config{Tools: []string{"write_*"}, Timeout: time.Minute}},
+		{name: "bad glob", cfg: Config{Tools: []string{"["}, Timeout: time.Minute}, wantErr: true},
+		{name: "no timeout", cfg: Config{Tools: []string{"exec"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(tt.cfg, discard)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (a == nil) != tt.wantNil {
+				t.Errorf("New = %v, want nil %v", a, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestRequired(t *testing.T) {
+	a, _ := New(Config{Tools: []string{"write_*", "exec"}, Timeout: time.Minute}, discard)
+	for tool, want := range map[string]bool{"write_file": true, "exec": true, "read_file": false} {
+		if got := a.Required(tool); got != want {
+			t.Errorf("Required(%s) = %v, want %v", tool, got, want)
+		}
+	}
+}
+
+// waitPending waits for a call to be queued and returns it.
+func waitPending(t *testing.T, a *Approver) Call {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if calls := a.Pending(); len(calls) > 0 {
+			return calls[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("no call became pending")
+	return Call{}
+}
+
+func TestRequest(t *testing.T) {
+	t.Run("decided", func(t *testing.T) {
+		a, _ := New(Config{Tools: []string{"exec"}, Timeout: time.Minute}, discard)
+		go func() {
+			call := waitPending(t, a)
+			_ = a.Decide(call.ID, Decision{Approved: true, By: "admin"})
+		}()
+		d, err := a.Request(context.Background(), Call{Tool: "exec"})
+		if err != nil || !d.Approved || d.By != "admin" {
+			t.Errorf("Request = %+v, %v; want approved by admin", d, err)
+		}
+		if n := len(a.Pending()); n != 0 {
+			t.Errorf("pending = %d after decision, want 0", n)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		a, _ := New(Config{Tools: []string{"exec"}, Timeout: 20 * time.Millisecond}, discard)
+		d, err := a.Request(context.Background(), Call{Tool: "exec"})
+		if err != nil || d.Approved || d.By != "timeout" {
+			t.Errorf("Request = %+v, %v; want timeout denial", d, err)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		a, _ := New(Config{Tools: []string{"exec"}, Timeout: time.Minute}, discard)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := a.Request(ctx, Call{Tool: "exec"}); !errors.Is(err, context.Canceled) {
+			t.Errorf("Request error = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		a, _ := New(Config{Tools: []string{"exec"}, Timeout: time.Minute}, discard)
+		if err := a.Decide("nope", Decision{}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Decide error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestWebhook(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantApproved bool
+		wantBy       string
+	}{
+		{name: "approves", status: http.StatusOK, body: `{"approved":true}`, wantApproved: true, wantBy: "webhook"},
+		{name: "denies", status: http.StatusOK, body: `{"approved":false,"reason":"not today"}`, wantBy: "webhook"},
+		{name: "defers to admin", status: http.StatusAccepted, wantApproved: true, wantBy: "admin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan Call, 1)
+			hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var call Call
+				_ = json.NewDecoder(r.Body).Decode(&call)
+				received <- call
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer hook.Close()
+
+			a, _ := New(Config{Tools: []string{"exec"}, Timeout: 5 * time.Second, WebhookURL: hook.URL}, discard)
+			if tt.wantBy == "admin" {
+				go func() {
+					call := <-received
+					received <- call
+					_ = a.Decide(call.ID, Decision{Approved: true, By: "admin"})
+				}()
+			}
+			d, err := a.Request(context.Background(), Call{Tool: "exec", Caller: "oauth:alice", Arguments: json.RawMessage(`{"command":"ls"}`)})
+			if err != nil {
+				t.Fatalf("Request: %v", err)
+			}
+			if d.Approved != tt.wantApproved || d.By != tt.wantBy {
+				t.Errorf("Request = %+v, want approved %v by %s", d, tt.wantApproved, tt.wantBy)
+			}
+			if call := <-received; call.Tool != "exec" || call.Caller != "oauth:alice" || string(call.Arguments) != `{"command":"ls"}` {
+				t.Errorf("webhook received %+v", call)
+			}
+		})
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	a, _ := New(Config{Tools: []string{"exec"}, Timeout: time.Minute}, discard)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/approvals", a.ListHandler)
+	mux.HandleFunc("POST /admin/approvals/{id}/{decision}", a.DecideHandler)
+
+	result := make(chan Decision, 1)
+	go func() {
+		d, _ := a.Request(context.Background(), Call{Tool: "exec"})
+		result <- d
+	}()
+	call := waitPending(t, a)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/approvals", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), call.ID) {
+		t.Errorf("GET /admin/approvals = %d %s", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{target: "/admin/approvals/" + call.ID + "/maybe", wantStatus: http.StatusNotFound},
+		{target: "/admin/approvals/unknown/deny", wantStatus: http.StatusNotFound},
+		{target: "/admin/approvals/" + call.ID + "/deny", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(`{"reason":"too risky"}`)))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("POST %s = %d, want %d", tt.target, rec.Code, tt.wantStatus)
+		}
+	}
+	if d := <-result; d.Approved || d.By != "admin" || d.Reason != "too risky" {
+		t.Errorf("decision = %+v, want denied by admin", d)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	a, _ := New(Config{Tools: []string{"exec"}, Timeout: time.Minute}, discard)
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	for _, name := range []string{"exec", "uuid"} {
+		tools.AddTool(server, &mcp.Tool{Name: name}, func(_ context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ran " + name}}}, nil, nil
+		})
+	}
+	server.AddReceivingMiddleware(Middleware(a))
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	tests := []struct {
+		name      string
+		tool      string
+		decide    bool
+		approve   bool
+		wantError bool
+		wantText  string
+	}{
+		{name: "not designated", tool: "uuid", wantText: "ran uuid"},
+		{name: "approved", tool: "exec", decide: true, approve: true, wantText: "ran exec"},
+		{name: "denied", tool: "exec", decide: true, wantError: true, wantText: "permission_denied: exec was not approved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.decide {
+				go func() {
+					call := waitPending(t, a)
+					_ = a.Decide(call.ID, Decision{Approved: tt.approve, By: "admin"})
+				}()
+			}
+			res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: tt.tool, Arguments: map[string]any{}})
+			if err != nil {
+				t.Fatalf("CallTool: %v", err)
+			}
+			if res.IsError != tt.wantError {
+				t.Errorf("IsError = %v, want %v", res.IsError, tt.wantError)
+			}
+			if text := res.Content[0].(*mcp.TextContent).Text; text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package approval
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// Middleware holds each tools/call to a tool that needs approval until
+// it is decided. Denied and timed-out calls fail with a tool error and
+// never reach the tool.
+func Middleware(a *Approver) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != "tools/call" {
+				return next(ctx, method, req)
+			}
+			params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+			if !ok || !a.Required(params.Name) {
+				return next(ctx, method, req)
+			}
+			call := Call{Tool: params.Name, Arguments: params.Arguments}
+			if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+				call.Caller = extra.Header.Get(middleware.HeaderCaller)
+			}
+
+			d, err := a.Request(ctx, call)
+			if err != nil {
+				return tools.ErrorResult(tools.AsError(err)), nil
+			}
+			if d.Approved {
+				return next(ctx, method, req)
+			}
+			e := tools.NewError(tools.CodePermissionDenied, "%s was not approved", params.Name)
+			if d.By == "timeout" {
+				e = tools.NewError(tools.CodeDeadlineExceeded, "%s was not approved in time", params.Name)
+			}
+			e = e.WithDetail("decided_by", d.By)
+			if d.Reason != "" {
+				e = e.WithDetail("reason", d.Reason)
+			}
+			return tools.ErrorResult(e), nil
+		}
+	}
+}
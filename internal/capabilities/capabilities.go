@@ -0,0 +1,108 @@
+// Package capabilities tells handlers what the client of the current
+// session declared at initialize, so that tools use sampling, elicitation,
+// or roots only with clients that support them:
+//
+//	if capabilities.FromContext(ctx).Sampling() {
+//		res, err := req.Session.CreateMessage(ctx, params)
+//		...
+//	}
+package capabilities
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Client is what a client declared at initialize.
+type Client struct {
+	// Name and Version identify the client implementation, if it said.
+	Name    string
+	Version string
+	// ProtocolVersion is the protocol version settled at initialize.
+	ProtocolVersion string
+	// Capabilities are the capabilities as declared.
+	Capabilities *mcp.ClientCapabilities
+}
+
+// Sampling reports whether the client serves sampling/createMessage.
+func (c *Client) Sampling() bool {
+	return c.caps() != nil && c.Capabilities.Sampling != nil
+}
+
+// Elicitation reports whether the client serves elicitation/create with
+// forms. Clients that declare elicitation without naming a mode support
+// forms.
+func (c *Client) Elicitation() bool {
+	if c.caps() == nil || c.Capabilities.Elicitation == nil {
+		return false
+	}
+	e := c.Capabilities.Elicitation
+	return e.Form != nil || e.URL == nil
+}
+
+// ElicitationURL reports whether the client serves elicitation/create
+// with URLs.
+func (c *Client) ElicitationURL() bool {
+	return c.caps() != nil && c.Capabilities.Elicitation != nil && c.Capabilities.Elicitation.URL != nil
+}
+
+// Roots reports whether the client serves roots/list.
+func (c *Client) Roots() bool {
+	return c.caps() != nil && c.Capabilities.RootsV2 != nil
+}
+
+// Experimental reports whether the client declared the non-standard
+// capability name.
+func (c *Client) Experimental(name string) bool {
+	if c.caps() == nil {
+		return false
+	}
+	_, ok := c.Capabilities.Experimental[name]
+	return ok
+}
+
+// caps returns the declared capabilities, or nil if c is nil.
+func (c *Client) caps() *mcp.ClientCapabilities {
+	if c == nil {
+		return nil
+	}
+	return c.Capabilities
+}
+
+type clientKey struct{}
+
+// FromContext returns the client of the request being handled, or nil if
+// its capabilities are unknown: outside Middleware, or in stateless HTTP
+// sessions, where initialize was served by another request. The methods of
+// a nil Client report nothing as supported, so callers that would rather
+// try than skip must check for nil.
+func FromContext(ctx context.Context) *Client {
+	c, _ := ctx.Value(clientKey{}).(*Client)
+	return c
+}
+
+// NewContext returns a copy of ctx carrying c, for tests of handlers.
+func NewContext(ctx context.Context, c *Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// Middleware adds the client of the session to the context of each request
+// after initialize.
+func Middleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		ss, ok := req.GetSession().(*mcp.ServerSession)
+		if !ok || method == "initialize" {
+			return next(ctx, method, req)
+		}
+		params := ss.InitializeParams()
+		if params == nil || params.Capabilities == nil {
+			return next(ctx, method, req)
+		}
+		c := &Client{ProtocolVersion: params.ProtocolVersion, Capabilities: params.Capabilities}
+		if params.ClientInfo != nil {
+			c.Name, c.Version = params.ClientInfo.Name, params.ClientInfo.Version
+		}
+		return next(NewContext(ctx, c), method, req)
+	}
+}
@@ -0,0 +1,91 @@
+package capabilities
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name                                   string
+		client                                 *Client
+		sampling, form, url, roots, experiment bool
+	}{
+		{name: "unknown", client: nil},
+		{name: "nothing declared", client: &Client{Capabilities: &mcp.ClientCapabilities{}}},
+		{name: "no capabilities", client: &Client{}},
+		{
+			name: "everything",
+			client: &Client{Capabilities: &mcp.ClientCapabilities{
+				Sampling:     &mcp.SamplingCapabilities{},
+				Elicitation:  &mcp.ElicitationCapabilities{Form: &mcp.FormElicitationCapabilities{}, URL: &mcp.URLElicitationCapabilities{}},
+				RootsV2:      &mcp.RootCapabilities{},
+				Experimental: map[string]any{"tasks": map[string]any{}},
+			}},
+			sampling: true, form: true, url: true, roots: true, experiment: true,
+		},
+		{name: "elicitation without a mode", client: &Client{Capabilities: &mcp.ClientCapabilities{Elicitation: &mcp.ElicitationCapabilities{}}}, form: true},
+		{name: "url elicitation only", client: &Client{Capabilities: &mcp.ClientCapabilities{Elicitation: &mcp.ElicitationCapabilities{URL: &mcp.URLElicitationCapabilities{}}}}, url: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.client
+			got := []bool{c.Sampling(), c.Elicitation(), c.ElicitationURL(), c.Roots(), c.Experimental("tasks")}
+			want := []bool{tt.sampling, tt.form, tt.url, tt.roots, tt.experiment}
+			for i, name := range []string{"Sampling", "Elicitation", "ElicitationURL", "Roots", "Experimental"} {
+				if got[i] != want[i] {
+					t.Errorf("%s() = %v, want %v", name, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	server.AddReceivingMiddleware(Middleware)
+	var seen *Client
+	mcp.AddTool(server, &mcp.Tool{Name: "probe"}, func(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, struct{}, error) {
+		seen = FromContext(ctx)
+		return &mcp.CallToolResult{}, struct{}{}, nil
+	})
+
+	ctx := context.Background()
+	st, ct := mcp.NewInMemoryTransports()
+	ss, err := server.Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ss.Close() }()
+	client := mcp.NewClient(&mcp.Implementation{Name: "probe-client", Version: "1.2.3"}, &mcp.ClientOptions{
+		CreateMessageHandler: func(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+			return nil, nil
+		},
+	})
+	cs, err := client.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cs.Close() }()
+
+	if _, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "probe", Arguments: map[string]any{}}); err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	if seen == nil {
+		t.Fatal("FromContext() = nil in the tool handler")
+	}
+	if seen.Name != "probe-client" || seen.Version != "1.2.3" || seen.ProtocolVersion == "" {
+		t.Errorf("client = %+v", seen)
+	}
+	if !seen.Sampling() || seen.Elicitation() {
+		t.Errorf("Sampling() = %v, Elicitation() = %v; want true, false", seen.Sampling(), seen.Elicitation())
+	}
+}
+
+func TestFromContextWithout(t *testing.T) {
+	if c := FromContext(context.Background()); c != nil || c.Sampling() {
+		t.Errorf("FromContext() = %+v, want nil", c)
+	}
+}
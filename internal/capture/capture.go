@@ -0,0 +1,408 @@
+// Package capture records MCP request/response pairs to disk for replay in
+// regression tests and benchmarks. Recordings are anonymized: only
+// protocol headers are kept, session IDs are replaced by pseudonyms, and
+// the values of fields that look secret are redacted. The directory is a
+// ring buffer: once it exceeds its size cap the oldest exchanges are
+// deleted.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+// maxBody is the most of a request or response body recorded. Larger
+// exchanges are recorded without their bodies.
+const maxBody = 1 << 20
+
+// Redacted replaces the values of secret fields.
+const Redacted = "[REDACTED]"
+
+// secretFields mark a field as secret when its name, lowercased and with
+// '-' and '_' removed, contains one of them.
+var secretFields = []string{"password", "passwd", "secret", "token", "key", "authorization", "credential", "cookie", "session"}
+
+// Config configures recording.
+type Config struct {
+	// Dir receives the recordings; empty disables recording.
+	Dir string
+	// MaxBytes caps the total size of Dir.
+	MaxBytes int64
+	// RedactFields are further field names whose values are redacted.
+	RedactFields []string
+}
+
+// LoadConfig reads the CAPTURE_* settings.
+func LoadConfig() Config {
+	return Config{
+		Dir:          config.GetEnv("CAPTURE_DIR", ""),
+		MaxBytes:     int64(config.GetEnvInt("CAPTURE_MAX_BYTES", 100<<20)),
+		RedactFields: config.GetEnvList("CAPTURE_REDACT_FIELDS"),
+	}
+}
+
+// Exchange is one recorded request and its response.
+type Exchange struct {
+	Seq  int64     `json:"seq"`
+	Time time.Time `json:"time"`
+	// Session is a pseudonym of the MCP session, shared by the exchanges
+	// of one session; empty before the session is established.
+	Session         string `json:"session,omitempty"`
+	ProtocolVersion string `json:"protocol_version,omitempty"`
+	Method          string `json:"method,omitempty"`
+	// Request is the JSON-RPC message or batch sent.
+	Request json.RawMessage `json:"request,omitempty"`
+	Status  int             `json:"status"`
+	// Response holds the JSON-RPC messages received, whether the server
+	// answered with JSON or an event stream.
+	Response   []json.RawMessage `json:"response,omitempty"`
+	DurationMS int64             `json:"duration_ms"`
+	// Truncated reports that a body exceeded the recording limit.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type file struct {
+	name string
+	size int64
+}
+
+// Recorder writes exchanges to a directory.
+type Recorder struct {
+	cfg    Config
+	logger *slog.Logger
+	redact []string
+
+	mu    sync.Mutex
+	seq   int64
+	files []file
+	total int64
+}
+
+// New returns a Recorder for cfg, resuming the ring buffer already in
+// cfg.Dir, or nil when recording is disabled.
+func New(cfg Config, logger *slog.Logger) (*Recorder, error) {
+	if cfg.Dir == "" {
+		return nil, nil
+	}
+	if cfg.MaxBytes <= 0 {
+		return nil, fmt.Errorf("CAPTURE_MAX_BYTES must be positive")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("capture: %w", err)
+	}
+	r := &Recorder{cfg: cfg, logger: logger, redact: slices.Clone(secretFields)}
+	for _, f := range cfg.RedactFields {
+		r.redact = append(r.redact, normalizeField(f))
+	}
+	entries, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("capture: %w", err)
+	}
+	for _, e := range entries {
+		seq, ok := seqOf(e.Name())
+		if !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		r.files = append(r.files, file{name: e.Name(), size: info.Size()})
+		r.total += info.Size()
+		r.seq = max(r.seq, seq)
+	}
+	return r, nil
+}
+
+// fileName names the recording of exchange seq, so that names sort in
+// recording order.
+func fileName(seq int64) string {
+	return fmt.Sprintf("%012d.json", seq)
+}
+
+// seqOf parses a name made by fileName.
+func seqOf(name string) (int64, bool) {
+	digits, ok := strings.CutSuffix(name, ".json")
+	if !ok || len(digits) != 12 {
+		return 0, false
+	}
+	seq, err := strconv.ParseInt(digits, 10, 64)
+	return seq, err == nil
+}
+
+// Middleware records the POST requests next serves, with their responses.
+// Recording adds a disk write to each request, so it is meant for
+// capturing traffic to replay, not for continuous use.
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			next.ServeHTTP(w, req)
+			return
+		}
+		start := time.Now()
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxBody+1))
+		if err != nil {
+			http.Error(w, "reading request body", http.StatusBadRequest)
+			return
+		}
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, req)
+
+		x := Exchange{
+			Time:            start.UTC(),
+			Session:         pseudonym(cmp.Or(req.Header.Get("Mcp-Session-Id"), w.Header().Get("Mcp-Session-Id"))),
+			ProtocolVersion: req.Header.Get("Mcp-Protocol-Version"),
+			Status:          rec.status,
+			DurationMS:      time.Since(start).Milliseconds(),
+		}
+		if len(body) > maxBody || rec.truncated {
+			x.Truncated = true
+		} else {
+			x.Request = r.anonymize(body)
+			x.Method = method(x.Request)
+			for _, msg := range Messages(w.Header().Get("Content-Type"), rec.body.Bytes()) {
+				x.Response = append(x.Response, r.anonymize(msg))
+			}
+		}
+		if err := r.write(x); err != nil {
+			r.logger.Warn("capture failed", "dir", r.cfg.Dir, "error", err)
+		}
+	})
+}
+
+// write stores x as the newest exchange and deletes the oldest ones beyond
+// the size cap.
+func (r *Recorder) write(x Exchange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	x.Seq = r.seq
+	data, err := json.Marshal(x)
+	if err != nil {
+		return err
+	}
+	name := fileName(x.Seq)
+	if err := os.WriteFile(filepath.Join(r.cfg.Dir, name), data, 0o600); err != nil {
+		return err
+	}
+	r.files = append(r.files, file{name: name, size: int64(len(data))})
+	r.total += int64(len(data))
+	for r.total > r.cfg.MaxBytes && len(r.files) > 1 {
+		oldest := r.files[0]
+		if err := os.Remove(filepath.Join(r.cfg.Dir, oldest.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		r.files = r.files[1:]
+		r.total -= oldest.size
+	}
+	return nil
+}
+
+// anonymize redacts the secret fields of a JSON message. Bodies that are
+// not JSON are recorded as a JSON string.
+func (r *Recorder) anonymize(msg []byte) json.RawMessage {
+	// Numbers are kept as written rather than rounded through float64
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		data, _ := json.Marshal(string(msg))
+		return data
+	}
+	data, err := json.Marshal(r.redactValue(v))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func (r *Recorder) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if r.secret(k) {
+				v[k] = Redacted
+				continue
+			}
+			v[k] = r.redactValue(val)
+		}
+		// The SDK repeats structured tool output as JSON in text content.
+		if text, ok := v["text"].(string); ok && v["type"] == "text" {
+			v["text"] = r.redactText(text)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = r.redactValue(val)
+		}
+	}
+	return v
+}
+
+// redactText redacts text holding a JSON object or array, and returns any
+// other text unchanged.
+func (r *Recorder) redactText(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return text
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return text
+	}
+	data, err := json.Marshal(r.redactValue(v))
+	if err != nil {
+		return text
+	}
+	return string(data)
+}
+
+func (r *Recorder) secret(field string) bool {
+	field = normalizeField(field)
+	return slices.ContainsFunc(r.redact, func(s string) bool { return strings.Contains(field, s) })
+}
+
+// normalizeField lowercases a field name and drops '-' and '_', so that
+// X-API-Key, api_key and apiKey compare equal.
+func normalizeField(field string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(field))
+}
+
+// pseudonym replaces a session ID with a stable name that does not reveal
+// it.
+func pseudonym(id string) string {
+	if id == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
+
+// method returns the JSON-RPC method of a request, or "batch".
+func method(msg json.RawMessage) string {
+	if bytes.HasPrefix(bytes.TrimSpace(msg), []byte("[")) {
+		return "batch"
+	}
+	var m struct {
+		Method string `json:"method"`
+	}
+	_ = json.Unmarshal(msg, &m)
+	return m.Method
+}
+
+// Messages returns the JSON-RPC messages of a response body, which is
+// either JSON or, for contentType text/event-stream, the data of each
+// event.
+func Messages(contentType string, body []byte) []json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if !strings.HasPrefix(contentType, "text/event-stream") {
+		return []json.RawMessage{json.RawMessage(bytes.TrimSpace(body))}
+	}
+	var msgs []json.RawMessage
+	var data []string
+	flush := func() {
+		if len(data) > 0 {
+			msgs = append(msgs, json.RawMessage(strings.Join(data, "\n")))
+			data = nil
+		}
+	}
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	sc.Buffer(make([]byte, 0, 64<<10), maxBody)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "data:"); ok {
+			data = append(data, strings.TrimPrefix(rest, " "))
+		}
+	}
+	flush()
+	return msgs
+}
+
+// Load reads the exchanges recorded in dir, oldest first.
+func Load(dir string) ([]Exchange, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var list []Exchange
+	for _, e := range entries {
+		if _, ok := seqOf(e.Name()); !ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var x Exchange
+		if err := json.Unmarshal(data, &x); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		list = append(list, x)
+	}
+	return list, nil
+}
+
+// responseRecorder keeps a copy of the response for recording while
+// passing it through.
+type responseRecorder struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if r.body.Len()+len(b) > maxBody {
+		r.truncated = true
+	} else {
+		r.body.Write(b)
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush passes flushes through, so event streams are not held back.
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,191 @@
+package capture
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestNew(t *testing.T) {
+	if r, err := New(Config{}, discard); r != nil || err != nil {
+		t.Errorf("New(no dir) = %v, %v, want nil, nil", r, err)
+	}
+	if _, err := New(Config{Dir: t.TempDir()}, discard); err == nil {
+		t.Error("New without MaxBytes succeeded")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	dir := t.TempDir()
+	r, err := New(Config{Dir: dir, MaxBytes: 1 << 20, RedactFields: []string{"ssn"}}, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if req.Method == http.MethodPost && !strings.Contains(string(body), "hunter2") {
+			t.Errorf("handler got %s, want the original body", body)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Mcp-Session-Id", "live-session")
+		_, _ = io.WriteString(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{\"token\":\"abc\",\"n\":12345678901234567}}\n\n")
+	}))
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"login","arguments":{"user":"ann","password":"hunter2","SSN":"123"}}}`
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	req.Header.Set("X-API-Key", "client-key")
+	req.Header.Set("Mcp-Protocol-Version", "2025-06-18")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/mcp", nil))
+
+	list, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("recorded %d exchanges, want 1 (GET is not recorded)", len(list))
+	}
+	x := list[0]
+	if x.Seq != 1 || x.Method != "tools/call" || x.Status != http.StatusOK || x.ProtocolVersion != "2025-06-18" {
+		t.Errorf("exchange = %+v", x)
+	}
+	if x.Session == "" || strings.Contains(x.Session, "live") {
+		t.Errorf("session = %q, want a pseudonym", x.Session)
+	}
+	raw, _ := os.ReadFile(dir + "/" + fileName(1))
+	for _, secret := range []string{"hunter2", "client-key", "abc", `"123"`, "live-session"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("recording contains %s: %s", secret, raw)
+		}
+	}
+	if !strings.Contains(string(raw), "ann") || !strings.Contains(string(raw), "12345678901234567") {
+		t.Errorf("recording lost non-secret values: %s", raw)
+	}
+	if len(x.Response) != 1 || !json.Valid(x.Response[0]) {
+		t.Errorf("response = %s, want one JSON message", x.Response)
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	r, err := New(Config{Dir: t.TempDir(), MaxBytes: 1 << 20}, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		msg    string
+		secret string
+		keep   string
+	}{
+		{
+			name:   "api key header",
+			msg:    `{"method":"tools/call","params":{"name":"http_fetch","arguments":{"url":"https://example.com","headers":{"X-API-Key":"k-123"}}}}`,
+			secret: "k-123",
+			keep:   "https://example.com",
+		},
+		{
+			name:   "cookie header",
+			msg:    `{"params":{"arguments":{"headers":{"Cookie":"sid=s-456"}}}}`,
+			secret: "s-456",
+		},
+		{
+			name:   "hmac key",
+			msg:    `{"method":"tools/call","params":{"name":"hash","arguments":{"text":"hello","algorithm":"sha256","hmac_key":"hk-789"}}}`,
+			secret: "hk-789",
+			keep:   "hello",
+		},
+		{
+			name:   "structured output in text content",
+			msg:    `{"result":{"content":[{"type":"text","text":"{\"session_token\":\"t-000\",\"n\":1}"}]}}`,
+			secret: "t-000",
+			keep:   `\"n\":1`,
+		},
+		{
+			name: "plain text content",
+			msg:  `{"result":{"content":[{"type":"text","text":"{not json"}]}}`,
+			keep: "{not json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(r.anonymize([]byte(tt.msg)))
+			if tt.secret != "" && strings.Contains(got, tt.secret) {
+				t.Errorf("anonymize kept %s: %s", tt.secret, got)
+			}
+			if !strings.Contains(got, tt.keep) {
+				t.Errorf("anonymize lost %s: %s", tt.keep, got)
+			}
+		})
+	}
+}
+
+func TestRingBuffer(t *testing.T) {
+	dir := t.TempDir()
+	r, err := New(Config{Dir: dir, MaxBytes: 400}, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		if err := r.write(Exchange{Method: "ping", Request: json.RawMessage(`{"method":"ping"}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, _ := Load(dir)
+	if len(list) == 0 || len(list) >= 10 || list[len(list)-1].Seq != 10 {
+		t.Fatalf("kept %d exchanges ending at %d, want the newest within the cap", len(list), list[len(list)-1].Seq)
+	}
+	var size int64
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		info, _ := e.Info()
+		size += info.Size()
+	}
+	if size > 400 {
+		t.Errorf("directory holds %d bytes, want at most 400", size)
+	}
+
+	// A new recorder continues the sequence and the cap
+	r, err = New(Config{Dir: dir, MaxBytes: 400}, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = r.write(Exchange{Method: "ping"})
+	list, _ = Load(dir)
+	if last := list[len(list)-1].Seq; last != 11 {
+		t.Errorf("last seq = %d, want 11", last)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []string
+	}{
+		{name: "json", contentType: "application/json", body: `{"id":1}` + "\n", want: []string{`{"id":1}`}},
+		{name: "empty", contentType: "application/json", body: "", want: nil},
+		{name: "event stream", contentType: "text/event-stream", body: "event: message\nid: 1\ndata: {\"id\":1}\n\ndata: {\"id\":2}\n\n", want: []string{`{"id":1}`, `{"id":2}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Messages(tt.contentType, []byte(tt.body))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Messages = %s, want %v", got, tt.want)
+			}
+			for i := range got {
+				if string(got[i]) != tt.want[i] {
+					t.Errorf("message %d = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// loadAPIKeys reads API_KEYS, whose raw keys are hashed immediately, and
+// API_KEYS_HASHED, which holds hex SHA-256 digests so raw keys never need
+// to appear in the environment. Both lists are comma-separated, or one per
+// line in a secret file. Malformed digests are recorded for Validate.
+func loadAPIKeys() map[[sha256.Size]byte]struct{} {
+	keys := make(map[[sha256.Size]byte]struct{})
+	for _, key := range splitKeys(GetEnv("API_KEYS", "")) {
+		keys[sha256.Sum256([]byte(key))] = struct{}{}
+	}
+
+	hashed := GetEnv("API_KEYS_HASHED", "")
+	for _, digest := range splitKeys(hashed) {
+		h, err := parseKeyHash(digest)
+		if err != nil {
+			addProblem("API_KEYS_HASHED", hashed, fmt.Errorf("API_KEYS_HASHED entry %q: %w", digest, err))
+			continue
+		}
+		keys[h] = struct{}{}
+	}
+	return keys
+}
+
+// splitKeys splits a key list on commas and newlines, dropping blanks.
+func splitKeys(s string) []string {
+	var keys []string
+	for _, key := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		if trimmed := strings.TrimSpace(key); trimmed != "" {
+			keys = append(keys, trimmed)
+		}
+	}
+	return keys
+}
+
+// parseKeyHash decodes a hex SHA-256 digest, optionally prefixed with
+// "sha256:" as printed by HashAPIKey.
+func parseKeyHash(s string) ([sha256.Size]byte, error) {
+	var h [sha256.Size]byte
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(s), "sha256:"))
+	if err != nil || len(b) != sha256.Size {
+		return h, fmt.Errorf("must be a hex SHA-256 digest (64 characters)")
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// HashAPIKey returns the API_KEYS_HASHED form of key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPIKeysHashed(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		hashed  string
+		testKey string
+		want    bool
+		count   int
+	}{
+		{
+			name:    "hashed key",
+			hashed:  HashAPIKey("secret"),
+			testKey: "secret",
+			want:    true,
+			count:   1,
+		},
+		{
+			name:    "bare hex digest",
+			hashed:  strings.TrimPrefix(HashAPIKey("secret"), "sha256:"),
+			testKey: "secret",
+			want:    true,
+			count:   1,
+		},
+		{
+			name:    "digest is not itself a key",
+			hashed:  HashAPIKey("secret"),
+			testKey: HashAPIKey("secret"),
+			want:    false,
+			count:   1,
+		},
+		{
+			name:    "combined with raw keys",
+			keys:    "raw",
+			hashed:  HashAPIKey("other") + "\n" + HashAPIKey("third"),
+			testKey: "raw",
+			want:    true,
+			count:   3,
+		},
+		{
+			name:    "duplicate of raw key",
+			keys:    "secret",
+			hashed:  HashAPIKey("secret"),
+			testKey: "secret",
+			want:    true,
+			count:   1,
+		},
+		{
+			name:    "malformed digest skipped",
+			hashed:  "not-a-digest," + HashAPIKey("secret"),
+			testKey: "secret",
+			want:    true,
+			count:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("API_KEYS", tt.keys)
+			t.Setenv("API_KEYS_HASHED", tt.hashed)
+
+			cfg := New()
+			if got := cfg.ValidateAPIKey(tt.testKey); got != tt.want {
+				t.Errorf("ValidateAPIKey(%q) = %v, want %v", tt.testKey, got, tt.want)
+			}
+			if got := cfg.APIKeyCount(); got != tt.count {
+				t.Errorf("APIKeyCount() = %d, want %d", got, tt.count)
+			}
+		})
+	}
+}
+
+func TestAPIKeysHashedInvalid(t *testing.T) {
+	clearEnv(t)
+	t.Cleanup(func() {
+		problemsMu.Lock()
+		defer problemsMu.Unlock()
+		delete(problems, "API_KEYS_HASHED")
+	})
+	t.Setenv("API_KEYS_HASHED", "sha256:abc")
+
+	New()
+	err := Validate()
+	if err == nil || !strings.Contains(err.Error(), "API_KEYS_HASHED") {
+		t.Fatalf("Validate() = %v, want API_KEYS_HASHED error", err)
+	}
+}
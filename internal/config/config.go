@@ -1,9 +1,15 @@
 package config
 
 import (
-	"os"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Config holds the application configuration loaded from environment variables
@@ -11,41 +17,40 @@ type Config struct {
 	Port        string
 	LogLevel    string
 	AuthEnabled bool
-	apiKeys     map[string]struct{}
+	apiKeys     map[[sha256.Size]byte]struct{} // SHA-256 hashes; raw keys are not kept
+	keysVersion string                         // fileVersion of the key file when keys were loaded
 	mu          sync.RWMutex
 }
 
 // New creates a new Config from environment variables
 func New() *Config {
 	cfg := &Config{
-		Port:        getEnv("PORT", "8080"),
-		LogLevel:    getEnv("LOG_LEVEL", "info"),
-		AuthEnabled: getEnvBool("AUTH_ENABLED", false),
-		apiKeys:     make(map[string]struct{}),
-	}
-
-	// Parse API keys from comma-separated list
-	keysStr := getEnv("API_KEYS", "")
-	if keysStr != "" {
-		keys := strings.Split(keysStr, ",")
-		for _, key := range keys {
-			trimmed := strings.TrimSpace(key)
-			if trimmed != "" {
-				cfg.apiKeys[trimmed] = struct{}{}
-			}
-		}
+		Port:        GetEnv("PORT", "8080"),
+		LogLevel:    GetEnv("LOG_LEVEL", "info"),
+		AuthEnabled: GetEnvBool("AUTH_ENABLED", false),
+		apiKeys:     loadAPIKeys(),
+		keysVersion: apiKeysVersion(),
 	}
 
 	return cfg
 }
 
-// ValidateAPIKey checks if the provided key is valid using constant-time comparison
+// ValidateAPIKey checks if the provided key is valid by hashing it and
+// comparing against every configured hash in constant time
 func (c *Config) ValidateAPIKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(key))
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	_, exists := c.apiKeys[key]
-	return exists
+	valid := 0
+	for h := range c.apiKeys {
+		valid |= subtle.ConstantTimeCompare(sum[:], h[:])
+	}
+	return valid == 1
 }
 
 // APIKeyCount returns the number of configured API keys
@@ -61,27 +66,141 @@ func (c *Config) HasAPIKeys() bool {
 	return c.APIKeyCount() > 0
 }
 
-// getEnv retrieves an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
+// GetEnv retrieves an environment variable or returns a default value.
+// Every GetEnv* helper also reads key_FILE and SECRETS_DIR; see lookupEnv.
+func GetEnv(key, defaultValue string) string {
+	if value, source, exists := lookupEnv(key); exists {
+		record(key, value, defaultValue, source)
 		return value
 	}
+	record(key, defaultValue, defaultValue, "default")
 	return defaultValue
 }
 
-// getEnvBool retrieves an environment variable as a boolean
-func getEnvBool(key string, defaultValue bool) bool {
-	value, exists := os.LookupEnv(key)
+// GetEnvBool retrieves an environment variable as a boolean
+func GetEnvBool(key string, defaultValue bool) bool {
+	def := strconv.FormatBool(defaultValue)
+	value, source, exists := lookupEnv(key)
 	if !exists {
+		record(key, def, def, "default")
 		return defaultValue
 	}
 
 	switch strings.ToLower(value) {
 	case "true", "1", "yes", "on":
+		record(key, "true", def, source)
 		return true
 	case "false", "0", "no", "off":
+		record(key, "false", def, source)
 		return false
 	default:
+		invalid(key, value, "boolean (true/false, 1/0, yes/no, on/off)")
+		record(key, def, def, "invalid")
+		return defaultValue
+	}
+}
+
+// GetEnvInt retrieves an environment variable as an integer
+func GetEnvInt(key string, defaultValue int) int {
+	def := strconv.Itoa(defaultValue)
+	value, source, exists := lookupEnv(key)
+	if !exists {
+		record(key, def, def, "default")
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		invalid(key, value, "integer")
+		record(key, def, def, "invalid")
+		return defaultValue
+	}
+	record(key, strconv.Itoa(n), def, source)
+	return n
+}
+
+// GetEnvDuration retrieves an environment variable as a time.Duration (e.g. "30s")
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	def := defaultValue.String()
+	value, source, exists := lookupEnv(key)
+	if !exists {
+		record(key, def, def, "default")
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		invalid(key, value, "duration (e.g. 30s, 5m, 1h)")
+		record(key, def, def, "invalid")
 		return defaultValue
 	}
+	record(key, d.String(), def, source)
+	return d
+}
+
+// GetEnvList retrieves a comma-separated environment variable as a slice,
+// trimming whitespace and dropping empty entries
+func GetEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(GetEnv(key, ""), ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			list = append(list, trimmed)
+		}
+	}
+	return list
+}
+
+// problem is an environment value that failed to parse.
+type problem struct {
+	value string
+	err   error
+}
+
+var (
+	problemsMu sync.Mutex
+	problems   = make(map[string]problem)
+)
+
+// invalid records that key held a value that could not be parsed. Empty
+// values are treated as unset.
+func invalid(key, value, want string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	addProblem(key, value, fmt.Errorf("%s=%q is not a valid %s", key, value, want))
+}
+
+// addProblem records err for key, which currently holds value.
+func addProblem(key, value string, err error) {
+	problemsMu.Lock()
+	defer problemsMu.Unlock()
+	problems[key] = problem{value: value, err: err}
+}
+
+// Validate returns an error describing every invalid value read through the
+// GetEnv* helpers so far, or nil. Call it after all configuration has been
+// loaded. Values that have since been changed in the environment or
+// secret files are not reported.
+func Validate() error {
+	problemsMu.Lock()
+	defer problemsMu.Unlock()
+
+	keys := make([]string, 0, len(problems))
+	for k, p := range problems {
+		if value, source, _ := resolveEnv(k); source != "" && value == p.value {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	errs := make([]error, 0, len(keys))
+	for _, k := range keys {
+		errs = append(errs, problems[k].err)
+	}
+	return errors.Join(errs...)
+}
+
+// Strict reports whether CONFIG_STRICT is set, in which case invalid values
+// must fail startup instead of falling back to defaults.
+func Strict() bool {
+	return GetEnvBool("CONFIG_STRICT", false)
 }
@@ -2,7 +2,10 @@ package config
 
 import (
 	"os"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -242,8 +245,8 @@ func TestGetEnvBool(t *testing.T) {
 			clearEnv(t)
 			t.Setenv("TEST_BOOL", tt.value)
 
-			if got := getEnvBool("TEST_BOOL", tt.defaultValue); got != tt.want {
-				t.Errorf("getEnvBool() = %v, want %v", got, tt.want)
+			if got := GetEnvBool("TEST_BOOL", tt.defaultValue); got != tt.want {
+				t.Errorf("GetEnvBool() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -252,20 +255,166 @@ func TestGetEnvBool(t *testing.T) {
 func TestGetEnvBool_NotSet(t *testing.T) {
 	clearEnv(t)
 
-	if got := getEnvBool("NOT_SET", true); got != true {
-		t.Errorf("getEnvBool() = %v, want true (default)", got)
+	if got := GetEnvBool("NOT_SET", true); got != true {
+		t.Errorf("GetEnvBool() = %v, want true (default)", got)
 	}
 
-	if got := getEnvBool("NOT_SET", false); got != false {
-		t.Errorf("getEnvBool() = %v, want false (default)", got)
+	if got := GetEnvBool("NOT_SET", false); got != false {
+		t.Errorf("GetEnvBool() = %v, want false (default)", got)
+	}
+}
+
+func TestGetEnvInt(t *testing.T) {
+	tests := []struct {
+		name         string
+		value        string
+		set          bool
+		defaultValue int
+		want         int
+	}{
+		{name: "not set", defaultValue: 5, want: 5},
+		{name: "valid", value: "42", set: true, defaultValue: 5, want: 42},
+		{name: "negative", value: "-3", set: true, defaultValue: 5, want: -3},
+		{name: "whitespace", value: " 7 ", set: true, defaultValue: 5, want: 7},
+		{name: "invalid returns default", value: "abc", set: true, defaultValue: 5, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			if tt.set {
+				t.Setenv("TEST_INT", tt.value)
+			}
+
+			if got := GetEnvInt("TEST_INT", tt.defaultValue); got != tt.want {
+				t.Errorf("GetEnvInt() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvDuration(t *testing.T) {
+	tests := []struct {
+		name         string
+		value        string
+		set          bool
+		defaultValue time.Duration
+		want         time.Duration
+	}{
+		{name: "not set", defaultValue: time.Second, want: time.Second},
+		{name: "valid", value: "1m30s", set: true, defaultValue: time.Second, want: 90 * time.Second},
+		{name: "invalid returns default", value: "soon", set: true, defaultValue: time.Second, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			if tt.set {
+				t.Setenv("TEST_DURATION", tt.value)
+			}
+
+			if got := GetEnvDuration("TEST_DURATION", tt.defaultValue); got != tt.want {
+				t.Errorf("GetEnvDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvList(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "empty", value: "", want: nil},
+		{name: "single", value: "a", want: []string{"a"}},
+		{name: "trims and drops empties", value: " a, ,b ,, c", want: []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("TEST_LIST", tt.value)
+
+			if got := GetEnvList("TEST_LIST"); !slices.Equal(got, tt.want) {
+				t.Errorf("GetEnvList() = %q, want %q", got, tt.want)
+			}
+		})
 	}
 }
 
 // clearEnv unsets relevant environment variables for clean test state
 func clearEnv(t *testing.T) {
 	t.Helper()
-	vars := []string{"PORT", "LOG_LEVEL", "AUTH_ENABLED", "API_KEYS", "TEST_BOOL"}
+	vars := []string{"PORT", "LOG_LEVEL", "AUTH_ENABLED", "API_KEYS", "API_KEYS_FILE", "API_KEYS_HASHED", "SECRETS_DIR", "TEST_BOOL", "TEST_INT", "TEST_DURATION", "TEST_LIST", "TEST_TOKEN", "TEST_TOKEN_FILE"}
 	for _, v := range vars {
 		os.Unsetenv(v)
 	}
 }
+
+func TestValidate(t *testing.T) {
+	resetProblems := func() {
+		problemsMu.Lock()
+		defer problemsMu.Unlock()
+		problems = make(map[string]problem)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr []string
+	}{
+		{
+			name: "valid values",
+			env:  map[string]string{"TEST_BOOL": "yes", "TEST_INT": "3", "TEST_DURATION": "5s"},
+		},
+		{
+			name: "empty values are unset",
+			env:  map[string]string{"TEST_BOOL": "", "TEST_INT": " ", "TEST_DURATION": ""},
+		},
+		{
+			name: "invalid values",
+			env:  map[string]string{"TEST_BOOL": "maybe", "TEST_INT": "12abc", "TEST_DURATION": "5"},
+			wantErr: []string{
+				`TEST_BOOL="maybe" is not a valid boolean`,
+				`TEST_DURATION="5" is not a valid duration`,
+				`TEST_INT="12abc" is not a valid integer`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			resetProblems()
+			t.Cleanup(resetProblems)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			GetEnvBool("TEST_BOOL", false)
+			GetEnvInt("TEST_INT", 1)
+			GetEnvDuration("TEST_DURATION", time.Second)
+
+			err := Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() error = nil, want %v", tt.wantErr)
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.wantErr) {
+				t.Fatalf("Validate() reported %d problems, want %d: %v", len(lines), len(tt.wantErr), err)
+			}
+			for i, want := range tt.wantErr {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("problem %d = %q, want prefix %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
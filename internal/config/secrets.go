@@ -0,0 +1,156 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lookupEnv resolves key from, in order, the environment variable itself,
+// the file named by key_FILE, and a file called key in SECRETS_DIR. The
+// latter two follow the Docker and Kubernetes secrets conventions, and
+// finally the values set by SetProviderValues. File contents are trimmed
+// of surrounding whitespace. source is "env", "file", or "provider". An
+// unreadable key_FILE is recorded as a problem for Validate.
+func lookupEnv(key string) (value, source string, ok bool) {
+	value, source, err := resolveEnv(key)
+	if err != nil {
+		addProblem(key+"_FILE", os.Getenv(key+"_FILE"), err)
+	}
+	return value, source, source != ""
+}
+
+// resolveEnv implements lookupEnv without recording problems. source is
+// empty when key is not set.
+func resolveEnv(key string) (value, source string, err error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, "env", nil
+	}
+	if path := secretPath(key); path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return strings.TrimSpace(string(data)), "file", nil
+		}
+		if _, set := os.LookupEnv(key + "_FILE"); set {
+			return "", "", fmt.Errorf("%s_FILE=%q cannot be read: %w", key, path, err)
+		}
+	}
+
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	if value, ok := providerValues[key]; ok {
+		return value, "provider", nil
+	}
+	return "", "", nil
+}
+
+var (
+	providerMu     sync.RWMutex
+	providerValues map[string]string
+)
+
+// SetProviderValues replaces the settings supplied by a secrets provider.
+// They are used for keys that are set neither in the environment nor in a
+// secret file.
+func SetProviderValues(values map[string]string) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	providerValues = values
+}
+
+// secretPath returns the file key would be read from when the variable
+// itself is unset, or "" if there is none.
+func secretPath(key string) string {
+	if path, ok := os.LookupEnv(key + "_FILE"); ok && path != "" {
+		return path
+	}
+	if dir := os.Getenv("SECRETS_DIR"); dir != "" {
+		path := filepath.Join(dir, key)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// ReloadAPIKeys re-reads API_KEYS and API_KEYS_HASHED from every source
+// lookupEnv consults, replaces the accepted key set, and returns the new
+// key count.
+func (c *Config) ReloadAPIKeys() int {
+	version := apiKeysVersion()
+	keys := loadAPIKeys()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiKeys = keys
+	c.keysVersion = version
+	return len(keys)
+}
+
+// apiKeysVersion combines the fileVersion of each file API keys are read
+// from; it is "" when they all come from the environment.
+func apiKeysVersion() string {
+	var versions []string
+	for _, path := range apiKeyFiles() {
+		versions = append(versions, fileVersion(path))
+	}
+	return strings.Join(versions, ";")
+}
+
+// apiKeyFiles returns the secret files API keys are read from.
+func apiKeyFiles() []string {
+	var paths []string
+	for _, key := range []string{"API_KEYS", "API_KEYS_HASHED"} {
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if path := secretPath(key); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// WatchAPIKeys polls the files API keys are loaded from every interval and
+// reloads the keys when a modification time or size changes, calling
+// onReload with the new count. It returns when ctx is done, and
+// immediately if the keys do not come from files.
+func (c *Config) WatchAPIKeys(ctx context.Context, interval time.Duration, onReload func(count int)) {
+	if len(apiKeyFiles()) == 0 || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.mu.RLock()
+			loaded := c.keysVersion
+			c.mu.RUnlock()
+			if apiKeysVersion() != loaded {
+				n := c.ReloadAPIKeys()
+				if onReload != nil {
+					onReload(n)
+				}
+			}
+		}
+	}
+}
+
+// fileVersion identifies the current contents of path by modification
+// time and size. Stat follows symlinks, so the atomic symlink swap used by
+// Kubernetes secret volumes is detected.
+func fileVersion(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetEnvFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tokenFile := write("token", "s3cret\n")
+	write("TEST_LIST", "a, b\n")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		key  string
+		want string
+	}{
+		{name: "env wins over file", env: map[string]string{"TEST_TOKEN": "from-env", "TEST_TOKEN_FILE": tokenFile}, key: "TEST_TOKEN", want: "from-env"},
+		{name: "file trimmed", env: map[string]string{"TEST_TOKEN_FILE": tokenFile}, key: "TEST_TOKEN", want: "s3cret"},
+		{name: "secrets dir", env: map[string]string{"SECRETS_DIR": dir}, key: "TEST_LIST", want: "a, b"},
+		{name: "missing from secrets dir", env: map[string]string{"SECRETS_DIR": dir}, key: "TEST_TOKEN", want: "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := GetEnv(tt.key, "default"); got != tt.want {
+				t.Errorf("GetEnv(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvUnreadableFile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("TEST_TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	if got := GetEnv("TEST_TOKEN", "default"); got != "default" {
+		t.Errorf("GetEnv() = %q, want default", got)
+	}
+	if err := Validate(); err == nil || !strings.Contains(err.Error(), "TEST_TOKEN_FILE") {
+		t.Errorf("Validate() error = %v, want TEST_TOKEN_FILE problem", err)
+	}
+}
+
+func TestWatchAPIKeys(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("API_KEYS_FILE", path)
+
+	cfg := New()
+	if !cfg.ValidateAPIKey("one") || cfg.APIKeyCount() != 2 {
+		t.Fatalf("initial keys not loaded from file")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan int, 1)
+	go cfg.WatchAPIKeys(ctx, 10*time.Millisecond, func(n int) { reloaded <- n })
+
+	// Ensure the modification time differs on filesystems with coarse
+	// timestamps.
+	if err := os.WriteFile(path, []byte("three\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case n := <-reloaded:
+		if n != 1 {
+			t.Errorf("reloaded key count = %d, want 1", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("keys were not reloaded")
+	}
+	if cfg.ValidateAPIKey("one") || !cfg.ValidateAPIKey("three") {
+		t.Errorf("key set not replaced after reload")
+	}
+}
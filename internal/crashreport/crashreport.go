@@ -0,0 +1,277 @@
+// Package crashreport sends recovered panics, bursts of tool errors, and
+// failures that stop the server to an error tracker. ERROR_REPORTING_DSN
+// selects it: a Sentry DSN, https://<key>@<host>/<project>, posts events
+// to the project's store endpoint, and a URL without a key receives the
+// same JSON events as a generic webhook.
+package crashreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/events"
+)
+
+// Kinds of report, as the kind tag and the kind label of Reports.
+const (
+	KindPanic      = "panic"
+	KindToolErrors = "tool_errors"
+	KindFatal      = "fatal"
+)
+
+// maxInflight caps the reports being sent at once; more are dropped, so a
+// panic in every call cannot pile up goroutines.
+const maxInflight = 8
+
+// Reports counts reports by kind and result (sent, failed, dropped).
+var Reports = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "crash_reports_total",
+		Help: "Total number of error reports, by kind (panic, tool_errors, fatal) and result (sent, failed, dropped).",
+	},
+	[]string{"kind", "result"},
+)
+
+// Config selects where and what is reported.
+type Config struct {
+	// DSN is the Sentry DSN or URL reports are sent to; empty disables
+	// reporting.
+	DSN         string
+	Environment string
+	// ToolErrors is how many failed tool calls within a minute are
+	// reported; 0 reports none.
+	ToolErrors int
+	Timeout    time.Duration
+}
+
+// LoadConfig reads the ERROR_REPORTING_* settings.
+func LoadConfig() Config {
+	return Config{
+		DSN:         config.GetEnv("ERROR_REPORTING_DSN", ""),
+		Environment: config.GetEnv("ERROR_REPORTING_ENVIRONMENT", ""),
+		ToolErrors:  config.GetEnvInt("ERROR_REPORTING_TOOL_ERRORS", 20),
+		Timeout:     config.GetEnvDuration("ERROR_REPORTING_TIMEOUT", 5*time.Second),
+	}
+}
+
+// Event is a report, in the Sentry event format.
+type Event struct {
+	ID          string            `json:"event_id"`
+	Time        time.Time         `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	Message     string            `json:"message"`
+	Release     string            `json:"release"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+}
+
+// Reporter sends reports in the background. A nil *Reporter reports
+// nothing, so callers need not check whether reporting is enabled.
+type Reporter struct {
+	cfg      Config
+	endpoint string
+	auth     string // X-Sentry-Auth header; empty for a generic URL
+	release  string
+	host     string
+	client   *http.Client
+	logger   atomic.Pointer[slog.Logger]
+	inflight atomic.Int32
+	wg       sync.WaitGroup
+}
+
+// New returns a Reporter for cfg tagging reports with the release
+// name@version, or nil when no DSN is configured.
+func New(cfg Config, name, version string) (*Reporter, error) {
+	if cfg.DSN == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(cfg.DSN)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("ERROR_REPORTING_DSN must be an http or https URL")
+	}
+	host, _ := os.Hostname()
+	r := &Reporter{
+		cfg:      cfg,
+		endpoint: u.String(),
+		release:  name + "@" + version,
+		host:     host,
+		client:   &http.Client{Timeout: cfg.Timeout},
+	}
+	if key := u.User.Username(); key != "" {
+		// The project is the last path element; anything before it is a
+		// prefix of the API path
+		prefix, project := path.Split(strings.TrimSuffix(u.Path, "/"))
+		if project == "" {
+			return nil, errors.New("ERROR_REPORTING_DSN has no Sentry project")
+		}
+		store := url.URL{Scheme: u.Scheme, Host: u.Host, Path: path.Join(prefix, "api", project, "store") + "/"}
+		r.endpoint = store.String()
+		r.auth = fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s/%s, sentry_key=%s", name, version, key)
+	}
+	r.logger.Store(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+	return r, nil
+}
+
+// SetLogger sets the logger of failed deliveries.
+func (r *Reporter) SetLogger(logger *slog.Logger) {
+	if r != nil {
+		r.logger.Store(logger)
+	}
+}
+
+// Panic reports a panic recovered in the tool called tool.
+func (r *Reporter) Panic(tool string, p any, stack []byte) {
+	r.report(KindPanic, "error", fmt.Sprintf("tool %s panicked: %v", tool, p),
+		map[string]string{"tool": tool}, map[string]any{"stack": string(stack)})
+}
+
+// Fatal reports err, which stopped the server, as msg.
+func (r *Reporter) Fatal(msg string, err error) {
+	r.report(KindFatal, "fatal", msg+": "+err.Error(), nil, nil)
+}
+
+// ToolErrors returns an events.Sink of tool_call.failed that reports when
+// cfg.ToolErrors calls fail within a minute, once a minute at most. It
+// returns nil when r is nil or the threshold is 0.
+func (r *Reporter) ToolErrors() events.Sink {
+	if r == nil || r.cfg.ToolErrors <= 0 {
+		return nil
+	}
+	return &toolErrors{r: r, threshold: r.cfg.ToolErrors, window: time.Minute, byTool: make(map[string]int)}
+}
+
+// Flush waits up to the request timeout for the reports being sent.
+func (r *Reporter) Flush() {
+	if r == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(r.cfg.Timeout):
+	}
+}
+
+// report sends an event in the background.
+func (r *Reporter) report(kind, level, msg string, tags map[string]string, extra map[string]any) {
+	if r == nil {
+		return
+	}
+	if r.inflight.Add(1) > maxInflight {
+		r.inflight.Add(-1)
+		Reports.WithLabelValues(kind, "dropped").Inc()
+		return
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	e := Event{
+		ID:          hex.EncodeToString(id),
+		Time:        time.Now().UTC(),
+		Level:       level,
+		Platform:    "go",
+		Logger:      "mcp-server",
+		Message:     msg,
+		Release:     r.release,
+		Environment: r.cfg.Environment,
+		ServerName:  r.host,
+		Tags:        map[string]string{"kind": kind},
+		Extra:       extra,
+	}
+	maps.Copy(e.Tags, tags)
+	r.wg.Go(func() {
+		defer r.inflight.Add(-1)
+		result := "sent"
+		if err := r.send(e); err != nil {
+			result = "failed"
+			r.logger.Load().Warn("error report not sent", "kind", kind, "id", e.ID, "error", err)
+		}
+		Reports.WithLabelValues(kind, result).Inc()
+	})
+}
+
+func (r *Reporter) send(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.auth != "" {
+		req.Header.Set("X-Sentry-Auth", r.auth)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// toolErrors counts failed tool calls per window.
+type toolErrors struct {
+	r         *Reporter
+	threshold int
+	window    time.Duration
+
+	mu       sync.Mutex
+	start    time.Time
+	count    int
+	byTool   map[string]int
+	reported bool
+}
+
+// Publish counts a tool_call.failed event.
+func (t *toolErrors) Publish(e events.Event) {
+	if e.Type != events.ToolCallFailed {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e.Time.Sub(t.start) >= t.window {
+		t.start, t.count, t.reported = e.Time, 0, false
+		clear(t.byTool)
+	}
+	tool, _ := e.Data["tool"].(string)
+	t.count++
+	t.byTool[tool]++
+	if t.count < t.threshold || t.reported {
+		return
+	}
+	t.reported = true
+	t.r.report(KindToolErrors, "warning", fmt.Sprintf("%d tool calls failed within %s", t.count, t.window),
+		nil, map[string]any{"tools": maps.Clone(t.byTool), "threshold": t.threshold})
+}
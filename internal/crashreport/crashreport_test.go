@@ -0,0 +1,145 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/events"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		dsn          string
+		wantEndpoint string
+		wantAuth     string
+		wantErr      string
+	}{
+		{name: "disabled"},
+		{name: "sentry", dsn: "https://abc123@o1.ingest.sentry.io/42", wantEndpoint: "https://o1.ingest.sentry.io/api/42/store/", wantAuth: "sentry_key=abc123"},
+		{name: "sentry with prefix", dsn: "https://abc123@sentry.example.com/errors/42", wantEndpoint: "https://sentry.example.com/errors/api/42/store/", wantAuth: "sentry_key=abc123"},
+		{name: "generic", dsn: "https://hooks.example.com/crash", wantEndpoint: "https://hooks.example.com/crash"},
+		{name: "sentry without project", dsn: "https://abc123@sentry.example.com/", wantErr: "no Sentry project"},
+		{name: "not a URL", dsn: "sentry", wantErr: "http or https URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := New(Config{DSN: tt.dsn, Timeout: time.Second}, "mcp-server", "1.2.3")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("New() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if tt.dsn == "" {
+				if r != nil {
+					t.Error("New() without a DSN returned a Reporter")
+				}
+				return
+			}
+			if r.endpoint != tt.wantEndpoint || !strings.Contains(r.auth, tt.wantAuth) {
+				t.Errorf("endpoint, auth = %q, %q, want %q, %q", r.endpoint, r.auth, tt.wantEndpoint, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e Event
+		_ = json.NewDecoder(r.Body).Decode(&e)
+		received <- r
+		bodies <- e
+	}))
+	defer srv.Close()
+	dsn := strings.Replace(srv.URL, "http://", "http://abc123@", 1) + "/42"
+	r, err := New(Config{DSN: dsn, Environment: "staging", Timeout: time.Second}, "mcp-server", "1.2.3")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	r.Panic("exec", "boom", []byte("goroutine 1"))
+	r.Flush()
+	req, e := <-received, <-bodies
+	if req.URL.Path != "/api/42/store/" || !strings.Contains(req.Header.Get("X-Sentry-Auth"), "sentry_key=abc123") {
+		t.Errorf("request = %s with X-Sentry-Auth %q", req.URL.Path, req.Header.Get("X-Sentry-Auth"))
+	}
+	if e.Release != "mcp-server@1.2.3" || e.Environment != "staging" || e.Tags["kind"] != KindPanic || e.Tags["tool"] != "exec" || len(e.ID) != 32 {
+		t.Errorf("event = %+v", e)
+	}
+	if e.Message != "tool exec panicked: boom" || e.Extra["stack"] != "goroutine 1" {
+		t.Errorf("message, stack = %q, %v", e.Message, e.Extra["stack"])
+	}
+
+	r.Fatal("server failed", errors.New("listen: address in use"))
+	r.Flush()
+	<-received
+	if e := <-bodies; e.Level != "fatal" || e.Message != "server failed: listen: address in use" {
+		t.Errorf("fatal event = %+v", e)
+	}
+
+	var nilReporter *Reporter
+	nilReporter.Panic("exec", "boom", nil)
+	nilReporter.Flush()
+	if nilReporter.ToolErrors() != nil {
+		t.Error("nil Reporter has a ToolErrors sink")
+	}
+}
+
+func TestToolErrors(t *testing.T) {
+	bodies := make(chan Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e Event
+		_ = json.NewDecoder(r.Body).Decode(&e)
+		bodies <- e
+	}))
+	defer srv.Close()
+	r, err := New(Config{DSN: srv.URL, ToolErrors: 3, Timeout: time.Second}, "mcp-server", "1.2.3")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	sink := r.ToolErrors()
+	start := time.Now()
+	fail := func(tool string, at time.Duration) {
+		sink.Publish(events.Event{Type: events.ToolCallFailed, Time: start.Add(at), Data: map[string]any{"tool": tool}})
+	}
+
+	// Three failures within a minute are reported once, however many follow
+	fail("exec", 0)
+	fail("exec", time.Second)
+	sink.Publish(events.Event{Type: events.ToolCallCompleted, Time: start.Add(time.Second)})
+	fail("http_fetch", 2*time.Second)
+	fail("exec", 3*time.Second)
+	r.Flush()
+	if len(bodies) != 1 {
+		t.Fatalf("reports = %d, want 1", len(bodies))
+	}
+	e := <-bodies
+	tools, _ := e.Extra["tools"].(map[string]any)
+	if e.Tags["kind"] != KindToolErrors || e.Level != "warning" || tools["exec"] != 2.0 || tools["http_fetch"] != 1.0 {
+		t.Errorf("event = %+v", e)
+	}
+
+	// The count starts again in the next minute
+	fail("exec", time.Minute+time.Second)
+	fail("exec", time.Minute+2*time.Second)
+	r.Flush()
+	if len(bodies) != 0 {
+		t.Errorf("reported %d failures of a new minute, under the threshold", 2)
+	}
+	fail("exec", time.Minute+3*time.Second)
+	r.Flush()
+	if len(bodies) != 1 {
+		t.Errorf("reports in the second minute = %d, want 1", len(bodies))
+	}
+}
@@ -0,0 +1,141 @@
+// Package events is the server's internal event bus. The server publishes
+// what happens to it — tool calls, sessions, authentication failures,
+// configuration reloads — and sinks subscribed to the bus forward the
+// events elsewhere: the log, webhooks, or a message broker supplied by an
+// embedder. New integrations subscribe a Sink instead of adding middleware.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	ServerStarted     = "server.started"
+	ServerStopped     = "server.stopped"
+	ToolCallStarted   = "tool_call.started"
+	ToolCallCompleted = "tool_call.completed"
+	ToolCallFailed    = "tool_call.failed"
+	AuthFailure       = "auth.failure"
+	RateLimitExceeded = "rate_limit.exceeded"
+	SessionOpened     = "session.opened"
+	SessionClosed     = "session.closed"
+	ConfigReloaded    = "config.reloaded"
+	ScheduleCompleted = "schedule.completed"
+	ScheduleFailed    = "schedule.failed"
+)
+
+// Types lists every event type.
+var Types = []string{
+	ServerStarted, ServerStopped, ToolCallStarted, ToolCallCompleted, ToolCallFailed, AuthFailure,
+	RateLimitExceeded, SessionOpened, SessionClosed, ConfigReloaded, ScheduleCompleted, ScheduleFailed,
+}
+
+// Event is something that happened to the server.
+type Event struct {
+	ID   string         `json:"id"`
+	Type string         `json:"type"`
+	Time time.Time      `json:"time"`
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// Sink receives published events. Publish is called on the publisher's
+// goroutine, often while a request waits, so it must not block: sinks that
+// do I/O queue the event and deliver it in the background.
+type Sink interface {
+	Publish(e Event)
+}
+
+// SinkFunc adapts a function to Sink.
+type SinkFunc func(e Event)
+
+// Publish calls f(e).
+func (f SinkFunc) Publish(e Event) { f(e) }
+
+// ValidateTypes rejects unknown event types.
+func ValidateTypes(types []string) error {
+	for _, t := range types {
+		if !slices.Contains(Types, t) {
+			return fmt.Errorf("unknown event type %q", t)
+		}
+	}
+	return nil
+}
+
+type subscription struct {
+	sink  Sink
+	types []string
+}
+
+// Bus fans published events out to subscribed sinks. A nil *Bus drops
+// every event, so publishers need not check whether anything listens.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []subscription
+}
+
+// NewBus returns a Bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe sends events of types to sink, or every event when types is
+// empty.
+func (b *Bus) Subscribe(sink Sink, types ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, subscription{sink: sink, types: types})
+}
+
+// Wants reports whether any sink receives events of type typ, so that
+// publishers can skip building events nobody reads.
+func (b *Bus) Wants(typ string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subs {
+		if len(s.types) == 0 || slices.Contains(s.types, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish sends an event of type typ to the sinks subscribed to it.
+func (b *Bus) Publish(typ string, data map[string]any) {
+	if !b.Wants(typ) {
+		return
+	}
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	e := Event{ID: hex.EncodeToString(id), Type: typ, Time: time.Now().UTC(), Data: data}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subs {
+		if len(s.types) == 0 || slices.Contains(s.types, typ) {
+			s.sink.Publish(e)
+		}
+	}
+}
+
+// LogSink logs each event at level.
+func LogSink(logger *slog.Logger, level slog.Level) Sink {
+	return SinkFunc(func(e Event) {
+		attrs := make([]slog.Attr, 0, len(e.Data)+2)
+		attrs = append(attrs, slog.String("type", e.Type), slog.String("id", e.ID))
+		for k, v := range e.Data {
+			attrs = append(attrs, slog.Any(k, v))
+		}
+		logger.LogAttrs(context.Background(), level, "event", attrs...)
+	})
+}
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var all, tools []Event
+	bus.Subscribe(SinkFunc(func(e Event) { all = append(all, e) }))
+	bus.Subscribe(SinkFunc(func(e Event) { tools = append(tools, e) }), ToolCallStarted, ToolCallFailed)
+
+	bus.Publish(ServerStarted, nil)
+	bus.Publish(ToolCallStarted, map[string]any{"tool": "uuid"})
+	bus.Publish(ToolCallFailed, map[string]any{"tool": "uuid"})
+
+	if len(all) != 3 {
+		t.Errorf("unfiltered sink got %d events, want 3", len(all))
+	}
+	if len(tools) != 2 || tools[0].Type != ToolCallStarted || tools[1].Type != ToolCallFailed {
+		t.Errorf("filtered sink got %+v, want started and failed", tools)
+	}
+	if e := tools[0]; e.ID == "" || e.Time.IsZero() || e.Data["tool"] != "uuid" {
+		t.Errorf("event = %+v, want ID, time, and data", e)
+	}
+	if all[1].ID != tools[0].ID {
+		t.Error("sinks received different IDs for one event")
+	}
+}
+
+func TestWants(t *testing.T) {
+	var nilBus *Bus
+	if nilBus.Wants(ServerStarted) {
+		t.Error("nil bus wants events")
+	}
+	nilBus.Publish(ServerStarted, nil)
+
+	bus := NewBus()
+	if bus.Wants(ServerStarted) {
+		t.Error("bus without sinks wants events")
+	}
+	bus.Subscribe(SinkFunc(func(Event) {}), AuthFailure)
+	if !bus.Wants(AuthFailure) || bus.Wants(ServerStarted) {
+		t.Error("Wants does not follow the subscribed types")
+	}
+}
+
+func TestValidateTypes(t *testing.T) {
+	if err := ValidateTypes(Types); err != nil {
+		t.Errorf("ValidateTypes(Types) = %v", err)
+	}
+	if err := ValidateTypes([]string{ToolCallFailed, "tool_call.exploded"}); err == nil || !strings.Contains(err.Error(), "tool_call.exploded") {
+		t.Errorf("ValidateTypes(unknown) = %v, want error naming it", err)
+	}
+}
+
+func TestLogSink(t *testing.T) {
+	var buf bytes.Buffer
+	bus := NewBus()
+	bus.Subscribe(LogSink(slog.New(slog.NewTextHandler(&buf, nil)), slog.LevelInfo))
+	bus.Publish(ConfigReloaded, map[string]any{"source": "api_keys_file"})
+
+	out := buf.String()
+	for _, want := range []string{"msg=event", "type=config.reloaded", "source=api_keys_file"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log %q does not contain %q", out, want)
+		}
+	}
+}
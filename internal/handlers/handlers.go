@@ -1,7 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
 )
 
 // HealthHandler is the health check handler.
@@ -10,3 +18,113 @@ func HealthHandler(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte(`{"healthy":true}` + "\n"))
 }
+
+// HealthCheck is a named check of something the server depends on.
+type HealthCheck struct {
+	Name string
+	// Check returns an error when the dependency is unhealthy.
+	Check func(ctx context.Context) error
+	// Optional checks report their error without failing /health, for
+	// dependencies whose loss degrades the server rather than stopping it.
+	Optional bool
+	// Details, if set, reports more about the dependency under details.
+	Details func() any
+}
+
+// healthCheckTimeout bounds each health check.
+const healthCheckTimeout = 5 * time.Second
+
+// NewHealthHandler returns a health check handler that runs checks in
+// order, reporting each result and 503 Service Unavailable if any check
+// that is not Optional fails. Without checks it behaves like HealthHandler.
+func NewHealthHandler(checks []HealthCheck) http.HandlerFunc {
+	if len(checks) == 0 {
+		return HealthHandler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		healthy := true
+		results := make(map[string]string, len(checks))
+		details := make(map[string]any)
+		for _, c := range checks {
+			ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+			err := c.Check(ctx)
+			cancel()
+			results[c.Name] = "ok"
+			if err != nil {
+				healthy = healthy && c.Optional
+				results[c.Name] = err.Error()
+			}
+			if c.Details != nil {
+				details[c.Name] = c.Details()
+			}
+		}
+
+		status := http.StatusOK
+		if !healthy {
+			status = http.StatusServiceUnavailable
+		}
+		body := map[string]any{"healthy": healthy, "checks": results}
+		if len(details) > 0 {
+			body["details"] = details
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+// NewReadyHandler returns a readiness handler answering 503 Service
+// Unavailable while draining reports true, so load balancers stop routing
+// new requests, and otherwise deferring to health.
+func NewReadyHandler(draining func() bool, health http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if draining() {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"healthy":true,"draining":true}` + "\n"))
+			return
+		}
+		health(w, r)
+	}
+}
+
+// ConfigHandler reports the effective configuration with secrets masked.
+// It must only be mounted behind authentication.
+func ConfigHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{"settings": config.Effective()})
+}
+
+// RateLimitHandler lists the clients rejected most often by the rate
+// limiter, 10 by default or ?n= (at most 1000). It must only be mounted
+// behind authentication.
+func RateLimitHandler(rejections *middleware.RejectionTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := 10
+		if v := r.URL.Query().Get("n"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 {
+				http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			n = min(parsed, 1000)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]any{"clients": rejections.Top(n)})
+	}
+}
+
+// PprofHandler serves the net/http/pprof profiles and the execution tracer
+// under /debug/pprof/. It must only be mounted behind authentication:
+// profiles expose the command line and memory contents.
+func PprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
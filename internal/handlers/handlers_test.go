@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
 )
 
 func TestHealthHandler(t *testing.T) {
@@ -51,3 +58,185 @@ func TestHealthHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestNewHealthHandler(t *testing.T) {
+	ok := HealthCheck{Name: "cache", Check: func(context.Context) error { return nil }}
+	down := HealthCheck{Name: "db", Check: func(context.Context) error { return errors.New("connection refused") }}
+	degraded := HealthCheck{
+		Name:     "tools",
+		Check:    func(context.Context) error { return errors.New("k8s failed") },
+		Optional: true,
+		Details:  func() any { return map[string]string{"k8s": "failed"} },
+	}
+
+	tests := []struct {
+		name       string
+		checks     []HealthCheck
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "no checks",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"healthy":true}` + "\n",
+		},
+		{
+			name:       "passing",
+			checks:     []HealthCheck{ok},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"checks":{"cache":"ok"},"healthy":true}` + "\n",
+		},
+		{
+			name:       "failing",
+			checks:     []HealthCheck{ok, down},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"checks":{"cache":"ok","db":"connection refused"},"healthy":false}` + "\n",
+		},
+		{
+			name:       "optional failing",
+			checks:     []HealthCheck{ok, degraded},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"checks":{"cache":"ok","tools":"k8s failed"},"details":{"tools":{"k8s":"failed"}},"healthy":true}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewHealthHandler(tt.checks)(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNewReadyHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		draining   bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "ready", wantStatus: http.StatusOK, wantBody: `{"healthy":true}` + "\n"},
+		{name: "draining", draining: true, wantStatus: http.StatusServiceUnavailable, wantBody: `{"healthy":true,"draining":true}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h := NewReadyHandler(func() bool { return tt.draining }, HealthHandler)
+			h(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestConfigHandler(t *testing.T) {
+	t.Setenv("HANDLERS_TEST_TOKEN", "hunter2")
+	config.GetEnv("HANDLERS_TEST_TOKEN", "")
+
+	rec := httptest.NewRecorder()
+	ConfigHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var body struct {
+		Settings []config.Setting `json:"settings"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	for _, s := range body.Settings {
+		if s.Key == "HANDLERS_TEST_TOKEN" {
+			if s.Value != "********" {
+				t.Errorf("HANDLERS_TEST_TOKEN = %q, want masked", s.Value)
+			}
+			return
+		}
+	}
+	t.Errorf("HANDLERS_TEST_TOKEN missing from %v", body.Settings)
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	rejections := middleware.NewRejectionTracker(10)
+	for _, client := range []string{"203.0.113.7", "203.0.113.7", "198.51.100.1"} {
+		rejections.Record(client)
+	}
+	handler := RateLimitHandler(rejections)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTop    []string
+	}{
+		{name: "default", wantStatus: http.StatusOK, wantTop: []string{"203.0.113.7", "198.51.100.1"}},
+		{name: "limited", query: "?n=1", wantStatus: http.StatusOK, wantTop: []string{"203.0.113.7"}},
+		{name: "invalid n", query: "?n=zero", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, "/admin/ratelimit"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				Clients []middleware.RejectedClient `json:"clients"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if len(body.Clients) != len(tt.wantTop) {
+				t.Fatalf("clients = %+v, want %v", body.Clients, tt.wantTop)
+			}
+			for i, want := range tt.wantTop {
+				if body.Clients[i].Client != want {
+					t.Errorf("client %d = %q, want %q", i, body.Clients[i].Client, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPprofHandler(t *testing.T) {
+	tests := []struct {
+		path     string
+		wantBody string
+	}{
+		{path: "/debug/pprof/", wantBody: "goroutine"},
+		{path: "/debug/pprof/heap?debug=1", wantBody: "heap profile"},
+		{path: "/debug/pprof/cmdline", wantBody: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			PprofHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body does not contain %q", tt.wantBody)
+			}
+		})
+	}
+}
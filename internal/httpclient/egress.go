@@ -0,0 +1,221 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+// ErrEgressDenied is wrapped by the errors of requests and connections
+// the egress allow-list refuses.
+var ErrEgressDenied = errors.New("egress denied")
+
+// EgressDenied counts requests and connections refused by the egress
+// allow-list, by client.
+var EgressDenied = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_client_egress_denied_total",
+		Help: "Total number of outbound requests and connections refused by the egress allow-list, by client.",
+	},
+	[]string{"client"},
+)
+
+// Egress is the allow-list of destinations clients may reach. A
+// destination must be on an allowed port and either match an allowed
+// host or have addresses in allowed CIDRs, the only ones dialed; with
+// neither hosts nor CIDRs set, any destination on an allowed port is.
+// The zero Egress allows everything.
+type Egress struct {
+	// Hosts are host names or IP addresses, or *.example.com for any
+	// subdomain.
+	Hosts []string
+	// CIDRs are the address ranges allowed whatever the host name.
+	CIDRs []netip.Prefix
+	// Ports are the allowed ports; empty allows any.
+	Ports []int
+}
+
+// LoadEgress reads the EGRESS_* settings. Invalid CIDRs and ports are
+// logged and ignored, which allows less, never more.
+func LoadEgress() Egress {
+	e := Egress{Hosts: config.GetEnvList("EGRESS_ALLOWED_HOSTS")}
+	for _, entry := range config.GetEnvList("EGRESS_ALLOWED_CIDRS") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			// A bare address is a single-address range
+			addr, aerr := netip.ParseAddr(entry)
+			if aerr != nil {
+				logger.Warn("invalid EGRESS_ALLOWED_CIDRS entry ignored", "entry", entry)
+				continue
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		e.CIDRs = append(e.CIDRs, prefix.Masked())
+	}
+	for _, entry := range config.GetEnvList("EGRESS_ALLOWED_PORTS") {
+		port, err := strconv.Atoi(entry)
+		if err != nil || port <= 0 || port > 65535 {
+			logger.Warn("invalid EGRESS_ALLOWED_PORTS entry ignored", "entry", entry)
+			continue
+		}
+		e.Ports = append(e.Ports, port)
+	}
+	return e
+}
+
+// Enabled reports whether e restricts anything.
+func (e Egress) Enabled() bool {
+	return len(e.Hosts) > 0 || len(e.CIDRs) > 0 || len(e.Ports) > 0
+}
+
+func (e Egress) portAllowed(port string) bool {
+	p, err := strconv.Atoi(port)
+	return len(e.Ports) == 0 || err == nil && slices.Contains(e.Ports, p)
+}
+
+func (e Egress) anyHost() bool {
+	return len(e.Hosts) == 0 && len(e.CIDRs) == 0
+}
+
+func (e Egress) hostAllowed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, entry := range e.Hosts {
+		entry = strings.ToLower(entry)
+		switch {
+		case entry == host:
+			return true
+		case strings.HasPrefix(entry, "*.") && strings.HasSuffix(host, entry[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+func (e Egress) addrAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range e.CIDRs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRequest refuses requests to destinations e disallows by name or
+// port. Names allowed only by CIDR are left to the dialer to resolve and
+// check, unless the request goes through a proxy, which resolves them
+// instead: through a proxy, names must be allowed as hosts.
+func (e Egress) checkRequest(u *url.URL, proxied bool) error {
+	host, port := u.Hostname(), u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	if !e.portAllowed(port) {
+		return fmt.Errorf("%w: port %s is not allowed", ErrEgressDenied, port)
+	}
+	if e.anyHost() || e.hostAllowed(host) {
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if e.addrAllowed(addr) {
+			return nil
+		}
+	} else if len(e.CIDRs) > 0 && !proxied {
+		return nil
+	}
+	return fmt.Errorf("%w: host %s is not allowed", ErrEgressDenied, host)
+}
+
+// dialer wraps dial so that every connection, to a destination or a
+// proxy, is checked against e. A name allowed only by CIDR is resolved
+// here and only its allowed addresses dialed, so it cannot be rebound
+// to another address between the check and the connection.
+func (e Egress) dialer(name string, dial func(ctx context.Context, network, address string) (net.Conn, error)) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if !e.portAllowed(port) {
+			return nil, e.denied(name, address, "port not allowed")
+		}
+		if e.anyHost() || e.hostAllowed(host) {
+			return dial(ctx, network, address)
+		}
+		if addr, err := netip.ParseAddr(host); err == nil {
+			if !e.addrAllowed(addr) {
+				return nil, e.denied(name, address, "address not allowed")
+			}
+			return dial(ctx, network, address)
+		}
+		if len(e.CIDRs) == 0 {
+			return nil, e.denied(name, address, "host not allowed")
+		}
+		addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return nil, err
+		}
+		var errs []error
+		for _, addr := range addrs {
+			if !e.addrAllowed(addr) {
+				continue
+			}
+			conn, err := dial(ctx, network, net.JoinHostPort(addr.Unmap().String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			return nil, e.denied(name, address, "no allowed address")
+		}
+		return nil, errors.Join(errs...)
+	}
+}
+
+func (e Egress) denied(name, address, reason string) error {
+	EgressDenied.WithLabelValues(name).Inc()
+	logger.Warn("egress denied", "client", name, "address", address, "reason", reason)
+	return fmt.Errorf("%w: %s: %s", ErrEgressDenied, address, reason)
+}
+
+// egressChecked refuses requests to destinations the egress allow-list
+// disallows before they are sent.
+type egressChecked struct {
+	next   http.RoundTripper
+	name   string
+	egress Egress
+	proxy  func(*http.Request) (*url.URL, error)
+}
+
+func (t *egressChecked) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxied := false
+	if t.proxy != nil {
+		if u, err := t.proxy(req); err == nil && u != nil {
+			proxied = true
+		}
+	}
+	if err := t.egress.checkRequest(req.URL, proxied); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		EgressDenied.WithLabelValues(t.name).Inc()
+		logger.Warn("egress denied", "client", t.name, "url", req.URL.Redacted())
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
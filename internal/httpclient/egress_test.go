@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestEgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	portNum := srv.Listener.Addr().(*net.TCPAddr).Port
+	loopback := netip.MustParsePrefix("127.0.0.0/8")
+
+	tests := []struct {
+		name   string
+		egress Egress
+		host   string
+		allow  bool
+	}{
+		{name: "unrestricted", egress: Egress{}, host: "127.0.0.1", allow: true},
+		{name: "host allowed", egress: Egress{Hosts: []string{"localhost"}}, host: "localhost", allow: true},
+		{name: "host not allowed", egress: Egress{Hosts: []string{"api.example.com"}}, host: "localhost"},
+		{name: "address as host", egress: Egress{Hosts: []string{"127.0.0.1"}}, host: "127.0.0.1", allow: true},
+		{name: "address in CIDR", egress: Egress{CIDRs: []netip.Prefix{loopback}}, host: "127.0.0.1", allow: true},
+		{name: "name resolving into CIDR", egress: Egress{CIDRs: []netip.Prefix{loopback}}, host: "localhost", allow: true},
+		{name: "address outside CIDR", egress: Egress{CIDRs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}, host: "127.0.0.1"},
+		{name: "name resolving outside CIDR", egress: Egress{CIDRs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}, host: "localhost"},
+		{name: "port allowed", egress: Egress{Ports: []int{portNum}}, host: "127.0.0.1", allow: true},
+		{name: "port not allowed", egress: Egress{Hosts: []string{"localhost"}, Ports: []int{443}}, host: "localhost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := NewFactory(Config{Timeout: 5 * time.Second, DialTimeout: time.Second, Retries: 2, MaxIdleConns: 10, Egress: tt.egress})
+			before := testutil.ToFloat64(EgressDenied.WithLabelValues("egress"))
+			resp, err := f.Client("egress", Options{}).Get("http://" + net.JoinHostPort(tt.host, port))
+			if tt.allow {
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				_ = resp.Body.Close()
+				return
+			}
+			if !errors.Is(err, ErrEgressDenied) {
+				t.Fatalf("Get() error = %v, want ErrEgressDenied", err)
+			}
+			if got := testutil.ToFloat64(EgressDenied.WithLabelValues("egress")); got != before+1 {
+				t.Errorf("denied = %v, want 1 (no retries)", got-before)
+			}
+		})
+	}
+}
+
+func TestEgressCheckRequest(t *testing.T) {
+	e := Egress{Hosts: []string{"*.example.com", "proxy.internal"}, CIDRs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, Ports: []int{443, 8443}}
+	tests := []struct {
+		url     string
+		proxied bool
+		allow   bool
+	}{
+		{url: "https://api.example.com/v1", allow: true},
+		{url: "https://api.example.com:8443/v1", allow: true},
+		{url: "http://api.example.com/v1"},
+		{url: "https://10.1.2.3/", allow: true},
+		{url: "https://192.168.1.1/"},
+		// Left to the dialer to resolve, unless a proxy resolves it
+		{url: "https://db.internal/", allow: true},
+		{url: "https://db.internal/", proxied: true},
+		{url: "https://api.example.com/", proxied: true, allow: true},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if err := e.checkRequest(u, tt.proxied); (err == nil) != tt.allow {
+			t.Errorf("checkRequest(%s, proxied %v) = %v, want allowed %v", tt.url, tt.proxied, err, tt.allow)
+		}
+	}
+}
+
+func TestLoadEgress(t *testing.T) {
+	t.Setenv("EGRESS_ALLOWED_HOSTS", "api.example.com")
+	t.Setenv("EGRESS_ALLOWED_CIDRS", "10.1.2.3/8,192.168.0.10,not-a-cidr")
+	t.Setenv("EGRESS_ALLOWED_PORTS", "443,0,https")
+	e := LoadEgress()
+	want := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.10/32")}
+	if len(e.CIDRs) != 2 || e.CIDRs[0] != want[0] || e.CIDRs[1] != want[1] {
+		t.Errorf("CIDRs = %v, want %v", e.CIDRs, want)
+	}
+	if len(e.Ports) != 1 || e.Ports[0] != 443 {
+		t.Errorf("Ports = %v, want [443]", e.Ports)
+	}
+	if !e.Enabled() || (Egress{}).Enabled() {
+		t.Error("Enabled() is wrong")
+	}
+}
@@ -0,0 +1,373 @@
+// Package httpclient builds the HTTP clients tools use to call external
+// services, so that they share timeouts, retries, proxy settings,
+// connection limits, and metrics instead of each configuring its own:
+//
+//	client := httpclient.New("prom_query", httpclient.Options{Timeout: cfg.Timeout})
+//
+// The name identifies the client in metrics and selects its connection
+// limit in HTTP_CLIENT_CONN_LIMITS; tools use their own name. Every
+// client enforces the EGRESS_* allow-list, whatever its tool allows, and
+// goes through the configured proxy trusting the configured CAs.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// Client metrics.
+var (
+	// Requests counts requests by client, method, and status code, or
+	// "error" when no response arrived. Each retry is counted.
+	Requests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_client_requests_total",
+			Help: "Total number of outbound HTTP requests, by client, method, and status code.",
+		},
+		[]string{"client", "method", "code"},
+	)
+	// RequestDuration observes how long requests took, by client.
+	RequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_client_request_duration_seconds",
+			Help:    "Duration of outbound HTTP requests until their response headers, by client.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"client"},
+	)
+	// Retries counts requests retried, by client.
+	Retries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_client_retries_total",
+			Help: "Total number of outbound HTTP requests retried, by client.",
+		},
+		[]string{"client"},
+	)
+)
+
+// maxRetryWait caps the wait before a retry, including one asked for by
+// a Retry-After header.
+const maxRetryWait = 30 * time.Second
+
+// Config holds the settings shared by every client.
+type Config struct {
+	// Timeout bounds a request, retries included.
+	Timeout time.Duration
+	// DialTimeout bounds establishing a connection.
+	DialTimeout time.Duration
+	// Retries is how many times an idempotent request is retried after a
+	// network error or a 429, 502, 503, or 504 response.
+	Retries int
+	// RetryBackoff is the wait before the first retry, doubled for each
+	// further one.
+	RetryBackoff time.Duration
+	// MaxIdleConns caps the idle connections each client keeps.
+	MaxIdleConns int
+	// MaxConnsPerHost caps each client's connections to one host; 0 is
+	// unlimited.
+	MaxConnsPerHost int
+	// ConnLimits replaces MaxConnsPerHost for the clients named.
+	ConnLimits map[string]int
+	// Egress restricts the destinations of every client.
+	Egress Egress
+	// Proxy is the URL of the proxy for every request, replacing
+	// HTTP_PROXY and HTTPS_PROXY; empty uses those.
+	Proxy string
+	// NoProxy are the hosts reached directly, replacing NO_PROXY when
+	// set: names, with a leading . or *. for subdomains, IP addresses,
+	// and CIDRs, optionally with a :port.
+	NoProxy []string
+	// CAFiles are PEM bundles of certificate authorities trusted in
+	// addition to the system's.
+	CAFiles []string
+}
+
+// LoadConfig reads the HTTP_CLIENT_* settings. HTTP_CLIENT_CONN_LIMITS is
+// a comma-separated list of name=connections; invalid entries are logged
+// and ignored.
+func LoadConfig() Config {
+	cfg := Config{
+		Timeout:         config.GetEnvDuration("HTTP_CLIENT_TIMEOUT", 30*time.Second),
+		DialTimeout:     config.GetEnvDuration("HTTP_CLIENT_DIAL_TIMEOUT", 10*time.Second),
+		Retries:         max(config.GetEnvInt("HTTP_CLIENT_RETRIES", 2), 0),
+		RetryBackoff:    max(config.GetEnvDuration("HTTP_CLIENT_RETRY_BACKOFF", 200*time.Millisecond), 0),
+		MaxIdleConns:    config.GetEnvInt("HTTP_CLIENT_MAX_IDLE_CONNS", 100),
+		MaxConnsPerHost: config.GetEnvInt("HTTP_CLIENT_MAX_CONNS_PER_HOST", 0),
+		ConnLimits:      make(map[string]int),
+		Egress:          LoadEgress(),
+		Proxy:           config.GetEnv("HTTP_CLIENT_PROXY", ""),
+		NoProxy:         config.GetEnvList("HTTP_CLIENT_NO_PROXY"),
+		CAFiles:         config.GetEnvList("HTTP_CLIENT_CA_FILES"),
+	}
+	for _, entry := range config.GetEnvList("HTTP_CLIENT_CONN_LIMITS") {
+		name, value, _ := strings.Cut(entry, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			logger.Warn("invalid HTTP_CLIENT_CONN_LIMITS entry ignored", "entry", entry)
+			continue
+		}
+		cfg.ConnLimits[strings.TrimSpace(name)] = limit
+	}
+	return cfg
+}
+
+// Options adjust one client.
+type Options struct {
+	// Timeout replaces Config.Timeout when positive.
+	Timeout time.Duration
+	// NoRetry disables retries, for clients that retry themselves or
+	// whose callers must see every response.
+	NoRetry bool
+	// NoProxy connects directly even when a proxy is configured, for
+	// clients that check the addresses they dial.
+	NoProxy bool
+	// Control, when set, is called with each address before it is
+	// dialed and refuses the connection by returning an error.
+	Control func(network, address string, c syscall.RawConn) error
+	// CheckRedirect is the client's redirect policy; nil follows up to
+	// 10 redirects.
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+}
+
+// Factory builds clients from a Config.
+type Factory struct {
+	cfg   Config
+	proxy func(*http.Request) (*url.URL, error)
+	roots *x509.CertPool
+}
+
+// NewFactory returns a Factory for cfg. It fails when cfg.Proxy is not an
+// http, https, or socks5 URL or a CA file cannot be read or holds no
+// certificates.
+func NewFactory(cfg Config) (*Factory, error) {
+	f := &Factory{cfg: cfg}
+
+	proxy := httpproxy.FromEnvironment()
+	if cfg.Proxy != "" {
+		u, err := url.Parse(cfg.Proxy)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+			return nil, fmt.Errorf("HTTP_CLIENT_PROXY must be an http, https, or socks5 URL")
+		}
+		proxy.HTTPProxy, proxy.HTTPSProxy = cfg.Proxy, cfg.Proxy
+	}
+	if len(cfg.NoProxy) > 0 {
+		proxy.NoProxy = strings.Join(cfg.NoProxy, ",")
+	}
+	proxyFunc := proxy.ProxyFunc()
+	f.proxy = func(req *http.Request) (*url.URL, error) { return proxyFunc(req.URL) }
+
+	if len(cfg.CAFiles) > 0 {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		for _, file := range cfg.CAFiles {
+			pem, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("HTTP_CLIENT_CA_FILES: %w", err)
+			}
+			if !roots.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("HTTP_CLIENT_CA_FILES: no PEM certificates in %s", file)
+			}
+		}
+		f.roots = roots
+	}
+	return f, nil
+}
+
+// Default is the Factory for the HTTP_CLIENT_* settings, created on first
+// use. The server calls it at startup so that invalid settings fail there.
+var Default = sync.OnceValues(func() (*Factory, error) {
+	return NewFactory(LoadConfig())
+})
+
+// New returns a client called name from the Default factory. When the
+// settings are invalid, its requests fail with the reason.
+func New(name string, opts Options) *http.Client {
+	f, err := Default()
+	if err != nil {
+		return &http.Client{Transport: failing{err}}
+	}
+	return f.Client(name, opts)
+}
+
+// failing fails every request with err.
+type failing struct {
+	err error
+}
+
+func (t failing) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return nil, t.err
+}
+
+// Client returns a new client called name. Each client has its own
+// connection pool, so a client should be built once and reused.
+func (f *Factory) Client(name string, opts Options) *http.Client {
+	dialer := &net.Dialer{Timeout: f.cfg.DialTimeout, KeepAlive: 30 * time.Second, Control: opts.Control}
+	maxConns := f.cfg.MaxConnsPerHost
+	if limit, ok := f.cfg.ConnLimits[name]; ok {
+		maxConns = limit
+	}
+	transport := &http.Transport{
+		Proxy:                 f.proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          f.cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   maxConns,
+		MaxConnsPerHost:       maxConns,
+	}
+	if opts.NoProxy {
+		transport.Proxy = nil
+	}
+	if f.roots != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: f.roots, MinVersion: tls.VersionTLS12}
+	}
+
+	var rt http.RoundTripper = &instrumented{next: transport, name: name}
+	if f.cfg.Egress.Enabled() {
+		// Requests are checked before they are sent, and connections,
+		// to proxies too, before they are made
+		transport.DialContext = f.cfg.Egress.dialer(name, dialer.DialContext)
+		rt = &egressChecked{next: rt, name: name, egress: f.cfg.Egress, proxy: transport.Proxy}
+	}
+	if f.cfg.Retries > 0 && !opts.NoRetry {
+		rt = &retrying{next: rt, name: name, retries: f.cfg.Retries, backoff: f.cfg.RetryBackoff}
+	}
+	timeout := f.cfg.Timeout
+	if opts.Timeout > 0 {
+		timeout = opts.Timeout
+	}
+	return &http.Client{Transport: rt, Timeout: timeout, CheckRedirect: opts.CheckRedirect}
+}
+
+// instrumented records the metrics of each request.
+type instrumented struct {
+	next http.RoundTripper
+	name string
+}
+
+func (t *instrumented) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	RequestDuration.WithLabelValues(t.name).Observe(time.Since(start).Seconds())
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	Requests.WithLabelValues(t.name, req.Method, code).Inc()
+	return resp, err
+}
+
+// retrying retries idempotent requests that failed transiently, with
+// exponential backoff and jitter, honoring Retry-After.
+type retrying struct {
+	next    http.RoundTripper
+	name    string
+	retries int
+	backoff time.Duration
+}
+
+func (t *retrying) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !replayable(req) {
+		return t.next.RoundTrip(req)
+	}
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt == t.retries || ctx.Err() != nil || !transient(resp, err) {
+			return resp, err
+		}
+		wait := t.wait(attempt, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			_ = resp.Body.Close()
+		}
+		Retries.WithLabelValues(t.name).Inc()
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// wait returns how long to wait before retry attempt+1.
+func (t *retrying) wait(attempt int, resp *http.Response) time.Duration {
+	// Doubling stops at the cap, so large attempts cannot overflow
+	d := max(t.backoff, 0)
+	for i := 0; i < attempt && d < maxRetryWait; i++ {
+		d *= 2
+	}
+	d = min(d, maxRetryWait)
+	// Jitter of ±50% keeps clients that failed together from retrying
+	// together
+	d = d/2 + rand.N(d+1)
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			d = max(d, time.Duration(secs)*time.Second)
+		}
+	}
+	return min(d, maxRetryWait)
+}
+
+// idempotent methods may be sent twice without changing the outcome.
+var idempotent = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete}
+
+// replayable reports whether req may be retried: it is idempotent, or
+// carries an Idempotency-Key, and its body, if any, can be sent again.
+func replayable(req *http.Request) bool {
+	if !slices.Contains(idempotent, req.Method) && req.Header.Get("Idempotency-Key") == "" {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// transient reports whether a request failed in a way worth retrying.
+func transient(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrEgressDenied)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
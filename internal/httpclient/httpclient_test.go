@@ -0,0 +1,203 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		key        bool
+		statuses   []int
+		noRetry    bool
+		wantCalls  int32
+		wantStatus int
+	}{
+		{name: "success", method: http.MethodGet, statuses: []int{200}, wantCalls: 1, wantStatus: 200},
+		{name: "retried until success", method: http.MethodGet, statuses: []int{503, 502, 200}, wantCalls: 3, wantStatus: 200},
+		{name: "retries exhausted", method: http.MethodGet, statuses: []int{503, 503, 503, 200}, wantCalls: 3, wantStatus: 503},
+		{name: "rate limited", method: http.MethodHead, statuses: []int{429, 200}, wantCalls: 2, wantStatus: 200},
+		{name: "not transient", method: http.MethodGet, statuses: []int{500, 200}, wantCalls: 1, wantStatus: 500},
+		{name: "put body resent", method: http.MethodPut, body: "payload", statuses: []int{504, 200}, wantCalls: 2, wantStatus: 200},
+		{name: "post not retried", method: http.MethodPost, body: "payload", statuses: []int{503, 200}, wantCalls: 1, wantStatus: 503},
+		{name: "post with idempotency key", method: http.MethodPost, body: "payload", key: true, statuses: []int{503, 200}, wantCalls: 2, wantStatus: 200},
+		{name: "retries disabled", method: http.MethodGet, statuses: []int{503, 200}, noRetry: true, wantCalls: 1, wantStatus: 503},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				if body, _ := io.ReadAll(r.Body); string(body) != tt.body {
+					t.Errorf("attempt %d body = %q, want %q", n, body, tt.body)
+				}
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			f, _ := NewFactory(Config{Timeout: 5 * time.Second, DialTimeout: time.Second, Retries: 2, RetryBackoff: time.Millisecond, MaxIdleConns: 10})
+			client := f.Client("test", Options{NoRetry: tt.noRetry})
+			req, _ := http.NewRequest(tt.method, srv.URL, nil)
+			if tt.body != "" {
+				req, _ = http.NewRequest(tt.method, srv.URL, strings.NewReader(tt.body))
+			}
+			if tt.key {
+				req.Header.Set("Idempotency-Key", "k1")
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus || calls.Load() != tt.wantCalls {
+				t.Errorf("status %d after %d calls, want %d after %d", resp.StatusCode, calls.Load(), tt.wantStatus, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	r := &retrying{backoff: 100 * time.Millisecond}
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{name: "first", attempt: 0, min: 50 * time.Millisecond, max: 150 * time.Millisecond},
+		{name: "doubled", attempt: 2, min: 200 * time.Millisecond, max: 600 * time.Millisecond},
+		{name: "retry after", attempt: 0, retryAfter: "2", min: 2 * time.Second, max: 2 * time.Second},
+		{name: "retry after capped", attempt: 0, retryAfter: "3600", min: maxRetryWait, max: maxRetryWait},
+		{name: "backoff capped", attempt: 80, min: maxRetryWait / 2, max: maxRetryWait},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+			if got := r.wait(tt.attempt, resp); got < tt.min || got > tt.max {
+				t.Errorf("wait() = %v, want %v to %v", got, tt.min, tt.max)
+			}
+		})
+	}
+	if got := (&retrying{backoff: -time.Second}).wait(1, nil); got != 0 {
+		t.Errorf("wait() with a negative backoff = %v, want 0", got)
+	}
+}
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	f, _ := NewFactory(Config{Timeout: 5 * time.Second, MaxIdleConns: 10, MaxConnsPerHost: 8, ConnLimits: map[string]int{"limited": 2}})
+	before := testutil.ToFloat64(Requests.WithLabelValues("limited", "GET", "418"))
+	client := f.Client("limited", Options{Timeout: time.Second})
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	if got := testutil.ToFloat64(Requests.WithLabelValues("limited", "GET", "418")); got != before+1 {
+		t.Errorf("requests = %v, want 1", got-before)
+	}
+
+	if client.Timeout != time.Second {
+		t.Errorf("Timeout = %v, want the option's 1s", client.Timeout)
+	}
+	if got := client.Transport.(*instrumented).next.(*http.Transport).MaxConnsPerHost; got != 2 {
+		t.Errorf("MaxConnsPerHost = %d, want the client's limit 2", got)
+	}
+	if got := f.Client("other", Options{}).Transport.(*instrumented).next.(*http.Transport).MaxConnsPerHost; got != 8 {
+		t.Errorf("MaxConnsPerHost = %d, want the default 8", got)
+	}
+}
+
+func TestProxyAndCAs(t *testing.T) {
+	// The proxy answers every request itself
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proxied-Host", r.Host)
+	}))
+	defer proxy.Close()
+	f, err := NewFactory(Config{Timeout: 5 * time.Second, MaxIdleConns: 10, Proxy: proxy.URL, NoProxy: []string{".internal.example.com"}})
+	if err != nil {
+		t.Fatalf("NewFactory() error = %v", err)
+	}
+	resp, err := f.Client("proxied", Options{}).Get("http://api.example.com/v1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	if got := resp.Header.Get("X-Proxied-Host"); got != "api.example.com" {
+		t.Errorf("proxied host = %q, want api.example.com", got)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://db.internal.example.com/", nil)
+	if u, _ := f.proxy(req); u != nil {
+		t.Errorf("proxy for a NoProxy host = %v, want none", u)
+	}
+
+	// A TLS server is trusted only with its CA
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer tlsSrv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsSrv.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []struct {
+		files []string
+		trust bool
+	}{{nil, false}, {[]string{caFile}, true}} {
+		f, err := NewFactory(Config{Timeout: 5 * time.Second, MaxIdleConns: 10, CAFiles: cfg.files})
+		if err != nil {
+			t.Fatalf("NewFactory() error = %v", err)
+		}
+		resp, err := f.Client("tls", Options{NoRetry: true}).Get(tlsSrv.URL)
+		if (err == nil) != cfg.trust {
+			t.Errorf("Get() with CA files %v error = %v, want trusted %v", cfg.files, err, cfg.trust)
+		}
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}
+
+	badFile := filepath.Join(t.TempDir(), "bad.pem")
+	_ = os.WriteFile(badFile, []byte("not a certificate"), 0o600)
+	for _, cfg := range []Config{
+		{Proxy: "proxy.example.com:3128"},
+		{Proxy: "ftp://proxy.example.com"},
+		{CAFiles: []string{filepath.Join(t.TempDir(), "missing.pem")}},
+		{CAFiles: []string{badFile}},
+	} {
+		if _, err := NewFactory(cfg); err == nil {
+			t.Errorf("NewFactory(%+v) succeeded", cfg)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("HTTP_CLIENT_CONN_LIMITS", "prom_query=4, http_fetch = 2,broken,neg=-1")
+	t.Setenv("HTTP_CLIENT_RETRIES", "-3")
+	t.Setenv("HTTP_CLIENT_RETRY_BACKOFF", "-1s")
+	cfg := LoadConfig()
+	if len(cfg.ConnLimits) != 2 || cfg.ConnLimits["prom_query"] != 4 || cfg.ConnLimits["http_fetch"] != 2 {
+		t.Errorf("ConnLimits = %v", cfg.ConnLimits)
+	}
+	if cfg.Retries != 0 || cfg.RetryBackoff != 0 {
+		t.Errorf("Retries, RetryBackoff = %d, %v, want 0, 0", cfg.Retries, cfg.RetryBackoff)
+	}
+}
@@ -0,0 +1,215 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	// Register SHA-2 implementations for crypto.Hash.New.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ErrBadSignature is returned when a signature does not match.
+var ErrBadSignature = errors.New("signature mismatch")
+
+// Token is a decoded compact-serialized JWS. Claims are decoded with
+// json.Number for numbers; see NumericDate.
+type Token struct {
+	Header map[string]any
+	Claims map[string]any
+
+	signingInput []byte
+	signature    string
+}
+
+// Parse decodes the header and claims of a compact-serialized token without
+// verifying it.
+func Parse(token string) (*Token, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token: expected 3 segments, got %d", len(parts))
+	}
+
+	t := &Token{signingInput: []byte(parts[0] + "." + parts[1]), signature: parts[2]}
+	if err := decodeSegment(parts[0], &t.Header); err != nil {
+		return nil, fmt.Errorf("malformed header: %w", err)
+	}
+	if err := decodeSegment(parts[1], &t.Claims); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+	return t, nil
+}
+
+// Alg returns the alg header.
+func (t *Token) Alg() string {
+	alg, _ := t.Header["alg"].(string)
+	return alg
+}
+
+// VerifyHMAC verifies an HS256/HS384/HS512 signature with secret.
+func (t *Token) VerifyHMAC(secret []byte) error {
+	alg, sig, err := t.prepare()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(alg, "HS") {
+		return fmt.Errorf("secret verification requires an HS* algorithm, token uses %s", alg)
+	}
+	h, err := hashFor(alg)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(h.New, secret)
+	mac.Write(t.signingInput)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// VerifyJWKS verifies an RS*, PS*, ES*, or EdDSA signature against the
+// keys in set matching the kid and alg headers.
+func (t *Token) VerifyJWKS(set *JWKSet) error {
+	alg, sig, err := t.prepare()
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(alg, "HS") {
+		return fmt.Errorf("%s tokens require a shared secret, not a JWKS", alg)
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	keys := set.Candidates(kid, alg)
+	if len(keys) == 0 {
+		return fmt.Errorf("no JWKS key matches kid %q and alg %s", kid, alg)
+	}
+	var lastErr error
+	for _, k := range keys {
+		pub, err := k.PublicKey()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if lastErr = verifyAsymmetric(alg, t.signingInput, sig, pub); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// prepare rejects unsigned tokens and decodes the signature.
+func (t *Token) prepare() (string, []byte, error) {
+	alg := t.Alg()
+	if alg == "" || strings.EqualFold(alg, "none") {
+		return "", nil, fmt.Errorf("unsigned tokens cannot be verified")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(t.signature)
+	if err != nil {
+		return "", nil, fmt.Errorf("malformed signature: %w", err)
+	}
+	return alg, sig, nil
+}
+
+// verifyAsymmetric verifies an RS*, PS*, ES*, or EdDSA signature.
+func verifyAsymmetric(alg string, signingInput, sig []byte, pub crypto.PublicKey) error {
+	if alg == "EdDSA" {
+		key, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("EdDSA requires an Ed25519 key")
+		}
+		if !ed25519.Verify(key, signingInput, sig) {
+			return ErrBadSignature
+		}
+		return nil
+	}
+
+	h, err := hashFor(alg)
+	if err != nil {
+		return err
+	}
+	hasher := h.New()
+	hasher.Write(signingInput)
+	digest := hasher.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		key, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an RSA key", alg)
+		}
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(key, h, digest, sig)
+		} else {
+			err = rsa.VerifyPSS(key, h, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return ErrBadSignature
+		}
+		return nil
+	case "ES":
+		key, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an EC key", alg)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return ErrBadSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return ErrBadSignature
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported algorithm %s", alg)
+	}
+}
+
+// hashFor returns the hash function for the bit size suffix of alg.
+func hashFor(alg string) (crypto.Hash, error) {
+	switch {
+	case strings.HasSuffix(alg, "256"):
+		return crypto.SHA256, nil
+	case strings.HasSuffix(alg, "384"):
+		return crypto.SHA384, nil
+	case strings.HasSuffix(alg, "512"):
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported algorithm %s", alg)
+	}
+}
+
+// decodeSegment base64url-decodes a token segment into v.
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// NumericDate converts a JWT NumericDate claim into a time.
+func NumericDate(v any) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)).UTC(), true
+}
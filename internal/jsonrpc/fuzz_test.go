@@ -0,0 +1,78 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var seeds = []string{
+	`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hash","arguments":{"data":"x"}}}`,
+	`{"params":{"name":"hash"},"method":"tools/call","id":"a"}`,
+	`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+	`{"jsonrpc":"2.0","id":1,"result":{"tools":[]}}`,
+	`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"x"}}`,
+	`[{"jsonrpc":"2.0","id":1,"method":"ping"}]`,
+	`{"jsonrpc":"2.0","id":1,"method":"ping","method":"x"}`,
+	`{"id":1e3,"method":"ping"}`,
+	`{"jsonrpc":"2.0","method":"tools/call","params":{"name":{}}}`,
+	`{"jsonrpc":"2.0","method":"tools/call","params":{"name":"","":{}}}`,
+	`{"method":`,
+	`[]`,
+	``,
+}
+
+// FuzzParse checks that Parse never panics, and that what it accepts the
+// lenient decoder reads the same way.
+func FuzzParse(f *testing.F) {
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		msgs, batch, err := Parse(data)
+		if err != nil {
+			if msgs != nil {
+				t.Fatalf("Parse returned messages with error %v", err)
+			}
+			return
+		}
+		if len(msgs) == 0 || batch != bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+			t.Fatalf("Parse accepted %q as %d messages, batch %v", data, len(msgs), batch)
+		}
+		if batch {
+			return
+		}
+		var lenient struct {
+			Method string          `json:"method"`
+			ID     json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(data, &lenient); err != nil {
+			t.Fatalf("Parse accepted %q, which encoding/json rejects: %v", data, err)
+		}
+		if lenient.Method != msgs[0].Method {
+			t.Fatalf("method %q, lenient decoder read %q", msgs[0].Method, lenient.Method)
+		}
+	})
+}
+
+// FuzzPeek checks that Peek never panics, and that it agrees with Parse on
+// the requests Parse accepts.
+func FuzzPeek(f *testing.F) {
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, body string) {
+		call, err := Peek(strings.NewReader(body))
+		msgs, batch, perr := Parse([]byte(body))
+		if perr != nil || batch {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Peek(%q) error = %v, but Parse accepts it", body, err)
+		}
+		if call.Method != msgs[0].Method || call.Tool != msgs[0].Tool() {
+			t.Fatalf("Peek(%q) = %+v, Parse read method %q tool %q", body, call, msgs[0].Method, msgs[0].Tool())
+		}
+	})
+}
@@ -0,0 +1,298 @@
+// Package jsonrpc parses JSON-RPC 2.0 messages strictly. Where the MCP SDK
+// decodes leniently — the last of duplicate members wins, unknown members
+// are ignored — this package rejects anything a peer could use to make two
+// readers of one message disagree: duplicate members at any depth, members
+// of the wrong type, oversized ids, and excessive nesting.
+//
+// Parse and ParseMessage check whole messages. Peek reads only as far as
+// the method and tool name of a request, for middleware that must not
+// buffer large bodies, and checks what it reads.
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Version is the only JSON-RPC version accepted.
+const Version = "2.0"
+
+// Limits on parsed messages.
+const (
+	// MaxIDLength is the longest id accepted, in bytes of a string id or
+	// digits of a numeric one.
+	MaxIDLength = 128
+	// MaxDepth is the deepest nesting of objects and arrays accepted.
+	MaxDepth = 64
+	// MaxBatch is the most messages accepted in a batch.
+	MaxBatch = 1000
+)
+
+// Parse errors. Errors returned by this package wrap one of them.
+var (
+	ErrSyntax        = errors.New("invalid JSON")
+	ErrDuplicate     = errors.New("duplicate member")
+	ErrType          = errors.New("member has the wrong type")
+	ErrVersion       = errors.New(`jsonrpc must be "2.0"`)
+	ErrIDTooLong     = errors.New("id too long")
+	ErrTooDeep       = errors.New("nesting too deep")
+	ErrUnknown       = errors.New("unknown member")
+	ErrInvalid       = errors.New("not a request, notification, or response")
+	ErrBatch         = errors.New("invalid batch")
+	ErrTrailingInput = errors.New("data after the message")
+)
+
+// Message is a request, notification, or response.
+type Message struct {
+	// ID is the raw id, or nil for notifications.
+	ID     json.RawMessage
+	Method string
+	Params json.RawMessage
+	Result json.RawMessage
+	Error  json.RawMessage
+}
+
+// IsNotification reports whether m is a request without an id.
+func (m *Message) IsNotification() bool { return m.Method != "" && m.ID == nil }
+
+// IsResponse reports whether m answers a request.
+func (m *Message) IsResponse() bool { return m.Method == "" }
+
+// Tool returns the tool name of a tools/call request.
+func (m *Message) Tool() string {
+	if m.Method != "tools/call" {
+		return ""
+	}
+	var p struct {
+		Name string `json:"name"`
+	}
+	_ = json.Unmarshal(m.Params, &p)
+	return p.Name
+}
+
+// Parse parses a message or a batch of them. batch reports whether data
+// was a batch, even an invalid one.
+func Parse(data []byte) (msgs []Message, batch bool, err error) {
+	data = bytes.TrimSpace(data)
+	batch = bytes.HasPrefix(data, []byte("["))
+	if err := validate(data); err != nil {
+		return nil, batch, err
+	}
+	if !batch {
+		m, err := decode(data)
+		if err != nil {
+			return nil, false, err
+		}
+		return []Message{*m}, false, nil
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, true, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+	switch {
+	case len(raw) == 0:
+		return nil, true, fmt.Errorf("%w: empty", ErrBatch)
+	case len(raw) > MaxBatch:
+		return nil, true, fmt.Errorf("%w: more than %d messages", ErrBatch, MaxBatch)
+	}
+	msgs = make([]Message, len(raw))
+	for i, r := range raw {
+		m, err := decode(r)
+		if err != nil {
+			return nil, true, fmt.Errorf("message %d: %w", i, err)
+		}
+		msgs[i] = *m
+	}
+	return msgs, true, nil
+}
+
+// ParseMessage parses a single message.
+func ParseMessage(data []byte) (*Message, error) {
+	msgs, batch, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if batch {
+		return nil, fmt.Errorf("%w: batch where a message was expected", ErrType)
+	}
+	return &msgs[0], nil
+}
+
+// validate checks that data is one JSON value, without duplicate members
+// or nesting beyond MaxDepth.
+func validate(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := walk(dec, 0); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return ErrTrailingInput
+	}
+	return nil
+}
+
+// walk reads one value from dec, checking objects for duplicate members.
+func walk(dec *json.Decoder, depth int) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return syntaxError(err)
+	}
+	return walkRest(dec, tok, depth)
+}
+
+// walkRest reads the rest of the value starting with tok.
+func walkRest(dec *json.Decoder, tok json.Token, depth int) error {
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+	if depth++; depth > MaxDepth {
+		return fmt.Errorf("%w: more than %d levels", ErrTooDeep, MaxDepth)
+	}
+	var seen map[string]struct{}
+	if delim == '{' {
+		seen = make(map[string]struct{})
+	}
+	for dec.More() {
+		if seen != nil {
+			key, err := dec.Token()
+			if err != nil {
+				return syntaxError(err)
+			}
+			name, _ := key.(string)
+			if _, dup := seen[name]; dup {
+				return fmt.Errorf("%w: %q", ErrDuplicate, name)
+			}
+			seen[name] = struct{}{}
+		}
+		if err := walk(dec, depth); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return syntaxError(err)
+	}
+	return nil
+}
+
+func syntaxError(err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("%w: %v", ErrSyntax, err)
+}
+
+// decode checks the members of a message that validate has accepted.
+func decode(data []byte) (*Message, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, fmt.Errorf("%w: message must be an object", ErrType)
+	}
+	m := &Message{}
+	var version string
+	for name, raw := range members {
+		var err error
+		switch name {
+		case "jsonrpc":
+			err = decodeString(name, raw, &version)
+		case "method":
+			err = decodeString(name, raw, &m.Method)
+			if err == nil && m.Method == "" {
+				err = fmt.Errorf("%w: method is empty", ErrType)
+			}
+		case "id":
+			m.ID, err = checkID(raw)
+		case "params":
+			if c := firstByte(raw); c != '{' && c != '[' {
+				err = fmt.Errorf("%w: params must be an object or array", ErrType)
+			}
+			m.Params = raw
+		case "result":
+			m.Result = raw
+		case "error":
+			if firstByte(raw) != '{' {
+				err = fmt.Errorf("%w: error must be an object", ErrType)
+			}
+			m.Error = raw
+		default:
+			err = fmt.Errorf("%w: %q", ErrUnknown, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if version != Version {
+		return nil, ErrVersion
+	}
+
+	if m.Method != "" {
+		if m.Result != nil || m.Error != nil {
+			return nil, fmt.Errorf("%w: request with a result or error", ErrInvalid)
+		}
+		if m.Method == "tools/call" && firstByte(m.Params) == '{' {
+			var p struct {
+				Name json.RawMessage `json:"name"`
+			}
+			_ = json.Unmarshal(m.Params, &p)
+			if p.Name != nil && firstByte(p.Name) != '"' {
+				return nil, fmt.Errorf("%w: params.name must be a string", ErrType)
+			}
+		}
+		return m, nil
+	}
+	switch {
+	case m.Params != nil:
+		return nil, fmt.Errorf("%w: params without a method", ErrInvalid)
+	case (m.Result == nil) == (m.Error == nil):
+		return nil, fmt.Errorf("%w: response needs exactly one of result and error", ErrInvalid)
+	case m.ID == nil:
+		return nil, fmt.Errorf("%w: response without an id", ErrInvalid)
+	}
+	return m, nil
+}
+
+func decodeString(name string, raw json.RawMessage, dst *string) error {
+	if err := json.Unmarshal(raw, dst); err != nil || firstByte(raw) != '"' {
+		return fmt.Errorf("%w: %s must be a string", ErrType, name)
+	}
+	return nil
+}
+
+// checkID accepts a string, an integer, or null within MaxIDLength.
+func checkID(raw json.RawMessage) (json.RawMessage, error) {
+	switch c := firstByte(raw); {
+	case c == '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+		}
+		if len(s) > MaxIDLength {
+			return nil, fmt.Errorf("%w: %d bytes", ErrIDTooLong, len(s))
+		}
+	case c == '-' || c >= '0' && c <= '9':
+		digits := strings.TrimPrefix(string(raw), "-")
+		if strings.ContainsAny(digits, ".eE") {
+			return nil, fmt.Errorf("%w: id must be an integer", ErrType)
+		}
+		if len(digits) > MaxIDLength {
+			return nil, fmt.Errorf("%w: %d digits", ErrIDTooLong, len(digits))
+		}
+	case string(raw) == "null":
+	default:
+		return nil, fmt.Errorf("%w: id must be a string, integer, or null", ErrType)
+	}
+	return raw, nil
+}
+
+func firstByte(raw json.RawMessage) byte {
+	if len(raw) == 0 {
+		return 0
+	}
+	return raw[0]
+}
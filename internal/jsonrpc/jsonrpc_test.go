@@ -0,0 +1,135 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	// params sits one level below the message
+	nested := func(n int) string { return strings.Repeat("[", n) + strings.Repeat("]", n) }
+	tests := []struct {
+		name      string
+		body      string
+		wantMsgs  int
+		wantBatch bool
+		wantErr   error
+	}{
+		{name: "request", body: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hash"}}`, wantMsgs: 1},
+		{name: "notification", body: `{"jsonrpc":"2.0","method":"notifications/initialized"}`, wantMsgs: 1},
+		{name: "response", body: `{"jsonrpc":"2.0","id":"a","result":{}}`, wantMsgs: 1},
+		{name: "error response", body: `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`, wantMsgs: 1},
+		{name: "batch", body: ` [{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/cancelled","params":{}}]`, wantMsgs: 2, wantBatch: true},
+		{name: "nesting at the limit", body: `{"jsonrpc":"2.0","id":1,"method":"x","params":` + nested(MaxDepth-1) + `}`, wantMsgs: 1},
+
+		{name: "duplicate member", body: `{"jsonrpc":"2.0","id":1,"method":"ping","method":"tools/call"}`, wantErr: ErrDuplicate},
+		{name: "nested duplicate", body: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hash","arguments":{"a":1,"a":2}}}`, wantErr: ErrDuplicate},
+		{name: "duplicate in batch", body: `[{"jsonrpc":"2.0","id":1,"id":2,"method":"ping"}]`, wantBatch: true, wantErr: ErrDuplicate},
+		{name: "numeric method", body: `{"jsonrpc":"2.0","id":1,"method":1}`, wantErr: ErrType},
+		{name: "empty method", body: `{"jsonrpc":"2.0","id":1,"method":""}`, wantErr: ErrType},
+		{name: "object id", body: `{"jsonrpc":"2.0","id":{},"method":"ping"}`, wantErr: ErrType},
+		{name: "fractional id", body: `{"jsonrpc":"2.0","id":1.5,"method":"ping"}`, wantErr: ErrType},
+		{name: "object tool name", body: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":{}}}`, wantErr: ErrType},
+		{name: "string params", body: `{"jsonrpc":"2.0","id":1,"method":"ping","params":"x"}`, wantErr: ErrType},
+		{name: "long string id", body: `{"jsonrpc":"2.0","id":"` + strings.Repeat("a", MaxIDLength+1) + `","method":"ping"}`, wantErr: ErrIDTooLong},
+		{name: "long numeric id", body: `{"jsonrpc":"2.0","id":` + strings.Repeat("9", MaxIDLength+1) + `,"method":"ping"}`, wantErr: ErrIDTooLong},
+		{name: "missing version", body: `{"id":1,"method":"ping"}`, wantErr: ErrVersion},
+		{name: "wrong version", body: `{"jsonrpc":"1.0","id":1,"method":"ping"}`, wantErr: ErrVersion},
+		{name: "unknown member", body: `{"jsonrpc":"2.0","id":1,"method":"ping","extra":true}`, wantErr: ErrUnknown},
+		{name: "request with result", body: `{"jsonrpc":"2.0","id":1,"method":"ping","result":{}}`, wantErr: ErrInvalid},
+		{name: "response without id", body: `{"jsonrpc":"2.0","result":{}}`, wantErr: ErrInvalid},
+		{name: "result and error", body: `{"jsonrpc":"2.0","id":1,"result":{},"error":{}}`, wantErr: ErrInvalid},
+		{name: "too deep", body: `{"jsonrpc":"2.0","id":1,"method":"x","params":` + nested(MaxDepth) + `}`, wantErr: ErrTooDeep},
+		{name: "empty batch", body: `[]`, wantBatch: true, wantErr: ErrBatch},
+		{name: "trailing data", body: `{"jsonrpc":"2.0","method":"ping"} {}`, wantErr: ErrTrailingInput},
+		{name: "truncated", body: `{"jsonrpc":"2.0","method":`, wantErr: ErrSyntax},
+		{name: "not an object", body: `"ping"`, wantErr: ErrType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs, batch, err := Parse([]byte(tt.body))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(msgs) != tt.wantMsgs || batch != tt.wantBatch {
+				t.Errorf("Parse() = %d messages, batch %v; want %d, batch %v", len(msgs), batch, tt.wantMsgs, tt.wantBatch)
+			}
+		})
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	m, err := ParseMessage([]byte(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"hash","arguments":{}}}`))
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	if string(m.ID) != "7" || m.Tool() != "hash" || m.IsNotification() || m.IsResponse() {
+		t.Errorf("message = %+v, tool %q", m, m.Tool())
+	}
+	if _, err := ParseMessage([]byte(`[{"jsonrpc":"2.0","method":"ping"}]`)); !errors.Is(err, ErrType) {
+		t.Errorf("ParseMessage(batch) error = %v, want ErrType", err)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    Call
+		wantErr error
+	}{
+		{name: "tool call", body: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hash"}}`, want: Call{Method: "tools/call", Tool: "hash"}},
+		{name: "params first", body: `{"params":{"arguments":{"a":[1]},"name":"hash"},"method":"tools/call"}`, want: Call{Method: "tools/call", Tool: "hash"}},
+		{name: "stops after the tool name", body: `{"method":"tools/call","params":{"name":"hash","arguments":` + strings.Repeat("[", 10*MaxDepth), want: Call{Method: "tools/call", Tool: "hash"}},
+		{name: "response", body: `{"jsonrpc":"2.0","id":1,"result":{}}`, want: Call{}},
+		{name: "batch", body: `[{}]`, want: Call{Batch: true}},
+		{name: "duplicate id", body: `{"id":1,"id":2,"method":"ping"}`, wantErr: ErrDuplicate},
+		{name: "duplicate tool name", body: `{"params":{"name":"a","name":"b"},"method":"tools/call"}`, wantErr: ErrDuplicate},
+		{name: "error after method", body: `{"method":"tools/call","params":{"name":7}}`, want: Call{Method: "tools/call"}, wantErr: ErrType},
+		{name: "long id", body: `{"id":"` + strings.Repeat("a", MaxIDLength+1) + `"}`, wantErr: ErrIDTooLong},
+		{name: "wrong version", body: `{"jsonrpc":2}`, wantErr: ErrType},
+		{name: "unknown member", body: `{"extra":1,"method":"ping"}`, wantErr: ErrUnknown},
+		{name: "too deep", body: `{"result":` + strings.Repeat("[", MaxDepth+1), wantErr: ErrTooDeep},
+		{name: "truncated", body: `{"method"`, wantErr: ErrSyntax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Peek(strings.NewReader(tt.body))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Peek() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Peek() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkParse compares the full duplicate-checking parse with Peek, which
+// stops at the tool name, as arguments grow.
+func BenchmarkParse(b *testing.B) {
+	for _, size := range []int{64, 4 << 10, 256 << 10} {
+		body := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"text","arguments":{"text":"` + strings.Repeat("x", size) + `"}}}`)
+		b.Run(fmt.Sprintf("Parse/size=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			for b.Loop() {
+				if _, _, err := Parse(body); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Peek/size=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			for b.Loop() {
+				if _, err := Peek(bytes.NewReader(body)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Call identifies the request at the start of a body.
+type Call struct {
+	// Method is the JSON-RPC method, or "" if it was not reached.
+	Method string
+	// Tool is the tool name of a tools/call request.
+	Tool string
+	// Batch reports that the body is a batch, which Peek does not read.
+	Batch bool
+}
+
+// Peek reads a request from r only as far as its method and, for
+// tools/call, the tool name, so that large arguments are not read. It
+// applies the checks of Parse to what it reads, but cannot see duplicates
+// or errors past the point where it stops. On error the Call holds what
+// was identified before it.
+func Peek(r io.Reader) (Call, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	switch tok, err := dec.Token(); {
+	case err != nil:
+		return Call{}, syntaxError(err)
+	case tok == json.Delim('['):
+		return Call{Batch: true}, nil
+	case tok != json.Delim('{'):
+		return Call{}, fmt.Errorf("%w: message must be an object", ErrType)
+	}
+
+	var call Call
+	var tool string
+	seen := make(map[string]struct{}, 4)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return call, syntaxError(err)
+		}
+		key, _ := tok.(string)
+		if _, dup := seen[key]; dup {
+			return call, fmt.Errorf("%w: %q", ErrDuplicate, key)
+		}
+		seen[key] = struct{}{}
+
+		switch key {
+		case "jsonrpc":
+			if v, err := stringToken(dec, key); err != nil {
+				return call, err
+			} else if v != Version {
+				return call, ErrVersion
+			}
+		case "method":
+			if call.Method, err = stringToken(dec, key); err != nil {
+				return call, err
+			}
+			if call.Method == "" {
+				return call, fmt.Errorf("%w: method is empty", ErrType)
+			}
+		case "id":
+			if err := peekID(dec); err != nil {
+				return call, err
+			}
+		case "params":
+			// params may precede method, so the name is kept either way
+			if tool, err = peekToolName(dec, call.Method != ""); err != nil {
+				return call, err
+			}
+		case "result", "error":
+			// Clients post responses to the server's requests too
+			if err := walk(dec, 1); err != nil {
+				return call, err
+			}
+		default:
+			return call, fmt.Errorf("%w: %q", ErrUnknown, key)
+		}
+		// Once params has been read past the name, the rest of the body
+		// is left unread
+		if _, params := seen["params"]; call.Method != "" && (call.Method != "tools/call" || params) {
+			break
+		}
+	}
+	if call.Method == "tools/call" {
+		call.Tool = tool
+	}
+	return call, nil
+}
+
+// stringToken reads the string value of member name.
+func stringToken(dec *json.Decoder, name string) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", syntaxError(err)
+	}
+	s, ok := tok.(string)
+	if !ok {
+		if err := walkRest(dec, tok, 1); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: %s must be a string", ErrType, name)
+	}
+	return s, nil
+}
+
+// peekID reads and checks an id.
+func peekID(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return syntaxError(err)
+	}
+	switch v := tok.(type) {
+	case string:
+		if len(v) > MaxIDLength {
+			return fmt.Errorf("%w: %d bytes", ErrIDTooLong, len(v))
+		}
+	case json.Number:
+		digits := strings.TrimPrefix(string(v), "-")
+		if strings.ContainsAny(digits, ".eE") {
+			return fmt.Errorf("%w: id must be an integer", ErrType)
+		}
+		if len(digits) > MaxIDLength {
+			return fmt.Errorf("%w: %d digits", ErrIDTooLong, len(digits))
+		}
+	case nil:
+	default:
+		_ = walkRest(dec, tok, 1)
+		return fmt.Errorf("%w: id must be a string, integer, or null", ErrType)
+	}
+	return nil
+}
+
+// peekToolName reads params and returns its "name" member, if it is an
+// object that has one. With last set nothing more will be read, so it
+// stops at the name instead of reading past the rest of params.
+func peekToolName(dec *json.Decoder, last bool) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", syntaxError(err)
+	}
+	switch tok {
+	case json.Delim('{'):
+	case json.Delim('['):
+		return "", walkRest(dec, tok, 1)
+	default:
+		return "", fmt.Errorf("%w: params must be an object or array", ErrType)
+	}
+
+	var name string
+	seen := make(map[string]struct{}, 2)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", syntaxError(err)
+		}
+		key, _ := tok.(string)
+		if _, dup := seen[key]; dup {
+			return "", fmt.Errorf("%w: params %q", ErrDuplicate, key)
+		}
+		seen[key] = struct{}{}
+		if key != "name" {
+			if err := walk(dec, 2); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if name, err = stringToken(dec, "params.name"); err != nil {
+			return "", err
+		}
+		if last {
+			return name, nil
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return "", syntaxError(err)
+	}
+	return name, nil
+}
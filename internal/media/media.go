@@ -0,0 +1,430 @@
+// Package media enforces limits on the binary content of tool results:
+// images, audio, and embedded blobs. Blocks of a type that is not allowed
+// are removed. Blocks over the size limit are removed too, or offloaded:
+// kept for a while behind a signed URL on the HTTP server, with a
+// resource_link to it in their place. Trace logs the size of each result.
+package media
+
+import (
+	"container/list"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+// Path is the HTTP path under which offloaded content is served.
+const Path = "/blobs/"
+
+// minKeyBytes is the shortest signing key accepted.
+const minKeyBytes = 16
+
+// Config sets which binary content tools may return inline.
+type Config struct {
+	// MaxBytes is the largest binary block sent inline, before base64
+	// encoding; zero is unlimited.
+	MaxBytes int
+	// MIMETypes are the allowed media types, such as image/png or image/*;
+	// empty allows any.
+	MIMETypes []string
+	// OffloadURL is the base URL clients reach the server at. When set,
+	// blocks over MaxBytes are offloaded instead of removed.
+	OffloadURL string
+	// URLTTL is how long offloaded content stays readable.
+	URLTTL time.Duration
+	// SigningKey signs offload URLs. When empty, a random key is made at
+	// startup, so URLs do not survive a restart.
+	SigningKey string
+	// OffloadMaxBytes caps the offloaded content held at once; the oldest
+	// is dropped to make room.
+	OffloadMaxBytes int
+}
+
+// LoadConfig reads the TOOL_BINARY_* settings.
+func LoadConfig() Config {
+	return Config{
+		MaxBytes:        config.GetEnvInt("TOOL_BINARY_MAX_BYTES", 0),
+		MIMETypes:       config.GetEnvList("TOOL_BINARY_MIME_TYPES"),
+		OffloadURL:      config.GetEnv("TOOL_BINARY_OFFLOAD_URL", ""),
+		URLTTL:          config.GetEnvDuration("TOOL_BINARY_URL_TTL", 15*time.Minute),
+		SigningKey:      config.GetEnv("TOOL_BINARY_SIGNING_KEY", ""),
+		OffloadMaxBytes: config.GetEnvInt("TOOL_BINARY_OFFLOAD_MAX_BYTES", 64<<20),
+	}
+}
+
+// Policy enforces Config on tools/call results and serves offloaded
+// content.
+type Policy struct {
+	cfg    Config
+	types  []string
+	base   string
+	key    []byte
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu    sync.Mutex
+	blobs map[string]*list.Element
+	order *list.List // of *blob, oldest first
+	held  int
+}
+
+// blob is offloaded content.
+type blob struct {
+	id       string
+	mimeType string
+	data     []byte
+	expires  time.Time
+}
+
+// New creates a Policy, or returns nil if cfg sets no limit.
+func New(cfg Config, logger *slog.Logger) (*Policy, error) {
+	if cfg.MaxBytes <= 0 && len(cfg.MIMETypes) == 0 {
+		if cfg.OffloadURL != "" {
+			return nil, fmt.Errorf("TOOL_BINARY_OFFLOAD_URL needs TOOL_BINARY_MAX_BYTES")
+		}
+		return nil, nil
+	}
+	p := &Policy{
+		cfg:    cfg,
+		logger: logger,
+		now:    time.Now,
+		blobs:  make(map[string]*list.Element),
+		order:  list.New(),
+	}
+	for _, t := range cfg.MIMETypes {
+		t = normalize(t)
+		if kind, sub, ok := strings.Cut(t, "/"); !ok || kind == "" || sub == "" {
+			return nil, fmt.Errorf("invalid TOOL_BINARY_MIME_TYPES entry %q: use type/subtype or type/*", t)
+		}
+		p.types = append(p.types, t)
+	}
+	if cfg.OffloadURL == "" {
+		return p, nil
+	}
+
+	u, err := url.Parse(cfg.OffloadURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("TOOL_BINARY_OFFLOAD_URL must be an absolute http or https URL")
+	}
+	if cfg.MaxBytes <= 0 || cfg.URLTTL <= 0 || cfg.OffloadMaxBytes <= 0 {
+		return nil, fmt.Errorf("TOOL_BINARY_MAX_BYTES, TOOL_BINARY_URL_TTL, and TOOL_BINARY_OFFLOAD_MAX_BYTES must be positive to offload")
+	}
+	p.base = strings.TrimSuffix(cfg.OffloadURL, "/")
+	switch {
+	case cfg.SigningKey == "":
+		p.key = make([]byte, 32)
+		_, _ = rand.Read(p.key)
+	case len(cfg.SigningKey) < minKeyBytes:
+		return nil, fmt.Errorf("TOOL_BINARY_SIGNING_KEY must be at least %d bytes", minKeyBytes)
+	default:
+		p.key = []byte(cfg.SigningKey)
+	}
+	return p, nil
+}
+
+// Offloading reports whether content over the limit is offloaded, and so
+// whether Handler must be served.
+func (p *Policy) Offloading() bool {
+	return p.base != ""
+}
+
+// Middleware enforces the policy on each tools/call result.
+func (p *Policy) Middleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		result, err := next(ctx, method, req)
+		res, ok := result.(*mcp.CallToolResult)
+		if method != "tools/call" || !ok || res == nil {
+			return result, err
+		}
+		out, removed, offloaded := p.apply(res)
+		if removed+offloaded > 0 {
+			p.logger.Warn("binary tool content over policy", "tool", toolName(req), "removed", removed, "offloaded", offloaded)
+		}
+		return out, err
+	}
+}
+
+// apply returns res with the policy applied to its content blocks, and
+// how many were removed and offloaded.
+func (p *Policy) apply(res *mcp.CallToolResult) (*mcp.CallToolResult, int, int) {
+	var content []mcp.Content
+	removed, offloaded := 0, 0
+	for i, c := range res.Content {
+		kind, mimeType, data, ok := binary(c)
+		var replacement mcp.Content
+		switch {
+		case !ok:
+		case !p.allowed(mimeType):
+			replacement = &mcp.TextContent{Text: fmt.Sprintf("[%s content of type %s removed: the type is not allowed]", kind, mimeType)}
+			removed++
+		case p.cfg.MaxBytes > 0 && len(data) > p.cfg.MaxBytes:
+			if link := p.offload(kind, mimeType, data); link != nil {
+				replacement = link
+				offloaded++
+				break
+			}
+			replacement = &mcp.TextContent{Text: fmt.Sprintf("[%s content of %d bytes removed: exceeds the %d byte limit]", kind, len(data), p.cfg.MaxBytes)}
+			removed++
+		}
+		if replacement == nil {
+			if content != nil {
+				content = append(content, c)
+			}
+			continue
+		}
+		if content == nil {
+			content = append(make([]mcp.Content, 0, len(res.Content)), res.Content[:i]...)
+		}
+		content = append(content, replacement)
+	}
+	if content == nil {
+		return res, 0, 0
+	}
+	out := *res
+	out.Content = content
+	return &out, removed, offloaded
+}
+
+// allowed reports whether mimeType matches one of the allowed types.
+func (p *Policy) allowed(mimeType string) bool {
+	if len(p.types) == 0 {
+		return true
+	}
+	mt := normalize(mimeType)
+	for _, t := range p.types {
+		if t == mt || t == "*/*" || strings.HasSuffix(t, "/*") && strings.HasPrefix(mt, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// offload keeps data and returns a link to its signed URL, or nil if
+// offloading is off or data alone is over the cap.
+func (p *Policy) offload(kind, mimeType string, data []byte) *mcp.ResourceLink {
+	if !p.Offloading() || len(data) > p.cfg.OffloadMaxBytes {
+		return nil
+	}
+	b := &blob{mimeType: mimeType, data: data, expires: p.now().Add(p.cfg.URLTTL)}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	b.id = hex.EncodeToString(id)
+
+	p.mu.Lock()
+	p.expire()
+	for p.held+len(data) > p.cfg.OffloadMaxBytes {
+		p.remove(p.order.Front())
+	}
+	p.blobs[b.id] = p.order.PushBack(b)
+	p.held += len(data)
+	p.mu.Unlock()
+
+	expires := b.expires.Unix()
+	size := int64(len(data))
+	return &mcp.ResourceLink{
+		URI:         p.base + Path + b.id + "?expires=" + strconv.FormatInt(expires, 10) + "&sig=" + p.sign(b.id, expires),
+		Name:        "tool-" + kind,
+		Description: fmt.Sprintf("%s content over the %d byte inline limit, readable until %s", kind, p.cfg.MaxBytes, b.expires.UTC().Format(time.RFC3339)),
+		MIMEType:    mimeType,
+		Size:        &size,
+	}
+}
+
+// sign returns the signature of the offload URL of id expiring at expires.
+func (p *Policy) sign(id string, expires int64) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(id + "." + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handler serves offloaded content at Path to requests with a valid
+// signature. The signature is the only credential, so Path must not sit
+// behind the /mcp authentication.
+func (p *Policy) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, Path)
+		expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+		sig, _ := hex.DecodeString(r.URL.Query().Get("sig"))
+		want, _ := hex.DecodeString(p.sign(id, expires))
+		if err != nil || !hmac.Equal(sig, want) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+		now := p.now()
+		if now.Unix() >= expires {
+			http.Error(w, "link expired", http.StatusGone)
+			return
+		}
+
+		p.mu.Lock()
+		p.expire()
+		var b *blob
+		if e, ok := p.blobs[id]; ok {
+			b = e.Value.(*blob)
+		}
+		p.mu.Unlock()
+		if b == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		// The content comes from tools, so it must not run as a page
+		h := w.Header()
+		h.Set("Content-Type", b.mimeType)
+		h.Set("Content-Length", strconv.Itoa(len(b.data)))
+		h.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(b.expires.Sub(now).Seconds())))
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Content-Security-Policy", "default-src 'none'; sandbox")
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(b.data)
+		}
+	})
+}
+
+// expire drops offloaded content past its TTL. p.mu must be held.
+func (p *Policy) expire() {
+	now := p.now()
+	for e := p.order.Front(); e != nil && !now.Before(e.Value.(*blob).expires); e = p.order.Front() {
+		p.remove(e)
+	}
+}
+
+func (p *Policy) remove(e *list.Element) {
+	b := p.order.Remove(e).(*blob)
+	delete(p.blobs, b.id)
+	p.held -= len(b.data)
+}
+
+// Trace returns middleware that logs the content sizes of each tools/call
+// result with the W3C trace ID of its HTTP request, so the record can be
+// joined with the "mcp request" record of MCP_TRACING. Sizes are of the
+// content as sent, so it must be added after Middleware. Nothing is
+// measured while logger discards info records.
+func Trace(logger *slog.Logger) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != "tools/call" || !logger.Enabled(ctx, slog.LevelInfo) {
+				return next(ctx, method, req)
+			}
+			result, err := next(ctx, method, req)
+			res, ok := result.(*mcp.CallToolResult)
+			if !ok || res == nil {
+				return result, err
+			}
+			traceID := ""
+			if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+				traceID = middleware.TraceIDFromHeader(extra.Header)
+			}
+			s := measure(res)
+			logger.LogAttrs(ctx, slog.LevelInfo, "tool content",
+				slog.String("trace_id", traceID),
+				slog.String("tool", toolName(req)),
+				slog.Int("content_blocks", len(res.Content)),
+				slog.Int("text_bytes", s.text),
+				slog.Int("binary_bytes", s.binary),
+				slog.Int("binary_blocks", s.binaryBlocks),
+				slog.Int("links", s.links),
+				slog.Int("structured_bytes", s.structured),
+			)
+			return result, err
+		}
+	}
+}
+
+// sizes are the sizes of a result's content.
+type sizes struct {
+	text, binary, binaryBlocks, links, structured int
+}
+
+func measure(res *mcp.CallToolResult) sizes {
+	var s sizes
+	for _, c := range res.Content {
+		if _, _, data, ok := binary(c); ok {
+			s.binary += len(data)
+			s.binaryBlocks++
+			continue
+		}
+		switch c := c.(type) {
+		case *mcp.TextContent:
+			s.text += len(c.Text)
+		case *mcp.EmbeddedResource:
+			if c.Resource != nil {
+				s.text += len(c.Resource.Text)
+			}
+		case *mcp.ResourceLink:
+			s.links++
+		}
+	}
+	if res.StructuredContent != nil {
+		// Encoded into a counter rather than a buffer, so large results
+		// cost no allocation; Encode appends a newline
+		var n byteCounter
+		if err := json.NewEncoder(&n).Encode(res.StructuredContent); err == nil {
+			s.structured = int(n) - 1
+		}
+	}
+	return s
+}
+
+// byteCounter counts the bytes written to it.
+type byteCounter int
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// binary returns the kind, media type, and data of a binary block.
+func binary(c mcp.Content) (kind, mimeType string, data []byte, ok bool) {
+	switch c := c.(type) {
+	case *mcp.ImageContent:
+		return "image", orDefault(c.MIMEType), c.Data, true
+	case *mcp.AudioContent:
+		return "audio", orDefault(c.MIMEType), c.Data, true
+	case *mcp.EmbeddedResource:
+		if c.Resource != nil && c.Resource.Blob != nil {
+			return "resource", orDefault(c.Resource.MIMEType), c.Resource.Blob, true
+		}
+	}
+	return "", "", nil, false
+}
+
+func orDefault(mimeType string) string {
+	if mimeType == "" {
+		return "application/octet-stream"
+	}
+	return mimeType
+}
+
+// normalize returns mimeType without parameters, in lower case.
+func normalize(mimeType string) string {
+	mt, _, _ := strings.Cut(mimeType, ";")
+	return strings.ToLower(strings.TrimSpace(mt))
+}
+
+func toolName(req mcp.Request) string {
+	if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok {
+		return params.Name
+	}
+	return ""
+}
@@ -0,0 +1,276 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestNew(t *testing.T) {
+	offload := Config{MaxBytes: 10, OffloadURL: "https://mcp.example.com", URLTTL: time.Minute, OffloadMaxBytes: 100}
+	with := func(f func(*Config)) Config {
+		c := offload
+		f(&c)
+		return c
+	}
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", cfg: Config{}, wantNil: true},
+		{name: "offload without a limit", cfg: Config{OffloadURL: "https://mcp.example.com"}, wantErr: true},
+		{name: "types only", cfg: Config{MIMETypes: []string{"image/*", "Audio/WAV; rate=8000"}}},
+		{name: "bad type", cfg: Config{MIMETypes: []string{"image"}}, wantErr: true},
+		{name: "offload", cfg: offload},
+		{name: "offload with key", cfg: with(func(c *Config) { c.SigningKey = strings.Repeat("k", minKeyBytes) })},
+		{name: "short key", cfg: with(func(c *Config) { c.SigningKey = "short" }), wantErr: true},
+		{name: "relative URL", cfg: with(func(c *Config) { c.OffloadURL = "/blobs" }), wantErr: true},
+		{name: "no room", cfg: with(func(c *Config) { c.OffloadMaxBytes = 0 }), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg, discard)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (p == nil) != tt.wantNil {
+				t.Errorf("New() = %v, want nil %v", p, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	png := bytes.Repeat([]byte{1}, 20)
+	res := &mcp.CallToolResult{Content: []mcp.Content{
+		&mcp.TextContent{Text: "caption"},
+		&mcp.ImageContent{Data: png[:5], MIMEType: "image/png"},
+		&mcp.ImageContent{Data: png, MIMEType: "image/png"},
+		&mcp.AudioContent{Data: png[:5], MIMEType: "audio/wav"},
+		&mcp.EmbeddedResource{Resource: &mcp.ResourceContents{URI: "file:///a", Blob: png[:5]}},
+		&mcp.EmbeddedResource{Resource: &mcp.ResourceContents{URI: "file:///b", MIMEType: "text/plain", Text: strings.Repeat("t", 20)}},
+	}}
+
+	tests := []struct {
+		name          string
+		cfg           Config
+		want          []string
+		wantRemoved   int
+		wantOffloaded int
+	}{
+		{
+			name:        "size limit",
+			cfg:         Config{MaxBytes: 10},
+			want:        []string{"text", "image", "removed: exceeds", "audio", "resource", "resource"},
+			wantRemoved: 1,
+		},
+		{
+			name:        "allowed types",
+			cfg:         Config{MIMETypes: []string{"image/*"}},
+			want:        []string{"text", "image", "image", "removed: the type", "removed: the type", "resource"},
+			wantRemoved: 2,
+		},
+		{
+			name:          "offload",
+			cfg:           Config{MaxBytes: 10, OffloadURL: "https://mcp.example.com/", URLTTL: time.Minute, OffloadMaxBytes: 100},
+			want:          []string{"text", "image", "https://mcp.example.com/blobs/", "audio", "resource", "resource"},
+			wantOffloaded: 1,
+		},
+		{
+			name:        "offload over the cap",
+			cfg:         Config{MaxBytes: 10, OffloadURL: "https://mcp.example.com", URLTTL: time.Minute, OffloadMaxBytes: 15},
+			want:        []string{"text", "image", "removed: exceeds", "audio", "resource", "resource"},
+			wantRemoved: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg, discard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, removed, offloaded := p.apply(res)
+			if removed != tt.wantRemoved || offloaded != tt.wantOffloaded {
+				t.Errorf("removed %d, offloaded %d; want %d, %d", removed, offloaded, tt.wantRemoved, tt.wantOffloaded)
+			}
+			if len(out.Content) != len(tt.want) {
+				t.Fatalf("got %d blocks, want %d", len(out.Content), len(tt.want))
+			}
+			for i, c := range out.Content {
+				if got := describe(c); !strings.Contains(got, tt.want[i]) {
+					t.Errorf("content[%d] = %q, want containing %q", i, got, tt.want[i])
+				}
+			}
+			if describe(res.Content[2]) != "image" {
+				t.Error("apply modified the original result")
+			}
+		})
+	}
+}
+
+// describe returns a block's type, or its text or URI where the policy
+// replaced it.
+func describe(c mcp.Content) string {
+	switch c := c.(type) {
+	case *mcp.TextContent:
+		if strings.HasPrefix(c.Text, "[") {
+			return c.Text
+		}
+		return "text"
+	case *mcp.ImageContent:
+		return "image"
+	case *mcp.AudioContent:
+		return "audio"
+	case *mcp.EmbeddedResource:
+		return "resource"
+	case *mcp.ResourceLink:
+		return c.URI
+	}
+	return "unknown"
+}
+
+func TestHandler(t *testing.T) {
+	p, err := New(Config{MaxBytes: 1, OffloadURL: "https://mcp.example.com", URLTTL: time.Minute, OffloadMaxBytes: 100}, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	p.now = func() time.Time { return now }
+	link := p.offload("image", "image/png", []byte("png!"))
+	u, err := url.Parse(link.URI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := u.RequestURI()
+	tampered := strings.Replace(valid, "expires=", "expires=9", 1)
+	other := p.offload("image", "image/png", []byte("gone"))
+	ou, _ := url.Parse(other.URI)
+	p.mu.Lock()
+	p.remove(p.blobs[strings.TrimPrefix(ou.Path, Path)])
+	p.mu.Unlock()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		later      time.Duration
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "valid", method: http.MethodGet, target: valid, wantStatus: http.StatusOK, wantBody: "png!"},
+		{name: "head", method: http.MethodHead, target: valid, wantStatus: http.StatusOK},
+		{name: "tampered", method: http.MethodGet, target: tampered, wantStatus: http.StatusForbidden},
+		{name: "unsigned", method: http.MethodGet, target: u.Path, wantStatus: http.StatusForbidden},
+		{name: "expired", method: http.MethodGet, target: valid, later: time.Minute, wantStatus: http.StatusGone},
+		{name: "evicted", method: http.MethodGet, target: ou.RequestURI(), wantStatus: http.StatusNotFound},
+		{name: "post", method: http.MethodPost, target: valid, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.now = func() time.Time { return now.Add(tt.later) }
+			rec := httptest.NewRecorder()
+			p.Handler().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if rec.Header().Get("Content-Type") != "image/png" || rec.Header().Get("Content-Length") != "4" {
+				t.Errorf("headers = %v", rec.Header())
+			}
+		})
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	next := func(context.Context, string, mcp.Request) (mcp.Result, error) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "hello"},
+				&mcp.ImageContent{Data: []byte("abc"), MIMEType: "image/png"},
+				&mcp.ResourceLink{URI: "https://example.com/x"},
+			},
+			StructuredContent: map[string]int{"n": 1},
+		}, nil
+	}
+	header := http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "draw"}, Extra: &mcp.RequestExtra{Header: header}}
+	if _, err := Trace(logger)(next)(context.Background(), "tools/call", req); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("log %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"msg":              "tool content",
+		"trace_id":         "4bf92f3577b34da6a3ce929d0e0e4736",
+		"tool":             "draw",
+		"content_blocks":   float64(3),
+		"text_bytes":       float64(5),
+		"binary_bytes":     float64(3),
+		"binary_blocks":    float64(1),
+		"links":            float64(1),
+		"structured_bytes": float64(len(`{"n":1}`)),
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestTrace_Disabled(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	want := &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "hello"}}}
+	next := func(context.Context, string, mcp.Request) (mcp.Result, error) { return want, nil }
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "draw"}}
+	got, err := Trace(logger)(next)(context.Background(), "tools/call", req)
+	if err != nil || got != want || buf.Len() != 0 {
+		t.Errorf("Trace() = %v, %v, log %q; want the result passed through unlogged", got, err, buf.String())
+	}
+}
+
+func BenchmarkTrace(b *testing.B) {
+	structured := make(map[string]string, 100)
+	for i := range 100 {
+		structured[strings.Repeat("k", i+1)] = strings.Repeat("v", 100)
+	}
+	next := func(context.Context, string, mcp.Request) (mcp.Result, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "hello"}}, StructuredContent: structured}, nil
+	}
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "draw"}, Extra: &mcp.RequestExtra{Header: http.Header{}}}
+	for _, level := range []slog.Level{slog.LevelInfo, slog.LevelWarn} {
+		b.Run("level="+level.String(), func(b *testing.B) {
+			handler := Trace(slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: level})))(next)
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := handler(context.Background(), "tools/call", req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
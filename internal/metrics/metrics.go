@@ -0,0 +1,102 @@
+// Package metrics owns the Prometheus registry of the HTTP transport. The
+// server's collectors are registered on a registry created by Setup rather
+// than the global default one, so tests and embedders can build as many
+// servers as they like without duplicate registration panics.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/crashreport"
+	"github.com/lkendrickd/mcp-server/internal/httpclient"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+	"github.com/lkendrickd/mcp-server/internal/session"
+	"github.com/lkendrickd/mcp-server/internal/stdio"
+	"github.com/lkendrickd/mcp-server/internal/tenant"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+	"github.com/lkendrickd/mcp-server/internal/webhook"
+)
+
+// Config selects what the registry exports.
+type Config struct {
+	// Histograms customizes the request duration histogram.
+	Histograms middleware.HistogramConfig
+	// Paths are reported in the path label in addition to
+	// middleware.DefaultMetricsPaths.
+	Paths []string
+	// GoCollector exports Go runtime metrics (go_*).
+	GoCollector bool
+	// ProcessCollector exports process metrics (process_*).
+	ProcessCollector bool
+}
+
+// LoadConfig reads the METRICS_* settings.
+func LoadConfig() (Config, error) {
+	cfg := Config{
+		Histograms:       middleware.HistogramConfig{Native: config.GetEnvBool("METRICS_NATIVE_HISTOGRAMS", false)},
+		Paths:            config.GetEnvList("METRICS_PATHS"),
+		GoCollector:      config.GetEnvBool("METRICS_GO_COLLECTOR", false),
+		ProcessCollector: config.GetEnvBool("METRICS_PROCESS_COLLECTOR", false),
+	}
+	for _, v := range config.GetEnvList("METRICS_BUCKETS") {
+		bound, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("METRICS_BUCKETS: %q is not a number", v)
+		}
+		cfg.Histograms.Buckets = append(cfg.Histograms.Buckets, bound)
+	}
+	if err := cfg.Histograms.Validate(); err != nil {
+		return cfg, fmt.Errorf("METRICS_BUCKETS: %w", err)
+	}
+	return cfg, nil
+}
+
+// Setup applies cfg to the middleware metrics and returns a new registry
+// holding them and the optional runtime collectors.
+func Setup(cfg Config) (*prometheus.Registry, error) {
+	if err := middleware.ConfigureHistograms(cfg.Histograms); err != nil {
+		return nil, err
+	}
+	middleware.SetMetricsPaths(slices.Concat(middleware.DefaultMetricsPaths, cfg.Paths))
+
+	reg := prometheus.NewRegistry()
+	cs := []prometheus.Collector{
+		middleware.RequestDuration, middleware.EndpointCount, middleware.IPRejectedCount,
+		middleware.InflightRequests, middleware.ShedCount, middleware.ConnRejectedCount,
+		middleware.RateLimitRequests, middleware.RateLimitTrackedClients,
+		middleware.RateLimitCleanupRemoved, middleware.RateLimitCleanupDuration,
+		webhook.Deliveries, webhook.DeliveryAttempts, webhook.DeliveryDuration,
+		session.ReapedSessions, session.LiveSessions,
+		tools.DeprecatedCalls, tools.PoolQueueWait, tools.PoolQueued, tools.PoolRejected,
+		tenant.Requests, crashreport.Reports,
+		stdio.Messages, stdio.Bytes, stdio.ParseErrors, stdio.StdoutWrites,
+		httpclient.Requests, httpclient.RequestDuration, httpclient.Retries, httpclient.EgressDenied,
+	}
+	if cfg.GoCollector {
+		cs = append(cs, collectors.NewGoCollector())
+	}
+	if cfg.ProcessCollector {
+		cs = append(cs, collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+	for _, c := range cs {
+		if err := reg.Register(c); err != nil {
+			return nil, fmt.Errorf("register metrics: %w", err)
+		}
+	}
+	return reg, nil
+}
+
+// Handler serves reg, offering the OpenMetrics format so that trace
+// exemplars reach the scraper.
+func Handler(reg *prometheus.Registry) http.Handler {
+	return promhttp.InstrumentMetricHandler(reg,
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true, Registry: reg}))
+}
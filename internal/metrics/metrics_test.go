@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: Config{},
+		},
+		{
+			name: "all set",
+			env: map[string]string{
+				"METRICS_BUCKETS":           "0.1,1",
+				"METRICS_NATIVE_HISTOGRAMS": "true",
+				"METRICS_PATHS":             "/api/*",
+				"METRICS_GO_COLLECTOR":      "true",
+				"METRICS_PROCESS_COLLECTOR": "true",
+			},
+			want: Config{
+				Histograms:       middleware.HistogramConfig{Buckets: []float64{0.1, 1}, Native: true},
+				Paths:            []string{"/api/*"},
+				GoCollector:      true,
+				ProcessCollector: true,
+			},
+		},
+		{
+			name:    "bad bucket",
+			env:     map[string]string{"METRICS_BUCKETS": "0.1,fast"},
+			wantErr: true,
+		},
+		{
+			name:    "unordered buckets",
+			env:     map[string]string{"METRICS_BUCKETS": "1,0.1"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"METRICS_BUCKETS", "METRICS_NATIVE_HISTOGRAMS", "METRICS_PATHS", "METRICS_GO_COLLECTOR", "METRICS_PROCESS_COLLECTOR"} {
+				t.Setenv(key, tt.env[key])
+			}
+			got, err := LoadConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Histograms.Native != tt.want.Histograms.Native ||
+				len(got.Histograms.Buckets) != len(tt.want.Histograms.Buckets) ||
+				len(got.Paths) != len(tt.want.Paths) ||
+				got.GoCollector != tt.want.GoCollector ||
+				got.ProcessCollector != tt.want.ProcessCollector {
+				t.Errorf("LoadConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	t.Cleanup(func() { middleware.SetMetricsPaths(middleware.DefaultMetricsPaths) })
+
+	// Setup must be callable repeatedly, unlike registering on the
+	// default registry.
+	if _, err := Setup(Config{}); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	reg, err := Setup(Config{GoCollector: true})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	middleware.EndpointCount.WithLabelValues("/health", http.MethodGet, "200").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"http_request_total", "go_goroutines", "promhttp_metric_handler_requests_total"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+	if strings.Contains(body, "process_cpu_seconds_total") {
+		t.Error("process collector registered without ProcessCollector")
+	}
+
+	if _, err := Setup(Config{Histograms: middleware.HistogramConfig{Buckets: []float64{1, 1}}}); err == nil {
+		t.Error("Setup() with invalid buckets succeeded")
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"crypto/subtle"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 )
@@ -17,9 +18,76 @@ type authErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// Places AuthMiddleware looks for an API key, for KeySources.
+const (
+	// KeySourceHeader is the X-API-Key header, or KeySources.Header.
+	KeySourceHeader = "header"
+	// KeySourceBearer is an "Authorization: Bearer" header.
+	KeySourceBearer = "bearer"
+	// KeySourceQuery is a query parameter. It is opt-in because URLs end
+	// up in proxy and access logs.
+	KeySourceQuery = "query"
+)
+
+// KeySources selects where AuthMiddleware looks for an API key. Sources
+// are tried in order and the first key found is validated.
+type KeySources struct {
+	Sources []string
+	// Header is the header of KeySourceHeader.
+	Header string
+	// QueryParam is the parameter of KeySourceQuery.
+	QueryParam string
+}
+
+// DefaultKeySources accepts the X-API-Key header and bearer tokens.
+var DefaultKeySources = KeySources{
+	Sources:    []string{KeySourceHeader, KeySourceBearer},
+	Header:     "X-API-Key",
+	QueryParam: "api_key",
+}
+
+// Validate checks that at least one source is given and all are known.
+func (k KeySources) Validate() error {
+	if len(k.Sources) == 0 {
+		return fmt.Errorf("no API key sources: use %s, %s, or %s", KeySourceHeader, KeySourceBearer, KeySourceQuery)
+	}
+	for _, src := range k.Sources {
+		switch src {
+		case KeySourceHeader, KeySourceBearer, KeySourceQuery:
+		default:
+			return fmt.Errorf("unknown API key source %q: use %s, %s, or %s", src, KeySourceHeader, KeySourceBearer, KeySourceQuery)
+		}
+	}
+	return nil
+}
+
+// key returns the API key of r and the source it came from, or "".
+func (k KeySources) key(r *http.Request) (string, string) {
+	for _, src := range k.Sources {
+		var key string
+		switch src {
+		case KeySourceHeader:
+			key = r.Header.Get(k.Header)
+		case KeySourceBearer:
+			scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if strings.EqualFold(scheme, "Bearer") {
+				key = strings.TrimSpace(token)
+			}
+		case KeySourceQuery:
+			key = r.URL.Query().Get(k.QueryParam)
+		}
+		if key != "" {
+			return key, src
+		}
+	}
+	return "", ""
+}
+
 // AuthMiddleware creates a middleware that validates API keys.
-// Protected paths require a valid API key in the X-API-Key header.
-func AuthMiddleware(validator APIKeyValidator, protectedPrefixes []string) func(http.Handler) http.Handler {
+// Protected paths require a valid API key from one of sources.
+// Rejections are JSON-RPC errors with 401 Unauthorized and a Bearer
+// challenge, so MCP clients can report them like protocol errors.
+func AuthMiddleware(validator APIKeyValidator, protectedPrefixes []string, sources KeySources) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Check if this path needs protection
@@ -28,19 +96,30 @@ func AuthMiddleware(validator APIKeyValidator, protectedPrefixes []string) func(
 				return
 			}
 
-			// Get API key from header
-			apiKey := r.Header.Get("X-API-Key")
+			apiKey, src := sources.key(r)
 			if apiKey == "" {
-				writeAuthError(w, http.StatusUnauthorized, "missing API key")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+				WriteRPCError(w, http.StatusUnauthorized, CodeUnauthorized, ReasonMissingCredentials, "missing API key")
 				return
 			}
 
 			// Validate the API key
 			if !validator.ValidateAPIKey(apiKey) {
-				writeAuthError(w, http.StatusUnauthorized, "invalid API key")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mcp", error="invalid_token"`)
+				WriteRPCError(w, http.StatusUnauthorized, CodeUnauthorized, ReasonInvalidCredentials, "invalid API key")
 				return
 			}
 
+			r.Header.Set(HeaderCaller, KeyCaller(apiKey))
+
+			// Keep the key out of anything downstream that logs URLs
+			if src == KeySourceQuery {
+				r = r.Clone(r.Context())
+				q := r.URL.Query()
+				q.Del(sources.QueryParam)
+				r.URL.RawQuery = q.Encode()
+				r.RequestURI = r.URL.RequestURI()
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -68,3 +147,25 @@ func writeAuthError(w http.ResponseWriter, status int, message string) {
 func SecureCompare(a, b string) bool {
 	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
 }
+
+// TokenAuthMiddleware requires every request to carry token in an
+// "Authorization: Bearer" header. It guards the admin API, whose token is
+// deliberately separate from the API keys clients hold.
+func TokenAuthMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || got == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				writeAuthError(w, http.StatusUnauthorized, "missing admin token")
+				return
+			}
+			if !SecureCompare(got, token) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin", error="invalid_token"`)
+				writeAuthError(w, http.StatusUnauthorized, "invalid admin token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -115,7 +116,7 @@ func TestAuthMiddleware(t *testing.T) {
 			})
 
 			validator := newMockValidator(tt.validKeys...)
-			middleware := AuthMiddleware(validator, protectedPrefixes)
+			middleware := AuthMiddleware(validator, protectedPrefixes, DefaultKeySources)
 			handler := middleware(nextHandler)
 
 			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
@@ -135,12 +136,18 @@ func TestAuthMiddleware(t *testing.T) {
 			}
 
 			if tt.wantError != "" {
-				var errResp authErrorResponse
+				var errResp RPCError
 				if err := json.NewDecoder(rec.Body).Decode(&errResp); err != nil {
 					t.Fatalf("failed to decode error response: %v", err)
 				}
-				if errResp.Error != tt.wantError {
-					t.Errorf("error = %q, want %q", errResp.Error, tt.wantError)
+				if errResp.JSONRPC != "2.0" || errResp.Error.Code != CodeUnauthorized || errResp.Error.Message != tt.wantError {
+					t.Errorf("error = %+v, want code %d and message %q", errResp, CodeUnauthorized, tt.wantError)
+				}
+				if errResp.Error.Data.Status != http.StatusUnauthorized || errResp.Error.Data.Reason == "" {
+					t.Errorf("error data = %+v, want a reason and status 401", errResp.Error.Data)
+				}
+				if !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), `Bearer realm="mcp"`) {
+					t.Errorf("WWW-Authenticate = %q, want a Bearer challenge", rec.Header().Get("WWW-Authenticate"))
 				}
 
 				if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
@@ -151,6 +158,109 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestAuthMiddlewareKeySources(t *testing.T) {
+	withQuery := KeySources{Sources: []string{KeySourceHeader, KeySourceBearer, KeySourceQuery}, Header: "X-API-Key", QueryParam: "api_key"}
+	customHeader := KeySources{Sources: []string{KeySourceHeader}, Header: "X-Token"}
+
+	tests := []struct {
+		name       string
+		sources    KeySources
+		target     string
+		header     string
+		value      string
+		wantStatus int
+		wantQuery  string
+	}{
+		{name: "x-api-key header", sources: DefaultKeySources, target: "/mcp", header: "X-API-Key", value: "valid-key", wantStatus: http.StatusOK},
+		{name: "bearer token", sources: DefaultKeySources, target: "/mcp", header: "Authorization", value: "Bearer valid-key", wantStatus: http.StatusOK},
+		{name: "lowercase bearer", sources: DefaultKeySources, target: "/mcp", header: "Authorization", value: "bearer valid-key", wantStatus: http.StatusOK},
+		{name: "basic auth is not a key", sources: DefaultKeySources, target: "/mcp", header: "Authorization", value: "Basic valid-key", wantStatus: http.StatusUnauthorized},
+		{name: "query disabled by default", sources: DefaultKeySources, target: "/mcp?api_key=valid-key", wantStatus: http.StatusUnauthorized},
+		{name: "query when enabled", sources: withQuery, target: "/mcp?api_key=valid-key&x=1", wantStatus: http.StatusOK, wantQuery: "x=1"},
+		{name: "custom header", sources: customHeader, target: "/mcp", header: "X-Token", value: "valid-key", wantStatus: http.StatusOK},
+		{name: "bearer not enabled", sources: customHeader, target: "/mcp", header: "Authorization", value: "Bearer valid-key", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotQuery, gotCaller string
+			handler := AuthMiddleware(newMockValidator("valid-key"), []string{"/mcp"}, tt.sources)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotQuery = r.URL.RawQuery
+				gotCaller = r.Header.Get(HeaderCaller)
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Code == http.StatusOK && gotQuery != tt.wantQuery {
+				t.Errorf("query passed on = %q, want %q", gotQuery, tt.wantQuery)
+			}
+			if rec.Code == http.StatusOK && gotCaller != KeyCaller("valid-key") {
+				t.Errorf("caller = %q, want %q", gotCaller, KeyCaller("valid-key"))
+			}
+		})
+	}
+}
+
+func TestKeySourcesValidate(t *testing.T) {
+	tests := []struct {
+		sources []string
+		wantErr bool
+	}{
+		{sources: []string{"header", "bearer", "query"}},
+		{sources: nil, wantErr: true},
+		{sources: []string{"cookie"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := KeySources{Sources: tt.sources}.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%v) error = %v, wantErr %v", tt.sources, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTokenAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantChallenge string
+	}{
+		{name: "valid token", authorization: "Bearer admin-secret", wantStatus: http.StatusOK},
+		{name: "missing token", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="admin"`},
+		{name: "wrong scheme", authorization: "Basic admin-secret", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="admin"`},
+		{name: "invalid token", authorization: "Bearer api-key", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="admin", error="invalid_token"`},
+	}
+
+	handler := TokenAuthMiddleware("admin-secret")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
+
 func TestIsProtectedPath(t *testing.T) {
 	tests := []struct {
 		name     string
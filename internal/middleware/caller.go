@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// HeaderCaller carries the authenticated caller of a request from the
+// HTTP auth middleware to tool middleware, which sees request headers in
+// CallToolRequest.Extra.Header. StripCaller removes any value the client
+// sent, so only the auth middleware can set it.
+const HeaderCaller = "X-Mcp-Caller"
+
+// HeaderTenant carries the tenant of a request, like HeaderCaller, from
+// the tenant stage to tool middleware.
+const HeaderTenant = "X-Mcp-Tenant"
+
+// StripCaller removes HeaderCaller and HeaderTenant from incoming
+// requests. It must wrap the auth and tenant middleware.
+func StripCaller(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(HeaderCaller)
+		r.Header.Del(HeaderTenant)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// KeyCaller identifies the holder of an API key without revealing it:
+// "api_key:" and the first 12 hex digits of its SHA-256 hash, as printed
+// by mcp-server config hash-key.
+func KeyCaller(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "api_key:" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+func TestStripCaller(t *testing.T) {
+	var got, tenant string
+	handler := StripCaller(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, tenant = r.Header.Get(HeaderCaller), r.Header.Get(HeaderTenant)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set(HeaderCaller, "oauth:admin")
+	req.Header.Set(HeaderTenant, "ops")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "" || tenant != "" {
+		t.Errorf("caller, tenant = %q, %q, want stripped", got, tenant)
+	}
+}
+
+func TestKeyCaller(t *testing.T) {
+	got := KeyCaller("valid-key")
+	if want := "api_key:" + strings.TrimPrefix(config.HashAPIKey("valid-key"), "sha256:")[:12]; got != want {
+		t.Errorf("KeyCaller = %q, want %q", got, want)
+	}
+	if KeyCaller("a") == KeyCaller("b") {
+		t.Error("KeyCaller does not distinguish keys")
+	}
+}
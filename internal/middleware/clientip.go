@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParsePrefixes parses CIDR ranges such as "10.0.0.0/8". Bare addresses are
+// treated as single-host ranges.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", v, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address %q: %w", v, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// containsAddr reports whether any prefix contains addr.
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIPResolver determines the address of the client behind a request.
+// X-Forwarded-For is only honoured when the connection comes from a
+// trusted proxy, so clients cannot spoof their address.
+type ClientIPResolver struct {
+	trusted []netip.Prefix
+}
+
+// NewClientIPResolver creates a resolver that trusts X-Forwarded-For from
+// the given proxy addresses or CIDR ranges. With none, the connection's
+// remote address is always used.
+func NewClientIPResolver(trustedProxies []string) (*ClientIPResolver, error) {
+	trusted, err := ParsePrefixes(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientIPResolver{trusted: trusted}, nil
+}
+
+// ClientIP returns the client address of r, or the zero Addr when it cannot
+// be determined. X-Forwarded-For is walked from the right, skipping trusted
+// proxies, and the first untrusted hop is the client.
+func (c *ClientIPResolver) ClientIP(r *http.Request) netip.Addr {
+	addr := remoteAddr(r.RemoteAddr)
+	if !addr.IsValid() || !containsAddr(c.trusted, addr) {
+		return addr
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Anything left of a malformed entry cannot be trusted
+			return addr
+		}
+		addr = hop.Unmap()
+		if !containsAddr(c.trusted, addr) {
+			return addr
+		}
+	}
+	return addr
+}
+
+// remoteAddr parses the host part of an http.Request RemoteAddr.
+func remoteAddr(remote string) netip.Addr {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted []string
+		remote  string
+		xff     []string
+		want    string
+	}{
+		{name: "no proxies", remote: "203.0.113.7:1234", want: "203.0.113.7"},
+		{name: "untrusted proxy ignored", remote: "203.0.113.7:1234", xff: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		{name: "trusted proxy", trusted: []string{"10.0.0.0/8"}, remote: "10.0.0.5:80", xff: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "spoofed leftmost entry", trusted: []string{"10.0.0.0/8"}, remote: "10.0.0.5:80", xff: []string{"1.2.3.4, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "proxy chain", trusted: []string{"10.0.0.0/8", "192.0.2.10"}, remote: "10.0.0.5:80", xff: []string{"198.51.100.1, 192.0.2.10", "10.1.1.1"}, want: "198.51.100.1"},
+		{name: "all trusted", trusted: []string{"10.0.0.0/8"}, remote: "10.0.0.5:80", xff: []string{"10.0.0.9"}, want: "10.0.0.9"},
+		{name: "malformed hop", trusted: []string{"10.0.0.0/8"}, remote: "10.0.0.5:80", xff: []string{"198.51.100.1, junk"}, want: "10.0.0.5"},
+		{name: "no header", trusted: []string{"10.0.0.0/8"}, remote: "10.0.0.5:80", want: "10.0.0.5"},
+		{name: "ipv6", remote: "[2001:db8::1]:443", want: "2001:db8::1"},
+		{name: "ipv4-mapped", remote: "[::ffff:203.0.113.7]:443", want: "203.0.113.7"},
+		{name: "unparseable remote", remote: "pipe", want: "invalid IP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, err := NewClientIPResolver(tt.trusted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if got := resolver.ClientIP(req).String(); got != tt.want {
+				t.Errorf("ClientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	prefixes, err := ParsePrefixes([]string{"10.1.2.3/8", "192.0.2.1", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.0.0.0/8", "192.0.2.1/32", "2001:db8::/32"}
+	for i, p := range prefixes {
+		if p.String() != want[i] {
+			t.Errorf("prefix %d = %s, want %s", i, p, want[i])
+		}
+	}
+
+	for _, bad := range []string{"10.0.0.0/33", "example.com", ""} {
+		if _, err := ParsePrefixes([]string{bad}); err == nil {
+			t.Errorf("ParsePrefixes(%q) error = nil, want error", bad)
+		}
+	}
+}
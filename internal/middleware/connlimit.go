@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ConnRejectedCount counts connections closed by LimitConnsPerIP because
+// their client already had the maximum open.
+var ConnRejectedCount = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "http_connections_rejected_total",
+		Help: "Total number of HTTP connections closed because their client address had too many open.",
+	},
+)
+
+// LimitConnsPerIP returns a listener accepting at most max concurrent
+// connections from each remote IP address. Connections beyond the limit
+// are closed as soon as they are accepted, before a byte is read, so one
+// client cannot hold every connection slot by opening them and sending
+// slowly. Behind a proxy every connection comes from the proxy's address,
+// so the limit applies to the proxy as a whole.
+func LimitConnsPerIP(ln net.Listener, max int) net.Listener {
+	return &connLimiter{Listener: ln, max: max, open: make(map[string]int)}
+}
+
+type connLimiter struct {
+	net.Listener
+	max  int
+	mu   sync.Mutex
+	open map[string]int
+}
+
+// Accept returns the next connection whose client is under the limit.
+func (l *connLimiter) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		ip := remoteIP(c)
+		l.mu.Lock()
+		if l.open[ip] >= l.max {
+			l.mu.Unlock()
+			ConnRejectedCount.Inc()
+			_ = c.Close()
+			continue
+		}
+		l.open[ip]++
+		l.mu.Unlock()
+		return &limitedConn{Conn: c, release: func() { l.release(ip) }}, nil
+	}
+}
+
+func (l *connLimiter) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.open[ip]--; l.open[ip] <= 0 {
+		delete(l.open, ip)
+	}
+}
+
+// limitedConn gives its slot back when closed, once however often Close
+// is called.
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// remoteIP returns the IP address of c's peer, or the whole address when
+// it has no port.
+func remoteIP(c net.Conn) string {
+	addr := c.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
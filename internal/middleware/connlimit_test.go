@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLimitConnsPerIP(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := LimitConnsPerIP(inner, 2)
+	defer ln.Close()
+	accepted := make(chan net.Conn, 4)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	dial := func() net.Conn {
+		t.Helper()
+		c, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		return c
+	}
+	accept := func() net.Conn {
+		t.Helper()
+		select {
+		case c := <-accepted:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("connection not accepted")
+			return nil
+		}
+	}
+
+	dial()
+	first := accept()
+	dial()
+	accept()
+
+	// A third connection is closed without being handed to the server
+	over := dial()
+	_ = over.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := over.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read() on the connection over the limit = %v, want EOF", err)
+	}
+	select {
+	case <-accepted:
+		t.Error("connection over the limit was accepted")
+	default:
+	}
+
+	// Closing a connection, twice, frees exactly one slot
+	_ = first.Close()
+	_ = first.Close()
+	dial()
+	accept()
+	over = dial()
+	_ = over.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := over.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read() after one slot was reused = %v, want EOF", err)
+	}
+}
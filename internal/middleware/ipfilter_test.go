@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestIPFilterMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		allow      []string
+		deny       []string
+		remote     string
+		wantStatus int
+		wantReason string
+	}{
+		{name: "no lists", remote: "203.0.113.7:1", wantStatus: http.StatusOK},
+		{name: "allowlisted", allow: []string{"203.0.113.0/24"}, remote: "203.0.113.7:1", wantStatus: http.StatusOK},
+		{name: "not allowlisted", allow: []string{"203.0.113.0/24"}, remote: "198.51.100.1:1", wantStatus: http.StatusForbidden, wantReason: "not_allowlisted"},
+		{name: "denylisted", deny: []string{"198.51.100.0/24"}, remote: "198.51.100.1:1", wantStatus: http.StatusForbidden, wantReason: "denylist"},
+		{name: "deny wins over allow", allow: []string{"0.0.0.0/0"}, deny: []string{"198.51.100.1"}, remote: "198.51.100.1:1", wantStatus: http.StatusForbidden, wantReason: "denylist"},
+		{name: "deny only lets others through", deny: []string{"198.51.100.0/24"}, remote: "203.0.113.7:1", wantStatus: http.StatusOK},
+		{name: "unknown client with allowlist", allow: []string{"203.0.113.0/24"}, remote: "pipe", wantStatus: http.StatusForbidden, wantReason: "unknown_client"},
+		{name: "unknown client with denylist only", deny: []string{"198.51.100.0/24"}, remote: "pipe", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, _ := NewClientIPResolver(nil)
+			filter, err := NewIPFilter(tt.allow, tt.deny, resolver)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var before float64
+			if tt.wantReason != "" {
+				before = testutil.ToFloat64(IPRejectedCount.WithLabelValues(tt.wantReason))
+			}
+
+			handler := IPFilterMiddleware(filter)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest("GET", "/mcp", nil)
+			req.RemoteAddr = tt.remote
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantReason != "" {
+				if got := testutil.ToFloat64(IPRejectedCount.WithLabelValues(tt.wantReason)) - before; got != 1 {
+					t.Errorf("rejected count for %s increased by %v, want 1", tt.wantReason, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/jsonrpc"
+)
+
+// maxTraceBody is the most of a request body read to identify the call.
+// The fields needed normally come first, so far less is read in practice.
+const maxTraceBody = 1 << 20
+
+// maxPooledBuffer is the capacity above which a body buffer is dropped
+// rather than pooled, so one large request does not pin its memory.
+const maxPooledBuffer = 64 << 10
+
+// traceBuffers holds body buffers for reuse across requests.
+var traceBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// MCPCall identifies the JSON-RPC call a request carries.
+type MCPCall struct {
+	// Method is the JSON-RPC method, "batch" for a batch, or "" if the
+	// body could not be parsed.
+	Method string
+	// Tool is the tool name of a tools/call request.
+	Tool string
+}
+
+type mcpCallKey struct{}
+
+// MCPCallFromContext returns the call identified by MCPTracingMiddleware.
+func MCPCallFromContext(ctx context.Context) (MCPCall, bool) {
+	call, ok := ctx.Value(mcpCallKey{}).(MCPCall)
+	return call, ok
+}
+
+// mcpTracer is the state of MCPTracingMiddleware.
+type mcpTracer struct {
+	logger   *slog.Logger
+	prefixes []string
+	// pooled reuses body buffers; it is only off in benchmarks.
+	pooled bool
+}
+
+// MCPTracingMiddleware identifies the JSON-RPC call in POST requests to
+// paths under prefixes, makes it available to later handlers through
+// MCPCallFromContext, and logs each call with its W3C trace ID, status,
+// and duration. The body is passed on unchanged. While logger discards
+// info records, calls are identified but not timed or logged.
+func MCPTracingMiddleware(logger *slog.Logger, prefixes []string) func(http.Handler) http.Handler {
+	t := &mcpTracer{logger: logger, prefixes: prefixes, pooled: true}
+	return t.wrap
+}
+
+func (t *mcpTracer) getBuffer() *bytes.Buffer {
+	if !t.pooled {
+		return new(bytes.Buffer)
+	}
+	buf := traceBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func (t *mcpTracer) putBuffer(buf *bytes.Buffer) {
+	if t.pooled && buf.Cap() <= maxPooledBuffer {
+		traceBuffers.Put(buf)
+	}
+}
+
+func (t *mcpTracer) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !isProtectedPath(r.URL.Path, t.prefixes) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+
+		// The buffer backs the body until the handler returns. It holds
+		// only the part read to find the call; the rest streams through.
+		buf := t.getBuffer()
+		defer t.putBuffer(buf)
+		call, invalid := readMCPCall(r.Body, buf)
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf.Bytes()), r.Body), r.Body}
+
+		ctx := context.WithValue(r.Context(), mcpCallKey{}, call)
+		if !t.logger.Enabled(ctx, slog.LevelInfo) {
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+		wrapped := newResponseWriter(w)
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+		attrs := []slog.Attr{
+			slog.String("trace_id", traceID(r)),
+			slog.String("method", call.Method),
+			slog.String("tool", call.Tool),
+			slog.Int("status", wrapped.statusCode),
+			slog.Duration("duration", time.Since(start)),
+		}
+		// Invalid bodies still reach the handler, which answers them with
+		// a JSON-RPC error
+		if invalid != nil {
+			attrs = append(attrs, slog.String("invalid", invalid.Error()))
+		}
+		t.logger.LogAttrs(ctx, slog.LevelInfo, "mcp request", attrs...)
+	})
+}
+
+// readMCPCall identifies the call at the start of a JSON-RPC request body,
+// reading only as far as the method and, for tools/call, the tool name.
+// Everything read is copied to buf so the body can be replayed. A body
+// that is not a valid request yields an empty MCPCall and the reason.
+func readMCPCall(body io.Reader, buf *bytes.Buffer) (MCPCall, error) {
+	call, err := jsonrpc.Peek(io.TeeReader(io.LimitReader(body, maxTraceBody), buf))
+	switch {
+	case err != nil:
+		return MCPCall{}, err
+	case call.Batch:
+		return MCPCall{Method: "batch"}, nil
+	}
+	return MCPCall{Method: call.Method, Tool: call.Tool}, nil
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadMCPCall(t *testing.T) {
+	large := strings.Repeat("x", 4*maxPooledBuffer)
+	tests := []struct {
+		name string
+		body string
+		want MCPCall
+		// maxRead bounds how much of the body may be read, 0 for no bound
+		maxRead int
+		wantErr bool
+	}{
+		{name: "tool call", body: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hash","arguments":{"data":"x"}}}`, want: MCPCall{Method: "tools/call", Tool: "hash"}},
+		{name: "params first", body: `{"params":{"arguments":{"a":[1,{"b":2}]},"name":"hash"},"method":"tools/call","id":1}`, want: MCPCall{Method: "tools/call", Tool: "hash"}},
+		{name: "other method", body: `{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"greeting"}}`, want: MCPCall{Method: "prompts/get"}},
+		{name: "notification", body: `{"jsonrpc":"2.0","method":"notifications/initialized"}`, want: MCPCall{Method: "notifications/initialized"}},
+		{name: "large arguments", body: `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"text","arguments":{"text":"` + large + `"}}}`, want: MCPCall{Method: "tools/call", Tool: "text"}, maxRead: 4096},
+		{name: "batch", body: ` [{"jsonrpc":"2.0","method":"ping","id":1}]`, want: MCPCall{Method: "batch"}},
+		{name: "non-string method", body: `{"method":7}`, want: MCPCall{}, wantErr: true},
+		{name: "duplicate past what is read", body: `{"method":"ping","method":"tools/call"}`, want: MCPCall{Method: "ping"}},
+		{name: "duplicate before method", body: `{"id":1,"id":2,"method":"ping"}`, want: MCPCall{}, wantErr: true},
+		{name: "duplicate tool name", body: `{"params":{"name":"hash","name":"exec"},"method":"tools/call"}`, want: MCPCall{}, wantErr: true},
+		{name: "oversized id", body: `{"id":"` + strings.Repeat("1", 1000) + `","method":"ping"}`, want: MCPCall{}, wantErr: true},
+		{name: "invalid", body: `{"method":`, want: MCPCall{}, wantErr: true},
+		{name: "empty", body: ``, want: MCPCall{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			rest := strings.NewReader(tt.body)
+			got, err := readMCPCall(rest, &buf)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("readMCPCall() = %+v, %v; want %+v, error %v", got, err, tt.want, tt.wantErr)
+			}
+			remaining, _ := io.ReadAll(rest)
+			if replayed := buf.String() + string(remaining); replayed != tt.body {
+				t.Errorf("read and remaining bytes do not reassemble the body")
+			}
+			if tt.maxRead > 0 && buf.Len() > tt.maxRead {
+				t.Errorf("read %d bytes, want at most %d", buf.Len(), tt.maxRead)
+			}
+		})
+	}
+}
+
+func TestMCPTracingMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hash","arguments":{"data":"` + strings.Repeat("x", 2*maxPooledBuffer) + `"}}}`
+	var gotBody string
+	var gotCall MCPCall
+	handler := MCPTracingMiddleware(logger, []string{"/mcp"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		gotCall, _ = MCPCallFromContext(r.Context())
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotBody != body {
+		t.Errorf("handler read %d bytes, want the original %d", len(gotBody), len(body))
+	}
+	if want := (MCPCall{Method: "tools/call", Tool: "hash"}); gotCall != want {
+		t.Errorf("MCPCallFromContext() = %+v, want %+v", gotCall, want)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("log record: %v", err)
+	}
+	for key, want := range map[string]any{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "method": "tools/call", "tool": "hash", "status": float64(http.StatusAccepted)} {
+		if record[key] != want {
+			t.Errorf("log %s = %v, want %v", key, record[key], want)
+		}
+	}
+
+	// Requests that carry no call pass through untouched
+	logs.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/mcp", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/health", strings.NewReader("{}")))
+	if logs.Len() != 0 {
+		t.Errorf("unexpected log for untraced request: %s", logs.String())
+	}
+
+	// Invalid requests are logged with the reason and still served
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"1.0","method":"ping"}`)))
+	if !strings.Contains(logs.String(), `"invalid":"jsonrpc must be \"2.0\""`) || gotBody != `{"jsonrpc":"1.0","method":"ping"}` {
+		t.Errorf("log = %s, body = %s; want the reason logged and the body passed on", logs.String(), gotBody)
+	}
+}
+
+func TestMCPTracingMiddleware_Disabled(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	var gotCall MCPCall
+	var gotWriter http.ResponseWriter
+	handler := MCPTracingMiddleware(logger, []string{"/mcp"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCall, _ = MCPCallFromContext(r.Context())
+		gotWriter = w
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hash"}}`)))
+	if want := (MCPCall{Method: "tools/call", Tool: "hash"}); gotCall != want {
+		t.Errorf("MCPCallFromContext() = %+v, want %+v", gotCall, want)
+	}
+	if gotWriter != rec || logs.Len() != 0 {
+		t.Errorf("writer wrapped or log written (%q) with info records disabled", logs.String())
+	}
+}
+
+func BenchmarkMCPTracingMiddleware(b *testing.B) {
+	for _, size := range []int{1 << 10, 32 << 10} {
+		body := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"text","arguments":{"text":"` + strings.Repeat("x", size) + `"}}}`)
+		for _, pooled := range []bool{false, true} {
+			b.Run(fmt.Sprintf("size=%d/pooled=%t", size, pooled), func(b *testing.B) {
+				tracer := &mcpTracer{logger: slog.New(slog.NewJSONHandler(io.Discard, nil)), prefixes: []string{"/mcp"}, pooled: pooled}
+				handler := tracer.wrap(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+					_, _ = io.Copy(io.Discard, r.Body)
+				}))
+				b.ReportAllocs()
+				b.SetBytes(int64(len(body)))
+				b.RunParallel(func(pb *testing.PB) {
+					rec := httptest.NewRecorder()
+					for pb.Next() {
+						req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+						handler.ServeHTTP(rec, req)
+					}
+				})
+			})
+		}
+	}
+}
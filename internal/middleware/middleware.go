@@ -1,21 +1,18 @@
 package middleware
 
 import (
+	"encoding/hex"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	RequestDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "Duration of HTTP requests.",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"path", "method", "status"},
-	)
+	RequestDuration = newRequestDuration(HistogramConfig{})
 
 	EndpointCount = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -26,6 +23,74 @@ var (
 	)
 )
 
+// HistogramConfig customizes the request duration histogram.
+type HistogramConfig struct {
+	// Buckets are the classic bucket upper bounds in seconds, strictly
+	// increasing. Nil uses prometheus.DefBuckets.
+	Buckets []float64
+	// Native additionally records a native histogram, whose buckets adapt
+	// to the observed values. Scrapers must negotiate the protobuf format
+	// to receive it.
+	Native bool
+}
+
+// Validate checks that the buckets are strictly increasing.
+func (c HistogramConfig) Validate() error {
+	for i := 1; i < len(c.Buckets); i++ {
+		if c.Buckets[i] <= c.Buckets[i-1] {
+			return fmt.Errorf("histogram buckets must be strictly increasing: %v", c.Buckets)
+		}
+	}
+	return nil
+}
+
+// ConfigureHistograms replaces RequestDuration with a histogram built from
+// cfg. Call it before RequestDuration is registered.
+func ConfigureHistograms(cfg HistogramConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	RequestDuration = newRequestDuration(cfg)
+	return nil
+}
+
+func newRequestDuration(cfg HistogramConfig) *prometheus.HistogramVec {
+	opts := prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests.",
+		Buckets: cfg.Buckets,
+	}
+	if opts.Buckets == nil {
+		opts.Buckets = prometheus.DefBuckets
+	}
+	if cfg.Native {
+		opts.NativeHistogramBucketFactor = 1.1
+		opts.NativeHistogramMaxBucketNumber = 160
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+	return prometheus.NewHistogramVec(opts, []string{"path", "method", "status"})
+}
+
+// traceID returns the trace ID of the W3C traceparent header of r, or "".
+// It links duration observations to traces as exemplars.
+func traceID(r *http.Request) string {
+	return TraceIDFromHeader(r.Header)
+}
+
+// TraceIDFromHeader returns the trace ID of the W3C traceparent header in
+// h, or "", for code that sees a request's headers but not the request.
+func TraceIDFromHeader(h http.Header) string {
+	// version-traceid-parentid-flags, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+	parts := strings.Split(h.Get("traceparent"), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || parts[1] == strings.Repeat("0", 32) {
+		return ""
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil || strings.ToLower(parts[1]) != parts[1] {
+		return ""
+	}
+	return parts[1]
+}
+
 // responseWriter wraps http.ResponseWriter to capture the status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -51,19 +116,39 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush sends buffered data to the client, keeping event streams working
+// through the wrapper.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // MetricsMiddleware is the middleware for capturing metrics
 func MetricsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		route := r.URL.Path
-		method := r.Method
+		// Only allow-listed paths and standard methods become label values
+		route := metricsPath(r.URL.Path)
+		method := metricsMethod(r.Method)
 
 		// Wrap the response writer to capture status code
 		wrapped := newResponseWriter(w)
 
 		// Start timer for duration metric
+		trace := traceID(r)
 		timer := prometheus.NewTimer(prometheus.ObserverFunc(func(v float64) {
 			status := strconv.Itoa(wrapped.statusCode)
-			RequestDuration.WithLabelValues(route, method, status).Observe(v)
+			observer := RequestDuration.WithLabelValues(route, method, status)
+			if eo, ok := observer.(prometheus.ExemplarObserver); ok && trace != "" {
+				eo.ObserveWithExemplar(v, prometheus.Labels{"trace_id": trace})
+				return
+			}
+			observer.Observe(v)
 		}))
 		defer timer.ObserveDuration()
 
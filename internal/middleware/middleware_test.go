@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func TestNewResponseWriter(t *testing.T) {
@@ -211,3 +213,108 @@ func TestMetricsMiddleware_DefaultStatus(t *testing.T) {
 		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
 	}
 }
+
+func TestConfigureHistograms(t *testing.T) {
+	old := RequestDuration
+	t.Cleanup(func() { RequestDuration = old })
+
+	if err := ConfigureHistograms(HistogramConfig{Buckets: []float64{0.1, 0.1, 1}}); err == nil {
+		t.Error("ConfigureHistograms(duplicate buckets) error = nil, want error")
+	}
+	if err := ConfigureHistograms(HistogramConfig{Buckets: []float64{1, 0.5}}); err == nil {
+		t.Error("ConfigureHistograms(decreasing buckets) error = nil, want error")
+	}
+	if RequestDuration != old {
+		t.Fatal("invalid config replaced RequestDuration")
+	}
+
+	if err := ConfigureHistograms(HistogramConfig{Buckets: []float64{0.05, 0.5, 5}, Native: true}); err != nil {
+		t.Fatalf("ConfigureHistograms() error = %v", err)
+	}
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(RequestDuration)
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	MetricsMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(httptest.NewRecorder(), req)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := families[0].GetMetric()[0].GetHistogram()
+	if n := len(h.GetBucket()); n != 3 {
+		t.Errorf("classic buckets = %d, want 3", n)
+	}
+	if h.GetSchema() == 0 && h.GetZeroThreshold() == 0 {
+		t.Error("native histogram not recorded")
+	}
+	var exemplar string
+	for _, b := range h.GetBucket() {
+		if e := b.GetExemplar(); e != nil {
+			exemplar = e.GetLabel()[0].GetValue()
+		}
+	}
+	if exemplar != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("exemplar trace_id = %q, want the traceparent trace ID", exemplar)
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{header: ""},
+		{header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{header: "00-not-hex-01"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("traceparent", tt.header)
+		if got := traceID(req); got != tt.want {
+			t.Errorf("traceID(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestMetricsPath(t *testing.T) {
+	t.Cleanup(func() { SetMetricsPaths(DefaultMetricsPaths) })
+	SetMetricsPaths(append([]string{"/custom", "/api/*"}, DefaultMetricsPaths...))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/health", "/health"},
+		{"/mcp", "/mcp"},
+		{"/mcp/session", "/mcp/*"},
+		{"/admin/config", "/admin/*"},
+		{"/.well-known/oauth-protected-resource", "/.well-known/*"},
+		{"/custom", "/custom"},
+		{"/custom/x", "other"},
+		{"/api/v1/users/42", "/api/*"},
+		{"/wp-login.php", "other"},
+		{"/", "other"},
+	}
+	for _, tt := range tests {
+		if got := metricsPath(tt.path); got != tt.want {
+			t.Errorf("metricsPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMetricsMethod(t *testing.T) {
+	for method, want := range map[string]string{
+		http.MethodGet:  http.MethodGet,
+		http.MethodPost: http.MethodPost,
+		"PROPFIND":      "OTHER",
+		"get":           "OTHER",
+	} {
+		if got := metricsMethod(method); got != want {
+			t.Errorf("metricsMethod(%q) = %q, want %q", method, got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultMetricsPaths are the routes served by mcp-server. Entries ending
+// in "*" match any path with that prefix and are reported as the entry
+// itself.
+var DefaultMetricsPaths = []string{"/health", "/ready", "/metrics", "/version", "/mcp", "/mcp/*", "/admin/*", "/.well-known/*"}
+
+// otherPath is the label of requests to paths that are not allow-listed,
+// so scanners probing random URLs cannot create unbounded label values.
+const otherPath = "other"
+
+// pathSet is an allow-list of metric path labels.
+type pathSet struct {
+	exact    map[string]struct{}
+	prefixes []string
+}
+
+var metricsPaths atomic.Pointer[pathSet]
+
+func init() {
+	SetMetricsPaths(DefaultMetricsPaths)
+}
+
+// SetMetricsPaths replaces the paths MetricsMiddleware reports by name; all
+// others are reported as "other". See DefaultMetricsPaths for the syntax.
+func SetMetricsPaths(paths []string) {
+	set := &pathSet{exact: make(map[string]struct{})}
+	for _, p := range paths {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			set.prefixes = append(set.prefixes, prefix)
+			continue
+		}
+		set.exact[p] = struct{}{}
+	}
+	metricsPaths.Store(set)
+}
+
+// metricsPath returns the label for path.
+func metricsPath(path string) string {
+	set := metricsPaths.Load()
+	if _, ok := set.exact[path]; ok {
+		return path
+	}
+	for _, prefix := range set.prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return prefix + "*"
+		}
+	}
+	return otherPath
+}
+
+// metricsMethod returns the label for method. net/http accepts any token
+// as a method, so unknown ones are reported as "OTHER".
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
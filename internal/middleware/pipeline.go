@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// Stage is a named step of a Pipeline. A nil Wrap marks a stage that is
+// disabled by configuration; it keeps its place so other stages can still
+// be positioned relative to it.
+type Stage struct {
+	Name string
+	Wrap func(http.Handler) http.Handler
+}
+
+// Pipeline is an ordered chain of HTTP middleware stages. The first stage
+// is the outermost: it sees each request first and the response last.
+type Pipeline struct {
+	stages []Stage
+}
+
+// NewPipeline creates a pipeline of stages in order.
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// Names returns the stage names in order.
+func (p *Pipeline) Names() []string {
+	names := make([]string, len(p.stages))
+	for i, s := range p.stages {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Enabled returns the names of the stages that wrap requests, in order.
+func (p *Pipeline) Enabled() []string {
+	var names []string
+	for _, s := range p.stages {
+		if s.Wrap != nil {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// index returns the position of the stage called name.
+func (p *Pipeline) index(name string) (int, error) {
+	i := slices.IndexFunc(p.stages, func(s Stage) bool { return s.Name == name })
+	if i < 0 {
+		return 0, fmt.Errorf("unknown middleware stage %q: have %v", name, p.Names())
+	}
+	return i, nil
+}
+
+// insert adds stage at position i, rejecting duplicate names.
+func (p *Pipeline) insert(i int, stage Stage) error {
+	if _, err := p.index(stage.Name); err == nil {
+		return fmt.Errorf("duplicate middleware stage %q", stage.Name)
+	}
+	p.stages = slices.Insert(p.stages, i, stage)
+	return nil
+}
+
+// InsertBefore adds stage just outside the stage called name.
+func (p *Pipeline) InsertBefore(name string, stage Stage) error {
+	i, err := p.index(name)
+	if err != nil {
+		return err
+	}
+	return p.insert(i, stage)
+}
+
+// InsertAfter adds stage just inside the stage called name.
+func (p *Pipeline) InsertAfter(name string, stage Stage) error {
+	i, err := p.index(name)
+	if err != nil {
+		return err
+	}
+	return p.insert(i+1, stage)
+}
+
+// Reorder puts the stages in the order of names, which must name every
+// stage exactly once so that no stage is dropped by accident.
+func (p *Pipeline) Reorder(names []string) error {
+	if len(names) != len(p.stages) {
+		return fmt.Errorf("middleware order %v must list each of %v once", names, p.Names())
+	}
+	ordered := make([]Stage, 0, len(names))
+	for _, name := range names {
+		i, err := p.index(name)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(ordered, func(s Stage) bool { return s.Name == name }) {
+			return fmt.Errorf("middleware order lists %q twice", name)
+		}
+		ordered = append(ordered, p.stages[i])
+	}
+	p.stages = ordered
+	return nil
+}
+
+// Then wraps h in the enabled stages.
+func (p *Pipeline) Then(h http.Handler) http.Handler {
+	for i := len(p.stages) - 1; i >= 0; i-- {
+		if wrap := p.stages[i].Wrap; wrap != nil {
+			h = wrap(h)
+		}
+	}
+	return h
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordStage returns a stage that appends name to the X-Stages header.
+func recordStage(name string) Stage {
+	return Stage{Name: name, Wrap: func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Header.Add("X-Stages", name)
+			next.ServeHTTP(w, r)
+		})
+	}}
+}
+
+func TestPipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func(p *Pipeline) error
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "default order skips disabled stages",
+			build: func(*Pipeline) error { return nil },
+			want:  []string{"a", "c"},
+		},
+		{
+			name: "insert before and after",
+			build: func(p *Pipeline) error {
+				return errors.Join(p.InsertBefore("a", recordStage("x")), p.InsertAfter("b", recordStage("y")))
+			},
+			want: []string{"x", "a", "y", "c"},
+		},
+		{
+			name:  "reorder",
+			build: func(p *Pipeline) error { return p.Reorder([]string{"c", "b", "a"}) },
+			want:  []string{"c", "a"},
+		},
+		{
+			name:    "unknown stage",
+			build:   func(p *Pipeline) error { return p.InsertAfter("z", recordStage("x")) },
+			wantErr: `unknown middleware stage "z"`,
+		},
+		{
+			name:    "duplicate stage",
+			build:   func(p *Pipeline) error { return p.InsertAfter("a", recordStage("c")) },
+			wantErr: `duplicate middleware stage "c"`,
+		},
+		{
+			name:    "reorder missing stage",
+			build:   func(p *Pipeline) error { return p.Reorder([]string{"c", "a"}) },
+			wantErr: "must list each",
+		},
+		{
+			name:    "reorder repeated stage",
+			build:   func(p *Pipeline) error { return p.Reorder([]string{"c", "a", "a"}) },
+			wantErr: `lists "a" twice`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPipeline(recordStage("a"), Stage{Name: "b"}, recordStage("c"))
+			err := tt.build(p)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			var got []string
+			h := p.Then(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = r.Header.Values("X-Stages")
+			}))
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("stages run = %v, want %v", got, tt.want)
+			}
+			if enabled := p.Enabled(); !slices.Equal(enabled, tt.want) {
+				t.Errorf("Enabled() = %v, want %v", enabled, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkPipeline measures the default hot path of an MCP request through
+// the metrics, rate limit, and tracing stages, each alone and together.
+func BenchmarkPipeline(b *testing.B) {
+	resolver, err := NewClientIPResolver(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	stages := map[string]Stage{
+		"metrics":    {Name: "metrics", Wrap: MetricsMiddleware},
+		"rate_limit": {Name: "rate_limit", Wrap: RateLimitMiddleware(NewRateLimiter(1<<30, time.Second, 1<<30), resolver, nil)},
+		"tracing":    {Name: "tracing", Wrap: MCPTracingMiddleware(slog.New(slog.NewJSONHandler(io.Discard, nil)), []string{"/mcp"})},
+	}
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"uuid","arguments":{"count":1}}}`)
+
+	for _, names := range [][]string{{"metrics"}, {"rate_limit"}, {"tracing"}, {"metrics", "rate_limit", "tracing"}} {
+		b.Run(strings.Join(names, "+"), func(b *testing.B) {
+			var chain []Stage
+			for _, name := range names {
+				chain = append(chain, stages[name])
+			}
+			handler := NewPipeline(chain...).Then(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+			}))
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				rec := httptest.NewRecorder()
+				for pb.Next() {
+					req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+					handler.ServeHTTP(rec, req)
+				}
+			})
+		})
+	}
+}
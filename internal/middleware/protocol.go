@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// HeaderProtocolVersion carries the MCP protocol version of requests after
+// initialization.
+const HeaderProtocolVersion = "Mcp-Protocol-Version"
+
+// SupportedProtocolVersions are the MCP protocol versions the SDK speaks,
+// newest first.
+var SupportedProtocolVersions = []string{"2025-11-25", "2025-06-18", "2025-03-26", "2024-11-05"}
+
+// ValidateProtocolVersions checks that versions is a non-empty subset of
+// SupportedProtocolVersions.
+func ValidateProtocolVersions(versions []string) error {
+	if len(versions) == 0 {
+		return fmt.Errorf("no MCP protocol versions: use one or more of %s", strings.Join(SupportedProtocolVersions, ", "))
+	}
+	for _, v := range versions {
+		if !slices.Contains(SupportedProtocolVersions, v) {
+			return fmt.Errorf("unsupported MCP protocol version %q: use one or more of %s", v, strings.Join(SupportedProtocolVersions, ", "))
+		}
+	}
+	return nil
+}
+
+// ProtocolMiddleware rejects MCP requests the handler cannot read before
+// the SDK does so with a plain-text error: those whose MCP-Protocol-Version
+// header names a version outside versions, with 400, and POSTs whose body
+// is not JSON, with 415. A missing header is accepted, since clients send
+// it only once initialize has settled the version.
+func ProtocolMiddleware(versions []string) func(http.Handler) http.Handler {
+	supported := strings.Join(versions, ", ")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if v := r.Header.Get(HeaderProtocolVersion); v != "" && !slices.Contains(versions, v) {
+				WriteRPCError(w, http.StatusBadRequest, CodeInvalidRequest, ReasonUnsupportedVersion,
+					fmt.Sprintf("unsupported MCP-Protocol-Version %q; supported versions: %s", v, supported))
+				return
+			}
+			if r.Method == http.MethodPost {
+				if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+					WriteRPCError(w, http.StatusUnsupportedMediaType, CodeInvalidRequest, ReasonUnsupportedMediaType,
+						"Content-Type must be application/json")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateProtocolVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		wantErr  bool
+	}{
+		{name: "all", versions: SupportedProtocolVersions},
+		{name: "subset", versions: []string{"2025-06-18"}},
+		{name: "empty", versions: nil, wantErr: true},
+		{name: "unknown", versions: []string{"2025-06-18", "2023-01-01"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateProtocolVersions(tt.versions); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProtocolVersions(%v) error = %v, wantErr %v", tt.versions, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProtocolMiddleware(t *testing.T) {
+	handler := ProtocolMiddleware([]string{"2025-06-18", "2025-03-26"})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		version     string
+		contentType string
+		wantStatus  int
+		wantReason  string
+	}{
+		{name: "supported version", method: http.MethodPost, version: "2025-06-18", contentType: "application/json", wantStatus: http.StatusOK},
+		{name: "no version", method: http.MethodPost, contentType: "application/json; charset=utf-8", wantStatus: http.StatusOK},
+		{name: "unsupported version", method: http.MethodPost, version: "2024-11-05", contentType: "application/json", wantStatus: http.StatusBadRequest, wantReason: ReasonUnsupportedVersion},
+		{name: "unsupported version on GET", method: http.MethodGet, version: "bogus", wantStatus: http.StatusBadRequest, wantReason: ReasonUnsupportedVersion},
+		{name: "GET without content type", method: http.MethodGet, version: "2025-03-26", wantStatus: http.StatusOK},
+		{name: "form body", method: http.MethodPost, contentType: "application/x-www-form-urlencoded", wantStatus: http.StatusUnsupportedMediaType, wantReason: ReasonUnsupportedMediaType},
+		{name: "missing content type", method: http.MethodPost, wantStatus: http.StatusUnsupportedMediaType, wantReason: ReasonUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/mcp", strings.NewReader("{}"))
+			if tt.version != "" {
+				req.Header.Set(HeaderProtocolVersion, tt.version)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantReason == "" {
+				return
+			}
+			var body RPCError
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Error.Code != CodeInvalidRequest || body.Error.Data.Reason != tt.wantReason {
+				t.Errorf("error = %+v, want code %d reason %s", body.Error, CodeInvalidRequest, tt.wantReason)
+			}
+			if tt.wantReason == ReasonUnsupportedVersion && !strings.Contains(body.Error.Message, "2025-06-18, 2025-03-26") {
+				t.Errorf("message = %q, want the supported versions", body.Error.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Rate limit algorithms accepted by NewLimiter.
+const (
+	AlgorithmTokenBucket      = "token-bucket"
+	AlgorithmSlidingWindowLog = "sliding-window-log"
+	AlgorithmFixedWindow      = "fixed-window"
+)
+
+// Limiter decides whether a request identified by key may proceed. All
+// implementations are safe for concurrent use.
+type Limiter interface {
+	// Reserve records a request for key and reports whether it is allowed;
+	// when it is not, it also returns how long until one would be.
+	Reserve(key string) (bool, time.Duration)
+	// Cleanup forgets keys whose state is indistinguishable from a new
+	// key and returns how many were removed.
+	Cleanup() int
+	// Len returns the number of keys being tracked.
+	Len() int
+}
+
+// NewLimiter creates a Limiter allowing limit requests per window for each
+// key using algorithm:
+//
+//   - token-bucket refills continuously and allows bursts of burst requests
+//   - sliding-window-log allows at most limit requests in any window-long
+//     period, remembering each request time (memory grows with limit)
+//   - fixed-window counts requests in consecutive windows aligned to the
+//     clock, so up to twice limit may pass around a window boundary
+func NewLimiter(algorithm string, limit int, window time.Duration, burst int) (Limiter, error) {
+	if limit <= 0 || window <= 0 {
+		return nil, fmt.Errorf("rate limit must be positive, got %d per %v", limit, window)
+	}
+	switch algorithm {
+	case AlgorithmTokenBucket:
+		return NewRateLimiter(limit, window, burst), nil
+	case AlgorithmSlidingWindowLog:
+		return newSlidingWindowLog(limit, window), nil
+	case AlgorithmFixedWindow:
+		return newFixedWindow(limit, window), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit algorithm %q: use %s, %s, or %s", algorithm, AlgorithmTokenBucket, AlgorithmSlidingWindowLog, AlgorithmFixedWindow)
+	}
+}
+
+// rateLimitShards is the number of independently locked key maps. Keys are
+// spread across shards by hash, so concurrent requests from different
+// clients rarely contend on the same mutex.
+const rateLimitShards = 64
+
+// shardedMap holds per-key limiter state of type T.
+type shardedMap[T any] struct {
+	seed   maphash.Seed
+	shards []limiterShard[T]
+}
+
+// limiterShard holds the state of the keys that hash to it. The padding
+// keeps neighbouring shard mutexes off the same cache line.
+type limiterShard[T any] struct {
+	mu      sync.Mutex
+	entries map[string]*T
+	_       [48]byte
+}
+
+func newShardedMap[T any](n int) shardedMap[T] {
+	m := shardedMap[T]{seed: maphash.MakeSeed(), shards: make([]limiterShard[T], n)}
+	for i := range m.shards {
+		m.shards[i].entries = make(map[string]*T)
+	}
+	return m
+}
+
+// lock locks the shard owning key and returns its state, creating it with
+// init if needed. The caller must unlock the returned shard.
+func (m *shardedMap[T]) lock(key string, init func() *T) (*limiterShard[T], *T) {
+	s := &m.shards[maphash.String(m.seed, key)%uint64(len(m.shards))]
+	s.mu.Lock()
+	v, ok := s.entries[key]
+	if !ok {
+		v = init()
+		s.entries[key] = v
+	}
+	return s, v
+}
+
+// len returns the number of keys in all shards.
+func (m *shardedMap[T]) len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		n += len(s.entries)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// sweep deletes the entries for which idle returns true, locking shards one
+// at a time, and returns how many were removed.
+func (m *shardedMap[T]) sweep(idle func(*T) bool) int {
+	removed := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		for key, v := range s.entries {
+			if idle(v) {
+				delete(s.entries, key)
+				removed++
+			}
+		}
+		s.mu.Unlock()
+	}
+	return removed
+}
+
+// RateLimiter is a per-key token bucket limiter. Each key may make burst
+// requests at once and is refilled at limit requests per window.
+type RateLimiter struct {
+	rate    float64 // tokens per second
+	burst   float64
+	buckets shardedMap[tokenBucket]
+	now     func() time.Time
+}
+
+// tokenBucket is the state of one key.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing limit requests per window for
+// each key, with bursts of up to burst requests. A burst below 1 is
+// raised to 1.
+func NewRateLimiter(limit int, window time.Duration, burst int) *RateLimiter {
+	return newRateLimiter(limit, window, burst, rateLimitShards)
+}
+
+func newRateLimiter(limit int, window time.Duration, burst, shards int) *RateLimiter {
+	return &RateLimiter{
+		rate:    float64(limit) / window.Seconds(),
+		burst:   math.Max(float64(burst), 1),
+		buckets: newShardedMap[tokenBucket](shards),
+		now:     time.Now,
+	}
+}
+
+// Allow consumes a token for key and reports whether the request may
+// proceed.
+func (rl *RateLimiter) Allow(key string) bool {
+	ok, _ := rl.Reserve(key)
+	return ok
+}
+
+// Reserve is like Allow but when the request is rejected also returns how
+// long until a token is available.
+func (rl *RateLimiter) Reserve(key string) (bool, time.Duration) {
+	now := rl.now()
+	s, b := rl.buckets.lock(key, func() *tokenBucket { return &tokenBucket{tokens: rl.burst, last: now} })
+	defer s.mu.Unlock()
+
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(rl.burst, b.tokens+elapsed*rl.rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if rl.rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+	return false, time.Duration((1 - b.tokens) / rl.rate * float64(time.Second))
+}
+
+// Len returns the number of keys being tracked.
+func (rl *RateLimiter) Len() int {
+	return rl.buckets.len()
+}
+
+// Cleanup forgets keys whose buckets have refilled completely, which
+// behave exactly like unseen keys, and returns how many were removed.
+func (rl *RateLimiter) Cleanup() int {
+	now := rl.now()
+	return rl.buckets.sweep(func(b *tokenBucket) bool {
+		return b.tokens+now.Sub(b.last).Seconds()*rl.rate >= rl.burst
+	})
+}
+
+// Run calls Cleanup every interval until ctx is done.
+func (rl *RateLimiter) Run(ctx context.Context, interval time.Duration) {
+	RunCleanup(ctx, rl, interval)
+}
+
+// RunCleanup calls l.Cleanup every interval until ctx is done, recording
+// cleanup metrics and the number of tracked clients.
+func RunCleanup(ctx context.Context, l Limiter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			RateLimitCleanupRemoved.Add(float64(l.Cleanup()))
+			RateLimitCleanupDuration.Observe(time.Since(start).Seconds())
+			RateLimitTrackedClients.Set(float64(l.Len()))
+		}
+	}
+}
+
+// RateLimitMiddleware rejects requests the limiter does not allow with 429
+// Too Many Requests and a Retry-After header. Clients are keyed by the
+// address resolver returns. Rejections are recorded in rejections, which
+// may be nil.
+func RateLimitMiddleware(rl Limiter, resolver *ClientIPResolver, rejections *RejectionTracker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addr := resolver.ClientIP(r)
+			key := "unknown"
+			if addr.IsValid() {
+				key = addr.String()
+			}
+			if ok, wait := rl.Reserve(key); !ok {
+				RateLimitRequests.WithLabelValues(keyClass(addr), "rejected").Inc()
+				if rejections != nil {
+					rejections.Record(key)
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeAuthError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			RateLimitRequests.WithLabelValues(keyClass(addr), "allowed").Inc()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"cmp"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	RateLimitRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limit_requests_total",
+			Help: "Total number of requests checked by the rate limiter, by client address class and result.",
+		},
+		[]string{"class", "result"},
+	)
+
+	RateLimitTrackedClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rate_limit_tracked_clients",
+			Help: "Number of clients the rate limiter holds state for, as of the last cleanup.",
+		},
+	)
+
+	RateLimitCleanupRemoved = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_limit_cleanup_removed_total",
+			Help: "Total number of idle clients removed by rate limiter cleanup.",
+		},
+	)
+
+	RateLimitCleanupDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "rate_limit_cleanup_duration_seconds",
+			Help:    "Duration of rate limiter cleanup runs.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 8),
+		},
+	)
+)
+
+// keyClass returns the address class of a client for metric labels, which
+// must not contain the addresses themselves.
+func keyClass(addr netip.Addr) string {
+	switch {
+	case !addr.IsValid():
+		return "unknown"
+	case addr.Is4():
+		return "ipv4"
+	default:
+		return "ipv6"
+	}
+}
+
+// RejectionTracker counts rate limit rejections per client so the most
+// limited clients can be listed. It holds at most capacity clients; when
+// full, the client with the fewest rejections is evicted.
+type RejectionTracker struct {
+	mu       sync.Mutex
+	capacity int
+	clients  map[string]*RejectedClient
+	total    int64
+	now      func() time.Time
+}
+
+// RejectedClient is a client and its rate limit rejections.
+type RejectedClient struct {
+	Client       string    `json:"client"`
+	Rejected     int64     `json:"rejected"`
+	LastRejected time.Time `json:"last_rejected"`
+}
+
+// NewRejectionTracker creates a tracker holding up to capacity clients.
+func NewRejectionTracker(capacity int) *RejectionTracker {
+	return &RejectionTracker{capacity: max(capacity, 1), clients: make(map[string]*RejectedClient), now: time.Now}
+}
+
+// Record counts a rejection of client.
+func (t *RejectionTracker) Record(client string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.clients[client]
+	if !ok {
+		if len(t.clients) >= t.capacity {
+			t.evict()
+		}
+		c = &RejectedClient{Client: client}
+		t.clients[client] = c
+	}
+	c.Rejected++
+	c.LastRejected = t.now()
+	t.total++
+}
+
+// Total returns the number of rejections recorded, including those of
+// evicted clients.
+func (t *RejectionTracker) Total() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total
+}
+
+// evict removes the client with the fewest rejections, preferring the
+// least recently rejected. Callers hold t.mu.
+func (t *RejectionTracker) evict() {
+	var victim *RejectedClient
+	for _, c := range t.clients {
+		if victim == nil || c.Rejected < victim.Rejected || (c.Rejected == victim.Rejected && c.LastRejected.Before(victim.LastRejected)) {
+			victim = c
+		}
+	}
+	if victim != nil {
+		delete(t.clients, victim.Client)
+	}
+}
+
+// Top returns up to n clients with the most rejections, most first.
+func (t *RejectionTracker) Top(n int) []RejectedClient {
+	t.mu.Lock()
+	list := make([]RejectedClient, 0, len(t.clients))
+	for _, c := range t.clients {
+		list = append(list, *c)
+	}
+	t.mu.Unlock()
+
+	slices.SortFunc(list, func(a, b RejectedClient) int {
+		if c := cmp.Compare(b.Rejected, a.Rejected); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Client, b.Client)
+	})
+	return list[:min(n, len(list))]
+}
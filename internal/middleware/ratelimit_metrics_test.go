@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRejectionTracker(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	tracker := NewRejectionTracker(3)
+	tracker.now = clock.now
+
+	for client, n := range map[string]int{"a": 5, "b": 1, "c": 3} {
+		for range n {
+			tracker.Record(client)
+			clock.advance(time.Second)
+		}
+	}
+
+	top := tracker.Top(2)
+	if len(top) != 2 || top[0].Client != "a" || top[0].Rejected != 5 || top[1].Client != "c" {
+		t.Fatalf("Top(2) = %+v, want a then c", top)
+	}
+
+	// A new client evicts the one with the fewest rejections.
+	tracker.Record("d")
+	var clients []string
+	for _, c := range tracker.Top(10) {
+		clients = append(clients, c.Client)
+	}
+	if len(clients) != 3 || clients[2] != "d" {
+		t.Errorf("clients after eviction = %v, want [a c d]", clients)
+	}
+	if total := tracker.Total(); total != 10 {
+		t.Errorf("Total() = %d, want 10 including the evicted client", total)
+	}
+}
+
+func TestRateLimitMiddleware_Metrics(t *testing.T) {
+	rl := NewRateLimiter(1, time.Minute, 1)
+	resolver, _ := NewClientIPResolver(nil)
+	tracker := NewRejectionTracker(10)
+	handler := RateLimitMiddleware(rl, resolver, tracker)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	allowed := testutil.ToFloat64(RateLimitRequests.WithLabelValues("ipv6", "allowed"))
+	rejected := testutil.ToFloat64(RateLimitRequests.WithLabelValues("ipv6", "rejected"))
+	for range 3 {
+		req := httptest.NewRequest("GET", "/mcp", nil)
+		req.RemoteAddr = "[2001:db8::1]:443"
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if got := testutil.ToFloat64(RateLimitRequests.WithLabelValues("ipv6", "allowed")) - allowed; got != 1 {
+		t.Errorf("allowed count increased by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(RateLimitRequests.WithLabelValues("ipv6", "rejected")) - rejected; got != 2 {
+		t.Errorf("rejected count increased by %v, want 2", got)
+	}
+	if top := tracker.Top(1); len(top) != 1 || top[0].Client != "2001:db8::1" || top[0].Rejected != 2 {
+		t.Errorf("Top(1) = %+v, want 2001:db8::1 with 2 rejections", top)
+	}
+}
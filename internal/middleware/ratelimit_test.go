@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a controllable now function.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestRateLimiter(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	rl := NewRateLimiter(60, time.Minute, 3)
+	rl.now = clock.now
+
+	for i := range 3 {
+		if !rl.Allow("a") {
+			t.Fatalf("request %d within burst rejected", i+1)
+		}
+	}
+	ok, wait := rl.Reserve("a")
+	if ok {
+		t.Fatal("request beyond burst allowed")
+	}
+	if wait != time.Second {
+		t.Errorf("wait = %v, want 1s", wait)
+	}
+	if !rl.Allow("b") {
+		t.Error("other key rejected")
+	}
+
+	clock.advance(time.Second)
+	if !rl.Allow("a") {
+		t.Error("request after refill rejected")
+	}
+	if rl.Allow("a") {
+		t.Error("second request after one token refilled allowed")
+	}
+
+	// Buckets never exceed the burst size.
+	clock.advance(time.Hour)
+	for range 3 {
+		rl.Allow("a")
+	}
+	if rl.Allow("a") {
+		t.Error("bucket refilled beyond burst")
+	}
+}
+
+func TestRateLimiter_Cleanup(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	rl := NewRateLimiter(1, time.Second, 2)
+	rl.now = clock.now
+
+	rl.Allow("a")
+	rl.Allow("b")
+	rl.Allow("b")
+	if n := rl.Len(); n != 2 {
+		t.Fatalf("Len() = %d, want 2", n)
+	}
+
+	clock.advance(time.Second)
+	if n := rl.Cleanup(); n != 1 {
+		t.Errorf("Cleanup() removed %d, want 1 (only a is full)", n)
+	}
+	clock.advance(time.Second)
+	rl.Cleanup()
+	if n := rl.Len(); n != 0 {
+		t.Errorf("Len() after refill = %d, want 0", n)
+	}
+}
+
+func TestRateLimiter_Concurrent(t *testing.T) {
+	rl := NewRateLimiter(1, time.Hour, 100)
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 50 {
+				if rl.Allow("shared") {
+					allowed.Add(1)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	if n := allowed.Load(); n != 100 {
+		t.Errorf("allowed %d concurrent requests, want exactly the burst of 100", n)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	rl := NewRateLimiter(1, time.Minute, 1)
+	resolver, _ := NewClientIPResolver(nil)
+	handler := RateLimitMiddleware(rl, resolver, nil)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/mcp", nil)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("203.0.113.7:1000"); rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", rec.Code)
+	}
+	rec := serve("203.0.113.7:1001")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+	if rec := serve("198.51.100.1:1000"); rec.Code != http.StatusOK {
+		t.Errorf("other client status = %d, want 200", rec.Code)
+	}
+}
+
+// BenchmarkRateLimiter compares a single shard, equivalent to one global
+// mutex, with the sharded limiter under parallel load from many clients.
+func BenchmarkRateLimiter(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+	}
+
+	for _, shards := range []int{1, rateLimitShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			rl := newRateLimiter(1000, time.Second, 1000, shards)
+			var next atomic.Uint64
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				i := next.Add(1) * 7919
+				for pb.Next() {
+					rl.Allow(keys[i%uint64(len(keys))])
+					i++
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkRateLimiter_SingleKey(b *testing.B) {
+	rl := NewRateLimiter(1000, time.Second, 1000)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rl.Allow("203.0.113.7")
+		}
+	})
+}
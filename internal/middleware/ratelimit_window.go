@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"time"
+)
+
+// slidingWindowLog allows at most limit requests in any window-long period
+// by remembering the time of each allowed request.
+type slidingWindowLog struct {
+	limit  int
+	window time.Duration
+	logs   shardedMap[requestLog]
+	now    func() time.Time
+}
+
+// requestLog is a ring of the most recent allowed request times of a key.
+type requestLog struct {
+	times []time.Time
+	next  int // index of the oldest entry once times is full
+}
+
+func newSlidingWindowLog(limit int, window time.Duration) *slidingWindowLog {
+	return &slidingWindowLog{limit: limit, window: window, logs: newShardedMap[requestLog](rateLimitShards), now: time.Now}
+}
+
+// Reserve implements Limiter.
+func (l *slidingWindowLog) Reserve(key string) (bool, time.Duration) {
+	now := l.now()
+	s, log := l.logs.lock(key, func() *requestLog { return &requestLog{times: make([]time.Time, 0, min(l.limit, 16))} })
+	defer s.mu.Unlock()
+
+	if len(log.times) < l.limit {
+		log.times = append(log.times, now)
+		return true, 0
+	}
+	// The log is full: the oldest entry decides whether another fits.
+	oldest := log.times[log.next]
+	if expires := oldest.Add(l.window); now.Before(expires) {
+		return false, expires.Sub(now)
+	}
+	log.times[log.next] = now
+	log.next = (log.next + 1) % l.limit
+	return true, 0
+}
+
+// Cleanup implements Limiter, forgetting keys with no request in the last
+// window.
+func (l *slidingWindowLog) Cleanup() int {
+	cutoff := l.now().Add(-l.window)
+	return l.logs.sweep(func(log *requestLog) bool {
+		newest := log.times[(log.next+len(log.times)-1)%len(log.times)]
+		return !newest.After(cutoff)
+	})
+}
+
+// Len implements Limiter.
+func (l *slidingWindowLog) Len() int {
+	return l.logs.len()
+}
+
+// fixedWindow counts requests in consecutive windows aligned to the clock.
+type fixedWindow struct {
+	limit    int
+	window   time.Duration
+	counters shardedMap[windowCount]
+	now      func() time.Time
+}
+
+// windowCount is the request count of a key in the window starting at start.
+type windowCount struct {
+	start time.Time
+	count int
+}
+
+func newFixedWindow(limit int, window time.Duration) *fixedWindow {
+	return &fixedWindow{limit: limit, window: window, counters: newShardedMap[windowCount](rateLimitShards), now: time.Now}
+}
+
+// Reserve implements Limiter.
+func (l *fixedWindow) Reserve(key string) (bool, time.Duration) {
+	now := l.now()
+	start := now.Truncate(l.window)
+	s, c := l.counters.lock(key, func() *windowCount { return &windowCount{start: start} })
+	defer s.mu.Unlock()
+
+	if !c.start.Equal(start) {
+		c.start, c.count = start, 0
+	}
+	if c.count < l.limit {
+		c.count++
+		return true, 0
+	}
+	return false, start.Add(l.window).Sub(now)
+}
+
+// Cleanup implements Limiter, forgetting keys whose window has ended.
+func (l *fixedWindow) Cleanup() int {
+	start := l.now().Truncate(l.window)
+	return l.counters.sweep(func(c *windowCount) bool {
+		return c.start.Before(start)
+	})
+}
+
+// Len implements Limiter.
+func (l *fixedWindow) Len() int {
+	return l.counters.len()
+}
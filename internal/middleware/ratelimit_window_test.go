@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewLimiter(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmTokenBucket, AlgorithmSlidingWindowLog, AlgorithmFixedWindow} {
+		l, err := NewLimiter(algorithm, 2, time.Hour, 2)
+		if err != nil {
+			t.Fatalf("NewLimiter(%q) error = %v", algorithm, err)
+		}
+		for i := range 2 {
+			if ok, _ := l.Reserve("a"); !ok {
+				t.Errorf("%s: request %d rejected", algorithm, i+1)
+			}
+		}
+		if ok, wait := l.Reserve("a"); ok || wait <= 0 {
+			t.Errorf("%s: third request = (%v, %v), want rejected with a wait", algorithm, ok, wait)
+		}
+	}
+
+	if _, err := NewLimiter("leaky", 1, time.Second, 1); err == nil {
+		t.Error("NewLimiter(unknown) error = nil, want error")
+	}
+	if _, err := NewLimiter(AlgorithmFixedWindow, 0, time.Second, 1); err == nil {
+		t.Error("NewLimiter(limit 0) error = nil, want error")
+	}
+}
+
+func TestSlidingWindowLog(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	l := newSlidingWindowLog(3, time.Minute)
+	l.now = clock.now
+
+	// Requests at 0s, 20s, and 40s fill the window.
+	for range 3 {
+		if ok, _ := l.Reserve("a"); !ok {
+			t.Fatal("request within limit rejected")
+		}
+		clock.advance(20 * time.Second)
+	}
+	// At 60s the first request has just left the window.
+	if ok, _ := l.Reserve("a"); !ok {
+		t.Error("request after oldest expired rejected")
+	}
+	ok, wait := l.Reserve("a")
+	if ok {
+		t.Fatal("request beyond limit allowed")
+	}
+	if wait != 20*time.Second {
+		t.Errorf("wait = %v, want 20s until the 20s request expires", wait)
+	}
+
+	if n := l.Cleanup(); n != 0 {
+		t.Errorf("Cleanup() removed %d active keys", n)
+	}
+	clock.advance(time.Minute)
+	if n := l.Cleanup(); n != 1 || l.Len() != 0 {
+		t.Errorf("Cleanup() = %d, Len() = %d, want 1 and 0", n, l.Len())
+	}
+}
+
+func TestFixedWindow(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1200, 0)} // on a minute boundary
+	l := newFixedWindow(2, time.Minute)
+	l.now = clock.now
+
+	clock.advance(50 * time.Second)
+	l.Reserve("a")
+	l.Reserve("a")
+	ok, wait := l.Reserve("a")
+	if ok {
+		t.Fatal("request beyond limit allowed")
+	}
+	if wait != 10*time.Second {
+		t.Errorf("wait = %v, want 10s until the next window", wait)
+	}
+
+	// A new window resets the count, so bursts straddle the boundary.
+	clock.advance(10 * time.Second)
+	for range 2 {
+		if ok, _ := l.Reserve("a"); !ok {
+			t.Error("request in new window rejected")
+		}
+	}
+
+	if n := l.Cleanup(); n != 0 {
+		t.Errorf("Cleanup() removed %d keys in the current window", n)
+	}
+	clock.advance(time.Minute)
+	if n := l.Cleanup(); n != 1 {
+		t.Errorf("Cleanup() = %d, want 1", n)
+	}
+}
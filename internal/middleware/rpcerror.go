@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// JSON-RPC error codes of rejected MCP requests, from the range JSON-RPC
+// reserves for implementation-defined server errors.
+const (
+	// CodeInvalidRequest is JSON-RPC's own code for a request the server
+	// will not read, such as one in an unsupported protocol version.
+	CodeInvalidRequest = -32600
+	// CodeUnauthorized means credentials were missing or invalid.
+	CodeUnauthorized = -32001
+	// CodeForbidden means the credentials do not grant access.
+	CodeForbidden = -32003
+	// CodeUnavailable means credentials could not be checked.
+	CodeUnavailable = -32004
+	// CodeRateLimited means the client sent too many requests.
+	CodeRateLimited = -32029
+)
+
+// Reasons in the data of JSON-RPC auth errors, stable for clients to match.
+const (
+	ReasonMissingCredentials = "missing_credentials"
+	ReasonInvalidCredentials = "invalid_credentials"
+	ReasonInvalidToken       = "invalid_token"
+	ReasonInsufficientScope  = "insufficient_scope"
+	ReasonUnavailable        = "temporarily_unavailable"
+)
+
+// Reasons in the data of JSON-RPC errors of requests the MCP handler
+// cannot read.
+const (
+	ReasonUnsupportedVersion   = "unsupported_protocol_version"
+	ReasonUnsupportedMediaType = "unsupported_media_type"
+)
+
+// Reasons in the data of JSON-RPC errors of requests refused for their
+// tenant.
+const (
+	ReasonUnknownTenant  = "unknown_tenant"
+	ReasonTenantMismatch = "tenant_mismatch"
+	ReasonRateLimited    = "rate_limited"
+)
+
+// RPCError is the body of a rejected MCP request: a JSON-RPC response
+// with a null ID, since the request was not read.
+type RPCError struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *struct{}       `json:"id"`
+	Error   RPCErrorDetails `json:"error"`
+}
+
+// RPCErrorDetails is the error object of an RPCError.
+type RPCErrorDetails struct {
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Data    RPCErrorData `json:"data"`
+}
+
+// RPCErrorData says why the request was rejected.
+type RPCErrorData struct {
+	Reason string `json:"reason"`
+	Status int    `json:"status"`
+}
+
+// WriteRPCError writes a JSON-RPC error body with HTTP status. Headers
+// such as WWW-Authenticate must be set before calling it.
+func WriteRPCError(w http.ResponseWriter, status, code int, reason, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(RPCError{
+		JSONRPC: "2.0",
+		Error: RPCErrorDetails{
+			Code:    code,
+			Message: message,
+			Data:    RPCErrorData{Reason: reason, Status: status},
+		},
+	})
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	InflightRequests = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "http_inflight_requests",
+			Help: "Number of requests currently holding a load shedding slot.",
+		},
+	)
+
+	ShedCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_shed_total",
+			Help: "Total number of HTTP requests rejected by load shedding, by reason.",
+		},
+		[]string{"reason"},
+	)
+)
+
+// LoadShedder caps the number of requests processed concurrently. Requests
+// beyond the cap wait in a bounded queue for a short time and are shed if
+// no slot frees up.
+type LoadShedder struct {
+	slots    chan struct{}
+	maxQueue int64
+	queued   atomic.Int64
+	wait     time.Duration
+}
+
+// NewLoadShedder allows maxInflight concurrent requests, with up to maxQueue
+// more waiting at most wait for a slot.
+func NewLoadShedder(maxInflight, maxQueue int, wait time.Duration) *LoadShedder {
+	return &LoadShedder{
+		slots:    make(chan struct{}, maxInflight),
+		maxQueue: int64(maxQueue),
+		wait:     wait,
+	}
+}
+
+// acquire takes a slot, queueing if needed, and returns "" on success or the
+// reason the request is shed: "queue_full", "timeout", or "canceled".
+func (s *LoadShedder) acquire(r *http.Request) string {
+	select {
+	case s.slots <- struct{}{}:
+		return ""
+	default:
+	}
+
+	if s.queued.Add(1) > s.maxQueue {
+		s.queued.Add(-1)
+		return "queue_full"
+	}
+	defer s.queued.Add(-1)
+
+	timer := time.NewTimer(s.wait)
+	defer timer.Stop()
+	select {
+	case s.slots <- struct{}{}:
+		return ""
+	case <-timer.C:
+		return "timeout"
+	case <-r.Context().Done():
+		return "canceled"
+	}
+}
+
+// release frees a slot taken by acquire.
+func (s *LoadShedder) release() {
+	<-s.slots
+}
+
+// LoadShedMiddleware limits concurrent requests to paths under
+// protectedPrefixes, answering 503 Service Unavailable with Retry-After
+// when the shedder is saturated. GET requests, which on /mcp open
+// long-lived event streams, are not counted.
+func LoadShedMiddleware(s *LoadShedder, protectedPrefixes []string) func(http.Handler) http.Handler {
+	retryAfter := strconv.Itoa(max(1, int(s.wait.Round(time.Second)/time.Second)))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || !isProtectedPath(r.URL.Path, protectedPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if reason := s.acquire(r); reason != "" {
+				ShedCount.WithLabelValues(reason).Inc()
+				w.Header().Set("Retry-After", retryAfter)
+				writeAuthError(w, http.StatusServiceUnavailable, "server is overloaded")
+				return
+			}
+			InflightRequests.Inc()
+			defer func() {
+				InflightRequests.Dec()
+				s.release()
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
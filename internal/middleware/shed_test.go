@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadShedMiddleware(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	handler := LoadShedMiddleware(NewLoadShedder(1, 1, 50*time.Millisecond), []string{"/mcp"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/mcp" && r.Method == http.MethodPost {
+				started <- struct{}{}
+				<-release
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	// Occupy the only slot.
+	var wg sync.WaitGroup
+	var first *httptest.ResponseRecorder
+	wg.Go(func() { first = serve(http.MethodPost, "/mcp") })
+	<-started
+
+	// Unprotected paths and GET streams are not limited.
+	if rec := serve(http.MethodPost, "/health"); rec.Code != http.StatusOK {
+		t.Errorf("unprotected path status = %d, want 200", rec.Code)
+	}
+	if rec := serve(http.MethodGet, "/mcp"); rec.Code != http.StatusOK {
+		t.Errorf("GET status = %d, want 200", rec.Code)
+	}
+
+	// A queued request times out.
+	rec := serve(http.MethodPost, "/mcp")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("queued request status = %d, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	// A queued request gets the slot once it frees up.
+	var queued *httptest.ResponseRecorder
+	wg.Go(func() { queued = serve(http.MethodPost, "/mcp") })
+	time.Sleep(10 * time.Millisecond)
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	wg.Wait()
+	if first.Code != http.StatusOK || queued.Code != http.StatusOK {
+		t.Errorf("statuses = %d, %d, want 200, 200", first.Code, queued.Code)
+	}
+}
+
+func TestLoadShedder_QueueFull(t *testing.T) {
+	s := NewLoadShedder(1, 0, time.Second)
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if reason := s.acquire(req); reason != "" {
+		t.Fatalf("first acquire = %q, want success", reason)
+	}
+	if reason := s.acquire(req); reason != "queue_full" {
+		t.Errorf("acquire with no queue = %q, want queue_full", reason)
+	}
+	s.release()
+	if reason := s.acquire(req); reason != "" {
+		t.Errorf("acquire after release = %q, want success", reason)
+	}
+}
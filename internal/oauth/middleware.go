@@ -0,0 +1,108 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims of the token that authorized the
+// request, or nil.
+func ClaimsFromContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(claimsKey{}).(*Claims)
+	return claims
+}
+
+// Middleware requires a valid bearer token on paths under protectedPrefixes.
+// Rejections carry an RFC 6750 WWW-Authenticate challenge pointing at the
+// protected resource metadata, so MCP clients can start authorization.
+func Middleware(v *Verifier, protectedPrefixes []string) func(http.Handler) http.Handler {
+	metadataURL := v.cfg.MetadataURL()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.ContainsFunc(protectedPrefixes, func(p string) bool { return strings.HasPrefix(r.URL.Path, p) }) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			token = strings.TrimSpace(token)
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				// RFC 6750 section 3.1: no error code when no credentials were sent
+				challenge(w, metadataURL, http.StatusUnauthorized, "", "missing bearer token", nil)
+				return
+			}
+
+			claims, err := v.Verify(r.Context(), token)
+			if err != nil {
+				if !errors.Is(err, ErrInvalidToken) {
+					logger.Error("bearer token verification unavailable", "error", err)
+					challenge(w, metadataURL, http.StatusServiceUnavailable, "temporarily_unavailable", "token verification is unavailable", nil)
+					return
+				}
+				challenge(w, metadataURL, http.StatusUnauthorized, "invalid_token", strings.TrimPrefix(err.Error(), ErrInvalidToken.Error()+": "), nil)
+				return
+			}
+			for _, scope := range v.cfg.RequiredScopes {
+				if !slices.Contains(claims.Scopes, scope) {
+					challenge(w, metadataURL, http.StatusForbidden, "insufficient_scope", "token lacks required scope "+scope, v.cfg.RequiredScopes)
+					return
+				}
+			}
+
+			r.Header.Set(middleware.HeaderCaller, "oauth:"+claims.Subject)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
+		})
+	}
+}
+
+// challenge writes a JSON-RPC error response with a Bearer
+// WWW-Authenticate header. code is an RFC 6750 error code, or empty when no
+// token was presented; it becomes the reason in the error data.
+func challenge(w http.ResponseWriter, metadataURL string, status int, code, description string, scopes []string) {
+	params := []string{`resource_metadata=` + quote(metadataURL)}
+	if code != "" {
+		params = append(params, `error=`+quote(code), `error_description=`+quote(description))
+	}
+	if len(scopes) > 0 {
+		params = append(params, `scope=`+quote(strings.Join(scopes, " ")))
+	}
+	if status != http.StatusServiceUnavailable {
+		w.Header().Set("WWW-Authenticate", "Bearer "+strings.Join(params, ", "))
+	}
+
+	rpcCode, reason := middleware.CodeUnauthorized, code
+	switch status {
+	case http.StatusForbidden:
+		rpcCode = middleware.CodeForbidden
+	case http.StatusServiceUnavailable:
+		rpcCode = middleware.CodeUnavailable
+	}
+	if code == "" {
+		reason = middleware.ReasonMissingCredentials
+	}
+	middleware.WriteRPCError(w, status, rpcCode, reason, description)
+}
+
+// quote renders s as an RFC 9110 quoted-string. error_description may
+// only contain printable ASCII other than '"' and '\', so those are
+// replaced rather than escaped.
+func quote(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '\''
+		}
+		return r
+	}, s)
+	return `"` + s + `"`
+}
@@ -0,0 +1,335 @@
+// Package oauth makes the HTTP transport an OAuth 2.1 resource server as
+// described by the MCP authorization specification: it validates bearer
+// tokens issued by a configured authorization server and publishes
+// protected resource metadata (RFC 9728) so clients can discover it.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/jose"
+)
+
+// MetadataPath is the well-known path of the protected resource metadata.
+const MetadataPath = "/.well-known/oauth-protected-resource"
+
+// maxFetchBytes caps the size of fetched metadata and JWKS documents.
+const maxFetchBytes = 1 << 20
+
+// minJWKSRefresh is how soon the JWKS may be re-fetched to look for a key
+// that was not in the cached set.
+const minJWKSRefresh = 30 * time.Second
+
+// ErrInvalidToken is wrapped by every error caused by the token itself, as
+// opposed to a failure to fetch the keys needed to verify it.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Config holds resource server settings.
+type Config struct {
+	Issuer          string        // authorization server issuer; empty disables OAuth
+	Resource        string        // canonical URL of the MCP endpoint
+	Audience        []string      // accepted aud values; defaults to Resource
+	JWKSURL         string        // discovered from the issuer metadata when empty
+	RequiredScopes  []string      // scopes every token must carry
+	ScopesSupported []string      // advertised in metadata; defaults to RequiredScopes
+	Leeway          time.Duration // clock skew tolerance for exp and nbf
+	JWKSCacheTTL    time.Duration // how long fetched keys are trusted
+	Timeout         time.Duration // bound on discovery and JWKS requests
+}
+
+// LoadConfig reads resource server settings from the environment.
+func LoadConfig() Config {
+	cfg := Config{
+		Issuer:          config.GetEnv("OAUTH_ISSUER", ""),
+		Resource:        config.GetEnv("OAUTH_RESOURCE", ""),
+		Audience:        config.GetEnvList("OAUTH_AUDIENCE"),
+		JWKSURL:         config.GetEnv("OAUTH_JWKS_URL", ""),
+		RequiredScopes:  config.GetEnvList("OAUTH_REQUIRED_SCOPES"),
+		ScopesSupported: config.GetEnvList("OAUTH_SCOPES_SUPPORTED"),
+		Leeway:          config.GetEnvDuration("OAUTH_LEEWAY", time.Minute),
+		JWKSCacheTTL:    config.GetEnvDuration("OAUTH_JWKS_CACHE_TTL", time.Hour),
+		Timeout:         config.GetEnvDuration("OAUTH_TIMEOUT", 10*time.Second),
+	}
+	if len(cfg.Audience) == 0 && cfg.Resource != "" {
+		cfg.Audience = []string{cfg.Resource}
+	}
+	if len(cfg.ScopesSupported) == 0 {
+		cfg.ScopesSupported = cfg.RequiredScopes
+	}
+	return cfg
+}
+
+// Enabled reports whether an issuer is configured.
+func (c Config) Enabled() bool {
+	return c.Issuer != ""
+}
+
+// MetadataURL returns the RFC 9728 metadata URL for Resource: the
+// well-known path inserted between its host and path.
+func (c Config) MetadataURL() string {
+	u, err := url.Parse(c.Resource)
+	if err != nil {
+		return ""
+	}
+	u.Path = MetadataPath + strings.TrimSuffix(u.Path, "/")
+	u.RawPath, u.RawQuery, u.Fragment = "", "", ""
+	return u.String()
+}
+
+// Claims is the validated content of an access token.
+type Claims struct {
+	Subject   string
+	ClientID  string
+	Scopes    []string
+	ExpiresAt time.Time
+	Raw       map[string]any
+}
+
+// Verifier validates JWT access tokens against the issuer's signing keys.
+type Verifier struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	jwksURL string
+	keys    *jose.JWKSet
+	fetched time.Time
+}
+
+// NewVerifier checks cfg and returns a Verifier that fetches keys with
+// client, or http.DefaultClient when nil.
+func NewVerifier(cfg Config, client *http.Client) (*Verifier, error) {
+	if err := checkURL("OAUTH_ISSUER", cfg.Issuer); err != nil {
+		return nil, err
+	}
+	if err := checkURL("OAUTH_RESOURCE", cfg.Resource); err != nil {
+		return nil, err
+	}
+	if cfg.JWKSURL != "" {
+		if err := checkURL("OAUTH_JWKS_URL", cfg.JWKSURL); err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.Audience) == 0 {
+		cfg.Audience = []string{cfg.Resource}
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Verifier{cfg: cfg, client: client, now: time.Now, jwksURL: cfg.JWKSURL}, nil
+}
+
+// checkURL requires value to be an absolute http(s) URL without a fragment.
+func checkURL(name, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.Fragment != "" {
+		return fmt.Errorf("%s=%q must be an absolute http(s) URL without a fragment", name, value)
+	}
+	return nil
+}
+
+// Metadata returns the protected resource metadata document.
+func (v *Verifier) Metadata() *oauthex.ProtectedResourceMetadata {
+	return &oauthex.ProtectedResourceMetadata{
+		Resource:               v.cfg.Resource,
+		AuthorizationServers:   []string{v.cfg.Issuer},
+		ScopesSupported:        v.cfg.ScopesSupported,
+		BearerMethodsSupported: []string{"header"},
+		ResourceName:           "mcp-server",
+	}
+}
+
+// Verify validates the signature, issuer, audience, and validity window of
+// token and returns its claims. Scopes are returned but not checked.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	tok, err := jose.Parse(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if alg := tok.Alg(); alg == "" || strings.EqualFold(alg, "none") || strings.HasPrefix(alg, "HS") {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, alg)
+	}
+
+	keys, err := v.signingKeys(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := tok.VerifyJWKS(keys); err != nil {
+		// The issuer may have rotated keys since they were cached.
+		fresh, ferr := v.signingKeys(ctx, true)
+		if ferr != nil || fresh == keys {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		if err := tok.VerifyJWKS(fresh); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+	}
+
+	return v.validateClaims(tok.Claims)
+}
+
+// validateClaims checks the registered claims of a verified token.
+func (v *Verifier) validateClaims(raw map[string]any) (*Claims, error) {
+	if iss, _ := raw["iss"].(string); iss != v.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q is not trusted", ErrInvalidToken, iss)
+	}
+	if !slices.ContainsFunc(stringList(raw["aud"]), func(aud string) bool { return slices.Contains(v.cfg.Audience, aud) }) {
+		return nil, fmt.Errorf("%w: token audience does not include this resource", ErrInvalidToken)
+	}
+
+	now := v.now()
+	exp, ok := jose.NumericDate(raw["exp"])
+	if !ok {
+		return nil, fmt.Errorf("%w: token has no expiration", ErrInvalidToken)
+	}
+	if !now.Before(exp.Add(v.cfg.Leeway)) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if nbf, ok := jose.NumericDate(raw["nbf"]); ok && now.Add(v.cfg.Leeway).Before(nbf) {
+		return nil, fmt.Errorf("%w: token is not yet valid", ErrInvalidToken)
+	}
+
+	claims := &Claims{ExpiresAt: exp, Raw: raw}
+	claims.Subject, _ = raw["sub"].(string)
+	claims.ClientID, _ = raw["client_id"].(string)
+	if scope, ok := raw["scope"].(string); ok {
+		claims.Scopes = strings.Fields(scope)
+	} else {
+		claims.Scopes = stringList(raw["scp"])
+	}
+	return claims, nil
+}
+
+// stringList reads a claim that may be a string or an array of strings.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+// signingKeys returns the cached JWKS, fetching it when it is missing or
+// older than JWKSCacheTTL. force re-fetches unless the keys were fetched
+// within minJWKSRefresh, which bounds the fetches tokens with unknown key
+// IDs can cause. A failed refresh keeps serving the cached keys.
+func (v *Verifier) signingKeys(ctx context.Context, force bool) (*jose.JWKSet, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	age := v.now().Sub(v.fetched)
+	if v.keys != nil && age < v.cfg.JWKSCacheTTL && (!force || age < minJWKSRefresh) {
+		return v.keys, nil
+	}
+
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		if v.keys != nil {
+			return v.keys, nil
+		}
+		return nil, err
+	}
+	v.keys, v.fetched = keys, v.now()
+	return keys, nil
+}
+
+// fetchKeys downloads the JWKS, discovering its URL from the issuer's
+// authorization server metadata (RFC 8414) the first time if needed.
+// Callers hold v.mu.
+func (v *Verifier) fetchKeys(ctx context.Context) (*jose.JWKSet, error) {
+	if v.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.cfg.Timeout)
+		defer cancel()
+	}
+
+	if v.jwksURL == "" {
+		jwksURL, err := v.discoverJWKS(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("discover authorization server: %w", err)
+		}
+		v.jwksURL = jwksURL
+	}
+
+	data, err := v.get(ctx, v.jwksURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	return jose.ParseJWKS(data)
+}
+
+// discoverJWKS reads jwks_uri from the issuer's metadata, trying the RFC
+// 8414 and OpenID Connect well-known paths in turn.
+func (v *Verifier) discoverJWKS(ctx context.Context) (string, error) {
+	issuer, err := url.Parse(v.cfg.Issuer)
+	if err != nil {
+		return "", err
+	}
+	var errs []error
+	for _, wellKnown := range []string{"/.well-known/oauth-authorization-server", "/.well-known/openid-configuration"} {
+		u := *issuer
+		u.Path = wellKnown + strings.TrimSuffix(issuer.Path, "/")
+		data, err := v.get(ctx, u.String())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", u.String(), err))
+			continue
+		}
+		var meta struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := json.Unmarshal(data, &meta); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", u.String(), err))
+			continue
+		}
+		// RFC 8414 section 3.3: the metadata must be for this issuer
+		if meta.Issuer != v.cfg.Issuer {
+			return "", fmt.Errorf("metadata issuer %q does not match %q", meta.Issuer, v.cfg.Issuer)
+		}
+		if err := checkURL("jwks_uri", meta.JWKSURI); err != nil {
+			return "", err
+		}
+		return meta.JWKSURI, nil
+	}
+	return "", errors.Join(errs...)
+}
+
+// get fetches target and returns a body of at most maxFetchBytes.
+func (v *Verifier) get(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxFetchBytes))
+}
@@ -0,0 +1,307 @@
+package oauth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+// authServer is a fake authorization server publishing metadata and a JWKS.
+type authServer struct {
+	*httptest.Server
+	key         *rsa.PrivateKey
+	kid         string
+	jwksFetches atomic.Int32
+}
+
+func newAuthServer(t *testing.T) *authServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := &authServer{key: key, kid: "k1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/oauth-authorization-server", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": as.URL, "jwks_uri": as.URL + "/jwks"})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, _ *http.Request) {
+		as.jwksFetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": as.kid,
+			"alg": "RS256",
+			"use": "sig",
+			"n":   b64(as.key.N.Bytes()),
+			"e":   b64(big.NewInt(int64(as.key.E)).Bytes()),
+		}}})
+	})
+	as.Server = httptest.NewServer(mux)
+	t.Cleanup(as.Close)
+	return as
+}
+
+// token signs claims with the server key.
+func (as *authServer) token(t *testing.T, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "at+jwt", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, as.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + b64(sig)
+}
+
+// claims returns valid claims for the test resource, with overrides.
+func (as *authServer) claims(overrides map[string]any) map[string]any {
+	claims := map[string]any{
+		"iss":   as.URL,
+		"aud":   "https://mcp.example.com/mcp",
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "mcp:tools other",
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+			continue
+		}
+		claims[k] = v
+	}
+	return claims
+}
+
+func b64(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+
+func newVerifier(t *testing.T, as *authServer, scopes ...string) *Verifier {
+	t.Helper()
+	v, err := NewVerifier(Config{
+		Issuer:         as.URL,
+		Resource:       "https://mcp.example.com/mcp",
+		RequiredScopes: scopes,
+		JWKSCacheTTL:   time.Hour,
+	}, as.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	as := newAuthServer(t)
+	v := newVerifier(t, as)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{name: "valid", token: as.token(t, "k1", as.claims(nil))},
+		{name: "audience array", token: as.token(t, "k1", as.claims(map[string]any{"aud": []string{"other", "https://mcp.example.com/mcp"}}))},
+		{name: "wrong audience", token: as.token(t, "k1", as.claims(map[string]any{"aud": "https://other.example.com"})), wantErr: "audience"},
+		{name: "missing audience", token: as.token(t, "k1", as.claims(map[string]any{"aud": nil})), wantErr: "audience"},
+		{name: "wrong issuer", token: as.token(t, "k1", as.claims(map[string]any{"iss": "https://evil.example.com"})), wantErr: "issuer"},
+		{name: "expired", token: as.token(t, "k1", as.claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})), wantErr: "expired"},
+		{name: "no expiration", token: as.token(t, "k1", as.claims(map[string]any{"exp": nil})), wantErr: "expiration"},
+		{name: "not yet valid", token: as.token(t, "k1", as.claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})), wantErr: "not yet valid"},
+		{name: "unknown key", token: as.token(t, "k2", as.claims(nil)), wantErr: "no JWKS key"},
+		{name: "tampered", token: as.token(t, "k1", as.claims(nil)) + "x", wantErr: "invalid token"},
+		{name: "malformed", token: "not.a-token", wantErr: "malformed"},
+		{name: "unsigned", token: b64([]byte(`{"alg":"none"}`)) + "." + b64([]byte(`{}`)) + ".", wantErr: "unsupported algorithm"},
+		{name: "hmac", token: b64([]byte(`{"alg":"HS256"}`)) + "." + b64([]byte(`{}`)) + ".c2ln", wantErr: "unsupported algorithm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(t.Context(), tt.token)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if claims.Subject != "user-1" || strings.Join(claims.Scopes, " ") != "mcp:tools other" {
+					t.Errorf("Verify() claims = %+v", claims)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerify_KeyCaching(t *testing.T) {
+	as := newAuthServer(t)
+	v := newVerifier(t, as)
+	token := as.token(t, "k1", as.claims(nil))
+
+	for range 3 {
+		if _, err := v.Verify(t.Context(), token); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := as.jwksFetches.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+
+	// An unknown kid may trigger one refresh, but not one per token.
+	for range 3 {
+		_, _ = v.Verify(t.Context(), as.token(t, "k2", as.claims(nil)))
+	}
+	if n := as.jwksFetches.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times after unknown kids within %v, want 1", n, minJWKSRefresh)
+	}
+
+	// Keys rotated after the refresh interval are picked up.
+	now := time.Now()
+	v.now = func() time.Time { return now.Add(time.Minute) }
+	as.kid = "k2"
+	if _, err := v.Verify(t.Context(), as.token(t, "k2", as.claims(nil))); err != nil {
+		t.Fatalf("Verify() after rotation error = %v", err)
+	}
+}
+
+func TestMetadataURL(t *testing.T) {
+	tests := []struct {
+		resource string
+		want     string
+	}{
+		{resource: "https://mcp.example.com/mcp", want: "https://mcp.example.com/.well-known/oauth-protected-resource/mcp"},
+		{resource: "https://mcp.example.com/", want: "https://mcp.example.com/.well-known/oauth-protected-resource"},
+		{resource: "https://mcp.example.com", want: "https://mcp.example.com/.well-known/oauth-protected-resource"},
+	}
+	for _, tt := range tests {
+		if got := (Config{Resource: tt.resource}).MetadataURL(); got != tt.want {
+			t.Errorf("MetadataURL(%q) = %q, want %q", tt.resource, got, tt.want)
+		}
+	}
+}
+
+func TestNewVerifier_InvalidConfig(t *testing.T) {
+	tests := []Config{
+		{Issuer: "issuer", Resource: "https://mcp.example.com/mcp"},
+		{Issuer: "https://as.example.com"},
+		{Issuer: "https://as.example.com", Resource: "https://mcp.example.com/mcp#frag"},
+		{Issuer: "https://as.example.com", Resource: "https://mcp.example.com/mcp", JWKSURL: "file:///keys"},
+	}
+	for _, cfg := range tests {
+		if _, err := NewVerifier(cfg, nil); err == nil {
+			t.Errorf("NewVerifier(%+v) error = nil, want error", cfg)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	as := newAuthServer(t)
+	v := newVerifier(t, as, "mcp:tools")
+	limited := as.token(t, "k1", as.claims(map[string]any{"scope": "other"}))
+
+	var gotSubject string
+	handler := Middleware(v, []string{"/mcp"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims := ClaimsFromContext(r.Context()); claims != nil {
+			gotSubject = claims.Subject
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	const metadata = `resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource/mcp"`
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantStatus    int
+		wantChallenge []string
+		wantReason    string
+	}{
+		{name: "unprotected path", path: "/health", wantStatus: http.StatusOK},
+		{name: "valid token", path: "/mcp", authorization: "Bearer " + as.token(t, "k1", as.claims(nil)), wantStatus: http.StatusOK},
+		{name: "lowercase scheme", path: "/mcp", authorization: "bearer " + as.token(t, "k1", as.claims(nil)), wantStatus: http.StatusOK},
+		{name: "missing token", path: "/mcp", wantStatus: http.StatusUnauthorized, wantChallenge: []string{"Bearer " + metadata}, wantReason: "missing_credentials"},
+		{name: "basic auth", path: "/mcp", authorization: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized, wantChallenge: []string{metadata}, wantReason: "missing_credentials"},
+		{
+			name:          "expired token",
+			path:          "/mcp",
+			authorization: "Bearer " + as.token(t, "k1", as.claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})),
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: []string{metadata, `error="invalid_token"`, `error_description="token expired"`},
+			wantReason:    "invalid_token",
+		},
+		{
+			name:          "insufficient scope",
+			path:          "/mcp",
+			authorization: "Bearer " + limited,
+			wantStatus:    http.StatusForbidden,
+			wantChallenge: []string{metadata, `error="insufficient_scope"`, `scope="mcp:tools"`},
+			wantReason:    "insufficient_scope",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSubject = ""
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			challenge := rec.Header().Get("WWW-Authenticate")
+			for _, want := range tt.wantChallenge {
+				if !strings.Contains(challenge, want) {
+					t.Errorf("WWW-Authenticate = %q, want it to contain %q", challenge, want)
+				}
+			}
+			if len(tt.wantChallenge) == 0 && challenge != "" {
+				t.Errorf("WWW-Authenticate = %q, want none", challenge)
+			}
+			if tt.wantReason != "" {
+				var body middleware.RPCError
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Data.Reason != tt.wantReason || body.Error.Data.Status != tt.wantStatus {
+					t.Errorf("body = %s, want a JSON-RPC error with reason %q", rec.Body, tt.wantReason)
+				}
+			}
+			if tt.wantStatus == http.StatusOK && tt.path == "/mcp" && gotSubject != "user-1" {
+				t.Errorf("claims subject = %q, want user-1", gotSubject)
+			}
+		})
+	}
+}
+
+func TestMiddleware_Unavailable(t *testing.T) {
+	as := newAuthServer(t)
+	v := newVerifier(t, as)
+	token := as.token(t, "k1", as.claims(nil))
+	as.Close()
+
+	handler := Middleware(v, []string{"/mcp"})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,288 @@
+// Package output caps the size of tool results so that a runaway tool
+// cannot flood a client's context window or the server's memory. A result
+// over the limit is truncated with a marker, replaced by an error, or
+// spilled: truncated, with the full output kept for a while as a resource
+// the same session can read.
+package output
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// Policies for results over the limit.
+const (
+	// PolicyTruncate cuts the result's text and appends a marker.
+	PolicyTruncate = "truncate"
+	// PolicyReject replaces the result with a resource_exhausted error.
+	PolicyReject = "reject"
+	// PolicySpill truncates like PolicyTruncate and links the full output
+	// as a resource.
+	PolicySpill = "spill"
+)
+
+// SpillScheme is the URI scheme of spilled outputs.
+const SpillScheme = "mcp-server"
+
+const spillPrefix = SpillScheme + "://outputs/"
+
+// Config sets the limit and what happens past it.
+type Config struct {
+	// MaxBytes is the largest result, as encoded JSON; zero is unlimited.
+	MaxBytes int
+	Policy   string
+	// SpillTTL is how long spilled outputs stay readable.
+	SpillTTL time.Duration
+	// SpillMaxBytes caps the spilled outputs held at once; the oldest are
+	// dropped to make room.
+	SpillMaxBytes int
+}
+
+// LoadConfig reads the TOOL_OUTPUT_* settings.
+func LoadConfig() Config {
+	return Config{
+		MaxBytes:      config.GetEnvInt("TOOL_OUTPUT_MAX_BYTES", 0),
+		Policy:        config.GetEnv("TOOL_OUTPUT_POLICY", PolicyTruncate),
+		SpillTTL:      config.GetEnvDuration("TOOL_OUTPUT_SPILL_TTL", 15*time.Minute),
+		SpillMaxBytes: config.GetEnvInt("TOOL_OUTPUT_SPILL_MAX_BYTES", 64<<20),
+	}
+}
+
+// Limiter enforces Config on tools/call results.
+type Limiter struct {
+	cfg    Config
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	spilled map[string]*list.Element
+	order   *list.List // of *spill, oldest first
+	held    int
+}
+
+// spill is a full output kept for reading.
+type spill struct {
+	id       string
+	session  *mcp.ServerSession
+	mimeType string
+	data     string
+	expires  time.Time
+}
+
+// New creates a Limiter, or returns nil if cfg sets no limit.
+func New(cfg Config, logger *slog.Logger) (*Limiter, error) {
+	if cfg.MaxBytes <= 0 {
+		return nil, nil
+	}
+	switch cfg.Policy {
+	case PolicyTruncate, PolicyReject:
+	case PolicySpill:
+		if cfg.SpillTTL <= 0 || cfg.SpillMaxBytes <= 0 {
+			return nil, fmt.Errorf("TOOL_OUTPUT_SPILL_TTL and TOOL_OUTPUT_SPILL_MAX_BYTES must be positive")
+		}
+	default:
+		return nil, fmt.Errorf("unknown TOOL_OUTPUT_POLICY %q: use %s, %s, or %s", cfg.Policy, PolicyTruncate, PolicyReject, PolicySpill)
+	}
+	return &Limiter{
+		cfg:     cfg,
+		logger:  logger,
+		now:     time.Now,
+		spilled: make(map[string]*list.Element),
+		order:   list.New(),
+	}, nil
+}
+
+// Register adds the resource template of spilled outputs to server. It
+// does nothing unless the policy is PolicySpill.
+func (l *Limiter) Register(server *mcp.Server) {
+	if l.cfg.Policy != PolicySpill {
+		return
+	}
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "tool-output",
+		Description: "Full output of a tool call whose result was truncated",
+		URITemplate: spillPrefix + "{id}",
+	}, l.read)
+}
+
+// Middleware enforces the limit on each tools/call result, after the tool
+// and any other middleware have produced it.
+func (l *Limiter) Middleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		result, err := next(ctx, method, req)
+		res, ok := result.(*mcp.CallToolResult)
+		if method != "tools/call" || !ok || res == nil {
+			return result, err
+		}
+		encoded, jerr := json.Marshal(res)
+		if jerr != nil || len(encoded) <= l.cfg.MaxBytes {
+			return result, err
+		}
+
+		name := ""
+		if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok {
+			name = params.Name
+		}
+		l.logger.Warn("tool output over limit", "tool", name, "bytes", len(encoded), "limit", l.cfg.MaxBytes, "policy", l.cfg.Policy)
+
+		switch l.cfg.Policy {
+		case PolicyReject:
+			e := tools.NewError(tools.CodeResourceExhausted, "output of %d bytes exceeds the %d byte limit", len(encoded), l.cfg.MaxBytes).
+				WithDetail("bytes", len(encoded)).
+				WithDetail("limit", l.cfg.MaxBytes)
+			return tools.ErrorResult(e), err
+		case PolicySpill:
+			ss, _ := req.GetSession().(*mcp.ServerSession)
+			link := l.store(ss, res, encoded)
+			return l.truncate(res, len(encoded), link), err
+		default:
+			return l.truncate(res, len(encoded), nil), err
+		}
+	}
+}
+
+// truncate returns res cut to about the limit: its content blocks while
+// they fit, the text of the first that does not cut short, and a marker,
+// followed by link if there is one. Structured content cannot be cut
+// without breaking its schema, so it is dropped.
+func (l *Limiter) truncate(res *mcp.CallToolResult, size int, link *mcp.ResourceLink) *mcp.CallToolResult {
+	marker := fmt.Sprintf("\n[output truncated: %d bytes exceeds the %d byte limit", size, l.cfg.MaxBytes)
+	if link != nil {
+		marker += "; the full output is at " + link.URI
+	}
+	marker += "]"
+
+	budget := l.cfg.MaxBytes - len(marker) - 256 // room for the result's framing
+	out := &mcp.CallToolResult{IsError: res.IsError, Meta: mcp.Meta{"truncated": map[string]any{"bytes": size, "limit": l.cfg.MaxBytes}}}
+	for _, c := range res.Content {
+		b, _ := json.Marshal(c)
+		if len(b) <= budget {
+			out.Content = append(out.Content, c)
+			budget -= len(b)
+			continue
+		}
+		if t, ok := c.(*mcp.TextContent); ok && budget > 0 {
+			out.Content = append(out.Content, &mcp.TextContent{Text: cutText(t.Text, budget)})
+		}
+		break
+	}
+	out.Content = append(out.Content, &mcp.TextContent{Text: marker})
+	if link != nil {
+		out.Content = append(out.Content, link)
+	}
+	return out
+}
+
+// cutText returns the longest prefix of s that encodes in at most n JSON
+// bytes, ending on a rune boundary.
+func cutText(s string, n int) string {
+	if len(s) > n {
+		s = s[:n]
+	}
+	for len(s) > 0 {
+		b, _ := json.Marshal(s)
+		if len(b) <= n {
+			break
+		}
+		// An escape takes at most six bytes per byte of text
+		s = s[:len(s)-max((len(b)-n)/6, 1)]
+		for len(s) > 0 {
+			if r, size := utf8.DecodeLastRuneInString(s); r != utf8.RuneError || size > 1 {
+				break
+			}
+			s = s[:len(s)-1]
+		}
+	}
+	return s
+}
+
+// store keeps the full output of res for session ss and returns its link.
+func (l *Limiter) store(ss *mcp.ServerSession, res *mcp.CallToolResult, encoded []byte) *mcp.ResourceLink {
+	sp := &spill{session: ss, mimeType: "application/json", data: string(encoded), expires: l.now().Add(l.cfg.SpillTTL)}
+	if text, ok := onlyText(res); ok {
+		sp.mimeType, sp.data = "text/plain", text
+	}
+	if len(sp.data) > l.cfg.SpillMaxBytes {
+		return nil
+	}
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	sp.id = hex.EncodeToString(b)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expire()
+	for l.held+len(sp.data) > l.cfg.SpillMaxBytes {
+		l.remove(l.order.Front())
+	}
+	l.spilled[sp.id] = l.order.PushBack(sp)
+	l.held += len(sp.data)
+
+	size := int64(len(sp.data))
+	return &mcp.ResourceLink{URI: spillPrefix + sp.id, Name: "tool-output", MIMEType: sp.mimeType, Size: &size}
+}
+
+// read serves a spilled output to the session it belongs to.
+func (l *Limiter) read(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	id, _ := strings.CutPrefix(uri, spillPrefix)
+
+	l.mu.Lock()
+	l.expire()
+	var sp *spill
+	if e, ok := l.spilled[id]; ok {
+		sp = e.Value.(*spill)
+	}
+	l.mu.Unlock()
+	if sp == nil || !sameSession(sp.session, req.Session) {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{URI: uri, MIMEType: sp.mimeType, Text: sp.data}}}, nil
+}
+
+// sameSession reports whether a and b are one session. Stateless HTTP
+// requests each get a new ServerSession, so those are matched by ID.
+func sameSession(a, b *mcp.ServerSession) bool {
+	return a == b || a != nil && b != nil && a.ID() != "" && a.ID() == b.ID()
+}
+
+// expire drops spilled outputs past their TTL. l.mu must be held.
+func (l *Limiter) expire() {
+	now := l.now()
+	for e := l.order.Front(); e != nil && !now.Before(e.Value.(*spill).expires); e = l.order.Front() {
+		l.remove(e)
+	}
+}
+
+func (l *Limiter) remove(e *list.Element) {
+	sp := l.order.Remove(e).(*spill)
+	delete(l.spilled, sp.id)
+	l.held -= len(sp.data)
+}
+
+// onlyText returns the text of res if it is all text content.
+func onlyText(res *mcp.CallToolResult) (string, bool) {
+	var b strings.Builder
+	for _, c := range res.Content {
+		t, ok := c.(*mcp.TextContent)
+		if !ok {
+			return "", false
+		}
+		b.WriteString(t.Text)
+	}
+	return b.String(), len(res.Content) > 0
+}
@@ -0,0 +1,170 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", cfg: Config{Policy: "bogus"}, wantNil: true},
+		{name: "truncate", cfg: Config{MaxBytes: 100, Policy: PolicyTruncate}},
+		{name: "spill", cfg: Config{MaxBytes: 100, Policy: PolicySpill, SpillTTL: time.Minute, SpillMaxBytes: 1000}},
+		{name: "spill without room", cfg: Config{MaxBytes: 100, Policy: PolicySpill, SpillTTL: time.Minute}, wantErr: true},
+		{name: "unknown policy", cfg: Config{MaxBytes: 100, Policy: "drop"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := New(tt.cfg, discard)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (l == nil) != tt.wantNil {
+				t.Errorf("New() = %v, want nil %v", l, tt.wantNil)
+			}
+		})
+	}
+}
+
+// connect serves a "big" tool returning n bytes of text and a "small" one
+// through a Limiter with cfg.
+func connect(t *testing.T, cfg Config) *mcp.ClientSession {
+	t.Helper()
+	l, err := New(cfg, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	type in struct {
+		N int `json:"n"`
+	}
+	mcp.AddTool(server, &mcp.Tool{Name: "big"}, func(_ context.Context, _ *mcp.CallToolRequest, input in) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: strings.Repeat("é", input.N/2)}}}, nil, nil
+	})
+	l.Register(server)
+	server.AddReceivingMiddleware(l.Middleware)
+
+	ctx := context.Background()
+	st, ct := mcp.NewInMemoryTransports()
+	ss, err := server.Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ss.Close() })
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cs.Close() })
+	return cs
+}
+
+func call(t *testing.T, cs *mcp.ClientSession, n int) *mcp.CallToolResult {
+	t.Helper()
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "big", Arguments: map[string]any{"n": n}})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	return res
+}
+
+func TestTruncate(t *testing.T) {
+	cs := connect(t, Config{MaxBytes: 2000, Policy: PolicyTruncate})
+	if res := call(t, cs, 100); len(res.Content) != 1 || res.Meta["truncated"] != nil {
+		t.Errorf("small result = %+v, want it untouched", res)
+	}
+
+	res := call(t, cs, 100_000)
+	if b, _ := json.Marshal(res); len(b) > 2000 {
+		t.Errorf("truncated result is %d bytes, want at most 2000", len(b))
+	}
+	if res.IsError || len(res.Content) != 2 {
+		t.Fatalf("truncated result = %+v, want text and a marker", res)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; text == "" || !utf8.ValidString(text) {
+		t.Errorf("truncated text = %q", text)
+	}
+	if marker := res.Content[1].(*mcp.TextContent).Text; !strings.Contains(marker, "output truncated") {
+		t.Errorf("marker = %q", marker)
+	}
+	if res.Meta["truncated"] == nil {
+		t.Error("_meta.truncated is missing")
+	}
+}
+
+func TestReject(t *testing.T) {
+	cs := connect(t, Config{MaxBytes: 2000, Policy: PolicyReject})
+	res := call(t, cs, 100_000)
+	if !res.IsError || !strings.HasPrefix(res.Content[0].(*mcp.TextContent).Text, "resource_exhausted") {
+		t.Errorf("rejected result = %+v, want a resource_exhausted error", res)
+	}
+}
+
+func TestSpill(t *testing.T) {
+	cs := connect(t, Config{MaxBytes: 2000, Policy: PolicySpill, SpillTTL: time.Minute, SpillMaxBytes: 250_000})
+	res := call(t, cs, 100_000)
+	link, ok := res.Content[len(res.Content)-1].(*mcp.ResourceLink)
+	if !ok || !strings.HasPrefix(link.URI, spillPrefix) || link.MIMEType != "text/plain" {
+		t.Fatalf("spilled result = %+v, want a resource link last", res.Content)
+	}
+
+	ctx := context.Background()
+	read, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: link.URI})
+	if err != nil {
+		t.Fatalf("ReadResource() error = %v", err)
+	}
+	if got := read.Contents[0].Text; got != strings.Repeat("é", 50_000) {
+		t.Errorf("spilled output is %d bytes, want the full 100000", len(got))
+	}
+
+	// Other sessions cannot read it
+	other := connect(t, Config{MaxBytes: 2000, Policy: PolicySpill, SpillTTL: time.Minute, SpillMaxBytes: 250_000})
+	if _, err := other.ReadResource(ctx, &mcp.ReadResourceParams{URI: link.URI}); err == nil {
+		t.Error("another session read the spilled output")
+	}
+
+	// The oldest spills make room for new ones
+	for range 3 {
+		call(t, cs, 100_000)
+	}
+	if _, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: link.URI}); err == nil {
+		t.Error("the oldest spill was kept past SpillMaxBytes")
+	}
+}
+
+func TestCutText(t *testing.T) {
+	tests := []struct {
+		s string
+		n int
+	}{
+		{s: "hello world", n: 7},
+		{s: strings.Repeat("é", 10), n: 8},
+		{s: strings.Repeat("<", 10), n: 20},
+		{s: "short", n: 100},
+	}
+	for _, tt := range tests {
+		got := cutText(tt.s, tt.n)
+		b, _ := json.Marshal(got)
+		if len(b) > tt.n || !utf8.ValidString(got) || !strings.HasPrefix(tt.s, got) {
+			t.Errorf("cutText(%q, %d) = %q (%d bytes encoded)", tt.s, tt.n, got, len(b))
+		}
+	}
+	if got := cutText("short", 100); got != "short" {
+		t.Errorf("cutText kept %q of a string that fits", got)
+	}
+}
@@ -0,0 +1,51 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// Middleware evaluates p before each tools/call. A denied call never
+// reaches the tool and fails with a permission_denied tool error naming
+// the rule. The caller is the identity the HTTP auth middleware recorded,
+// empty on stdio or with authentication off.
+func Middleware(p *Policy, logger *slog.Logger) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != "tools/call" {
+				return next(ctx, method, req)
+			}
+			params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+			if !ok {
+				return next(ctx, method, req)
+			}
+			in := Input{Tool: params.Name}
+			in.Hints, _ = tools.ToolHints(params.Name)
+			if len(params.Arguments) > 0 {
+				// Arguments that are not an object are left to the tool
+				// to reject; argument conditions then see nothing
+				_ = json.Unmarshal(params.Arguments, &in.Arguments)
+			}
+			if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+				in.Caller = extra.Header.Get(middleware.HeaderCaller)
+			}
+
+			d := p.Evaluate(in)
+			if d.Allow {
+				return next(ctx, method, req)
+			}
+			logger.Warn("tool call denied by policy", "tool", in.Tool, "caller", in.Caller, "rule", d.Rule)
+			e := tools.NewError(tools.CodePermissionDenied, "%s denied by policy", in.Tool)
+			if d.Rule != "" {
+				e = e.WithDetail("rule", d.Rule)
+			}
+			return tools.ErrorResult(e), nil
+		}
+	}
+}
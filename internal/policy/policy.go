@@ -0,0 +1,267 @@
+// Package policy authorizes tool calls against rules loaded from a YAML
+// file. Each tools/call is evaluated with the caller identity, the tool
+// name, its annotation hints, and its arguments before the tool runs;
+// the first matching rule decides, and the file's default applies when
+// none match.
+//
+//	default: allow
+//	rules:
+//	  - name: write-only-tmp
+//	    effect: deny
+//	    tools: [write_file]
+//	    arguments:
+//	      path: {not_prefix: /tmp/}
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// Effect is the outcome of a rule.
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Policy is an ordered rule set.
+type Policy struct {
+	Default Effect `yaml:"default"`
+	Rules   []Rule `yaml:"rules"`
+}
+
+// Rule applies its Effect to calls matching every condition it sets.
+// Callers and Tools are path.Match globs; an empty list matches any.
+type Rule struct {
+	Name      string           `yaml:"name"`
+	Effect    Effect           `yaml:"effect"`
+	Callers   []string         `yaml:"callers"`
+	Tools     []string         `yaml:"tools"`
+	Hints     HintMatch        `yaml:"hints"`
+	Arguments map[string]Match `yaml:"arguments"`
+}
+
+// HintMatch matches tool annotation hints; unset fields match any.
+type HintMatch struct {
+	ReadOnly    *bool `yaml:"read_only"`
+	Destructive *bool `yaml:"destructive"`
+	Idempotent  *bool `yaml:"idempotent"`
+	OpenWorld   *bool `yaml:"open_world"`
+}
+
+// Match tests one argument, addressed by a dot-separated path into the
+// call's arguments. All set conditions must hold, and a missing argument
+// only matches Exists: false. Absolute paths are cleaned before prefix
+// tests, so /tmp/../etc does not match the prefix /tmp/.
+type Match struct {
+	Exists     *bool   `yaml:"exists"`
+	Equals     *string `yaml:"equals"`
+	Prefix     string  `yaml:"prefix"`
+	NotPrefix  string  `yaml:"not_prefix"`
+	Matches    string  `yaml:"matches"`
+	NotMatches string  `yaml:"not_matches"`
+
+	matches    *regexp.Regexp
+	notMatches *regexp.Regexp
+}
+
+// Input describes a tool call to authorize.
+type Input struct {
+	Caller    string
+	Tool      string
+	Hints     tools.Hints
+	Arguments map[string]any
+}
+
+// Decision is the result of evaluating an Input. Rule names the rule
+// that decided, empty when the default applied.
+type Decision struct {
+	Allow bool
+	Rule  string
+}
+
+// Load reads and compiles the policy file at path.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse compiles a policy document, rejecting unknown fields.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if p.Default == "" {
+		p.Default = Allow
+	}
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *Policy) compile() error {
+	if p.Default != Allow && p.Default != Deny {
+		return fmt.Errorf("default %q: use allow or deny", p.Default)
+	}
+	var errs []error
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if r.Effect != Allow && r.Effect != Deny {
+			errs = append(errs, fmt.Errorf("rule %s: effect %q: use allow or deny", r.Name, r.Effect))
+		}
+		for _, g := range append(append([]string(nil), r.Callers...), r.Tools...) {
+			if _, err := path.Match(g, ""); err != nil {
+				errs = append(errs, fmt.Errorf("rule %s: pattern %q: %w", r.Name, g, err))
+			}
+		}
+		for field, m := range r.Arguments {
+			var err error
+			if m.Matches != "" {
+				if m.matches, err = regexp.Compile(m.Matches); err != nil {
+					errs = append(errs, fmt.Errorf("rule %s: argument %s: %w", r.Name, field, err))
+				}
+			}
+			if m.NotMatches != "" {
+				if m.notMatches, err = regexp.Compile(m.NotMatches); err != nil {
+					errs = append(errs, fmt.Errorf("rule %s: argument %s: %w", r.Name, field, err))
+				}
+			}
+			r.Arguments[field] = m
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Evaluate decides whether the call described by in may proceed.
+func (p *Policy) Evaluate(in Input) Decision {
+	for _, r := range p.Rules {
+		if r.match(in) {
+			return Decision{Allow: r.Effect == Allow, Rule: r.Name}
+		}
+	}
+	return Decision{Allow: p.Default == Allow}
+}
+
+func (r *Rule) match(in Input) bool {
+	if !globs(r.Callers, in.Caller) || !globs(r.Tools, in.Tool) || !r.Hints.match(in.Hints) {
+		return false
+	}
+	for field, m := range r.Arguments {
+		v, ok := lookup(in.Arguments, field)
+		if !m.match(v, ok) {
+			return false
+		}
+	}
+	return true
+}
+
+func globs(patterns []string, s string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (h HintMatch) match(hints tools.Hints) bool {
+	for _, c := range []struct {
+		want *bool
+		got  bool
+	}{
+		{h.ReadOnly, hints.ReadOnly},
+		{h.Destructive, hints.Destructive},
+		{h.Idempotent, hints.Idempotent},
+		{h.OpenWorld, hints.OpenWorld},
+	} {
+		if c.want != nil && *c.want != c.got {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Match) match(v string, ok bool) bool {
+	if m.Exists != nil && *m.Exists != ok {
+		return false
+	}
+	if !ok {
+		return m.Exists != nil
+	}
+	if m.Equals != nil && *m.Equals != v {
+		return false
+	}
+	if strings.HasPrefix(v, "/") {
+		v = path.Clean(v)
+	}
+	if m.Prefix != "" && !hasPathPrefix(v, m.Prefix) {
+		return false
+	}
+	if m.NotPrefix != "" && hasPathPrefix(v, m.NotPrefix) {
+		return false
+	}
+	if m.matches != nil && !m.matches.MatchString(v) {
+		return false
+	}
+	if m.notMatches != nil && m.notMatches.MatchString(v) {
+		return false
+	}
+	return true
+}
+
+// hasPathPrefix reports whether v starts with prefix. A prefix ending in
+// a slash also matches the directory itself.
+func hasPathPrefix(v, prefix string) bool {
+	return strings.HasPrefix(v, prefix) || (strings.HasSuffix(prefix, "/") && v == strings.TrimSuffix(prefix, "/"))
+}
+
+// lookup resolves a dot-separated field in args and formats its value.
+func lookup(args map[string]any, field string) (string, bool) {
+	var v any = args
+	for _, key := range strings.Split(field, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if v, ok = m[key]; !ok {
+			return "", false
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case nil:
+		return "", true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
@@ -0,0 +1,197 @@
+package policy
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+const testPolicy = `
+default: allow
+rules:
+  - name: write-only-tmp
+    effect: deny
+    tools: [write_file]
+    arguments:
+      path: {not_prefix: /tmp/}
+  - name: ops-may-exec
+    effect: allow
+    callers: ["oauth:ops-*"]
+    tools: [exec]
+  - name: no-exec
+    effect: deny
+    tools: [exec]
+  - name: guests-read-only
+    effect: deny
+    callers: ["api_key:guest*"]
+    hints: {read_only: false}
+  - name: no-internal-fetch
+    effect: deny
+    tools: [http_fetch]
+    arguments:
+      request.url: {matches: '^https?://[^/]*\.internal([:/]|$)'}
+`
+
+func TestEvaluate(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		in       Input
+		wantOK   bool
+		wantRule string
+	}{
+		{name: "write in tmp", in: Input{Tool: "write_file", Arguments: map[string]any{"path": "/tmp/out.txt"}}, wantOK: true},
+		{name: "write tmp itself", in: Input{Tool: "write_file", Arguments: map[string]any{"path": "/tmp"}}, wantOK: true},
+		{name: "write outside tmp", in: Input{Tool: "write_file", Arguments: map[string]any{"path": "/etc/passwd"}}, wantRule: "write-only-tmp"},
+		{name: "write escaping tmp", in: Input{Tool: "write_file", Arguments: map[string]any{"path": "/tmp/../etc/passwd"}}, wantRule: "write-only-tmp"},
+		{name: "write without path", in: Input{Tool: "write_file"}, wantOK: true},
+		{name: "exec by ops", in: Input{Caller: "oauth:ops-alice", Tool: "exec"}, wantOK: true, wantRule: "ops-may-exec"},
+		{name: "exec by others", in: Input{Caller: "oauth:bob", Tool: "exec"}, wantRule: "no-exec"},
+		{name: "guest reads", in: Input{Caller: "api_key:guest1", Tool: "uuid", Hints: tools.Hints{ReadOnly: true}}, wantOK: true},
+		{name: "guest writes", in: Input{Caller: "api_key:guest1", Tool: "memory_set"}, wantRule: "guests-read-only"},
+		{name: "nested argument", in: Input{Tool: "http_fetch", Arguments: map[string]any{"request": map[string]any{"url": "http://db.internal:5432/"}}}, wantRule: "no-internal-fetch"},
+		{name: "nested argument no match", in: Input{Tool: "http_fetch", Arguments: map[string]any{"request": map[string]any{"url": "https://example.com"}}}, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := p.Evaluate(tt.in)
+			if d.Allow != tt.wantOK || d.Rule != tt.wantRule {
+				t.Errorf("Evaluate = %+v, want {Allow:%v Rule:%s}", d, tt.wantOK, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{name: "empty", doc: ""},
+		{name: "default deny", doc: "default: deny"},
+		{name: "bad default", doc: "default: maybe", wantErr: `default "maybe"`},
+		{name: "bad effect", doc: "rules: [{name: r, effect: block}]", wantErr: `rule r: effect "block"`},
+		{name: "bad glob", doc: "rules: [{effect: deny, tools: ['[']}]", wantErr: "rule rule-1: pattern"},
+		{name: "bad regexp", doc: "rules: [{name: r, effect: deny, arguments: {path: {matches: '('}}}]", wantErr: "rule r: argument path"},
+		{name: "unknown field", doc: "rules: [{name: r, effect: deny, tool: x}]", wantErr: "field tool not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.doc))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parse: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultDeny(t *testing.T) {
+	p, err := Parse([]byte("default: deny\nrules: [{name: reads, effect: allow, hints: {read_only: true}}]"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if d := p.Evaluate(Input{Tool: "exec"}); d.Allow || d.Rule != "" {
+		t.Errorf("Evaluate(exec) = %+v, want default deny", d)
+	}
+	if d := p.Evaluate(Input{Tool: "uuid", Hints: tools.Hints{ReadOnly: true}}); !d.Allow {
+		t.Errorf("Evaluate(uuid) = %+v, want allow", d)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(testPolicy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(p.Rules) != 5 {
+		t.Errorf("rules = %d, want 5", len(p.Rules))
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Load(missing) succeeded")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	type in struct {
+		Path string `json:"path"`
+	}
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	tools.AddTool(server, &mcp.Tool{Name: "write_file", Annotations: tools.Destructive(false, false)}, func(_ context.Context, _ *mcp.CallToolRequest, input in) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "wrote " + input.Path}}}, nil, nil
+	})
+	server.AddReceivingMiddleware(Middleware(p, slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		wantError bool
+		wantText  string
+	}{
+		{name: "allowed", path: "/tmp/a", wantText: "wrote /tmp/a"},
+		{name: "denied", path: "/etc/a", wantError: true, wantText: "permission_denied: write_file denied by policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "write_file", Arguments: map[string]any{"path": tt.path}})
+			if err != nil {
+				t.Fatalf("CallTool: %v", err)
+			}
+			if res.IsError != tt.wantError {
+				t.Errorf("IsError = %v, want %v", res.IsError, tt.wantError)
+			}
+			if text := res.Content[0].(*mcp.TextContent).Text; text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if !tt.wantError {
+				return
+			}
+			meta, _ := res.Meta["error"].(map[string]any)
+			if details, _ := meta["details"].(map[string]any); details["rule"] != "write-only-tmp" {
+				t.Errorf("_meta.error = %v, want rule write-only-tmp", res.Meta["error"])
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package schedule
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression: the five fields minute, hour, day of
+// month, month, and day of week, each *, a value, a range a-b, or a list
+// of these, optionally with a /step. Months and days of week may be given
+// by their three-letter English names, and Sunday is 0 or 7. As in
+// Vixie cron, when both day fields are restricted a day matching either
+// matches. The macros @yearly, @monthly, @weekly, @daily, and @hourly
+// are accepted too.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domAll and dowAll record a day field of *, which leaves the other
+	// alone deciding the day
+	domAll, dowAll bool
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// ParseCron parses a cron expression.
+func ParseCron(expr string) (Cron, error) {
+	if m, ok := macros[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Cron{}, fmt.Errorf("cron %q: want 5 fields, got %d", expr, len(fields))
+	}
+	var c Cron
+	var err error
+	parsers := []struct {
+		dst      *uint64
+		min, max int
+		names    []string
+	}{
+		{&c.minute, 0, 59, nil},
+		{&c.hour, 0, 23, nil},
+		{&c.dom, 1, 31, nil},
+		{&c.month, 1, 12, monthNames},
+		{&c.dow, 0, 7, dayNames},
+	}
+	for i, p := range parsers {
+		if *p.dst, err = parseField(fields[i], p.min, p.max, p.names); err != nil {
+			return Cron{}, fmt.Errorf("cron %q: %w", expr, err)
+		}
+	}
+	// Sunday is both 0 and 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAll, c.dowAll = fields[2] == "*", fields[4] == "*"
+	return c, nil
+}
+
+// parseField returns the bit set of the values field selects within
+// [min, max].
+func parseField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(field, ",") {
+		span, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+		lo, hi := min, max
+		if span != "*" {
+			from, to, isRange := strings.Cut(span, "-")
+			var err error
+			if lo, err = parseValue(from, min, max, names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(to, min, max, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// a/n steps from a to the end of the range
+				hi = max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q", span)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, min, max int, names []string) (int, error) {
+	if i := slices.Index(names, strings.ToLower(s)); i >= 0 && s != "" {
+		return i, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("%q is not a value from %d to %d", s, min, max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t that c matches, in t's location,
+// or the zero time if c matches no time in the next five years, as for
+// February 30.
+func (c Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = later(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+		case !c.matchDay(t):
+			t = later(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+		case c.hour&(1<<t.Hour()) == 0:
+			// Added rather than built with time.Date, which maps a
+			// time skipped by a daylight saving change to an earlier one
+			t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// later returns next, or the minute after t when a daylight saving change
+// made next no later than t, so that Next always moves forward.
+func later(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Minute)
+}
+
+func (c Cron) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domAll || c.dowAll {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,458 @@
+// Package schedule runs tools on cron schedules, for cache warmers,
+// cleanups, and other periodic work. Schedules are read from a YAML file:
+//
+//	timezone: Europe/Berlin
+//	schedules:
+//	  - name: warm-cache
+//	    cron: "*/15 * * * *"
+//	    tool: cache_warm
+//	    arguments: {region: eu}
+//	    timeout: 2m
+//
+// The result of each run is published as a schedule.completed or
+// schedule.failed event and kept as the resource schedule://<name>. The
+// admin API lists the schedules, runs them on demand, and pauses and
+// resumes them.
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/events"
+)
+
+// URIScheme is the URI scheme of the resources holding the last run of
+// each schedule.
+const URIScheme = "schedule"
+
+// Triggers of a run.
+const (
+	TriggerCron  = "cron"
+	TriggerAdmin = "admin"
+)
+
+var (
+	// ErrNotFound is returned for a schedule that does not exist.
+	ErrNotFound = errors.New("no schedule with that name")
+	// ErrRunning is returned when a schedule is run while its previous
+	// run has not finished.
+	ErrRunning = errors.New("schedule is already running")
+	// ErrNotStarted is returned when a schedule is run before the
+	// Scheduler is.
+	ErrNotStarted = errors.New("scheduler is not running")
+)
+
+// namePattern restricts schedule names to those usable in URIs and paths.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Config locates the schedules.
+type Config struct {
+	// File is the YAML file of schedules; empty disables scheduling.
+	File string
+}
+
+// LoadConfig reads the SCHEDULE_* settings.
+func LoadConfig() Config {
+	return Config{File: config.GetEnv("SCHEDULE_FILE", "")}
+}
+
+// Schedule runs a tool with fixed arguments whenever its cron expression
+// matches.
+type Schedule struct {
+	Name      string         `yaml:"name"`
+	Cron      string         `yaml:"cron"`
+	Tool      string         `yaml:"tool"`
+	Arguments map[string]any `yaml:"arguments"`
+	// Timeout bounds each run, on top of the tool's own timeout.
+	Timeout time.Duration `yaml:"timeout"`
+	// Paused schedules run only when triggered through the admin API,
+	// until resumed.
+	Paused bool `yaml:"paused"`
+}
+
+type file struct {
+	// Timezone is the IANA zone cron expressions are evaluated in; UTC
+	// by default.
+	Timezone  string     `yaml:"timezone"`
+	Schedules []Schedule `yaml:"schedules"`
+}
+
+// CallFunc calls a tool, as the Scheduler does for each run.
+type CallFunc func(ctx context.Context, tool string, args map[string]any) (*mcp.CallToolResult, error)
+
+// Run is the outcome of one run of a schedule.
+type Run struct {
+	Trigger    string              `json:"trigger"`
+	Started    time.Time           `json:"started"`
+	Finished   time.Time           `json:"finished"`
+	DurationMS int64               `json:"duration_ms"`
+	Error      string              `json:"error,omitempty"`
+	Result     *mcp.CallToolResult `json:"result,omitempty"`
+}
+
+// Status is a schedule as listed on the admin API and read as a resource.
+type Status struct {
+	Name    string    `json:"name"`
+	Cron    string    `json:"cron"`
+	Tool    string    `json:"tool"`
+	Paused  bool      `json:"paused"`
+	Running bool      `json:"running"`
+	Next    time.Time `json:"next,omitzero"`
+	Last    *Run      `json:"last,omitempty"`
+}
+
+type entry struct {
+	Schedule
+	cron    Cron
+	next    time.Time
+	running bool
+	last    *Run
+}
+
+// Scheduler runs the tools of its schedules. A schedule whose previous
+// run is still going when it is due again is skipped, not queued.
+type Scheduler struct {
+	loc     *time.Location
+	logger  *slog.Logger
+	publish func(typ string, data map[string]any)
+	now     func() time.Time
+	wake    chan struct{}
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	entries []*entry
+	ctx     context.Context
+	call    CallFunc
+}
+
+// Load returns a Scheduler for the schedules in cfg.File, publishing the
+// results of their runs with publish, or nil when cfg.File is empty.
+func Load(cfg Config, logger *slog.Logger, publish func(typ string, data map[string]any)) (*Scheduler, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		return nil, fmt.Errorf("schedule: %w", err)
+	}
+	s, err := New(data, logger, publish)
+	if err != nil {
+		return nil, fmt.Errorf("schedule %s: %w", cfg.File, err)
+	}
+	return s, nil
+}
+
+// New returns a Scheduler for a schedule document, rejecting unknown
+// fields, invalid cron expressions, and duplicate names.
+func New(data []byte, logger *slog.Logger, publish func(typ string, data map[string]any)) (*Scheduler, error) {
+	var f file
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	loc := time.UTC
+	if f.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(f.Timezone); err != nil {
+			return nil, fmt.Errorf("timezone: %w", err)
+		}
+	}
+
+	s := &Scheduler{loc: loc, logger: logger, publish: publish, now: time.Now, wake: make(chan struct{}, 1)}
+	seen := make(map[string]bool)
+	for i, sc := range f.Schedules {
+		switch {
+		case !namePattern.MatchString(sc.Name):
+			return nil, fmt.Errorf("schedule %d: name %q must be letters, digits, _, ., and -", i+1, sc.Name)
+		case seen[sc.Name]:
+			return nil, fmt.Errorf("schedule %s: duplicate name", sc.Name)
+		case sc.Tool == "":
+			return nil, fmt.Errorf("schedule %s: tool is required", sc.Name)
+		case sc.Timeout < 0:
+			return nil, fmt.Errorf("schedule %s: timeout must not be negative", sc.Name)
+		}
+		seen[sc.Name] = true
+		c, err := ParseCron(sc.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", sc.Name, err)
+		}
+		if c.Next(time.Now().In(loc)).IsZero() {
+			return nil, fmt.Errorf("schedule %s: cron %q never matches", sc.Name, sc.Cron)
+		}
+		s.entries = append(s.entries, &entry{Schedule: sc, cron: c})
+	}
+	return s, nil
+}
+
+// Len returns the number of schedules.
+func (s *Scheduler) Len() int {
+	return len(s.entries)
+}
+
+// Run runs the schedules with call until ctx is done, then waits for the
+// runs in progress, whose context ctx is too.
+func (s *Scheduler) Run(ctx context.Context, call CallFunc) {
+	s.mu.Lock()
+	s.ctx, s.call = ctx, call
+	now := s.now().In(s.loc)
+	for _, e := range s.entries {
+		e.next = e.cron.Next(now)
+	}
+	s.mu.Unlock()
+
+	for {
+		// Runs due are started, and the next due looked up, under one
+		// lock, so a schedule resumed meanwhile wakes the loop
+		s.mu.Lock()
+		now := s.now().In(s.loc)
+		wait := time.Minute
+		for _, e := range s.entries {
+			if e.Paused {
+				continue
+			}
+			if !now.Before(e.next) {
+				s.start(e, TriggerCron)
+				e.next = e.cron.Next(now)
+			}
+			wait = min(wait, e.next.Sub(now))
+		}
+		s.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.wg.Wait()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// start runs e in the background unless it is running already, and
+// reports whether it did. The caller must hold s.mu.
+func (s *Scheduler) start(e *entry, trigger string) bool {
+	if e.running {
+		s.logger.Warn("scheduled run skipped: previous run still going", "schedule", e.Name, "tool", e.Tool, "trigger", trigger)
+		return false
+	}
+	e.running = true
+	s.wg.Add(1)
+	go s.run(s.ctx, s.call, e, trigger)
+	return true
+}
+
+// run calls e's tool and records and publishes the outcome.
+func (s *Scheduler) run(ctx context.Context, call CallFunc, e *entry, trigger string) {
+	defer s.wg.Done()
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+	r := &Run{Trigger: trigger, Started: s.now().UTC()}
+	res, err := call(ctx, e.Tool, e.Arguments)
+	r.Finished = s.now().UTC()
+	r.DurationMS = r.Finished.Sub(r.Started).Milliseconds()
+	switch {
+	case err != nil:
+		r.Error = err.Error()
+	case res.IsError:
+		r.Error, r.Result = errorText(res), res
+	default:
+		r.Result = res
+	}
+
+	s.mu.Lock()
+	e.running, e.last = false, r
+	s.mu.Unlock()
+
+	data := map[string]any{"schedule": e.Name, "tool": e.Tool, "trigger": trigger, "duration_ms": r.DurationMS}
+	if r.Error != "" {
+		s.logger.Warn("scheduled run failed", "schedule", e.Name, "tool", e.Tool, "trigger", trigger, "duration_ms", r.DurationMS, "error", r.Error)
+		data["error"] = r.Error
+		s.publish(events.ScheduleFailed, data)
+		return
+	}
+	s.logger.Info("scheduled run completed", "schedule", e.Name, "tool", e.Tool, "trigger", trigger, "duration_ms", r.DurationMS)
+	data["result"] = r.Result
+	s.publish(events.ScheduleCompleted, data)
+}
+
+// errorText joins the text of a failed tool result.
+func errorText(res *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range res.Content {
+		if t, ok := c.(*mcp.TextContent); ok {
+			parts = append(parts, t.Text)
+		}
+	}
+	if len(parts) == 0 {
+		return "tool returned an error"
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Trigger runs the schedule called name now, whether or not it is paused.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.find(name)
+	switch {
+	case e == nil:
+		return ErrNotFound
+	case s.call == nil:
+		return ErrNotStarted
+	case !s.start(e, TriggerAdmin):
+		return ErrRunning
+	}
+	return nil
+}
+
+// SetPaused pauses or resumes the schedule called name. A resumed
+// schedule next runs when its cron expression next matches.
+func (s *Scheduler) SetPaused(name string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.find(name)
+	if e == nil {
+		return ErrNotFound
+	}
+	if e.Paused && !paused {
+		e.next = e.cron.Next(s.now().In(s.loc))
+	}
+	e.Paused = paused
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// find returns the entry called name, or nil. The caller must hold s.mu.
+func (s *Scheduler) find(name string) *entry {
+	for _, e := range s.entries {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// Statuses returns the schedules in file order.
+func (s *Scheduler) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Status, 0, len(s.entries))
+	for _, e := range s.entries {
+		list = append(list, s.status(e))
+	}
+	return list
+}
+
+// status returns the Status of e. The caller must hold s.mu.
+func (s *Scheduler) status(e *entry) Status {
+	st := Status{Name: e.Name, Cron: e.Cron, Tool: e.Tool, Paused: e.Paused, Running: e.running, Last: e.last}
+	if !e.Paused {
+		st.Next = e.next
+	}
+	return st
+}
+
+// Register adds a resource schedule://<name> for each schedule to server,
+// reading as the schedule's Status with the result of its last run.
+func (s *Scheduler) Register(server *mcp.Server) {
+	for _, e := range s.entries {
+		server.AddResource(&mcp.Resource{
+			Name:        "schedule-" + e.Name,
+			Description: fmt.Sprintf("Last scheduled run of the %s tool (%s)", e.Tool, e.Cron),
+			URI:         URIScheme + "://" + e.Name,
+			MIMEType:    "application/json",
+		}, s.read)
+	}
+}
+
+func (s *Scheduler) read(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	name, _ := strings.CutPrefix(uri, URIScheme+"://")
+	s.mu.Lock()
+	e := s.find(name)
+	var st Status
+	if e != nil {
+		st = s.status(e)
+	}
+	s.mu.Unlock()
+	if e == nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{URI: uri, MIMEType: "application/json", Text: string(data)}}}, nil
+}
+
+// ListHandler lists the schedules with their next and last runs.
+func (s *Scheduler) ListHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{"schedules": s.Statuses()})
+}
+
+// ActionHandler applies {action} to the schedule named by the {name} path
+// value: "run" starts it now, "pause" and "resume" stop and restart its
+// cron runs.
+func (s *Scheduler) ActionHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	var err error
+	status := http.StatusOK
+	switch r.PathValue("action") {
+	case "run":
+		err, status = s.Trigger(name), http.StatusAccepted
+	case "pause":
+		err = s.SetPaused(name, true)
+	case "resume":
+		err = s.SetPaused(name, false)
+	default:
+		http.Error(w, "action must be run, pause, or resume", http.StatusNotFound)
+		return
+	}
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, ErrRunning):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	s.logger.Info("schedule updated", "schedule", name, "action", r.PathValue("action"))
+
+	s.mu.Lock()
+	st := s.status(s.find(name))
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(st)
+}
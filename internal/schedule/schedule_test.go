@@ -0,0 +1,265 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/events"
+)
+
+func TestParseCron(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	from := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC) // a Friday
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", from, time.Date(2026, 1, 2, 15, 5, 0, 0, time.UTC)},
+		{"*/15 * * * *", from, time.Date(2026, 1, 2, 15, 15, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", from, time.Date(2026, 1, 2, 17, 0, 0, 0, time.UTC)},
+		{"30 2 * * mon-fri", from, time.Date(2026, 1, 5, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", from, time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", from, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		// Either restricted day field matches
+		{"0 0 13 * fri", from, time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", from, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", from, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", from, time.Date(2026, 1, 2, 16, 0, 0, 0, time.UTC)},
+		// 02:30 does not exist on the day clocks spring forward
+		{"30 2 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, ny), time.Date(2026, 3, 9, 2, 30, 0, 0, ny)},
+		{"0 3 * * *", time.Date(2026, 3, 8, 1, 0, 0, 0, ny), time.Date(2026, 3, 8, 3, 0, 0, 0, ny)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron() error = %v", err)
+			}
+			if got := c.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "@often"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded", expr)
+		}
+	}
+	if c, _ := ParseCron("0 0 30 feb *"); !c.Next(from).IsZero() {
+		t.Error("Next() of February 30 is not zero")
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{name: "valid", doc: "timezone: Europe/Berlin\nschedules:\n  - {name: warm, cron: '@daily', tool: warm, timeout: 1m}\n"},
+		{name: "empty", doc: ""},
+		{name: "unknown field", doc: "schedules:\n  - {name: warm, cron: '@daily', tool: warm, every: 1m}\n", wantErr: "every"},
+		{name: "bad name", doc: "schedules:\n  - {name: 'warm cache', cron: '@daily', tool: warm}\n", wantErr: "name"},
+		{name: "duplicate", doc: "schedules:\n  - {name: warm, cron: '@daily', tool: warm}\n  - {name: warm, cron: '@hourly', tool: warm}\n", wantErr: "duplicate"},
+		{name: "no tool", doc: "schedules:\n  - {name: warm, cron: '@daily'}\n", wantErr: "tool"},
+		{name: "bad cron", doc: "schedules:\n  - {name: warm, cron: '* *', tool: warm}\n", wantErr: "5 fields"},
+		{name: "never", doc: "schedules:\n  - {name: warm, cron: '0 0 31 apr *', tool: warm}\n", wantErr: "never"},
+		{name: "bad timezone", doc: "timezone: Mars/Olympus\n", wantErr: "timezone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New([]byte(tt.doc), slog.New(slog.DiscardHandler), nil)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("New() error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// recorder collects published events.
+type recorder struct {
+	mu     sync.Mutex
+	events []events.Event
+	ch     chan struct{}
+}
+
+func (r *recorder) publish(typ string, data map[string]any) {
+	r.mu.Lock()
+	r.events = append(r.events, events.Event{Type: typ, Data: data})
+	r.mu.Unlock()
+	r.ch <- struct{}{}
+}
+
+// next waits for the next published event.
+func (r *recorder) next(t *testing.T) events.Event {
+	t.Helper()
+	select {
+	case <-r.ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event published")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[len(r.events)-1]
+}
+
+func TestScheduler(t *testing.T) {
+	doc := `
+schedules:
+  - name: warm
+    cron: "* * * * *"
+    tool: warm_cache
+    arguments: {region: eu}
+  - name: cleanup
+    cron: "@yearly"
+    tool: cleanup
+    paused: true
+`
+	rec := &recorder{ch: make(chan struct{}, 10)}
+	s, err := New([]byte(doc), slog.New(slog.DiscardHandler), rec.publish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The clock runs 50ms before the end of a minute, so the every-minute
+	// schedule is due at once
+	wall := time.Now()
+	offset := wall.Truncate(time.Minute).Add(time.Minute - 50*time.Millisecond).Sub(wall)
+	s.now = func() time.Time { return time.Now().Add(offset) }
+
+	release := make(chan struct{})
+	call := func(ctx context.Context, tool string, args map[string]any) (*mcp.CallToolResult, error) {
+		switch tool {
+		case "warm_cache":
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "warmed " + args["region"].(string)}}}, nil
+		case "cleanup":
+			<-release
+			return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "disk busy"}}}, nil
+		}
+		return nil, errors.New("unknown tool")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx, call)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The cron run publishes its result
+	e := rec.next(t)
+	if e.Type != events.ScheduleCompleted || e.Data["schedule"] != "warm" || e.Data["trigger"] != TriggerCron {
+		t.Fatalf("event = %+v, want warm completed by cron", e)
+	}
+	if res := e.Data["result"].(*mcp.CallToolResult); res.Content[0].(*mcp.TextContent).Text != "warmed eu" {
+		t.Errorf("result = %+v", res)
+	}
+
+	// The admin API runs a paused schedule, once at a time
+	handler := http.NewServeMux()
+	handler.HandleFunc("GET /admin/schedules", s.ListHandler)
+	handler.HandleFunc("POST /admin/schedules/{name}/{action}", s.ActionHandler)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	actions := []struct {
+		path string
+		want int
+	}{
+		{"/admin/schedules/cleanup/run", http.StatusAccepted},
+		{"/admin/schedules/cleanup/run", http.StatusConflict},
+		{"/admin/schedules/missing/run", http.StatusNotFound},
+		{"/admin/schedules/cleanup/explode", http.StatusNotFound},
+		{"/admin/schedules/warm/pause", http.StatusOK},
+	}
+	for _, a := range actions {
+		if w := do(http.MethodPost, a.path); w.Code != a.want {
+			t.Errorf("POST %s = %d, want %d", a.path, w.Code, a.want)
+		}
+	}
+	close(release)
+	if e := rec.next(t); e.Type != events.ScheduleFailed || e.Data["error"] != "disk busy" || e.Data["trigger"] != TriggerAdmin {
+		t.Errorf("event = %+v, want cleanup failed by admin", e)
+	}
+
+	var list struct {
+		Schedules []Status `json:"schedules"`
+	}
+	if err := json.NewDecoder(do(http.MethodGet, "/admin/schedules").Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Schedules) != 2 || !list.Schedules[0].Paused || !list.Schedules[0].Next.IsZero() || list.Schedules[1].Last == nil {
+		t.Errorf("schedules = %+v", list.Schedules)
+	}
+	if w := do(http.MethodPost, "/admin/schedules/warm/resume"); w.Code != http.StatusOK {
+		t.Errorf("resume = %d", w.Code)
+	}
+	if st := s.Statuses()[0]; st.Paused || !st.Next.After(s.now()) {
+		t.Errorf("resumed schedule = %+v", st)
+	}
+
+	// The last run is readable as a resource
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	s.Register(server)
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = session.Close() }()
+	res, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: "schedule://warm"})
+	if err != nil {
+		t.Fatalf("ReadResource() error = %v", err)
+	}
+	var st Status
+	if err := json.Unmarshal([]byte(res.Contents[0].Text), &st); err != nil || st.Last == nil || st.Last.Trigger != TriggerCron {
+		t.Errorf("resource = %s, %v", res.Contents[0].Text, err)
+	}
+	if _, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: "schedule://missing"}); err == nil {
+		t.Error("ReadResource() of an unknown schedule succeeded")
+	}
+}
+
+func TestTriggerBeforeRun(t *testing.T) {
+	s, err := New([]byte("schedules:\n  - {name: warm, cron: '@daily', tool: warm}\n"), slog.New(slog.DiscardHandler), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Trigger("warm"); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Trigger() error = %v, want ErrNotStarted", err)
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/admin/schedules/warm/run", io.NopCloser(strings.NewReader("")))
+	r.SetPathValue("name", "warm")
+	r.SetPathValue("action", "run")
+	s.ActionHandler(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("run before start = %d, want 503", w.Code)
+	}
+}
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// secretsManagerAPI is the subset of the Secrets Manager client used, so
+// tests can substitute a fake.
+type secretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, in *secretsmanager.GetSecretValueInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// AWS reads settings from an AWS Secrets Manager secret whose SecretString
+// is a JSON object.
+type AWS struct {
+	secretID string
+	client   secretsManagerAPI
+}
+
+// NewAWS loads AWS credentials from the default chain (environment,
+// shared config, web identity, or instance role) and returns a provider.
+func NewAWS(ctx context.Context, cfg Config) (*AWS, error) {
+	if cfg.AWSSecretID == "" {
+		return nil, errors.New("AWS_SECRET_ID is required")
+	}
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.AWSRegion != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.AWSRegion))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS configuration: %w", err)
+	}
+	return &AWS{secretID: cfg.AWSSecretID, client: secretsmanager.NewFromConfig(awsCfg)}, nil
+}
+
+// Name implements Provider.
+func (a *AWS) Name() string { return "aws" }
+
+// Fetch implements Provider.
+func (a *AWS) Fetch(ctx context.Context) (map[string]string, error) {
+	out, err := a.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(a.secretID)})
+	if err != nil {
+		return nil, err
+	}
+	if out.SecretString == nil {
+		return nil, errors.New("secret has no SecretString")
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(*out.SecretString), &values); err != nil {
+		return nil, fmt.Errorf("SecretString must be a JSON object of settings: %w", err)
+	}
+	return stringMap(values), nil
+}
@@ -0,0 +1,118 @@
+// Package secrets loads settings such as API_KEYS from an external secret
+// manager and refreshes them on an interval.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+// Provider fetches settings from a secret manager. Keys are setting names
+// such as API_KEYS; values are their string values.
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// Config selects and configures the secrets provider.
+type Config struct {
+	// Provider is "vault", "aws", or empty to disable.
+	Provider        string
+	RefreshInterval time.Duration
+	Timeout         time.Duration
+
+	VaultAddr      string
+	VaultToken     string
+	VaultNamespace string
+	// VaultPath is the secret's API path below /v1, e.g.
+	// "secret/data/mcp-server" for a KV v2 mount called "secret".
+	VaultPath string
+
+	// AWSSecretID is the name or ARN of a secret whose SecretString is a
+	// JSON object of settings. Region and credentials come from the
+	// standard AWS configuration chain.
+	AWSSecretID string
+	AWSRegion   string
+}
+
+// LoadConfig reads the secrets provider configuration from environment
+// variables. VAULT_TOKEN may itself come from VAULT_TOKEN_FILE.
+func LoadConfig() Config {
+	return Config{
+		Provider:        config.GetEnv("SECRETS_PROVIDER", ""),
+		RefreshInterval: config.GetEnvDuration("SECRETS_REFRESH_INTERVAL", 5*time.Minute),
+		Timeout:         config.GetEnvDuration("SECRETS_TIMEOUT", 10*time.Second),
+		VaultAddr:       config.GetEnv("VAULT_ADDR", ""),
+		VaultToken:      config.GetEnv("VAULT_TOKEN", ""),
+		VaultNamespace:  config.GetEnv("VAULT_NAMESPACE", ""),
+		VaultPath:       config.GetEnv("VAULT_SECRET_PATH", ""),
+		AWSSecretID:     config.GetEnv("AWS_SECRET_ID", ""),
+		AWSRegion:       config.GetEnv("AWS_REGION", ""),
+	}
+}
+
+// New returns the configured provider, or nil if none is configured.
+func New(ctx context.Context, cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "vault":
+		return NewVault(cfg)
+	case "aws":
+		return NewAWS(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_PROVIDER %q: use vault or aws", cfg.Provider)
+	}
+}
+
+// Load fetches the provider's settings once and installs them with
+// config.SetProviderValues. It returns the number of settings loaded.
+func Load(ctx context.Context, p Provider, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	values, err := p.Fetch(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", p.Name(), err)
+	}
+	config.SetProviderValues(values)
+	return len(values), nil
+}
+
+// Refresh calls Load every interval until ctx is done, passing each
+// outcome to onRefresh. A failed refresh keeps the previously loaded values.
+func Refresh(ctx context.Context, p Provider, interval, timeout time.Duration, onRefresh func(n int, err error)) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := Load(ctx, p, timeout)
+			onRefresh(n, err)
+		}
+	}
+}
+
+// stringMap converts a JSON object of settings to strings. Non-string
+// values are kept in their JSON form, so {"PORT": 8080} becomes "8080".
+func stringMap(raw map[string]json.RawMessage) map[string]string {
+	out := make(map[string]string, len(raw))
+	for k, v := range raw {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			out[k] = s
+			continue
+		}
+		out[k] = string(v)
+	}
+	return out
+}
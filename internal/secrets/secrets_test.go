@@ -0,0 +1,190 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+func TestVaultFetch(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "kv v2",
+			status: http.StatusOK,
+			body:   `{"data":{"data":{"API_KEYS":"a,b","PORT":8080},"metadata":{"version":3}}}`,
+			want:   map[string]string{"API_KEYS": "a,b", "PORT": "8080"},
+		},
+		{
+			name:   "kv v1",
+			status: http.StatusOK,
+			body:   `{"data":{"API_KEYS":"a"}}`,
+			want:   map[string]string{"API_KEYS": "a"},
+		},
+		{
+			name:    "forbidden",
+			status:  http.StatusForbidden,
+			body:    `{"errors":["permission denied"]}`,
+			wantErr: "status 403",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/secret/data/mcp" || r.Header.Get("X-Vault-Token") != "tok" || r.Header.Get("X-Vault-Namespace") != "team" {
+					http.Error(w, "bad request", http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			v, err := NewVault(Config{VaultAddr: srv.URL, VaultToken: "tok", VaultNamespace: "team", VaultPath: "/secret/data/mcp", Timeout: time.Second})
+			if err != nil {
+				t.Fatalf("NewVault() error = %v", err)
+			}
+			got, err := v.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewVaultInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "bad address", cfg: Config{VaultAddr: "vault:8200", VaultToken: "t", VaultPath: "p"}, wantErr: "invalid VAULT_ADDR"},
+		{name: "no token", cfg: Config{VaultAddr: "https://vault", VaultPath: "p"}, wantErr: "VAULT_TOKEN"},
+		{name: "no path", cfg: Config{VaultAddr: "https://vault", VaultToken: "t"}, wantErr: "VAULT_SECRET_PATH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewVault(tt.cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewVault() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type fakeSecretsManager struct {
+	out *secretsmanager.GetSecretValueOutput
+	err error
+}
+
+func (f *fakeSecretsManager) GetSecretValue(_ context.Context, in *secretsmanager.GetSecretValueInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	if aws.ToString(in.SecretId) != "mcp/prod" {
+		return nil, errors.New("unexpected secret id")
+	}
+	return f.out, f.err
+}
+
+func TestAWSFetch(t *testing.T) {
+	tests := []struct {
+		name    string
+		fake    *fakeSecretsManager
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "json object",
+			fake: &fakeSecretsManager{out: &secretsmanager.GetSecretValueOutput{SecretString: aws.String(`{"API_KEYS":"k1"}`)}},
+			want: map[string]string{"API_KEYS": "k1"},
+		},
+		{
+			name:    "not json",
+			fake:    &fakeSecretsManager{out: &secretsmanager.GetSecretValueOutput{SecretString: aws.String("k1")}},
+			wantErr: "must be a JSON object",
+		},
+		{
+			name:    "binary secret",
+			fake:    &fakeSecretsManager{out: &secretsmanager.GetSecretValueOutput{SecretBinary: []byte("x")}},
+			wantErr: "no SecretString",
+		},
+		{
+			name:    "api error",
+			fake:    &fakeSecretsManager{err: errors.New("AccessDenied")},
+			wantErr: "AccessDenied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &AWS{secretID: "mcp/prod", client: tt.fake}
+			got, err := a.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type staticProvider map[string]string
+
+func (p staticProvider) Name() string { return "static" }
+
+func (p staticProvider) Fetch(context.Context) (map[string]string, error) { return p, nil }
+
+func TestLoad(t *testing.T) {
+	t.Cleanup(func() { config.SetProviderValues(nil) })
+	t.Setenv("SECRETS_TEST_OVERRIDE", "from-env")
+
+	n, err := Load(context.Background(), staticProvider{"SECRETS_TEST_KEY": "from-provider", "SECRETS_TEST_OVERRIDE": "from-provider"}, time.Second)
+	if err != nil || n != 2 {
+		t.Fatalf("Load() = %d, %v; want 2, nil", n, err)
+	}
+	if got := config.GetEnv("SECRETS_TEST_KEY", ""); got != "from-provider" {
+		t.Errorf("GetEnv(SECRETS_TEST_KEY) = %q, want provider value", got)
+	}
+	if got := config.GetEnv("SECRETS_TEST_OVERRIDE", ""); got != "from-env" {
+		t.Errorf("GetEnv(SECRETS_TEST_OVERRIDE) = %q, want environment to win", got)
+	}
+}
+
+func TestNewUnknownProvider(t *testing.T) {
+	if _, err := New(context.Background(), Config{Provider: "gcp"}); err == nil {
+		t.Error("New() error = nil, want unknown provider error")
+	}
+	if p, err := New(context.Background(), Config{}); p != nil || err != nil {
+		t.Errorf("New() = %v, %v; want nil, nil", p, err)
+	}
+}
@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Vault reads settings from a HashiCorp Vault KV secret over the HTTP API.
+// Both KV v1 and v2 responses are understood.
+type Vault struct {
+	endpoint  string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+// NewVault validates the Vault settings and returns a provider.
+func NewVault(cfg Config) (*Vault, error) {
+	base, err := url.Parse(cfg.VaultAddr)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid VAULT_ADDR %q", cfg.VaultAddr)
+	}
+	if cfg.VaultToken == "" {
+		return nil, errors.New("VAULT_TOKEN is required")
+	}
+	path := strings.Trim(cfg.VaultPath, "/")
+	if path == "" {
+		return nil, errors.New("VAULT_SECRET_PATH is required")
+	}
+	return &Vault{
+		endpoint:  base.JoinPath("v1", path).String(),
+		token:     cfg.VaultToken,
+		namespace: cfg.VaultNamespace,
+		client:    &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Name implements Provider.
+func (v *Vault) Name() string { return "vault" }
+
+// Fetch implements Provider.
+func (v *Vault) Fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading secret: status %d", resp.StatusCode)
+	}
+
+	var secret struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("decoding secret: %w", err)
+	}
+	// KV v2 nests the values under data.data next to data.metadata.
+	if inner, ok := secret.Data["data"]; ok {
+		if _, v2 := secret.Data["metadata"]; v2 {
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(inner, &values); err != nil {
+				return nil, fmt.Errorf("decoding secret: %w", err)
+			}
+			return stringMap(values), nil
+		}
+	}
+	return stringMap(secret.Data), nil
+}
@@ -0,0 +1,223 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+// Reasons a session is reaped, as the reason label of ReapedSessions.
+const (
+	ReasonIdle         = "idle"
+	ReasonUnresponsive = "unresponsive"
+)
+
+var (
+	ReapedSessions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcp_sessions_reaped_total",
+			Help: "Total number of MCP sessions closed by the server, by reason (idle, unresponsive).",
+		},
+		[]string{"reason"},
+	)
+
+	LiveSessions = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "mcp_sessions_live",
+			Help: "Number of MCP sessions watched for idleness and pings.",
+		},
+	)
+)
+
+// ReaperConfig sets how sessions are pinged and when they are closed.
+type ReaperConfig struct {
+	// PingInterval is how often each session is pinged; zero disables
+	// pings.
+	PingInterval time.Duration
+	// PingTimeout bounds each ping.
+	PingTimeout time.Duration
+	// PingFailures is how many pings in a row may fail before the session
+	// is closed.
+	PingFailures int
+	// IdleTimeout closes sessions that sent nothing for this long; zero
+	// keeps idle sessions open.
+	IdleTimeout time.Duration
+}
+
+// LoadReaperConfig reads the SESSION_PING_* and SESSION_IDLE_TIMEOUT
+// settings.
+func LoadReaperConfig() ReaperConfig {
+	return ReaperConfig{
+		PingInterval: config.GetEnvDuration("SESSION_PING_INTERVAL", 0),
+		PingTimeout:  config.GetEnvDuration("SESSION_PING_TIMEOUT", 10*time.Second),
+		PingFailures: config.GetEnvInt("SESSION_PING_FAILURES", 3),
+		IdleTimeout:  config.GetEnvDuration("SESSION_IDLE_TIMEOUT", 0),
+	}
+}
+
+// Reaper pings the live sessions of this process and closes those that
+// stop answering or sit idle, freeing their transport and session state.
+// Only sessions with an ID are watched: stateless HTTP sessions end with
+// their request, and stdio has a single session that lives as long as the
+// process.
+type Reaper struct {
+	cfg    ReaperConfig
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	sessions map[*mcp.ServerSession]*watched
+}
+
+// watched is the state of one session.
+type watched struct {
+	lastActive time.Time
+	failures   int
+}
+
+// NewReaper creates a Reaper, or returns nil if cfg neither pings nor
+// closes idle sessions.
+func NewReaper(cfg ReaperConfig, logger *slog.Logger) *Reaper {
+	if cfg.PingInterval <= 0 && cfg.IdleTimeout <= 0 {
+		return nil
+	}
+	if cfg.PingFailures < 1 {
+		cfg.PingFailures = 1
+	}
+	return &Reaper{cfg: cfg, logger: logger, now: time.Now, sessions: make(map[*mcp.ServerSession]*watched)}
+}
+
+// Middleware records the activity of each session, starting to watch it
+// on its first message.
+func (r *Reaper) Middleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if ss, ok := req.GetSession().(*mcp.ServerSession); ok && ss.ID() != "" {
+			r.touch(ss)
+		}
+		return next(ctx, method, req)
+	}
+}
+
+func (r *Reaper) touch(ss *mcp.ServerSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if w, ok := r.sessions[ss]; ok {
+		w.lastActive = r.now()
+		return
+	}
+	r.sessions[ss] = &watched{lastActive: r.now()}
+	LiveSessions.Inc()
+	go func() {
+		_ = ss.Wait()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.sessions[ss]; ok {
+			delete(r.sessions, ss)
+			LiveSessions.Dec()
+		}
+	}()
+}
+
+// Run checks the sessions every PingInterval, or a tenth of IdleTimeout
+// without pings, until ctx is done.
+func (r *Reaper) Run(ctx context.Context) {
+	interval := r.cfg.PingInterval
+	if interval <= 0 {
+		interval = max(r.cfg.IdleTimeout/10, time.Second)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check(ctx)
+		}
+	}
+}
+
+// check closes idle sessions and pings the others, concurrently so that
+// one slow client does not hold up the rest.
+func (r *Reaper) check(ctx context.Context) {
+	now := r.now()
+	var idle, live []*mcp.ServerSession
+	r.mu.Lock()
+	for ss, w := range r.sessions {
+		if r.cfg.IdleTimeout > 0 && now.Sub(w.lastActive) >= r.cfg.IdleTimeout {
+			idle = append(idle, ss)
+		} else {
+			live = append(live, ss)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, ss := range idle {
+		r.reap(ss, ReasonIdle)
+	}
+	if r.cfg.PingInterval <= 0 {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, ss := range live {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.ping(ctx, ss)
+		}()
+	}
+	wg.Wait()
+}
+
+// ping pings ss, closing it after PingFailures failures in a row.
+func (r *Reaper) ping(ctx context.Context, ss *mcp.ServerSession) {
+	pingCtx, cancel := context.WithTimeout(ctx, r.cfg.PingTimeout)
+	err := ss.Ping(pingCtx, nil)
+	cancel()
+	if ctx.Err() != nil {
+		return // shutting down
+	}
+
+	r.mu.Lock()
+	w, ok := r.sessions[ss]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	if err == nil {
+		w.failures = 0
+		r.mu.Unlock()
+		return
+	}
+	w.failures++
+	failures := w.failures
+	r.mu.Unlock()
+
+	r.logger.Debug("session ping failed", "session_id", ss.ID(), "failures", failures, "error", err)
+	if failures >= r.cfg.PingFailures {
+		r.reap(ss, ReasonUnresponsive)
+	}
+}
+
+// reap stops watching ss and closes it.
+func (r *Reaper) reap(ss *mcp.ServerSession, reason string) {
+	r.mu.Lock()
+	_, ok := r.sessions[ss]
+	if ok {
+		delete(r.sessions, ss)
+		LiveSessions.Dec()
+	}
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	ReapedSessions.WithLabelValues(reason).Inc()
+	r.logger.Info("session reaped", "session_id", ss.ID(), "reason", reason)
+	_ = ss.Close()
+}
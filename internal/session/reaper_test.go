@@ -0,0 +1,142 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewReaper(t *testing.T) {
+	if r := NewReaper(ReaperConfig{PingTimeout: time.Second}, discard); r != nil {
+		t.Errorf("NewReaper() without pings or idle timeout = %v, want nil", r)
+	}
+	if r := NewReaper(ReaperConfig{IdleTimeout: time.Minute}, discard); r == nil || r.cfg.PingFailures != 1 {
+		t.Errorf("NewReaper() = %+v, want a reaper closing after one failure", r)
+	}
+}
+
+// connect returns a server session whose client blocks pings while
+// blockPings is open.
+func connect(t *testing.T, blockPings chan struct{}) *mcp.ServerSession {
+	t.Helper()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	client.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method == "ping" && blockPings != nil {
+				select {
+				case <-blockPings:
+				case <-ctx.Done():
+				}
+			}
+			return next(ctx, method, req)
+		}
+	})
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	t.Cleanup(func() { _ = cs.Close() })
+	return ss
+}
+
+func TestReaper(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        ReaperConfig
+		block      bool
+		later      time.Duration
+		checks     int
+		wantReason string
+	}{
+		{
+			name:   "active",
+			cfg:    ReaperConfig{PingInterval: time.Minute, PingTimeout: time.Second, PingFailures: 1, IdleTimeout: time.Hour},
+			later:  time.Minute,
+			checks: 2,
+		},
+		{
+			name:       "idle",
+			cfg:        ReaperConfig{IdleTimeout: time.Hour},
+			later:      time.Hour,
+			checks:     1,
+			wantReason: ReasonIdle,
+		},
+		{
+			name:   "one failed ping",
+			cfg:    ReaperConfig{PingInterval: time.Minute, PingTimeout: 20 * time.Millisecond, PingFailures: 2},
+			block:  true,
+			checks: 1,
+		},
+		{
+			name:       "unresponsive",
+			cfg:        ReaperConfig{PingInterval: time.Minute, PingTimeout: 20 * time.Millisecond, PingFailures: 2},
+			block:      true,
+			checks:     2,
+			wantReason: ReasonUnresponsive,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var block chan struct{}
+			if tt.block {
+				block = make(chan struct{})
+				defer close(block)
+			}
+			ss := connect(t, block)
+			r := NewReaper(tt.cfg, discard)
+			now := time.Now()
+			r.now = func() time.Time { return now }
+			r.touch(ss)
+
+			before := testutil.ToFloat64(ReapedSessions.WithLabelValues(ReasonIdle)) + testutil.ToFloat64(ReapedSessions.WithLabelValues(ReasonUnresponsive))
+			var reasonBefore float64
+			if tt.wantReason != "" {
+				reasonBefore = testutil.ToFloat64(ReapedSessions.WithLabelValues(tt.wantReason))
+			}
+			now = now.Add(tt.later)
+			for range tt.checks {
+				r.check(context.Background())
+			}
+
+			r.mu.Lock()
+			_, watched := r.sessions[ss]
+			r.mu.Unlock()
+			after := testutil.ToFloat64(ReapedSessions.WithLabelValues(ReasonIdle)) + testutil.ToFloat64(ReapedSessions.WithLabelValues(ReasonUnresponsive))
+			if tt.wantReason == "" {
+				if !watched || after != before {
+					t.Fatalf("session reaped (watched %v, reaped %v), want it kept", watched, after-before)
+				}
+				if tt.block {
+					return
+				}
+				if err := ss.Ping(context.Background(), nil); err != nil {
+					t.Errorf("ping after check: %v", err)
+				}
+				return
+			}
+			if watched {
+				t.Fatal("session still watched, want it reaped")
+			}
+			if got := testutil.ToFloat64(ReapedSessions.WithLabelValues(tt.wantReason)) - reasonBefore; got != 1 {
+				t.Errorf("reaped %s = %v, want 1", tt.wantReason, got)
+			}
+			done := make(chan error, 1)
+			go func() { done <- ss.Wait() }()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("session not closed")
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// Package session lets streamable HTTP sessions span replicas behind a
+// load balancer. Affinity pins a client to the replica that created its
+// session with a cookie and response header; a shared Store lets any
+// replica accept the session instead.
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+// HeaderSessionID is the MCP session header of the streamable HTTP
+// transport.
+const HeaderSessionID = "Mcp-Session-Id"
+
+// HeaderReplica names the replica that serves a session, for load
+// balancers that route on response headers.
+const HeaderReplica = "X-MCP-Replica"
+
+// touchInterval bounds how often a session's LastSeen is rewritten.
+const touchInterval = time.Minute
+
+// Config configures session affinity and sharing.
+type Config struct {
+	// Affinity emits the replica cookie and header when a session is
+	// created.
+	Affinity bool
+	// Cookie is the name of the affinity cookie.
+	Cookie string
+	// Replica identifies this replica; it defaults to the host name.
+	Replica string
+	// Store is "" for sessions local to each replica, "memory", or "dir".
+	Store string
+	// Dir is the shared directory of the dir store.
+	Dir string
+	// TTL is how long an idle session stays in the store.
+	TTL time.Duration
+}
+
+// LoadConfig reads the SESSION_* settings.
+func LoadConfig() Config {
+	replica := config.GetEnv("SESSION_REPLICA_ID", "")
+	if replica == "" {
+		replica, _ = os.Hostname()
+	}
+	return Config{
+		Affinity: config.GetEnvBool("SESSION_AFFINITY", false),
+		Cookie:   config.GetEnv("SESSION_COOKIE", "mcp_replica"),
+		Replica:  replica,
+		Store:    config.GetEnv("SESSION_STORE", ""),
+		Dir:      config.GetEnv("SESSION_STORE_DIR", "/var/lib/mcp-server/sessions"),
+		TTL:      config.GetEnvDuration("SESSION_TTL", 30*time.Minute),
+	}
+}
+
+// NewStore returns the store cfg selects, or nil when sessions are local.
+func NewStore(cfg Config) (Store, error) {
+	switch cfg.Store {
+	case "":
+		return nil, nil
+	case "memory":
+		return NewMemoryStore(cfg.TTL), nil
+	case "dir":
+		return NewDirStore(cfg.Dir, cfg.TTL)
+	default:
+		return nil, fmt.Errorf("unknown SESSION_STORE %q: use memory or dir", cfg.Store)
+	}
+}
+
+// Middleware applies cfg to the MCP endpoint. With a store, the MCP
+// handler must be stateless: the middleware records sessions created by
+// initialize responses, rejects unknown session IDs with 404 Not Found as
+// the transport would, and forgets sessions on DELETE.
+func Middleware(cfg Config, store Store, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(HeaderSessionID)
+			if store != nil && id != "" {
+				s, ok, err := store.Get(r.Context(), id)
+				if err != nil {
+					logger.Error("session store lookup failed", "error", err)
+					http.Error(w, "session store unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				if !ok {
+					http.Error(w, "session not found", http.StatusNotFound)
+					return
+				}
+				if r.Method == http.MethodDelete {
+					if err := store.Delete(r.Context(), id); err != nil {
+						logger.Warn("session store delete failed", "error", err)
+					}
+				} else if now := time.Now(); now.Sub(s.LastSeen) >= touchInterval {
+					s.LastSeen = now
+					if err := store.Put(r.Context(), s); err != nil {
+						logger.Warn("session store update failed", "error", err)
+					}
+				}
+			}
+
+			if id == "" && (cfg.Affinity || store != nil) {
+				w = &sessionWriter{ResponseWriter: w, created: func(h http.Header) {
+					created := h.Get(HeaderSessionID)
+					if created == "" {
+						return
+					}
+					if cfg.Affinity {
+						h.Set(HeaderReplica, cfg.Replica)
+						h.Add("Set-Cookie", (&http.Cookie{
+							Name:     cfg.Cookie,
+							Value:    cfg.Replica,
+							Path:     "/",
+							HttpOnly: true,
+							SameSite: http.SameSiteLaxMode,
+						}).String())
+					}
+					if store != nil {
+						now := time.Now()
+						s := Session{ID: created, Replica: cfg.Replica, Created: now, LastSeen: now}
+						if err := store.Put(r.Context(), s); err != nil {
+							logger.Warn("session store insert failed", "error", err)
+						}
+					}
+				}}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// sessionWriter calls created with the response headers before they are
+// sent, once.
+type sessionWriter struct {
+	http.ResponseWriter
+	created     func(http.Header)
+	wroteHeader bool
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.created(w.Header())
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps event streams working through the wrapper.
+func (w *sessionWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// RunCleanup calls store.Cleanup every interval until ctx is done.
+func RunCleanup(ctx context.Context, store Store, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := store.Cleanup(ctx); err != nil {
+				logger.Warn("session store cleanup failed", "error", err)
+			}
+		}
+	}
+}
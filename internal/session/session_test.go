@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// initializer answers requests without a session with a new session ID,
+// like the streamable HTTP transport answering initialize.
+var initializer = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(HeaderSessionID) == "" {
+		w.Header().Set(HeaderSessionID, "new-session")
+	}
+	_, _ = io.WriteString(w, "{}")
+})
+
+func TestMiddlewareAffinity(t *testing.T) {
+	cfg := Config{Affinity: true, Cookie: "mcp_replica", Replica: "replica-1"}
+	h := Middleware(cfg, nil, discard)(initializer)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	if got := rec.Header().Get(HeaderReplica); got != "replica-1" {
+		t.Errorf("%s = %q, want replica-1", HeaderReplica, got)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "mcp_replica" || cookies[0].Value != "replica-1" {
+		t.Errorf("cookies = %v, want mcp_replica=replica-1", cookies)
+	}
+
+	// Requests in an existing session are not re-pinned
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set(HeaderSessionID, "new-session")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Set-Cookie"); got != "" {
+		t.Errorf("Set-Cookie = %q in an existing session", got)
+	}
+}
+
+func TestMiddlewareStore(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	h := Middleware(Config{Replica: "replica-1"}, store, discard)(initializer)
+	ctx := context.Background()
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	s, ok, _ := store.Get(ctx, "new-session")
+	if !ok || s.Replica != "replica-1" {
+		t.Fatalf("stored session = %+v, %v; want one created by replica-1", s, ok)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		id     string
+		want   int
+	}{
+		{name: "known session", method: http.MethodPost, id: "new-session", want: http.StatusOK},
+		{name: "unknown session", method: http.MethodPost, id: "other", want: http.StatusNotFound},
+		{name: "delete", method: http.MethodDelete, id: "new-session", want: http.StatusOK},
+		{name: "deleted session", method: http.MethodPost, id: "new-session", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/mcp", nil)
+			req.Header.Set(HeaderSessionID, tt.id)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	tests := []struct {
+		store   string
+		wantNil bool
+		wantErr bool
+	}{
+		{store: "", wantNil: true},
+		{store: "memory"},
+		{store: "dir"},
+		{store: "redis", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.store, func(t *testing.T) {
+			got, err := NewStore(Config{Store: tt.store, Dir: t.TempDir(), TTL: time.Hour})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("NewStore() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
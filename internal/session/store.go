@@ -0,0 +1,202 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session is what replicas share about a streamable HTTP session.
+type Session struct {
+	ID string `json:"id"`
+	// Replica is the replica that served the initialize request.
+	Replica  string    `json:"replica"`
+	Created  time.Time `json:"created"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Store holds the sessions known to all replicas. Implementations must be
+// safe for concurrent use and must not return sessions idle for longer
+// than their TTL.
+type Store interface {
+	Get(ctx context.Context, id string) (Session, bool, error)
+	Put(ctx context.Context, s Session) error
+	Delete(ctx context.Context, id string) error
+	// Cleanup removes idle sessions and returns how many were removed.
+	Cleanup(ctx context.Context) (int, error)
+}
+
+// MemoryStore is an in-process Store, for a single replica and tests.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewMemoryStore creates a store forgetting sessions idle for ttl.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]Session), ttl: ttl, now: time.Now}
+}
+
+// Get returns the session id if it is known and not idle.
+func (m *MemoryStore) Get(_ context.Context, id string) (Session, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if ok && m.now().Sub(s.LastSeen) >= m.ttl {
+		delete(m.sessions, id)
+		return Session{}, false, nil
+	}
+	return s, ok, nil
+}
+
+// Put stores s, replacing any session with the same ID.
+func (m *MemoryStore) Put(_ context.Context, s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions[s.ID] = s
+	return nil
+}
+
+// Delete forgets session id.
+func (m *MemoryStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, id)
+	return nil
+}
+
+// Cleanup forgets idle sessions.
+func (m *MemoryStore) Cleanup(context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for id, s := range m.sessions {
+		if m.now().Sub(s.LastSeen) >= m.ttl {
+			delete(m.sessions, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// validID matches the session IDs DirStore accepts as file names.
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// DirStore keeps one JSON file per session in a directory that replicas
+// share, such as a ReadWriteMany volume.
+type DirStore struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewDirStore creates a store in dir, creating the directory if needed,
+// forgetting sessions idle for ttl.
+func NewDirStore(dir string, ttl time.Duration) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DirStore{dir: dir, ttl: ttl, now: time.Now}, nil
+}
+
+func (d *DirStore) path(id string) (string, error) {
+	if !validID.MatchString(id) {
+		return "", fmt.Errorf("invalid session ID %q", id)
+	}
+	return filepath.Join(d.dir, id+".json"), nil
+}
+
+// Get returns the session id if it is known and not idle. Unknown and
+// malformed IDs are both reported as not found.
+func (d *DirStore) Get(_ context.Context, id string) (Session, bool, error) {
+	p, err := d.path(id)
+	if err != nil {
+		return Session{}, false, nil
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return Session{}, false, nil
+	}
+	if err != nil {
+		return Session{}, false, err
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Session{}, false, fmt.Errorf("parsing %s: %w", p, err)
+	}
+	if d.now().Sub(s.LastSeen) >= d.ttl {
+		_ = os.Remove(p)
+		return Session{}, false, nil
+	}
+	return s, true, nil
+}
+
+// Put writes s, replacing the file atomically so readers never see a
+// partial session.
+func (d *DirStore) Put(_ context.Context, s Session) error {
+	p, err := d.path(s.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(d.dir, ".session-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Delete removes session id.
+func (d *DirStore) Delete(_ context.Context, id string) error {
+	p, err := d.path(id)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Cleanup removes the files of idle sessions.
+func (d *DirStore) Cleanup(ctx context.Context) (int, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() || !validID.MatchString(id) {
+			continue
+		}
+		if _, found, err := d.Get(ctx, id); err == nil && !found {
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStores(t *testing.T) {
+	dir, err := NewDirStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]Store{
+		"memory": NewMemoryStore(time.Hour),
+		"dir":    dir,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			want := Session{ID: "abc-123", Replica: "a", Created: now, LastSeen: now}
+			if err := store.Put(ctx, want); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			got, ok, err := store.Get(ctx, want.ID)
+			if err != nil || !ok || got.Replica != want.Replica || !got.LastSeen.Equal(want.LastSeen) {
+				t.Fatalf("Get() = %+v, %v, %v; want %+v", got, ok, err, want)
+			}
+			if _, ok, _ := store.Get(ctx, "../escape"); ok {
+				t.Error("Get() found a session for a malformed ID")
+			}
+
+			idle := Session{ID: "idle", Created: now.Add(-2 * time.Hour), LastSeen: now.Add(-2 * time.Hour)}
+			if err := store.Put(ctx, idle); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := store.Cleanup(ctx); err != nil || n != 1 {
+				t.Errorf("Cleanup() = %d, %v; want 1", n, err)
+			}
+
+			if err := store.Delete(ctx, want.ID); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, ok, _ := store.Get(ctx, want.ID); ok {
+				t.Error("Get() found a deleted session")
+			}
+		})
+	}
+}
+
+func TestDirStoreShared(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewDirStore(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewDirStore(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := a.Put(ctx, Session{ID: "shared", Replica: "a", LastSeen: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if s, ok, err := b.Get(ctx, "shared"); err != nil || !ok || s.Replica != "a" {
+		t.Errorf("other replica Get() = %+v, %v, %v; want the session from replica a", s, ok, err)
+	}
+}
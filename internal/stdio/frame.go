@@ -0,0 +1,72 @@
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// frameReader passes on the lines of its input that hold a JSON-RPC
+// message or batch and drops the rest, so that one malformed line costs
+// the client one message rather than the session. The MCP SDK stops
+// reading at the first message it cannot decode.
+type frameReader struct {
+	io.Closer
+	r      *bufio.Reader
+	logger *slog.Logger
+	line   []byte // the unread part of the current line
+	err    error  // returned once line is drained
+}
+
+func newFrameReader(r io.ReadCloser, logger *slog.Logger) *frameReader {
+	return &frameReader{Closer: r, r: bufio.NewReader(r), logger: logger}
+}
+
+func (f *frameReader) Read(p []byte) (int, error) {
+	for len(f.line) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+		line, err := f.r.ReadBytes('\n')
+		f.err = err
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := checkFrame(line); err != nil {
+			ParseErrors.Inc()
+			f.logger.Warn("malformed stdio message skipped", "bytes", len(line), "error", err)
+			continue
+		}
+		f.line = line
+	}
+	n := copy(p, f.line)
+	f.line = f.line[n:]
+	return n, nil
+}
+
+// checkFrame reports whether line decodes as the MCP SDK decodes input: a
+// JSON-RPC message, or a non-empty array of them.
+func checkFrame(line []byte) error {
+	if !json.Valid(line) {
+		return errors.New("invalid JSON")
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(line, &batch); err != nil {
+		_, err := jsonrpc.DecodeMessage(line)
+		return err
+	}
+	if len(batch) == 0 {
+		return errors.New("empty batch")
+	}
+	for _, raw := range batch {
+		if _, err := jsonrpc.DecodeMessage(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
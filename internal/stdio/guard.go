@@ -0,0 +1,64 @@
+package stdio
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StdoutWrites counts lines written to standard output by anything but
+// the transport while GuardStdout is in effect.
+var StdoutWrites = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "mcp_stdio_stdout_writes_total",
+		Help: "Total number of lines written to stdout outside the stdio protocol and diverted to the log.",
+	},
+)
+
+// GuardStdout reserves standard output for the protocol: a tool printing
+// to os.Stdout, or a child process inheriting it, would otherwise corrupt
+// the message stream. It returns a file writing to the original standard
+// output, for NewTransport, and points os.Stdout and, on Unix, file
+// descriptor 1 at a pipe whose lines are logged as warnings and counted in
+// StdoutWrites. restore undoes the redirection once the log has the last
+// of those lines.
+func GuardStdout(logger *slog.Logger) (out *os.File, restore func(), err error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	out, undo, err := redirectStdout(w)
+	if err != nil {
+		_ = r.Close()
+		_ = w.Close()
+		return nil, nil, err
+	}
+	orig := os.Stdout
+	os.Stdout = w
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 4096), 1<<20)
+		for sc.Scan() {
+			StdoutWrites.Inc()
+			logger.Warn("stdout write diverted to the log; tools must not print to stdout with the stdio transport", "output", sc.Text())
+		}
+		// Keep draining past an overlong line so writers never block
+		_, _ = io.Copy(io.Discard, r)
+		_ = r.Close()
+	})
+	var once sync.Once
+	return out, func() {
+		once.Do(func() {
+			os.Stdout = orig
+			undo()
+			_ = w.Close()
+			wg.Wait()
+		})
+	}, nil
+}
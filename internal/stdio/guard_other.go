@@ -0,0 +1,12 @@
+//go:build !unix
+
+package stdio
+
+import "os"
+
+// redirectStdout returns the original standard output. Without dup2 only
+// writes through os.Stdout are diverted to w, not those of child
+// processes or writes to the handle itself.
+func redirectStdout(*os.File) (*os.File, func(), error) {
+	return os.Stdout, func() {}, nil
+}
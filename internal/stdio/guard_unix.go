@@ -0,0 +1,25 @@
+//go:build unix
+
+package stdio
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// redirectStdout points file descriptor 1 at w and returns a duplicate of
+// the original, and a function pointing descriptor 1 back at it.
+func redirectStdout(w *os.File) (*os.File, func(), error) {
+	fd, err := unix.Dup(1)
+	if err != nil {
+		return nil, nil, err
+	}
+	unix.CloseOnExec(fd)
+	if err := unix.Dup2(int(w.Fd()), 1); err != nil {
+		_ = unix.Close(fd)
+		return nil, nil, err
+	}
+	out := os.NewFile(uintptr(fd), "/dev/stdout")
+	return out, func() { _ = unix.Dup2(fd, 1) }, nil
+}
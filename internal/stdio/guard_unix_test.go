@@ -0,0 +1,27 @@
+//go:build unix
+
+package stdio
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestGuardStdoutDescriptor(t *testing.T) {
+	var buf bytes.Buffer
+	_, restore, err := GuardStdout(slog.New(slog.NewJSONHandler(&buf, nil)))
+	if err != nil {
+		t.Fatalf("GuardStdout() error = %v", err)
+	}
+	// Writes to descriptor 1, as a child process inheriting it makes, are
+	// diverted as well as those through os.Stdout
+	_, _ = unix.Write(1, []byte("raw write\n"))
+	restore()
+	if !strings.Contains(buf.String(), `"output":"raw write"`) {
+		t.Errorf("log = %s, want the write to descriptor 1", buf.String())
+	}
+}
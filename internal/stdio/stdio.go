@@ -0,0 +1,200 @@
+// Package stdio serves MCP over stdin and stdout, counting the messages
+// and bytes that pass so that stdio deployments report their traffic on
+// /metrics as HTTP deployments do.
+package stdio
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// otherMethod is the method label of methods MCP does not define, so a
+// peer cannot create a series per made-up method.
+const otherMethod = "other"
+
+// Stdio metrics.
+var (
+	// Messages counts messages by direction (in, out) and method.
+	// Responses are counted under the method of the request they answer.
+	Messages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcp_stdio_messages_total",
+			Help: "Total number of MCP messages over stdio, by direction (in, out) and method.",
+		},
+		[]string{"direction", "method"},
+	)
+	// Bytes counts bytes read from stdin and written to stdout.
+	Bytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcp_stdio_bytes_total",
+			Help: "Total number of bytes over stdio, by direction (in, out).",
+		},
+		[]string{"direction"},
+	)
+	// ParseErrors counts input that is not a JSON-RPC message.
+	ParseErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mcp_stdio_parse_errors_total",
+			Help: "Total number of malformed messages read from stdin.",
+		},
+	)
+)
+
+// methods are the MCP methods reported in the method label.
+var methods = map[string]bool{
+	"initialize":                           true,
+	"ping":                                 true,
+	"tools/list":                           true,
+	"tools/call":                           true,
+	"resources/list":                       true,
+	"resources/read":                       true,
+	"resources/templates/list":             true,
+	"resources/subscribe":                  true,
+	"resources/unsubscribe":                true,
+	"prompts/list":                         true,
+	"prompts/get":                          true,
+	"completion/complete":                  true,
+	"logging/setLevel":                     true,
+	"roots/list":                           true,
+	"sampling/createMessage":               true,
+	"elicitation/create":                   true,
+	"notifications/initialized":            true,
+	"notifications/cancelled":              true,
+	"notifications/progress":               true,
+	"notifications/message":                true,
+	"notifications/roots/list_changed":     true,
+	"notifications/tools/list_changed":     true,
+	"notifications/prompts/list_changed":   true,
+	"notifications/resources/list_changed": true,
+	"notifications/resources/updated":      true,
+}
+
+// Config tunes the handling of malformed input.
+type Config struct {
+	// Strict ends the session at the first line that is not a JSON-RPC
+	// message, as mcp.StdioTransport does. Otherwise such lines are
+	// logged, counted in ParseErrors, and skipped.
+	Strict bool
+	// Logger receives the skipped lines; nil discards them.
+	Logger *slog.Logger
+}
+
+// NewTransport returns a transport reading newline-delimited JSON-RPC from
+// in and writing it to out, like mcp.StdioTransport over os.Stdin and
+// os.Stdout, that counts the messages and bytes passing through. Closing
+// the connection closes in but not out.
+func NewTransport(in io.ReadCloser, out io.Writer, cfg Config) mcp.Transport {
+	var r io.ReadCloser = &countingReader{ReadCloser: in, bytes: Bytes.WithLabelValues("in")}
+	if !cfg.Strict {
+		r = newFrameReader(r, cmp.Or(cfg.Logger, slog.New(slog.DiscardHandler)))
+	}
+	return &transport{inner: &mcp.IOTransport{
+		Reader: r,
+		Writer: &countingWriter{Writer: out, bytes: Bytes.WithLabelValues("out")},
+	}}
+}
+
+type transport struct {
+	inner mcp.Transport
+}
+
+func (t *transport) Connect(ctx context.Context) (mcp.Connection, error) {
+	c, err := t.inner.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Connection: c, pending: make(map[call]string)}, nil
+}
+
+// conn counts messages, remembering the method of each request until it
+// is answered.
+type conn struct {
+	mcp.Connection
+	mu      sync.Mutex
+	pending map[call]string
+}
+
+// call identifies a request by its id and whether it was read, as both
+// peers number their requests independently.
+type call struct {
+	in bool
+	id jsonrpc.ID
+}
+
+func (c *conn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	msg, err := c.Connection.Read(ctx)
+	if err != nil {
+		if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+			ParseErrors.Inc()
+		}
+		return nil, err
+	}
+	Messages.WithLabelValues("in", c.method(msg, true)).Inc()
+	return msg, nil
+}
+
+func (c *conn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	if err := c.Connection.Write(ctx, msg); err != nil {
+		return err
+	}
+	Messages.WithLabelValues("out", c.method(msg, false)).Inc()
+	return nil
+}
+
+// method returns the method label of msg, read when in is set. Requests
+// in either direction are remembered so that their responses, which go
+// the other way, can be attributed.
+func (c *conn) method(msg jsonrpc.Message, in bool) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var method string
+	switch msg := msg.(type) {
+	case *jsonrpc.Request:
+		method = msg.Method
+		if !methods[method] {
+			method = otherMethod
+		}
+		if msg.IsCall() {
+			c.pending[call{in, msg.ID}] = method
+		}
+	case *jsonrpc.Response:
+		method = c.pending[call{!in, msg.ID}]
+		delete(c.pending, call{!in, msg.ID})
+	}
+	if method == "" {
+		method = otherMethod
+	}
+	return method
+}
+
+type countingReader struct {
+	io.ReadCloser
+	bytes prometheus.Counter
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.bytes.Add(float64(n))
+	return n, err
+}
+
+type countingWriter struct {
+	io.Writer
+	bytes prometheus.Counter
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.bytes.Add(float64(n))
+	return n, err
+}
+
+func (w *countingWriter) Close() error { return nil }
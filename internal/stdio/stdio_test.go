@@ -0,0 +1,144 @@
+package stdio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// pipes returns a client transport connected to a stdio transport.
+func pipes(cfg Config) (client mcp.Transport, server mcp.Transport, stdin *io.PipeWriter) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	return &mcp.IOTransport{Reader: outR, Writer: inW}, NewTransport(inR, outW, cfg), inW
+}
+
+func TestTransport(t *testing.T) {
+	clientTransport, serverTransport, _ := pipes(Config{})
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.Run(ctx, serverTransport) }()
+
+	metric := func(direction, method string) float64 {
+		return testutil.ToFloat64(Messages.WithLabelValues(direction, method))
+	}
+	inInit, outInit := metric("in", "initialize"), metric("out", "initialize")
+	inList, outList := metric("in", "tools/list"), metric("out", "tools/list")
+	bytesIn, bytesOut := testutil.ToFloat64(Bytes.WithLabelValues("in")), testutil.ToFloat64(Bytes.WithLabelValues("out"))
+
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = session.Close() }()
+	if _, err := session.ListTools(ctx, nil); err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+
+	tests := []struct {
+		direction, method string
+		before            float64
+	}{
+		{"in", "initialize", inInit},
+		{"out", "initialize", outInit},
+		{"in", "tools/list", inList},
+		{"out", "tools/list", outList},
+	}
+	// The server counts a response once written, which may be just after
+	// the client has read it
+	deadline := time.Now().Add(5 * time.Second)
+	for metric("out", "tools/list") == outList && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for _, tt := range tests {
+		if got := metric(tt.direction, tt.method); got != tt.before+1 {
+			t.Errorf("messages{%s,%s} = %v, want %v", tt.direction, tt.method, got, tt.before+1)
+		}
+	}
+	if testutil.ToFloat64(Bytes.WithLabelValues("in")) <= bytesIn || testutil.ToFloat64(Bytes.WithLabelValues("out")) <= bytesOut {
+		t.Error("bytes were not counted")
+	}
+}
+
+func TestTransportMalformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		strict   bool
+		input    string
+		wantErrs float64
+	}{
+		{name: "valid", input: `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n"},
+		{name: "blank lines", input: "\n  \r\n" + `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n"},
+		{name: "invalid JSON skipped", input: "{not json\n" + `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n", wantErrs: 1},
+		{name: "not JSON-RPC skipped", input: `{"hello":"world"}` + "\n[]\n" + `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n", wantErrs: 2},
+		{name: "bad batch member skipped", input: `[{"jsonrpc":"2.0","id":1,"method":"ping"},1]` + "\n" + `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n", wantErrs: 1},
+		{name: "last line without newline", input: "oops\n" + `{"jsonrpc":"2.0","id":1,"method":"ping"}`, wantErrs: 1},
+		{name: "strict", strict: true, input: "{not json\n", wantErrs: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, serverTransport, stdin := pipes(Config{Strict: tt.strict})
+			conn, err := serverTransport.Connect(context.Background())
+			if err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+			defer func() { _ = conn.Close() }()
+			before := testutil.ToFloat64(ParseErrors)
+			go func() {
+				_, _ = stdin.Write([]byte(tt.input))
+				_ = stdin.Close()
+			}()
+			msg, err := conn.Read(context.Background())
+			if tt.strict {
+				if err == nil {
+					t.Fatal("Read() of malformed input succeeded in strict mode")
+				}
+			} else if req, ok := msg.(*jsonrpc.Request); err != nil || !ok || req.Method != "ping" {
+				t.Fatalf("Read() = %v, %v, want the ping", msg, err)
+			}
+			if got := testutil.ToFloat64(ParseErrors); got != before+tt.wantErrs {
+				t.Errorf("parse errors = %v, want %v", got-before, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestGuardStdout(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	orig := os.Stdout
+	before := testutil.ToFloat64(StdoutWrites)
+
+	out, restore, err := GuardStdout(logger)
+	if err != nil {
+		t.Fatalf("GuardStdout() error = %v", err)
+	}
+	if out == nil || os.Stdout == orig {
+		t.Fatal("os.Stdout was not redirected")
+	}
+	fmt.Println("stray output")
+	fmt.Print("unterminated")
+	restore()
+	restore()
+
+	if os.Stdout != orig {
+		t.Error("os.Stdout not restored")
+	}
+	if got := testutil.ToFloat64(StdoutWrites); got != before+2 {
+		t.Errorf("stdout writes = %v, want 2", got-before)
+	}
+	if !strings.Contains(buf.String(), `"output":"stray output"`) || !strings.Contains(buf.String(), `"output":"unterminated"`) {
+		t.Errorf("log = %s, want both writes", buf.String())
+	}
+}
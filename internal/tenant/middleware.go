@@ -0,0 +1,128 @@
+package tenant
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// Results of a request, as the result label of Requests.
+const (
+	ResultAllowed     = "allowed"
+	ResultRateLimited = "rate_limited"
+	ResultUnknown     = "unknown_tenant"
+	ResultMismatch    = "tenant_mismatch"
+)
+
+// Requests counts MCP requests by tenant and result. Requests no tenant
+// accepts, or whose Host and caller disagree, are counted under the
+// tenant "unknown".
+var Requests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "mcp_tenant_requests_total",
+		Help: "Total number of MCP requests by tenant and result (allowed, rate_limited, unknown_tenant, tenant_mismatch).",
+	},
+	[]string{"tenant", "result"},
+)
+
+// HTTPMiddleware assigns each request under protectedPrefixes its tenant,
+// recorded in middleware.HeaderTenant, and applies the tenant's rate
+// limit. It must run inside the auth stage, whose caller identity decides
+// the tenant. Requests without a tenant, or whose caller does not belong
+// to the tenant of their Host, get 403 Forbidden, and requests over the
+// limit 429 Too Many Requests with a Retry-After header.
+func HTTPMiddleware(s *Set, protectedPrefixes []string, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !protected(r.URL.Path, protectedPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			caller := r.Header.Get(middleware.HeaderCaller)
+			t, err := s.Resolve(r.Host, caller, r.Header)
+			if err != nil {
+				Requests.WithLabelValues("unknown", ResultMismatch).Inc()
+				logger.Warn("request to a tenant's host from another caller", "caller", caller, "host", r.Host)
+				middleware.WriteRPCError(w, http.StatusForbidden, middleware.CodeForbidden, middleware.ReasonTenantMismatch, "caller does not belong to the tenant of this host")
+				return
+			}
+			if t == nil {
+				Requests.WithLabelValues("unknown", ResultUnknown).Inc()
+				logger.Warn("request matches no tenant", "caller", caller)
+				middleware.WriteRPCError(w, http.StatusForbidden, middleware.CodeForbidden, middleware.ReasonUnknownTenant, "no tenant for this caller")
+				return
+			}
+			if ok, wait := s.reserve(t.Name); !ok {
+				Requests.WithLabelValues(t.Name, ResultRateLimited).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				middleware.WriteRPCError(w, http.StatusTooManyRequests, middleware.CodeRateLimited, middleware.ReasonRateLimited, "tenant rate limit exceeded")
+				return
+			}
+			Requests.WithLabelValues(t.Name, ResultAllowed).Inc()
+			r.Header.Set(middleware.HeaderTenant, t.Name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Middleware scopes tools to the tenant HTTPMiddleware recorded: tools/list
+// only lists the tenant's tools, and calls of any other tool fail with a
+// permission_denied tool error, as if the policy denied them. Requests
+// without a tenant, such as those on stdio, are left alone.
+func Middleware(s *Set) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != "tools/list" && method != "tools/call" {
+				return next(ctx, method, req)
+			}
+			var name string
+			if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+				name = extra.Header.Get(middleware.HeaderTenant)
+			}
+			if name == "" {
+				return next(ctx, method, req)
+			}
+
+			if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok {
+				if !s.Allows(name, params.Name) {
+					e := tools.NewError(tools.CodePermissionDenied, "%s is not available to tenant %s", params.Name, name)
+					return tools.ErrorResult(e.WithDetail("tenant", name)), nil
+				}
+				return next(ctx, method, req)
+			}
+
+			result, err := next(ctx, method, req)
+			list, ok := result.(*mcp.ListToolsResult)
+			if err != nil || !ok {
+				return result, err
+			}
+			scoped := *list
+			scoped.Tools = make([]*mcp.Tool, 0, len(list.Tools))
+			for _, tool := range list.Tools {
+				if s.Allows(name, tool.Name) {
+					scoped.Tools = append(scoped.Tools, tool)
+				}
+			}
+			return &scoped, nil
+		}
+	}
+}
+
+// protected reports whether path is under one of prefixes.
+func protected(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
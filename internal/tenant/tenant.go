@@ -0,0 +1,213 @@
+// Package tenant lets one deployment serve several teams, or stand in for
+// several small servers. Each request is assigned a tenant from its Host,
+// caller identity, or a header, and the tenant decides which tools the
+// request sees and may call and how fast it may send requests. Tenants
+// are loaded from a YAML file:
+//
+//	header: X-Tenant
+//	tenants:
+//	  - name: search
+//	    hosts: [search.mcp.example.com]
+//	    callers: ["api_key:3f2a9c*"]
+//	    tools: [http_fetch, "net.*"]
+//	    rate_limit: {requests: 100, window: 1m}
+package tenant
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+// Config is the tenants file.
+type Config struct {
+	// Header names a request header naming the tenant, for requests whose
+	// caller matches no tenant. Only set it when a gateway in front of the
+	// server sets the header, since clients can send any value.
+	Header string `yaml:"header"`
+	// Default is the tenant of requests no caller or header assigns; when
+	// empty they are rejected.
+	Default string   `yaml:"default"`
+	Tenants []Tenant `yaml:"tenants"`
+}
+
+// Tenant is a team sharing the deployment. Hosts, Callers, and Tools are
+// path.Match globs.
+type Tenant struct {
+	Name string `yaml:"name"`
+	// Hosts are the Host names, without port, served as the tenant. Since
+	// clients choose the Host, requests to them belong to the tenant only
+	// when their caller does too, or, for a tenant without Callers, when
+	// they are unauthenticated; other requests to them are refused.
+	Hosts []string `yaml:"hosts"`
+	// Callers are the caller identities that belong to the tenant, as
+	// recorded by the auth stage.
+	Callers []string `yaml:"callers"`
+	// Tools the tenant may list and call; empty allows every tool.
+	Tools     []string  `yaml:"tools"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+}
+
+// RateLimit allows Requests per Window to the whole tenant, in bursts of
+// up to Burst. A zero Requests leaves the tenant unlimited.
+type RateLimit struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+	Burst    int           `yaml:"burst"`
+}
+
+// ErrHostMismatch is returned by Resolve for a request to a tenant's host
+// whose caller does not belong to that tenant.
+var ErrHostMismatch = errors.New("caller does not belong to the tenant of the host")
+
+// Set is a loaded tenants file.
+type Set struct {
+	cfg      Config
+	byName   map[string]*Tenant
+	limiters map[string]middleware.Limiter
+}
+
+// Load reads and checks the tenants file at path.
+func Load(file string) (*Set, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("tenants: %w", err)
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("tenants %s: %w", file, err)
+	}
+	return s, nil
+}
+
+// Parse checks a tenants document, rejecting unknown fields.
+func Parse(data []byte) (*Set, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	s := &Set{cfg: cfg, byName: make(map[string]*Tenant), limiters: make(map[string]middleware.Limiter)}
+	var errs []error
+	for i := range s.cfg.Tenants {
+		t := &s.cfg.Tenants[i]
+		if t.Name == "" {
+			errs = append(errs, fmt.Errorf("tenant %d has no name", i+1))
+			continue
+		}
+		if _, dup := s.byName[t.Name]; dup {
+			errs = append(errs, fmt.Errorf("tenant %s is defined twice", t.Name))
+			continue
+		}
+		s.byName[t.Name] = t
+		for j, h := range t.Hosts {
+			t.Hosts[j] = strings.ToLower(h)
+		}
+		for _, g := range slices.Concat(t.Hosts, t.Callers, t.Tools) {
+			if _, err := path.Match(g, ""); err != nil {
+				errs = append(errs, fmt.Errorf("tenant %s: pattern %q: %w", t.Name, g, err))
+			}
+		}
+		if rl := t.RateLimit; rl.Requests != 0 {
+			l, err := middleware.NewLimiter(middleware.AlgorithmTokenBucket, rl.Requests, rl.Window, max(rl.Burst, rl.Requests))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("tenant %s: %w", t.Name, err))
+				continue
+			}
+			s.limiters[t.Name] = l
+		}
+	}
+	if s.cfg.Default != "" && s.byName[s.cfg.Default] == nil {
+		errs = append(errs, fmt.Errorf("default tenant %q is not defined", s.cfg.Default))
+	}
+	if len(s.byName) == 0 && len(errs) == 0 {
+		errs = append(errs, errors.New("no tenants defined"))
+	}
+	return s, errors.Join(errs...)
+}
+
+// Len returns the number of tenants.
+func (s *Set) Len() int {
+	return len(s.byName)
+}
+
+// Resolve returns the tenant of a request to host from caller. A request
+// to a host some tenant lists belongs to that tenant if the caller
+// matches its callers, or if it has none and the request is
+// unauthenticated; any other request to the host fails with
+// ErrHostMismatch, so a caller cannot choose another tenant by its Host.
+// Other requests belong to the first tenant listing the caller, else the
+// tenant named by the configured header, else the default. It returns
+// nil when none applies.
+func (s *Set) Resolve(host, caller string, header http.Header) (*Tenant, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host != "" {
+		for i := range s.cfg.Tenants {
+			t := &s.cfg.Tenants[i]
+			if len(t.Hosts) == 0 || !globs(t.Hosts, strings.ToLower(host)) {
+				continue
+			}
+			if len(t.Callers) > 0 && caller != "" && globs(t.Callers, caller) || len(t.Callers) == 0 && caller == "" {
+				return t, nil
+			}
+			return nil, ErrHostMismatch
+		}
+	}
+	if caller != "" {
+		for i := range s.cfg.Tenants {
+			if t := &s.cfg.Tenants[i]; len(t.Callers) > 0 && globs(t.Callers, caller) {
+				return t, nil
+			}
+		}
+	}
+	if s.cfg.Header != "" {
+		if t := s.byName[header.Get(s.cfg.Header)]; t != nil {
+			return t, nil
+		}
+	}
+	return s.byName[s.cfg.Default], nil
+}
+
+// Allows reports whether the tenant called name may use tool. Unknown
+// tenants may use nothing.
+func (s *Set) Allows(name, tool string) bool {
+	t := s.byName[name]
+	return t != nil && globs(t.Tools, tool)
+}
+
+// reserve counts a request against the tenant's rate limit.
+func (s *Set) reserve(name string) (bool, time.Duration) {
+	l := s.limiters[name]
+	if l == nil {
+		return true, 0
+	}
+	return l.Reserve(name)
+}
+
+func globs(patterns []string, s string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+const testTenants = `
+header: X-Tenant
+default: guests
+tenants:
+  - name: search
+    callers: ["api_key:search*"]
+    tools: [http_fetch, "dns_*"]
+    rate_limit: {requests: 2, window: 1h}
+  - name: ops
+    callers: ["oauth:ops-*"]
+  - name: docs
+    hosts: ["docs.example.com", "*.docs.example.com"]
+    tools: [http_fetch]
+  - name: billing
+    hosts: ["billing.example.com"]
+    callers: ["api_key:billing*"]
+  - name: guests
+    tools: [uuid]
+`
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{name: "valid", doc: testTenants},
+		{name: "empty", doc: "", wantErr: "no tenants defined"},
+		{name: "unknown field", doc: "tenants:\n  - name: a\n    tool: [x]\n", wantErr: "field tool not found"},
+		{name: "no name", doc: "tenants:\n  - tools: [x]\n", wantErr: "tenant 1 has no name"},
+		{name: "duplicate", doc: "tenants:\n  - name: a\n  - name: a\n", wantErr: "tenant a is defined twice"},
+		{name: "bad glob", doc: "tenants:\n  - name: a\n    tools: [\"[\"]\n", wantErr: "pattern"},
+		{name: "bad rate limit", doc: "tenants:\n  - name: a\n    rate_limit: {requests: 5}\n", wantErr: "rate limit must be positive"},
+		{name: "unknown default", doc: "default: b\ntenants:\n  - name: a\n", wantErr: `default tenant "b" is not defined`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.doc))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	s, err := Parse([]byte(testTenants))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		host   string
+		caller string
+		header string
+		want   string
+		err    error
+	}{
+		{name: "api key", caller: "api_key:search01", want: "search"},
+		{name: "oauth", caller: "oauth:ops-alice", want: "ops"},
+		{name: "caller wins over header", caller: "api_key:search01", header: "ops", want: "search"},
+		{name: "header", caller: "api_key:other", header: "ops", want: "ops"},
+		{name: "unknown header", header: "nobody", want: "guests"},
+		{name: "default", want: "guests"},
+		{name: "host", host: "docs.example.com", want: "docs"},
+		{name: "host glob with port", host: "EU.Docs.example.com:8443", want: "docs"},
+		{name: "host and caller", host: "billing.example.com", caller: "api_key:billing01", want: "billing"},
+		{name: "unlisted host", host: "other.example.com", caller: "oauth:ops-alice", want: "ops"},
+		// The client chooses the Host, so it cannot move a caller to another tenant
+		{name: "caller to another tenant's host", host: "billing.example.com", caller: "api_key:search01", err: ErrHostMismatch},
+		{name: "caller to an open host", host: "docs.example.com", caller: "api_key:search01", err: ErrHostMismatch},
+		{name: "unauthenticated to a host with callers", host: "billing.example.com", err: ErrHostMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.header != "" {
+				h.Set("X-Tenant", tt.header)
+			}
+			got, err := s.Resolve(tt.host, tt.caller, h)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) || got != nil {
+					t.Errorf("Resolve(%q, %q, %q) = %+v, %v, want %v", tt.host, tt.caller, tt.header, got, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got == nil || got.Name != tt.want {
+				t.Errorf("Resolve(%q, %q, %q) = %+v, %v, want %s", tt.host, tt.caller, tt.header, got, err, tt.want)
+			}
+		})
+	}
+
+	noDefault, _ := Parse([]byte("tenants:\n  - name: a\n    callers: [x]\n"))
+	if got, _ := noDefault.Resolve("", "y", http.Header{}); got != nil {
+		t.Errorf("Resolve() without a default = %+v, want nil", got)
+	}
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	s, err := Parse([]byte(testTenants + "    callers: [\"api_key:guest*\"]\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	s.cfg.Default = ""
+	var tenant string
+	handler := HTTPMiddleware(s, []string{"/mcp"}, discard)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = r.Header.Get(middleware.HeaderTenant)
+	}))
+
+	tests := []struct {
+		name       string
+		path       string
+		host       string
+		caller     string
+		wantStatus int
+		wantTenant string
+		wantReason string
+	}{
+		{name: "unprotected", path: "/health", wantStatus: http.StatusOK},
+		{name: "first", path: "/mcp", caller: "api_key:search01", wantStatus: http.StatusOK, wantTenant: "search"},
+		{name: "second", path: "/mcp", caller: "api_key:search02", wantStatus: http.StatusOK, wantTenant: "search"},
+		{name: "over the tenant limit", path: "/mcp", caller: "api_key:search03", wantStatus: http.StatusTooManyRequests, wantReason: middleware.ReasonRateLimited},
+		{name: "other tenant unlimited", path: "/mcp", caller: "api_key:guest", wantStatus: http.StatusOK, wantTenant: "guests"},
+		{name: "no tenant", path: "/mcp", caller: "api_key:stray", wantStatus: http.StatusForbidden, wantReason: middleware.ReasonUnknownTenant},
+		{name: "host", path: "/mcp", host: "docs.example.com", wantStatus: http.StatusOK, wantTenant: "docs"},
+		{name: "host of another tenant", path: "/mcp", host: "billing.example.com", caller: "api_key:guest", wantStatus: http.StatusForbidden, wantReason: middleware.ReasonTenantMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant = ""
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			req.Header.Set(middleware.HeaderCaller, tt.caller)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus || tenant != tt.wantTenant {
+				t.Fatalf("status, tenant = %d, %q, want %d, %q", rec.Code, tenant, tt.wantStatus, tt.wantTenant)
+			}
+			if tt.wantReason == "" {
+				return
+			}
+			var body middleware.RPCError
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error.Data.Reason != tt.wantReason {
+				t.Errorf("body = %+v, %v, want reason %s", body, err, tt.wantReason)
+			}
+			if tt.wantStatus == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+				t.Error("Retry-After not set")
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	s, err := Parse([]byte(testTenants))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	listed := []*mcp.Tool{{Name: "http_fetch"}, {Name: "dns_lookup"}, {Name: "exec"}}
+	next := func(_ context.Context, method string, _ mcp.Request) (mcp.Result, error) {
+		if method == "tools/list" {
+			return &mcp.ListToolsResult{Tools: listed}, nil
+		}
+		return &mcp.CallToolResult{}, nil
+	}
+	handler := Middleware(s)(next)
+	extra := func(tenant string) *mcp.RequestExtra {
+		h := http.Header{}
+		if tenant != "" {
+			h.Set(middleware.HeaderTenant, tenant)
+		}
+		return &mcp.RequestExtra{Header: h}
+	}
+
+	tests := []struct {
+		name      string
+		tenant    string
+		wantTools string
+	}{
+		{name: "scoped", tenant: "search", wantTools: "http_fetch,dns_lookup"},
+		{name: "all tools", tenant: "ops", wantTools: "http_fetch,dns_lookup,exec"},
+		{name: "no tenant", wantTools: "http_fetch,dns_lookup,exec"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := handler(context.Background(), "tools/list", &mcp.ListToolsRequest{Params: &mcp.ListToolsParams{}, Extra: extra(tt.tenant)})
+			if err != nil {
+				t.Fatalf("tools/list error = %v", err)
+			}
+			var names []string
+			for _, tool := range res.(*mcp.ListToolsResult).Tools {
+				names = append(names, tool.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.wantTools {
+				t.Errorf("tools = %q, want %q", got, tt.wantTools)
+			}
+
+			call := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "exec"}, Extra: extra(tt.tenant)}
+			res, err = handler(context.Background(), "tools/call", call)
+			if err != nil {
+				t.Fatalf("tools/call error = %v", err)
+			}
+			if denied := res.(*mcp.CallToolResult).IsError; denied != !strings.Contains(tt.wantTools, "exec") {
+				t.Errorf("exec denied = %v", denied)
+			}
+		})
+	}
+	if len(listed) != 3 {
+		t.Errorf("tools/list result modified in place: %d tools", len(listed))
+	}
+}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ReadOnly returns the annotations of a tool that does not modify its
+// environment. openWorld reports whether it reaches systems outside the
+// server, such as the network or a cluster.
+func ReadOnly(openWorld bool) *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true, OpenWorldHint: &openWorld}
+}
+
+// Additive returns the annotations of a tool that adds to its environment
+// without overwriting or deleting anything.
+func Additive(idempotent, openWorld bool) *mcp.ToolAnnotations {
+	destructive := false
+	return &mcp.ToolAnnotations{DestructiveHint: &destructive, IdempotentHint: idempotent, OpenWorldHint: &openWorld}
+}
+
+// Destructive returns the annotations of a tool that may overwrite or
+// delete data.
+func Destructive(idempotent, openWorld bool) *mcp.ToolAnnotations {
+	destructive := true
+	return &mcp.ToolAnnotations{DestructiveHint: &destructive, IdempotentHint: idempotent, OpenWorldHint: &openWorld}
+}
+
+// Hints are a tool's annotations with the MCP defaults applied, for
+// policies that allow or deny calls by what a tool may do.
+type Hints struct {
+	ReadOnly    bool `json:"read_only"`
+	Destructive bool `json:"destructive"`
+	Idempotent  bool `json:"idempotent"`
+	OpenWorld   bool `json:"open_world"`
+}
+
+// HintsOf applies the MCP defaults to a: a tool without annotations is
+// assumed to modify its environment destructively and to reach an open
+// world.
+func HintsOf(a *mcp.ToolAnnotations) Hints {
+	if a == nil {
+		a = &mcp.ToolAnnotations{}
+	}
+	h := Hints{ReadOnly: a.ReadOnlyHint, Idempotent: a.IdempotentHint, Destructive: true, OpenWorld: true}
+	if a.DestructiveHint != nil {
+		h.Destructive = *a.DestructiveHint
+	}
+	if a.OpenWorldHint != nil {
+		h.OpenWorld = *a.OpenWorldHint
+	}
+	if h.ReadOnly {
+		h.Destructive, h.Idempotent = false, true
+	}
+	return h
+}
+
+// annotations holds the annotations of every tool registered with
+// AddTool, by name.
+var annotations sync.Map
+
+// ToolHints returns the hints of the tool called name, and false if no
+// tool of that name was registered with AddTool.
+func ToolHints(name string) (Hints, bool) {
+	a, ok := annotations.Load(name)
+	if !ok {
+		return HintsOf(nil), false
+	}
+	return HintsOf(a.(*mcp.ToolAnnotations)), true
+}
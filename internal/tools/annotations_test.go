@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestHintsOf(t *testing.T) {
+	tests := []struct {
+		name string
+		a    *mcp.ToolAnnotations
+		want Hints
+	}{
+		{name: "unannotated", want: Hints{Destructive: true, OpenWorld: true}},
+		{name: "read only", a: ReadOnly(false), want: Hints{ReadOnly: true, Idempotent: true}},
+		{name: "read only open world", a: ReadOnly(true), want: Hints{ReadOnly: true, Idempotent: true, OpenWorld: true}},
+		{name: "additive", a: Additive(false, true), want: Hints{OpenWorld: true}},
+		{name: "destructive", a: Destructive(true, false), want: Hints{Destructive: true, Idempotent: true}},
+		{name: "read only wins", a: &mcp.ToolAnnotations{ReadOnlyHint: true}, want: Hints{ReadOnly: true, Idempotent: true, OpenWorld: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HintsOf(tt.a); got != tt.want {
+				t.Errorf("HintsOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolHints(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1"}, nil)
+	AddTool(server, &mcp.Tool{Name: "annotated_test_tool", Annotations: Destructive(false, false)},
+		func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, struct{}, error) {
+			return nil, struct{}{}, nil
+		})
+
+	got, ok := ToolHints("annotated_test_tool")
+	if !ok || got != (Hints{Destructive: true}) {
+		t.Errorf("ToolHints() = %+v, %v; want destructive", got, ok)
+	}
+	if _, ok := ToolHints("unregistered_tool"); ok {
+		t.Error("ToolHints() found an unregistered tool")
+	}
+}
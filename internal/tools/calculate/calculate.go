@@ -0,0 +1,67 @@
+package calculate
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// DefaultDigits is the number of significant digits in the result when the
+// caller does not specify one.
+const DefaultDigits = 30
+
+// MaxDigits caps the requested number of significant digits.
+const MaxDigits = 70
+
+// Input is the input for the calculate tool.
+type Input struct {
+	Expression string             `json:"expression" jsonschema:"arithmetic expression, e.g. sqrt(2) * (3 + 4)^2 or mean([1, 2, 3])"`
+	Variables  map[string]float64 `json:"variables,omitempty" jsonschema:"named values usable in the expression"`
+	Digits     int                `json:"digits,omitempty" jsonschema:"significant digits in the result (default 30, max 70)"`
+}
+
+// Output is the output of the calculate tool.
+type Output struct {
+	Result string  `json:"result" jsonschema:"the result as a decimal string at the requested precision"`
+	Value  float64 `json:"value" jsonschema:"the result as a double-precision number"`
+}
+
+// Calculate evaluates an arithmetic expression.
+func Calculate(ctx context.Context, req *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	if strings.TrimSpace(input.Expression) == "" {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "expression is required")
+	}
+	digits := input.Digits
+	if digits <= 0 {
+		digits = DefaultDigits
+	}
+	if digits > MaxDigits {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "digits must be at most %d", MaxDigits)
+	}
+
+	result, err := Evaluate(input.Expression, input.Variables)
+	if err != nil {
+		return nil, Output{}, err
+	}
+	value, _ := result.Float64()
+
+	logger.Info("tool called", "tool", "calculate", "expression_length", len(input.Expression))
+	return nil, Output{Result: result.Text('g', digits), Value: value}, nil
+}
+
+func init() {
+	tools.Register(tools.Namespace("util", func(server *mcp.Server) {
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "calculate",
+			Description: "Evaluate an arithmetic expression with high precision. Supports + - * / % ^, parentheses, pi and e, sqrt, abs, pow, exp, ln/log, log10, log2, trig functions, floor/ceil/round/trunc, and sum, mean, median, min, max, variance, stddev, count over lists like [1, 2, 3]",
+			Annotations: tools.ReadOnly(false),
+		}, Calculate)
+	}))
+}
@@ -0,0 +1,89 @@
+package calculate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      Input
+		wantResult string
+		wantValue  float64
+		wantErr    string
+		wantCode   tools.Code
+	}{
+		{
+			name:       "default digits",
+			input:      Input{Expression: "2 / 3"},
+			wantResult: "0.666666666666666666666666666667",
+			wantValue:  2.0 / 3,
+		},
+		{
+			name:       "custom digits",
+			input:      Input{Expression: "2 / 3", Digits: 5},
+			wantResult: "0.66667",
+			wantValue:  2.0 / 3,
+		},
+		{
+			name:       "variables",
+			input:      Input{Expression: "r^2 * pi", Variables: map[string]float64{"r": 2}, Digits: 10},
+			wantResult: "12.56637061",
+			wantValue:  12.566370614359172,
+		},
+		{
+			name:     "empty",
+			input:    Input{Expression: " "},
+			wantErr:  "expression is required",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name:     "too many digits",
+			input:    Input{Expression: "1", Digits: 100},
+			wantErr:  "digits must be at most",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name:     "syntax error",
+			input:    Input{Expression: "1 +"},
+			wantErr:  "unexpected end of expression",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name:     "too long",
+			input:    Input{Expression: strings.Repeat("1+", MaxExpressionBytes)},
+			wantErr:  "expression exceeds",
+			wantCode: tools.CodeResourceExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Calculate(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Result != tt.wantResult {
+				t.Errorf("Result = %q, want %q", out.Result, tt.wantResult)
+			}
+			if out.Value != tt.wantValue {
+				t.Errorf("Value = %v, want %v", out.Value, tt.wantValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,601 @@
+package calculate
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// Precision is the mantissa size in bits used for arithmetic.
+const Precision = 256
+
+// Limits on expression complexity.
+const (
+	MaxExpressionBytes = 4096
+	MaxDepth           = 100
+	MaxIntegerExponent = 10000
+)
+
+// value is either a scalar or a list of scalars. Lists appear only as
+// arguments to aggregate functions.
+type value struct {
+	num  *big.Float
+	list []*big.Float
+}
+
+func (v value) isList() bool { return v.list != nil }
+
+// Constants to more digits than Precision can represent.
+const (
+	piDigits = "3.14159265358979323846264338327950288419716939937510582097494459230781640628620899863"
+	eDigits  = "2.71828182845904523536028747135266249775724709369995957496696762772407663035354759457"
+)
+
+func mustParse(s string) *big.Float {
+	f, ok := newFloat().SetString(s)
+	if !ok {
+		panic("calculate: invalid constant " + s)
+	}
+	return f
+}
+
+func newFloat() *big.Float { return new(big.Float).SetPrec(Precision) }
+
+func fromFloat64(f float64) (*big.Float, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("result is not a finite number")
+	}
+	return newFloat().SetFloat64(f), nil
+}
+
+// parser is a recursive-descent evaluator over the grammar:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/" | "%") unary }
+//	unary   = ("+" | "-") unary | power
+//	power   = primary [ ("^" | "**") unary ]
+//	primary = number | name | name "(" [ args ] ")" | "(" expr ")" | "[" [ args ] "]"
+//	args    = expr { "," expr }
+//
+// Expressions are evaluated as they are parsed; nothing is ever compiled or
+// executed as code.
+type parser struct {
+	src   string
+	pos   int
+	depth int
+	vars  map[string]*big.Float
+}
+
+// Evaluate parses and evaluates expr with the given variables.
+func Evaluate(expr string, vars map[string]float64) (*big.Float, error) {
+	if len(expr) > MaxExpressionBytes {
+		return nil, tools.NewError(tools.CodeResourceExhausted, "expression exceeds %d bytes", MaxExpressionBytes).
+			WithDetail("max_bytes", MaxExpressionBytes)
+	}
+	p := &parser{src: expr, vars: map[string]*big.Float{
+		"pi": mustParse(piDigits),
+		"e":  mustParse(eDigits),
+	}}
+	for name, v := range vars {
+		f, err := fromFloat64(v)
+		if err != nil {
+			return nil, tools.WrapError(tools.CodeInvalidArgument, err, "variable "+name)
+		}
+		p.vars[name] = f
+	}
+
+	v, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	if v.isList() {
+		return nil, tools.NewError(tools.CodeInvalidArgument, "expression evaluates to a list; use an aggregate such as sum or mean")
+	}
+	return v.num, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return tools.NewError(tools.CodeInvalidArgument, "at position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept consumes tok if it is next in the input.
+func (p *parser) accept(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return p.errorf("expression nested deeper than %d levels", MaxDepth)
+	}
+	return nil
+}
+
+func (p *parser) expr() (value, error) {
+	if err := p.enter(); err != nil {
+		return value{}, err
+	}
+	defer func() { p.depth-- }()
+
+	left, err := p.term()
+	if err != nil {
+		return value{}, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.accept("+"):
+			op = '+'
+		case p.accept("-"):
+			op = '-'
+		default:
+			return left, nil
+		}
+		right, err := p.term()
+		if err != nil {
+			return value{}, err
+		}
+		if left, err = p.binary(op, left, right); err != nil {
+			return value{}, err
+		}
+	}
+}
+
+func (p *parser) term() (value, error) {
+	left, err := p.unary()
+	if err != nil {
+		return value{}, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.peek("**"):
+			return left, nil
+		case p.accept("*"):
+			op = '*'
+		case p.accept("/"):
+			op = '/'
+		case p.accept("%"):
+			op = '%'
+		default:
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return value{}, err
+		}
+		if left, err = p.binary(op, left, right); err != nil {
+			return value{}, err
+		}
+	}
+}
+
+func (p *parser) peek(tok string) bool {
+	p.skipSpace()
+	return strings.HasPrefix(p.src[p.pos:], tok)
+}
+
+func (p *parser) unary() (value, error) {
+	if err := p.enter(); err != nil {
+		return value{}, err
+	}
+	defer func() { p.depth-- }()
+
+	switch {
+	case p.accept("-"):
+		v, err := p.unary()
+		if err != nil {
+			return value{}, err
+		}
+		if v.isList() {
+			return value{}, p.errorf("cannot negate a list")
+		}
+		return value{num: newFloat().Neg(v.num)}, nil
+	case p.accept("+"):
+		return p.unary()
+	}
+	return p.power()
+}
+
+func (p *parser) power() (value, error) {
+	base, err := p.primary()
+	if err != nil {
+		return value{}, err
+	}
+	if p.accept("**") || p.accept("^") {
+		// Right-associative, and binds tighter than a leading minus on
+		// the base but not on the exponent: -2^2 = -4, 2^-1 = 0.5.
+		exp, err := p.unary()
+		if err != nil {
+			return value{}, err
+		}
+		return p.binary('^', base, exp)
+	}
+	return base, nil
+}
+
+func (p *parser) primary() (value, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return value{}, p.errorf("unexpected end of expression")
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		v, err := p.expr()
+		if err != nil {
+			return value{}, err
+		}
+		if !p.accept(")") {
+			return value{}, p.errorf("expected )")
+		}
+		return v, nil
+	case c == '[':
+		p.pos++
+		args, err := p.args("]")
+		if err != nil {
+			return value{}, err
+		}
+		list := flatten(args)
+		if list == nil {
+			list = []*big.Float{}
+		}
+		return value{list: list}, nil
+	case c >= '0' && c <= '9' || c == '.':
+		return p.number()
+	case c == '_' || unicode.IsLetter(rune(c)):
+		return p.name()
+	}
+	return value{}, p.errorf("unexpected %q", c)
+}
+
+func (p *parser) number() (value, error) {
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.' || p.src[p.pos] == '_') {
+		p.pos++
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+	}
+	text := strings.ReplaceAll(p.src[start:p.pos], "_", "")
+	f, ok := newFloat().SetString(text)
+	if !ok {
+		p.pos = start
+		return value{}, p.errorf("invalid number %q", text)
+	}
+	return value{num: f}, nil
+}
+
+func (p *parser) name() (value, error) {
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+		p.pos++
+	}
+	name := p.src[start:p.pos]
+
+	if p.accept("(") {
+		args, err := p.args(")")
+		if err != nil {
+			return value{}, err
+		}
+		v, err := call(strings.ToLower(name), args)
+		if err != nil {
+			p.pos = start
+			return value{}, p.errorf("%s: %v", name, err)
+		}
+		return v, nil
+	}
+
+	v, ok := p.vars[name]
+	if !ok {
+		v, ok = p.vars[strings.ToLower(name)]
+	}
+	if !ok {
+		p.pos = start
+		return value{}, p.errorf("unknown variable %q", name)
+	}
+	return value{num: v}, nil
+}
+
+// args parses a comma-separated argument list up to and including closer.
+func (p *parser) args(closer string) ([]value, error) {
+	var args []value
+	if p.accept(closer) {
+		return args, nil
+	}
+	for {
+		v, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+		if p.accept(closer) {
+			return args, nil
+		}
+		if !p.accept(",") {
+			return nil, p.errorf("expected , or %s", closer)
+		}
+	}
+}
+
+func (p *parser) binary(op byte, a, b value) (value, error) {
+	if a.isList() || b.isList() {
+		return value{}, p.errorf("operator %c does not apply to lists", op)
+	}
+	x, y := a.num, b.num
+	z := newFloat()
+	switch op {
+	case '+':
+		z.Add(x, y)
+	case '-':
+		z.Sub(x, y)
+	case '*':
+		z.Mul(x, y)
+	case '/':
+		if y.Sign() == 0 {
+			return value{}, p.errorf("division by zero")
+		}
+		z.Quo(x, y)
+	case '%':
+		if y.Sign() == 0 {
+			return value{}, p.errorf("modulo by zero")
+		}
+		// x - y*trunc(x/y), matching math.Mod's sign convention.
+		q := newFloat().Quo(x, y)
+		qi, _ := q.Int(nil)
+		z.Sub(x, newFloat().Mul(y, newFloat().SetInt(qi)))
+	case '^':
+		r, err := pow(x, y)
+		if err != nil {
+			return value{}, p.errorf("%v", err)
+		}
+		z = r
+	}
+	if z.IsInf() {
+		return value{}, p.errorf("result overflows")
+	}
+	return value{num: z}, nil
+}
+
+// pow computes x^y exactly for integer exponents and via float64 otherwise.
+func pow(x, y *big.Float) (*big.Float, error) {
+	if y.IsInt() {
+		n, acc := y.Int64()
+		if acc != big.Exact || n > MaxIntegerExponent || n < -MaxIntegerExponent {
+			return nil, fmt.Errorf("integer exponents are limited to ±%d", MaxIntegerExponent)
+		}
+		if n < 0 && x.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		result := newFloat().SetInt64(1)
+		base := newFloat().Set(x)
+		for k := max(n, -n); k > 0; k >>= 1 {
+			if k&1 == 1 {
+				result.Mul(result, base)
+			}
+			base.Mul(base, base)
+		}
+		if n < 0 {
+			result.Quo(newFloat().SetInt64(1), result)
+		}
+		return result, nil
+	}
+	xf, _ := x.Float64()
+	yf, _ := y.Float64()
+	return fromFloat64(math.Pow(xf, yf))
+}
+
+// flatten expands list arguments into a single list of scalars.
+func flatten(args []value) []*big.Float {
+	var out []*big.Float
+	for _, a := range args {
+		if a.isList() {
+			out = append(out, a.list...)
+		} else {
+			out = append(out, a.num)
+		}
+	}
+	return out
+}
+
+// unaryFuncs are evaluated in float64; their results are not exact anyway.
+var unaryFuncs = map[string]func(float64) float64{
+	"exp":   math.Exp,
+	"ln":    math.Log,
+	"log":   math.Log,
+	"log10": math.Log10,
+	"log2":  math.Log2,
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+	"asin":  math.Asin,
+	"acos":  math.Acos,
+	"atan":  math.Atan,
+	"sinh":  math.Sinh,
+	"cosh":  math.Cosh,
+	"tanh":  math.Tanh,
+}
+
+// call evaluates a named function.
+func call(name string, args []value) (value, error) {
+	if fn, ok := unaryFuncs[name]; ok {
+		x, err := scalarArgs(args, 1)
+		if err != nil {
+			return value{}, err
+		}
+		xf, _ := x[0].Float64()
+		f, err := fromFloat64(fn(xf))
+		return value{num: f}, err
+	}
+
+	switch name {
+	case "sqrt":
+		x, err := scalarArgs(args, 1)
+		if err != nil {
+			return value{}, err
+		}
+		if x[0].Sign() < 0 {
+			return value{}, fmt.Errorf("square root of a negative number")
+		}
+		return value{num: newFloat().Sqrt(x[0])}, nil
+	case "abs":
+		x, err := scalarArgs(args, 1)
+		if err != nil {
+			return value{}, err
+		}
+		return value{num: newFloat().Abs(x[0])}, nil
+	case "pow":
+		x, err := scalarArgs(args, 2)
+		if err != nil {
+			return value{}, err
+		}
+		f, err := pow(x[0], x[1])
+		return value{num: f}, err
+	case "atan2":
+		x, err := scalarArgs(args, 2)
+		if err != nil {
+			return value{}, err
+		}
+		y, _ := x[0].Float64()
+		xf, _ := x[1].Float64()
+		f, err := fromFloat64(math.Atan2(y, xf))
+		return value{num: f}, err
+	case "floor", "ceil", "round", "trunc":
+		x, err := scalarArgs(args, 1)
+		if err != nil {
+			return value{}, err
+		}
+		return value{num: roundTo(name, x[0])}, nil
+	}
+
+	list := flatten(args)
+	if len(list) == 0 {
+		if _, ok := aggregates[name]; ok {
+			return value{}, fmt.Errorf("requires at least one value")
+		}
+		return value{}, fmt.Errorf("unknown function")
+	}
+	agg, ok := aggregates[name]
+	if !ok {
+		return value{}, fmt.Errorf("unknown function")
+	}
+	f, err := agg(list)
+	return value{num: f}, err
+}
+
+// scalarArgs checks the argument count and that no argument is a list.
+func scalarArgs(args []value, n int) ([]*big.Float, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("expects %d argument(s), got %d", n, len(args))
+	}
+	out := make([]*big.Float, n)
+	for i, a := range args {
+		if a.isList() {
+			return nil, fmt.Errorf("argument %d must be a number", i+1)
+		}
+		out[i] = a.num
+	}
+	return out, nil
+}
+
+// roundTo applies an integer rounding mode; round rounds half away from zero.
+func roundTo(mode string, x *big.Float) *big.Float {
+	t, _ := x.Int(nil) // truncates toward zero
+	tf := newFloat().SetInt(t)
+	frac := newFloat().Sub(x, tf)
+	one := newFloat().SetInt64(1)
+	switch mode {
+	case "floor":
+		if frac.Sign() < 0 {
+			tf.Sub(tf, one)
+		}
+	case "ceil":
+		if frac.Sign() > 0 {
+			tf.Add(tf, one)
+		}
+	case "round":
+		half := newFloat().SetFloat64(0.5)
+		if newFloat().Abs(frac).Cmp(half) >= 0 {
+			if x.Sign() < 0 {
+				tf.Sub(tf, one)
+			} else {
+				tf.Add(tf, one)
+			}
+		}
+	}
+	return tf
+}
+
+var aggregates = map[string]func([]*big.Float) (*big.Float, error){
+	"sum":      func(xs []*big.Float) (*big.Float, error) { return sum(xs), nil },
+	"mean":     mean,
+	"avg":      mean,
+	"median":   median,
+	"min":      func(xs []*big.Float) (*big.Float, error) { return slices.MinFunc(xs, (*big.Float).Cmp), nil },
+	"max":      func(xs []*big.Float) (*big.Float, error) { return slices.MaxFunc(xs, (*big.Float).Cmp), nil },
+	"variance": func(xs []*big.Float) (*big.Float, error) { return variance(xs), nil },
+	"stddev":   func(xs []*big.Float) (*big.Float, error) { return newFloat().Sqrt(variance(xs)), nil },
+	"count":    func(xs []*big.Float) (*big.Float, error) { return newFloat().SetInt64(int64(len(xs))), nil },
+}
+
+func sum(xs []*big.Float) *big.Float {
+	s := newFloat()
+	for _, x := range xs {
+		s.Add(s, x)
+	}
+	return s
+}
+
+func mean(xs []*big.Float) (*big.Float, error) {
+	return newFloat().Quo(sum(xs), newFloat().SetInt64(int64(len(xs)))), nil
+}
+
+func median(xs []*big.Float) (*big.Float, error) {
+	sorted := slices.Clone(xs)
+	slices.SortFunc(sorted, (*big.Float).Cmp)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return newFloat().Set(sorted[mid]), nil
+	}
+	m := newFloat().Add(sorted[mid-1], sorted[mid])
+	return m.Quo(m, newFloat().SetInt64(2)), nil
+}
+
+// variance is the population variance.
+func variance(xs []*big.Float) *big.Float {
+	m, _ := mean(xs)
+	v := newFloat()
+	for _, x := range xs {
+		d := newFloat().Sub(x, m)
+		v.Add(v, d.Mul(d, d))
+	}
+	return v.Quo(v, newFloat().SetInt64(int64(len(xs))))
+}
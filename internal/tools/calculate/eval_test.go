@@ -0,0 +1,83 @@
+package calculate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expr    string
+		vars    map[string]float64
+		want    string
+		wantErr string
+	}{
+		{expr: "1 + 2 * 3", want: "7"},
+		{expr: "(1 + 2) * 3", want: "9"},
+		{expr: "0.1 + 0.2", want: "0.3"},
+		{expr: "1 / 3", want: "0.333333333333333333333333333333"},
+		{expr: "2^10", want: "1024"},
+		{expr: "2 ** 3 ** 2", want: "512"},
+		{expr: "-2^2", want: "-4"},
+		{expr: "2^-1", want: "0.5"},
+		{expr: "2^0.5", want: "1.41421356237309514547462185874"},
+		{expr: "sqrt(2)", want: "1.41421356237309504880168872421"},
+		{expr: "7 % 3", want: "1"},
+		{expr: "-7 % 3", want: "-1"},
+		{expr: "1_000 * 1e3", want: "1000000"},
+		{expr: "pi", want: "3.14159265358979323846264338328"},
+		{expr: "sin(pi / 2)", want: "1"},
+		{expr: "atan2(1, 1) * 4", want: "3.14159265358979311599796346854"},
+		{expr: "abs(-3) + pow(2, 3)", want: "11"},
+		{expr: "round(2.5) + round(-2.5)", want: "0"},
+		{expr: "floor(-1.5) + ceil(1.2)", want: "0"},
+		{expr: "trunc(-1.7)", want: "-1"},
+		{expr: "sum([1, 2, 3], 4)", want: "10"},
+		{expr: "mean([1, 2, 3, 4])", want: "2.5"},
+		{expr: "median([3, 1, 2])", want: "2"},
+		{expr: "median([4, 1, 3, 2])", want: "2.5"},
+		{expr: "min(3, 1, 2) + max([3, 1, 2])", want: "4"},
+		{expr: "stddev(2, 4, 4, 4, 5, 5, 7, 9)", want: "2"},
+		{expr: "variance([1, 2, 3, 4])", want: "1.25"},
+		{expr: "count([1, 2, 3])", want: "3"},
+		{expr: "x * y", vars: map[string]float64{"x": 3, "y": 4}, want: "12"},
+		{expr: "1 / 0", wantErr: "division by zero"},
+		{expr: "1 % 0", wantErr: "modulo by zero"},
+		{expr: "sqrt(-1)", wantErr: "negative"},
+		{expr: "ln(0)", wantErr: "not a finite number"},
+		{expr: "2^100000", wantErr: "limited to"},
+		{expr: "1 +", wantErr: "unexpected end"},
+		{expr: "(1 + 2", wantErr: "expected )"},
+		{expr: "1 2", wantErr: "unexpected '2'"},
+		{expr: "foo", wantErr: "unknown variable"},
+		{expr: "foo(1)", wantErr: "unknown function"},
+		{expr: "sqrt(1, 2)", wantErr: "expects 1 argument"},
+		{expr: "mean()", wantErr: "at least one value"},
+		{expr: "[1, 2]", wantErr: "evaluates to a list"},
+		{expr: "[1] + 1", wantErr: "does not apply to lists"},
+		{expr: strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200), wantErr: "nested deeper"},
+		{expr: strings.Repeat("1+", MaxExpressionBytes), wantErr: "exceeds"},
+	}
+
+	for _, tt := range tests {
+		name := tt.expr
+		if len(name) > 40 {
+			name = name[:40]
+		}
+		t.Run(name, func(t *testing.T) {
+			got, err := Evaluate(tt.expr, tt.vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s := got.Text('g', 30); s != tt.want {
+				t.Errorf("Evaluate(%q) = %s, want %s", tt.expr, s, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+var logger = slog.New(NewLogHandler(slog.NewJSONHandler(os.Stderr, nil)))
+
+// Handler is a tool handler with its input and output types erased so that
+// a Middleware can wrap any tool.
+type Handler func(ctx context.Context, req *mcp.CallToolRequest, in any) (*mcp.CallToolResult, any, error)
+
+// Middleware wraps the handler of the tool called name.
+type Middleware func(name string, next Handler) Handler
+
+// Chain wraps h in mws. The first middleware is the outermost, so it sees
+// the call first and the result last.
+func Chain[In, Out any](name string, h mcp.ToolHandlerFor[In, Out], mws ...Middleware) mcp.ToolHandlerFor[In, Out] {
+	next := Handler(func(ctx context.Context, req *mcp.CallToolRequest, in any) (*mcp.CallToolResult, any, error) {
+		typed, _ := in.(In)
+		return h(ctx, req, typed)
+	})
+	for i := len(mws) - 1; i >= 0; i-- {
+		next = mws[i](name, next)
+	}
+	return func(ctx context.Context, req *mcp.CallToolRequest, in In) (*mcp.CallToolResult, Out, error) {
+		res, out, err := next(ctx, req, in)
+		typed, _ := out.(Out)
+		return res, typed, err
+	}
+}
+
+// ChainConfig selects the middleware in the default chain.
+type ChainConfig struct {
+	// Timeout bounds every tool call; zero leaves calls unbounded.
+	Timeout       time.Duration
+	RecoverPanics bool
+	// Pool bounds the calls running at once; nil leaves them unbounded.
+	Pool *Pool
+}
+
+// LoadChainConfig reads the default chain configuration from environment
+// variables.
+func LoadChainConfig() ChainConfig {
+	cfg := loadChainSettings()
+	cfg.Pool = sharedPool(LoadPoolConfig())
+	return cfg
+}
+
+// loadChainSettings is LoadChainConfig without the pool.
+func loadChainSettings() ChainConfig {
+	return ChainConfig{
+		Timeout:       config.GetEnvDuration("TOOL_TIMEOUT", 0),
+		RecoverPanics: config.GetEnvBool("TOOL_RECOVER_PANICS", true),
+	}
+}
+
+// DefaultChain returns the middleware AddTool applies to every tool:
+// NormalizeErrors, then Recover, Timeout, and the Pool when enabled. The
+// pool is inside Timeout so that waiting for a worker counts towards it.
+func DefaultChain(cfg ChainConfig) []Middleware {
+	mws := []Middleware{NormalizeErrors}
+	if cfg.RecoverPanics {
+		mws = append(mws, Recover)
+	}
+	if cfg.Timeout > 0 {
+		mws = append(mws, Timeout(cfg.Timeout))
+	}
+	if cfg.Pool != nil {
+		mws = append(mws, cfg.Pool.Middleware)
+	}
+	return mws
+}
+
+// AddTool registers a tool wrapped in the default chain followed by mws.
+// Tool packages use it in place of mcp.AddTool. The tool's annotations
+// are recorded for ToolHints. Deprecated tools, marked with Deprecate or
+// listed in TOOL_DEPRECATED, are also wrapped in Deprecated. During
+// RegisterEach the tool is named for its Namespace, and a tool whose name
+// is taken is not added.
+func AddTool[In, Out any](server *mcp.Server, tool *mcp.Tool, h mcp.ToolHandlerFor[In, Out], mws ...Middleware) {
+	if reg := registeringOn(server); reg != nil && !reg.add(tool) {
+		return
+	}
+	annotations.Store(tool.Name, tool.Annotations)
+	chain := DefaultChain(LoadChainConfig())
+	if d, ok := configuredDeprecation(tool); ok {
+		if _, marked := tool.Meta["deprecated"]; !marked {
+			Deprecate(tool, d)
+		}
+		deprecations.Store(tool.Name, d)
+		chain = append(chain, Deprecated(d))
+	} else {
+		deprecations.Delete(tool.Name)
+	}
+	chain = append(chain, mws...)
+	mcp.AddTool(server, tool, Chain(tool.Name, h, chain...))
+	ToolsChanged()
+}
+
+// panicReporter is called with each panic Recover recovers; see
+// ReportPanics.
+var panicReporter atomic.Pointer[func(tool string, p any, stack []byte)]
+
+// ReportPanics calls report with each panic recovered in a tool, its tool
+// name, and the stack, in addition to logging it. The last call wins.
+func ReportPanics(report func(tool string, p any, stack []byte)) {
+	panicReporter.Store(&report)
+}
+
+// Recover is a Middleware that turns a panic in the tool into an internal
+// error and logs the stack.
+func Recover(name string, next Handler) Handler {
+	return func(ctx context.Context, req *mcp.CallToolRequest, in any) (res *mcp.CallToolResult, out any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				stack := debug.Stack()
+				// Logged without ctx so the stack stays out of client logs
+				logger.Error("tool panic", "tool", name, "panic", r, "stack", string(stack))
+				if report := panicReporter.Load(); report != nil {
+					(*report)(name, r, stack)
+				}
+				res, out, err = nil, nil, NewError(CodeInternal, "tool %s failed unexpectedly", name)
+			}
+		}()
+		return next(ctx, req, in)
+	}
+}
+
+// Timeout returns a Middleware that cancels the call's context after d.
+func Timeout(d time.Duration) Middleware {
+	return func(_ string, next Handler) Handler {
+		return func(ctx context.Context, req *mcp.CallToolRequest, in any) (*mcp.CallToolResult, any, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, req, in)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type chainInput struct {
+	Value string
+}
+
+type chainOutput struct {
+	Value string
+}
+
+func echo(_ context.Context, _ *mcp.CallToolRequest, in chainInput) (*mcp.CallToolResult, chainOutput, error) {
+	return nil, chainOutput{Value: in.Value}, nil
+}
+
+// record returns a Middleware that appends its label to calls.
+func record(label string, calls *[]string) Middleware {
+	return func(name string, next Handler) Handler {
+		return func(ctx context.Context, req *mcp.CallToolRequest, in any) (*mcp.CallToolResult, any, error) {
+			*calls = append(*calls, label+":"+name)
+			return next(ctx, req, in)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	h := Chain("echo", echo, record("outer", &calls), record("inner", &calls))
+
+	_, out, err := h(context.Background(), &mcp.CallToolRequest{}, chainInput{Value: "hi"})
+	if err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	if out.Value != "hi" {
+		t.Errorf("out.Value = %q, want %q", out.Value, "hi")
+	}
+	if want := []string{"outer:echo", "inner:echo"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	h := Chain("boom", func(context.Context, *mcp.CallToolRequest, chainInput) (*mcp.CallToolResult, chainOutput, error) {
+		panic("boom")
+	}, Recover)
+
+	_, _, err := h(context.Background(), &mcp.CallToolRequest{}, chainInput{})
+	var e *Error
+	if !errors.As(err, &e) || e.Code != CodeInternal {
+		t.Fatalf("error = %v, want internal tool error", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	h := Chain("slow", func(ctx context.Context, _ *mcp.CallToolRequest, _ chainInput) (*mcp.CallToolResult, chainOutput, error) {
+		<-ctx.Done()
+		return nil, chainOutput{}, ctx.Err()
+	}, NormalizeErrors, Timeout(10*time.Millisecond))
+
+	_, _, err := h(context.Background(), &mcp.CallToolRequest{}, chainInput{})
+	if err == nil || !strings.HasPrefix(err.Error(), "deadline_exceeded:") {
+		t.Fatalf("error = %v, want deadline_exceeded", err)
+	}
+}
+
+func TestDefaultChain(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ChainConfig
+		want int
+	}{
+		{name: "errors only", cfg: ChainConfig{}, want: 1},
+		{name: "with recover", cfg: ChainConfig{RecoverPanics: true}, want: 2},
+		{name: "with recover and timeout", cfg: ChainConfig{RecoverPanics: true, Timeout: time.Second}, want: 3},
+		{name: "with pool", cfg: ChainConfig{RecoverPanics: true, Timeout: time.Second, Pool: NewPool(PoolConfig{Workers: 1})}, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(DefaultChain(tt.cfg)); got != tt.want {
+				t.Errorf("len(DefaultChain()) = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkDispatch measures a tools/call through the SDK and the default
+// chain, against the chain alone.
+func BenchmarkDispatch(b *testing.B) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	AddTool(server, &mcp.Tool{Name: "echo"}, echo)
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		b.Fatal(err)
+	}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer session.Close()
+
+	b.Run("session", func(b *testing.B) {
+		params := &mcp.CallToolParams{Name: "echo", Arguments: map[string]any{"Value": "x"}}
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := session.CallTool(ctx, params); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("chain", func(b *testing.B) {
+		h := Chain("echo", echo, DefaultChain(ChainConfig{Timeout: time.Minute, RecoverPanics: true})...)
+		req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "echo"}}
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, _, err := h(ctx, req, chainInput{Value: "x"}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,355 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// waitDelay bounds how long Wait blocks on output pipes held open by
+// grandchildren after the command itself has exited or been killed.
+const waitDelay = 2 * time.Second
+
+// Config controls whether the exec tool is available and what it may run.
+type Config struct {
+	// Enabled registers the exec tool. It is off by default.
+	Enabled bool
+	// AllowedCommands lists the command names or absolute paths that may be
+	// run. Names are resolved against PATH once at startup.
+	AllowedCommands []string
+	// WorkDirs lists directories commands may run in. The first entry is
+	// the default; when empty the server's working directory is used and
+	// callers may not choose another.
+	WorkDirs []string
+	// EnvPassthrough lists environment variables inherited from the server.
+	// All other variables are withheld from the command.
+	EnvPassthrough []string
+	Timeout        time.Duration
+	MaxOutputBytes int
+	MaxArgs        int
+	MaxArgBytes    int
+	MaxStdinBytes  int
+}
+
+// LoadConfig reads the exec configuration from environment variables.
+func LoadConfig() Config {
+	env := config.GetEnvList("EXEC_ENV_PASSTHROUGH")
+	if len(env) == 0 {
+		env = []string{"PATH"}
+	}
+	return Config{
+		Enabled:         config.GetEnvBool("EXEC_ENABLED", false),
+		AllowedCommands: config.GetEnvList("EXEC_ALLOWED_COMMANDS"),
+		WorkDirs:        config.GetEnvList("EXEC_WORKDIRS"),
+		EnvPassthrough:  env,
+		Timeout:         config.GetEnvDuration("EXEC_TIMEOUT", 30*time.Second),
+		MaxOutputBytes:  config.GetEnvInt("EXEC_MAX_OUTPUT_BYTES", 64<<10),
+		MaxArgs:         config.GetEnvInt("EXEC_MAX_ARGS", 64),
+		MaxArgBytes:     config.GetEnvInt("EXEC_MAX_ARG_BYTES", 4096),
+		MaxStdinBytes:   config.GetEnvInt("EXEC_MAX_STDIN_BYTES", 64<<10),
+	}
+}
+
+// Input is the input for the exec tool.
+type Input struct {
+	Command        string   `json:"command" jsonschema:"the command to run; must be allow-listed by the operator"`
+	Args           []string `json:"args,omitempty" jsonschema:"arguments passed directly to the command without a shell"`
+	Cwd            string   `json:"cwd,omitempty" jsonschema:"working directory; must be within an allowed directory"`
+	Stdin          string   `json:"stdin,omitempty" jsonschema:"data written to the command's standard input"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty" jsonschema:"timeout in seconds; capped by the server limit"`
+}
+
+// Output is the output of the exec tool.
+type Output struct {
+	ExitCode        int    `json:"exit_code" jsonschema:"the process exit code, or -1 if it was killed"`
+	Stdout          string `json:"stdout" jsonschema:"standard output, possibly truncated"`
+	Stderr          string `json:"stderr" jsonschema:"standard error, possibly truncated"`
+	StdoutTruncated bool   `json:"stdout_truncated" jsonschema:"whether stdout was cut off at the size limit"`
+	StderrTruncated bool   `json:"stderr_truncated" jsonschema:"whether stderr was cut off at the size limit"`
+	TimedOut        bool   `json:"timed_out" jsonschema:"whether the command was killed for exceeding the timeout"`
+	DurationMs      int64  `json:"duration_ms" jsonschema:"wall-clock run time in milliseconds"`
+}
+
+// Executor runs allow-listed commands constrained by a Config.
+type Executor struct {
+	cfg      Config
+	commands map[string]string
+	workDirs []string
+}
+
+// NewExecutor resolves the allow-listed commands and working directories.
+// Commands that cannot be found are reported as an error so that
+// misconfiguration is caught at startup rather than on first use.
+func NewExecutor(cfg Config) (*Executor, error) {
+	e := &Executor{cfg: cfg, commands: make(map[string]string)}
+
+	for _, name := range cfg.AllowedCommands {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return nil, fmt.Errorf("allowed command %q: %w", name, err)
+		}
+		if path, err = filepath.Abs(path); err != nil {
+			return nil, fmt.Errorf("allowed command %q: %w", name, err)
+		}
+		e.commands[name] = path
+	}
+
+	workDirs := cfg.WorkDirs
+	if len(workDirs) == 0 {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		workDirs = []string{wd}
+	}
+	for _, dir := range workDirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("work dir %q: %w", dir, err)
+		}
+		if abs, err = filepath.EvalSymlinks(abs); err != nil {
+			return nil, fmt.Errorf("work dir %q: %w", dir, err)
+		}
+		e.workDirs = append(e.workDirs, abs)
+	}
+	return e, nil
+}
+
+// Exec runs a command and returns its exit status and captured output.
+func (e *Executor) Exec(ctx context.Context, req *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	path, ok := e.commands[input.Command]
+	if !ok {
+		e.audit(req, input, "", "denied", "command not allowed")
+		return nil, Output{}, tools.NewError(tools.CodePermissionDenied, "command %q is not allowed", input.Command)
+	}
+	if err := e.validateArgs(input.Args); err != nil {
+		e.audit(req, input, "", "denied", err.Error())
+		return nil, Output{}, err
+	}
+	if len(input.Stdin) > e.cfg.MaxStdinBytes {
+		e.audit(req, input, "", "denied", "stdin too large")
+		return nil, Output{}, tools.NewError(tools.CodeResourceExhausted, "stdin exceeds %d bytes", e.cfg.MaxStdinBytes).
+			WithDetail("max_bytes", e.cfg.MaxStdinBytes)
+	}
+	cwd, err := e.resolveCwd(input.Cwd)
+	if err != nil {
+		e.audit(req, input, "", "denied", err.Error())
+		return nil, Output{}, err
+	}
+
+	timeout := e.cfg.Timeout
+	if input.TimeoutSeconds > 0 {
+		if t := time.Duration(input.TimeoutSeconds) * time.Second; t < timeout {
+			timeout = t
+		}
+	}
+	// Clients that asked for progress see the output as it is produced;
+	// the stream outlives the timeout so the last of it is still sent
+	stream := tools.NewStream(ctx, req, 0)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The stream only gets what the buffers keep, so streaming clients see
+	// no more than MaxOutputBytes of either
+	stdout := &limitedBuffer{limit: e.cfg.MaxOutputBytes}
+	stderr := &limitedBuffer{limit: e.cfg.MaxOutputBytes}
+	if stream.Streaming() {
+		stdout.forward, stderr.forward = stream, stream
+	}
+
+	cmd := exec.CommandContext(ctx, path, input.Args...)
+	cmd.Dir = cwd
+	cmd.Env = e.environ()
+	cmd.Stdin = strings.NewReader(input.Stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = waitDelay
+
+	start := time.Now()
+	runErr := cmd.Run()
+	stream.Flush()
+	out := Output{
+		ExitCode:        cmd.ProcessState.ExitCode(),
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		StdoutTruncated: stdout.truncated,
+		StderrTruncated: stderr.truncated,
+		TimedOut:        errors.Is(ctx.Err(), context.DeadlineExceeded),
+		DurationMs:      time.Since(start).Milliseconds(),
+	}
+
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) && !out.TimedOut {
+		e.audit(req, input, cwd, "error", runErr.Error())
+		return nil, Output{}, tools.WrapError(tools.CodeInternal, runErr, "running "+input.Command)
+	}
+
+	e.audit(req, input, cwd, "completed", "",
+		"exit_code", out.ExitCode,
+		"timed_out", out.TimedOut,
+		"duration_ms", out.DurationMs,
+		"stdout_bytes", stdout.total,
+		"stderr_bytes", stderr.total,
+	)
+	return nil, out, nil
+}
+
+// validateArgs enforces argument count and size limits and rejects control
+// characters. Arguments are never interpreted by a shell, so metacharacters
+// are passed through literally.
+func (e *Executor) validateArgs(args []string) error {
+	if len(args) > e.cfg.MaxArgs {
+		return tools.NewError(tools.CodeResourceExhausted, "too many arguments: %d exceeds %d", len(args), e.cfg.MaxArgs).
+			WithDetail("max_args", e.cfg.MaxArgs)
+	}
+	for i, arg := range args {
+		if len(arg) > e.cfg.MaxArgBytes {
+			return tools.NewError(tools.CodeResourceExhausted, "argument %d exceeds %d bytes", i, e.cfg.MaxArgBytes).
+				WithDetail("max_bytes", e.cfg.MaxArgBytes)
+		}
+		if !utf8.ValidString(arg) {
+			return tools.NewError(tools.CodeInvalidArgument, "argument %d is not valid UTF-8", i)
+		}
+		for _, r := range arg {
+			if r < 0x20 && r != '\t' || r == 0x7f {
+				return tools.NewError(tools.CodeInvalidArgument, "argument %d contains control characters", i)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveCwd returns the directory to run in, confined to the allowed
+// working directories. Symlinks are resolved before the containment check.
+func (e *Executor) resolveCwd(cwd string) (string, error) {
+	if cwd == "" {
+		return e.workDirs[0], nil
+	}
+	if len(e.cfg.WorkDirs) == 0 {
+		return "", tools.NewError(tools.CodePermissionDenied, "cwd may not be set: no working directories are configured")
+	}
+
+	abs := cwd
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(e.workDirs[0], abs)
+	}
+	abs, err := filepath.EvalSymlinks(filepath.Clean(abs))
+	if err != nil {
+		return "", tools.NewError(tools.CodeNotFound, "cwd %s does not exist", cwd)
+	}
+	for _, dir := range e.workDirs {
+		rel, err := filepath.Rel(dir, abs)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return abs, nil
+		}
+	}
+	return "", tools.NewError(tools.CodePermissionDenied, "cwd %s is outside the allowed working directories", cwd)
+}
+
+// environ returns the passthrough subset of the server's environment.
+func (e *Executor) environ() []string {
+	env := make([]string, 0, len(e.cfg.EnvPassthrough))
+	for _, key := range e.cfg.EnvPassthrough {
+		if v, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		}
+	}
+	return env
+}
+
+// audit records every exec attempt, including denied ones.
+func (e *Executor) audit(req *mcp.CallToolRequest, input Input, cwd, outcome, reason string, attrs ...any) {
+	args := []any{
+		"tool", "exec",
+		"command", input.Command,
+		"args", input.Args,
+		"cwd", cwd,
+		"outcome", outcome,
+	}
+	if req != nil && req.Session != nil {
+		args = append(args, "session_id", req.Session.ID())
+	}
+	if reason != "" {
+		args = append(args, "reason", reason)
+	}
+	args = append(args, attrs...)
+	if outcome == "completed" {
+		logger.Info("tool called", args...)
+		return
+	}
+	logger.Warn("tool called", args...)
+}
+
+// limitedBuffer keeps the first limit bytes written and counts the rest.
+// If forward is set, the bytes kept are also written to it.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	total     int
+	truncated bool
+	forward   io.Writer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.total += len(p)
+	if room := b.limit - b.buf.Len(); room > 0 {
+		kept := p
+		if len(p) > room {
+			kept = p[:room]
+			b.truncated = true
+		}
+		b.buf.Write(kept)
+		if b.forward != nil {
+			_, _ = b.forward.Write(kept)
+		}
+	} else if len(p) > 0 {
+		b.truncated = true
+	}
+	// Report the full length so the command is not killed by a short write.
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return strings.ToValidUTF8(b.buf.String(), "�")
+}
+
+func init() {
+	tools.RegisterConfig(func() { LoadConfig() })
+	tools.Register(tools.Namespace("exec", func(server *mcp.Server) {
+		cfg := LoadConfig()
+		if !cfg.Enabled {
+			return
+		}
+		if len(cfg.AllowedCommands) == 0 {
+			logger.Warn("exec tool enabled without EXEC_ALLOWED_COMMANDS; not registering")
+			return
+		}
+		e, err := NewExecutor(cfg)
+		if err != nil {
+			logger.Error("exec tool disabled", "error", err)
+			return
+		}
+
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "exec",
+			Description: "Run an operator allow-listed command with arguments (no shell) and return its exit code and output",
+			Annotations: tools.Destructive(false, true),
+		}, e.Exec)
+	}))
+}
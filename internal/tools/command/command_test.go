@@ -0,0 +1,248 @@
+package command
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func newTestExecutor(t *testing.T, dirs ...string) *Executor {
+	t.Helper()
+	for _, name := range []string{"echo", "cat", "sleep", "pwd"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s not available: %v", name, err)
+		}
+	}
+	e, err := NewExecutor(Config{
+		AllowedCommands: []string{"echo", "cat", "sleep", "pwd"},
+		WorkDirs:        dirs,
+		EnvPassthrough:  []string{"PATH"},
+		Timeout:         5 * time.Second,
+		MaxOutputBytes:  8,
+		MaxArgs:         3,
+		MaxArgBytes:     16,
+		MaxStdinBytes:   32,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestExec(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	e := newTestExecutor(t, dir)
+
+	tests := []struct {
+		name          string
+		input         Input
+		wantStdout    string
+		wantTruncated bool
+		wantErr       string
+		wantCode      tools.Code
+	}{
+		{
+			name:       "simple",
+			input:      Input{Command: "echo", Args: []string{"hi"}},
+			wantStdout: "hi\n",
+		},
+		{
+			name:       "no shell interpretation",
+			input:      Input{Command: "echo", Args: []string{"$HOME;"}},
+			wantStdout: "$HOME;\n",
+		},
+		{
+			name:       "stdin",
+			input:      Input{Command: "cat", Stdin: "abc"},
+			wantStdout: "abc",
+		},
+		{
+			name:          "output truncated",
+			input:         Input{Command: "echo", Args: []string{"0123456789"}},
+			wantStdout:    "01234567",
+			wantTruncated: true,
+		},
+		{
+			name:     "command not allowed",
+			input:    Input{Command: "rm", Args: []string{"-rf", "/"}},
+			wantErr:  "not allowed",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name:     "too many args",
+			input:    Input{Command: "echo", Args: []string{"a", "b", "c", "d"}},
+			wantErr:  "too many arguments",
+			wantCode: tools.CodeResourceExhausted,
+		},
+		{
+			name:     "arg too long",
+			input:    Input{Command: "echo", Args: []string{strings.Repeat("a", 17)}},
+			wantErr:  "exceeds 16 bytes",
+			wantCode: tools.CodeResourceExhausted,
+		},
+		{
+			name:     "control characters",
+			input:    Input{Command: "echo", Args: []string{"a\x00b"}},
+			wantErr:  "control characters",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name:     "stdin too large",
+			input:    Input{Command: "cat", Stdin: strings.Repeat("a", 33)},
+			wantErr:  "stdin exceeds",
+			wantCode: tools.CodeResourceExhausted,
+		},
+		{
+			name:     "cwd outside",
+			input:    Input{Command: "pwd", Cwd: "/"},
+			wantErr:  "outside the allowed working directories",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name:     "cwd traversal",
+			input:    Input{Command: "pwd", Cwd: "../"},
+			wantErr:  "outside the allowed working directories",
+			wantCode: tools.CodePermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := e.Exec(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", out.Stdout, tt.wantStdout)
+			}
+			if out.StdoutTruncated != tt.wantTruncated {
+				t.Errorf("StdoutTruncated = %v, want %v", out.StdoutTruncated, tt.wantTruncated)
+			}
+			if out.ExitCode != 0 {
+				t.Errorf("ExitCode = %d, want 0", out.ExitCode)
+			}
+		})
+	}
+}
+
+func TestResolveCwd(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	e := newTestExecutor(t, dir)
+	root := e.workDirs[0]
+
+	tests := []struct {
+		cwd     string
+		want    string
+		wantErr string
+	}{
+		{cwd: "", want: root},
+		{cwd: "sub", want: filepath.Join(root, "sub")},
+		{cwd: filepath.Join(root, "sub"), want: filepath.Join(root, "sub")},
+		{cwd: "sub/..", want: root},
+		{cwd: "missing", wantErr: "does not exist"},
+		{cwd: "..", wantErr: "outside"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cwd, func(t *testing.T) {
+			got, err := e.resolveCwd(tt.cwd)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveCwd(%q) = %q, want %q", tt.cwd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExec_CwdWithoutWorkDirs(t *testing.T) {
+	e := newTestExecutor(t)
+
+	_, _, err := e.Exec(context.Background(), &mcp.CallToolRequest{}, Input{Command: "pwd", Cwd: "/tmp"})
+	if err == nil || !strings.Contains(err.Error(), "may not be set") {
+		t.Fatalf("error = %v, want cwd rejection", err)
+	}
+}
+
+func TestExec_Timeout(t *testing.T) {
+	e := newTestExecutor(t)
+
+	_, out, err := e.Exec(context.Background(), &mcp.CallToolRequest{}, Input{Command: "sleep", Args: []string{"5"}, TimeoutSeconds: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !out.TimedOut {
+		t.Error("TimedOut = false, want true")
+	}
+	if out.ExitCode != -1 {
+		t.Errorf("ExitCode = %d, want -1", out.ExitCode)
+	}
+}
+
+func TestExec_NonZeroExit(t *testing.T) {
+	e := newTestExecutor(t)
+
+	_, out, err := e.Exec(context.Background(), &mcp.CallToolRequest{}, Input{Command: "cat", Args: []string{"/nope"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.ExitCode == 0 {
+		t.Error("ExitCode = 0, want non-zero")
+	}
+	if out.Stderr == "" {
+		t.Error("Stderr is empty, want error output")
+	}
+}
+
+func TestNewExecutor_UnknownCommand(t *testing.T) {
+	_, err := NewExecutor(Config{AllowedCommands: []string{"definitely-not-a-real-command"}})
+	if err == nil {
+		t.Fatal("expected error for unknown command")
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	var forwarded strings.Builder
+	b := &limitedBuffer{limit: 4, forward: &forwarded}
+	for _, s := range []string{"ab", "cd", "ef"} {
+		if n, err := b.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if b.String() != "abcd" || !b.truncated || b.total != 6 {
+		t.Errorf("got %q truncated=%v total=%d", b.String(), b.truncated, b.total)
+	}
+	if forwarded.String() != "abcd" {
+		t.Errorf("forwarded %q, want only the bytes kept", forwarded.String())
+	}
+}
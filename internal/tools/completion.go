@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MaxCompletions is the most values a completion/complete result holds,
+// as the MCP specification allows.
+const MaxCompletions = 100
+
+// Completer suggests values for an argument from the value typed so far.
+// args holds the arguments the client has already resolved, so one
+// argument's suggestions can depend on another's value. The result may be
+// longer than MaxCompletions; Complete cuts it.
+type Completer func(ctx context.Context, value string, args map[string]string) ([]string, error)
+
+// completionKey identifies an argument of a prompt or resource template.
+type completionKey struct {
+	ref string // "ref/prompt" or "ref/resource"
+	// name is the prompt name or the resource URI template.
+	name string
+	arg  string
+}
+
+// completers holds the registered Completers.
+var completers sync.Map // completionKey -> Completer
+
+// CompletePrompt registers c to complete the argument arg of the prompt
+// called prompt. Register it alongside the prompt.
+func CompletePrompt(prompt, arg string, c Completer) {
+	completers.Store(completionKey{"ref/prompt", prompt, arg}, c)
+}
+
+// CompleteResource registers c to complete the variable arg of the
+// resource template uriTemplate. Register it alongside the template.
+func CompleteResource(uriTemplate, arg string, c Completer) {
+	completers.Store(completionKey{"ref/resource", uriTemplate, arg}, c)
+}
+
+// Complete answers completion/complete with the registered Completer of
+// the argument; arguments without one get no suggestions. The server sets
+// it as the mcp.ServerOptions CompletionHandler.
+func Complete(ctx context.Context, req *mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	res := &mcp.CompleteResult{Completion: mcp.CompletionResultDetails{Values: []string{}}}
+	p := req.Params
+	if p == nil || p.Ref == nil {
+		return res, nil
+	}
+	name := p.Ref.Name
+	if p.Ref.Type == "ref/resource" {
+		name = p.Ref.URI
+	}
+	c, ok := completers.Load(completionKey{p.Ref.Type, name, p.Argument.Name})
+	if !ok {
+		return res, nil
+	}
+	var args map[string]string
+	if p.Context != nil {
+		args = p.Context.Arguments
+	}
+	values, err := c.(Completer)(ctx, p.Argument.Value, args)
+	if err != nil {
+		return nil, err
+	}
+	res.Completion.Total = len(values)
+	if len(values) > MaxCompletions {
+		values, res.Completion.HasMore = values[:MaxCompletions], true
+	}
+	if values != nil {
+		res.Completion.Values = values
+	}
+	return res, nil
+}
+
+// Values returns a Completer suggesting the given values that start with
+// the value typed, ignoring case, in order.
+func Values(values ...string) Completer {
+	return func(_ context.Context, value string, _ map[string]string) ([]string, error) {
+		return MatchPrefix(values, value), nil
+	}
+}
+
+// MatchPrefix returns the candidates that start with prefix, ignoring
+// case. Candidates that match case too come first; the order is otherwise
+// kept.
+func MatchPrefix(candidates []string, prefix string) []string {
+	lower := strings.ToLower(prefix)
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c), lower) {
+			matches = append(matches, c)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return strings.HasPrefix(matches[i], prefix) && !strings.HasPrefix(matches[j], prefix)
+	})
+	return matches
+}
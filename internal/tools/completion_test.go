@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestComplete(t *testing.T) {
+	many := make([]string, MaxCompletions+5)
+	for i := range many {
+		many[i] = fmt.Sprintf("v%03d", i)
+	}
+	CompletePrompt("test_greet", "language", Values("English", "Esperanto", "French", "english-uk"))
+	CompletePrompt("test_greet", "many", Values(many...))
+	CompletePrompt("test_greet", "broken", func(context.Context, string, map[string]string) ([]string, error) {
+		return nil, errors.New("backend down")
+	})
+	CompleteResource("test://items/{kind}/{id}", "id", func(_ context.Context, value string, args map[string]string) ([]string, error) {
+		return []string{args["kind"] + "-" + value + "1"}, nil
+	})
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &mcp.ServerOptions{CompletionHandler: Complete})
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+	if session.InitializeResult().Capabilities.Completions == nil {
+		t.Error("completions capability not advertised")
+	}
+
+	prompt := &mcp.CompleteReference{Type: "ref/prompt", Name: "test_greet"}
+	tests := []struct {
+		name        string
+		params      *mcp.CompleteParams
+		want        []string
+		wantTotal   int
+		wantHasMore bool
+		wantErr     bool
+	}{
+		{
+			name:      "prefix ignoring case, exact case first",
+			params:    &mcp.CompleteParams{Ref: prompt, Argument: mcp.CompleteParamsArgument{Name: "language", Value: "e"}},
+			want:      []string{"english-uk", "English", "Esperanto"},
+			wantTotal: 3,
+		},
+		{
+			name:        "capped",
+			params:      &mcp.CompleteParams{Ref: prompt, Argument: mcp.CompleteParamsArgument{Name: "many", Value: "v"}},
+			want:        many[:MaxCompletions],
+			wantTotal:   len(many),
+			wantHasMore: true,
+		},
+		{
+			name: "resource with context",
+			params: &mcp.CompleteParams{
+				Ref:      &mcp.CompleteReference{Type: "ref/resource", URI: "test://items/{kind}/{id}"},
+				Argument: mcp.CompleteParamsArgument{Name: "id", Value: "4"},
+				Context:  &mcp.CompleteContext{Arguments: map[string]string{"kind": "book"}},
+			},
+			want:      []string{"book-41"},
+			wantTotal: 1,
+		},
+		{
+			name:   "unknown argument",
+			params: &mcp.CompleteParams{Ref: prompt, Argument: mcp.CompleteParamsArgument{Name: "other"}},
+			want:   []string{},
+		},
+		{
+			name:   "unknown prompt",
+			params: &mcp.CompleteParams{Ref: &mcp.CompleteReference{Type: "ref/prompt", Name: "missing"}, Argument: mcp.CompleteParamsArgument{Name: "language"}},
+			want:   []string{},
+		},
+		{
+			name:    "completer error",
+			params:  &mcp.CompleteParams{Ref: prompt, Argument: mcp.CompleteParamsArgument{Name: "broken"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := session.Complete(ctx, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			c := res.Completion
+			if !reflect.DeepEqual(c.Values, tt.want) || c.Total != tt.wantTotal || c.HasMore != tt.wantHasMore {
+				t.Errorf("completion = %v (total %d, hasMore %v), want %v (total %d, hasMore %v)",
+					c.Values, c.Total, c.HasMore, tt.want, tt.wantTotal, tt.wantHasMore)
+			}
+		})
+	}
+}
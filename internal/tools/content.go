@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ResultBuilder builds a CallToolResult from several kinds of content, for
+// tools whose result is more than their marshaled output: text alongside
+// structured JSON, images, audio, embedded files, or links to resources.
+// Create one with NewResult and finish it with Build.
+//
+// For tools with a typed output, the SDK sets StructuredContent from the
+// output the handler returns, but leaves Content alone once it is set;
+// add the output with JSON so clients without structured content support
+// still see it.
+type ResultBuilder struct {
+	res mcp.CallToolResult
+	err error
+}
+
+// NewResult returns an empty ResultBuilder.
+func NewResult() *ResultBuilder {
+	return &ResultBuilder{}
+}
+
+// Text adds a text block.
+func (b *ResultBuilder) Text(text string) *ResultBuilder {
+	b.res.Content = append(b.res.Content, &mcp.TextContent{Text: text})
+	return b
+}
+
+// Textf adds a text block formatted as by fmt.Sprintf.
+func (b *ResultBuilder) Textf(format string, args ...any) *ResultBuilder {
+	return b.Text(fmt.Sprintf(format, args...))
+}
+
+// JSON adds a text block holding v encoded as JSON.
+func (b *ResultBuilder) JSON(v any) *ResultBuilder {
+	data, err := json.Marshal(v)
+	if err != nil {
+		b.fail(fmt.Errorf("encoding content: %w", err))
+		return b
+	}
+	return b.Text(string(data))
+}
+
+// Structured sets the result's structured content to v, which must encode
+// as a JSON object. Tools with a typed output need not call it.
+func (b *ResultBuilder) Structured(v any) *ResultBuilder {
+	data, err := json.Marshal(v)
+	if err != nil {
+		b.fail(fmt.Errorf("encoding structured content: %w", err))
+		return b
+	}
+	b.res.StructuredContent = json.RawMessage(data)
+	return b
+}
+
+// Image adds an image block. data is the raw image; it is base64-encoded
+// on the wire.
+func (b *ResultBuilder) Image(data []byte, mimeType string) *ResultBuilder {
+	b.res.Content = append(b.res.Content, &mcp.ImageContent{Data: data, MIMEType: mimeType})
+	return b
+}
+
+// Audio adds an audio block. data is the raw audio; it is base64-encoded
+// on the wire.
+func (b *ResultBuilder) Audio(data []byte, mimeType string) *ResultBuilder {
+	b.res.Content = append(b.res.Content, &mcp.AudioContent{Data: data, MIMEType: mimeType})
+	return b
+}
+
+// Resource embeds the contents of the resource uri. Text types that are
+// valid UTF-8 are embedded as text, anything else as a base64 blob.
+func (b *ResultBuilder) Resource(uri, mimeType string, data []byte) *ResultBuilder {
+	rc := &mcp.ResourceContents{URI: uri, MIMEType: mimeType}
+	if isText(mimeType) && utf8.Valid(data) {
+		rc.Text = string(data)
+	} else {
+		rc.Blob = data
+	}
+	b.res.Content = append(b.res.Content, &mcp.EmbeddedResource{Resource: rc})
+	return b
+}
+
+// Link adds a link to the resource uri, which the client may read later.
+// A negative size means the size is unknown.
+func (b *ResultBuilder) Link(uri, name, mimeType string, size int64) *ResultBuilder {
+	link := &mcp.ResourceLink{URI: uri, Name: name, MIMEType: mimeType}
+	if size >= 0 {
+		link.Size = &size
+	}
+	b.res.Content = append(b.res.Content, link)
+	return b
+}
+
+// Meta sets key in the result's _meta.
+func (b *ResultBuilder) Meta(key string, value any) *ResultBuilder {
+	if b.res.Meta == nil {
+		b.res.Meta = mcp.Meta{}
+	}
+	b.res.Meta[key] = value
+	return b
+}
+
+// Build returns the result, or the first error met while adding content.
+func (b *ResultBuilder) Build() (*mcp.CallToolResult, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	res := b.res
+	return &res, nil
+}
+
+func (b *ResultBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// isText reports whether mimeType names a textual format.
+func isText(mimeType string) bool {
+	mt, _, _ := strings.Cut(mimeType, ";")
+	mt = strings.TrimSpace(strings.ToLower(mt))
+	switch {
+	case strings.HasPrefix(mt, "text/"):
+		return true
+	case mt == "application/json", mt == "application/xml", mt == "application/yaml", mt == "application/toml":
+		return true
+	}
+	return strings.HasSuffix(mt, "+json") || strings.HasSuffix(mt, "+xml")
+}
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestResultBuilder(t *testing.T) {
+	res, err := NewResult().
+		Textf("%d files", 2).
+		JSON(map[string]int{"count": 2}).
+		Structured(map[string]int{"count": 2}).
+		Image([]byte{1, 2}, "image/png").
+		Audio([]byte{3}, "audio/wav").
+		Resource("file:///a.txt", "text/plain; charset=utf-8", []byte("hi")).
+		Resource("file:///b.bin", "application/octet-stream", []byte{0xff}).
+		Link("file:///c.txt", "c.txt", "text/plain", 10).
+		Link("file:///d", "d", "", -1).
+		Meta("page", 1).
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	encoded, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var got struct {
+		Content []struct {
+			Type     string `json:"type"`
+			Text     string `json:"text"`
+			Data     string `json:"data"`
+			MIMEType string `json:"mimeType"`
+			URI      string `json:"uri"`
+			Size     *int64 `json:"size"`
+			Resource *struct {
+				Text string `json:"text"`
+				Blob string `json:"blob"`
+			} `json:"resource"`
+		} `json:"content"`
+		StructuredContent map[string]int `json:"structuredContent"`
+		Meta              map[string]any `json:"_meta"`
+	}
+	if err := json.Unmarshal(encoded, &got); err != nil {
+		t.Fatalf("unmarshal %s: %v", encoded, err)
+	}
+
+	wantTypes := []string{"text", "text", "image", "audio", "resource", "resource", "resource_link", "resource_link"}
+	if len(got.Content) != len(wantTypes) {
+		t.Fatalf("got %d blocks, want %d: %s", len(got.Content), len(wantTypes), encoded)
+	}
+	for i, want := range wantTypes {
+		if got.Content[i].Type != want {
+			t.Errorf("content[%d].type = %q, want %q", i, got.Content[i].Type, want)
+		}
+	}
+	c := got.Content
+	if c[0].Text != "2 files" || c[1].Text != `{"count":2}` {
+		t.Errorf("text blocks = %q, %q", c[0].Text, c[1].Text)
+	}
+	if c[2].Data != "AQI=" || c[2].MIMEType != "image/png" || c[3].Data != "Aw==" {
+		t.Errorf("binary blocks = %+v, %+v; want base64 data", c[2], c[3])
+	}
+	if c[4].Resource == nil || c[4].Resource.Text != "hi" || c[5].Resource == nil || c[5].Resource.Blob != "/w==" {
+		t.Errorf("embedded resources = %+v, %+v; want text then blob", c[4].Resource, c[5].Resource)
+	}
+	if c[6].URI != "file:///c.txt" || c[6].Size == nil || *c[6].Size != 10 || c[7].Size != nil {
+		t.Errorf("links = %+v, %+v", c[6], c[7])
+	}
+	if got.StructuredContent["count"] != 2 || got.Meta["page"] != float64(1) {
+		t.Errorf("structuredContent = %v, _meta = %v", got.StructuredContent, got.Meta)
+	}
+}
+
+func TestResultBuilder_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *ResultBuilder
+	}{
+		{name: "JSON", b: NewResult().Text("ok").JSON(math.Inf(1))},
+		{name: "Structured", b: NewResult().Structured(make(chan int)).Text("ok")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.b.Build()
+			if err == nil || res != nil {
+				t.Fatalf("Build() = %v, %v; want an error", res, err)
+			}
+		})
+	}
+}
+
+func TestIsText(t *testing.T) {
+	tests := map[string]bool{
+		"text/plain":               true,
+		"Text/HTML; charset=utf-8": true,
+		"application/json":         true,
+		"application/ld+json":      true,
+		"image/svg+xml":            true,
+		"application/octet-stream": false,
+		"image/png":                false,
+		"":                         false,
+	}
+	for mt, want := range tests {
+		if got := isText(mt); got != want {
+			t.Errorf("isText(%q) = %v, want %v", mt, got, want)
+		}
+	}
+}
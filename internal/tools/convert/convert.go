@@ -0,0 +1,39 @@
+package convert
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+func init() {
+	tools.RegisterConfig(func() { LoadCurrencyConfig() })
+	tools.Register(tools.Namespace("data", func(server *mcp.Server) {
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "convert_units",
+			Description: "Convert a quantity between units of length, mass, temperature, data size, or time",
+			Annotations: tools.ReadOnly(false),
+		}, ConvertUnits)
+
+		cfg := LoadCurrencyConfig()
+		if cfg.RatesURL == "" {
+			return
+		}
+		rates, err := NewHTTPRates(cfg.RatesURL, cfg.Timeout)
+		if err != nil {
+			logger.Error("convert_currency disabled", "error", err)
+			return
+		}
+		c := NewCurrency(rates, cfg.CacheTTL)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "convert_currency",
+			Description: "Convert an amount between currencies using the operator's configured exchange rate provider",
+			Annotations: tools.ReadOnly(true),
+		}, c.Convert)
+	}))
+}
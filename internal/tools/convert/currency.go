@@ -0,0 +1,171 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+	"github.com/lkendrickd/mcp-server/internal/tools/httpfetch"
+)
+
+// RatesFunc returns exchange rates relative to base, keyed by ISO 4217 code:
+// one unit of base buys rates[code] units of code.
+type RatesFunc func(ctx context.Context, base string) (map[string]float64, error)
+
+// CurrencyConfig controls the exchange rate source.
+type CurrencyConfig struct {
+	// RatesURL is the provider endpoint; "{base}" is replaced with the
+	// source currency code. An empty URL disables convert_currency.
+	RatesURL string
+	CacheTTL time.Duration
+	Timeout  time.Duration
+}
+
+// LoadCurrencyConfig reads the currency configuration from environment variables.
+func LoadCurrencyConfig() CurrencyConfig {
+	return CurrencyConfig{
+		RatesURL: config.GetEnv("CURRENCY_RATES_URL", ""),
+		CacheTTL: config.GetEnvDuration("CURRENCY_CACHE_TTL", time.Hour),
+		Timeout:  config.GetEnvDuration("CURRENCY_TIMEOUT", 10*time.Second),
+	}
+}
+
+// NewHTTPRates returns a RatesFunc that fetches rates from rawURL. The
+// provider must return a JSON object with a "rates" map, as Frankfurter,
+// open.er-api.com, and exchangerate.host do. Only the URL's host is
+// reachable.
+func NewHTTPRates(rawURL string, timeout time.Duration) (RatesFunc, error) {
+	u, err := url.Parse(strings.ReplaceAll(rawURL, "{base}", "USD"))
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid rates URL %q", rawURL)
+	}
+
+	fetcher := httpfetch.NewFetcher(httpfetch.Config{
+		AllowedHosts: []string{u.Hostname()},
+		MaxBytes:     1 << 20,
+		Timeout:      timeout,
+		MaxRedirects: 3,
+	})
+	return func(ctx context.Context, base string) (map[string]float64, error) {
+		target := strings.ReplaceAll(rawURL, "{base}", url.QueryEscape(base))
+		_, out, err := fetcher.Fetch(ctx, &mcp.CallToolRequest{}, httpfetch.Input{URL: target})
+		if err != nil {
+			return nil, err
+		}
+		if out.StatusCode != 200 {
+			return nil, tools.NewError(tools.CodeUnavailable, "rates provider returned status %d", out.StatusCode)
+		}
+		var body struct {
+			Rates map[string]float64 `json:"rates"`
+		}
+		if err := json.Unmarshal([]byte(out.Body), &body); err != nil || len(body.Rates) == 0 {
+			return nil, tools.NewError(tools.CodeUnavailable, "rates provider returned no rates")
+		}
+		return body.Rates, nil
+	}, nil
+}
+
+type cachedRates struct {
+	rates     map[string]float64
+	fetchedAt time.Time
+}
+
+// Currency converts amounts using rates from a RatesFunc, caching each
+// base currency's rates for a TTL.
+type Currency struct {
+	rates RatesFunc
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedRates
+}
+
+// NewCurrency creates a Currency backed by rates.
+func NewCurrency(rates RatesFunc, ttl time.Duration) *Currency {
+	return &Currency{rates: rates, ttl: ttl, now: time.Now, cache: make(map[string]cachedRates)}
+}
+
+// CurrencyInput is the input for the convert_currency tool.
+type CurrencyInput struct {
+	Amount float64 `json:"amount" jsonschema:"the amount to convert"`
+	From   string  `json:"from" jsonschema:"source ISO 4217 currency code, e.g. USD"`
+	To     string  `json:"to" jsonschema:"target ISO 4217 currency code, e.g. EUR"`
+}
+
+// CurrencyOutput is the output of the convert_currency tool.
+type CurrencyOutput struct {
+	Amount    float64 `json:"amount" jsonschema:"the converted amount"`
+	Rate      float64 `json:"rate" jsonschema:"units of the target currency per unit of the source"`
+	From      string  `json:"from" jsonschema:"the source currency code"`
+	To        string  `json:"to" jsonschema:"the target currency code"`
+	RatesTime string  `json:"rates_time" jsonschema:"when the rates were fetched, as RFC 3339"`
+}
+
+// Convert converts an amount between currencies.
+func (c *Currency) Convert(ctx context.Context, req *mcp.CallToolRequest, input CurrencyInput) (*mcp.CallToolResult, CurrencyOutput, error) {
+	from := strings.ToUpper(strings.TrimSpace(input.From))
+	to := strings.ToUpper(strings.TrimSpace(input.To))
+	if !isCurrencyCode(from) || !isCurrencyCode(to) {
+		return nil, CurrencyOutput{}, tools.NewError(tools.CodeInvalidArgument, "currencies must be three-letter ISO 4217 codes")
+	}
+
+	entry, err := c.lookup(ctx, from)
+	if err != nil {
+		return nil, CurrencyOutput{}, tools.WrapError(tools.CodeUnavailable, err, "fetching "+from+" rates")
+	}
+	rate := 1.0
+	if from != to {
+		var ok bool
+		if rate, ok = entry.rates[to]; !ok {
+			return nil, CurrencyOutput{}, tools.NewError(tools.CodeNotFound, "no rate from %s to %s", from, to)
+		}
+	}
+
+	logger.Info("tool called", "tool", "convert_currency", "from", from, "to", to)
+	return nil, CurrencyOutput{
+		Amount:    input.Amount * rate,
+		Rate:      rate,
+		From:      from,
+		To:        to,
+		RatesTime: entry.fetchedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// lookup returns cached rates for base, refreshing them when stale. The
+// lock is held across the fetch so concurrent misses share one request.
+func (c *Currency) lookup(ctx context.Context, base string) (cachedRates, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.cache[base]; ok && c.now().Sub(entry.fetchedAt) < c.ttl {
+		return entry, nil
+	}
+	rates, err := c.rates(ctx, base)
+	if err != nil {
+		return cachedRates{}, err
+	}
+	entry := cachedRates{rates: rates, fetchedAt: c.now()}
+	c.cache[base] = entry
+	return entry, nil
+}
+
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestCurrency_Convert(t *testing.T) {
+	calls := 0
+	rates := func(_ context.Context, base string) (map[string]float64, error) {
+		calls++
+		if base != "USD" {
+			return nil, errors.New("unsupported base")
+		}
+		return map[string]float64{"EUR": 0.5, "GBP": 0.25}, nil
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCurrency(rates, time.Hour)
+	c.now = func() time.Time { return now }
+
+	tests := []struct {
+		name     string
+		input    CurrencyInput
+		want     float64
+		wantErr  string
+		wantCode tools.Code
+	}{
+		{name: "convert", input: CurrencyInput{Amount: 10, From: "USD", To: "EUR"}, want: 5},
+		{name: "lower case", input: CurrencyInput{Amount: 8, From: "usd", To: "gbp"}, want: 2},
+		{name: "same currency", input: CurrencyInput{Amount: 3, From: "USD", To: "USD"}, want: 3},
+		{name: "missing rate", input: CurrencyInput{Amount: 1, From: "USD", To: "JPY"}, wantErr: "no rate", wantCode: tools.CodeNotFound},
+		{name: "invalid code", input: CurrencyInput{Amount: 1, From: "US", To: "EUR"}, wantErr: "ISO 4217", wantCode: tools.CodeInvalidArgument},
+		{name: "provider error", input: CurrencyInput{Amount: 1, From: "EUR", To: "USD"}, wantErr: "unsupported base", wantCode: tools.CodeUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := c.Convert(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Amount != tt.want {
+				t.Errorf("Amount = %v, want %v", out.Amount, tt.want)
+			}
+		})
+	}
+
+	// USD rates were fetched once and served from cache afterwards; the
+	// failed EUR lookup accounts for the second call.
+	if calls != 2 {
+		t.Errorf("rates fetched %d times, want 2", calls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	_, _, _ = c.Convert(context.Background(), &mcp.CallToolRequest{}, CurrencyInput{Amount: 1, From: "USD", To: "EUR"})
+	if calls != 3 {
+		t.Errorf("rates fetched %d times after expiry, want 3", calls)
+	}
+}
+
+func TestNewHTTPRates_InvalidURL(t *testing.T) {
+	if _, err := NewHTTPRates("not a url", time.Second); err == nil {
+		t.Fatal("expected error for invalid URL")
+	}
+}
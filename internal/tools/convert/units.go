@@ -0,0 +1,165 @@
+package convert
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// unit is a unit of measure. Linear units convert through their category's
+// base unit by factor; temperature units use toBase/fromBase instead.
+type unit struct {
+	name     string
+	category string
+	factor   float64
+	toBase   func(float64) float64
+	fromBase func(float64) float64
+}
+
+// Unit categories.
+const (
+	Length      = "length"
+	Mass        = "mass"
+	Temperature = "temperature"
+	Data        = "data"
+	Time        = "time"
+)
+
+// units maps accepted spellings to units. Lookups try the exact spelling
+// first so that case-sensitive data units (Mb vs MB) resolve correctly,
+// then fall back to lower case.
+var units = map[string]unit{}
+
+func define(category string, factor float64, names ...string) {
+	u := unit{name: names[0], category: category, factor: factor}
+	for _, n := range names {
+		units[n] = u
+	}
+}
+
+func init() {
+	// Length, base metre.
+	define(Length, 1, "m", "meter", "meters", "metre", "metres")
+	define(Length, 1e3, "km", "kilometer", "kilometers", "kilometre", "kilometres")
+	define(Length, 1e-2, "cm", "centimeter", "centimeters", "centimetre", "centimetres")
+	define(Length, 1e-3, "mm", "millimeter", "millimeters", "millimetre", "millimetres")
+	define(Length, 1e-6, "um", "µm", "micrometer", "micrometers", "micron", "microns")
+	define(Length, 1e-9, "nm", "nanometer", "nanometers")
+	define(Length, 0.0254, "in", "inch", "inches")
+	define(Length, 0.3048, "ft", "foot", "feet")
+	define(Length, 0.9144, "yd", "yard", "yards")
+	define(Length, 1609.344, "mi", "mile", "miles")
+	define(Length, 1852, "nmi", "nautical_mile", "nautical_miles")
+
+	// Mass, base kilogram.
+	define(Mass, 1, "kg", "kilogram", "kilograms")
+	define(Mass, 1e-3, "g", "gram", "grams")
+	define(Mass, 1e-6, "mg", "milligram", "milligrams")
+	define(Mass, 1e-9, "ug", "µg", "microgram", "micrograms")
+	define(Mass, 1e3, "t", "tonne", "tonnes", "metric_ton")
+	define(Mass, 0.45359237, "lb", "lbs", "pound", "pounds")
+	define(Mass, 0.028349523125, "oz", "ounce", "ounces")
+	define(Mass, 6.35029318, "st", "stone", "stones")
+	define(Mass, 907.18474, "short_ton", "us_ton")
+
+	// Data, base byte. Lower-case "b" resolves to byte via the fallback.
+	define(Data, 1, "B", "byte", "bytes")
+	define(Data, 0.125, "bit", "bits")
+	for i, prefix := range []string{"K", "M", "G", "T", "P", "E"} {
+		dec := math.Pow(1000, float64(i+1))
+		bin := math.Pow(1024, float64(i+1))
+		define(Data, dec, prefix+"B")
+		define(Data, bin, prefix+"iB", strings.ToLower(prefix+"iB"))
+		define(Data, dec/8, prefix+"b", prefix+"bit")
+	}
+	units["kB"] = units["KB"]
+
+	// Time, base second.
+	define(Time, 1e-9, "ns", "nanosecond", "nanoseconds")
+	define(Time, 1e-6, "us", "µs", "microsecond", "microseconds")
+	define(Time, 1e-3, "ms", "millisecond", "milliseconds")
+	define(Time, 1, "s", "sec", "second", "seconds")
+	define(Time, 60, "min", "minute", "minutes")
+	define(Time, 3600, "h", "hr", "hour", "hours")
+	define(Time, 86400, "d", "day", "days")
+	define(Time, 7*86400, "wk", "week", "weeks")
+	define(Time, 365.25*86400/12, "mo", "month", "months")
+	define(Time, 365.25*86400, "yr", "year", "years")
+
+	// Temperature, base kelvin.
+	temperature := func(toK, fromK func(float64) float64, names ...string) {
+		u := unit{name: names[0], category: Temperature, toBase: toK, fromBase: fromK}
+		for _, n := range names {
+			units[n] = u
+		}
+	}
+	temperature(func(v float64) float64 { return v }, func(v float64) float64 { return v },
+		"K", "kelvin")
+	temperature(func(v float64) float64 { return v + 273.15 }, func(v float64) float64 { return v - 273.15 },
+		"C", "°C", "celsius")
+	temperature(func(v float64) float64 { return (v-32)*5/9 + 273.15 }, func(v float64) float64 { return (v-273.15)*9/5 + 32 },
+		"F", "°F", "fahrenheit")
+}
+
+// lookupUnit resolves a unit spelling.
+func lookupUnit(name string) (unit, bool) {
+	name = strings.TrimSpace(name)
+	if u, ok := units[name]; ok {
+		return u, true
+	}
+	lower := strings.ToLower(name)
+	for _, candidate := range []string{lower, strings.ToUpper(name)} {
+		if u, ok := units[candidate]; ok {
+			return u, true
+		}
+	}
+	return unit{}, false
+}
+
+// UnitsInput is the input for the convert_units tool.
+type UnitsInput struct {
+	Value float64 `json:"value" jsonschema:"the quantity to convert"`
+	From  string  `json:"from" jsonschema:"source unit, e.g. km, lb, F, GiB, h"`
+	To    string  `json:"to" jsonschema:"target unit in the same category"`
+}
+
+// UnitsOutput is the output of the convert_units tool.
+type UnitsOutput struct {
+	Value    float64 `json:"value" jsonschema:"the converted quantity"`
+	From     string  `json:"from" jsonschema:"the canonical source unit"`
+	To       string  `json:"to" jsonschema:"the canonical target unit"`
+	Category string  `json:"category" jsonschema:"length, mass, temperature, data, or time"`
+}
+
+// ConvertUnits converts a quantity between units of the same category.
+func ConvertUnits(ctx context.Context, req *mcp.CallToolRequest, input UnitsInput) (*mcp.CallToolResult, UnitsOutput, error) {
+	from, ok := lookupUnit(input.From)
+	if !ok {
+		return nil, UnitsOutput{}, tools.NewError(tools.CodeInvalidArgument, "unknown unit %q", input.From)
+	}
+	to, ok := lookupUnit(input.To)
+	if !ok {
+		return nil, UnitsOutput{}, tools.NewError(tools.CodeInvalidArgument, "unknown unit %q", input.To)
+	}
+	if from.category != to.category {
+		return nil, UnitsOutput{}, tools.NewError(tools.CodeInvalidArgument, "cannot convert %s (%s) to %s (%s)", from.name, from.category, to.name, to.category)
+	}
+
+	var value float64
+	if from.category == Temperature {
+		kelvin := from.toBase(input.Value)
+		if kelvin < 0 {
+			return nil, UnitsOutput{}, tools.NewError(tools.CodeInvalidArgument, "temperature is below absolute zero")
+		}
+		value = to.fromBase(kelvin)
+	} else {
+		value = input.Value * from.factor / to.factor
+	}
+
+	logger.Info("tool called", "tool", "convert_units", "from", from.name, "to", to.name)
+	return nil, UnitsOutput{Value: value, From: from.name, To: to.name, Category: from.category}, nil
+}
@@ -0,0 +1,62 @@
+package convert
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestConvertUnits(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        UnitsInput
+		want         float64
+		wantCategory string
+		wantErr      string
+		wantCode     tools.Code
+	}{
+		{name: "km to mi", input: UnitsInput{Value: 1, From: "km", To: "mi"}, want: 0.621371192237334, wantCategory: Length},
+		{name: "feet to meters", input: UnitsInput{Value: 10, From: "feet", To: "m"}, want: 3.048, wantCategory: Length},
+		{name: "lb to kg", input: UnitsInput{Value: 1, From: "lb", To: "kg"}, want: 0.45359237, wantCategory: Mass},
+		{name: "F to C", input: UnitsInput{Value: 212, From: "F", To: "C"}, want: 100, wantCategory: Temperature},
+		{name: "celsius to kelvin", input: UnitsInput{Value: 0, From: "celsius", To: "kelvin"}, want: 273.15, wantCategory: Temperature},
+		{name: "lower-case c to f", input: UnitsInput{Value: -40, From: "c", To: "f"}, want: -40, wantCategory: Temperature},
+		{name: "GiB to MB", input: UnitsInput{Value: 1, From: "GiB", To: "MB"}, want: 1073.741824, wantCategory: Data},
+		{name: "Mb to MB", input: UnitsInput{Value: 8, From: "Mb", To: "MB"}, want: 1, wantCategory: Data},
+		{name: "lower-case mib", input: UnitsInput{Value: 1, From: "mib", To: "KiB"}, want: 1024, wantCategory: Data},
+		{name: "hours to minutes", input: UnitsInput{Value: 1.5, From: "h", To: "min"}, want: 90, wantCategory: Time},
+		{name: "days to weeks", input: UnitsInput{Value: 14, From: "days", To: "wk"}, want: 2, wantCategory: Time},
+		{name: "unknown unit", input: UnitsInput{Value: 1, From: "furlong", To: "m"}, wantErr: "unknown unit", wantCode: tools.CodeInvalidArgument},
+		{name: "category mismatch", input: UnitsInput{Value: 1, From: "kg", To: "m"}, wantErr: "cannot convert", wantCode: tools.CodeInvalidArgument},
+		{name: "below absolute zero", input: UnitsInput{Value: -300, From: "C", To: "K"}, wantErr: "absolute zero", wantCode: tools.CodeInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := ConvertUnits(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(out.Value-tt.want) > 1e-9*math.Max(1, math.Abs(tt.want)) {
+				t.Errorf("Value = %v, want %v", out.Value, tt.want)
+			}
+			if out.Category != tt.wantCategory {
+				t.Errorf("Category = %q, want %q", out.Category, tt.wantCategory)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package dataformat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// MaxInputBytes caps the size of documents accepted by the tool.
+const MaxInputBytes = 5 << 20
+
+// Supported formats.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// yamlLine extracts the line number from yaml error messages such as
+// "yaml: line 3: mapping values are not allowed in this context".
+var yamlLine = regexp.MustCompile(`line (\d+)`)
+
+// Input is the input for the convert_data tool.
+type Input struct {
+	Input  string `json:"input" jsonschema:"the document to convert"`
+	From   string `json:"from" jsonschema:"input format: json, yaml, or toml"`
+	To     string `json:"to,omitempty" jsonschema:"output format: json (default), yaml, or toml"`
+	Schema any    `json:"schema,omitempty" jsonschema:"optional JSON Schema (draft 2020-12) the document must satisfy"`
+	Indent int    `json:"indent,omitempty" jsonschema:"spaces per indentation level for json and yaml output (default 2)"`
+}
+
+// Output is the output of the convert_data tool.
+type Output struct {
+	Output          string `json:"output" jsonschema:"the converted document; empty when schema validation fails"`
+	Format          string `json:"format" jsonschema:"the output format"`
+	Valid           *bool  `json:"valid,omitempty" jsonschema:"whether the document satisfies the schema, when one was given"`
+	ValidationError string `json:"validation_error,omitempty" jsonschema:"why the document does not satisfy the schema"`
+}
+
+// PositionError reports where in the input a parse error occurred.
+type PositionError struct {
+	Format string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *PositionError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("invalid %s at line %d, column %d: %s", e.Format, e.Line, e.Column, e.Msg)
+	case e.Line > 0:
+		return fmt.Sprintf("invalid %s at line %d: %s", e.Format, e.Line, e.Msg)
+	default:
+		return fmt.Sprintf("invalid %s: %s", e.Format, e.Msg)
+	}
+}
+
+// Convert translates a document between JSON, YAML, and TOML, optionally
+// validating it against a JSON Schema first.
+func Convert(ctx context.Context, req *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	if len(input.Input) > MaxInputBytes {
+		return nil, Output{}, tools.NewError(tools.CodeResourceExhausted, "input exceeds %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+	from := strings.ToLower(input.From)
+	to := strings.ToLower(input.To)
+	if to == "" {
+		to = FormatJSON
+	}
+	if input.Indent < 0 || input.Indent > 8 {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "indent must be between 0 and 8")
+	}
+	indent := input.Indent
+	if indent == 0 {
+		indent = 2
+	}
+
+	doc, err := decode(from, input.Input)
+	if err != nil {
+		var terr *tools.Error
+		if !errors.As(err, &terr) {
+			err = tools.WrapError(tools.CodeInvalidArgument, err, "parsing input")
+		}
+		return nil, Output{}, err
+	}
+
+	out := Output{Format: to}
+	if input.Schema != nil {
+		valid, reason, err := validate(input.Schema, doc)
+		if err != nil {
+			return nil, Output{}, err
+		}
+		out.Valid = &valid
+		if !valid {
+			out.ValidationError = reason
+			logger.Info("tool called", "tool", "convert_data", "from", from, "to", to, "valid", false)
+			return nil, out, nil
+		}
+	}
+
+	if out.Output, err = encode(to, doc, indent); err != nil {
+		return nil, Output{}, err
+	}
+
+	logger.Info("tool called", "tool", "convert_data", "from", from, "to", to, "input_bytes", len(input.Input))
+	return nil, out, nil
+}
+
+// decode parses input into generic values: map[string]any, []any, string,
+// bool, int64, float64, time.Time, or nil.
+func decode(format, input string) (any, error) {
+	var doc any
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(strings.NewReader(input))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, jsonPositionError(input, dec, err)
+		}
+		end := int(dec.InputOffset())
+		if _, err := dec.Token(); err != io.EOF {
+			rest := input[end:]
+			end += len(rest) - len(strings.TrimLeft(rest, " \t\r\n"))
+			line, col := position(input, end)
+			return nil, &PositionError{Format: FormatJSON, Line: line, Column: col, Msg: "unexpected data after top-level value"}
+		}
+	case FormatYAML:
+		dec := yaml.NewDecoder(strings.NewReader(input))
+		if err := dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+			return nil, yamlPositionError(err)
+		}
+		var extra any
+		if err := dec.Decode(&extra); !errors.Is(err, io.EOF) {
+			return nil, &PositionError{Format: FormatYAML, Msg: "multiple documents are not supported"}
+		}
+	case FormatTOML:
+		var table map[string]any
+		if _, err := toml.Decode(input, &table); err != nil {
+			var perr toml.ParseError
+			if errors.As(err, &perr) {
+				return nil, &PositionError{Format: FormatTOML, Line: perr.Position.Line, Column: perr.Position.Col, Msg: perr.Message}
+			}
+			return nil, &PositionError{Format: FormatTOML, Msg: err.Error()}
+		}
+		doc = table
+	default:
+		return nil, tools.NewError(tools.CodeInvalidArgument, "unsupported format %q: use json, yaml, or toml", format)
+	}
+	return normalize(doc)
+}
+
+// encode renders doc in the requested format.
+func encode(format string, doc any, indent int) (string, error) {
+	switch format {
+	case FormatJSON:
+		b, err := json.MarshalIndent(doc, "", strings.Repeat(" ", indent))
+		if err != nil {
+			return "", err
+		}
+		return string(b) + "\n", nil
+	case FormatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(indent)
+		if err := enc.Encode(doc); err != nil {
+			return "", err
+		}
+		if err := enc.Close(); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	case FormatTOML:
+		table, ok := doc.(map[string]any)
+		if !ok {
+			return "", tools.NewError(tools.CodeInvalidArgument, "toml output requires a top-level object, got %s", describe(doc))
+		}
+		if path := findNull(table, ""); path != "" {
+			return "", tools.NewError(tools.CodeInvalidArgument, "toml cannot represent null (at %s)", path)
+		}
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(table); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	default:
+		return "", tools.NewError(tools.CodeInvalidArgument, "unsupported format %q: use json, yaml, or toml", format)
+	}
+}
+
+// validate checks doc against a JSON Schema. The document is round-tripped
+// through JSON so the validator sees only JSON types.
+func validate(rawSchema, doc any) (bool, string, error) {
+	b, err := json.Marshal(rawSchema)
+	if err != nil {
+		return false, "", tools.WrapError(tools.CodeInvalidArgument, err, "invalid schema")
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return false, "", tools.WrapError(tools.CodeInvalidArgument, err, "invalid schema")
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		return false, "", tools.WrapError(tools.CodeInvalidArgument, err, "invalid schema")
+	}
+
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return false, "", err
+	}
+	var instance any
+	if err := json.Unmarshal(b, &instance); err != nil {
+		return false, "", err
+	}
+	if err := resolved.Validate(instance); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// normalize converts decoder-specific values into a common representation
+// every encoder accepts.
+func normalize(v any) (any, error) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, item := range t {
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = n
+		}
+		return t, nil
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for k, item := range t {
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(k)] = n
+		}
+		return m, nil
+	case []any:
+		for i, item := range t {
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = n
+		}
+		return t, nil
+	case []map[string]any:
+		// TOML arrays of tables.
+		out := make([]any, len(t))
+		for i, item := range t {
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = n
+		}
+		return out, nil
+	case json.Number:
+		if i, err := strconv.ParseInt(string(t), 10, 64); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	case int:
+		return int64(t), nil
+	case uint64:
+		return float64(t), nil
+	case time.Time:
+		// TOML local dates and times carry no offset; the decoder marks
+		// them with named zones, which are rendered without one.
+		switch t.Location().String() {
+		case "date-local":
+			return t.Format(time.DateOnly), nil
+		case "time-local":
+			return t.Format("15:04:05.999999999"), nil
+		case "datetime-local":
+			return t.Format("2006-01-02T15:04:05.999999999"), nil
+		}
+		return t, nil
+	default:
+		return v, nil
+	}
+}
+
+// findNull returns the path of the first null value in v, or "".
+func findNull(v any, path string) string {
+	switch t := v.(type) {
+	case nil:
+		if path == "" {
+			return "$"
+		}
+		return path
+	case map[string]any:
+		for k, item := range t {
+			if p := findNull(item, path+"."+k); p != "" {
+				return p
+			}
+		}
+	case []any:
+		for i, item := range t {
+			if p := findNull(item, fmt.Sprintf("%s[%d]", path, i)); p != "" {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+func describe(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case []any:
+		return "an array"
+	default:
+		return fmt.Sprintf("a %T", v)
+	}
+}
+
+func jsonPositionError(input string, dec *json.Decoder, err error) error {
+	offset := int(dec.InputOffset())
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = int(syntaxErr.Offset) - 1
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		offset = len(input)
+	}
+	line, col := position(input, max(offset, 0))
+	return &PositionError{Format: FormatJSON, Line: line, Column: col, Msg: strings.TrimPrefix(err.Error(), "json: ")}
+}
+
+func yamlPositionError(err error) error {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	perr := &PositionError{Format: FormatYAML, Msg: msg}
+	if m := yamlLine.FindStringSubmatch(msg); m != nil {
+		perr.Line, _ = strconv.Atoi(m[1])
+		perr.Msg = strings.TrimPrefix(msg[len(m[0]):], ": ")
+	}
+	return perr
+}
+
+// position converts a 0-based byte offset into a 1-based line and column.
+func position(input string, offset int) (int, int) {
+	offset = min(offset, len(input))
+	before := input[:offset]
+	line := strings.Count(before, "\n") + 1
+	col := offset - strings.LastIndexByte(before, '\n')
+	return line, col
+}
+
+func init() {
+	tools.Register(tools.Namespace("data", func(server *mcp.Server) {
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "convert_data",
+			Description: "Convert a document between JSON, YAML, and TOML, optionally validating it against a JSON Schema; parse errors report line and column",
+			Annotations: tools.ReadOnly(false),
+		}, Convert)
+	}))
+}
@@ -0,0 +1,169 @@
+package dataformat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    Input
+		want     string
+		wantErr  string
+		wantCode tools.Code
+	}{
+		{
+			name:  "json to yaml",
+			input: Input{Input: `{"name":"app","ports":[80,443],"debug":false}`, From: "json", To: "yaml"},
+			want:  "debug: false\nname: app\nports:\n  - 80\n  - 443\n",
+		},
+		{
+			name:  "yaml to json",
+			input: Input{Input: "name: app\nreplicas: 3\nratio: 0.5\n", From: "yaml"},
+			want:  "{\n  \"name\": \"app\",\n  \"ratio\": 0.5,\n  \"replicas\": 3\n}\n",
+		},
+		{
+			name:  "toml to json",
+			input: Input{Input: "title = \"x\"\n\n[server]\nport = 8080\n\n[[users]]\nname = \"a\"\n", From: "toml", Indent: 1},
+			want:  "{\n \"server\": {\n  \"port\": 8080\n },\n \"title\": \"x\",\n \"users\": [\n  {\n   \"name\": \"a\"\n  }\n ]\n}\n",
+		},
+		{
+			name:  "json to toml",
+			input: Input{Input: `{"title":"x","server":{"port":8080}}`, From: "json", To: "toml"},
+			want:  "title = \"x\"\n\n[server]\n  port = 8080\n",
+		},
+		{
+			name:  "toml local date",
+			input: Input{Input: "day = 2024-01-02\n", From: "toml"},
+			want:  "{\n  \"day\": \"2024-01-02\"\n}\n",
+		},
+		{
+			name:  "large integer preserved",
+			input: Input{Input: `{"id": 9007199254740993}`, From: "json", To: "yaml"},
+			want:  "id: 9007199254740993\n",
+		},
+		{
+			name:     "toml requires object",
+			input:    Input{Input: `[1, 2]`, From: "json", To: "toml"},
+			wantErr:  "top-level object",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name:     "toml cannot encode null",
+			input:    Input{Input: `{"a": {"b": null}}`, From: "json", To: "toml"},
+			wantErr:  "null (at .a.b)",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name:     "unsupported format",
+			input:    Input{Input: `a`, From: "xml"},
+			wantErr:  "unsupported format",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name:     "yaml multiple documents",
+			input:    Input{Input: "a: 1\n---\nb: 2\n", From: "yaml"},
+			wantErr:  "multiple documents",
+			wantCode: tools.CodeInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Convert(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Output != tt.want {
+				t.Errorf("Output =\n%s\nwant\n%s", out.Output, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert_ErrorPositions(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      Input
+		wantLine   int
+		wantColumn int
+	}{
+		{name: "json syntax", input: Input{Input: "{\n  \"a\": 1,\n  \"b\": x\n}", From: "json"}, wantLine: 3, wantColumn: 8},
+		{name: "json trailing data", input: Input{Input: "{}\n{}", From: "json"}, wantLine: 2, wantColumn: 1},
+		{name: "yaml", input: Input{Input: "a: 1\nb: [\n", From: "yaml"}, wantLine: 2},
+		{name: "toml", input: Input{Input: "a = 1\nb = \n", From: "toml"}, wantLine: 2, wantColumn: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Convert(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			var perr *PositionError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want *PositionError", err)
+			}
+			if code := tools.AsError(err).Code; code != tools.CodeInvalidArgument {
+				t.Errorf("code = %s, want %s", code, tools.CodeInvalidArgument)
+			}
+			if perr.Line != tt.wantLine || tt.wantColumn != 0 && perr.Column != tt.wantColumn {
+				t.Errorf("position = %d:%d, want %d:%d (%v)", perr.Line, perr.Column, tt.wantLine, tt.wantColumn, err)
+			}
+		})
+	}
+}
+
+func TestConvert_Schema(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"name"},
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"port": map[string]any{"type": "integer", "maximum": 65535},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		wantValid bool
+	}{
+		{name: "valid", input: "name: app\nport: 8080\n", wantValid: true},
+		{name: "missing required", input: "port: 8080\n"},
+		{name: "out of range", input: "name: app\nport: 70000\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Convert(context.Background(), &mcp.CallToolRequest{}, Input{Input: tt.input, From: "yaml", Schema: schema})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Valid == nil || *out.Valid != tt.wantValid {
+				t.Fatalf("Valid = %v, want %v", out.Valid, tt.wantValid)
+			}
+			if !tt.wantValid && (out.ValidationError == "" || out.Output != "") {
+				t.Errorf("got output %q, validation error %q; want error only", out.Output, out.ValidationError)
+			}
+		})
+	}
+
+	_, _, err := Convert(context.Background(), &mcp.CallToolRequest{}, Input{Input: "{}", From: "json", Schema: map[string]any{"type": 5}})
+	if err == nil || !strings.Contains(err.Error(), "invalid schema") {
+		t.Errorf("error = %v, want invalid schema", err)
+	}
+}
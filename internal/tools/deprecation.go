@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+// DeprecatedCalls counts calls to deprecated tools, so operators can tell
+// when a tool is no longer used and can be removed.
+var DeprecatedCalls = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "mcp_tool_deprecated_calls_total",
+		Help: "Total number of calls to deprecated tools, by tool.",
+	},
+	[]string{"tool"},
+)
+
+// Deprecation marks a tool as deprecated. Deprecated tools keep working,
+// but their description says so, and each call adds a warning block to
+// the result, logs a warning the client can receive, and counts towards
+// DeprecatedCalls.
+type Deprecation struct {
+	// Replacement is the tool to use instead, if any.
+	Replacement string `json:"replacement,omitempty"`
+	// Removal is the version or date after which the tool may be removed.
+	Removal string `json:"removal,omitempty"`
+	// Message explains the deprecation or how to migrate.
+	Message string `json:"message,omitempty"`
+}
+
+// Notice returns the deprecation notice of the tool called name.
+func (d Deprecation) Notice(name string) string {
+	var b strings.Builder
+	b.WriteString("Tool " + name + " is deprecated")
+	if d.Removal != "" {
+		b.WriteString(" and may be removed after " + d.Removal)
+	}
+	if d.Replacement != "" {
+		b.WriteString("; use " + d.Replacement + " instead")
+	}
+	b.WriteString(".")
+	if d.Message != "" {
+		b.WriteString(" " + d.Message)
+	}
+	return b.String()
+}
+
+// Deprecate marks tool as deprecated and returns it, for use in AddTool:
+//
+//	tools.AddTool(server, tools.Deprecate(&mcp.Tool{...}, tools.Deprecation{Replacement: "new_tool"}), h)
+//
+// The notice is prepended to the tool's description and d is listed
+// under _meta.deprecated in tools/list.
+func Deprecate(tool *mcp.Tool, d Deprecation) *mcp.Tool {
+	tool.Description = "DEPRECATED: " + d.Notice(tool.Name) + " " + tool.Description
+	if tool.Meta == nil {
+		tool.Meta = mcp.Meta{}
+	}
+	tool.Meta["deprecated"] = d
+	return tool
+}
+
+// deprecations holds the deprecation of every deprecated tool registered
+// with AddTool, by name.
+var deprecations sync.Map
+
+// DeprecationOf returns the deprecation of the tool called name, and false
+// if it is not deprecated.
+func DeprecationOf(name string) (Deprecation, bool) {
+	d, ok := deprecations.Load(name)
+	if !ok {
+		return Deprecation{}, false
+	}
+	return d.(Deprecation), true
+}
+
+// deprecatedTools reads TOOL_DEPRECATED.
+func deprecatedTools() []string {
+	return config.GetEnvList("TOOL_DEPRECATED")
+}
+
+// configuredDeprecation returns the deprecation of tool set in code with
+// Deprecate or by the operator in TOOL_DEPRECATED, a comma-separated list
+// of tool names, each optionally followed by = and its replacement.
+// TOOL_DEPRECATED deprecates tools without a code change, ahead of
+// removing them from a deployment.
+func configuredDeprecation(tool *mcp.Tool) (Deprecation, bool) {
+	if d, ok := tool.Meta["deprecated"].(Deprecation); ok {
+		return d, true
+	}
+	for _, entry := range deprecatedTools() {
+		name, replacement, _ := strings.Cut(entry, "=")
+		if strings.TrimSpace(name) == tool.Name {
+			return Deprecation{Replacement: strings.TrimSpace(replacement)}, true
+		}
+	}
+	return Deprecation{}, false
+}
+
+// Deprecated returns the Middleware AddTool applies to deprecated tools.
+func Deprecated(d Deprecation) Middleware {
+	return func(name string, next Handler) Handler {
+		notice := d.Notice(name)
+		return func(ctx context.Context, req *mcp.CallToolRequest, in any) (*mcp.CallToolResult, any, error) {
+			DeprecatedCalls.WithLabelValues(name).Inc()
+			logger.WarnContext(ctx, "deprecated tool called", "tool", name, "replacement", d.Replacement, "removal", d.Removal)
+
+			res, out, err := next(ctx, req, in)
+			if err != nil {
+				return res, out, err
+			}
+			if res == nil {
+				// Content set here stops the SDK adding the output as
+				// text, so add it as the SDK would
+				res = &mcp.CallToolResult{}
+				if out != nil {
+					data, jerr := json.Marshal(out)
+					if jerr != nil {
+						return nil, out, jerr
+					}
+					res.Content = []mcp.Content{&mcp.TextContent{Text: string(data)}}
+				}
+			}
+			res.Content = append(res.Content, &mcp.TextContent{Text: "[deprecated] " + notice})
+			if res.Meta == nil {
+				res.Meta = mcp.Meta{}
+			}
+			res.Meta["deprecated"] = d
+			return res, out, nil
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDeprecationNotice(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Deprecation
+		want string
+	}{
+		{"bare", Deprecation{}, "Tool old is deprecated."},
+		{"replacement", Deprecation{Replacement: "new"}, "Tool old is deprecated; use new instead."},
+		{
+			"everything",
+			Deprecation{Replacement: "new", Removal: "v2.0.0", Message: "new takes a path."},
+			"Tool old is deprecated and may be removed after v2.0.0; use new instead. new takes a path.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d.Notice("old"); got != tt.want {
+				t.Errorf("Notice() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	type output struct {
+		Value string `json:"value"`
+	}
+	tests := []struct {
+		name      string
+		tool      *mcp.Tool
+		env       string
+		h         mcp.ToolHandlerFor[struct{}, output]
+		wantText  []string
+		wantError bool
+	}{
+		{
+			name: "output only",
+			tool: Deprecate(&mcp.Tool{Name: "old_output", Description: "Does things."}, Deprecation{Replacement: "new"}),
+			h: func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, output, error) {
+				return nil, output{Value: "x"}, nil
+			},
+			wantText: []string{`{"value":"x"}`, "[deprecated] Tool old_output is deprecated; use new instead."},
+		},
+		{
+			name: "content",
+			tool: Deprecate(&mcp.Tool{Name: "old_content"}, Deprecation{}),
+			h: func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, output, error) {
+				return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "hi"}}}, output{}, nil
+			},
+			wantText: []string{"hi", "[deprecated] Tool old_content is deprecated."},
+		},
+		{
+			name: "from environment",
+			tool: &mcp.Tool{Name: "old_env"},
+			env:  "other, old_env = new_env",
+			h: func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, output, error) {
+				return nil, output{}, nil
+			},
+			wantText: []string{`{"value":""}`, "[deprecated] Tool old_env is deprecated; use new_env instead."},
+		},
+		{
+			name: "error",
+			tool: Deprecate(&mcp.Tool{Name: "old_error"}, Deprecation{}),
+			h: func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, output, error) {
+				return nil, output{}, errors.New("boom")
+			},
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TOOL_DEPRECATED", tt.env)
+			server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+			AddTool(server, tt.tool, tt.h)
+			if _, ok := DeprecationOf(tt.tool.Name); !ok {
+				t.Errorf("DeprecationOf(%q) not found", tt.tool.Name)
+			}
+			if !strings.HasPrefix(tt.tool.Description, "DEPRECATED: ") {
+				t.Errorf("Description = %q, want the deprecation notice first", tt.tool.Description)
+			}
+
+			ctx := context.Background()
+			serverTransport, clientTransport := mcp.NewInMemoryTransports()
+			if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+				t.Fatalf("server connect: %v", err)
+			}
+			client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+			session, err := client.Connect(ctx, clientTransport, nil)
+			if err != nil {
+				t.Fatalf("client connect: %v", err)
+			}
+			defer session.Close()
+
+			before := testutil.ToFloat64(DeprecatedCalls.WithLabelValues(tt.tool.Name))
+			res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: tt.tool.Name, Arguments: map[string]any{}})
+			if err != nil {
+				t.Fatalf("CallTool: %v", err)
+			}
+			if got := testutil.ToFloat64(DeprecatedCalls.WithLabelValues(tt.tool.Name)) - before; got != 1 {
+				t.Errorf("DeprecatedCalls = %v, want 1", got)
+			}
+			if res.IsError != tt.wantError {
+				t.Fatalf("IsError = %v, want %v", res.IsError, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			var text []string
+			for _, c := range res.Content {
+				if tc, ok := c.(*mcp.TextContent); ok {
+					text = append(text, tc.Text)
+				}
+			}
+			if strings.Join(text, "\n") != strings.Join(tt.wantText, "\n") {
+				t.Errorf("Content = %q, want %q", text, tt.wantText)
+			}
+			if _, ok := res.Meta["deprecated"]; !ok {
+				t.Errorf("Meta = %v, want deprecated", res.Meta)
+			}
+		})
+	}
+}
+
+func TestAddTool_NotDeprecated(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	AddTool(server, &mcp.Tool{Name: "current"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		return nil, nil, nil
+	})
+	if d, ok := DeprecationOf("current"); ok {
+		t.Errorf("DeprecationOf(current) = %+v, want not deprecated", d)
+	}
+}
@@ -0,0 +1,199 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// Resolver is the subset of *net.Resolver used by the tool.
+type Resolver interface {
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupNS(ctx context.Context, name string) ([]*net.NS, error)
+}
+
+// Config controls how lookups are performed.
+type Config struct {
+	// Server is an optional "host:port" DNS server; empty uses the system resolver.
+	Server  string
+	Timeout time.Duration
+}
+
+// LoadConfig reads the DNS configuration from environment variables.
+func LoadConfig() Config {
+	return Config{
+		Server:  config.GetEnv("DNS_RESOLVER", ""),
+		Timeout: config.GetEnvDuration("DNS_TIMEOUT", 5*time.Second),
+	}
+}
+
+// NewResolver builds a resolver that queries cfg.Server when set.
+func NewResolver(cfg Config) Resolver {
+	if cfg.Server == "" {
+		return net.DefaultResolver
+	}
+
+	server := cfg.Server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// Input is the input for the dns_lookup tool.
+type Input struct {
+	Name string `json:"name" jsonschema:"the domain name to look up"`
+	Type string `json:"type,omitempty" jsonschema:"record type: A (default), AAAA, CNAME, MX, TXT, or NS"`
+}
+
+// Record is a single DNS answer.
+type Record struct {
+	Value    string `json:"value" jsonschema:"the record value (address, host name, or text)"`
+	Priority int    `json:"priority,omitempty" jsonschema:"MX preference; lower is preferred"`
+}
+
+// Output is the output of the dns_lookup tool.
+type Output struct {
+	Name    string   `json:"name" jsonschema:"the queried name"`
+	Type    string   `json:"type" jsonschema:"the queried record type"`
+	Records []Record `json:"records" jsonschema:"the answers"`
+}
+
+// Lookup performs DNS lookups with a bounded timeout.
+type Lookup struct {
+	resolver Resolver
+	timeout  time.Duration
+}
+
+// NewLookup creates a Lookup using resolver and timeout.
+func NewLookup(resolver Resolver, timeout time.Duration) *Lookup {
+	return &Lookup{resolver: resolver, timeout: timeout}
+}
+
+// Lookup resolves the requested record type for a name.
+func (l *Lookup) Lookup(ctx context.Context, _ *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	name := strings.TrimSuffix(strings.TrimSpace(input.Name), ".")
+	if name == "" {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "name is required")
+	}
+	recordType := strings.ToUpper(input.Type)
+	if recordType == "" {
+		recordType = "A"
+	}
+
+	if l.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+
+	records, err := l.query(ctx, name, recordType)
+	if err != nil {
+		return nil, Output{}, err
+	}
+	if records == nil {
+		records = []Record{}
+	}
+
+	logger.Info("tool called", "tool", "dns_lookup", "name", name, "type", recordType, "records", len(records))
+	return nil, Output{Name: name, Type: recordType, Records: records}, nil
+}
+
+// query dispatches a lookup by record type.
+func (l *Lookup) query(ctx context.Context, name, recordType string) ([]Record, error) {
+	var records []Record
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := l.resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, lookupError(recordType, name, err)
+		}
+		for _, ip := range ips {
+			records = append(records, Record{Value: ip.String()})
+		}
+	case "CNAME":
+		cname, err := l.resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, lookupError(recordType, name, err)
+		}
+		records = append(records, Record{Value: strings.TrimSuffix(cname, ".")})
+	case "MX":
+		mxs, err := l.resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, lookupError(recordType, name, err)
+		}
+		for _, mx := range mxs {
+			records = append(records, Record{Value: strings.TrimSuffix(mx.Host, "."), Priority: int(mx.Pref)})
+		}
+	case "TXT":
+		txts, err := l.resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, lookupError(recordType, name, err)
+		}
+		for _, txt := range txts {
+			records = append(records, Record{Value: txt})
+		}
+	case "NS":
+		nss, err := l.resolver.LookupNS(ctx, name)
+		if err != nil {
+			return nil, lookupError(recordType, name, err)
+		}
+		for _, ns := range nss {
+			records = append(records, Record{Value: strings.TrimSuffix(ns.Host, ".")})
+		}
+	default:
+		return nil, tools.NewError(tools.CodeInvalidArgument, "unsupported record type %q: use A, AAAA, CNAME, MX, TXT, or NS", recordType)
+	}
+	return records, nil
+}
+
+// lookupError converts a resolver error into a descriptive tool error.
+func lookupError(recordType, name string, err error) error {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			return tools.NewError(tools.CodeNotFound, "no %s records found for %s", recordType, name)
+		case dnsErr.IsTimeout:
+			return tools.NewError(tools.CodeDeadlineExceeded, "%s lookup for %s timed out", recordType, name)
+		}
+	}
+	return tools.WrapError(tools.CodeUnavailable, err, recordType+" lookup for "+name+" failed")
+}
+
+func init() {
+	tools.RegisterConfig(func() { LoadConfig() })
+	tools.Register(tools.Namespace("net", func(server *mcp.Server) {
+		cfg := LoadConfig()
+		l := NewLookup(NewResolver(cfg), cfg.Timeout)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "dns_lookup",
+			Description: "Look up DNS A, AAAA, CNAME, MX, TXT, or NS records for a domain",
+			Annotations: tools.ReadOnly(true),
+		}, l.Lookup)
+	}))
+}
@@ -0,0 +1,126 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// fakeResolver serves canned answers for example.com.
+type fakeResolver struct {
+	delay time.Duration
+}
+
+func (f *fakeResolver) wait(ctx context.Context) error {
+	select {
+	case <-time.After(f.delay):
+		return nil
+	case <-ctx.Done():
+		return &net.DNSError{Err: "timeout", IsTimeout: true}
+	}
+}
+
+func (f *fakeResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	if host != "example.com" {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	if network == "ip6" {
+		return []net.IP{net.ParseIP("2001:db8::1")}, nil
+	}
+	return []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")}, nil
+}
+
+func (f *fakeResolver) LookupCNAME(_ context.Context, _ string) (string, error) {
+	return "target.example.net.", nil
+}
+
+func (f *fakeResolver) LookupMX(_ context.Context, _ string) ([]*net.MX, error) {
+	return []*net.MX{{Host: "mx1.example.com.", Pref: 10}, {Host: "mx2.example.com.", Pref: 20}}, nil
+}
+
+func (f *fakeResolver) LookupTXT(_ context.Context, _ string) ([]string, error) {
+	return []string{"v=spf1 -all"}, nil
+}
+
+func (f *fakeResolver) LookupNS(_ context.Context, _ string) ([]*net.NS, error) {
+	return []*net.NS{{Host: "ns1.example.com."}}, nil
+}
+
+func TestLookup(t *testing.T) {
+	l := NewLookup(&fakeResolver{}, time.Second)
+
+	tests := []struct {
+		name     string
+		input    Input
+		want     []Record
+		wantErr  string
+		wantCode tools.Code
+	}{
+		{name: "default A", input: Input{Name: "example.com"}, want: []Record{{Value: "192.0.2.1"}, {Value: "192.0.2.2"}}},
+		{name: "AAAA lowercase type", input: Input{Name: "example.com.", Type: "aaaa"}, want: []Record{{Value: "2001:db8::1"}}},
+		{name: "CNAME", input: Input{Name: "www.example.com", Type: "CNAME"}, want: []Record{{Value: "target.example.net"}}},
+		{name: "MX", input: Input{Name: "example.com", Type: "MX"}, want: []Record{{Value: "mx1.example.com", Priority: 10}, {Value: "mx2.example.com", Priority: 20}}},
+		{name: "TXT", input: Input{Name: "example.com", Type: "TXT"}, want: []Record{{Value: "v=spf1 -all"}}},
+		{name: "NS", input: Input{Name: "example.com", Type: "NS"}, want: []Record{{Value: "ns1.example.com"}}},
+		{name: "not found", input: Input{Name: "missing.test"}, wantErr: "no A records found", wantCode: tools.CodeNotFound},
+		{name: "unsupported type", input: Input{Name: "example.com", Type: "SRV"}, wantErr: "unsupported record type", wantCode: tools.CodeInvalidArgument},
+		{name: "empty name", input: Input{}, wantErr: "name is required", wantCode: tools.CodeInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := l.Lookup(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(out.Records) != len(tt.want) {
+				t.Fatalf("Records = %+v, want %+v", out.Records, tt.want)
+			}
+			for i := range tt.want {
+				if out.Records[i] != tt.want[i] {
+					t.Errorf("Records[%d] = %+v, want %+v", i, out.Records[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLookup_Timeout(t *testing.T) {
+	l := NewLookup(&fakeResolver{delay: time.Second}, 10*time.Millisecond)
+
+	_, _, err := l.Lookup(context.Background(), &mcp.CallToolRequest{}, Input{Name: "example.com"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("error = %v, want timeout", err)
+	}
+	if e := tools.AsError(err); e.Code != tools.CodeDeadlineExceeded || !e.Retryable {
+		t.Errorf("error = %+v, want a retryable deadline_exceeded", e)
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	if NewResolver(Config{}) != net.DefaultResolver {
+		t.Error("empty server should use the default resolver")
+	}
+	r, ok := NewResolver(Config{Server: "1.1.1.1"}).(*net.Resolver)
+	if !ok || r == net.DefaultResolver || !r.PreferGo || r.Dial == nil {
+		t.Error("custom server should build a dedicated Go resolver")
+	}
+}
@@ -0,0 +1,117 @@
+package encoding
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// MaxInputBytes caps the size of the data accepted by the tool.
+const MaxInputBytes = 10 << 20
+
+// codec encodes and decodes a single scheme.
+type codec struct {
+	encode func([]byte) string
+	decode func(string) ([]byte, error)
+}
+
+// codecs maps scheme names to their codec.
+var codecs = map[string]codec{
+	"base64":        base64Codec(base64.StdEncoding),
+	"base64_raw":    base64Codec(base64.RawStdEncoding),
+	"base64url":     base64Codec(base64.URLEncoding),
+	"base64url_raw": base64Codec(base64.RawURLEncoding),
+	"hex": {
+		encode: hex.EncodeToString,
+		decode: hex.DecodeString,
+	},
+	"url": {
+		encode: func(b []byte) string { return url.QueryEscape(string(b)) },
+		decode: func(s string) ([]byte, error) {
+			v, err := url.QueryUnescape(s)
+			return []byte(v), err
+		},
+	},
+	"url_path": {
+		encode: func(b []byte) string { return url.PathEscape(string(b)) },
+		decode: func(s string) ([]byte, error) {
+			v, err := url.PathUnescape(s)
+			return []byte(v), err
+		},
+	},
+}
+
+// Input is the input for the encode_decode tool.
+type Input struct {
+	Data      string `json:"data" jsonschema:"the data to encode or decode"`
+	Operation string `json:"operation" jsonschema:"encode or decode"`
+	Scheme    string `json:"scheme" jsonschema:"base64, base64_raw (no padding), base64url, base64url_raw, hex, url (query escaping), or url_path"`
+}
+
+// Output is the output of the encode_decode tool.
+type Output struct {
+	Result         string `json:"result" jsonschema:"the encoded or decoded data"`
+	ResultEncoding string `json:"result_encoding" jsonschema:"text, or base64 when decoded bytes are not valid UTF-8"`
+	Bytes          int    `json:"bytes" jsonschema:"length of the raw (unencoded) data in bytes"`
+}
+
+// EncodeDecode encodes or decodes data using the requested scheme.
+func EncodeDecode(_ context.Context, _ *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	if len(input.Data) > MaxInputBytes {
+		return nil, Output{}, tools.NewError(tools.CodeResourceExhausted, "data exceeds %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+
+	scheme := strings.ToLower(input.Scheme)
+	c, ok := codecs[scheme]
+	if !ok {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "unsupported scheme %q: use base64, base64_raw, base64url, base64url_raw, hex, url, or url_path", input.Scheme)
+	}
+
+	var out Output
+	switch strings.ToLower(input.Operation) {
+	case "encode":
+		out = Output{Result: c.encode([]byte(input.Data)), ResultEncoding: "text", Bytes: len(input.Data)}
+	case "decode":
+		decoded, err := c.decode(strings.TrimSpace(input.Data))
+		if err != nil {
+			return nil, Output{}, tools.WrapError(tools.CodeInvalidArgument, err, "invalid "+scheme+" input")
+		}
+		out = Output{Result: string(decoded), ResultEncoding: "text", Bytes: len(decoded)}
+		if !utf8.Valid(decoded) {
+			out.Result = base64.StdEncoding.EncodeToString(decoded)
+			out.ResultEncoding = "base64"
+		}
+	default:
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "unsupported operation %q: use encode or decode", input.Operation)
+	}
+
+	logger.Info("tool called", "tool", "encode_decode", "operation", input.Operation, "scheme", scheme, "bytes", out.Bytes)
+	return nil, out, nil
+}
+
+// base64Codec builds a codec from a base64 encoding.
+func base64Codec(enc *base64.Encoding) codec {
+	return codec{encode: enc.EncodeToString, decode: enc.DecodeString}
+}
+
+func init() {
+	tools.Register(tools.Namespace("data", func(server *mcp.Server) {
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "encode_decode",
+			Description: "Encode or decode data as base64 (standard/URL-safe, with or without padding), hex, or URL percent-encoding",
+			Annotations: tools.ReadOnly(false),
+		}, EncodeDecode)
+	}))
+}
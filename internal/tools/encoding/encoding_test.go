@@ -0,0 +1,70 @@
+package encoding
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        Input
+		wantResult   string
+		wantEncoding string
+		wantErr      string
+		wantCode     tools.Code
+	}{
+		{name: "base64 encode", input: Input{Data: "hello?", Operation: "encode", Scheme: "base64"}, wantResult: "aGVsbG8/", wantEncoding: "text"},
+		{name: "base64 decode", input: Input{Data: "aGVsbG8/", Operation: "decode", Scheme: "base64"}, wantResult: "hello?", wantEncoding: "text"},
+		{name: "base64 raw encode", input: Input{Data: "hi", Operation: "encode", Scheme: "base64_raw"}, wantResult: "aGk", wantEncoding: "text"},
+		{name: "base64url encode", input: Input{Data: "hello?", Operation: "encode", Scheme: "base64url"}, wantResult: "aGVsbG8_", wantEncoding: "text"},
+		{name: "base64url raw decode", input: Input{Data: "aGk", Operation: "decode", Scheme: "base64url_raw"}, wantResult: "hi", wantEncoding: "text"},
+		{name: "hex encode", input: Input{Data: "hi", Operation: "encode", Scheme: "hex"}, wantResult: "6869", wantEncoding: "text"},
+		{name: "hex decode binary", input: Input{Data: "ff00", Operation: "decode", Scheme: "hex"}, wantResult: "/wA=", wantEncoding: "base64"},
+		{name: "url encode", input: Input{Data: "a b&c", Operation: "encode", Scheme: "url"}, wantResult: "a+b%26c", wantEncoding: "text"},
+		{name: "url decode", input: Input{Data: "a+b%26c", Operation: "decode", Scheme: "url"}, wantResult: "a b&c", wantEncoding: "text"},
+		{name: "url path encode", input: Input{Data: "a b/c", Operation: "encode", Scheme: "url_path"}, wantResult: "a%20b%2Fc", wantEncoding: "text"},
+		{name: "case insensitive", input: Input{Data: "hi", Operation: "ENCODE", Scheme: "HEX"}, wantResult: "6869", wantEncoding: "text"},
+		{name: "invalid base64", input: Input{Data: "***", Operation: "decode", Scheme: "base64"}, wantErr: "invalid base64 input", wantCode: tools.CodeInvalidArgument},
+		{name: "invalid hex", input: Input{Data: "zz", Operation: "decode", Scheme: "hex"}, wantErr: "invalid hex input", wantCode: tools.CodeInvalidArgument},
+		{name: "invalid url", input: Input{Data: "%zz", Operation: "decode", Scheme: "url"}, wantErr: "invalid url input", wantCode: tools.CodeInvalidArgument},
+		{name: "unknown scheme", input: Input{Data: "x", Operation: "encode", Scheme: "rot13"}, wantErr: "unsupported scheme", wantCode: tools.CodeInvalidArgument},
+		{name: "unknown operation", input: Input{Data: "x", Operation: "reverse", Scheme: "hex"}, wantErr: "unsupported operation", wantCode: tools.CodeInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := EncodeDecode(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Result != tt.wantResult {
+				t.Errorf("Result = %q, want %q", out.Result, tt.wantResult)
+			}
+			if out.ResultEncoding != tt.wantEncoding {
+				t.Errorf("ResultEncoding = %q, want %q", out.ResultEncoding, tt.wantEncoding)
+			}
+		})
+	}
+}
+
+func TestEncodeDecode_InputTooLarge(t *testing.T) {
+	input := Input{Data: strings.Repeat("a", MaxInputBytes+1), Operation: "encode", Scheme: "hex"}
+	if _, _, err := EncodeDecode(context.Background(), &mcp.CallToolRequest{}, input); err == nil {
+		t.Fatal("expected error for oversized input")
+	}
+}
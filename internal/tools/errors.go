@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Code classifies a tool error so clients can react without parsing
+// messages.
+type Code string
+
+const (
+	CodeInvalidArgument   Code = "invalid_argument"
+	CodeNotFound          Code = "not_found"
+	CodePermissionDenied  Code = "permission_denied"
+	CodeResourceExhausted Code = "resource_exhausted"
+	CodeDeadlineExceeded  Code = "deadline_exceeded"
+	CodeCanceled          Code = "canceled"
+	CodeUnavailable       Code = "unavailable"
+	CodeInternal          Code = "internal"
+	// CodeUnknown is used for errors that were not created with NewError.
+	CodeUnknown Code = "unknown"
+)
+
+// Error is a structured tool error. Tools registered through AddTool
+// report it as an IsError result whose _meta.error holds these fields.
+type Error struct {
+	Code      Code           `json:"code"`
+	Message   string         `json:"message"`
+	Retryable bool           `json:"retryable"`
+	Details   map[string]any `json:"details,omitempty"`
+	// Err is the underlying cause; it is not sent to clients.
+	Err error `json:"-"`
+}
+
+// NewError creates an Error. Unavailable and deadline errors are marked
+// retryable; use Retry to override.
+func NewError(code Code, format string, args ...any) *Error {
+	return &Error{
+		Code:      code,
+		Message:   fmt.Sprintf(format, args...),
+		Retryable: code == CodeUnavailable || code == CodeDeadlineExceeded,
+	}
+}
+
+// WrapError creates an Error with err as its cause. The message is
+// msg followed by err's text.
+func WrapError(code Code, err error, msg string) *Error {
+	e := NewError(code, "%s: %v", msg, err)
+	e.Err = err
+	return e
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Retry sets whether the call may succeed if repeated unchanged.
+func (e *Error) Retry(retryable bool) *Error {
+	e.Retryable = retryable
+	return e
+}
+
+// WithDetail attaches a machine-readable detail such as a limit or a field
+// name.
+func (e *Error) WithDetail(key string, value any) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// AsError returns err as an *Error. Context errors map to their codes and
+// anything else becomes CodeUnknown with err's text as the message.
+func AsError(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Code: CodeDeadlineExceeded, Message: err.Error(), Retryable: true, Err: err}
+	case errors.Is(err, context.Canceled):
+		return &Error{Code: CodeCanceled, Message: err.Error(), Err: err}
+	default:
+		return &Error{Code: CodeUnknown, Message: err.Error(), Err: err}
+	}
+}
+
+// ErrorResult returns the result a tool failing with e reports, for
+// middleware that rejects a call before it reaches the tool.
+func ErrorResult(e *Error) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{&mcp.TextContent{Text: e.Error()}},
+		Meta:    mcp.Meta{"error": e},
+	}
+}
+
+// errorSlotKey is the context key for the *error slot used by
+// ErrorMiddleware to recover the structured error of a failed call.
+type errorSlotKey struct{}
+
+// NormalizeErrors is a Middleware that passes any error returned by the
+// tool through AsError. The SDK reports it as text content of the form
+// "code: message" and ErrorMiddleware adds the structured error under
+// _meta.error.
+func NormalizeErrors(_ string, next Handler) Handler {
+	return func(ctx context.Context, req *mcp.CallToolRequest, in any) (*mcp.CallToolResult, any, error) {
+		res, out, err := next(ctx, req, in)
+		if err == nil {
+			return res, out, nil
+		}
+		e := AsError(err)
+		if slot, ok := ctx.Value(errorSlotKey{}).(*error); ok {
+			*slot = e
+		}
+		return nil, out, e
+	}
+}
+
+// ErrorMiddleware attaches the structured error of a failed tools/call to
+// the result's _meta.error. RegisterAll installs it.
+func ErrorMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method != "tools/call" {
+			return next(ctx, method, req)
+		}
+		var slot error
+		result, err := next(context.WithValue(ctx, errorSlotKey{}, &slot), method, req)
+		if res, ok := result.(*mcp.CallToolResult); ok && res != nil && res.IsError && slot != nil {
+			if res.Meta == nil {
+				res.Meta = mcp.Meta{}
+			}
+			res.Meta["error"] = slot
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestAsError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantCode      Code
+		wantRetryable bool
+		wantText      string
+	}{
+		{
+			name:     "tool error",
+			err:      NewError(CodeNotFound, "key %q not found", "a"),
+			wantCode: CodeNotFound,
+			wantText: `not_found: key "a" not found`,
+		},
+		{
+			name:     "wrapped tool error",
+			err:      fmt.Errorf("outer: %w", NewError(CodeInvalidArgument, "bad")),
+			wantCode: CodeInvalidArgument,
+			wantText: "invalid_argument: bad",
+		},
+		{
+			name:          "unavailable is retryable",
+			err:           WrapError(CodeUnavailable, errors.New("connection refused"), "dial"),
+			wantCode:      CodeUnavailable,
+			wantRetryable: true,
+			wantText:      "unavailable: dial: connection refused",
+		},
+		{
+			name:     "retry override",
+			err:      NewError(CodeUnavailable, "down").Retry(false),
+			wantCode: CodeUnavailable,
+			wantText: "unavailable: down",
+		},
+		{
+			name:          "deadline",
+			err:           fmt.Errorf("query: %w", context.DeadlineExceeded),
+			wantCode:      CodeDeadlineExceeded,
+			wantRetryable: true,
+			wantText:      "deadline_exceeded: query: context deadline exceeded",
+		},
+		{
+			name:     "canceled",
+			err:      context.Canceled,
+			wantCode: CodeCanceled,
+			wantText: "canceled: context canceled",
+		},
+		{
+			name:     "plain error",
+			err:      errors.New("something broke"),
+			wantCode: CodeUnknown,
+			wantText: "unknown: something broke",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AsError(tt.err)
+			if got.Code != tt.wantCode {
+				t.Errorf("Code = %q, want %q", got.Code, tt.wantCode)
+			}
+			if got.Retryable != tt.wantRetryable {
+				t.Errorf("Retryable = %v, want %v", got.Retryable, tt.wantRetryable)
+			}
+			if got.Error() != tt.wantText {
+				t.Errorf("Error() = %q, want %q", got.Error(), tt.wantText)
+			}
+		})
+	}
+}
+
+func TestWrapErrorUnwrap(t *testing.T) {
+	cause := errors.New("cause")
+	if err := WrapError(CodeInternal, cause, "failed"); !errors.Is(err, cause) {
+		t.Errorf("errors.Is(WrapError(...), cause) = false, want true")
+	}
+}
+
+func TestAddToolErrors(t *testing.T) {
+	type in struct {
+		Fail bool `json:"fail"`
+	}
+	type out struct {
+		Items []string `json:"items"`
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	AddTool(server, &mcp.Tool{Name: "probe"}, func(_ context.Context, _ *mcp.CallToolRequest, input in) (*mcp.CallToolResult, out, error) {
+		if input.Fail {
+			return nil, out{}, NewError(CodeResourceExhausted, "too many items").WithDetail("limit", 10)
+		}
+		return nil, out{Items: []string{"a"}}, nil
+	})
+	server.AddReceivingMiddleware(ErrorMiddleware)
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	tests := []struct {
+		name      string
+		fail      bool
+		wantError bool
+		wantText  string
+	}{
+		{name: "success", fail: false, wantText: `{"items":["a"]}`},
+		{name: "tool error", fail: true, wantError: true, wantText: "resource_exhausted: too many items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "probe", Arguments: map[string]any{"fail": tt.fail}})
+			if err != nil {
+				t.Fatalf("CallTool: %v", err)
+			}
+			if res.IsError != tt.wantError {
+				t.Errorf("IsError = %v, want %v", res.IsError, tt.wantError)
+			}
+			if text := res.Content[0].(*mcp.TextContent).Text; text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if !tt.wantError {
+				return
+			}
+			meta, ok := res.Meta["error"].(map[string]any)
+			if !ok {
+				t.Fatalf("_meta.error = %v, want object", res.Meta["error"])
+			}
+			if meta["code"] != "resource_exhausted" || meta["retryable"] != false {
+				t.Errorf("_meta.error = %v", meta)
+			}
+			if details, _ := meta["details"].(map[string]any); details["limit"] != float64(10) {
+				t.Errorf("_meta.error.details = %v", meta["details"])
+			}
+		})
+	}
+
+	// Invalid arguments fail before the handler runs and surface as a
+	// protocol error rather than a tool result.
+	if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "probe", Arguments: map[string]any{"fail": "yes"}}); err == nil {
+		t.Errorf("CallTool with invalid arguments error = nil, want error")
+	}
+}
@@ -0,0 +1,555 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/capabilities"
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = tools.Logger()
+
+// clientRootsTimeout bounds the roots/list round trip to the client.
+const clientRootsTimeout = 5 * time.Second
+
+// Config controls which directories the filesystem tools may access and
+// which operations are exposed.
+type Config struct {
+	// Roots are the directories tools may operate within. When empty, no
+	// filesystem tools are registered.
+	Roots          []string
+	MaxReadBytes   int64
+	MaxWriteBytes  int64
+	MaxListEntries int
+	ReadEnabled    bool
+	WriteEnabled   bool
+	ListEnabled    bool
+	StatEnabled    bool
+}
+
+// LoadConfig reads the filesystem configuration from environment variables.
+func LoadConfig() Config {
+	return Config{
+		Roots:          config.GetEnvList("FS_ROOTS"),
+		MaxReadBytes:   int64(config.GetEnvInt("FS_MAX_READ_BYTES", 1<<20)),
+		MaxWriteBytes:  int64(config.GetEnvInt("FS_MAX_WRITE_BYTES", 1<<20)),
+		MaxListEntries: config.GetEnvInt("FS_MAX_LIST_ENTRIES", 1000),
+		ReadEnabled:    config.GetEnvBool("FS_READ_ENABLED", true),
+		WriteEnabled:   config.GetEnvBool("FS_WRITE_ENABLED", false),
+		ListEnabled:    config.GetEnvBool("FS_LIST_ENABLED", true),
+		StatEnabled:    config.GetEnvBool("FS_STAT_ENABLED", true),
+	}
+}
+
+// Settings are the fs section of the tool config file, shared by the
+// filesystem tools.
+type Settings struct {
+	// Roots replace FS_ROOTS; an empty list denies every path.
+	Roots []string `yaml:"roots"`
+}
+
+var settings = tools.NewSettings[Settings]("fs")
+
+// root is an allow-listed directory opened as a traversal-safe os.Root.
+type root struct {
+	path string
+	dir  *os.Root
+}
+
+// FS implements the filesystem tools over a set of allow-listed roots.
+type FS struct {
+	cfg Config
+
+	mu        sync.Mutex
+	roots     []root
+	rootPaths []string // the paths roots were opened from
+}
+
+// New opens the configured roots.
+func New(cfg Config) (*FS, error) {
+	roots, err := openRoots(cfg.Roots)
+	if err != nil {
+		return nil, err
+	}
+	return &FS{cfg: cfg, roots: roots, rootPaths: cfg.Roots}, nil
+}
+
+// openRoots opens paths as roots. Paths are made absolute and symlinks are
+// resolved so that containment checks compare canonical paths.
+func openRoots(paths []string) ([]root, error) {
+	var roots []root
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err == nil {
+			abs, err = filepath.EvalSymlinks(abs)
+		}
+		var dir *os.Root
+		if err == nil {
+			dir, err = os.OpenRoot(abs)
+		}
+		if err != nil {
+			_ = closeRoots(roots)
+			return nil, fmt.Errorf("root %q: %w", p, err)
+		}
+		roots = append(roots, root{path: abs, dir: dir})
+	}
+	return roots, nil
+}
+
+func closeRoots(roots []root) error {
+	var errs []error
+	for _, r := range roots {
+		errs = append(errs, r.dir.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// currentRoots returns the roots, reopening them first when the fs section
+// of the tool config file has changed them. Roots that fail to open are
+// logged and the previous ones kept until a later call opens them.
+func (f *FS) currentRoots() []root {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	want := f.cfg.Roots
+	if s, ok := settings.Get(); ok && s.Roots != nil {
+		want = s.Roots
+	}
+	if slices.Equal(want, f.rootPaths) {
+		return f.roots
+	}
+	roots, err := openRoots(want)
+	if err != nil {
+		// rootPaths still names the roots served, so the next call retries
+		logger.Error("filesystem roots not reloaded; keeping the previous roots", "roots", want, "error", err)
+		return f.roots
+	}
+	old := f.roots
+	f.roots, f.rootPaths = roots, want
+	// Calls in flight may still be using the old roots
+	time.AfterFunc(time.Minute, func() { _ = closeRoots(old) })
+	logger.Info("filesystem roots reloaded", "roots", want)
+	return roots
+}
+
+// Close releases the opened roots.
+func (f *FS) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return closeRoots(f.roots)
+}
+
+// ReadInput is the input for the read_file tool.
+type ReadInput struct {
+	Path     string `json:"path" jsonschema:"absolute path, or a path relative to the first allowed root"`
+	Offset   int64  `json:"offset,omitempty" jsonschema:"byte offset to start reading from"`
+	MaxBytes int64  `json:"max_bytes,omitempty" jsonschema:"maximum bytes to return; capped by the server limit"`
+}
+
+// ReadOutput is the output of the read_file tool.
+type ReadOutput struct {
+	Path      string `json:"path" jsonschema:"the resolved absolute path"`
+	Content   string `json:"content" jsonschema:"the file content"`
+	Encoding  string `json:"encoding" jsonschema:"text; base64 when the content is not valid UTF-8; or image or audio when the whole file is returned as an image or audio block instead of here"`
+	MIMEType  string `json:"mime_type,omitempty" jsonschema:"the detected media type of image and audio files"`
+	Size      int64  `json:"size" jsonschema:"the total file size in bytes"`
+	Truncated bool   `json:"truncated" jsonschema:"whether more content remains after the returned bytes"`
+}
+
+// WriteInput is the input for the write_file tool.
+type WriteInput struct {
+	Path       string `json:"path" jsonschema:"absolute path, or a path relative to the first allowed root"`
+	Content    string `json:"content" jsonschema:"the content to write"`
+	Encoding   string `json:"encoding,omitempty" jsonschema:"content encoding: text (default) or base64"`
+	Append     bool   `json:"append,omitempty" jsonschema:"append instead of overwriting"`
+	CreateDirs bool   `json:"create_dirs,omitempty" jsonschema:"create missing parent directories"`
+}
+
+// WriteOutput is the output of the write_file tool.
+type WriteOutput struct {
+	Path         string `json:"path" jsonschema:"the resolved absolute path"`
+	BytesWritten int    `json:"bytes_written" jsonschema:"number of bytes written"`
+}
+
+// PathInput is the input for tools that take only a path.
+type PathInput struct {
+	Path string `json:"path" jsonschema:"absolute path, or a path relative to the first allowed root"`
+}
+
+// Entry describes a file or directory.
+type Entry struct {
+	Name    string `json:"name" jsonschema:"the base name"`
+	Type    string `json:"type" jsonschema:"file, directory, symlink, or other"`
+	Size    int64  `json:"size" jsonschema:"size in bytes"`
+	Mode    string `json:"mode" jsonschema:"permission bits, e.g. -rw-r--r--"`
+	ModTime string `json:"mod_time" jsonschema:"last modification time as RFC 3339"`
+}
+
+// ListOutput is the output of the list_directory tool.
+type ListOutput struct {
+	Path      string  `json:"path" jsonschema:"the resolved absolute path"`
+	Entries   []Entry `json:"entries" jsonschema:"directory entries sorted by name"`
+	Truncated bool    `json:"truncated" jsonschema:"whether entries were omitted due to the listing limit"`
+}
+
+// StatOutput is the output of the file_stat tool.
+type StatOutput struct {
+	Path string `json:"path" jsonschema:"the resolved absolute path"`
+	Entry
+}
+
+// ReadFile reads a file within the allowed roots.
+func (f *FS) ReadFile(ctx context.Context, req *mcp.CallToolRequest, input ReadInput) (*mcp.CallToolResult, ReadOutput, error) {
+	r, rel, abs, err := f.resolve(ctx, req, input.Path)
+	if err != nil {
+		return nil, ReadOutput{}, err
+	}
+
+	file, err := r.dir.Open(rel)
+	if err != nil {
+		return nil, ReadOutput{}, pathError(input.Path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, ReadOutput{}, pathError(input.Path, err)
+	}
+	if info.IsDir() {
+		return nil, ReadOutput{}, tools.NewError(tools.CodeInvalidArgument, "%s is a directory", input.Path)
+	}
+	if input.Offset < 0 {
+		return nil, ReadOutput{}, tools.NewError(tools.CodeInvalidArgument, "offset must not be negative")
+	}
+	if input.Offset > 0 {
+		if _, err := file.Seek(input.Offset, io.SeekStart); err != nil {
+			return nil, ReadOutput{}, pathError(input.Path, err)
+		}
+	}
+
+	limit := f.cfg.MaxReadBytes
+	if input.MaxBytes > 0 && input.MaxBytes < limit {
+		limit = input.MaxBytes
+	}
+	data, err := io.ReadAll(io.LimitReader(file, limit))
+	if err != nil {
+		return nil, ReadOutput{}, pathError(input.Path, err)
+	}
+
+	out := ReadOutput{
+		Path:      abs,
+		Content:   string(data),
+		Encoding:  "text",
+		Size:      info.Size(),
+		Truncated: input.Offset+int64(len(data)) < info.Size(),
+	}
+	// A whole image or audio file goes in its own block, which clients
+	// can show, rather than as base64 text
+	if kind, mimeType := media(data); kind != "" && input.Offset == 0 && !out.Truncated {
+		out.Content, out.Encoding, out.MIMEType = "", kind, mimeType
+		b := tools.NewResult().JSON(out)
+		if kind == "image" {
+			b.Image(data, mimeType)
+		} else {
+			b.Audio(data, mimeType)
+		}
+		res, err := b.Build()
+		logger.Info("tool called", "tool", "read_file", "path", abs, "bytes", len(data), "mime_type", mimeType)
+		return res, out, err
+	}
+	if !utf8.Valid(data) {
+		out.Content = base64.StdEncoding.EncodeToString(data)
+		out.Encoding = "base64"
+	}
+
+	logger.Info("tool called", "tool", "read_file", "path", abs, "bytes", len(data))
+	return nil, out, nil
+}
+
+// media returns "image" or "audio" and the media type of data if it is
+// an image or audio file, or empty strings otherwise.
+func media(data []byte) (string, string) {
+	mimeType := http.DetectContentType(data)
+	if kind, _, _ := strings.Cut(mimeType, "/"); kind == "image" || kind == "audio" {
+		return kind, mimeType
+	}
+	return "", ""
+}
+
+// WriteFile writes a file within the allowed roots.
+func (f *FS) WriteFile(ctx context.Context, req *mcp.CallToolRequest, input WriteInput) (*mcp.CallToolResult, WriteOutput, error) {
+	r, rel, abs, err := f.resolve(ctx, req, input.Path)
+	if err != nil {
+		return nil, WriteOutput{}, err
+	}
+	if rel == "." {
+		return nil, WriteOutput{}, tools.NewError(tools.CodePermissionDenied, "cannot write to a root directory")
+	}
+
+	var data []byte
+	switch strings.ToLower(input.Encoding) {
+	case "", "text":
+		data = []byte(input.Content)
+	case "base64":
+		if data, err = base64.StdEncoding.DecodeString(input.Content); err != nil {
+			return nil, WriteOutput{}, tools.WrapError(tools.CodeInvalidArgument, err, "invalid base64 content")
+		}
+	default:
+		return nil, WriteOutput{}, tools.NewError(tools.CodeInvalidArgument, "unsupported encoding %q: use text or base64", input.Encoding)
+	}
+	if int64(len(data)) > f.cfg.MaxWriteBytes {
+		return nil, WriteOutput{}, tools.NewError(tools.CodeResourceExhausted, "content exceeds %d bytes", f.cfg.MaxWriteBytes).
+			WithDetail("max_bytes", f.cfg.MaxWriteBytes)
+	}
+
+	if input.CreateDirs {
+		if err := r.dir.MkdirAll(filepath.Dir(rel), 0o755); err != nil {
+			return nil, WriteOutput{}, pathError(input.Path, err)
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if input.Append {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		if info, err := r.dir.Stat(rel); err == nil && info.Size()+int64(len(data)) > f.cfg.MaxWriteBytes {
+			return nil, WriteOutput{}, tools.NewError(tools.CodeResourceExhausted, "appending would grow the file beyond %d bytes", f.cfg.MaxWriteBytes).
+				WithDetail("max_bytes", f.cfg.MaxWriteBytes)
+		}
+	}
+	file, err := r.dir.OpenFile(rel, flags, 0o644)
+	if err != nil {
+		return nil, WriteOutput{}, pathError(input.Path, err)
+	}
+	n, err := file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, WriteOutput{}, pathError(input.Path, err)
+	}
+
+	logger.Info("tool called", "tool", "write_file", "path", abs, "bytes", n, "append", input.Append)
+	return nil, WriteOutput{Path: abs, BytesWritten: n}, nil
+}
+
+// ListDirectory lists a directory within the allowed roots.
+func (f *FS) ListDirectory(ctx context.Context, req *mcp.CallToolRequest, input PathInput) (*mcp.CallToolResult, ListOutput, error) {
+	r, rel, abs, err := f.resolve(ctx, req, input.Path)
+	if err != nil {
+		return nil, ListOutput{}, err
+	}
+
+	dir, err := r.dir.Open(rel)
+	if err != nil {
+		return nil, ListOutput{}, pathError(input.Path, err)
+	}
+	defer func() { _ = dir.Close() }()
+
+	// Read one past the limit to detect truncation without listing everything.
+	dirEntries, err := dir.ReadDir(f.cfg.MaxListEntries + 1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, ListOutput{}, pathError(input.Path, err)
+	}
+
+	out := ListOutput{Path: abs, Entries: []Entry{}}
+	if len(dirEntries) > f.cfg.MaxListEntries {
+		dirEntries = dirEntries[:f.cfg.MaxListEntries]
+		out.Truncated = true
+	}
+	for _, de := range dirEntries {
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		out.Entries = append(out.Entries, newEntry(info))
+	}
+	slices.SortFunc(out.Entries, func(a, b Entry) int { return strings.Compare(a.Name, b.Name) })
+
+	logger.Info("tool called", "tool", "list_directory", "path", abs, "entries", len(out.Entries))
+	return nil, out, nil
+}
+
+// Stat describes a file or directory within the allowed roots.
+func (f *FS) Stat(ctx context.Context, req *mcp.CallToolRequest, input PathInput) (*mcp.CallToolResult, StatOutput, error) {
+	r, rel, abs, err := f.resolve(ctx, req, input.Path)
+	if err != nil {
+		return nil, StatOutput{}, err
+	}
+
+	info, err := r.dir.Lstat(rel)
+	if err != nil {
+		return nil, StatOutput{}, pathError(input.Path, err)
+	}
+
+	logger.Info("tool called", "tool", "file_stat", "path", abs)
+	return nil, StatOutput{Path: abs, Entry: newEntry(info)}, nil
+}
+
+// resolve maps a requested path to an allowed root and a path relative to
+// it. Lexical escapes are rejected here; os.Root additionally rejects
+// symlinks that point outside the root at access time.
+func (f *FS) resolve(ctx context.Context, req *mcp.CallToolRequest, path string) (*root, string, string, error) {
+	if path == "" {
+		return nil, "", "", tools.NewError(tools.CodeInvalidArgument, "path is required")
+	}
+	roots := f.currentRoots()
+	if len(roots) == 0 {
+		return nil, "", "", tools.NewError(tools.CodePermissionDenied, "no filesystem roots are configured")
+	}
+
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(roots[0].path, abs)
+	}
+	abs = filepath.Clean(abs)
+
+	for i := range roots {
+		r := &roots[i]
+		if rel, ok := within(r.path, abs); ok {
+			if err := f.checkClientRoots(ctx, req, abs); err != nil {
+				return nil, "", "", err
+			}
+			return r, rel, abs, nil
+		}
+	}
+	return nil, "", "", tools.NewError(tools.CodePermissionDenied, "path %s is outside the allowed roots", path)
+}
+
+// checkClientRoots enforces the roots advertised by the MCP client, if any.
+// Clients that do not support roots/list leave only the server roots in effect,
+// and are not asked when they declared as much at initialize.
+func (f *FS) checkClientRoots(ctx context.Context, req *mcp.CallToolRequest, abs string) error {
+	if req == nil || req.Session == nil {
+		return nil
+	}
+	if c := capabilities.FromContext(ctx); c != nil && !c.Roots() {
+		return nil
+	}
+
+	rootsCtx, cancel := context.WithTimeout(ctx, clientRootsTimeout)
+	defer cancel()
+	res, err := req.Session.ListRoots(rootsCtx, nil)
+	if err != nil {
+		logger.WarnContext(ctx, "client roots unavailable; only the server roots apply", "error", err)
+		return nil
+	}
+	if len(res.Roots) == 0 {
+		return nil
+	}
+
+	for _, cr := range res.Roots {
+		u, err := url.Parse(cr.URI)
+		if err != nil || u.Scheme != "file" {
+			continue
+		}
+		if _, ok := within(filepath.Clean(u.Path), abs); ok {
+			return nil
+		}
+	}
+	return tools.NewError(tools.CodePermissionDenied, "path %s is outside the client's roots", abs)
+}
+
+// within reports whether target is base or beneath it, returning the
+// relative path.
+func within(base, target string) (string, bool) {
+	rel, err := filepath.Rel(base, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// newEntry converts file info into an Entry.
+func newEntry(info fs.FileInfo) Entry {
+	kind := "other"
+	switch {
+	case info.Mode().IsRegular():
+		kind = "file"
+	case info.IsDir():
+		kind = "directory"
+	case info.Mode()&fs.ModeSymlink != 0:
+		kind = "symlink"
+	}
+	return Entry{
+		Name:    info.Name(),
+		Type:    kind,
+		Size:    info.Size(),
+		Mode:    info.Mode().Perm().String(),
+		ModTime: info.ModTime().UTC().Format(time.RFC3339),
+	}
+}
+
+// pathError converts filesystem errors into messages that do not leak
+// details beyond the requested path.
+func pathError(path string, err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return tools.NewError(tools.CodeNotFound, "%s does not exist", path)
+	case errors.Is(err, fs.ErrPermission):
+		return tools.NewError(tools.CodePermissionDenied, "permission denied: %s", path)
+	case strings.Contains(err.Error(), "path escapes from parent"):
+		return tools.NewError(tools.CodePermissionDenied, "path %s escapes the allowed root", path)
+	default:
+		return tools.WrapError(tools.CodeInternal, err, path)
+	}
+}
+
+func init() {
+	tools.RegisterConfig(func() { LoadConfig() })
+	tools.Register(tools.Namespace("fs", func(server *mcp.Server) {
+		cfg := LoadConfig()
+		if s, ok := settings.Get(); len(cfg.Roots) == 0 && (!ok || len(s.Roots) == 0) {
+			return
+		}
+		f, err := New(cfg)
+		if err != nil {
+			logger.Error("filesystem tools disabled", "error", err)
+			return
+		}
+
+		if cfg.ReadEnabled {
+			tools.AddTool(server, &mcp.Tool{
+				Name:        "read_file",
+				Description: "Read a file within the server's allowed directories",
+				Annotations: tools.ReadOnly(false),
+			}, f.ReadFile)
+		}
+		if cfg.WriteEnabled {
+			tools.AddTool(server, &mcp.Tool{
+				Name:        "write_file",
+				Description: "Write or append to a file within the server's allowed directories",
+				Annotations: tools.Destructive(false, false),
+			}, f.WriteFile)
+		}
+		if cfg.ListEnabled {
+			tools.AddTool(server, &mcp.Tool{
+				Name:        "list_directory",
+				Description: "List the entries of a directory within the server's allowed directories",
+				Annotations: tools.ReadOnly(false),
+			}, f.ListDirectory)
+		}
+		if cfg.StatEnabled {
+			tools.AddTool(server, &mcp.Tool{
+				Name:        "file_stat",
+				Description: "Get type, size, permissions, and modification time of a path within the server's allowed directories",
+				Annotations: tools.ReadOnly(false),
+			}, f.Stat)
+		}
+	}))
+}
@@ -0,0 +1,439 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/capabilities"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func newTestFS(t *testing.T) (*FS, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "binary.bin"), []byte{0xff, 0xfe, 0x00}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := New(Config{
+		Roots:          []string{dir},
+		MaxReadBytes:   5,
+		MaxWriteBytes:  16,
+		MaxListEntries: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = f.Close() })
+
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, dir
+}
+
+func TestReadFile(t *testing.T) {
+	f, dir := newTestFS(t)
+
+	tests := []struct {
+		name          string
+		input         ReadInput
+		wantContent   string
+		wantEncoding  string
+		wantTruncated bool
+		wantErr       string
+		wantCode      tools.Code
+	}{
+		{
+			name:          "relative path truncated at limit",
+			input:         ReadInput{Path: "hello.txt"},
+			wantContent:   "hello",
+			wantEncoding:  "text",
+			wantTruncated: true,
+		},
+		{
+			name:         "absolute path with offset",
+			input:        ReadInput{Path: filepath.Join(dir, "hello.txt"), Offset: 6},
+			wantContent:  "world",
+			wantEncoding: "text",
+		},
+		{
+			name:          "max bytes below limit",
+			input:         ReadInput{Path: "hello.txt", MaxBytes: 2},
+			wantContent:   "he",
+			wantEncoding:  "text",
+			wantTruncated: true,
+		},
+		{
+			name:         "binary content",
+			input:        ReadInput{Path: "binary.bin"},
+			wantContent:  "//4A",
+			wantEncoding: "base64",
+		},
+		{
+			name:     "traversal rejected",
+			input:    ReadInput{Path: "../outside.txt"},
+			wantErr:  "outside the allowed roots",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name:     "absolute path outside root",
+			input:    ReadInput{Path: "/etc/passwd"},
+			wantErr:  "outside the allowed roots",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name:     "missing file",
+			input:    ReadInput{Path: "missing.txt"},
+			wantErr:  "does not exist",
+			wantCode: tools.CodeNotFound,
+		},
+		{
+			name:     "directory",
+			input:    ReadInput{Path: "sub"},
+			wantErr:  "is a directory",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name:     "empty path",
+			input:    ReadInput{},
+			wantErr:  "path is required",
+			wantCode: tools.CodeInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := f.ReadFile(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", out.Content, tt.wantContent)
+			}
+			if out.Encoding != tt.wantEncoding {
+				t.Errorf("Encoding = %q, want %q", out.Encoding, tt.wantEncoding)
+			}
+			if out.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", out.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestReadFile_Image(t *testing.T) {
+	dir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	if err := os.WriteFile(filepath.Join(dir, "pixel.png"), png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := New(Config{Roots: []string{dir}, MaxReadBytes: 1 << 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	tests := []struct {
+		name         string
+		input        ReadInput
+		wantEncoding string
+		wantImage    bool
+	}{
+		{name: "whole image", input: ReadInput{Path: "pixel.png"}, wantEncoding: "image", wantImage: true},
+		{name: "part of an image", input: ReadInput{Path: "pixel.png", MaxBytes: 8}, wantEncoding: "base64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, out, err := f.ReadFile(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Encoding != tt.wantEncoding {
+				t.Errorf("Encoding = %q, want %q", out.Encoding, tt.wantEncoding)
+			}
+			if !tt.wantImage {
+				if res != nil {
+					t.Errorf("result = %+v, want nil", res)
+				}
+				return
+			}
+			if out.Content != "" || out.MIMEType != "image/png" {
+				t.Errorf("Content = %q, MIMEType = %q; want the image in its own block", out.Content, out.MIMEType)
+			}
+			if res == nil || len(res.Content) != 2 {
+				t.Fatalf("result = %+v, want text and image blocks", res)
+			}
+			img, ok := res.Content[1].(*mcp.ImageContent)
+			if !ok || string(img.Data) != string(png) || img.MIMEType != "image/png" {
+				t.Errorf("content[1] = %#v, want the PNG", res.Content[1])
+			}
+		})
+	}
+}
+
+func TestReadFile_SymlinkEscape(t *testing.T) {
+	f, dir := newTestFS(t)
+
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	_, _, err := f.ReadFile(context.Background(), &mcp.CallToolRequest{}, ReadInput{Path: "link.txt"})
+	if err == nil || !strings.Contains(err.Error(), "escapes the allowed root") {
+		t.Fatalf("error = %v, want escape error", err)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	f, dir := newTestFS(t)
+
+	tests := []struct {
+		name     string
+		input    WriteInput
+		want     string
+		wantErr  string
+		wantCode tools.Code
+	}{
+		{
+			name:  "overwrite",
+			input: WriteInput{Path: "hello.txt", Content: "bye"},
+			want:  "bye",
+		},
+		{
+			name:  "append",
+			input: WriteInput{Path: "hello.txt", Content: "!", Append: true},
+			want:  "bye!",
+		},
+		{
+			name:  "base64",
+			input: WriteInput{Path: "b64.txt", Content: "aGk=", Encoding: "base64"},
+			want:  "hi",
+		},
+		{
+			name:  "create dirs",
+			input: WriteInput{Path: "a/b/c.txt", Content: "deep", CreateDirs: true},
+			want:  "deep",
+		},
+		{
+			name:     "missing parent",
+			input:    WriteInput{Path: "x/y.txt", Content: "nope"},
+			wantErr:  "does not exist",
+			wantCode: tools.CodeNotFound,
+		},
+		{
+			name:     "too large",
+			input:    WriteInput{Path: "big.txt", Content: strings.Repeat("a", 17)},
+			wantErr:  "exceeds 16 bytes",
+			wantCode: tools.CodeResourceExhausted,
+		},
+		{
+			name:     "append beyond limit",
+			input:    WriteInput{Path: "hello.txt", Content: strings.Repeat("a", 13), Append: true},
+			wantErr:  "beyond 16 bytes",
+			wantCode: tools.CodeResourceExhausted,
+		},
+		{
+			name:     "traversal",
+			input:    WriteInput{Path: "../escape.txt", Content: "x"},
+			wantErr:  "outside the allowed roots",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name:     "root itself",
+			input:    WriteInput{Path: dir, Content: "x"},
+			wantErr:  "root directory",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name:     "bad encoding",
+			input:    WriteInput{Path: "e.txt", Content: "x", Encoding: "hex"},
+			wantErr:  "unsupported encoding",
+			wantCode: tools.CodeInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := f.WriteFile(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := os.ReadFile(out.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("file content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListDirectory(t *testing.T) {
+	f, _ := newTestFS(t)
+
+	_, out, err := f.ListDirectory(context.Background(), &mcp.CallToolRequest{}, PathInput{Path: "."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Entries) != 2 || !out.Truncated {
+		t.Fatalf("got %d entries (truncated=%v), want 2 truncated", len(out.Entries), out.Truncated)
+	}
+
+	_, _, err = f.ListDirectory(context.Background(), &mcp.CallToolRequest{}, PathInput{Path: ".."})
+	if err == nil || !strings.Contains(err.Error(), "outside the allowed roots") {
+		t.Fatalf("error = %v, want outside roots", err)
+	}
+}
+
+func TestStat(t *testing.T) {
+	f, _ := newTestFS(t)
+
+	tests := []struct {
+		path     string
+		wantType string
+		wantSize int64
+	}{
+		{path: "hello.txt", wantType: "file", wantSize: 11},
+		{path: "sub", wantType: "directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, out, err := f.Stat(context.Background(), &mcp.CallToolRequest{}, PathInput{Path: tt.path})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Type != tt.wantType {
+				t.Errorf("Type = %q, want %q", out.Type, tt.wantType)
+			}
+			if tt.wantType == "file" && out.Size != tt.wantSize {
+				t.Errorf("Size = %d, want %d", out.Size, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		base, target string
+		wantRel      string
+		wantOK       bool
+	}{
+		{"/data", "/data", ".", true},
+		{"/data", "/data/a/b", "a/b", true},
+		{"/data", "/data2/a", "", false},
+		{"/data", "/", "", false},
+		{"/data", "/data/..foo", "..foo", true},
+	}
+
+	for _, tt := range tests {
+		rel, ok := within(tt.base, tt.target)
+		if rel != tt.wantRel || ok != tt.wantOK {
+			t.Errorf("within(%q, %q) = %q, %v; want %q, %v", tt.base, tt.target, rel, ok, tt.wantRel, tt.wantOK)
+		}
+	}
+}
+
+func TestCheckClientRoots_NotDeclared(t *testing.T) {
+	f, dir := newTestFS(t)
+	// A zero session would fail roots/list; it must not be asked
+	ctx := capabilities.NewContext(context.Background(), &capabilities.Client{Capabilities: &mcp.ClientCapabilities{}})
+	req := &mcp.CallToolRequest{Session: &mcp.ServerSession{}}
+	if err := f.checkClientRoots(ctx, req, filepath.Join(dir, "hello.txt")); err != nil {
+		t.Errorf("checkClientRoots() error = %v, want nil", err)
+	}
+}
+
+func TestNew_MissingRoot(t *testing.T) {
+	_, err := New(Config{Roots: []string{filepath.Join(t.TempDir(), "missing")}})
+	if err == nil {
+		t.Fatal("expected error for missing root")
+	}
+}
+
+func TestRootsReload(t *testing.T) {
+	f, _ := newTestFS(t)
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "other.txt"), []byte("other"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "tools.yaml")
+	load := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := tools.LoadSettings(path); err != nil {
+			t.Fatalf("LoadSettings() error = %v", err)
+		}
+	}
+	t.Cleanup(func() { load("{}") })
+
+	tests := []struct {
+		name     string
+		settings string
+		path     string
+		create   bool
+		wantErr  bool
+	}{
+		{name: "environment roots", settings: "{}", path: "hello.txt"},
+		{name: "file roots", settings: "fs:\n  roots: [" + other + "]\n", path: "other.txt"},
+		{name: "old root gone", settings: "fs:\n  roots: [" + other + "]\n", path: "hello.txt", wantErr: true},
+		{name: "missing root keeps previous", settings: "fs:\n  roots: [" + filepath.Join(other, "missing") + "]\n", path: "other.txt"},
+		{name: "missing root retried once created", settings: "fs:\n  roots: [" + filepath.Join(other, "missing") + "]\n", path: "late.txt", create: true},
+		{name: "section removed", settings: "{}", path: "hello.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load(tt.settings)
+			if tt.create {
+				dir := filepath.Join(other, "missing")
+				if err := os.Mkdir(dir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "late.txt"), []byte("late"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, _, err := f.Stat(context.Background(), &mcp.CallToolRequest{}, PathInput{Path: tt.path})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Stat(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,513 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// MaxLogCount caps the number of commits git_log returns.
+const MaxLogCount = 200
+
+// revPattern matches revisions such as HEAD~2, main, v1.2.3, a1b2c3d, and
+// HEAD@{1}. A leading "-" is rejected separately so that revisions can never
+// be parsed as options.
+var revPattern = regexp.MustCompile(`^[A-Za-z0-9._/~^@{}-]+$`)
+
+// Config controls which repositories and paths the git tools may read.
+type Config struct {
+	// Repos lists repository working-tree paths. An empty list disables
+	// the tools.
+	Repos []string
+	// AllowedPaths restricts tools to these repository-relative path
+	// prefixes. An empty list allows the whole repository.
+	AllowedPaths   []string
+	Binary         string
+	MaxOutputBytes int
+	Timeout        time.Duration
+}
+
+// LoadConfig reads the git configuration from environment variables.
+func LoadConfig() Config {
+	return Config{
+		Repos:          config.GetEnvList("GIT_REPOS"),
+		AllowedPaths:   config.GetEnvList("GIT_ALLOWED_PATHS"),
+		Binary:         config.GetEnv("GIT_BINARY", "git"),
+		MaxOutputBytes: config.GetEnvInt("GIT_MAX_OUTPUT_BYTES", 256<<10),
+		Timeout:        config.GetEnvDuration("GIT_TIMEOUT", 30*time.Second),
+	}
+}
+
+// LogInput is the input for the git_log tool.
+type LogInput struct {
+	Repo     string `json:"repo,omitempty" jsonschema:"repository name or path; defaults to the first configured repository"`
+	Rev      string `json:"rev,omitempty" jsonschema:"revision or range to list, e.g. main or v1.0..HEAD (default HEAD)"`
+	Path     string `json:"path,omitempty" jsonschema:"only list commits touching this path"`
+	MaxCount int    `json:"max_count,omitempty" jsonschema:"maximum commits to return (default 20, max 200)"`
+}
+
+// Commit summarizes a commit.
+type Commit struct {
+	Hash    string `json:"hash" jsonschema:"the full commit hash"`
+	Author  string `json:"author" jsonschema:"the author name"`
+	Email   string `json:"email" jsonschema:"the author email"`
+	Date    string `json:"date" jsonschema:"the author date as RFC 3339"`
+	Subject string `json:"subject" jsonschema:"the first line of the commit message"`
+}
+
+// LogOutput is the output of the git_log tool.
+type LogOutput struct {
+	Commits []Commit `json:"commits" jsonschema:"commits, newest first"`
+}
+
+// ShowInput is the input for the git_show tool.
+type ShowInput struct {
+	Repo string `json:"repo,omitempty" jsonschema:"repository name or path; defaults to the first configured repository"`
+	Rev  string `json:"rev" jsonschema:"the commit to show"`
+	Path string `json:"path,omitempty" jsonschema:"show this file's content at the revision instead of the commit"`
+}
+
+// DiffInput is the input for the git_diff tool.
+type DiffInput struct {
+	Repo string `json:"repo,omitempty" jsonschema:"repository name or path; defaults to the first configured repository"`
+	From string `json:"from,omitempty" jsonschema:"base revision; empty diffs the working tree against the index"`
+	To   string `json:"to,omitempty" jsonschema:"target revision; empty compares against the working tree"`
+	Path string `json:"path,omitempty" jsonschema:"limit the diff to this path"`
+	Stat bool   `json:"stat,omitempty" jsonschema:"return a diffstat summary instead of the patch"`
+}
+
+// TextOutput is the output of the git_show and git_diff tools.
+type TextOutput struct {
+	Output    string `json:"output" jsonschema:"the git output, possibly truncated"`
+	Truncated bool   `json:"truncated" jsonschema:"whether the output was cut off at the size limit"`
+}
+
+// BlameInput is the input for the git_blame tool.
+type BlameInput struct {
+	Repo      string `json:"repo,omitempty" jsonschema:"repository name or path; defaults to the first configured repository"`
+	Path      string `json:"path" jsonschema:"the file to annotate"`
+	Rev       string `json:"rev,omitempty" jsonschema:"annotate the file as of this revision (default HEAD)"`
+	StartLine int    `json:"start_line,omitempty" jsonschema:"first line to annotate (1-based)"`
+	EndLine   int    `json:"end_line,omitempty" jsonschema:"last line to annotate"`
+}
+
+// BlameLine attributes a line to the commit that last changed it.
+type BlameLine struct {
+	Line    int    `json:"line" jsonschema:"the line number"`
+	Hash    string `json:"hash" jsonschema:"the commit that last changed the line"`
+	Author  string `json:"author" jsonschema:"the author of that commit"`
+	Date    string `json:"date" jsonschema:"the author date as RFC 3339"`
+	Content string `json:"content" jsonschema:"the line content"`
+}
+
+// BlameOutput is the output of the git_blame tool.
+type BlameOutput struct {
+	Lines     []BlameLine `json:"lines" jsonschema:"annotated lines"`
+	Truncated bool        `json:"truncated" jsonschema:"whether lines were omitted due to the output limit"`
+}
+
+// Git runs read-only git commands against configured repositories.
+type Git struct {
+	cfg   Config
+	repos []string
+}
+
+// New resolves the configured repositories, failing if any is not a
+// directory.
+func New(cfg Config) (*Git, error) {
+	g := &Git{cfg: cfg}
+	for _, r := range cfg.Repos {
+		abs, err := filepath.Abs(r)
+		if err != nil {
+			return nil, fmt.Errorf("repo %q: %w", r, err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("repo %q: %w", r, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("repo %q is not a directory", r)
+		}
+		g.repos = append(g.repos, abs)
+	}
+	return g, nil
+}
+
+// Log lists commits.
+func (g *Git) Log(ctx context.Context, req *mcp.CallToolRequest, input LogInput) (*mcp.CallToolResult, LogOutput, error) {
+	repo, err := g.repo(input.Repo)
+	if err != nil {
+		return nil, LogOutput{}, err
+	}
+	rev := input.Rev
+	if rev == "" {
+		rev = "HEAD"
+	}
+	if err := checkRev(rev); err != nil {
+		return nil, LogOutput{}, err
+	}
+	pathspecs, err := g.pathspecs(input.Path)
+	if err != nil {
+		return nil, LogOutput{}, err
+	}
+	count := input.MaxCount
+	if count <= 0 {
+		count = 20
+	}
+	count = min(count, MaxLogCount)
+
+	args := []string{"log", "--max-count=" + strconv.Itoa(count), "--format=%H%x1f%an%x1f%ae%x1f%aI%x1f%s%x1e", rev, "--"}
+	out, _, err := g.run(ctx, repo, append(args, pathspecs...)...)
+	if err != nil {
+		return nil, LogOutput{}, err
+	}
+
+	result := LogOutput{Commits: []Commit{}}
+	for record := range strings.SplitSeq(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		date := fields[3]
+		if t, err := time.Parse(time.RFC3339, date); err == nil {
+			date = t.Format(time.RFC3339)
+		}
+		result.Commits = append(result.Commits, Commit{Hash: fields[0], Author: fields[1], Email: fields[2], Date: date, Subject: fields[4]})
+	}
+
+	logger.Info("tool called", "tool", "git_log", "repo", repo, "rev", rev, "commits", len(result.Commits))
+	return nil, result, nil
+}
+
+// Show displays a commit, or a file's content at a revision.
+func (g *Git) Show(ctx context.Context, req *mcp.CallToolRequest, input ShowInput) (*mcp.CallToolResult, TextOutput, error) {
+	repo, err := g.repo(input.Repo)
+	if err != nil {
+		return nil, TextOutput{}, err
+	}
+	if err := checkRev(input.Rev); err != nil {
+		return nil, TextOutput{}, err
+	}
+
+	var args []string
+	if input.Path != "" {
+		p, err := g.checkPath(input.Path)
+		if err != nil {
+			return nil, TextOutput{}, err
+		}
+		args = []string{"show", "--no-textconv", input.Rev + ":" + p}
+	} else {
+		pathspecs, err := g.pathspecs("")
+		if err != nil {
+			return nil, TextOutput{}, err
+		}
+		args = append([]string{"show", "--no-ext-diff", "--no-textconv", "--stat", "--patch", input.Rev, "--"}, pathspecs...)
+	}
+
+	out, truncated, err := g.run(ctx, repo, args...)
+	if err != nil {
+		return nil, TextOutput{}, err
+	}
+
+	logger.Info("tool called", "tool", "git_show", "repo", repo, "rev", input.Rev, "path", input.Path)
+	return nil, TextOutput{Output: out, Truncated: truncated}, nil
+}
+
+// Diff compares revisions or the working tree.
+func (g *Git) Diff(ctx context.Context, req *mcp.CallToolRequest, input DiffInput) (*mcp.CallToolResult, TextOutput, error) {
+	repo, err := g.repo(input.Repo)
+	if err != nil {
+		return nil, TextOutput{}, err
+	}
+	if input.To != "" && input.From == "" {
+		return nil, TextOutput{}, tools.NewError(tools.CodeInvalidArgument, "from is required when to is set")
+	}
+
+	// External diff drivers and text converters can run arbitrary commands.
+	args := []string{"diff", "--no-ext-diff", "--no-textconv"}
+	if input.Stat {
+		args = append(args, "--stat")
+	}
+	for _, rev := range []string{input.From, input.To} {
+		if rev == "" {
+			continue
+		}
+		if err := checkRev(rev); err != nil {
+			return nil, TextOutput{}, err
+		}
+		args = append(args, rev)
+	}
+	pathspecs, err := g.pathspecs(input.Path)
+	if err != nil {
+		return nil, TextOutput{}, err
+	}
+	args = append(append(args, "--"), pathspecs...)
+
+	out, truncated, err := g.run(ctx, repo, args...)
+	if err != nil {
+		return nil, TextOutput{}, err
+	}
+
+	logger.Info("tool called", "tool", "git_diff", "repo", repo, "from", input.From, "to", input.To)
+	return nil, TextOutput{Output: out, Truncated: truncated}, nil
+}
+
+// Blame annotates a file's lines with the commits that last changed them.
+func (g *Git) Blame(ctx context.Context, req *mcp.CallToolRequest, input BlameInput) (*mcp.CallToolResult, BlameOutput, error) {
+	repo, err := g.repo(input.Repo)
+	if err != nil {
+		return nil, BlameOutput{}, err
+	}
+	if input.Path == "" {
+		return nil, BlameOutput{}, tools.NewError(tools.CodeInvalidArgument, "path is required")
+	}
+	p, err := g.checkPath(input.Path)
+	if err != nil {
+		return nil, BlameOutput{}, err
+	}
+	rev := input.Rev
+	if rev == "" {
+		rev = "HEAD"
+	}
+	if err := checkRev(rev); err != nil {
+		return nil, BlameOutput{}, err
+	}
+
+	args := []string{"blame", "--line-porcelain"}
+	if input.StartLine > 0 || input.EndLine > 0 {
+		start := max(input.StartLine, 1)
+		if input.EndLine > 0 && input.EndLine < start {
+			return nil, BlameOutput{}, tools.NewError(tools.CodeInvalidArgument, "end_line must not be before start_line")
+		}
+		span := strconv.Itoa(start) + ","
+		if input.EndLine > 0 {
+			span += strconv.Itoa(input.EndLine)
+		}
+		args = append(args, "-L", span)
+	}
+	args = append(args, rev, "--", p)
+
+	out, truncated, err := g.run(ctx, repo, args...)
+	if err != nil {
+		return nil, BlameOutput{}, err
+	}
+	lines := parseBlame(out)
+
+	logger.Info("tool called", "tool", "git_blame", "repo", repo, "path", p, "lines", len(lines))
+	return nil, BlameOutput{Lines: lines, Truncated: truncated}, nil
+}
+
+// parseBlame parses --line-porcelain output. An entry is emitted only once
+// its content line is seen, so a partial entry at the end of truncated
+// output is dropped.
+func parseBlame(out string) []BlameLine {
+	lines := []BlameLine{}
+	var cur BlameLine
+	var authorTime int64
+	var tz string
+	for line := range strings.SplitSeq(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			cur.Content = line[1:]
+			if authorTime > 0 {
+				t := time.Unix(authorTime, 0).UTC()
+				if zone, err := time.Parse("-0700", tz); err == nil {
+					t = t.In(zone.Location())
+				}
+				cur.Date = t.Format(time.RFC3339)
+			}
+			lines = append(lines, cur)
+			cur = BlameLine{}
+		case strings.HasPrefix(line, "author "):
+			cur.Author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			authorTime, _ = strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64)
+		case strings.HasPrefix(line, "author-tz "):
+			tz = strings.TrimPrefix(line, "author-tz ")
+		default:
+			// Header lines are "<hash> <orig-line> <final-line> [<count>]".
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && len(fields[0]) >= 40 {
+				cur.Hash = fields[0]
+				cur.Line, _ = strconv.Atoi(fields[2])
+			}
+		}
+	}
+	return lines
+}
+
+// repo selects a configured repository by path or base name.
+func (g *Git) repo(name string) (string, error) {
+	if len(g.repos) == 0 {
+		return "", tools.NewError(tools.CodePermissionDenied, "no repositories are configured")
+	}
+	if name == "" {
+		return g.repos[0], nil
+	}
+	for _, r := range g.repos {
+		if r == name || filepath.Base(r) == name || filepath.Clean(name) == r {
+			return r, nil
+		}
+	}
+	return "", tools.NewError(tools.CodePermissionDenied, "repository %q is not configured", name)
+}
+
+// checkPath validates a repository-relative path against the allow-list.
+func (g *Git) checkPath(p string) (string, error) {
+	clean := path.Clean(filepath.ToSlash(p))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", tools.NewError(tools.CodePermissionDenied, "path %s must be relative to the repository root", p)
+	}
+	if strings.HasPrefix(clean, ":") || strings.HasPrefix(clean, "-") {
+		return "", tools.NewError(tools.CodeInvalidArgument, "invalid path %s", p)
+	}
+	if len(g.cfg.AllowedPaths) == 0 {
+		return clean, nil
+	}
+	for _, allowed := range g.cfg.AllowedPaths {
+		allowed = strings.TrimSuffix(path.Clean(allowed), "/")
+		if clean == allowed || strings.HasPrefix(clean, allowed+"/") {
+			return clean, nil
+		}
+	}
+	return "", tools.NewError(tools.CodePermissionDenied, "path %s is not in the allowed paths", p)
+}
+
+// pathspecs returns the literal pathspecs limiting a command: the requested
+// path if given, otherwise the allow-list (or nothing when unrestricted).
+func (g *Git) pathspecs(p string) ([]string, error) {
+	if p != "" {
+		clean, err := g.checkPath(p)
+		if err != nil {
+			return nil, err
+		}
+		return []string{":(literal)" + clean}, nil
+	}
+	specs := make([]string, 0, len(g.cfg.AllowedPaths))
+	for _, allowed := range g.cfg.AllowedPaths {
+		specs = append(specs, ":(literal)"+strings.TrimSuffix(path.Clean(allowed), "/"))
+	}
+	return specs, nil
+}
+
+// checkRev rejects revisions that could be parsed as options or contain
+// unexpected characters.
+func checkRev(rev string) error {
+	if rev == "" {
+		return tools.NewError(tools.CodeInvalidArgument, "rev is required")
+	}
+	if strings.HasPrefix(rev, "-") || !revPattern.MatchString(rev) {
+		return tools.NewError(tools.CodeInvalidArgument, "invalid revision %q", rev)
+	}
+	return nil
+}
+
+// run executes git in repo with a sanitized environment, returning output
+// truncated to the configured limit.
+func (g *Git) run(ctx context.Context, repo string, args ...string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.cfg.Timeout)
+	defer cancel()
+
+	base := []string{
+		"-C", repo,
+		"--no-pager",
+		"--no-optional-locks",
+		// Never run filesystem monitors configured in the repository.
+		"-c", "core.fsmonitor=false",
+	}
+	cmd := exec.CommandContext(ctx, g.cfg.Binary, append(base, args...)...)
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + os.TempDir(),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_TERMINAL_PROMPT=0",
+		"LC_ALL=C",
+	}
+
+	stdout := &limitedBuffer{limit: g.cfg.MaxOutputBytes}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", false, tools.NewError(tools.CodeDeadlineExceeded, "git %s timed out after %s", args[0], g.cfg.Timeout)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", false, tools.NewError(tools.CodeInvalidArgument, "git %s: %s", args[0], msg)
+	}
+	return strings.ToValidUTF8(stdout.buf.String(), "�"), stdout.truncated, nil
+}
+
+// limitedBuffer keeps the first limit bytes written and discards the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func init() {
+	tools.RegisterConfig(func() { LoadConfig() })
+	tools.Register(tools.Namespace("git", func(server *mcp.Server) {
+		cfg := LoadConfig()
+		if len(cfg.Repos) == 0 {
+			return
+		}
+		g, err := New(cfg)
+		if err != nil {
+			logger.Error("git tools disabled", "error", err)
+			return
+		}
+
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "git_log",
+			Description: "List commits in a configured git repository, optionally filtered by revision range and path",
+			Annotations: tools.ReadOnly(false),
+		}, g.Log)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "git_show",
+			Description: "Show a commit's message and patch, or a file's content at a revision",
+			Annotations: tools.ReadOnly(false),
+		}, g.Show)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "git_diff",
+			Description: "Diff two revisions, or the working tree, optionally limited to a path or summarized as a diffstat",
+			Annotations: tools.ReadOnly(false),
+		}, g.Diff)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "git_blame",
+			Description: "Annotate a file's lines with the commit, author, and date that last changed them",
+			Annotations: tools.ReadOnly(false),
+		}, g.Blame)
+	}))
+}
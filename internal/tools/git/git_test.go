@@ -0,0 +1,240 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// newTestRepo creates a repository with two commits touching src/ and secret/.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Ada", "GIT_AUTHOR_EMAIL=ada@example.com",
+			"GIT_COMMITTER_NAME=Ada", "GIT_COMMITTER_EMAIL=ada@example.com",
+			"GIT_AUTHOR_DATE=2024-01-02T03:04:05Z", "GIT_COMMITTER_DATE=2024-01-02T03:04:05Z",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("src/main.go", "package main\n")
+	write("secret/key.txt", "hunter2\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial commit")
+	write("src/main.go", "package main\n\nfunc main() {}\n")
+	write("secret/key.txt", "hunter3\n")
+	run("commit", "-q", "-am", "add main")
+	return dir
+}
+
+func newTestGit(t *testing.T, allowed ...string) *Git {
+	t.Helper()
+	g, err := New(Config{
+		Repos:          []string{newTestRepo(t)},
+		AllowedPaths:   allowed,
+		Binary:         "git",
+		MaxOutputBytes: 64 << 10,
+		Timeout:        10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestLog(t *testing.T) {
+	g := newTestGit(t)
+
+	_, out, err := g.Log(context.Background(), &mcp.CallToolRequest{}, LogInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Commits) != 2 {
+		t.Fatalf("got %d commits, want 2", len(out.Commits))
+	}
+	c := out.Commits[0]
+	if c.Subject != "add main" || c.Author != "Ada" || c.Email != "ada@example.com" || c.Date != "2024-01-02T03:04:05Z" || len(c.Hash) != 40 {
+		t.Errorf("unexpected commit: %+v", c)
+	}
+
+	_, out, err = g.Log(context.Background(), &mcp.CallToolRequest{}, LogInput{Rev: "HEAD~1", MaxCount: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Commits) != 1 || out.Commits[0].Subject != "initial commit" {
+		t.Errorf("HEAD~1 log = %+v", out.Commits)
+	}
+}
+
+func TestShow(t *testing.T) {
+	g := newTestGit(t, "src")
+
+	_, out, err := g.Show(context.Background(), &mcp.CallToolRequest{}, ShowInput{Rev: "HEAD"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.Output, "func main()") || strings.Contains(out.Output, "hunter") {
+		t.Errorf("show output should include src changes only:\n%s", out.Output)
+	}
+
+	_, out, err = g.Show(context.Background(), &mcp.CallToolRequest{}, ShowInput{Rev: "HEAD~1", Path: "src/main.go"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Output != "package main\n" {
+		t.Errorf("file at HEAD~1 = %q", out.Output)
+	}
+
+	_, _, err = g.Show(context.Background(), &mcp.CallToolRequest{}, ShowInput{Rev: "HEAD", Path: "secret/key.txt"})
+	if err == nil || !strings.Contains(err.Error(), "not in the allowed paths") {
+		t.Errorf("error = %v, want allow-list rejection", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	g := newTestGit(t)
+
+	_, out, err := g.Diff(context.Background(), &mcp.CallToolRequest{}, DiffInput{From: "HEAD~1", To: "HEAD", Path: "src/main.go"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.Output, "+func main() {}") || strings.Contains(out.Output, "hunter") {
+		t.Errorf("unexpected diff:\n%s", out.Output)
+	}
+
+	_, out, err = g.Diff(context.Background(), &mcp.CallToolRequest{}, DiffInput{From: "HEAD~1", To: "HEAD", Stat: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.Output, "2 files changed") {
+		t.Errorf("unexpected stat:\n%s", out.Output)
+	}
+}
+
+func TestBlame(t *testing.T) {
+	g := newTestGit(t)
+
+	_, out, err := g.Blame(context.Background(), &mcp.CallToolRequest{}, BlameInput{Path: "src/main.go", StartLine: 3, EndLine: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(out.Lines))
+	}
+	l := out.Lines[0]
+	if l.Line != 3 || l.Content != "func main() {}" || l.Author != "Ada" || l.Date != "2024-01-02T03:04:05Z" {
+		t.Errorf("unexpected blame line: %+v", l)
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	g := newTestGit(t)
+	g.cfg.MaxOutputBytes = 10
+
+	_, out, err := g.Show(context.Background(), &mcp.CallToolRequest{}, ShowInput{Rev: "HEAD"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !out.Truncated || len(out.Output) != 10 {
+		t.Errorf("got %d bytes truncated=%v, want 10 truncated", len(out.Output), out.Truncated)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	g := newTestGit(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		call     func() error
+		wantErr  string
+		wantCode tools.Code
+	}{
+		{
+			name: "option injection in rev",
+			call: func() error {
+				_, _, err := g.Log(ctx, &mcp.CallToolRequest{}, LogInput{Rev: "--output=/tmp/x"})
+				return err
+			},
+			wantErr:  "invalid revision",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name: "path traversal",
+			call: func() error {
+				_, _, err := g.Show(ctx, &mcp.CallToolRequest{}, ShowInput{Rev: "HEAD", Path: "../etc/passwd"})
+				return err
+			},
+			wantErr:  "relative to the repository root",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name: "unknown repo",
+			call: func() error {
+				_, _, err := g.Log(ctx, &mcp.CallToolRequest{}, LogInput{Repo: "other"})
+				return err
+			},
+			wantErr:  "not configured",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name: "to without from",
+			call: func() error {
+				_, _, err := g.Diff(ctx, &mcp.CallToolRequest{}, DiffInput{To: "HEAD"})
+				return err
+			},
+			wantErr:  "from is required",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name: "unknown revision",
+			call: func() error {
+				_, _, err := g.Show(ctx, &mcp.CallToolRequest{}, ShowInput{Rev: "nope"})
+				return err
+			},
+			wantErr:  "git show",
+			wantCode: tools.CodeInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+			if code := tools.AsError(err).Code; code != tt.wantCode {
+				t.Errorf("code = %s, want %s", code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package hash
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	gohash "hash"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// MaxInputBytes caps the decoded input size accepted by the tool.
+const MaxInputBytes = 10 << 20
+
+// algorithms maps supported algorithm names to hash constructors.
+// BLAKE2 variants use their 256-bit digest size.
+var algorithms = map[string]func() gohash.Hash{
+	"md5":     md5.New,
+	"sha1":    sha1.New,
+	"sha256":  sha256.New,
+	"sha512":  sha512.New,
+	"blake2b": mustBlake2(func() (gohash.Hash, error) { return blake2b.New256(nil) }),
+	"blake2s": mustBlake2(func() (gohash.Hash, error) { return blake2s.New256(nil) }),
+}
+
+// Input is the input for the hash tool.
+type Input struct {
+	Data           string `json:"data" jsonschema:"the data to hash"`
+	InputEncoding  string `json:"input_encoding,omitempty" jsonschema:"how data is encoded: text (default) or base64"`
+	Algorithm      string `json:"algorithm,omitempty" jsonschema:"hash algorithm: md5, sha1, sha256 (default), sha512, blake2b, blake2s"`
+	HMACKey        string `json:"hmac_key,omitempty" jsonschema:"when set, compute an HMAC using this key instead of a plain digest"`
+	OutputEncoding string `json:"output_encoding,omitempty" jsonschema:"digest encoding: hex (default) or base64"`
+	Expected       string `json:"expected,omitempty" jsonschema:"optional expected digest to compare against, in the output encoding"`
+}
+
+// Output is the output of the hash tool.
+type Output struct {
+	Algorithm string `json:"algorithm" jsonschema:"the algorithm used"`
+	HMAC      bool   `json:"hmac" jsonschema:"whether the digest is an HMAC"`
+	Digest    string `json:"digest" jsonschema:"the encoded digest"`
+	Match     *bool  `json:"match,omitempty" jsonschema:"whether the digest equals expected; omitted when expected is not set"`
+}
+
+// Hash computes a digest or HMAC of the provided data.
+func Hash(_ context.Context, _ *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	algorithm := strings.ToLower(input.Algorithm)
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	newHash, ok := algorithms[algorithm]
+	if !ok {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "unsupported algorithm %q", input.Algorithm)
+	}
+
+	data, err := decodeInput(input.Data, input.InputEncoding)
+	if err != nil {
+		return nil, Output{}, err
+	}
+
+	var h gohash.Hash
+	if input.HMACKey != "" {
+		h = hmac.New(newHash, []byte(input.HMACKey))
+	} else {
+		h = newHash()
+	}
+	h.Write(data)
+
+	digest, err := encodeOutput(h.Sum(nil), input.OutputEncoding)
+	if err != nil {
+		return nil, Output{}, err
+	}
+
+	out := Output{Algorithm: algorithm, HMAC: input.HMACKey != "", Digest: digest}
+	if input.Expected != "" {
+		match := subtle.ConstantTimeCompare([]byte(strings.TrimSpace(input.Expected)), []byte(digest)) == 1
+		out.Match = &match
+	}
+
+	logger.Info("tool called", "tool", "hash", "algorithm", algorithm, "hmac", out.HMAC, "bytes", len(data))
+	return nil, out, nil
+}
+
+// decodeInput decodes data according to the requested input encoding.
+func decodeInput(data, encoding string) ([]byte, error) {
+	var decoded []byte
+	switch strings.ToLower(encoding) {
+	case "", "text":
+		decoded = []byte(data)
+	case "base64":
+		var err error
+		if decoded, err = base64.StdEncoding.DecodeString(data); err != nil {
+			return nil, tools.WrapError(tools.CodeInvalidArgument, err, "invalid base64 input")
+		}
+	default:
+		return nil, tools.NewError(tools.CodeInvalidArgument, "unsupported input encoding %q", encoding)
+	}
+
+	if len(decoded) > MaxInputBytes {
+		return nil, tools.NewError(tools.CodeResourceExhausted, "input exceeds %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+	return decoded, nil
+}
+
+// encodeOutput encodes a digest according to the requested output encoding.
+func encodeOutput(sum []byte, encoding string) (string, error) {
+	switch strings.ToLower(encoding) {
+	case "", "hex":
+		return hex.EncodeToString(sum), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(sum), nil
+	default:
+		return "", tools.NewError(tools.CodeInvalidArgument, "unsupported output encoding %q", encoding)
+	}
+}
+
+// mustBlake2 adapts an error-returning BLAKE2 constructor. Unkeyed
+// construction cannot fail, so an error indicates a programming bug.
+func mustBlake2(fn func() (gohash.Hash, error)) func() gohash.Hash {
+	return func() gohash.Hash {
+		h, err := fn()
+		if err != nil {
+			panic(err)
+		}
+		return h
+	}
+}
+
+func init() {
+	tools.Register(tools.Namespace("data", func(server *mcp.Server) {
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "hash",
+			Description: "Compute a checksum or HMAC (md5, sha1, sha256, sha512, blake2b, blake2s) of text or base64 data",
+			Annotations: tools.ReadOnly(false),
+		}, Hash)
+	}))
+}
@@ -0,0 +1,118 @@
+package hash
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      Input
+		wantDigest string
+		wantMatch  *bool
+		wantErr    string
+		wantCode   tools.Code
+	}{
+		{
+			name:       "default sha256",
+			input:      Input{Data: "hello"},
+			wantDigest: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		},
+		{
+			name:       "md5",
+			input:      Input{Data: "hello", Algorithm: "md5"},
+			wantDigest: "5d41402abc4b2a76b9719d911017c592",
+		},
+		{
+			name:       "sha1 uppercase algorithm",
+			input:      Input{Data: "hello", Algorithm: "SHA1"},
+			wantDigest: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d",
+		},
+		{
+			name:       "sha512 base64 output",
+			input:      Input{Data: "", Algorithm: "sha512", OutputEncoding: "base64"},
+			wantDigest: "z4PhNX7vuL3xVChQ1m2AB9Yg5AULVxXcg/SpIdNs6c5H0NE8XYXysP+DGNKHfuwvY7kxvUdBeoGlODJ6+SfaPg==",
+		},
+		{
+			name:       "blake2b",
+			input:      Input{Data: "abc", Algorithm: "blake2b"},
+			wantDigest: "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319",
+		},
+		{
+			name:       "blake2s",
+			input:      Input{Data: "abc", Algorithm: "blake2s"},
+			wantDigest: "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982",
+		},
+		{
+			name:       "base64 input",
+			input:      Input{Data: "aGVsbG8=", InputEncoding: "base64"},
+			wantDigest: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		},
+		{
+			name:       "hmac sha256",
+			input:      Input{Data: "The quick brown fox jumps over the lazy dog", HMACKey: "key"},
+			wantDigest: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		},
+		{
+			name:       "expected matches",
+			input:      Input{Data: "hello", Algorithm: "md5", Expected: "5d41402abc4b2a76b9719d911017c592"},
+			wantDigest: "5d41402abc4b2a76b9719d911017c592",
+			wantMatch:  boolPtr(true),
+		},
+		{
+			name:       "expected mismatch",
+			input:      Input{Data: "hello", Algorithm: "md5", Expected: "deadbeef"},
+			wantDigest: "5d41402abc4b2a76b9719d911017c592",
+			wantMatch:  boolPtr(false),
+		},
+		{name: "unsupported algorithm", input: Input{Data: "x", Algorithm: "crc32"}, wantErr: "unsupported algorithm", wantCode: tools.CodeInvalidArgument},
+		{name: "invalid base64", input: Input{Data: "!!", InputEncoding: "base64"}, wantErr: "invalid base64", wantCode: tools.CodeInvalidArgument},
+		{name: "unsupported input encoding", input: Input{Data: "x", InputEncoding: "hex"}, wantErr: "unsupported input encoding", wantCode: tools.CodeInvalidArgument},
+		{name: "unsupported output encoding", input: Input{Data: "x", OutputEncoding: "base32"}, wantErr: "unsupported output encoding", wantCode: tools.CodeInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Hash(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Digest != tt.wantDigest {
+				t.Errorf("Digest = %q, want %q", out.Digest, tt.wantDigest)
+			}
+			if out.HMAC != (tt.input.HMACKey != "") {
+				t.Errorf("HMAC = %v, want %v", out.HMAC, tt.input.HMACKey != "")
+			}
+			switch {
+			case tt.wantMatch == nil && out.Match != nil:
+				t.Errorf("Match = %v, want nil", *out.Match)
+			case tt.wantMatch != nil && (out.Match == nil || *out.Match != *tt.wantMatch):
+				t.Errorf("Match = %v, want %v", out.Match, *tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestHash_InputTooLarge(t *testing.T) {
+	_, _, err := Hash(context.Background(), &mcp.CallToolRequest{}, Input{Data: strings.Repeat("a", MaxInputBytes+1)})
+	if err == nil {
+		t.Fatal("expected error for oversized input")
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
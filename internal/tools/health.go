@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Health returns what /health reports about the tools: the state of each
+// Lazy under "dependencies" and the error of each failed registrar under
+// "registrars". It is empty while there is nothing to report.
+func Health() map[string]map[string]string {
+	health := make(map[string]map[string]string)
+	if status := LazyStatus(); len(status) > 0 {
+		health["dependencies"] = status
+	}
+	if failures := RegistrarFailures(); len(failures) > 0 {
+		health["registrars"] = failures
+	}
+	return health
+}
+
+// CheckHealth is a health check failing while any Lazy is failed or any
+// registrar failed. The server reports it without failing /health, since
+// the other tools keep serving.
+func CheckHealth(context.Context) error {
+	var failed []string
+	for name, s := range LazyStatus() {
+		if strings.HasPrefix(s, "failed: ") {
+			failed = append(failed, name+" "+s)
+		}
+	}
+	for name, err := range RegistrarFailures() {
+		failed = append(failed, name+" failed to register: "+err)
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Strings(failed)
+	return errors.New(strings.Join(failed, "; "))
+}
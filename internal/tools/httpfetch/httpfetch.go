@@ -0,0 +1,312 @@
+package httpfetch
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/httpclient"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// MaxRequestBodyBytes caps the size of request bodies sent with POST.
+const MaxRequestBodyBytes = 1 << 20
+
+// errBlockedAddress is returned when a connection targets a non-public IP.
+var errBlockedAddress = errors.New("destination address is not allowed")
+
+// forbiddenHeaders are request headers callers may not override.
+var forbiddenHeaders = map[string]struct{}{
+	"Host":                {},
+	"Content-Length":      {},
+	"Transfer-Encoding":   {},
+	"Connection":          {},
+	"Upgrade":             {},
+	"Proxy-Authorization": {},
+}
+
+// Config controls which destinations the fetch tool may reach and how much
+// it may read.
+type Config struct {
+	// AllowedHosts lists host names the tool may contact. Entries may be
+	// exact ("api.example.com"), subdomain wildcards ("*.example.com"), or
+	// "*" to allow any host. An empty list disables all fetches.
+	AllowedHosts []string
+	// AllowPrivate permits connections to loopback, private, link-local,
+	// and other non-public addresses.
+	AllowPrivate bool
+	MaxBytes     int64
+	Timeout      time.Duration
+	MaxRedirects int
+}
+
+// LoadConfig reads the fetch configuration from environment variables.
+func LoadConfig() Config {
+	return Config{
+		AllowedHosts: config.GetEnvList("FETCH_ALLOWED_HOSTS"),
+		AllowPrivate: config.GetEnvBool("FETCH_ALLOW_PRIVATE", false),
+		MaxBytes:     int64(config.GetEnvInt("FETCH_MAX_BYTES", 1<<20)),
+		Timeout:      config.GetEnvDuration("FETCH_TIMEOUT", 30*time.Second),
+		MaxRedirects: config.GetEnvInt("FETCH_MAX_REDIRECTS", 5),
+	}
+}
+
+// Settings are the http_fetch section of the tool config file; fields
+// that are set override the environment.
+type Settings struct {
+	// AllowedHosts replaces FETCH_ALLOWED_HOSTS; an empty list disables
+	// all fetches.
+	AllowedHosts []string `yaml:"allowed_hosts"`
+	// MaxBytes replaces FETCH_MAX_BYTES.
+	MaxBytes int64 `yaml:"max_bytes"`
+}
+
+var settings = tools.NewSettings[Settings]("http_fetch")
+
+// Input is the input for the http_fetch tool.
+type Input struct {
+	URL     string            `json:"url" jsonschema:"the http or https URL to fetch; the host must be allow-listed by the operator"`
+	Method  string            `json:"method,omitempty" jsonschema:"GET (default), HEAD, or POST"`
+	Headers map[string]string `json:"headers,omitempty" jsonschema:"additional request headers"`
+	Body    string            `json:"body,omitempty" jsonschema:"request body for POST"`
+}
+
+// Output is the output of the http_fetch tool.
+type Output struct {
+	StatusCode   int               `json:"status_code" jsonschema:"the HTTP status code"`
+	FinalURL     string            `json:"final_url" jsonschema:"the URL after following redirects"`
+	Headers      map[string]string `json:"headers" jsonschema:"response headers; repeated values are joined with ', '"`
+	Body         string            `json:"body" jsonschema:"the response body, possibly truncated"`
+	BodyEncoding string            `json:"body_encoding" jsonschema:"text, or base64 when the body is not valid UTF-8"`
+	Truncated    bool              `json:"truncated" jsonschema:"whether the body was cut off at the size limit"`
+}
+
+// Fetcher performs HTTP requests constrained by a Config.
+type Fetcher struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewFetcher creates a Fetcher whose client enforces the configured
+// destination, redirect, and timeout policies.
+func NewFetcher(cfg Config) *Fetcher {
+	f := &Fetcher{cfg: cfg}
+
+	var control func(network, address string, c syscall.RawConn) error
+	if !cfg.AllowPrivate {
+		// Checking the resolved address at dial time also defeats DNS
+		// rebinding, where a name first resolves publicly and later privately.
+		control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", errBlockedAddress, host)
+			}
+			return nil
+		}
+	}
+
+	f.client = httpclient.New("http_fetch", httpclient.Options{
+		Timeout: cfg.Timeout,
+		// Callers see every response as it is, retryable or not
+		NoRetry: true,
+		// Proxies would dial on our behalf and bypass the address check.
+		NoProxy: true,
+		Control: control,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", cfg.MaxRedirects)
+			}
+			return f.checkURL(req.URL)
+		},
+	})
+	return f
+}
+
+// Fetch performs the requested HTTP call and returns the response.
+func (f *Fetcher) Fetch(ctx context.Context, _ *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	target, err := url.Parse(input.URL)
+	if err != nil {
+		return nil, Output{}, tools.WrapError(tools.CodeInvalidArgument, err, "invalid url")
+	}
+	if err := f.checkURL(target); err != nil {
+		return nil, Output{}, err
+	}
+
+	method := strings.ToUpper(input.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if method != http.MethodGet && method != http.MethodHead && method != http.MethodPost {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "unsupported method %q: use GET, HEAD, or POST", input.Method)
+	}
+	if input.Body != "" && method != http.MethodPost {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "body is only allowed with POST")
+	}
+	if len(input.Body) > MaxRequestBodyBytes {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "body exceeds %d bytes", MaxRequestBodyBytes).
+			WithDetail("max_bytes", MaxRequestBodyBytes)
+	}
+
+	var body io.Reader
+	if input.Body != "" {
+		body = strings.NewReader(input.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, Output{}, tools.WrapError(tools.CodeInvalidArgument, err, "failed to create request")
+	}
+	for k, v := range input.Headers {
+		if _, forbidden := forbiddenHeaders[http.CanonicalHeaderKey(k)]; forbidden {
+			return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "header %q may not be set", k)
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, errBlockedAddress) || errors.Is(err, httpclient.ErrEgressDenied) || errors.As(err, new(*tools.Error)) {
+			return nil, Output{}, tools.WrapError(tools.CodePermissionDenied, err, "request failed")
+		}
+		return nil, Output{}, tools.WrapError(tools.CodeUnavailable, err, "request failed")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	maxBytes := f.cfg.MaxBytes
+	if s, ok := settings.Get(); ok && s.MaxBytes > 0 {
+		maxBytes = s.MaxBytes
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, Output{}, tools.WrapError(tools.CodeUnavailable, err, "failed to read body")
+	}
+	truncated := int64(len(data)) > maxBytes
+	if truncated {
+		data = data[:maxBytes]
+	}
+
+	out := Output{
+		StatusCode:   resp.StatusCode,
+		FinalURL:     resp.Request.URL.String(),
+		Headers:      make(map[string]string, len(resp.Header)),
+		Body:         string(data),
+		BodyEncoding: "text",
+		Truncated:    truncated,
+	}
+	for k, v := range resp.Header {
+		out.Headers[k] = strings.Join(v, ", ")
+	}
+	if !utf8.Valid(data) {
+		out.Body = base64.StdEncoding.EncodeToString(data)
+		out.BodyEncoding = "base64"
+	}
+
+	logger.Info("tool called", "tool", "http_fetch", "method", method, "host", target.Hostname(), "status", resp.StatusCode, "bytes", len(data), "truncated", truncated)
+	return nil, out, nil
+}
+
+// checkURL verifies the scheme and that the host is allow-listed.
+func (f *Fetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return tools.NewError(tools.CodeInvalidArgument, "unsupported scheme %q: use http or https", u.Scheme)
+	}
+	if u.User != nil {
+		return tools.NewError(tools.CodeInvalidArgument, "credentials in url are not allowed")
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return tools.NewError(tools.CodeInvalidArgument, "url must include a host")
+	}
+	allowed := f.cfg.AllowedHosts
+	if s, ok := settings.Get(); ok && s.AllowedHosts != nil {
+		allowed = s.AllowedHosts
+	}
+	if !hostAllowed(host, allowed) {
+		return tools.NewError(tools.CodePermissionDenied, "host %q is not in the allow-list", host)
+	}
+	return nil
+}
+
+// hostAllowed reports whether host matches any allow-list entry.
+func hostAllowed(host string, allowed []string) bool {
+	for _, entry := range allowed {
+		entry = strings.ToLower(entry)
+		switch {
+		case entry == "*", entry == host:
+			return true
+		case strings.HasPrefix(entry, "*.") && strings.HasSuffix(host, entry[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address.
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, block := range nonPublicBlocks {
+		if block.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// nonPublicBlocks are special-purpose ranges not covered by the net.IP
+// predicates, such as carrier-grade NAT and NAT64 translated space.
+var nonPublicBlocks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+)
+
+// mustParseCIDRs parses a fixed list of CIDR blocks.
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	blocks := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, block, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func init() {
+	tools.RegisterConfig(func() { LoadConfig() })
+	tools.Register(tools.Namespace("net", func(server *mcp.Server) {
+		f := NewFetcher(LoadConfig())
+		// POST requests may change state on the remote side
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "http_fetch",
+			Description: "Fetch content from an allow-listed URL via HTTP GET, HEAD, or POST",
+			Annotations: tools.Destructive(false, true),
+		}, f.Fetch)
+	}))
+}
@@ -0,0 +1,227 @@
+// Package jobs runs long tool work in the background. A tool starts a job
+// with Start and returns its ID at once; the client then follows it with
+// the job_status, job_result, and job_cancel tools:
+//
+//	func (t *Tool) Export(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, jobs.Status, error) {
+//		status, err := jobs.Start(req, "export", func(ctx context.Context) (*mcp.CallToolResult, error) {
+//			return t.export(ctx, in)
+//		})
+//		return nil, status, err
+//	}
+//
+// Jobs run on JOBS_WORKERS workers, in the order they were started, and
+// are kept for JOBS_TTL after they finish.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// Config controls where jobs are kept and how many run.
+type Config struct {
+	// Backend is "memory" (default) or "file".
+	Backend string
+	// FilePath is where the file backend persists jobs.
+	FilePath string
+	// Workers is how many jobs run at once.
+	Workers int
+	// MaxJobs caps the jobs kept, queued, running, or finished.
+	MaxJobs int
+	// TTL is how long a finished job is kept.
+	TTL time.Duration
+}
+
+// LoadConfig reads the JOBS_* settings.
+func LoadConfig() Config {
+	return Config{
+		Backend:  config.GetEnv("JOBS_BACKEND", "memory"),
+		FilePath: config.GetEnv("JOBS_FILE", "jobs.json"),
+		Workers:  max(config.GetEnvInt("JOBS_WORKERS", 4), 1),
+		MaxJobs:  max(config.GetEnvInt("JOBS_MAX", 1000), 1),
+		TTL:      config.GetEnvDuration("JOBS_TTL", time.Hour),
+	}
+}
+
+// manager is the Manager shared by Start and the job tools, created on
+// first use.
+var manager = sync.OnceValues(func() (*Manager, error) {
+	return NewManager(LoadConfig())
+})
+
+// Status is a job as reported to clients.
+type Status struct {
+	ID       string `json:"id" jsonschema:"the job ID, for job_status, job_result, and job_cancel"`
+	Tool     string `json:"tool" jsonschema:"the tool that started the job"`
+	State    string `json:"state" jsonschema:"queued, running, succeeded, failed, or canceled"`
+	Error    string `json:"error,omitempty" jsonschema:"why the job failed or was canceled"`
+	Created  string `json:"created" jsonschema:"when the job was started, as RFC 3339"`
+	Started  string `json:"started,omitempty" jsonschema:"when the job began running, as RFC 3339"`
+	Finished string `json:"finished,omitempty" jsonschema:"when the job finished, as RFC 3339"`
+	Expires  string `json:"expires,omitempty" jsonschema:"when a finished job is forgotten, as RFC 3339"`
+}
+
+// Start queues fn as a job of the tool called tool on behalf of the caller
+// of req and returns its Status, for the tool to return at once.
+func Start(req *mcp.CallToolRequest, tool string, fn Func) (Status, error) {
+	m, err := manager()
+	if err != nil {
+		return Status{}, tools.WrapError(tools.CodeUnavailable, err, "jobs unavailable")
+	}
+	r, err := m.Start(tool, callerOf(req), fn)
+	if err != nil {
+		return Status{}, err
+	}
+	logger.Info("job started", "tool", tool, "job", r.ID)
+	return m.status(r), nil
+}
+
+// status converts r into its client-facing form.
+func (m *Manager) status(r Record) Status {
+	s := Status{ID: r.ID, Tool: r.Tool, State: r.State, Created: r.Created.Format(time.RFC3339)}
+	if r.Error != nil {
+		s.Error = r.Error.Message
+	}
+	if !r.Started.IsZero() {
+		s.Started = r.Started.Format(time.RFC3339)
+	}
+	if !r.Finished.IsZero() {
+		s.Finished = r.Finished.Format(time.RFC3339)
+		s.Expires = r.Finished.Add(m.cfg.TTL).Format(time.RFC3339)
+	}
+	return s
+}
+
+// callerOf returns the authenticated caller of req, or "" without
+// authentication. Jobs are visible only to the caller that started them.
+func callerOf(req *mcp.CallToolRequest) string {
+	if req == nil || req.Extra == nil || req.Extra.Header == nil {
+		return ""
+	}
+	return req.Extra.Header.Get(middleware.HeaderCaller)
+}
+
+// IDInput is the input of the job tools.
+type IDInput struct {
+	ID string `json:"id" jsonschema:"the job ID returned by the tool that started the job"`
+}
+
+// ListOutput is the output of the job_list tool.
+type ListOutput struct {
+	Jobs []Status `json:"jobs" jsonschema:"your jobs, newest first"`
+}
+
+// Tools implements the job tools over a Manager.
+type Tools struct {
+	m *Manager
+}
+
+// NewTools creates the job tools of m.
+func NewTools(m *Manager) *Tools {
+	return &Tools{m: m}
+}
+
+// Status reports the state of a job.
+func (t *Tools) Status(_ context.Context, req *mcp.CallToolRequest, in IDInput) (*mcp.CallToolResult, Status, error) {
+	r, err := t.m.Get(in.ID, callerOf(req))
+	if err != nil {
+		return nil, Status{}, notFound(err, in.ID)
+	}
+	return nil, t.m.status(r), nil
+}
+
+// Result returns the result of a finished job, or its error.
+func (t *Tools) Result(_ context.Context, req *mcp.CallToolRequest, in IDInput) (*mcp.CallToolResult, any, error) {
+	r, err := t.m.Get(in.ID, callerOf(req))
+	if err != nil {
+		return nil, nil, notFound(err, in.ID)
+	}
+	switch r.State {
+	case StateQueued, StateRunning:
+		return nil, nil, tools.NewError(tools.CodeUnavailable, "job %s is %s; try again later", r.ID, r.State).
+			WithDetail("state", r.State)
+	case StateSucceeded:
+		var res mcp.CallToolResult
+		if err := json.Unmarshal(r.Result, &res); err != nil {
+			return nil, nil, tools.WrapError(tools.CodeInternal, err, "decoding the job result")
+		}
+		return &res, nil, nil
+	default:
+		if r.Error == nil {
+			return nil, nil, tools.NewError(tools.CodeInternal, "job %s %s", r.ID, r.State)
+		}
+		return nil, nil, r.Error
+	}
+}
+
+// Cancel cancels a queued or running job.
+func (t *Tools) Cancel(_ context.Context, req *mcp.CallToolRequest, in IDInput) (*mcp.CallToolResult, Status, error) {
+	r, err := t.m.Cancel(in.ID, callerOf(req))
+	if err != nil {
+		return nil, Status{}, notFound(err, in.ID)
+	}
+	logger.Info("job canceled", "tool", r.Tool, "job", r.ID, "state", r.State)
+	return nil, t.m.status(r), nil
+}
+
+// List lists the caller's jobs.
+func (t *Tools) List(_ context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, ListOutput, error) {
+	out := ListOutput{Jobs: []Status{}}
+	for _, r := range t.m.List(callerOf(req)) {
+		out.Jobs = append(out.Jobs, t.m.status(r))
+	}
+	return nil, out, nil
+}
+
+func notFound(err error, id string) error {
+	if errors.Is(err, ErrNotFound) {
+		return tools.NewError(tools.CodeNotFound, "job %s not found; jobs are kept for a while after they finish", id)
+	}
+	return err
+}
+
+func init() {
+	tools.RegisterConfig(func() { LoadConfig() })
+	tools.Register(tools.Namespace("jobs", func(server *mcp.Server) {
+		m, err := manager()
+		if err != nil {
+			logger.Error("job tools disabled", "error", err)
+			return
+		}
+		t := NewTools(m)
+
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "job_status",
+			Description: "Report the state of a background job started by another tool",
+			Annotations: tools.ReadOnly(false),
+		}, t.Status)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "job_result",
+			Description: "Return the result of a finished background job, or fail while it is still running",
+			Annotations: tools.ReadOnly(false),
+		}, t.Result)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "job_cancel",
+			Description: "Cancel a queued or running background job",
+			Annotations: tools.Destructive(true, false),
+		}, t.Cancel)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "job_list",
+			Description: "List your background jobs, newest first",
+			Annotations: tools.ReadOnly(false),
+		}, t.List)
+	}))
+}
@@ -0,0 +1,185 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// wait polls job id until it leaves the queued and running states.
+func wait(t *testing.T, m *Manager, id, caller string) Record {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r, err := m.Get(id, caller)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if r.done() {
+			return r
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s still %s", id, r.State)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func request(caller string) *mcp.CallToolRequest {
+	h := http.Header{}
+	if caller != "" {
+		h.Set(middleware.HeaderCaller, caller)
+	}
+	return &mcp.CallToolRequest{Extra: &mcp.RequestExtra{Header: h}}
+}
+
+func TestManager(t *testing.T) {
+	m, err := NewManager(Config{Workers: 1, MaxJobs: 10, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl := NewTools(m)
+	ctx := context.Background()
+
+	// A successful job's result is returned by job_result
+	ok, err := m.Start("export", "alice", func(context.Context) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "done"}}}, nil
+	})
+	if err != nil || ok.State != StateQueued || len(ok.ID) != 32 {
+		t.Fatalf("Start() = %+v, %v", ok, err)
+	}
+	if r := wait(t, m, ok.ID, "alice"); r.State != StateSucceeded {
+		t.Fatalf("state = %s, want succeeded", r.State)
+	}
+	res, _, err := tl.Result(ctx, request("alice"), IDInput{ID: ok.ID})
+	if err != nil || len(res.Content) != 1 || res.Content[0].(*mcp.TextContent).Text != "done" {
+		t.Errorf("Result() = %+v, %v", res, err)
+	}
+
+	// Jobs are invisible to other callers
+	if _, _, err := tl.Result(ctx, request("bob"), IDInput{ID: ok.ID}); tools.AsError(err).Code != tools.CodeNotFound {
+		t.Errorf("Result() by another caller error = %v, want not_found", err)
+	}
+
+	// A failing job reports its error
+	failed, _ := m.Start("export", "alice", func(context.Context) (*mcp.CallToolResult, error) {
+		return nil, tools.NewError(tools.CodeInvalidArgument, "no such table")
+	})
+	wait(t, m, failed.ID, "alice")
+	if _, _, err := tl.Result(ctx, request("alice"), IDInput{ID: failed.ID}); tools.AsError(err).Code != tools.CodeInvalidArgument {
+		t.Errorf("Result() of a failed job error = %v, want invalid_argument", err)
+	}
+
+	// A running job is canceled through its context, and a job queued
+	// behind it never runs
+	started := make(chan struct{})
+	running, _ := m.Start("export", "alice", func(ctx context.Context) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	ran := false
+	queued, _ := m.Start("export", "alice", func(context.Context) (*mcp.CallToolResult, error) {
+		ran = true
+		return nil, nil
+	})
+	<-started
+	if _, _, err := tl.Result(ctx, request("alice"), IDInput{ID: running.ID}); tools.AsError(err).Code != tools.CodeUnavailable {
+		t.Errorf("Result() of a running job error = %v, want unavailable", err)
+	}
+	if _, s, err := tl.Cancel(ctx, request("alice"), IDInput{ID: queued.ID}); err != nil || s.State != StateCanceled {
+		t.Errorf("Cancel() of a queued job = %+v, %v", s, err)
+	}
+	if _, _, err := tl.Cancel(ctx, request("alice"), IDInput{ID: running.ID}); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if r := wait(t, m, running.ID, "alice"); r.State != StateCanceled {
+		t.Errorf("state after Cancel() = %s, want canceled", r.State)
+	}
+	if ran {
+		t.Error("a job canceled while queued ran")
+	}
+
+	// A panic fails the job, not the worker
+	panicked, _ := m.Start("export", "alice", func(context.Context) (*mcp.CallToolResult, error) { panic("boom") })
+	if r := wait(t, m, panicked.ID, "alice"); r.State != StateFailed || r.Error.Code != tools.CodeInternal {
+		t.Errorf("panicked job = %+v", r)
+	}
+
+	_, list, _ := tl.List(ctx, request("alice"), struct{}{})
+	if len(list.Jobs) != 5 || list.Jobs[0].ID != panicked.ID {
+		t.Errorf("List() = %d jobs, want 5 newest first", len(list.Jobs))
+	}
+}
+
+func TestManagerLimits(t *testing.T) {
+	m, err := NewManager(Config{Workers: 1, MaxJobs: 2, TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	quick := func(context.Context) (*mcp.CallToolResult, error) { return nil, nil }
+
+	first, _ := m.Start("export", "", quick)
+	wait(t, m, first.ID, "")
+	if _, err := m.Start("export", "", quick); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if _, err := m.Start("export", "", quick); tools.AsError(err).Code != tools.CodeResourceExhausted {
+		t.Errorf("Start() beyond MaxJobs error = %v, want resource_exhausted", err)
+	}
+
+	// Finished jobs expire after the TTL, making room
+	now = now.Add(2 * time.Minute)
+	if _, err := m.Get(first.ID, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of an expired job error = %v, want ErrNotFound", err)
+	}
+	if _, err := m.Start("export", "", quick); err != nil {
+		t.Errorf("Start() after expiry error = %v", err)
+	}
+}
+
+func TestFileBackend(t *testing.T) {
+	cfg := Config{Backend: "file", FilePath: filepath.Join(t.TempDir(), "jobs.json"), Workers: 1, MaxJobs: 10, TTL: time.Hour}
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, _ := m.Start("export", "", func(context.Context) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "kept"}}}, nil
+	})
+	wait(t, m, done.ID, "")
+	block := make(chan struct{})
+	defer close(block)
+	running, _ := m.Start("export", "", func(context.Context) (*mcp.CallToolResult, error) {
+		<-block
+		return nil, nil
+	})
+	m.flush()
+
+	// A new process keeps the results and fails the jobs it cannot resume
+	reloaded, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager() reloading error = %v", err)
+	}
+	res, _, err := NewTools(reloaded).Result(context.Background(), nil, IDInput{ID: done.ID})
+	if err != nil || res.Content[0].(*mcp.TextContent).Text != "kept" {
+		t.Errorf("Result() after reload = %+v, %v", res, err)
+	}
+	if r, err := reloaded.Get(running.ID, ""); err != nil || r.State != StateFailed || r.Error.Code != tools.CodeUnavailable {
+		t.Errorf("interrupted job after reload = %+v, %v", r, err)
+	}
+
+	if _, err := NewManager(Config{Backend: "redis"}); err == nil {
+		t.Error("NewManager() with an unknown backend succeeded")
+	}
+}
@@ -0,0 +1,367 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// States of a job.
+const (
+	StateQueued    = "queued"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCanceled  = "canceled"
+)
+
+// ErrNotFound is returned for jobs that do not exist, have expired, or
+// belong to another caller.
+var ErrNotFound = errors.New("job not found")
+
+// Func is the work of a job. It should return soon after ctx is canceled
+// by job_cancel. Its result is what job_result returns.
+type Func func(ctx context.Context) (*mcp.CallToolResult, error)
+
+// Record is a job as kept by the Manager and persisted in the jobs file.
+type Record struct {
+	ID     string `json:"id"`
+	Tool   string `json:"tool"`
+	Caller string `json:"caller,omitempty"`
+	State  string `json:"state"`
+	// Result is the job's result once it succeeded.
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error is the error of a failed or canceled job.
+	Error    *tools.Error `json:"error,omitempty"`
+	Created  time.Time    `json:"created"`
+	Started  time.Time    `json:"started,omitzero"`
+	Finished time.Time    `json:"finished,omitzero"`
+}
+
+func (r *Record) done() bool {
+	return r.State == StateSucceeded || r.State == StateFailed || r.State == StateCanceled
+}
+
+// Manager runs jobs on a fixed number of workers, in the order they were
+// started, and keeps their records until cfg.TTL after they finish. With
+// cfg.Backend "file" the records are written to cfg.FilePath after every
+// change, so results survive a restart; jobs that were queued or running
+// when the server stopped are failed when the file is loaded.
+type Manager struct {
+	cfg   Config
+	queue chan job
+	now   func() time.Time
+
+	mu      sync.Mutex
+	records map[string]*Record
+	cancels map[string]context.CancelFunc
+	start   sync.Once
+
+	// With the file backend, save marks the records changed and a single
+	// writer goroutine writes them out, so bursts of changes coalesce
+	// into one write and the file is never written under mu.
+	dirty   chan struct{}
+	changes uint64
+	written uint64
+	wrote   *sync.Cond
+}
+
+type job struct {
+	id  string
+	ctx context.Context
+	fn  Func
+}
+
+// NewManager creates a Manager for cfg, loading the jobs file when the
+// backend is "file". Workers start with the first job.
+func NewManager(cfg Config) (*Manager, error) {
+	m := &Manager{
+		cfg:     cfg,
+		queue:   make(chan job, cfg.MaxJobs),
+		now:     time.Now,
+		records: make(map[string]*Record),
+		cancels: make(map[string]context.CancelFunc),
+	}
+	switch cfg.Backend {
+	case "", "memory":
+	case "file":
+		m.dirty = make(chan struct{}, 1)
+		m.wrote = sync.NewCond(&m.mu)
+		if err := m.load(); err != nil {
+			return nil, err
+		}
+		go m.write()
+	default:
+		return nil, fmt.Errorf("unsupported jobs backend %q: use memory or file", cfg.Backend)
+	}
+	return m, nil
+}
+
+// Start queues fn as a job of tool on behalf of caller and returns its
+// record. It fails with resource_exhausted when cfg.MaxJobs jobs are
+// kept already.
+func (m *Manager) Start(tool, caller string, fn Func) (Record, error) {
+	m.start.Do(func() {
+		for range m.cfg.Workers {
+			go m.work()
+		}
+	})
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	if len(m.records) >= m.cfg.MaxJobs {
+		cancel()
+		return Record{}, tools.NewError(tools.CodeResourceExhausted, "too many jobs; wait for some to finish or expire").
+			Retry(true).WithDetail("max_jobs", m.cfg.MaxJobs)
+	}
+	r := &Record{ID: hex.EncodeToString(id), Tool: tool, Caller: caller, State: StateQueued, Created: m.now().UTC()}
+	select {
+	case m.queue <- job{id: r.ID, ctx: ctx, fn: fn}:
+	default:
+		// Only jobs canceled while queued, and since expired, can fill
+		// the queue beyond the records kept
+		cancel()
+		return Record{}, tools.NewError(tools.CodeResourceExhausted, "too many jobs queued; try again later").Retry(true)
+	}
+	m.records[r.ID] = r
+	m.cancels[r.ID] = cancel
+	m.save()
+	return *r, nil
+}
+
+// Get returns the record of job id started by caller.
+func (m *Manager) Get(id, caller string) (Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	r, ok := m.records[id]
+	if !ok || r.Caller != caller {
+		return Record{}, ErrNotFound
+	}
+	return *r, nil
+}
+
+// List returns the records of the jobs started by caller, newest first.
+func (m *Manager) List(caller string) []Record {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	var out []Record
+	for _, r := range m.records {
+		if r.Caller == caller {
+			out = append(out, *r)
+		}
+	}
+	slices.SortFunc(out, func(a, b Record) int { return b.Created.Compare(a.Created) })
+	return out
+}
+
+// Cancel cancels job id started by caller. A queued job never runs; a
+// running one is canceled once its Func returns. Canceling a finished
+// job changes nothing.
+func (m *Manager) Cancel(id, caller string) (Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.records[id]
+	if !ok || r.Caller != caller {
+		return Record{}, ErrNotFound
+	}
+	if cancel := m.cancels[id]; cancel != nil {
+		cancel()
+	}
+	if r.State == StateQueued {
+		m.finish(r, nil, context.Canceled)
+	}
+	return *r, nil
+}
+
+// work runs queued jobs until the process exits.
+func (m *Manager) work() {
+	for j := range m.queue {
+		m.mu.Lock()
+		r := m.records[j.id]
+		if r == nil || r.State != StateQueued {
+			// Canceled or expired while queued
+			m.mu.Unlock()
+			continue
+		}
+		r.State, r.Started = StateRunning, m.now().UTC()
+		m.save()
+		m.mu.Unlock()
+
+		res, err := run(j)
+
+		m.mu.Lock()
+		if j.ctx.Err() != nil && err == nil {
+			err = j.ctx.Err()
+		}
+		m.finish(r, res, err)
+		m.mu.Unlock()
+	}
+}
+
+// run calls j's Func, turning a panic into an internal error so that one
+// job cannot stop the worker.
+func run(j job) (res *mcp.CallToolResult, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			res, err = nil, tools.NewError(tools.CodeInternal, "job failed unexpectedly: %v", p)
+		}
+	}()
+	return j.fn(j.ctx)
+}
+
+// finish records the outcome of r. The caller must hold m.mu.
+func (m *Manager) finish(r *Record, res *mcp.CallToolResult, err error) {
+	if cancel := m.cancels[r.ID]; cancel != nil {
+		cancel()
+		delete(m.cancels, r.ID)
+	}
+	r.Finished = m.now().UTC()
+	switch {
+	case errors.Is(err, context.Canceled):
+		r.State, r.Error = StateCanceled, tools.AsError(err)
+	case err != nil:
+		r.State, r.Error = StateFailed, tools.AsError(err)
+	default:
+		if res == nil {
+			res = &mcp.CallToolResult{Content: []mcp.Content{}}
+		}
+		data, merr := json.Marshal(res)
+		if merr != nil {
+			r.State, r.Error = StateFailed, tools.WrapError(tools.CodeInternal, merr, "encoding the result")
+			break
+		}
+		r.State, r.Result = StateSucceeded, data
+	}
+	m.save()
+}
+
+// sweep drops finished jobs older than cfg.TTL. The caller must hold m.mu.
+func (m *Manager) sweep() {
+	cutoff := m.now().Add(-m.cfg.TTL)
+	changed := false
+	for id, r := range m.records {
+		if r.done() && r.Finished.Before(cutoff) {
+			delete(m.records, id)
+			changed = true
+		}
+	}
+	if changed {
+		m.save()
+	}
+}
+
+// load reads the jobs file, if it exists. Jobs left unfinished by the
+// previous process cannot resume and are failed.
+func (m *Manager) load() error {
+	data, err := os.ReadFile(m.cfg.FilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var records []*Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("parsing %s: %w", m.cfg.FilePath, err)
+	}
+	for _, r := range records {
+		if !r.done() {
+			r.State, r.Finished = StateFailed, m.now().UTC()
+			r.Error = tools.NewError(tools.CodeUnavailable, "job interrupted by a server restart")
+		}
+		m.records[r.ID] = r
+	}
+	m.sweep()
+	return nil
+}
+
+// save marks the records changed for the writer with the file backend.
+// The caller must hold m.mu.
+func (m *Manager) save() {
+	if m.dirty == nil {
+		return
+	}
+	m.changes++
+	select {
+	case m.dirty <- struct{}{}:
+	default:
+		// A write is pending already and will include this change
+	}
+}
+
+// write writes the records to the jobs file whenever they change, through
+// a temporary file renamed into place so that a crash never leaves it
+// partially written. The records are copied under m.mu and encoded and
+// written outside it. Failures are logged: the records stay in memory.
+func (m *Manager) write() {
+	for range m.dirty {
+		m.mu.Lock()
+		version := m.changes
+		records := make([]Record, 0, len(m.records))
+		for _, r := range m.records {
+			records = append(records, *r)
+		}
+		m.mu.Unlock()
+
+		if err := writeFile(m.cfg.FilePath, records); err != nil {
+			logger.Error("jobs file not written", "file", m.cfg.FilePath, "error", err)
+		}
+
+		m.mu.Lock()
+		m.written = version
+		m.wrote.Broadcast()
+		m.mu.Unlock()
+	}
+}
+
+// flush waits until every change so far has been written to the jobs
+// file.
+func (m *Manager) flush() {
+	if m.dirty == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for m.written < m.changes {
+		m.wrote.Wait()
+	}
+}
+
+func writeFile(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".jobs-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,231 @@
+package jsontool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// MaxInputBytes caps the size of JSON documents accepted by the tools.
+const MaxInputBytes = 5 << 20
+
+// QueryInput is the input for the json_query tool.
+type QueryInput struct {
+	JSON string `json:"json" jsonschema:"the JSON document to query"`
+	Path string `json:"path" jsonschema:"JSONPath ($.items[*].name, $..id, $.list[1:3]) or jq-style (.items[0].name) expression"`
+}
+
+// QueryOutput is the output of the json_query tool.
+type QueryOutput struct {
+	Results []any `json:"results" jsonschema:"all values matched by the path, in document order"`
+	Count   int   `json:"count" jsonschema:"the number of matched values"`
+}
+
+// ValidateInput is the input for the json_validate tool.
+type ValidateInput struct {
+	JSON string `json:"json" jsonschema:"the JSON document to validate"`
+}
+
+// ValidateOutput is the output of the json_validate tool.
+type ValidateOutput struct {
+	Valid  bool   `json:"valid" jsonschema:"whether the document is well-formed JSON"`
+	Error  string `json:"error,omitempty" jsonschema:"the parse error, if any"`
+	Line   int    `json:"line,omitempty" jsonschema:"1-based line of the parse error"`
+	Column int    `json:"column,omitempty" jsonschema:"1-based column of the parse error"`
+}
+
+// FormatInput is the input for the json_format tool.
+type FormatInput struct {
+	JSON     string `json:"json" jsonschema:"the JSON document to format"`
+	Compact  bool   `json:"compact,omitempty" jsonschema:"emit compact single-line JSON instead of indenting"`
+	Indent   int    `json:"indent,omitempty" jsonschema:"spaces per indent level when not compact (default: 2, max: 8)"`
+	SortKeys bool   `json:"sort_keys,omitempty" jsonschema:"sort object keys alphabetically"`
+}
+
+// FormatOutput is the output of the json_format tool.
+type FormatOutput struct {
+	JSON string `json:"json" jsonschema:"the formatted JSON"`
+}
+
+// Query evaluates a path expression against a JSON document.
+func Query(_ context.Context, _ *mcp.CallToolRequest, input QueryInput) (*mcp.CallToolResult, QueryOutput, error) {
+	doc, err := decodeArg(input.JSON)
+	if err != nil {
+		return nil, QueryOutput{}, err
+	}
+
+	segs, err := compilePath(input.Path)
+	if err != nil {
+		return nil, QueryOutput{}, err
+	}
+
+	results, err := evaluate(doc, segs)
+	if err != nil {
+		return nil, QueryOutput{}, err
+	}
+	if results == nil {
+		results = []any{}
+	}
+
+	logger.Info("tool called", "tool", "json_query", "path", input.Path, "matches", len(results))
+	return nil, QueryOutput{Results: results, Count: len(results)}, nil
+}
+
+// Validate reports whether a document is well-formed JSON, with the error
+// position when it is not.
+func Validate(_ context.Context, _ *mcp.CallToolRequest, input ValidateInput) (*mcp.CallToolResult, ValidateOutput, error) {
+	if len(input.JSON) > MaxInputBytes {
+		return nil, ValidateOutput{}, tools.NewError(tools.CodeResourceExhausted, "json exceeds %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+
+	out := ValidateOutput{Valid: true}
+	if _, err := decode(input.JSON); err != nil {
+		out = ValidateOutput{Error: err.Error()}
+		var parseErr *parseError
+		if errors.As(err, &parseErr) {
+			out.Line, out.Column = position(input.JSON, parseErr.offset)
+		}
+	}
+
+	logger.Info("tool called", "tool", "json_validate", "valid", out.Valid)
+	return nil, out, nil
+}
+
+// Format pretty-prints or compacts a JSON document.
+func Format(_ context.Context, _ *mcp.CallToolRequest, input FormatInput) (*mcp.CallToolResult, FormatOutput, error) {
+	if input.Indent < 0 || input.Indent > 8 {
+		return nil, FormatOutput{}, tools.NewError(tools.CodeInvalidArgument, "indent must be between 0 and 8")
+	}
+	doc, err := decodeArg(input.JSON)
+	if err != nil {
+		return nil, FormatOutput{}, err
+	}
+
+	// Re-encoding from the decoded value sorts keys; otherwise operate on
+	// the original bytes to preserve member order.
+	src := []byte(input.JSON)
+	if input.SortKeys {
+		if src, err = json.Marshal(doc); err != nil {
+			return nil, FormatOutput{}, tools.WrapError(tools.CodeInternal, err, "failed to encode json")
+		}
+	}
+
+	var buf bytes.Buffer
+	if input.Compact {
+		err = json.Compact(&buf, src)
+	} else {
+		indent := input.Indent
+		if indent == 0 {
+			indent = 2
+		}
+		err = json.Indent(&buf, src, "", strings.Repeat(" ", indent))
+	}
+	if err != nil {
+		return nil, FormatOutput{}, tools.WrapError(tools.CodeInternal, err, "failed to format json")
+	}
+
+	logger.Info("tool called", "tool", "json_format", "compact", input.Compact, "sort_keys", input.SortKeys)
+	return nil, FormatOutput{JSON: buf.String()}, nil
+}
+
+// decodeArg decodes a JSON argument of a tool, reporting malformed JSON as
+// an invalid argument.
+func decodeArg(data string) (any, error) {
+	v, err := decode(data)
+	var terr *tools.Error
+	if err != nil && !errors.As(err, &terr) {
+		return nil, &tools.Error{Code: tools.CodeInvalidArgument, Message: err.Error(), Err: err}
+	}
+	return v, err
+}
+
+// decode parses a single JSON value, preserving number precision and
+// rejecting trailing data.
+func decode(data string) (any, error) {
+	if len(data) > MaxInputBytes {
+		return nil, tools.NewError(tools.CodeResourceExhausted, "json exceeds %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// Offset counts the offending byte; point at it rather than past it.
+			return nil, &parseError{msg: syntaxErr.Error(), offset: max(syntaxErr.Offset-1, 0)}
+		}
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+
+	offset := dec.InputOffset()
+	var extra any
+	if err := dec.Decode(&extra); !errors.Is(err, io.EOF) {
+		rest := data[offset:]
+		offset += int64(len(rest) - len(strings.TrimLeft(rest, " \t\r\n")))
+		return nil, &parseError{msg: "unexpected data after top-level value", offset: offset}
+	}
+	return v, nil
+}
+
+// parseError is a JSON syntax error with the 0-based offset of the
+// offending byte.
+type parseError struct {
+	msg    string
+	offset int64
+}
+
+// Error implements the error interface.
+func (e *parseError) Error() string {
+	return "invalid json: " + e.msg
+}
+
+// position converts a 0-based byte offset into a 1-based line and column.
+func position(data string, offset int64) (line, column int) {
+	prefix := data[:min(offset, int64(len(data)))]
+	line = strings.Count(prefix, "\n") + 1
+	column = len(prefix) - strings.LastIndexByte(prefix, '\n')
+	return line, column
+}
+
+// sortedKeys returns the keys of m in lexical order.
+func sortedKeys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+func init() {
+	tools.Register(tools.Namespace("data", func(server *mcp.Server) {
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "json_query",
+			Description: "Evaluate a JSONPath or jq-style path expression against a JSON document",
+			Annotations: tools.ReadOnly(false),
+		}, Query)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "json_validate",
+			Description: "Check whether a document is well-formed JSON and report the error position",
+			Annotations: tools.ReadOnly(false),
+		}, Validate)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "json_format",
+			Description: "Pretty-print or compact a JSON document, optionally sorting keys",
+			Annotations: tools.ReadOnly(false),
+		}, Format)
+	}))
+}
@@ -0,0 +1,159 @@
+package jsontool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+const sampleDoc = `{
+  "store": {
+    "name": "corner shop",
+    "books": [
+      {"title": "Go", "price": 30, "tags": ["lang"]},
+      {"title": "Rust", "price": 40},
+      {"title": "Zig", "price": 25}
+    ]
+  },
+  "id": 12345678901234567890
+}`
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "root", path: "$", want: ""},
+		{name: "member", path: "$.store.name", want: `["corner shop"]`},
+		{name: "jq style", path: ".store.books[0].title", want: `["Go"]`},
+		{name: "bare member", path: "store.name", want: `["corner shop"]`},
+		{name: "quoted member", path: `$['store']["name"]`, want: `["corner shop"]`},
+		{name: "negative index", path: "$.store.books[-1].title", want: `["Zig"]`},
+		{name: "wildcard", path: "$.store.books[*].price", want: `[30,40,25]`},
+		{name: "dot wildcard", path: "$.store.books.*.title", want: `["Go","Rust","Zig"]`},
+		{name: "slice", path: "$.store.books[1:].title", want: `["Rust","Zig"]`},
+		{name: "recursive", path: "$..title", want: `["Go","Rust","Zig"]`},
+		{name: "recursive index", path: "$..tags[0]", want: `["lang"]`},
+		{name: "large number preserved", path: "$.id", want: `[12345678901234567890]`},
+		{name: "missing member", path: "$.nope", want: `[]`},
+		{name: "index out of range", path: "$.store.books[9]", want: `[]`},
+		{name: "bad index", path: "$.store.books[x]", wantErr: true},
+		{name: "unterminated bracket", path: "$.store[0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Query(context.Background(), &mcp.CallToolRequest{}, QueryInput{JSON: sampleDoc, Path: tt.path})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && tools.AsError(err).Code != tools.CodeInvalidArgument {
+				t.Errorf("Query() error code = %s, want %s", tools.AsError(err).Code, tools.CodeInvalidArgument)
+			}
+			if tt.wantErr || tt.want == "" {
+				return
+			}
+			got, err := json.Marshal(out.Results)
+			if err != nil {
+				t.Fatalf("marshal results: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Results = %s, want %s", got, tt.want)
+			}
+			if out.Count != len(out.Results) {
+				t.Errorf("Count = %d, want %d", out.Count, len(out.Results))
+			}
+		})
+	}
+}
+
+func TestQuery_InvalidJSON(t *testing.T) {
+	if _, _, err := Query(context.Background(), &mcp.CallToolRequest{}, QueryInput{JSON: "{", Path: "$"}); err == nil {
+		t.Fatal("expected error for invalid json")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       string
+		wantValid  bool
+		wantLine   int
+		wantColumn int
+	}{
+		{name: "valid object", json: `{"a": [1, 2]}`, wantValid: true},
+		{name: "valid scalar", json: `"x"`, wantValid: true},
+		{name: "error on second line", json: "{\n  \"a\": ,\n}", wantLine: 2, wantColumn: 8},
+		{name: "trailing data", json: `{} {}`, wantLine: 1, wantColumn: 4},
+		{name: "empty", json: ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Validate(context.Background(), &mcp.CallToolRequest{}, ValidateInput{JSON: tt.json})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Valid != tt.wantValid {
+				t.Errorf("Valid = %v, want %v (error: %s)", out.Valid, tt.wantValid, out.Error)
+			}
+			if !tt.wantValid && out.Error == "" {
+				t.Error("expected error message for invalid json")
+			}
+			if out.Line != tt.wantLine || out.Column != tt.wantColumn {
+				t.Errorf("position = %d:%d, want %d:%d", out.Line, out.Column, tt.wantLine, tt.wantColumn)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   FormatInput
+		want    string
+		wantErr bool
+	}{
+		{name: "default indent preserves order", input: FormatInput{JSON: `{"b":1,"a":[1]}`}, want: "{\n  \"b\": 1,\n  \"a\": [\n    1\n  ]\n}"},
+		{name: "compact", input: FormatInput{JSON: "{ \"b\" : 1 ,\n \"a\" : 2 }", Compact: true}, want: `{"b":1,"a":2}`},
+		{name: "sort keys compact", input: FormatInput{JSON: `{"b":1,"a":2}`, Compact: true, SortKeys: true}, want: `{"a":2,"b":1}`},
+		{name: "custom indent", input: FormatInput{JSON: `{"a":1}`, Indent: 4}, want: "{\n    \"a\": 1\n}"},
+		{name: "invalid indent", input: FormatInput{JSON: `{}`, Indent: 20}, wantErr: true},
+		{name: "invalid json", input: FormatInput{JSON: `{"a":}`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Format(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Format() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && tools.AsError(err).Code != tools.CodeInvalidArgument {
+				t.Errorf("Format() error code = %s, want %s", tools.AsError(err).Code, tools.CodeInvalidArgument)
+			}
+			if !tt.wantErr && out.JSON != tt.want {
+				t.Errorf("JSON = %q, want %q", out.JSON, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode_TooLarge(t *testing.T) {
+	if _, err := decode(`"` + strings.Repeat("a", MaxInputBytes) + `"`); err == nil {
+		t.Fatal("expected error for oversized input")
+	}
+}
+
+func TestEvaluate_ResultLimit(t *testing.T) {
+	items := make([]any, MaxResults+1)
+	if _, err := evaluate(map[string]any{"items": items}, []segment{{key: "items"}, {wildcard: true}}); err == nil {
+		t.Fatal("expected error when result limit is exceeded")
+	}
+}
@@ -0,0 +1,222 @@
+package jsontool
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// MaxResults bounds the number of values a single path expression may yield,
+// so recursive descent over large documents cannot exhaust memory.
+const MaxResults = 10000
+
+// segment is a single step of a compiled path expression.
+type segment struct {
+	key       string // object member name; empty with wildcard or index
+	index     *int   // array index (negative counts from the end)
+	slice     *[2]*int
+	wildcard  bool
+	recursive bool // descend into every nested value before matching
+}
+
+// compilePath parses a JSONPath ($.a.b[0], $..name, $.items[*].id, $.a[1:3])
+// or jq-style (.a.b[0]) expression into segments.
+func compilePath(expr string) ([]segment, error) {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case expr == "", expr == "$", expr == ".":
+		return nil, nil
+	case strings.HasPrefix(expr, "$"):
+		expr = expr[1:]
+	case !strings.HasPrefix(expr, ".") && !strings.HasPrefix(expr, "["):
+		expr = "." + expr
+	}
+
+	var segs []segment
+	for i := 0; i < len(expr); {
+		recursive := false
+		switch expr[i] {
+		case '.':
+			i++
+			if i < len(expr) && expr[i] == '.' {
+				recursive = true
+				i++
+			}
+			if i < len(expr) && expr[i] == '[' {
+				if !recursive {
+					continue
+				}
+				seg, n, err := parseBracket(expr[i:])
+				if err != nil {
+					return nil, err
+				}
+				seg.recursive = true
+				segs = append(segs, seg)
+				i += n
+				continue
+			}
+			start := i
+			for i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+				i++
+			}
+			name := expr[start:i]
+			if name == "" {
+				if recursive {
+					return nil, tools.NewError(tools.CodeInvalidArgument, "path %q: expected member name after '..'", expr)
+				}
+				continue
+			}
+			seg := segment{key: name, recursive: recursive}
+			if name == "*" {
+				seg = segment{wildcard: true, recursive: recursive}
+			}
+			segs = append(segs, seg)
+		case '[':
+			seg, n, err := parseBracket(expr[i:])
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, seg)
+			i += n
+		default:
+			return nil, tools.NewError(tools.CodeInvalidArgument, "path: unexpected character %q at offset %d", expr[i], i)
+		}
+	}
+	return segs, nil
+}
+
+// parseBracket parses a bracketed selector starting at s[0] == '[' and
+// returns the segment and the number of bytes consumed.
+func parseBracket(s string) (segment, int, error) {
+	if len(s) > 1 && (s[1] == '\'' || s[1] == '"') {
+		quote := s[1]
+		closeQuote := strings.IndexByte(s[2:], quote)
+		if closeQuote < 0 || len(s) < closeQuote+4 || s[closeQuote+3] != ']' {
+			return segment{}, 0, tools.NewError(tools.CodeInvalidArgument, "path: unterminated quoted member in %q", s)
+		}
+		return segment{key: s[2 : closeQuote+2]}, closeQuote + 4, nil
+	}
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return segment{}, 0, tools.NewError(tools.CodeInvalidArgument, "path: missing ']' in %q", s)
+	}
+
+	body := strings.TrimSpace(s[1:end])
+	switch {
+	case body == "*" || body == "":
+		return segment{wildcard: true}, end + 1, nil
+	case strings.Contains(body, ":"):
+		parts := strings.SplitN(body, ":", 2)
+		var bounds [2]*int
+		for j, p := range parts {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return segment{}, 0, tools.NewError(tools.CodeInvalidArgument, "path: invalid slice bound %q", p)
+			}
+			bounds[j] = &n
+		}
+		return segment{slice: &bounds}, end + 1, nil
+	default:
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return segment{}, 0, tools.NewError(tools.CodeInvalidArgument, "path: invalid index %q", body)
+		}
+		return segment{index: &n}, end + 1, nil
+	}
+}
+
+// evaluate applies segments to root and returns all matching values.
+func evaluate(root any, segs []segment) ([]any, error) {
+	current := []any{root}
+	for _, seg := range segs {
+		var next []any
+		for _, v := range current {
+			candidates := []any{v}
+			if seg.recursive {
+				candidates = descendants(v, nil)
+			}
+			for _, c := range candidates {
+				next = apply(c, seg, next)
+				if len(next) > MaxResults {
+					return nil, tools.NewError(tools.CodeResourceExhausted, "path matched more than %d values", MaxResults).
+						WithDetail("max_results", MaxResults)
+				}
+			}
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// apply appends the children of v selected by seg to out.
+func apply(v any, seg segment, out []any) []any {
+	switch node := v.(type) {
+	case map[string]any:
+		if seg.wildcard {
+			for _, k := range sortedKeys(node) {
+				out = append(out, node[k])
+			}
+		} else if seg.index == nil && seg.slice == nil {
+			if child, ok := node[seg.key]; ok {
+				out = append(out, child)
+			}
+		}
+	case []any:
+		switch {
+		case seg.wildcard:
+			out = append(out, node...)
+		case seg.index != nil:
+			i := *seg.index
+			if i < 0 {
+				i += len(node)
+			}
+			if i >= 0 && i < len(node) {
+				out = append(out, node[i])
+			}
+		case seg.slice != nil:
+			lo, hi := sliceBounds(len(node), *seg.slice)
+			out = append(out, node[lo:hi]...)
+		}
+	}
+	return out
+}
+
+// descendants returns v and every value nested beneath it, depth first.
+func descendants(v any, out []any) []any {
+	out = append(out, v)
+	switch node := v.(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(node) {
+			out = descendants(node[k], out)
+		}
+	case []any:
+		for _, child := range node {
+			out = descendants(child, out)
+		}
+	}
+	return out
+}
+
+// sliceBounds resolves optional, possibly negative slice bounds.
+func sliceBounds(n int, bounds [2]*int) (int, int) {
+	resolve := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += n
+		}
+		return min(max(i, 0), n)
+	}
+	lo, hi := resolve(bounds[0], 0), resolve(bounds[1], n)
+	if lo > hi {
+		lo = hi
+	}
+	return lo, hi
+}
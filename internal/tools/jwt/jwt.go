@@ -0,0 +1,169 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/jose"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+	"github.com/lkendrickd/mcp-server/internal/tools/httpfetch"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// MaxTokenBytes caps the size of tokens accepted by the tool.
+const MaxTokenBytes = 64 << 10
+
+// FetchFunc retrieves the document at url, typically a JWKS.
+type FetchFunc func(ctx context.Context, url string) ([]byte, error)
+
+// Input is the input for the jwt tool.
+type Input struct {
+	Token     string `json:"token" jsonschema:"the compact-serialized JWT"`
+	Secret    string `json:"secret,omitempty" jsonschema:"shared secret for verifying HS256/HS384/HS512 signatures"`
+	JWKSURL   string `json:"jwks_url,omitempty" jsonschema:"JWKS URL for verifying RS*, PS*, ES*, and EdDSA signatures; the host must be allow-listed"`
+	LeewaySec int    `json:"leeway_seconds,omitempty" jsonschema:"clock skew tolerance applied to exp and nbf checks (default: 0)"`
+}
+
+// Output is the output of the jwt tool.
+type Output struct {
+	Header            map[string]any `json:"header" jsonschema:"the decoded JOSE header"`
+	Claims            map[string]any `json:"claims" jsonschema:"the decoded claims"`
+	SignatureVerified *bool          `json:"signature_verified,omitempty" jsonschema:"whether the signature verified; omitted when no secret or jwks_url was given"`
+	VerificationError string         `json:"verification_error,omitempty" jsonschema:"why verification failed"`
+	ExpiresAt         string         `json:"expires_at,omitempty" jsonschema:"the exp claim as RFC 3339"`
+	NotBefore         string         `json:"not_before,omitempty" jsonschema:"the nbf claim as RFC 3339"`
+	IssuedAt          string         `json:"issued_at,omitempty" jsonschema:"the iat claim as RFC 3339"`
+	Expired           bool           `json:"expired" jsonschema:"whether exp is in the past"`
+	NotYetValid       bool           `json:"not_yet_valid" jsonschema:"whether nbf is in the future"`
+	Valid             bool           `json:"valid" jsonschema:"true when the signature verified and the token is within its validity window"`
+}
+
+// Inspector decodes and verifies JWTs.
+type Inspector struct {
+	fetch FetchFunc
+	now   func() time.Time
+}
+
+// NewInspector creates an Inspector that retrieves JWKS documents with fetch.
+// A nil fetch disables JWKS verification.
+func NewInspector(fetch FetchFunc) *Inspector {
+	return &Inspector{fetch: fetch, now: time.Now}
+}
+
+// Inspect decodes a JWT and optionally verifies its signature.
+func (in *Inspector) Inspect(ctx context.Context, _ *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	token := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(input.Token), "Bearer "))
+	if token == "" {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "token is required")
+	}
+	if len(token) > MaxTokenBytes {
+		return nil, Output{}, tools.NewError(tools.CodeResourceExhausted, "token exceeds %d bytes", MaxTokenBytes).
+			WithDetail("max_bytes", MaxTokenBytes)
+	}
+	if input.Secret != "" && input.JWKSURL != "" {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "provide either secret or jwks_url, not both")
+	}
+
+	tok, err := jose.Parse(token)
+	if err != nil {
+		return nil, Output{}, &tools.Error{Code: tools.CodeInvalidArgument, Message: err.Error(), Err: err}
+	}
+	out := Output{Header: tok.Header, Claims: tok.Claims}
+
+	now := in.now()
+	leeway := time.Duration(input.LeewaySec) * time.Second
+	if exp, ok := jose.NumericDate(out.Claims["exp"]); ok {
+		out.ExpiresAt = exp.Format(time.RFC3339)
+		out.Expired = !now.Before(exp.Add(leeway))
+	}
+	if nbf, ok := jose.NumericDate(out.Claims["nbf"]); ok {
+		out.NotBefore = nbf.Format(time.RFC3339)
+		out.NotYetValid = now.Add(leeway).Before(nbf)
+	}
+	if iat, ok := jose.NumericDate(out.Claims["iat"]); ok {
+		out.IssuedAt = iat.Format(time.RFC3339)
+	}
+
+	if input.Secret != "" || input.JWKSURL != "" {
+		err := in.verify(ctx, tok, input)
+		verified := err == nil
+		out.SignatureVerified = &verified
+		if err != nil {
+			out.VerificationError = err.Error()
+		}
+		out.Valid = verified && !out.Expired && !out.NotYetValid
+	}
+
+	logger.Info("tool called", "tool", "jwt", "alg", out.Header["alg"], "verified", out.SignatureVerified != nil && *out.SignatureVerified, "expired", out.Expired)
+	return nil, out, nil
+}
+
+// verify checks the token signature using the secret or JWKS in input. Its
+// errors are reported in the output rather than failing the call.
+func (in *Inspector) verify(ctx context.Context, tok *jose.Token, input Input) error {
+	if input.Secret != "" {
+		return tok.VerifyHMAC([]byte(input.Secret))
+	}
+	if in.fetch == nil {
+		return fmt.Errorf("JWKS verification is not enabled")
+	}
+	data, err := in.fetch(ctx, input.JWKSURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	set, err := jose.ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	return tok.VerifyJWKS(set)
+}
+
+// newJWKSFetch returns a FetchFunc backed by the http_fetch SSRF protections,
+// restricted to JWT_JWKS_ALLOWED_HOSTS. It returns nil when no hosts are
+// allowed so that JWKS verification is disabled by default.
+func newJWKSFetch() FetchFunc {
+	hosts := config.GetEnvList("JWT_JWKS_ALLOWED_HOSTS")
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	fetcher := httpfetch.NewFetcher(httpfetch.Config{
+		AllowedHosts: hosts,
+		MaxBytes:     1 << 20,
+		Timeout:      config.GetEnvDuration("JWT_JWKS_TIMEOUT", 10*time.Second),
+		MaxRedirects: 3,
+	})
+	return func(ctx context.Context, url string) ([]byte, error) {
+		_, out, err := fetcher.Fetch(ctx, &mcp.CallToolRequest{}, httpfetch.Input{URL: url})
+		if err != nil {
+			return nil, err
+		}
+		if out.StatusCode != 200 {
+			return nil, fmt.Errorf("unexpected status %d", out.StatusCode)
+		}
+		if out.Truncated || out.BodyEncoding != "text" {
+			return nil, fmt.Errorf("response is not a valid JWKS document")
+		}
+		return []byte(out.Body), nil
+	}
+}
+
+func init() {
+	tools.RegisterConfig(func() { newJWKSFetch() })
+	tools.Register(tools.Namespace("data", func(server *mcp.Server) {
+		in := NewInspector(newJWKSFetch())
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "jwt",
+			Description: "Decode a JWT's header and claims, check exp/nbf validity, and optionally verify its signature with a shared secret or JWKS URL",
+			Annotations: tools.ReadOnly(true),
+		}, in.Inspect)
+	}))
+}
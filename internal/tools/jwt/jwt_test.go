@@ -0,0 +1,260 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var testNow = time.Unix(1_700_000_000, 0)
+
+func b64(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+
+func b64JSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return b64(data)
+}
+
+// signingInput builds the first two token segments.
+func signingInput(t *testing.T, header, claims map[string]any) string {
+	return b64JSON(t, header) + "." + b64JSON(t, claims)
+}
+
+func hsToken(t *testing.T, secret string, claims map[string]any) string {
+	t.Helper()
+	in := signingInput(t, map[string]any{"alg": "HS256", "typ": "JWT"}, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(in))
+	return in + "." + b64(mac.Sum(nil))
+}
+
+func digest(in string) []byte {
+	sum := sha256.Sum256([]byte(in))
+	return sum[:]
+}
+
+func newInspector(jwks map[string]any) *Inspector {
+	in := NewInspector(func(_ context.Context, url string) ([]byte, error) {
+		if url != "https://issuer.example.com/jwks.json" {
+			return nil, errors.New("host not allowed")
+		}
+		return json.Marshal(jwks)
+	})
+	in.now = func() time.Time { return testNow }
+	return in
+}
+
+func TestInspect_HMAC(t *testing.T) {
+	in := newInspector(nil)
+	valid := map[string]any{"sub": "alice", "exp": testNow.Add(time.Hour).Unix(), "iat": testNow.Unix()}
+
+	tests := []struct {
+		name         string
+		input        Input
+		wantVerified *bool
+		wantValid    bool
+		wantExpired  bool
+		wantNotYet   bool
+		wantErr      string
+		wantCode     tools.Code
+	}{
+		{name: "decode only", input: Input{Token: hsToken(t, "s3cret", valid)}},
+		{name: "valid secret", input: Input{Token: hsToken(t, "s3cret", valid), Secret: "s3cret"}, wantVerified: ptr(true), wantValid: true},
+		{name: "bearer prefix", input: Input{Token: "Bearer " + hsToken(t, "s3cret", valid), Secret: "s3cret"}, wantVerified: ptr(true), wantValid: true},
+		{name: "wrong secret", input: Input{Token: hsToken(t, "s3cret", valid), Secret: "nope"}, wantVerified: ptr(false)},
+		{
+			name:         "expired",
+			input:        Input{Token: hsToken(t, "k", map[string]any{"exp": testNow.Add(-time.Minute).Unix()}), Secret: "k"},
+			wantVerified: ptr(true),
+			wantExpired:  true,
+		},
+		{
+			name:         "expired within leeway",
+			input:        Input{Token: hsToken(t, "k", map[string]any{"exp": testNow.Add(-time.Minute).Unix()}), Secret: "k", LeewaySec: 120},
+			wantVerified: ptr(true),
+			wantValid:    true,
+		},
+		{
+			name:         "not yet valid",
+			input:        Input{Token: hsToken(t, "k", map[string]any{"nbf": testNow.Add(time.Hour).Unix()}), Secret: "k"},
+			wantVerified: ptr(true),
+			wantNotYet:   true,
+		},
+		{name: "malformed", input: Input{Token: "abc.def"}, wantErr: "expected 3 segments", wantCode: tools.CodeInvalidArgument},
+		{name: "bad header", input: Input{Token: "!!!.e30.sig"}, wantErr: "malformed header", wantCode: tools.CodeInvalidArgument},
+		{name: "empty", input: Input{}, wantErr: "token is required", wantCode: tools.CodeInvalidArgument},
+		{name: "both secret and jwks", input: Input{Token: "a.b.c", Secret: "x", JWKSURL: "https://x"}, wantErr: "not both", wantCode: tools.CodeInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := in.Inspect(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			switch {
+			case tt.wantVerified == nil && out.SignatureVerified != nil:
+				t.Errorf("SignatureVerified = %v, want nil", *out.SignatureVerified)
+			case tt.wantVerified != nil && (out.SignatureVerified == nil || *out.SignatureVerified != *tt.wantVerified):
+				t.Errorf("SignatureVerified = %v, want %v (error: %s)", out.SignatureVerified, *tt.wantVerified, out.VerificationError)
+			}
+			if out.Valid != tt.wantValid || out.Expired != tt.wantExpired || out.NotYetValid != tt.wantNotYet {
+				t.Errorf("valid/expired/notYet = %v/%v/%v, want %v/%v/%v",
+					out.Valid, out.Expired, out.NotYetValid, tt.wantValid, tt.wantExpired, tt.wantNotYet)
+			}
+		})
+	}
+}
+
+func TestInspect_DecodesClaims(t *testing.T) {
+	in := newInspector(nil)
+	token := hsToken(t, "k", map[string]any{"sub": "alice", "exp": 1700003600, "iat": 1700000000})
+
+	_, out, err := in.Inspect(context.Background(), &mcp.CallToolRequest{}, Input{Token: token})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Header["alg"] != "HS256" || out.Claims["sub"] != "alice" {
+		t.Errorf("header/claims = %v / %v", out.Header, out.Claims)
+	}
+	if out.ExpiresAt != "2023-11-14T23:13:20Z" || out.IssuedAt != "2023-11-14T22:13:20Z" {
+		t.Errorf("ExpiresAt/IssuedAt = %q / %q", out.ExpiresAt, out.IssuedAt)
+	}
+}
+
+func TestInspect_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := map[string]any{"keys": []map[string]any{
+		{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "OKP", "kid": "ed1", "crv": "Ed25519", "x": b64(edPub)},
+	}}
+	in := newInspector(jwks)
+	claims := map[string]any{"sub": "bob", "exp": testNow.Add(time.Hour).Unix()}
+	const jwksURL = "https://issuer.example.com/jwks.json"
+
+	sign := func(alg, kid string) string {
+		input := signingInput(t, map[string]any{"alg": alg, "kid": kid}, claims)
+		var sig []byte
+		switch alg {
+		case "RS256":
+			sig, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest(input))
+		case "PS256":
+			sig, err = rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, digest(input), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		case "ES256":
+			var r, s *big.Int
+			r, s, err = ecdsa.Sign(rand.Reader, ecKey, digest(input))
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		case "EdDSA":
+			sig = ed25519.Sign(edPriv, []byte(input))
+		}
+		if err != nil {
+			t.Fatalf("sign %s: %v", alg, err)
+		}
+		return input + "." + b64(sig)
+	}
+
+	tests := []struct {
+		name      string
+		input     Input
+		wantValid bool
+		wantError string
+	}{
+		{name: "RS256", input: Input{Token: sign("RS256", "rsa1"), JWKSURL: jwksURL}, wantValid: true},
+		{name: "PS256", input: Input{Token: sign("PS256", "rsa1"), JWKSURL: jwksURL}, wantValid: true},
+		{name: "ES256", input: Input{Token: sign("ES256", "ec1"), JWKSURL: jwksURL}, wantValid: true},
+		{name: "EdDSA", input: Input{Token: sign("EdDSA", "ed1"), JWKSURL: jwksURL}, wantValid: true},
+		{name: "wrong kid key type", input: Input{Token: sign("RS256", "ec1"), JWKSURL: jwksURL}, wantError: "requires an RSA key"},
+		{name: "unknown kid", input: Input{Token: sign("RS256", "missing"), JWKSURL: jwksURL}, wantError: "no JWKS key matches"},
+		{name: "fetch failure", input: Input{Token: sign("RS256", "rsa1"), JWKSURL: "https://evil.example.com/"}, wantError: "failed to fetch JWKS"},
+		{name: "HS with JWKS", input: Input{Token: hsToken(t, "k", claims), JWKSURL: jwksURL}, wantError: "require a shared secret"},
+		{name: "RS with secret", input: Input{Token: sign("RS256", "rsa1"), Secret: "k"}, wantError: "requires an HS* algorithm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := in.Inspect(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Valid != tt.wantValid {
+				t.Errorf("Valid = %v, want %v (error: %s)", out.Valid, tt.wantValid, out.VerificationError)
+			}
+			if tt.wantError != "" && !strings.Contains(out.VerificationError, tt.wantError) {
+				t.Errorf("VerificationError = %q, want containing %q", out.VerificationError, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestInspect_TamperedSignature(t *testing.T) {
+	in := newInspector(nil)
+	token := hsToken(t, "k", map[string]any{"sub": "alice"})
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + b64JSON(t, map[string]any{"sub": "mallory"}) + "." + parts[2]
+
+	_, out, err := in.Inspect(context.Background(), &mcp.CallToolRequest{}, Input{Token: tampered, Secret: "k"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.SignatureVerified == nil || *out.SignatureVerified || out.Valid {
+		t.Errorf("tampered token verified: %+v", out)
+	}
+}
+
+func TestInspect_NoJWKSFetcher(t *testing.T) {
+	in := NewInspector(nil)
+	token := signingInput(t, map[string]any{"alg": "RS256"}, map[string]any{}) + ".c2ln"
+
+	_, out, err := in.Inspect(context.Background(), &mcp.CallToolRequest{}, Input{Token: token, JWKSURL: "https://x/jwks"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.VerificationError, "not enabled") {
+		t.Errorf("VerificationError = %q, want JWKS disabled", out.VerificationError)
+	}
+}
+
+func ptr(b bool) *bool { return &b }
@@ -0,0 +1,449 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// errReadOnly is returned by the transport for any non-read request.
+var errReadOnly = errors.New("kubernetes tools are read-only")
+
+// Config controls cluster access for the Kubernetes tools.
+type Config struct {
+	// Enabled registers the tools. They are off by default.
+	Enabled bool
+	// Kubeconfig is a kubeconfig path. When empty, in-cluster credentials
+	// are used if available, falling back to the default loading rules.
+	Kubeconfig string
+	// Context selects a kubeconfig context; empty uses the current one.
+	Context string
+	// Namespaces limits access to these namespaces. An empty list allows
+	// every namespace.
+	Namespaces  []string
+	MaxItems    int
+	MaxLogLines int
+	MaxLogBytes int
+	Timeout     time.Duration
+}
+
+// LoadConfig reads the Kubernetes configuration from environment variables.
+func LoadConfig() Config {
+	return Config{
+		Enabled:     config.GetEnvBool("K8S_ENABLED", false),
+		Kubeconfig:  config.GetEnv("K8S_KUBECONFIG", ""),
+		Context:     config.GetEnv("K8S_CONTEXT", ""),
+		Namespaces:  config.GetEnvList("K8S_NAMESPACES"),
+		MaxItems:    config.GetEnvInt("K8S_MAX_ITEMS", 500),
+		MaxLogLines: config.GetEnvInt("K8S_MAX_LOG_LINES", 1000),
+		MaxLogBytes: config.GetEnvInt("K8S_MAX_LOG_BYTES", 256<<10),
+		Timeout:     config.GetEnvDuration("K8S_TIMEOUT", 15*time.Second),
+	}
+}
+
+// NewClient builds a clientset whose transport rejects every request that
+// is not a GET, so the tools cannot mutate the cluster even if a handler
+// were to try.
+func NewClient(cfg Config) (kubernetes.Interface, error) {
+	restConfig, err := restConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	restConfig.Timeout = cfg.Timeout
+	restConfig.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return readOnlyTransport{next: rt}
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+func restConfig(cfg Config) (*rest.Config, error) {
+	if cfg.Kubeconfig == "" && cfg.Context == "" {
+		if rc, err := rest.InClusterConfig(); err == nil {
+			return rc, nil
+		}
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if cfg.Kubeconfig != "" {
+		rules.ExplicitPath = cfg.Kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: cfg.Context}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
+
+// readOnlyTransport refuses non-GET requests before they leave the process.
+type readOnlyTransport struct {
+	next http.RoundTripper
+}
+
+func (t readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return nil, fmt.Errorf("%w: %s %s", errReadOnly, req.Method, req.URL.Path)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// ListInput is the input for the list tools.
+type ListInput struct {
+	Namespace     string `json:"namespace" jsonschema:"the namespace to list"`
+	LabelSelector string `json:"label_selector,omitempty" jsonschema:"label selector, e.g. app=web"`
+	Limit         int    `json:"limit,omitempty" jsonschema:"maximum items to return; capped by the server limit"`
+}
+
+// Pod summarizes a pod.
+type Pod struct {
+	Name      string `json:"name" jsonschema:"the pod name"`
+	Phase     string `json:"phase" jsonschema:"Pending, Running, Succeeded, Failed, or Unknown"`
+	Ready     string `json:"ready" jsonschema:"ready containers out of total, e.g. 1/2"`
+	Restarts  int32  `json:"restarts" jsonschema:"total container restarts"`
+	Node      string `json:"node,omitempty" jsonschema:"the node the pod is scheduled on"`
+	IP        string `json:"ip,omitempty" jsonschema:"the pod IP"`
+	StartTime string `json:"start_time,omitempty" jsonschema:"when the pod started, as RFC 3339"`
+}
+
+// PodsOutput is the output of the k8s_list_pods tool.
+type PodsOutput struct {
+	Pods      []Pod `json:"pods" jsonschema:"pods sorted by name"`
+	Truncated bool  `json:"truncated" jsonschema:"whether more pods exist beyond the limit"`
+}
+
+// Deployment summarizes a deployment.
+type Deployment struct {
+	Name      string   `json:"name" jsonschema:"the deployment name"`
+	Replicas  int32    `json:"replicas" jsonschema:"desired replicas"`
+	Ready     int32    `json:"ready" jsonschema:"ready replicas"`
+	Updated   int32    `json:"updated" jsonschema:"replicas running the latest template"`
+	Available int32    `json:"available" jsonschema:"available replicas"`
+	Images    []string `json:"images" jsonschema:"container images in the pod template"`
+}
+
+// DeploymentsOutput is the output of the k8s_list_deployments tool.
+type DeploymentsOutput struct {
+	Deployments []Deployment `json:"deployments" jsonschema:"deployments sorted by name"`
+	Truncated   bool         `json:"truncated" jsonschema:"whether more deployments exist beyond the limit"`
+}
+
+// EventsInput is the input for the k8s_list_events tool.
+type EventsInput struct {
+	Namespace string `json:"namespace" jsonschema:"the namespace to list"`
+	Object    string `json:"object,omitempty" jsonschema:"only events about the object with this name"`
+	Limit     int    `json:"limit,omitempty" jsonschema:"maximum events to return; capped by the server limit"`
+}
+
+// Event summarizes an event.
+type Event struct {
+	Type     string `json:"type" jsonschema:"Normal or Warning"`
+	Reason   string `json:"reason" jsonschema:"short machine-readable reason"`
+	Object   string `json:"object" jsonschema:"the involved object as kind/name"`
+	Message  string `json:"message" jsonschema:"the event message"`
+	Count    int32  `json:"count" jsonschema:"how many times the event occurred"`
+	LastSeen string `json:"last_seen,omitempty" jsonschema:"when the event last occurred, as RFC 3339"`
+}
+
+// EventsOutput is the output of the k8s_list_events tool.
+type EventsOutput struct {
+	Events    []Event `json:"events" jsonschema:"events, most recent first"`
+	Truncated bool    `json:"truncated" jsonschema:"whether more events exist beyond the limit"`
+}
+
+// LogsInput is the input for the k8s_pod_logs tool.
+type LogsInput struct {
+	Namespace    string `json:"namespace" jsonschema:"the pod's namespace"`
+	Pod          string `json:"pod" jsonschema:"the pod name"`
+	Container    string `json:"container,omitempty" jsonschema:"the container; required for multi-container pods"`
+	TailLines    int    `json:"tail_lines,omitempty" jsonschema:"number of lines from the end (default 100)"`
+	SinceSeconds int    `json:"since_seconds,omitempty" jsonschema:"only logs newer than this many seconds"`
+	Previous     bool   `json:"previous,omitempty" jsonschema:"logs of the previous terminated container instance"`
+}
+
+// LogsOutput is the output of the k8s_pod_logs tool.
+type LogsOutput struct {
+	Logs      string `json:"logs" jsonschema:"the log text"`
+	Truncated bool   `json:"truncated" jsonschema:"whether the logs were cut off at the size limit"`
+}
+
+// Cluster implements the Kubernetes tools over a clientset.
+type Cluster struct {
+	cfg    Config
+	client kubernetes.Interface
+}
+
+// NewCluster creates a Cluster backed by client.
+func NewCluster(cfg Config, client kubernetes.Interface) *Cluster {
+	return &Cluster{cfg: cfg, client: client}
+}
+
+// ListPods lists pods in a namespace.
+func (c *Cluster) ListPods(ctx context.Context, req *mcp.CallToolRequest, input ListInput) (*mcp.CallToolResult, PodsOutput, error) {
+	opts, err := c.listOptions(input.Namespace, input.LabelSelector, input.Limit)
+	if err != nil {
+		return nil, PodsOutput{}, err
+	}
+	list, err := c.client.CoreV1().Pods(input.Namespace).List(ctx, opts)
+	if err != nil {
+		return nil, PodsOutput{}, apiError(err, "listing pods")
+	}
+
+	out := PodsOutput{Pods: []Pod{}, Truncated: list.Continue != ""}
+	for _, p := range list.Items {
+		pod := Pod{Name: p.Name, Phase: string(p.Status.Phase), Node: p.Spec.NodeName, IP: p.Status.PodIP}
+		ready := 0
+		for _, cs := range p.Status.ContainerStatuses {
+			if cs.Ready {
+				ready++
+			}
+			pod.Restarts += cs.RestartCount
+		}
+		pod.Ready = fmt.Sprintf("%d/%d", ready, len(p.Spec.Containers))
+		if p.Status.StartTime != nil {
+			pod.StartTime = p.Status.StartTime.UTC().Format(time.RFC3339)
+		}
+		out.Pods = append(out.Pods, pod)
+	}
+	slices.SortFunc(out.Pods, func(a, b Pod) int { return strings.Compare(a.Name, b.Name) })
+
+	logger.Info("tool called", "tool", "k8s_list_pods", "namespace", input.Namespace, "count", len(out.Pods))
+	return nil, out, nil
+}
+
+// ListDeployments lists deployments in a namespace.
+func (c *Cluster) ListDeployments(ctx context.Context, req *mcp.CallToolRequest, input ListInput) (*mcp.CallToolResult, DeploymentsOutput, error) {
+	opts, err := c.listOptions(input.Namespace, input.LabelSelector, input.Limit)
+	if err != nil {
+		return nil, DeploymentsOutput{}, err
+	}
+	list, err := c.client.AppsV1().Deployments(input.Namespace).List(ctx, opts)
+	if err != nil {
+		return nil, DeploymentsOutput{}, apiError(err, "listing deployments")
+	}
+
+	out := DeploymentsOutput{Deployments: []Deployment{}, Truncated: list.Continue != ""}
+	for _, d := range list.Items {
+		dep := Deployment{
+			Name:      d.Name,
+			Ready:     d.Status.ReadyReplicas,
+			Updated:   d.Status.UpdatedReplicas,
+			Available: d.Status.AvailableReplicas,
+			Images:    []string{},
+		}
+		if d.Spec.Replicas != nil {
+			dep.Replicas = *d.Spec.Replicas
+		}
+		for _, ctr := range d.Spec.Template.Spec.Containers {
+			dep.Images = append(dep.Images, ctr.Image)
+		}
+		out.Deployments = append(out.Deployments, dep)
+	}
+	slices.SortFunc(out.Deployments, func(a, b Deployment) int { return strings.Compare(a.Name, b.Name) })
+
+	logger.Info("tool called", "tool", "k8s_list_deployments", "namespace", input.Namespace, "count", len(out.Deployments))
+	return nil, out, nil
+}
+
+// ListEvents lists events in a namespace, most recent first.
+func (c *Cluster) ListEvents(ctx context.Context, req *mcp.CallToolRequest, input EventsInput) (*mcp.CallToolResult, EventsOutput, error) {
+	if err := c.checkNamespace(input.Namespace); err != nil {
+		return nil, EventsOutput{}, err
+	}
+	opts := metav1.ListOptions{}
+	if input.Object != "" {
+		opts.FieldSelector = "involvedObject.name=" + input.Object
+	}
+	list, err := c.client.CoreV1().Events(input.Namespace).List(ctx, opts)
+	if err != nil {
+		return nil, EventsOutput{}, apiError(err, "listing events")
+	}
+
+	events := list.Items
+	slices.SortFunc(events, func(a, b corev1.Event) int { return eventTime(b).Compare(eventTime(a)) })
+	limit := c.limit(input.Limit)
+
+	out := EventsOutput{Events: []Event{}}
+	if len(events) > limit {
+		events = events[:limit]
+		out.Truncated = true
+	}
+	for _, e := range events {
+		ev := Event{
+			Type:    e.Type,
+			Reason:  e.Reason,
+			Object:  strings.ToLower(e.InvolvedObject.Kind) + "/" + e.InvolvedObject.Name,
+			Message: e.Message,
+			Count:   e.Count,
+		}
+		if t := eventTime(e); !t.IsZero() {
+			ev.LastSeen = t.UTC().Format(time.RFC3339)
+		}
+		out.Events = append(out.Events, ev)
+	}
+
+	logger.Info("tool called", "tool", "k8s_list_events", "namespace", input.Namespace, "count", len(out.Events))
+	return nil, out, nil
+}
+
+// PodLogs reads a pod's logs.
+func (c *Cluster) PodLogs(ctx context.Context, req *mcp.CallToolRequest, input LogsInput) (*mcp.CallToolResult, LogsOutput, error) {
+	if err := c.checkNamespace(input.Namespace); err != nil {
+		return nil, LogsOutput{}, err
+	}
+	if input.Pod == "" {
+		return nil, LogsOutput{}, tools.NewError(tools.CodeInvalidArgument, "pod is required")
+	}
+	tail := int64(input.TailLines)
+	if tail <= 0 {
+		tail = 100
+	}
+	tail = min(tail, int64(c.cfg.MaxLogLines))
+	limit := int64(c.cfg.MaxLogBytes)
+
+	opts := &corev1.PodLogOptions{
+		Container:  input.Container,
+		TailLines:  &tail,
+		Previous:   input.Previous,
+		LimitBytes: &limit,
+	}
+	if input.SinceSeconds > 0 {
+		since := int64(input.SinceSeconds)
+		opts.SinceSeconds = &since
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	stream, err := c.client.CoreV1().Pods(input.Namespace).GetLogs(input.Pod, opts).Stream(ctx)
+	if err != nil {
+		return nil, LogsOutput{}, apiError(err, "reading logs")
+	}
+	defer func() { _ = stream.Close() }()
+
+	// Read one byte past the limit to detect truncation by servers that
+	// ignore LimitBytes.
+	data, err := io.ReadAll(io.LimitReader(stream, limit+1))
+	if err != nil {
+		return nil, LogsOutput{}, apiError(err, "reading logs")
+	}
+	out := LogsOutput{}
+	if int64(len(data)) > limit {
+		data = data[:limit]
+		out.Truncated = true
+	}
+	out.Logs = strings.ToValidUTF8(string(data), string(utf8.RuneError))
+
+	logger.Info("tool called", "tool", "k8s_pod_logs", "namespace", input.Namespace, "pod", input.Pod, "bytes", len(data))
+	return nil, out, nil
+}
+
+func (c *Cluster) listOptions(namespace, selector string, limit int) (metav1.ListOptions, error) {
+	if err := c.checkNamespace(namespace); err != nil {
+		return metav1.ListOptions{}, err
+	}
+	return metav1.ListOptions{LabelSelector: selector, Limit: int64(c.limit(limit))}, nil
+}
+
+func (c *Cluster) limit(requested int) int {
+	if requested > 0 && requested < c.cfg.MaxItems {
+		return requested
+	}
+	return c.cfg.MaxItems
+}
+
+// checkNamespace enforces the namespace allow-list. Cluster-wide listing is
+// never permitted, so a namespace is always required.
+func (c *Cluster) checkNamespace(namespace string) error {
+	if namespace == "" {
+		return tools.NewError(tools.CodeInvalidArgument, "namespace is required")
+	}
+	if len(c.cfg.Namespaces) > 0 && !slices.Contains(c.cfg.Namespaces, namespace) {
+		return tools.NewError(tools.CodePermissionDenied, "namespace %q is not allowed", namespace)
+	}
+	return nil
+}
+
+// apiError classifies a Kubernetes API error: missing objects and RBAC
+// denials are reported as such, anything else as the cluster being
+// unavailable.
+func apiError(err error, msg string) *tools.Error {
+	code := tools.CodeUnavailable
+	switch {
+	case apierrors.IsNotFound(err):
+		code = tools.CodeNotFound
+	case apierrors.IsForbidden(err), errors.Is(err, errReadOnly):
+		code = tools.CodePermissionDenied
+	}
+	return tools.WrapError(code, err, msg)
+}
+
+// eventTime returns the most recent timestamp recorded on an event.
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.FirstTimestamp.Time
+	}
+}
+
+func init() {
+	tools.RegisterConfig(func() { LoadConfig() })
+	tools.Register(tools.Namespace("k8s", func(server *mcp.Server) {
+		cfg := LoadConfig()
+		if !cfg.Enabled {
+			return
+		}
+		// The client is built on the first call, so a cluster that cannot
+		// be reached only fails these tools
+		c := tools.NewLazy("k8s", func(context.Context) (*Cluster, error) {
+			client, err := NewClient(cfg)
+			if err != nil {
+				return nil, err
+			}
+			return NewCluster(cfg, client), nil
+		})
+
+		namespaces := "any namespace"
+		if len(cfg.Namespaces) > 0 {
+			namespaces = "namespaces " + strings.Join(cfg.Namespaces, ", ")
+		}
+		tools.AddLazyTool(server, &mcp.Tool{
+			Name:        "k8s_list_pods",
+			Description: "List pods with phase, readiness, and restarts (read-only; " + namespaces + ")",
+			Annotations: tools.ReadOnly(true),
+		}, c, (*Cluster).ListPods)
+		tools.AddLazyTool(server, &mcp.Tool{
+			Name:        "k8s_list_deployments",
+			Description: "List deployments with replica status and images (read-only; " + namespaces + ")",
+			Annotations: tools.ReadOnly(true),
+		}, c, (*Cluster).ListDeployments)
+		tools.AddLazyTool(server, &mcp.Tool{
+			Name:        "k8s_list_events",
+			Description: "List recent events, optionally for one object (read-only; " + namespaces + ")",
+			Annotations: tools.ReadOnly(true),
+		}, c, (*Cluster).ListEvents)
+		tools.AddLazyTool(server, &mcp.Tool{
+			Name:        "k8s_pod_logs",
+			Description: "Read the tail of a pod container's logs (read-only; " + namespaces + ")",
+			Annotations: tools.ReadOnly(true),
+		}, c, (*Cluster).PodLogs)
+	}))
+}
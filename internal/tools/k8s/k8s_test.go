@@ -0,0 +1,248 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func newTestCluster(t *testing.T) *Cluster {
+	t.Helper()
+	replicas := int32(3)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	client := fake.NewClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-b", Namespace: "prod", Labels: map[string]string{"app": "web"}},
+			Spec:       corev1.PodSpec{NodeName: "node-1", Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "app", Ready: true, RestartCount: 2},
+					{Name: "sidecar", Ready: false, RestartCount: 1},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "prod", Labels: map[string]string{"app": "web"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod", Labels: map[string]string{"app": "db"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "db"}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-pod", Namespace: "kube-system"},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Image: "web:1.2"}}}},
+			},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: 2, UpdatedReplicas: 3, AvailableReplicas: 2},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "e1", Namespace: "prod"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-a"},
+			Type:           "Warning", Reason: "FailedScheduling", Message: "0/3 nodes available", Count: 4,
+			LastTimestamp: metav1.NewTime(now),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "e2", Namespace: "prod"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-b"},
+			Type:           "Normal", Reason: "Started", Count: 1,
+			LastTimestamp: metav1.NewTime(now.Add(-time.Hour)),
+		},
+	)
+	return NewCluster(Config{
+		Namespaces:  []string{"prod"},
+		MaxItems:    100,
+		MaxLogLines: 100,
+		MaxLogBytes: 1024,
+		Timeout:     5 * time.Second,
+	}, client)
+}
+
+func TestListPods(t *testing.T) {
+	c := newTestCluster(t)
+
+	_, out, err := c.ListPods(context.Background(), &mcp.CallToolRequest{}, ListInput{Namespace: "prod", LabelSelector: "app=web"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Pods) != 2 || out.Pods[0].Name != "web-a" {
+		t.Fatalf("got pods %+v, want web-a and web-b", out.Pods)
+	}
+	b := out.Pods[1]
+	if b.Ready != "1/2" || b.Restarts != 3 || b.Phase != "Running" || b.Node != "node-1" {
+		t.Errorf("unexpected pod summary: %+v", b)
+	}
+}
+
+func TestListDeployments(t *testing.T) {
+	c := newTestCluster(t)
+
+	_, out, err := c.ListDeployments(context.Background(), &mcp.CallToolRequest{}, ListInput{Namespace: "prod"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Deployments) != 1 {
+		t.Fatalf("got %d deployments, want 1", len(out.Deployments))
+	}
+	d := out.Deployments[0]
+	if d.Replicas != 3 || d.Ready != 2 || d.Images[0] != "web:1.2" {
+		t.Errorf("unexpected deployment summary: %+v", d)
+	}
+}
+
+func TestListEvents(t *testing.T) {
+	c := newTestCluster(t)
+
+	_, out, err := c.ListEvents(context.Background(), &mcp.CallToolRequest{}, EventsInput{Namespace: "prod", Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Events) != 1 || !out.Truncated {
+		t.Fatalf("got %d events (truncated=%v), want 1 truncated", len(out.Events), out.Truncated)
+	}
+	e := out.Events[0]
+	if e.Reason != "FailedScheduling" || e.Object != "pod/web-a" || e.LastSeen != "2025-01-01T12:00:00Z" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
+
+func TestPodLogs(t *testing.T) {
+	c := newTestCluster(t)
+
+	// The fake clientset serves a fixed body for any log request.
+	_, out, err := c.PodLogs(context.Background(), &mcp.CallToolRequest{}, LogsInput{Namespace: "prod", Pod: "web-a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Logs != "fake logs" {
+		t.Errorf("Logs = %q, want fake logs", out.Logs)
+	}
+}
+
+func TestNamespaceAllowList(t *testing.T) {
+	c := newTestCluster(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		call     func() error
+		wantErr  string
+		wantCode tools.Code
+	}{
+		{
+			name: "pods in disallowed namespace",
+			call: func() error {
+				_, _, err := c.ListPods(ctx, &mcp.CallToolRequest{}, ListInput{Namespace: "kube-system"})
+				return err
+			},
+			wantErr:  "not allowed",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name: "cluster-wide listing",
+			call: func() error {
+				_, _, err := c.ListDeployments(ctx, &mcp.CallToolRequest{}, ListInput{})
+				return err
+			},
+			wantErr:  "namespace is required",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name: "logs in disallowed namespace",
+			call: func() error {
+				_, _, err := c.PodLogs(ctx, &mcp.CallToolRequest{}, LogsInput{Namespace: "kube-system", Pod: "secret-pod"})
+				return err
+			},
+			wantErr:  "not allowed",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name: "logs without pod",
+			call: func() error {
+				_, _, err := c.PodLogs(ctx, &mcp.CallToolRequest{}, LogsInput{Namespace: "prod"})
+				return err
+			},
+			wantErr:  "pod is required",
+			wantCode: tools.CodeInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+			if code := tools.AsError(err).Code; code != tt.wantCode {
+				t.Errorf("code = %s, want %s", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name string
+		err  error
+		want tools.Code
+	}{
+		{name: "not found", err: apierrors.NewNotFound(pods, "web"), want: tools.CodeNotFound},
+		{name: "forbidden", err: apierrors.NewForbidden(pods, "web", errors.New("rbac")), want: tools.CodePermissionDenied},
+		{name: "read-only", err: errReadOnly, want: tools.CodePermissionDenied},
+		{name: "unreachable", err: errors.New("connection refused"), want: tools.CodeUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apiError(tt.err, "listing pods"); got.Code != tt.want || !errors.Is(got, tt.err) {
+				t.Errorf("apiError = %+v, want code %s wrapping %v", got, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+type stubTransport struct{ called bool }
+
+func (s *stubTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	s.called = true
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func TestReadOnlyTransport(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			next := &stubTransport{}
+			req, _ := http.NewRequest(method, "https://cluster/api/v1/namespaces/prod/pods/web", nil)
+			_, err := readOnlyTransport{next: next}.RoundTrip(req)
+			if !errors.Is(err, errReadOnly) || next.called {
+				t.Errorf("error = %v, forwarded = %v; want errReadOnly and no forwarding", err, next.called)
+			}
+		})
+	}
+
+	next := &stubTransport{}
+	req, _ := http.NewRequest(http.MethodGet, "https://cluster/api/v1/namespaces/prod/pods", nil)
+	if _, err := (readOnlyTransport{next: next}).RoundTrip(req); err != nil || !next.called {
+		t.Errorf("GET error = %v, forwarded = %v; want success", err, next.called)
+	}
+}
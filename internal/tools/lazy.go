@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/httpclient"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+// Deps are the dependencies the server shares with registrars added with
+// WithDeps, so tools reuse them instead of building their own.
+type Deps struct {
+	// Config is the server's configuration.
+	Config *config.Config
+	// Logger is the tools logger; see Logger.
+	Logger *slog.Logger
+	// HTTPClient is shared by tools that make HTTP requests.
+	HTTPClient *http.Client
+	// TraceID returns the W3C trace ID of a call over HTTP, or "".
+	TraceID func(req *mcp.CallToolRequest) string
+}
+
+// DefaultDeps returns the Deps used when the server provides none.
+func DefaultDeps() *Deps {
+	return defaultDeps()
+}
+
+var defaultDeps = sync.OnceValue(func() *Deps {
+	return &Deps{
+		Config:     config.New(),
+		Logger:     logger,
+		HTTPClient: httpclient.New("tools", httpclient.Options{}),
+		TraceID:    traceID,
+	}
+})
+
+func traceID(req *mcp.CallToolRequest) string {
+	if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+		return middleware.TraceIDFromHeader(extra.Header)
+	}
+	return ""
+}
+
+// DepsRegistrar is a Registrar that receives the shared Deps.
+type DepsRegistrar func(server *mcp.Server, deps *Deps)
+
+// WithDeps returns a Registrar calling r with the Deps given to
+// RegisterEachWith, or DefaultDeps.
+func WithDeps(r DepsRegistrar) Registrar {
+	return func(server *mcp.Server) {
+		deps := DefaultDeps()
+		if reg := registeringOn(server); reg != nil && reg.deps != nil {
+			deps = reg.deps
+		}
+		r(server, deps)
+	}
+}
+
+// lazyRetry is how long a failed Lazy waits before building again.
+var lazyRetry = 5 * time.Second
+
+// Lazy builds a value, such as a connection pool or an API client, on
+// first use rather than at registration, so tools needing it are listed
+// at once and a dependency that is down at startup only fails their
+// calls. A failed build is retried on a later call, at most once per
+// lazyRetry. Health reports each Lazy for /health.
+type Lazy[T any] struct {
+	name  string
+	build func(ctx context.Context) (T, error)
+
+	mu       sync.Mutex
+	ready    bool
+	val      T
+	err      error
+	failedAt time.Time
+}
+
+// lazies holds every Lazy by name, for LazyStatus.
+var lazies sync.Map // string -> interface{ status() string }
+
+// NewLazy returns a Lazy called name that runs build on first use.
+func NewLazy[T any](name string, build func(ctx context.Context) (T, error)) *Lazy[T] {
+	l := &Lazy[T]{name: name, build: build}
+	lazies.Store(name, l)
+	return l
+}
+
+// Get returns the value, building it if needed. Concurrent callers wait
+// for one build.
+func (l *Lazy[T]) Get(ctx context.Context) (T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ready {
+		return l.val, nil
+	}
+	var zero T
+	if l.err != nil && time.Since(l.failedAt) < lazyRetry {
+		return zero, l.err
+	}
+	v, err := l.build(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			l.err, l.failedAt = err, time.Now()
+			logger.Error("tool dependency failed", "dependency", l.name, "error", err)
+		}
+		return zero, err
+	}
+	l.val, l.ready, l.err = v, true, nil
+	logger.Info("tool dependency ready", "dependency", l.name)
+	return v, nil
+}
+
+func (l *Lazy[T]) status() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case l.ready:
+		return "ready"
+	case l.err != nil:
+		return "failed: " + l.err.Error()
+	default:
+		return "pending"
+	}
+}
+
+// LazyStatus returns the state of each Lazy by name: "pending" until its
+// first use, "ready", or "failed: " and the error.
+func LazyStatus() map[string]string {
+	status := make(map[string]string)
+	lazies.Range(func(name, l any) bool {
+		status[name.(string)] = l.(interface{ status() string }).status()
+		return true
+	})
+	return status
+}
+
+// AddLazyTool is AddTool for a handler that needs the value of l, such as
+// a method expression of its type:
+//
+//	tools.AddLazyTool(server, &mcp.Tool{...}, cluster, (*Cluster).ListPods)
+//
+// Calls made while l cannot be built fail as unavailable.
+func AddLazyTool[T, In, Out any](server *mcp.Server, tool *mcp.Tool, l *Lazy[T], h func(T, context.Context, *mcp.CallToolRequest, In) (*mcp.CallToolResult, Out, error), mws ...Middleware) {
+	AddTool(server, tool, func(ctx context.Context, req *mcp.CallToolRequest, in In) (*mcp.CallToolResult, Out, error) {
+		v, err := l.Get(ctx)
+		if err != nil {
+			var zero Out
+			return nil, zero, WrapError(CodeUnavailable, err, tool.Name+" is not ready")
+		}
+		return h(v, ctx, req, in)
+	}, mws...)
+}
@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestLazy(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		calls      int
+		wantBuilds int
+		wantStatus string
+	}{
+		{name: "unused", wantStatus: "pending"},
+		{name: "ready", calls: 3, wantBuilds: 1, wantStatus: "ready"},
+		{name: "failed", err: errors.New("refused"), calls: 3, wantBuilds: 1, wantStatus: "failed: refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builds := 0
+			l := NewLazy("test_lazy_"+tt.name, func(context.Context) (int, error) {
+				builds++
+				return 42, tt.err
+			})
+			for range tt.calls {
+				v, err := l.Get(context.Background())
+				if !errors.Is(err, tt.err) || (err == nil && v != 42) {
+					t.Fatalf("Get() = %v, %v, want 42, %v", v, err, tt.err)
+				}
+			}
+			if builds != tt.wantBuilds {
+				t.Errorf("builds = %d, want %d", builds, tt.wantBuilds)
+			}
+			if got := LazyStatus()[l.name]; got != tt.wantStatus {
+				t.Errorf("LazyStatus() = %q, want %q", got, tt.wantStatus)
+			}
+			err := CheckHealth(context.Background())
+			if failed := err != nil && strings.Contains(err.Error(), l.name); failed != (tt.err != nil) {
+				t.Errorf("CheckHealth() = %v, want failure %v", err, tt.err != nil)
+			}
+		})
+	}
+}
+
+func TestAddLazyTool(t *testing.T) {
+	type client struct{ greeting string }
+	var fail bool
+	deps := &Deps{Logger: Logger()}
+	var got *Deps
+	registrar := WithDeps(func(server *mcp.Server, d *Deps) {
+		got = d
+		c := NewLazy("test_lazy_tool", func(context.Context) (*client, error) {
+			if fail {
+				return nil, errors.New("down")
+			}
+			return &client{greeting: "hi"}, nil
+		})
+		AddLazyTool(server, &mcp.Tool{Name: "greet"}, c, func(c *client, _ context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: c.greeting}}}, nil, nil
+		})
+	})
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	if err := RegisterEachWith(server, []Registrar{registrar}, deps); err != nil {
+		t.Fatalf("RegisterEachWith() error = %v", err)
+	}
+	if got != deps {
+		t.Errorf("registrar got deps %p, want %p", got, deps)
+	}
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	fail = true
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "greet", Arguments: map[string]any{}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if !res.IsError || !strings.Contains(res.Content[0].(*mcp.TextContent).Text, "unavailable") {
+		t.Errorf("failed build result = %+v, want an unavailable error", res.Content[0])
+	}
+
+	defer func(d time.Duration) { lazyRetry = d }(lazyRetry)
+	lazyRetry = 0
+	fail = false
+	res, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "greet", Arguments: map[string]any{}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError || res.Content[0].(*mcp.TextContent).Text != "hi" {
+		t.Errorf("retried result = %+v, want hi", res.Content[0])
+	}
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+// toolsVersion counts changes to the registered tools, so cached
+// tools/list responses know when they are stale.
+var toolsVersion atomic.Uint64
+
+// ToolsChanged invalidates every cached tools/list response. AddTool and
+// RemoveTools call it; code adding or removing tools on an mcp.Server
+// directly must call it too.
+func ToolsChanged() {
+	toolsVersion.Add(1)
+}
+
+// RemoveTools removes the tools called names from server.
+func RemoveTools(server *mcp.Server, names ...string) {
+	server.RemoveTools(names...)
+	ToolsChanged()
+}
+
+// listKey identifies a tools/list response: the page, and the tenant,
+// which decides what is listed.
+type listKey struct {
+	cursor, tenant string
+}
+
+// cachedList is a tools/list result serialized once, so a cache hit costs
+// neither the listing nor the JSON encoding.
+type cachedList struct {
+	*mcp.ListToolsResult
+	body json.RawMessage
+}
+
+// MarshalJSON returns the serialized result.
+func (c *cachedList) MarshalJSON() ([]byte, error) {
+	return c.body, nil
+}
+
+// CacheToolList returns receiving middleware serving tools/list from a
+// cache of serialized responses, refreshed after the tools change. It must
+// be the outermost middleware to see tools/list, since the results it
+// returns are not *mcp.ListToolsResult.
+func CacheToolList() mcp.Middleware {
+	var (
+		mu      sync.Mutex
+		version uint64
+		lists   = make(map[listKey]*cachedList)
+	)
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != "tools/list" {
+				return next(ctx, method, req)
+			}
+			var key listKey
+			if params, ok := req.GetParams().(*mcp.ListToolsParams); ok && params != nil {
+				key.cursor = params.Cursor
+			}
+			if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+				key.tenant = extra.Header.Get(middleware.HeaderTenant)
+			}
+
+			// Read before listing, so a change while listing leaves the
+			// entry stale rather than wrongly fresh
+			current := toolsVersion.Load()
+			mu.Lock()
+			if version != current {
+				clear(lists)
+				version = current
+			}
+			cached, ok := lists[key]
+			mu.Unlock()
+			if ok {
+				return cached, nil
+			}
+
+			result, err := next(ctx, method, req)
+			list, ok := result.(*mcp.ListToolsResult)
+			if err != nil || !ok {
+				return result, err
+			}
+			body, err := json.Marshal(list)
+			if err != nil {
+				return result, nil
+			}
+			cached = &cachedList{ListToolsResult: list, body: body}
+			mu.Lock()
+			if version == current {
+				lists[key] = cached
+			}
+			mu.Unlock()
+			return cached, nil
+		}
+	}
+}
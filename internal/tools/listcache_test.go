@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+func TestCacheToolList(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	noop := func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		return nil, nil, nil
+	}
+	AddTool(server, &mcp.Tool{Name: "cached_a"}, noop)
+	listed := 0
+	server.AddReceivingMiddleware(CacheToolList(), func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method == "tools/list" {
+				listed++
+			}
+			return next(ctx, method, req)
+		}
+	})
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	tests := []struct {
+		name       string
+		add        string
+		wantTools  string
+		wantListed int
+	}{
+		{name: "first list", wantTools: "cached_a", wantListed: 1},
+		{name: "cached", wantTools: "cached_a", wantListed: 1},
+		{name: "tool added", add: "cached_b", wantTools: "cached_a,cached_b", wantListed: 2},
+		{name: "cached again", wantTools: "cached_a,cached_b", wantListed: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.add != "" {
+				AddTool(server, &mcp.Tool{Name: tt.add}, noop)
+			}
+			res, err := session.ListTools(ctx, nil)
+			if err != nil {
+				t.Fatalf("ListTools() error = %v", err)
+			}
+			var names []string
+			for _, tool := range res.Tools {
+				names = append(names, tool.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.wantTools {
+				t.Errorf("tools = %q, want %q", got, tt.wantTools)
+			}
+			if listed != tt.wantListed {
+				t.Errorf("tools listed %d times, want %d", listed, tt.wantListed)
+			}
+		})
+	}
+}
+
+func TestCacheToolList_Tenants(t *testing.T) {
+	listed := 0
+	handler := CacheToolList()(func(_ context.Context, _ string, req mcp.Request) (mcp.Result, error) {
+		listed++
+		tenant := req.GetExtra().Header.Get(middleware.HeaderTenant)
+		return &mcp.ListToolsResult{Tools: []*mcp.Tool{{Name: "for_" + tenant}}}, nil
+	})
+	list := func(tenant string) string {
+		t.Helper()
+		h := http.Header{}
+		h.Set(middleware.HeaderTenant, tenant)
+		res, err := handler(context.Background(), "tools/list", &mcp.ListToolsRequest{Params: &mcp.ListToolsParams{}, Extra: &mcp.RequestExtra{Header: h}})
+		if err != nil {
+			t.Fatalf("tools/list error = %v", err)
+		}
+		body, err := json.Marshal(res)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return string(body)
+	}
+
+	for _, tenant := range []string{"a", "b", "a", "b"} {
+		if body := list(tenant); !strings.Contains(body, `"for_`+tenant+`"`) {
+			t.Errorf("tenant %s listed %s", tenant, body)
+		}
+	}
+	if listed != 2 {
+		t.Errorf("tools listed %d times, want once per tenant", listed)
+	}
+}
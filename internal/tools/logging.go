@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// LoggerName is the logger field of log messages sent to clients.
+const LoggerName = "mcp-server"
+
+// LogHandler is a slog.Handler that passes records to a base handler and
+// also sends those logged with the context of a tool call to the client
+// that made it, as notifications/message. Clients choose the least severe
+// level they receive with logging/setLevel; until they do, they receive
+// nothing. Log with the call's context, e.g. logger.WarnContext(ctx, ...),
+// for a record to reach the client.
+type LogHandler struct {
+	base slog.Handler
+	// with applies the attributes and groups added to this handler to a
+	// session's handler.
+	with func(slog.Handler) slog.Handler
+}
+
+// NewLogHandler returns a LogHandler passing records to base.
+func NewLogHandler(base slog.Handler) *LogHandler {
+	return &LogHandler{base: base, with: func(h slog.Handler) slog.Handler { return h }}
+}
+
+// Enabled reports whether the base handler or the client of ctx wants
+// records at level.
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.base.Enabled(ctx, level) {
+		return true
+	}
+	lh := sessionLog(ctx)
+	return lh != nil && lh.Enabled(ctx, level)
+}
+
+// Handle passes r to the base handler and sends it to the client of ctx.
+// Failures to notify the client are ignored.
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.base.Enabled(ctx, r.Level) {
+		err = h.base.Handle(ctx, r)
+	}
+	if lh := sessionLog(ctx); lh != nil {
+		if sh := h.with(lh); sh.Enabled(ctx, r.Level) {
+			_ = sh.Handle(ctx, r.Clone())
+		}
+	}
+	return err
+}
+
+// WithAttrs implements slog.Handler.
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	with := h.with
+	return &LogHandler{
+		base: h.base.WithAttrs(attrs),
+		with: func(s slog.Handler) slog.Handler { return with(s).WithAttrs(attrs) },
+	}
+}
+
+// WithGroup implements slog.Handler.
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	with := h.with
+	return &LogHandler{
+		base: h.base.WithGroup(name),
+		with: func(s slog.Handler) slog.Handler { return with(s).WithGroup(name) },
+	}
+}
+
+// sessionLogs holds the log handlers of live sessions.
+var sessionLogs = struct {
+	sync.Mutex
+	m map[*mcp.ServerSession]*mcp.LoggingHandler
+}{m: make(map[*mcp.ServerSession]*mcp.LoggingHandler)}
+
+// sessionLog returns the log handler of the session handling ctx, or nil
+// outside a tool call.
+func sessionLog(ctx context.Context) *mcp.LoggingHandler {
+	ss, _ := ctx.Value(sessionKey{}).(*mcp.ServerSession)
+	if ss == nil {
+		return nil
+	}
+
+	sessionLogs.Lock()
+	defer sessionLogs.Unlock()
+	if lh, ok := sessionLogs.m[ss]; ok {
+		return lh
+	}
+	lh := mcp.NewLoggingHandler(ss, &mcp.LoggingHandlerOptions{LoggerName: LoggerName})
+	sessionLogs.m[ss] = lh
+	go func() {
+		_ = ss.Wait()
+		sessionLogs.Lock()
+		delete(sessionLogs.m, ss)
+		sessionLogs.Unlock()
+	}()
+	return lh
+}
+
+// Logger returns the logger of the tools package. Records logged with the
+// context of a tool call also reach its client; see LogHandler.
+func Logger() *slog.Logger {
+	return logger
+}
+
+// LogWriter is an io.Writer that logs each line written to it through
+// Logger, for libraries and commands that write their output to a writer.
+// Tools must not print to os.Stdout: with the stdio transport it carries
+// the protocol.
+type LogWriter struct {
+	ctx   context.Context
+	level slog.Level
+	mu    sync.Mutex
+	buf   []byte
+}
+
+// NewLogWriter returns a LogWriter logging at level with ctx, the context
+// of the tool call, so the lines also reach its client.
+func NewLogWriter(ctx context.Context, level slog.Level) *LogWriter {
+	return &LogWriter{ctx: ctx, level: level}
+}
+
+// Write logs the complete lines of p and keeps the rest for the next
+// Write or Close.
+func (w *LogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Close logs an unterminated last line.
+func (w *LogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.log(w.buf)
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *LogWriter) log(line []byte) {
+	logger.Log(w.ctx, w.level, "tool output", "output", string(bytes.TrimSuffix(line, []byte("\r"))))
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestLogHandler(t *testing.T) {
+	var base bytes.Buffer
+	log := slog.New(NewLogHandler(slog.NewJSONHandler(&base, &slog.HandlerOptions{Level: slog.LevelInfo}))).
+		With("component", "test").WithGroup("call")
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	server.AddReceivingMiddleware(SessionMiddleware)
+	AddTool(server, &mcp.Tool{Name: "chatty"}, func(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, struct{}, error) {
+		log.DebugContext(ctx, "debug detail", "n", 1)
+		log.WarnContext(ctx, "careful", "n", 2)
+		log.Warn("no context", "n", 3)
+		return nil, struct{}{}, nil
+	})
+
+	var mu sync.Mutex
+	var got []*mcp.LoggingMessageParams
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, &mcp.ClientOptions{
+		LoggingMessageHandler: func(_ context.Context, req *mcp.LoggingMessageRequest) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, req.Params)
+		},
+	})
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	messages := func() []*mcp.LoggingMessageParams {
+		// Notifications may trail the result
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*mcp.LoggingMessageParams(nil), got...)
+	}
+
+	call := func() {
+		t.Helper()
+		if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "chatty", Arguments: map[string]any{}}); err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+	}
+
+	// Nothing is sent until the client sets a level
+	call()
+	if msgs := messages(); len(msgs) != 0 {
+		t.Fatalf("got %d messages before logging/setLevel, want 0", len(msgs))
+	}
+
+	if err := session.SetLoggingLevel(ctx, &mcp.SetLoggingLevelParams{Level: "debug"}); err != nil {
+		t.Fatalf("SetLoggingLevel: %v", err)
+	}
+	call()
+	msgs := messages()
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages at debug, want 2 (records logged without context stay local)", len(msgs))
+	}
+	if msgs[0].Level != "debug" || msgs[1].Level != "warning" || msgs[1].Logger != LoggerName {
+		t.Errorf("messages = %+v, %+v", msgs[0], msgs[1])
+	}
+	var data map[string]any
+	raw, _ := json.Marshal(msgs[1].Data)
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatalf("data %s: %v", raw, err)
+	}
+	if data["msg"] != "careful" || data["component"] != "test" || data["call"].(map[string]any)["n"] != float64(2) {
+		t.Errorf("data = %v, want the message with its attributes and group", data)
+	}
+
+	mu.Lock()
+	got = nil
+	mu.Unlock()
+	if err := session.SetLoggingLevel(ctx, &mcp.SetLoggingLevelParams{Level: "error"}); err != nil {
+		t.Fatalf("SetLoggingLevel: %v", err)
+	}
+	call()
+	if msgs := messages(); len(msgs) != 0 {
+		t.Errorf("got %d messages at error, want 0", len(msgs))
+	}
+
+	// The base handler keeps its own level and sees every record
+	if n := strings.Count(base.String(), `"msg":"careful"`); n != 3 {
+		t.Errorf("base handler got %d warnings, want 3", n)
+	}
+	if strings.Contains(base.String(), "debug detail") {
+		t.Error("base handler got a debug record below its level")
+	}
+}
+
+func TestLogWriter(t *testing.T) {
+	var buf bytes.Buffer
+	orig := logger
+	logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	defer func() { logger = orig }()
+
+	w := NewLogWriter(context.Background(), slog.LevelWarn)
+	_, _ = w.Write([]byte("first line\nsecond "))
+	_, _ = w.Write([]byte("line\r\nunterminated"))
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Fatalf("logged %d records before Close, want 2:\n%s", n, buf.String())
+	}
+	_ = w.Close()
+
+	var outputs []string
+	for line := range strings.Lines(buf.String()) {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("record %q: %v", line, err)
+		}
+		if rec["level"] != "WARN" {
+			t.Errorf("level = %v, want WARN", rec["level"])
+		}
+		outputs = append(outputs, rec["output"].(string))
+	}
+	if want := []string{"first line", "second line", "unterminated"}; !slices.Equal(outputs, want) {
+		t.Errorf("outputs = %q, want %q", outputs, want)
+	}
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.yaml.in/yaml/v3"
+)
+
+// Manifest describes the tools a server exposes, including their input and
+// output schemas, for documentation and client-side validation.
+type Manifest struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Bundle  string      `json:"bundle"`
+	Tools   []*mcp.Tool `json:"tools"`
+}
+
+// ManifestClient is the client name of the session BuildManifest opens.
+const ManifestClient = "manifest"
+
+// BuildManifest lists the tools registered on server through an in-memory
+// client session, so the manifest matches what clients see over the wire.
+func BuildManifest(ctx context.Context, impl *mcp.Implementation, server *mcp.Server) (*Manifest, error) {
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		return nil, fmt.Errorf("connecting server: %w", err)
+	}
+	defer func() { _ = ss.Close() }()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: ManifestClient, Version: impl.Version}, nil)
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		return nil, fmt.Errorf("connecting client: %w", err)
+	}
+	defer func() { _ = cs.Close() }()
+
+	m := &Manifest{Name: impl.Name, Version: impl.Version, Bundle: Bundle, Tools: []*mcp.Tool{}}
+	for tool, err := range cs.Tools(ctx, nil) {
+		if err != nil {
+			return nil, fmt.Errorf("listing tools: %w", err)
+		}
+		m.Tools = append(m.Tools, tool)
+	}
+	slices.SortFunc(m.Tools, func(a, b *mcp.Tool) int { return strings.Compare(a.Name, b.Name) })
+	return m, nil
+}
+
+// Encode writes the manifest as "json" or "yaml".
+func (m *Manifest) Encode(w io.Writer, format string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case "yaml":
+		// Round-trip through JSON so YAML keys match the JSON field names.
+		var doc any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unsupported format %q: use json or yaml", format)
+	}
+}
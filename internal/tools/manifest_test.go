@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestBuildManifest(t *testing.T) {
+	impl := &mcp.Implementation{Name: "test-server", Version: "1.2.3"}
+	server := mcp.NewServer(impl, nil)
+	AddTool(server, &mcp.Tool{Name: "zeta", Description: "last"}, echo)
+	AddTool(server, &mcp.Tool{Name: "alpha", Description: "first"}, echo)
+
+	m, err := BuildManifest(context.Background(), impl, server)
+	if err != nil {
+		t.Fatalf("BuildManifest() error = %v", err)
+	}
+	if m.Name != "test-server" || m.Version != "1.2.3" {
+		t.Errorf("manifest server = %s %s", m.Name, m.Version)
+	}
+	if len(m.Tools) != 2 || m.Tools[0].Name != "alpha" || m.Tools[1].Name != "zeta" {
+		t.Fatalf("manifest tools not sorted: %v", m.Tools)
+	}
+	if m.Tools[0].InputSchema == nil || m.Tools[0].OutputSchema == nil {
+		t.Errorf("manifest tool schemas missing")
+	}
+
+	tests := []struct {
+		format  string
+		want    string
+		wantErr string
+	}{
+		{format: "json", want: `"name": "alpha"`},
+		{format: "yaml", want: "name: alpha\n"},
+		{format: "toml", wantErr: "unsupported format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := m.Encode(&buf, tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Encode() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("Encode() output missing %q:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// MaxKeyBytes caps the length of a key.
+const MaxKeyBytes = 256
+
+// Scopes control whether keys are visible to one MCP session or to all.
+const (
+	ScopeSession = "session"
+	ScopeGlobal  = "global"
+)
+
+// Config controls the memory store backend and limits.
+type Config struct {
+	// Backend is "memory" (default) or "file".
+	Backend string
+	// FilePath is where the file backend persists entries.
+	FilePath string
+	// Scope is the default scope, "session" or "global".
+	Scope string
+	// AllowGlobal permits callers to request the global scope explicitly
+	// when the default scope is "session".
+	AllowGlobal   bool
+	DefaultTTL    time.Duration
+	MaxTTL        time.Duration
+	MaxKeys       int
+	MaxValueBytes int
+}
+
+// LoadConfig reads the memory configuration from environment variables.
+func LoadConfig() Config {
+	return Config{
+		Backend:       config.GetEnv("MEMORY_BACKEND", "memory"),
+		FilePath:      config.GetEnv("MEMORY_FILE", "memory.json"),
+		Scope:         config.GetEnv("MEMORY_SCOPE", ScopeSession),
+		AllowGlobal:   config.GetEnvBool("MEMORY_ALLOW_GLOBAL", false),
+		DefaultTTL:    config.GetEnvDuration("MEMORY_DEFAULT_TTL", 0),
+		MaxTTL:        config.GetEnvDuration("MEMORY_MAX_TTL", 0),
+		MaxKeys:       config.GetEnvInt("MEMORY_MAX_KEYS", 10000),
+		MaxValueBytes: config.GetEnvInt("MEMORY_MAX_VALUE_BYTES", 64<<10),
+	}
+}
+
+// NewStore creates the Store selected by cfg.Backend.
+func NewStore(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryStore(cfg.MaxKeys), nil
+	case "file":
+		return NewFileStore(cfg.FilePath, cfg.MaxKeys)
+	default:
+		return nil, fmt.Errorf("unsupported memory backend %q: use memory or file", cfg.Backend)
+	}
+}
+
+// SetInput is the input for the memory_set tool.
+type SetInput struct {
+	Key        string `json:"key" jsonschema:"the key to store the value under"`
+	Value      any    `json:"value" jsonschema:"any JSON value"`
+	TTLSeconds int    `json:"ttl_seconds,omitempty" jsonschema:"seconds until the value expires; 0 uses the server default"`
+	Scope      string `json:"scope,omitempty" jsonschema:"session or global; defaults to the server setting"`
+}
+
+// KeyInput is the input for the memory_get and memory_delete tools.
+type KeyInput struct {
+	Key   string `json:"key" jsonschema:"the key to look up"`
+	Scope string `json:"scope,omitempty" jsonschema:"session or global; defaults to the server setting"`
+}
+
+// ListInput is the input for the memory_list tool.
+type ListInput struct {
+	Prefix string `json:"prefix,omitempty" jsonschema:"only list keys starting with this prefix"`
+	Scope  string `json:"scope,omitempty" jsonschema:"session or global; defaults to the server setting"`
+}
+
+// Item is a stored value as returned to callers.
+type Item struct {
+	Key       string `json:"key" jsonschema:"the key"`
+	Value     any    `json:"value,omitempty" jsonschema:"the stored JSON value"`
+	UpdatedAt string `json:"updated_at" jsonschema:"when the value was last set, as RFC 3339"`
+	ExpiresAt string `json:"expires_at,omitempty" jsonschema:"when the value expires, as RFC 3339"`
+}
+
+// GetOutput is the output of the memory_get tool.
+type GetOutput struct {
+	Found bool  `json:"found" jsonschema:"whether the key exists"`
+	Item  *Item `json:"item,omitempty" jsonschema:"the stored item when found"`
+}
+
+// SetOutput is the output of the memory_set tool.
+type SetOutput struct {
+	Item Item `json:"item" jsonschema:"the stored item"`
+}
+
+// ListOutput is the output of the memory_list tool.
+type ListOutput struct {
+	Items []Item `json:"items" jsonschema:"matching items sorted by key, without values"`
+}
+
+// DeleteOutput is the output of the memory_delete tool.
+type DeleteOutput struct {
+	Deleted bool `json:"deleted" jsonschema:"whether the key existed"`
+}
+
+// Memory implements the memory tools over a Store.
+type Memory struct {
+	cfg   Config
+	store Store
+	now   func() time.Time
+}
+
+// NewMemory creates a Memory backed by store.
+func NewMemory(cfg Config, store Store) *Memory {
+	return &Memory{cfg: cfg, store: store, now: time.Now}
+}
+
+// Set stores a value.
+func (m *Memory) Set(ctx context.Context, req *mcp.CallToolRequest, input SetInput) (*mcp.CallToolResult, SetOutput, error) {
+	key, err := m.storeKey(req, input.Scope, input.Key)
+	if err != nil {
+		return nil, SetOutput{}, err
+	}
+	value, err := json.Marshal(input.Value)
+	if err != nil {
+		return nil, SetOutput{}, tools.WrapError(tools.CodeInvalidArgument, err, "invalid value")
+	}
+	if len(value) > m.cfg.MaxValueBytes {
+		return nil, SetOutput{}, tools.NewError(tools.CodeResourceExhausted, "value exceeds %d bytes", m.cfg.MaxValueBytes).
+			WithDetail("max_bytes", m.cfg.MaxValueBytes)
+	}
+	if input.TTLSeconds < 0 {
+		return nil, SetOutput{}, tools.NewError(tools.CodeInvalidArgument, "ttl_seconds must not be negative")
+	}
+
+	ttl := m.cfg.DefaultTTL
+	if input.TTLSeconds > 0 {
+		ttl = time.Duration(input.TTLSeconds) * time.Second
+	}
+	if m.cfg.MaxTTL > 0 && (ttl == 0 || ttl > m.cfg.MaxTTL) {
+		ttl = m.cfg.MaxTTL
+	}
+
+	now := m.now().UTC()
+	entry := Entry{Key: key, Value: value, UpdatedAt: now}
+	if ttl > 0 {
+		entry.ExpiresAt = now.Add(ttl)
+	}
+	if err := m.store.Set(ctx, entry); err != nil {
+		code := tools.CodeInternal
+		if errors.Is(err, ErrStoreFull) {
+			code = tools.CodeResourceExhausted
+		}
+		return nil, SetOutput{}, tools.WrapError(code, err, "storing "+input.Key)
+	}
+
+	logger.Info("tool called", "tool", "memory_set", "key", input.Key, "bytes", len(value))
+	return nil, SetOutput{Item: toItem(entry, input.Key, false)}, nil
+}
+
+// Get retrieves a value.
+func (m *Memory) Get(ctx context.Context, req *mcp.CallToolRequest, input KeyInput) (*mcp.CallToolResult, GetOutput, error) {
+	key, err := m.storeKey(req, input.Scope, input.Key)
+	if err != nil {
+		return nil, GetOutput{}, err
+	}
+	entry, ok, err := m.store.Get(ctx, key)
+	if err != nil {
+		return nil, GetOutput{}, err
+	}
+
+	logger.Info("tool called", "tool", "memory_get", "key", input.Key, "found", ok)
+	if !ok {
+		return nil, GetOutput{}, nil
+	}
+	item := toItem(entry, input.Key, true)
+	return nil, GetOutput{Found: true, Item: &item}, nil
+}
+
+// List enumerates keys in the caller's scope.
+func (m *Memory) List(ctx context.Context, req *mcp.CallToolRequest, input ListInput) (*mcp.CallToolResult, ListOutput, error) {
+	scope, err := m.scopePrefix(req, input.Scope)
+	if err != nil {
+		return nil, ListOutput{}, err
+	}
+	entries, err := m.store.List(ctx, scope+input.Prefix)
+	if err != nil {
+		return nil, ListOutput{}, err
+	}
+
+	out := ListOutput{Items: make([]Item, 0, len(entries))}
+	for _, e := range entries {
+		out.Items = append(out.Items, toItem(e, strings.TrimPrefix(e.Key, scope), false))
+	}
+
+	logger.Info("tool called", "tool", "memory_list", "prefix", input.Prefix, "count", len(out.Items))
+	return nil, out, nil
+}
+
+// Delete removes a value.
+func (m *Memory) Delete(ctx context.Context, req *mcp.CallToolRequest, input KeyInput) (*mcp.CallToolResult, DeleteOutput, error) {
+	key, err := m.storeKey(req, input.Scope, input.Key)
+	if err != nil {
+		return nil, DeleteOutput{}, err
+	}
+	deleted, err := m.store.Delete(ctx, key)
+	if err != nil {
+		return nil, DeleteOutput{}, err
+	}
+
+	logger.Info("tool called", "tool", "memory_delete", "key", input.Key, "deleted", deleted)
+	return nil, DeleteOutput{Deleted: deleted}, nil
+}
+
+// storeKey validates key and qualifies it with the caller's scope.
+func (m *Memory) storeKey(req *mcp.CallToolRequest, scope, key string) (string, error) {
+	if key == "" {
+		return "", tools.NewError(tools.CodeInvalidArgument, "key is required")
+	}
+	if len(key) > MaxKeyBytes {
+		return "", tools.NewError(tools.CodeInvalidArgument, "key exceeds %d bytes", MaxKeyBytes).
+			WithDetail("max_bytes", MaxKeyBytes)
+	}
+	prefix, err := m.scopePrefix(req, scope)
+	if err != nil {
+		return "", err
+	}
+	return prefix + key, nil
+}
+
+// scopePrefix returns the store key prefix for the requested scope. Session
+// keys are namespaced by the MCP session ID so sessions cannot observe each
+// other's values.
+func (m *Memory) scopePrefix(req *mcp.CallToolRequest, scope string) (string, error) {
+	if scope == "" {
+		scope = m.cfg.Scope
+	}
+	switch scope {
+	case ScopeGlobal:
+		if m.cfg.Scope != ScopeGlobal && !m.cfg.AllowGlobal {
+			return "", tools.NewError(tools.CodePermissionDenied, "global scope is not enabled on this server")
+		}
+		return "global/", nil
+	case ScopeSession:
+		id := ""
+		if req != nil && req.Session != nil {
+			id = req.Session.ID()
+		}
+		return "session/" + id + "/", nil
+	default:
+		return "", tools.NewError(tools.CodeInvalidArgument, "unsupported scope %q: use session or global", scope)
+	}
+}
+
+// toItem converts an Entry into its caller-facing form.
+func toItem(e Entry, key string, withValue bool) Item {
+	item := Item{Key: key, UpdatedAt: e.UpdatedAt.Format(time.RFC3339)}
+	if !e.ExpiresAt.IsZero() {
+		item.ExpiresAt = e.ExpiresAt.Format(time.RFC3339)
+	}
+	if withValue {
+		var v any
+		if err := json.Unmarshal(e.Value, &v); err == nil {
+			item.Value = v
+		}
+	}
+	return item
+}
+
+func init() {
+	tools.RegisterConfig(func() { LoadConfig() })
+	tools.Register(tools.Namespace("memory", func(server *mcp.Server) {
+		cfg := LoadConfig()
+		store, err := NewStore(cfg)
+		if err != nil {
+			logger.Error("memory tools disabled", "error", err)
+			return
+		}
+		m := NewMemory(cfg, store)
+
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "memory_set",
+			Description: "Store a JSON value under a key, optionally with a TTL, to persist state between calls",
+			Annotations: tools.Destructive(true, false),
+		}, m.Set)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "memory_get",
+			Description: "Retrieve a value previously stored with memory_set",
+			Annotations: tools.ReadOnly(false),
+		}, m.Get)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "memory_list",
+			Description: "List stored keys, optionally filtered by prefix",
+			Annotations: tools.ReadOnly(false),
+		}, m.List)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "memory_delete",
+			Description: "Delete a stored key",
+			Annotations: tools.Destructive(true, false),
+		}, m.Delete)
+	}))
+}
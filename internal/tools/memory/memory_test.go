@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func newTestMemory(cfg Config) *Memory {
+	if cfg.Scope == "" {
+		cfg.Scope = ScopeSession
+	}
+	cfg.MaxKeys = 100
+	cfg.MaxValueBytes = 32
+	return NewMemory(cfg, NewMemoryStore(cfg.MaxKeys))
+}
+
+func TestMemory_RoundTrip(t *testing.T) {
+	m := newTestMemory(Config{})
+	ctx := context.Background()
+	req := &mcp.CallToolRequest{}
+
+	if _, _, err := m.Set(ctx, req, SetInput{Key: "notes/a", Value: map[string]any{"n": 1.0}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Set(ctx, req, SetInput{Key: "other", Value: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, got, err := m.Get(ctx, req, KeyInput{Key: "notes/a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Found || got.Item.Value.(map[string]any)["n"] != 1.0 {
+		t.Fatalf("Get = %+v, want stored value", got)
+	}
+
+	_, list, err := m.List(ctx, req, ListInput{Prefix: "notes/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Key != "notes/a" {
+		t.Fatalf("List = %+v, want notes/a", list.Items)
+	}
+
+	_, del, _ := m.Delete(ctx, req, KeyInput{Key: "notes/a"})
+	if !del.Deleted {
+		t.Error("Deleted = false, want true")
+	}
+	_, got, _ = m.Get(ctx, req, KeyInput{Key: "notes/a"})
+	if got.Found {
+		t.Error("Found = true after delete")
+	}
+}
+
+func TestMemory_TTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := newTestMemory(Config{DefaultTTL: time.Minute, MaxTTL: time.Hour})
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		ttl  int
+		want time.Time
+	}{
+		{name: "default", want: now.Add(time.Minute)},
+		{name: "explicit", ttl: 120, want: now.Add(2 * time.Minute)},
+		{name: "capped", ttl: 7200, want: now.Add(time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := m.Set(ctx, &mcp.CallToolRequest{}, SetInput{Key: tt.name, Value: 1, TTLSeconds: tt.ttl})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Item.ExpiresAt != tt.want.Format(time.RFC3339) {
+				t.Errorf("ExpiresAt = %s, want %s", out.Item.ExpiresAt, tt.want.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestMemory_Errors(t *testing.T) {
+	m := newTestMemory(Config{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		input    SetInput
+		wantErr  string
+		wantCode tools.Code
+	}{
+		{name: "empty key", input: SetInput{Value: 1}, wantErr: "key is required", wantCode: tools.CodeInvalidArgument},
+		{name: "long key", input: SetInput{Key: strings.Repeat("k", MaxKeyBytes+1)}, wantErr: "key exceeds", wantCode: tools.CodeInvalidArgument},
+		{name: "large value", input: SetInput{Key: "k", Value: strings.Repeat("v", 40)}, wantErr: "value exceeds", wantCode: tools.CodeResourceExhausted},
+		{name: "negative ttl", input: SetInput{Key: "k", TTLSeconds: -1}, wantErr: "must not be negative", wantCode: tools.CodeInvalidArgument},
+		{name: "global disabled", input: SetInput{Key: "k", Scope: ScopeGlobal}, wantErr: "global scope is not enabled", wantCode: tools.CodePermissionDenied},
+		{name: "bad scope", input: SetInput{Key: "k", Scope: "tenant"}, wantErr: "unsupported scope", wantCode: tools.CodeInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := m.Set(ctx, &mcp.CallToolRequest{}, tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+			if code := tools.AsError(err).Code; code != tt.wantCode {
+				t.Errorf("code = %s, want %s", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestMemory_ScopeIsolation(t *testing.T) {
+	m := newTestMemory(Config{AllowGlobal: true})
+	ctx := context.Background()
+	req := &mcp.CallToolRequest{}
+
+	_, _, _ = m.Set(ctx, req, SetInput{Key: "k", Value: "session"})
+	_, _, _ = m.Set(ctx, req, SetInput{Key: "k", Value: "global", Scope: ScopeGlobal})
+
+	_, got, _ := m.Get(ctx, req, KeyInput{Key: "k"})
+	if got.Item.Value != "session" {
+		t.Errorf("session value = %v, want session", got.Item.Value)
+	}
+	_, got, _ = m.Get(ctx, req, KeyInput{Key: "k", Scope: ScopeGlobal})
+	if got.Item.Value != "global" {
+		t.Errorf("global value = %v, want global", got.Item.Value)
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	if _, err := NewStore(Config{Backend: "redis"}); err == nil {
+		t.Error("expected error for unsupported backend")
+	}
+	if _, err := NewStore(Config{Backend: "memory", MaxKeys: 1}); err != nil {
+		t.Errorf("memory backend: %v", err)
+	}
+}
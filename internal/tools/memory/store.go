@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrStoreFull is returned when a new key would exceed the store's capacity.
+var ErrStoreFull = errors.New("memory store is full")
+
+// Entry is a stored value.
+type Entry struct {
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	// ExpiresAt is zero for entries that never expire.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+func (e Entry) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// Store persists entries. Implementations must be safe for concurrent use
+// and must not return expired entries.
+type Store interface {
+	Get(ctx context.Context, key string) (Entry, bool, error)
+	Set(ctx context.Context, entry Entry) error
+	Delete(ctx context.Context, key string) (bool, error)
+	// List returns entries whose keys start with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]Entry, error)
+}
+
+// MemoryStore is an in-process Store. Expired entries are dropped lazily
+// on access and swept whenever a new key is added.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+	maxKeys int
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty store holding at most maxKeys entries.
+func NewMemoryStore(maxKeys int) *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry), maxKeys: maxKeys, now: time.Now}
+}
+
+// Get returns the entry for key if present and unexpired.
+func (s *MemoryStore) Get(_ context.Context, key string) (Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if ok && e.expired(s.now()) {
+		delete(s.entries, key)
+		return Entry{}, false, nil
+	}
+	return e, ok, nil
+}
+
+// Set stores entry, replacing any existing value for its key.
+func (s *MemoryStore) Set(_ context.Context, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set(entry)
+}
+
+func (s *MemoryStore) set(entry Entry) error {
+	if _, exists := s.entries[entry.Key]; !exists && len(s.entries) >= s.maxKeys {
+		s.sweep()
+		if len(s.entries) >= s.maxKeys {
+			return fmt.Errorf("%w: limit is %d keys", ErrStoreFull, s.maxKeys)
+		}
+	}
+	s.entries[entry.Key] = entry
+	return nil
+}
+
+// Delete removes key, reporting whether it existed.
+func (s *MemoryStore) Delete(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	delete(s.entries, key)
+	return ok && !e.expired(s.now()), nil
+}
+
+// List returns unexpired entries whose keys start with prefix.
+func (s *MemoryStore) List(_ context.Context, prefix string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	var out []Entry
+	for k, e := range s.entries {
+		if strings.HasPrefix(k, prefix) {
+			out = append(out, e)
+		}
+	}
+	slices.SortFunc(out, func(a, b Entry) int { return strings.Compare(a.Key, b.Key) })
+	return out, nil
+}
+
+// sweep drops expired entries. The caller must hold s.mu.
+func (s *MemoryStore) sweep() {
+	now := s.now()
+	for k, e := range s.entries {
+		if e.expired(now) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+// FileStore is a MemoryStore that persists its contents to a JSON file
+// after every change, so entries survive restarts.
+type FileStore struct {
+	*MemoryStore
+	path string
+}
+
+// NewFileStore loads entries from path, if it exists, and persists future
+// changes there.
+func NewFileStore(path string, maxKeys int) (*FileStore, error) {
+	s := &FileStore{MemoryStore: NewMemoryStore(maxKeys), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for _, e := range entries {
+		s.entries[e.Key] = e
+	}
+	s.sweep()
+	return s, nil
+}
+
+// Set stores entry and persists the store.
+func (s *FileStore) Set(_ context.Context, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.set(entry); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// Delete removes key and persists the store.
+func (s *FileStore) Delete(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return false, nil
+	}
+	delete(s.entries, key)
+	return !e.expired(s.now()), s.save()
+}
+
+// save writes all entries to a temporary file and renames it into place
+// so that a crash never leaves a partially written store. The caller must
+// hold s.mu.
+func (s *FileStore) save() error {
+	entries := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".memory-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStore(10)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_ = s.Set(ctx, Entry{Key: "a", Value: []byte(`1`), ExpiresAt: now.Add(time.Minute)})
+	_ = s.Set(ctx, Entry{Key: "b", Value: []byte(`2`)})
+
+	if _, ok, _ := s.Get(ctx, "a"); !ok {
+		t.Fatal("a missing before expiry")
+	}
+	now = now.Add(time.Minute)
+	if _, ok, _ := s.Get(ctx, "a"); ok {
+		t.Fatal("a present after expiry")
+	}
+	entries, _ := s.List(ctx, "")
+	if len(entries) != 1 || entries[0].Key != "b" {
+		t.Fatalf("List = %v, want only b", entries)
+	}
+}
+
+func TestMemoryStore_Full(t *testing.T) {
+	s := NewMemoryStore(1)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, Entry{Key: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ctx, Entry{Key: "a", Value: []byte(`2`)}); err != nil {
+		t.Fatalf("overwrite failed: %v", err)
+	}
+	if err := s.Set(ctx, Entry{Key: "b"}); !errors.Is(err, ErrStoreFull) {
+		t.Fatalf("error = %v, want ErrStoreFull", err)
+	}
+}
+
+func TestFileStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.json")
+	ctx := context.Background()
+
+	s, err := NewFileStore(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Set(ctx, Entry{Key: "keep", Value: []byte(`"yes"`)})
+	_ = s.Set(ctx, Entry{Key: "drop", Value: []byte(`"no"`)})
+	if deleted, err := s.Delete(ctx, "drop"); !deleted || err != nil {
+		t.Fatalf("Delete = %v, %v", deleted, err)
+	}
+
+	reopened, err := NewFileStore(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := reopened.List(ctx, "")
+	if len(entries) != 1 || entries[0].Key != "keep" || string(entries[0].Value) != `"yes"` {
+		t.Fatalf("reloaded entries = %v, want only keep", entries)
+	}
+}
@@ -0,0 +1,232 @@
+package tools
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
+
+// Pool metrics.
+var (
+	// PoolQueueWait observes how long calls waited for a worker.
+	PoolQueueWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mcp_tool_queue_wait_seconds",
+			Help:    "Time tool calls waited in the worker pool queue, by tool.",
+			Buckets: []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10, 30},
+		},
+		[]string{"tool"},
+	)
+	// PoolQueued is the number of calls waiting for a worker.
+	PoolQueued = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "mcp_tool_queue_depth",
+			Help: "Number of tool calls waiting in the worker pool queue.",
+		},
+	)
+	// PoolRejected counts calls rejected because the queue was full.
+	PoolRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcp_tool_queue_rejected_total",
+			Help: "Total number of tool calls rejected because the worker pool queue was full, by tool.",
+		},
+		[]string{"tool"},
+	)
+)
+
+// PoolConfig sizes the worker pool.
+type PoolConfig struct {
+	// Workers is how many tool calls run at once; 0 disables the pool.
+	Workers int
+	// Queue is how many calls may wait for a worker before further calls
+	// are rejected.
+	Queue int
+	// Priorities orders waiting calls by tool, highest first; tools not
+	// listed have priority 0. Calls of equal priority wait in turn.
+	Priorities map[string]int
+}
+
+// LoadPoolConfig reads the TOOL_POOL_* settings. TOOL_POOL_PRIORITIES is
+// a comma-separated list of tool=priority; invalid entries are logged and
+// ignored.
+func LoadPoolConfig() PoolConfig {
+	cfg := PoolConfig{
+		Workers:    config.GetEnvInt("TOOL_POOL_WORKERS", 0),
+		Queue:      config.GetEnvInt("TOOL_POOL_QUEUE", 100),
+		Priorities: make(map[string]int),
+	}
+	for _, entry := range config.GetEnvList("TOOL_POOL_PRIORITIES") {
+		name, value, _ := strings.Cut(entry, "=")
+		priority, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			logger.Warn("invalid TOOL_POOL_PRIORITIES entry ignored", "entry", entry)
+			continue
+		}
+		cfg.Priorities[strings.TrimSpace(name)] = priority
+	}
+	return cfg
+}
+
+// Pool bounds how many tool calls run at once, smoothing load when tools
+// do heavy CPU work. Calls beyond the bound wait in a queue, highest
+// priority first, and run on their own goroutine once a worker is free,
+// so a tool's context, panics, and results behave as without the pool.
+type Pool struct {
+	cfg PoolConfig
+
+	mu      sync.Mutex
+	running int
+	queue   waitQueue
+	seq     uint64
+}
+
+// NewPool returns a Pool for cfg, or nil when cfg.Workers is 0.
+func NewPool(cfg PoolConfig) *Pool {
+	if cfg.Workers <= 0 {
+		return nil
+	}
+	return &Pool{cfg: cfg}
+}
+
+// pools holds the pool shared by every tool registered with the same
+// configuration, so TOOL_POOL_WORKERS bounds all tools together.
+var pools = struct {
+	sync.Mutex
+	key  string
+	pool *Pool
+}{}
+
+// sharedPool returns the Pool for cfg shared by all tools.
+func sharedPool(cfg PoolConfig) *Pool {
+	pools.Lock()
+	defer pools.Unlock()
+	// fmt prints maps in key order, so equal configurations match
+	if key := fmt.Sprint(cfg); key != pools.key {
+		pools.key, pools.pool = key, NewPool(cfg)
+	}
+	return pools.pool
+}
+
+// Middleware is the Middleware running the tool called name in p. A call
+// waits for a worker until its context ends and fails with
+// resource_exhausted when the queue is full.
+func (p *Pool) Middleware(name string, next Handler) Handler {
+	priority := p.cfg.Priorities[name]
+	wait := PoolQueueWait.WithLabelValues(name)
+	return func(ctx context.Context, req *mcp.CallToolRequest, in any) (*mcp.CallToolResult, any, error) {
+		start := time.Now()
+		ok, err := p.acquire(ctx, priority)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			PoolRejected.WithLabelValues(name).Inc()
+			return nil, nil, NewError(CodeResourceExhausted, "tool %s: too many calls waiting, try again later", name).
+				Retry(true).WithDetail("queue", p.cfg.Queue)
+		}
+		defer p.release()
+		wait.Observe(time.Since(start).Seconds())
+		return next(ctx, req, in)
+	}
+}
+
+// acquire waits for a worker. It returns false when the queue is full,
+// and ctx's error when ctx ends first.
+func (p *Pool) acquire(ctx context.Context, priority int) (bool, error) {
+	p.mu.Lock()
+	if p.running < p.cfg.Workers {
+		p.running++
+		p.mu.Unlock()
+		return true, nil
+	}
+	if p.queue.Len() >= p.cfg.Queue {
+		p.mu.Unlock()
+		return false, nil
+	}
+	p.seq++
+	w := &waiter{priority: priority, seq: p.seq, ready: make(chan struct{})}
+	heap.Push(&p.queue, w)
+	PoolQueued.Inc()
+	p.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return true, nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		if w.index >= 0 {
+			heap.Remove(&p.queue, w.index)
+			PoolQueued.Dec()
+			p.mu.Unlock()
+			return false, ctx.Err()
+		}
+		p.mu.Unlock()
+		// The worker was handed over as ctx ended; pass it on
+		p.release()
+		return false, ctx.Err()
+	}
+}
+
+// release hands the caller's worker to the first waiting call, or frees
+// it.
+func (p *Pool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queue.Len() == 0 {
+		p.running--
+		return
+	}
+	w := heap.Pop(&p.queue).(*waiter)
+	PoolQueued.Dec()
+	close(w.ready)
+}
+
+// waiter is a call waiting for a worker.
+type waiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	index    int // in the queue, or -1 once removed
+}
+
+// waitQueue is a heap of waiters, highest priority and then earliest
+// first.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
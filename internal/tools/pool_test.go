@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestPool(t *testing.T) {
+	p := NewPool(PoolConfig{Workers: 1, Queue: 2, Priorities: map[string]int{"urgent": 5}})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	handler := func(name string) Handler {
+		return p.Middleware(name, func(context.Context, *mcp.CallToolRequest, any) (*mcp.CallToolResult, any, error) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			<-release
+			return nil, nil, nil
+		})
+	}
+	queued := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			p.mu.Lock()
+			got := p.queue.Len()
+			p.mu.Unlock()
+			if got == n {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("queue length = %d, want %d", got, n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	var wg sync.WaitGroup
+	call := func(name string) {
+		wg.Go(func() { _, _, _ = handler(name)(context.Background(), nil, nil) })
+	}
+	call("first")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		mu.Lock()
+		started := len(order)
+		mu.Unlock()
+		if started == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first call did not start")
+		}
+	}
+	call("routine")
+	queued(1)
+	call("urgent")
+	queued(2)
+
+	// A full queue rejects further calls at once
+	_, _, err := handler("overflow")(context.Background(), nil, nil)
+	var te *Error
+	if !errors.As(err, &te) || te.Code != CodeResourceExhausted || !te.Retryable {
+		t.Fatalf("call with a full queue: error = %v, want retryable resource_exhausted", err)
+	}
+
+	// A call whose context ends while queued leaves the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cfg.Queue = 3
+	done := make(chan error)
+	go func() {
+		_, _, err := handler("canceled")(ctx, nil, nil)
+		done <- err
+	}()
+	queued(3)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled call error = %v, want context.Canceled", err)
+	}
+	queued(2)
+
+	// Waiting calls run by priority, then in turn
+	close(release)
+	wg.Wait()
+	want := []string{"first", "urgent", "routine"}
+	if len(order) != len(want) || order[0] != want[0] || order[1] != want[1] || order[2] != want[2] {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if p.running != 0 {
+		t.Errorf("running = %d after every call returned, want 0", p.running)
+	}
+}
+
+func TestLoadPoolConfig(t *testing.T) {
+	t.Setenv("TOOL_POOL_WORKERS", "4")
+	t.Setenv("TOOL_POOL_PRIORITIES", "exec=10, hash = -2, bogus=high")
+	cfg := LoadPoolConfig()
+	if cfg.Workers != 4 || cfg.Queue != 100 || len(cfg.Priorities) != 2 || cfg.Priorities["exec"] != 10 || cfg.Priorities["hash"] != -2 {
+		t.Errorf("LoadPoolConfig() = %+v", cfg)
+	}
+	if sharedPool(cfg) != sharedPool(LoadPoolConfig()) {
+		t.Error("equal configurations do not share a pool")
+	}
+	if NewPool(PoolConfig{}) != nil {
+		t.Error("NewPool() without workers returned a pool")
+	}
+}
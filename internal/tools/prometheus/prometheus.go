@@ -0,0 +1,358 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/httpclient"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// Config controls the Prometheus endpoint and query guards.
+type Config struct {
+	// URL is the Prometheus base URL, e.g. http://prometheus:9090. An
+	// empty URL disables the tool.
+	URL         string
+	BearerToken string
+	Timeout     time.Duration
+	// MaxRange bounds end-start for range queries.
+	MaxRange time.Duration
+	// MaxPoints bounds (end-start)/step for range queries.
+	MaxPoints int
+	MaxSeries int
+	MaxBytes  int64
+}
+
+// LoadConfig reads the Prometheus configuration from environment variables.
+func LoadConfig() Config {
+	return Config{
+		URL:         config.GetEnv("PROM_URL", ""),
+		BearerToken: config.GetEnv("PROM_BEARER_TOKEN", ""),
+		Timeout:     config.GetEnvDuration("PROM_TIMEOUT", 30*time.Second),
+		MaxRange:    config.GetEnvDuration("PROM_MAX_RANGE", 7*24*time.Hour),
+		MaxPoints:   config.GetEnvInt("PROM_MAX_POINTS", 11000),
+		MaxSeries:   config.GetEnvInt("PROM_MAX_SERIES", 500),
+		MaxBytes:    int64(config.GetEnvInt("PROM_MAX_BYTES", 10<<20)),
+	}
+}
+
+// Input is the input for the prom_query tool.
+type Input struct {
+	Query     string `json:"query" jsonschema:"the PromQL expression"`
+	Time      string `json:"time,omitempty" jsonschema:"evaluation time for instant queries: RFC 3339, unix seconds, now, or now-<duration> (default now)"`
+	Start     string `json:"start,omitempty" jsonschema:"start of a range query; setting it makes the query a range query"`
+	End       string `json:"end,omitempty" jsonschema:"end of a range query (default now)"`
+	Step      string `json:"step,omitempty" jsonschema:"range query resolution as a duration, e.g. 30s or 5m (default: range/250)"`
+	MaxSeries int    `json:"max_series,omitempty" jsonschema:"maximum series to return; capped by the server limit"`
+}
+
+// Sample is a single timestamped value. Values are strings as in the
+// Prometheus API so that NaN and ±Inf survive JSON encoding.
+type Sample struct {
+	Time  string `json:"time" jsonschema:"the sample time as RFC 3339"`
+	Value string `json:"value" jsonschema:"the sample value"`
+}
+
+// Series is one labelled result.
+type Series struct {
+	Metric map[string]string `json:"metric" jsonschema:"the series labels"`
+	Value  *Sample           `json:"value,omitempty" jsonschema:"the sample for instant vector and scalar results"`
+	Values []Sample          `json:"values,omitempty" jsonschema:"the samples for range results"`
+}
+
+// Output is the output of the prom_query tool.
+type Output struct {
+	ResultType string   `json:"result_type" jsonschema:"vector, matrix, scalar, or string"`
+	Series     []Series `json:"series" jsonschema:"the result series"`
+	Truncated  bool     `json:"truncated" jsonschema:"whether series were omitted due to the series limit"`
+	Warnings   []string `json:"warnings,omitempty" jsonschema:"warnings reported by Prometheus"`
+}
+
+// Client queries a Prometheus HTTP API.
+type Client struct {
+	cfg    Config
+	base   *url.URL
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewClient validates the endpoint URL and returns a Client.
+func NewClient(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid Prometheus URL %q", cfg.URL)
+	}
+	return &Client{cfg: cfg, base: base, client: httpclient.New("prom_query", httpclient.Options{Timeout: cfg.Timeout}), now: time.Now}, nil
+}
+
+// Query runs an instant or range query.
+func (c *Client) Query(ctx context.Context, req *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	if strings.TrimSpace(input.Query) == "" {
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "query is required")
+	}
+	now := c.now()
+	params := url.Values{"query": {input.Query}}
+	endpoint := "/api/v1/query"
+
+	if input.Start == "" {
+		if input.End != "" || input.Step != "" {
+			return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "end and step require start")
+		}
+		t, err := parseTime(input.Time, now)
+		if err != nil {
+			return nil, Output{}, tools.WrapError(tools.CodeInvalidArgument, err, "invalid time")
+		}
+		params.Set("time", formatTime(t))
+	} else {
+		start, end, step, err := c.rangeParams(input, now)
+		if err != nil {
+			return nil, Output{}, err
+		}
+		endpoint = "/api/v1/query_range"
+		params.Set("start", formatTime(start))
+		params.Set("end", formatTime(end))
+		params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	}
+
+	maxSeries := c.cfg.MaxSeries
+	if input.MaxSeries > 0 && input.MaxSeries < maxSeries {
+		maxSeries = input.MaxSeries
+	}
+	params.Set("limit", strconv.Itoa(maxSeries+1))
+
+	out, err := c.do(ctx, endpoint, params, maxSeries)
+	if err != nil {
+		return nil, Output{}, err
+	}
+
+	logger.Info("tool called", "tool", "prom_query", "endpoint", endpoint, "result_type", out.ResultType, "series", len(out.Series))
+	return nil, out, nil
+}
+
+// rangeParams resolves and guards the range query window and step.
+func (c *Client) rangeParams(input Input, now time.Time) (time.Time, time.Time, time.Duration, error) {
+	start, err := parseTime(input.Start, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, tools.WrapError(tools.CodeInvalidArgument, err, "invalid start")
+	}
+	end, err := parseTime(input.End, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, tools.WrapError(tools.CodeInvalidArgument, err, "invalid end")
+	}
+	window := end.Sub(start)
+	if window <= 0 {
+		return time.Time{}, time.Time{}, 0, tools.NewError(tools.CodeInvalidArgument, "start must be before end")
+	}
+	if window > c.cfg.MaxRange {
+		return time.Time{}, time.Time{}, 0, tools.NewError(tools.CodeInvalidArgument, "range %s exceeds the maximum of %s", window, c.cfg.MaxRange).
+			WithDetail("max_range", c.cfg.MaxRange.String())
+	}
+
+	var step time.Duration
+	if input.Step == "" {
+		step = max((window / 250).Round(time.Second), time.Second)
+	} else if step, err = time.ParseDuration(input.Step); err != nil || step <= 0 {
+		return time.Time{}, time.Time{}, 0, tools.NewError(tools.CodeInvalidArgument, "invalid step %q", input.Step)
+	}
+	if points := int(window/step) + 1; points > c.cfg.MaxPoints {
+		return time.Time{}, time.Time{}, 0, tools.NewError(tools.CodeInvalidArgument, "range/step yields %d points per series, above the maximum of %d; increase step", points, c.cfg.MaxPoints).
+			WithDetail("max_points", c.cfg.MaxPoints)
+	}
+	return start, end, step, nil
+}
+
+// apiResponse is the Prometheus HTTP API envelope.
+type apiResponse struct {
+	Status    string   `json:"status"`
+	ErrorType string   `json:"errorType"`
+	Error     string   `json:"error"`
+	Warnings  []string `json:"warnings"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type apiSeries struct {
+	Metric map[string]string `json:"metric"`
+	Value  []any             `json:"value"`
+	Values [][]any           `json:"values"`
+}
+
+func (c *Client) do(ctx context.Context, endpoint string, params url.Values, maxSeries int) (Output, error) {
+	u := c.base.JoinPath(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(params.Encode()))
+	if err != nil {
+		return Output{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.BearerToken)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Output{}, tools.WrapError(tools.CodeUnavailable, err, "querying Prometheus")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.cfg.MaxBytes+1))
+	if err != nil {
+		return Output{}, tools.WrapError(tools.CodeUnavailable, err, "reading Prometheus response")
+	}
+	if int64(len(body)) > c.cfg.MaxBytes {
+		return Output{}, tools.NewError(tools.CodeResourceExhausted, "response exceeds %d bytes; narrow the query or increase step", c.cfg.MaxBytes).
+			WithDetail("max_bytes", c.cfg.MaxBytes)
+	}
+
+	var api apiResponse
+	if err := json.Unmarshal(body, &api); err != nil {
+		return Output{}, tools.NewError(tools.CodeUnavailable, "unexpected Prometheus response (status %d)", resp.StatusCode)
+	}
+	if api.Status != "success" {
+		return Output{}, tools.NewError(apiErrorCode(api.ErrorType), "prometheus %s: %s", api.ErrorType, api.Error)
+	}
+
+	out := Output{ResultType: api.Data.ResultType, Series: []Series{}, Warnings: api.Warnings}
+	switch api.Data.ResultType {
+	case "scalar", "string":
+		var pair []any
+		if err := json.Unmarshal(api.Data.Result, &pair); err != nil {
+			return Output{}, err
+		}
+		s, err := sample(pair)
+		if err != nil {
+			return Output{}, err
+		}
+		out.Series = append(out.Series, Series{Metric: map[string]string{}, Value: &s})
+		return out, nil
+	case "vector", "matrix":
+	default:
+		return Output{}, tools.NewError(tools.CodeInternal, "unsupported result type %q", api.Data.ResultType)
+	}
+
+	var series []apiSeries
+	if err := json.Unmarshal(api.Data.Result, &series); err != nil {
+		return Output{}, err
+	}
+	if len(series) > maxSeries {
+		series = series[:maxSeries]
+		out.Truncated = true
+	}
+	for _, s := range series {
+		item := Series{Metric: s.Metric}
+		if item.Metric == nil {
+			item.Metric = map[string]string{}
+		}
+		if s.Value != nil {
+			v, err := sample(s.Value)
+			if err != nil {
+				return Output{}, err
+			}
+			item.Value = &v
+		}
+		for _, pair := range s.Values {
+			v, err := sample(pair)
+			if err != nil {
+				return Output{}, err
+			}
+			item.Values = append(item.Values, v)
+		}
+		out.Series = append(out.Series, item)
+	}
+	return out, nil
+}
+
+// apiErrorCode maps a Prometheus errorType to a tool error code.
+func apiErrorCode(errorType string) tools.Code {
+	switch errorType {
+	case "bad_data":
+		return tools.CodeInvalidArgument
+	case "timeout":
+		return tools.CodeDeadlineExceeded
+	case "canceled":
+		return tools.CodeCanceled
+	case "unavailable":
+		return tools.CodeUnavailable
+	default:
+		return tools.CodeInternal
+	}
+}
+
+// sample converts a [unix_seconds, "value"] pair.
+func sample(pair []any) (Sample, error) {
+	if len(pair) != 2 {
+		return Sample{}, errors.New("malformed sample")
+	}
+	ts, ok := pair[0].(float64)
+	value, ok2 := pair[1].(string)
+	if !ok || !ok2 {
+		return Sample{}, errors.New("malformed sample")
+	}
+	sec, frac := math.Modf(ts)
+	t := time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	return Sample{Time: t.Format(time.RFC3339Nano), Value: value}, nil
+}
+
+// parseTime accepts RFC 3339, unix seconds, "now", or "now-<duration>".
+func parseTime(s string, now time.Time) (time.Time, error) {
+	switch {
+	case s == "" || s == "now":
+		return now, nil
+	case strings.HasPrefix(s, "now-"):
+		d, err := time.ParseDuration(s[len("now-"):])
+		if err != nil || d < 0 {
+			return time.Time{}, fmt.Errorf("invalid relative time %q", s)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
+
+func init() {
+	tools.RegisterConfig(func() { LoadConfig() })
+	tools.Register(tools.Namespace("prometheus", func(server *mcp.Server) {
+		cfg := LoadConfig()
+		if cfg.URL == "" {
+			return
+		}
+		c, err := NewClient(cfg)
+		if err != nil {
+			logger.Error("prom_query disabled", "error", err)
+			return
+		}
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "prom_query",
+			Description: "Run an instant or range PromQL query against the configured Prometheus server",
+			Annotations: tools.ReadOnly(true),
+		}, c.Query)
+	}))
+}
@@ -0,0 +1,168 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(Config{
+		URL:       srv.URL,
+		Timeout:   5 * time.Second,
+		MaxRange:  24 * time.Hour,
+		MaxPoints: 100,
+		MaxSeries: 2,
+		MaxBytes:  1 << 20,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	c.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return c
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         Input
+		response      string
+		wantPath      string
+		wantResult    string
+		wantSeries    int
+		wantTruncated bool
+		wantErr       string
+	}{
+		{
+			name:       "instant vector",
+			input:      Input{Query: "up"},
+			response:   `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"a"},"value":[1700000000,"1"]}]}}`,
+			wantPath:   "/api/v1/query",
+			wantResult: "vector",
+			wantSeries: 1,
+		},
+		{
+			name:          "series truncated",
+			input:         Input{Query: "up"},
+			response:      `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"a"},"value":[1,"1"]},{"metric":{"job":"b"},"value":[1,"1"]},{"metric":{"job":"c"},"value":[1,"1"]}]}}`,
+			wantPath:      "/api/v1/query",
+			wantResult:    "vector",
+			wantSeries:    2,
+			wantTruncated: true,
+		},
+		{
+			name:       "scalar",
+			input:      Input{Query: "1+1", Time: "2023-11-14T22:13:20Z"},
+			response:   `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"2"]}}`,
+			wantPath:   "/api/v1/query",
+			wantResult: "scalar",
+			wantSeries: 1,
+		},
+		{
+			name:       "range matrix",
+			input:      Input{Query: "up", Start: "now-1h", Step: "1m"},
+			response:   `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1699996400,"1"],[1699996460,"1"]]}]}}`,
+			wantPath:   "/api/v1/query_range",
+			wantResult: "matrix",
+			wantSeries: 1,
+		},
+		{
+			name:     "prometheus error",
+			input:    Input{Query: "up{"},
+			response: `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			wantErr:  "bad_data: parse error",
+		},
+		{
+			name:    "empty query",
+			input:   Input{},
+			wantErr: "query is required",
+		},
+		{
+			name:    "range too long",
+			input:   Input{Query: "up", Start: "now-48h"},
+			wantErr: "exceeds the maximum",
+		},
+		{
+			name:    "too many points",
+			input:   Input{Query: "up", Start: "now-1h", Step: "10s"},
+			wantErr: "increase step",
+		},
+		{
+			name:    "start after end",
+			input:   Input{Query: "up", Start: "now", End: "now-1h"},
+			wantErr: "start must be before end",
+		},
+		{
+			name:    "step without start",
+			input:   Input{Query: "up", Step: "1m"},
+			wantErr: "require start",
+		},
+		{
+			name:    "invalid time",
+			input:   Input{Query: "up", Time: "yesterday"},
+			wantErr: "invalid time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath string
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				_, _ = w.Write([]byte(tt.response))
+			})
+
+			_, out, err := c.Query(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Query() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if gotPath != tt.wantPath {
+				t.Errorf("path = %q, want %q", gotPath, tt.wantPath)
+			}
+			if out.ResultType != tt.wantResult {
+				t.Errorf("ResultType = %q, want %q", out.ResultType, tt.wantResult)
+			}
+			if len(out.Series) != tt.wantSeries {
+				t.Errorf("len(Series) = %d, want %d", len(out.Series), tt.wantSeries)
+			}
+			if out.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", out.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestQueryMaxBytes(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	})
+	c.cfg.MaxBytes = 10
+
+	_, _, err := c.Query(context.Background(), &mcp.CallToolRequest{}, Input{Query: "up"})
+	if err == nil || !strings.Contains(err.Error(), "exceeds 10 bytes") {
+		t.Fatalf("Query() error = %v, want size error", err)
+	}
+}
+
+func TestNewClientInvalidURL(t *testing.T) {
+	for _, u := range []string{"prometheus:9090", "ftp://host", "http://"} {
+		if _, err := NewClient(Config{URL: u}); err == nil {
+			t.Errorf("NewClient(%q) error = nil, want error", u)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package random
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"log/slog"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// Limits applied to generator inputs.
+const (
+	MaxCount        = 1000
+	MaxStringLength = 1024
+	MaxBytes        = 4096
+)
+
+// charClasses maps character class names to their alphabets.
+var charClasses = map[string]string{
+	"lower":   "abcdefghijklmnopqrstuvwxyz",
+	"upper":   "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"digits":  "0123456789",
+	"symbols": "!@#$%^&*()-_=+[]{};:,.<>?/~",
+}
+
+// ambiguous lists characters that are easily confused when read aloud or
+// in certain fonts.
+const ambiguous = "0O1lI|"
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// IntInput is the input for the random_int tool.
+type IntInput struct {
+	Min   int64 `json:"min" jsonschema:"inclusive lower bound"`
+	Max   int64 `json:"max" jsonschema:"inclusive upper bound"`
+	Count int   `json:"count,omitempty" jsonschema:"how many integers to generate (default: 1, max: 1000)"`
+}
+
+// IntOutput is the output of the random_int tool.
+type IntOutput struct {
+	Values []int64 `json:"values" jsonschema:"the generated integers"`
+}
+
+// StringInput is the input for the random_string tool.
+type StringInput struct {
+	Length           int      `json:"length,omitempty" jsonschema:"string length (default: 16, max: 1024)"`
+	Classes          []string `json:"classes,omitempty" jsonschema:"character classes to draw from: lower, upper, digits, symbols (default: lower, upper, digits)"`
+	RequireEach      bool     `json:"require_each,omitempty" jsonschema:"guarantee at least one character from every selected class, as password policies often require"`
+	ExcludeAmbiguous bool     `json:"exclude_ambiguous,omitempty" jsonschema:"exclude easily confused characters such as 0, O, 1, l, and I"`
+}
+
+// StringOutput is the output of the random_string tool.
+type StringOutput struct {
+	Value string `json:"value" jsonschema:"the generated string"`
+}
+
+// BytesInput is the input for the random_bytes tool.
+type BytesInput struct {
+	Length   int    `json:"length,omitempty" jsonschema:"number of random bytes (default: 32, max: 4096)"`
+	Encoding string `json:"encoding,omitempty" jsonschema:"output encoding: base64 (default), base64url, or hex"`
+}
+
+// BytesOutput is the output of the random_bytes tool.
+type BytesOutput struct {
+	Value string `json:"value" jsonschema:"the encoded random bytes"`
+}
+
+// ULIDInput is the input for the generate_ulid tool.
+type ULIDInput struct {
+	Count int `json:"count,omitempty" jsonschema:"how many ULIDs to generate (default: 1, max: 1000)"`
+}
+
+// ULIDOutput is the output of the generate_ulid tool.
+type ULIDOutput struct {
+	ULIDs []string `json:"ulids" jsonschema:"the generated ULIDs"`
+}
+
+// RandomInt generates cryptographically secure integers in [min, max].
+func RandomInt(_ context.Context, _ *mcp.CallToolRequest, input IntInput) (*mcp.CallToolResult, IntOutput, error) {
+	if input.Min > input.Max {
+		return nil, IntOutput{}, tools.NewError(tools.CodeInvalidArgument, "min must not exceed max")
+	}
+	count, err := resolveCount(input.Count)
+	if err != nil {
+		return nil, IntOutput{}, err
+	}
+
+	span := new(big.Int).Sub(big.NewInt(input.Max), big.NewInt(input.Min))
+	span.Add(span, big.NewInt(1))
+
+	values := make([]int64, count)
+	for i := range values {
+		n, err := rand.Int(rand.Reader, span)
+		if err != nil {
+			return nil, IntOutput{}, tools.WrapError(tools.CodeInternal, err, "failed to generate random integer")
+		}
+		values[i] = n.Add(n, big.NewInt(input.Min)).Int64()
+	}
+
+	logger.Info("tool called", "tool", "random_int", "min", input.Min, "max", input.Max, "count", count)
+	return nil, IntOutput{Values: values}, nil
+}
+
+// RandomString generates a secure random string from the selected classes.
+func RandomString(_ context.Context, _ *mcp.CallToolRequest, input StringInput) (*mcp.CallToolResult, StringOutput, error) {
+	length := input.Length
+	if length == 0 {
+		length = 16
+	}
+	if length < 0 || length > MaxStringLength {
+		return nil, StringOutput{}, tools.NewError(tools.CodeInvalidArgument, "length must be between 1 and %d", MaxStringLength).
+			WithDetail("max", MaxStringLength)
+	}
+
+	classes := input.Classes
+	if len(classes) == 0 {
+		classes = []string{"lower", "upper", "digits"}
+	}
+	if input.RequireEach && len(classes) > length {
+		return nil, StringOutput{}, tools.NewError(tools.CodeInvalidArgument, "length %d is too short to include all %d classes", length, len(classes))
+	}
+
+	var alphabets []string
+	for _, class := range classes {
+		chars, ok := charClasses[strings.ToLower(class)]
+		if !ok {
+			return nil, StringOutput{}, tools.NewError(tools.CodeInvalidArgument, "unknown character class %q: use lower, upper, digits, or symbols", class)
+		}
+		if input.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(ambiguous, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+		alphabets = append(alphabets, chars)
+	}
+
+	alphabet := strings.Join(alphabets, "")
+	out := make([]byte, 0, length)
+	if input.RequireEach {
+		for _, chars := range alphabets {
+			c, err := pick(chars)
+			if err != nil {
+				return nil, StringOutput{}, err
+			}
+			out = append(out, c)
+		}
+	}
+	for len(out) < length {
+		c, err := pick(alphabet)
+		if err != nil {
+			return nil, StringOutput{}, err
+		}
+		out = append(out, c)
+	}
+	if err := shuffle(out); err != nil {
+		return nil, StringOutput{}, err
+	}
+
+	logger.Info("tool called", "tool", "random_string", "length", length, "classes", classes)
+	return nil, StringOutput{Value: string(out)}, nil
+}
+
+// RandomBytes generates secure random bytes in the requested encoding.
+func RandomBytes(_ context.Context, _ *mcp.CallToolRequest, input BytesInput) (*mcp.CallToolResult, BytesOutput, error) {
+	length := input.Length
+	if length == 0 {
+		length = 32
+	}
+	if length < 0 || length > MaxBytes {
+		return nil, BytesOutput{}, tools.NewError(tools.CodeInvalidArgument, "length must be between 1 and %d", MaxBytes).
+			WithDetail("max", MaxBytes)
+	}
+
+	var encode func([]byte) string
+	switch strings.ToLower(input.Encoding) {
+	case "", "base64":
+		encode = base64.StdEncoding.EncodeToString
+	case "base64url":
+		encode = base64.RawURLEncoding.EncodeToString
+	case "hex":
+		encode = hex.EncodeToString
+	default:
+		return nil, BytesOutput{}, tools.NewError(tools.CodeInvalidArgument, "unsupported encoding %q: use base64, base64url, or hex", input.Encoding)
+	}
+
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, BytesOutput{}, tools.WrapError(tools.CodeInternal, err, "failed to generate random bytes")
+	}
+
+	logger.Info("tool called", "tool", "random_bytes", "length", length)
+	return nil, BytesOutput{Value: encode(buf)}, nil
+}
+
+// GenerateULID generates lexicographically sortable ULIDs.
+func GenerateULID(_ context.Context, _ *mcp.CallToolRequest, input ULIDInput) (*mcp.CallToolResult, ULIDOutput, error) {
+	count, err := resolveCount(input.Count)
+	if err != nil {
+		return nil, ULIDOutput{}, err
+	}
+
+	ulids := make([]string, count)
+	for i := range ulids {
+		if ulids[i], err = newULID(time.Now()); err != nil {
+			return nil, ULIDOutput{}, err
+		}
+	}
+
+	logger.Info("tool called", "tool", "generate_ulid", "count", count)
+	return nil, ULIDOutput{ULIDs: ulids}, nil
+}
+
+// newULID encodes a 48-bit millisecond timestamp followed by 80 random bits
+// as 26 Crockford base32 characters.
+func newULID(t time.Time) (string, error) {
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(t.UnixMilli())<<16)
+	if _, err := rand.Read(id[6:]); err != nil {
+		return "", tools.WrapError(tools.CodeInternal, err, "failed to generate ULID entropy")
+	}
+
+	// 128 bits are emitted as 26 five-bit groups, the first carrying the
+	// top 3 bits padded with two leading zero bits.
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out), nil
+}
+
+// resolveCount applies the default and bounds to a requested count.
+func resolveCount(count int) (int, error) {
+	if count == 0 {
+		return 1, nil
+	}
+	if count < 0 || count > MaxCount {
+		return 0, tools.NewError(tools.CodeInvalidArgument, "count must be between 1 and %d", MaxCount).
+			WithDetail("max", MaxCount)
+	}
+	return count, nil
+}
+
+// pick returns a uniformly random byte from alphabet.
+func pick(alphabet string) (byte, error) {
+	if alphabet == "" {
+		return 0, tools.NewError(tools.CodeInvalidArgument, "character set is empty")
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+	if err != nil {
+		return 0, tools.WrapError(tools.CodeInternal, err, "failed to generate random character")
+	}
+	return alphabet[n.Int64()], nil
+}
+
+// shuffle performs a Fisher-Yates shuffle using a secure random source.
+func shuffle(b []byte) error {
+	for i := len(b) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return tools.WrapError(tools.CodeInternal, err, "failed to shuffle")
+		}
+		j := int(n.Int64())
+		b[i], b[j] = b[j], b[i]
+	}
+	return nil
+}
+
+func init() {
+	tools.Register(tools.Namespace("util", func(server *mcp.Server) {
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "random_int",
+			Description: "Generate cryptographically secure random integers within an inclusive range",
+			Annotations: tools.ReadOnly(false),
+		}, RandomInt)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "random_string",
+			Description: "Generate a secure random string or password from selected character classes",
+			Annotations: tools.ReadOnly(false),
+		}, RandomString)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "random_bytes",
+			Description: "Generate secure random bytes encoded as base64 or hex",
+			Annotations: tools.ReadOnly(false),
+		}, RandomBytes)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "generate_ulid",
+			Description: "Generate lexicographically sortable ULIDs",
+			Annotations: tools.ReadOnly(false),
+		}, GenerateULID)
+	}))
+}
@@ -0,0 +1,186 @@
+package random
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var ulidRegex = regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)
+
+func TestRandomInt(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     IntInput
+		wantCount int
+		wantErr   bool
+	}{
+		{name: "single value", input: IntInput{Min: 1, Max: 6}, wantCount: 1},
+		{name: "many values", input: IntInput{Min: -5, Max: 5, Count: 200}, wantCount: 200},
+		{name: "degenerate range", input: IntInput{Min: 7, Max: 7, Count: 3}, wantCount: 3},
+		{name: "min greater than max", input: IntInput{Min: 10, Max: 1}, wantErr: true},
+		{name: "count too large", input: IntInput{Max: 1, Count: MaxCount + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := RandomInt(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RandomInt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if code := tools.AsError(err).Code; code != tools.CodeInvalidArgument {
+					t.Errorf("RandomInt() error code = %s, want %s", code, tools.CodeInvalidArgument)
+				}
+				return
+			}
+			if len(out.Values) != tt.wantCount {
+				t.Fatalf("len(Values) = %d, want %d", len(out.Values), tt.wantCount)
+			}
+			for _, v := range out.Values {
+				if v < tt.input.Min || v > tt.input.Max {
+					t.Errorf("value %d outside [%d, %d]", v, tt.input.Min, tt.input.Max)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomString(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      StringInput
+		wantLength int
+		allowed    string
+		wantErr    bool
+	}{
+		{name: "defaults", input: StringInput{}, wantLength: 16, allowed: charClasses["lower"] + charClasses["upper"] + charClasses["digits"]},
+		{name: "digits only", input: StringInput{Length: 8, Classes: []string{"digits"}}, wantLength: 8, allowed: charClasses["digits"]},
+		{name: "exclude ambiguous", input: StringInput{Length: 200, Classes: []string{"upper", "digits"}, ExcludeAmbiguous: true}, wantLength: 200, allowed: "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"},
+		{name: "unknown class", input: StringInput{Classes: []string{"emoji"}}, wantErr: true},
+		{name: "too long", input: StringInput{Length: MaxStringLength + 1}, wantErr: true},
+		{name: "require each too short", input: StringInput{Length: 2, Classes: []string{"lower", "upper", "digits"}, RequireEach: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := RandomString(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RandomString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if code := tools.AsError(err).Code; code != tools.CodeInvalidArgument {
+					t.Errorf("RandomString() error code = %s, want %s", code, tools.CodeInvalidArgument)
+				}
+				return
+			}
+			if len(out.Value) != tt.wantLength {
+				t.Errorf("length = %d, want %d", len(out.Value), tt.wantLength)
+			}
+			for _, r := range out.Value {
+				if !strings.ContainsRune(tt.allowed, r) {
+					t.Errorf("unexpected character %q in %q", r, out.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomString_RequireEach(t *testing.T) {
+	input := StringInput{Length: 4, Classes: []string{"lower", "upper", "digits", "symbols"}, RequireEach: true}
+	for i := 0; i < 50; i++ {
+		_, out, err := RandomString(context.Background(), &mcp.CallToolRequest{}, input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, class := range input.Classes {
+			if !strings.ContainsAny(out.Value, charClasses[class]) {
+				t.Fatalf("%q missing a %s character", out.Value, class)
+			}
+		}
+	}
+}
+
+func TestRandomBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   BytesInput
+		decode  func(string) ([]byte, error)
+		wantLen int
+		wantErr bool
+	}{
+		{name: "default base64", input: BytesInput{}, decode: base64.StdEncoding.DecodeString, wantLen: 32},
+		{name: "hex", input: BytesInput{Length: 16, Encoding: "hex"}, decode: hex.DecodeString, wantLen: 16},
+		{name: "base64url", input: BytesInput{Length: 10, Encoding: "base64url"}, decode: base64.RawURLEncoding.DecodeString, wantLen: 10},
+		{name: "unsupported encoding", input: BytesInput{Encoding: "base32"}, wantErr: true},
+		{name: "too many bytes", input: BytesInput{Length: MaxBytes + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := RandomBytes(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RandomBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			raw, err := tt.decode(out.Value)
+			if err != nil {
+				t.Fatalf("decode %q: %v", out.Value, err)
+			}
+			if len(raw) != tt.wantLen {
+				t.Errorf("decoded length = %d, want %d", len(raw), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestGenerateULID(t *testing.T) {
+	_, out, err := GenerateULID(context.Background(), &mcp.CallToolRequest{}, ULIDInput{Count: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.ULIDs) != 100 {
+		t.Fatalf("len(ULIDs) = %d, want 100", len(out.ULIDs))
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range out.ULIDs {
+		if !ulidRegex.MatchString(id) {
+			t.Errorf("ULID %q has invalid format", id)
+		}
+		if seen[id] {
+			t.Errorf("duplicate ULID %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestNewULID_TimestampPrefix(t *testing.T) {
+	// 1469922850259 ms encodes to the 10-character prefix 01ARZ3NDEK.
+	ts := time.UnixMilli(1469922850259)
+	id, err := newULID(ts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := id[:10]; got != "01ARZ3NDEK" {
+		t.Errorf("timestamp prefix = %q, want %q", got, "01ARZ3NDEK")
+	}
+
+	later, err := newULID(ts.Add(time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if later[:10] <= id[:10] {
+		t.Errorf("ULID prefixes not increasing: %q then %q", id[:10], later[:10])
+	}
+}
@@ -1,10 +1,24 @@
 package tools
 
-import "github.com/modelcontextprotocol/go-sdk/mcp"
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+)
 
 // Registrar is a function that registers tools with an MCP server.
 type Registrar func(server *mcp.Server)
 
+// Bundle names the set of tool packages compiled into the binary. The main
+// package sets it from the bundle_* build tag in use.
+var Bundle = "custom"
+
 // Registry holds all tool registrars.
 var Registry []Registrar
 
@@ -13,9 +27,250 @@ func Register(r Registrar) {
 	Registry = append(Registry, r)
 }
 
-// RegisterAll registers all tools with the given MCP server.
-func RegisterAll(server *mcp.Server) {
-	for _, r := range Registry {
-		r(server)
+// configLoaders are the functions added with RegisterConfig.
+var configLoaders []func()
+
+// RegisterConfig adds load, which reads the settings of a tool package
+// without acting on them, for LoadConfigs. Packages whose registrar reads
+// settings add one alongside it.
+func RegisterConfig(load func()) {
+	configLoaders = append(configLoaders, load)
+}
+
+// LoadConfigs reads the settings of the registry, of the middleware chain
+// AddTool applies, and of every tool package added with RegisterConfig,
+// without registering tools. The config commands use it to cover tool
+// settings without opening the roots, pools, or clients tools build.
+func LoadConfigs() {
+	LoadRegistryConfig()
+	loadChainSettings()
+	LoadPoolConfig()
+	deprecatedTools()
+	for _, load := range configLoaders {
+		load()
+	}
+}
+
+// RegisterAll registers all tools with the given MCP server and installs
+// ErrorMiddleware and SessionMiddleware.
+func RegisterAll(server *mcp.Server) error {
+	return RegisterEach(server, Registry)
+}
+
+// RegisterEach is RegisterAll for registrars in place of Registry. It
+// applies the TOOL_NAMESPACE* settings and, when two tools end up with the
+// same name, fails or keeps the first as TOOL_DUPLICATES says.
+func RegisterEach(server *mcp.Server, registrars []Registrar) error {
+	return RegisterEachWith(server, registrars, nil)
+}
+
+// RegisterEachWith is RegisterEach handing deps to registrars added with
+// WithDeps; nil hands them DefaultDeps.
+func RegisterEachWith(server *mcp.Server, registrars []Registrar, deps *Deps) error {
+	cfg := LoadRegistryConfig()
+	if cfg.Duplicates != DuplicatesFail && cfg.Duplicates != DuplicatesSkip {
+		return fmt.Errorf("unknown TOOL_DUPLICATES %q: use %s or %s", cfg.Duplicates, DuplicatesFail, DuplicatesSkip)
+	}
+	server.AddReceivingMiddleware(ErrorMiddleware, SessionMiddleware)
+	reg := &registration{cfg: cfg, deps: deps, names: make(map[string]string)}
+	registrations.Lock()
+	registrations.m[server] = reg
+	registrations.Unlock()
+	defer func() {
+		registrations.Lock()
+		delete(registrations.m, server)
+		registrations.Unlock()
+	}()
+
+	for i, r := range registrars {
+		reg.run(server, fmt.Sprintf("registrar %d", i), r)
+	}
+	return errors.Join(reg.errs...)
+}
+
+// CheckedRegistrar is a Registrar that can fail, for example on invalid
+// configuration.
+type CheckedRegistrar func(server *mcp.Server) error
+
+// Checked returns a Registrar calling r. During RegisterEach an error from
+// r is handled like a panic in any registrar: it is logged and reported by
+// CheckHealth, and the tools r added are removed so the others still
+// serve. Outside RegisterEach it is only logged.
+func Checked(r CheckedRegistrar) Registrar {
+	return func(server *mcp.Server) {
+		err := r(server)
+		if err == nil {
+			return
+		}
+		if reg := registeringOn(server); reg != nil {
+			reg.failure = err
+			return
+		}
+		logger.Error("tool registrar failed", "error", err)
+	}
+}
+
+// registrarFailures holds the error of each registrar that failed, by
+// namespace or position.
+var registrarFailures sync.Map // string -> string
+
+// RegistrarFailures returns the error of each registrar that failed, by
+// its namespace, or "registrar N" for the Nth registrar without one.
+func RegistrarFailures() map[string]string {
+	failures := make(map[string]string)
+	registrarFailures.Range(func(name, err any) bool {
+		failures[name.(string)] = err.(string)
+		return true
+	})
+	return failures
+}
+
+// What RegisterEach does when a tool name is taken.
+const (
+	// DuplicatesFail fails registration.
+	DuplicatesFail = "fail"
+	// DuplicatesSkip logs a warning and keeps the tool registered first.
+	DuplicatesSkip = "skip"
+)
+
+// RegistryConfig selects which namespaces are registered, whether their
+// tools are named after them, and how duplicate names are handled.
+type RegistryConfig struct {
+	// Prefix names each tool namespace, Separator, name, e.g. fs.read_file.
+	Prefix bool
+	// Separator joins namespace and tool name. MCP tool names allow
+	// letters, digits, _, -, and .; clients may be stricter.
+	Separator string
+	// Include, if not empty, lists the only namespaces registered.
+	Include []string
+	// Exclude lists namespaces that are not registered.
+	Exclude []string
+	// Duplicates is DuplicatesFail or DuplicatesSkip.
+	Duplicates string
+}
+
+// LoadRegistryConfig reads the TOOL_NAMESPACE* and TOOL_DUPLICATES
+// settings.
+func LoadRegistryConfig() RegistryConfig {
+	return RegistryConfig{
+		Prefix:     config.GetEnvBool("TOOL_NAMESPACE_PREFIX", false),
+		Separator:  config.GetEnv("TOOL_NAMESPACE_SEPARATOR", "."),
+		Include:    config.GetEnvList("TOOL_NAMESPACES_INCLUDE"),
+		Exclude:    config.GetEnvList("TOOL_NAMESPACES_EXCLUDE"),
+		Duplicates: config.GetEnv("TOOL_DUPLICATES", DuplicatesFail),
+	}
+}
+
+// Enabled reports whether the tools of namespace ns are registered.
+func (c RegistryConfig) Enabled(ns string) bool {
+	if slices.Contains(c.Exclude, ns) {
+		return false
+	}
+	return len(c.Include) == 0 || slices.Contains(c.Include, ns)
+}
+
+// Namespace returns a Registrar that registers the tools of r under the
+// namespace ns, for use with Register:
+//
+//	tools.Register(tools.Namespace("fs", func(server *mcp.Server) { ... }))
+//
+// During RegisterEach, r is skipped if ns is not enabled, and its tools
+// are prefixed with ns if the config says so. Registrars without a
+// namespace are always registered.
+func Namespace(ns string, r Registrar) Registrar {
+	return func(server *mcp.Server) {
+		reg := registeringOn(server)
+		if reg == nil {
+			r(server)
+			return
+		}
+		if !reg.cfg.Enabled(ns) {
+			return
+		}
+		prev := reg.namespace
+		reg.namespace = ns
+		defer func() { reg.namespace = prev }()
+		reg.run(server, ns, r)
+	}
+}
+
+// registration is the state of a RegisterEach call.
+type registration struct {
+	cfg       RegistryConfig
+	deps      *Deps
+	namespace string            // of the registrar running
+	names     map[string]string // tool name -> namespace that added it
+	added     []string          // tool names in the order added
+	failure   error             // of the Checked registrar that just ran
+	errs      []error
+}
+
+// run calls r, named name. If r panics or fails, the failure is logged and
+// recorded, and the tools r added are removed again.
+func (reg *registration) run(server *mcp.Server, name string, r Registrar) {
+	start := len(reg.added)
+	defer func() {
+		err := reg.failure
+		reg.failure = nil
+		var stack string
+		if p := recover(); p != nil {
+			err, stack = fmt.Errorf("panic: %v", p), string(debug.Stack())
+		}
+		if err == nil {
+			registrarFailures.Delete(name)
+			return
+		}
+		removed := slices.Clone(reg.added[start:])
+		RemoveTools(server, removed...)
+		for _, n := range removed {
+			delete(reg.names, n)
+		}
+		reg.added = reg.added[:start]
+		registrarFailures.Store(name, err.Error())
+		args := []any{"registrar", name, "error", err, "tools", removed}
+		if stack != "" {
+			args = append(args, "stack", stack)
+		}
+		logger.Error("tool registrar failed; its tools are not served", args...)
+	}()
+	r(server)
+}
+
+// registrations holds the registrations in progress.
+var registrations = struct {
+	sync.Mutex
+	m map[*mcp.Server]*registration
+}{m: make(map[*mcp.Server]*registration)}
+
+// registeringOn returns the registration in progress on server, or nil.
+func registeringOn(server *mcp.Server) *registration {
+	registrations.Lock()
+	defer registrations.Unlock()
+	return registrations.m[server]
+}
+
+// add names tool for its namespace, listed under _meta.namespace, and
+// reports whether it may be added. A tool whose name is taken is recorded
+// as an error or, with DuplicatesSkip, logged.
+func (reg *registration) add(tool *mcp.Tool) bool {
+	if reg.namespace != "" {
+		if reg.cfg.Prefix {
+			tool.Name = reg.namespace + reg.cfg.Separator + tool.Name
+		}
+		if tool.Meta == nil {
+			tool.Meta = mcp.Meta{}
+		}
+		tool.Meta["namespace"] = reg.namespace
+	}
+	if ns, ok := reg.names[tool.Name]; ok {
+		if reg.cfg.Duplicates == DuplicatesSkip {
+			logger.Warn("duplicate tool skipped", "tool", tool.Name, "namespace", reg.namespace, "registered_by", ns)
+			return false
+		}
+		reg.errs = append(reg.errs, fmt.Errorf("tool %q registered twice (namespaces %q and %q); rename one or set TOOL_DUPLICATES=skip", tool.Name, ns, reg.namespace))
+		return false
 	}
+	reg.names[tool.Name] = reg.namespace
+	reg.added = append(reg.added, tool.Name)
+	return true
 }
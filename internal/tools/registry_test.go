@@ -1,6 +1,10 @@
 package tools
 
 import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -136,3 +140,180 @@ func TestRegisterAllPassesServer(t *testing.T) {
 		t.Error("registrar did not receive the expected server instance")
 	}
 }
+
+func TestRegisterEach_Namespaces(t *testing.T) {
+	noop := func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		return nil, nil, nil
+	}
+	tool := func(name string) Registrar {
+		return func(server *mcp.Server) {
+			AddTool(server, &mcp.Tool{Name: name}, noop)
+		}
+	}
+	registrars := []Registrar{
+		Namespace("fs", tool("read_file")),
+		Namespace("net", tool("dns_lookup")),
+		tool("plain"),
+	}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		registrars []Registrar
+		want       []string
+		wantErr    string
+	}{
+		{
+			name:       "unprefixed",
+			registrars: registrars,
+			want:       []string{"dns_lookup", "plain", "read_file"},
+		},
+		{
+			name:       "prefixed",
+			env:        map[string]string{"TOOL_NAMESPACE_PREFIX": "true"},
+			registrars: registrars,
+			want:       []string{"fs.read_file", "net.dns_lookup", "plain"},
+		},
+		{
+			name:       "separator",
+			env:        map[string]string{"TOOL_NAMESPACE_PREFIX": "true", "TOOL_NAMESPACE_SEPARATOR": "_"},
+			registrars: registrars,
+			want:       []string{"fs_read_file", "net_dns_lookup", "plain"},
+		},
+		{
+			name:       "include",
+			env:        map[string]string{"TOOL_NAMESPACES_INCLUDE": "fs"},
+			registrars: registrars,
+			want:       []string{"plain", "read_file"},
+		},
+		{
+			name:       "exclude",
+			env:        map[string]string{"TOOL_NAMESPACES_EXCLUDE": "fs,net"},
+			registrars: registrars,
+			want:       []string{"plain"},
+		},
+		{
+			name:       "collision",
+			registrars: []Registrar{Namespace("fs", tool("read_file")), Namespace("git", tool("read_file"))},
+			want:       []string{"read_file"},
+			wantErr:    `tool "read_file" registered twice (namespaces "fs" and "git")`,
+		},
+		{
+			name:       "duplicate unnamespaced",
+			registrars: []Registrar{tool("plain"), tool("plain")},
+			want:       []string{"plain"},
+			wantErr:    `tool "plain" registered twice`,
+		},
+		{
+			name:       "skip duplicates",
+			env:        map[string]string{"TOOL_DUPLICATES": "skip"},
+			registrars: []Registrar{Namespace("fs", tool("read_file")), Namespace("git", tool("read_file"))},
+			want:       []string{"read_file"},
+		},
+		{
+			name:       "unknown duplicates policy",
+			env:        map[string]string{"TOOL_DUPLICATES": "ignore"},
+			registrars: registrars,
+			wantErr:    `unknown TOOL_DUPLICATES "ignore"`,
+		},
+		{
+			name:       "prefix avoids collision",
+			env:        map[string]string{"TOOL_NAMESPACE_PREFIX": "true"},
+			registrars: []Registrar{Namespace("fs", tool("read_file")), Namespace("git", tool("read_file"))},
+			want:       []string{"fs.read_file", "git.read_file"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+			err := RegisterEach(server, tt.registrars)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("RegisterEach() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("RegisterEach() error = %v, want %q", err, tt.wantErr)
+			}
+
+			m, err := BuildManifest(context.Background(), &mcp.Implementation{Name: "test-server", Version: "1.0.0"}, server)
+			if err != nil {
+				t.Fatalf("BuildManifest() error = %v", err)
+			}
+			var got []string
+			for _, tool := range m.Tools {
+				got = append(got, tool.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("tools = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterEach_Failures(t *testing.T) {
+	noop := func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		return nil, nil, nil
+	}
+	tests := []struct {
+		name        string
+		registrar   Registrar
+		failureName string
+		wantFailure string
+	}{
+		{
+			name: "panic",
+			registrar: Namespace("test_panic", func(server *mcp.Server) {
+				AddTool(server, &mcp.Tool{Name: "half_done"}, noop)
+				panic("no config")
+			}),
+			failureName: "test_panic",
+			wantFailure: "panic: no config",
+		},
+		{
+			name: "error",
+			registrar: Namespace("test_error", Checked(func(server *mcp.Server) error {
+				AddTool(server, &mcp.Tool{Name: "half_done"}, noop)
+				return errors.New("bad DSN")
+			})),
+			failureName: "test_error",
+			wantFailure: "bad DSN",
+		},
+		{
+			name: "unnamespaced",
+			registrar: Checked(func(*mcp.Server) error {
+				return errors.New("bad DSN")
+			}),
+			failureName: "registrar 1",
+			wantFailure: "bad DSN",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { registrarFailures.Delete(tt.failureName) })
+			server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+			ok := func(server *mcp.Server) { AddTool(server, &mcp.Tool{Name: "fine"}, noop) }
+			if err := RegisterEach(server, []Registrar{ok, tt.registrar}); err != nil {
+				t.Fatalf("RegisterEach() error = %v, want the failure tolerated", err)
+			}
+
+			m, err := BuildManifest(context.Background(), &mcp.Implementation{Name: "test-server", Version: "1.0.0"}, server)
+			if err != nil {
+				t.Fatalf("BuildManifest() error = %v", err)
+			}
+			if len(m.Tools) != 1 || m.Tools[0].Name != "fine" {
+				t.Errorf("tools = %v, want only fine", m.Tools)
+			}
+			if got := RegistrarFailures()[tt.failureName]; got != tt.wantFailure {
+				t.Errorf("RegistrarFailures()[%q] = %q, want %q", tt.failureName, got, tt.wantFailure)
+			}
+			if err := CheckHealth(context.Background()); err == nil || !strings.Contains(err.Error(), tt.wantFailure) {
+				t.Errorf("CheckHealth() = %v, want %q", err, tt.wantFailure)
+			}
+			if _, ok := Health()["registrars"]; !ok {
+				t.Errorf("Health() = %v, want registrars", Health())
+			}
+		})
+	}
+}
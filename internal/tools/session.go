@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MaxSessionValues is the most keys a session's SessionData holds.
+const MaxSessionValues = 1024
+
+// SessionData is a key/value store that lives as long as an MCP session,
+// for tools that keep state between calls, such as cursors or tokens for
+// upstream services. Values stay in process memory and are never sent to
+// the client. It is safe for concurrent use.
+type SessionData struct {
+	mu     sync.Mutex
+	values map[string]any
+}
+
+// Get returns the value of key.
+func (d *SessionData) Get(key string) (any, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	v, ok := d.values[key]
+	return v, ok
+}
+
+// Set stores value under key. It fails once the session holds
+// MaxSessionValues other keys.
+func (d *SessionData) Set(key string, value any) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.values[key]; !ok && len(d.values) >= MaxSessionValues {
+		return NewError(CodeResourceExhausted, "session holds %d values; delete some first", MaxSessionValues)
+	}
+	d.values[key] = value
+	return nil
+}
+
+// Delete removes key, reporting whether it was present.
+func (d *SessionData) Delete(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.values[key]
+	delete(d.values, key)
+	return ok
+}
+
+// Keys returns the keys in use, sorted.
+func (d *SessionData) Keys() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	keys := make([]string, 0, len(d.values))
+	for k := range d.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SessionValue returns the value of key in the session of ctx, if it is
+// set and has type T.
+func SessionValue[T any](ctx context.Context, key string) (T, bool) {
+	v, _ := SessionStore(ctx).Get(key)
+	t, ok := v.(T)
+	return t, ok
+}
+
+// sessionKey is the context key for the *mcp.ServerSession of a call.
+type sessionKey struct{}
+
+// sessionData holds the SessionData of live sessions. Sessions are keyed by
+// identity, not ID: stdio and in-memory sessions have no ID.
+var sessionData = struct {
+	sync.Mutex
+	m map[*mcp.ServerSession]*SessionData
+}{m: make(map[*mcp.ServerSession]*SessionData)}
+
+// SessionStore returns the SessionData of the MCP session handling ctx,
+// created on first use and dropped when the session ends. Stateless HTTP
+// sessions (SESSION_STORE) end with each request, so nothing carries over
+// between their calls. Outside SessionMiddleware it returns a new, empty
+// SessionData.
+func SessionStore(ctx context.Context) *SessionData {
+	ss, _ := ctx.Value(sessionKey{}).(*mcp.ServerSession)
+	if ss == nil {
+		return &SessionData{values: make(map[string]any)}
+	}
+
+	sessionData.Lock()
+	defer sessionData.Unlock()
+	if d, ok := sessionData.m[ss]; ok {
+		return d
+	}
+	d := &SessionData{values: make(map[string]any)}
+	sessionData.m[ss] = d
+	go func() {
+		_ = ss.Wait()
+		sessionData.Lock()
+		delete(sessionData.m, ss)
+		sessionData.Unlock()
+	}()
+	return d
+}
+
+// SessionMiddleware makes the session of each tools/call available to
+// SessionStore. RegisterAll installs it.
+func SessionMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if ss, ok := req.GetSession().(*mcp.ServerSession); ok && method == "tools/call" {
+			ctx = context.WithValue(ctx, sessionKey{}, ss)
+		}
+		return next(ctx, method, req)
+	}
+}
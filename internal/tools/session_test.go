@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSessionStore(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	// counter returns how often it was called in the session
+	AddTool(server, &mcp.Tool{Name: "counter"}, func(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, struct{}, error) {
+		n, _ := SessionValue[int](ctx, "n")
+		if err := SessionStore(ctx).Set("n", n+1); err != nil {
+			return nil, struct{}{}, err
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprint(n + 1)}}}, struct{}{}, nil
+	})
+	server.AddReceivingMiddleware(SessionMiddleware)
+
+	ctx := context.Background()
+	connect := func() (*mcp.ServerSession, *mcp.ClientSession) {
+		t.Helper()
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		ss, err := server.Connect(ctx, serverTransport, nil)
+		if err != nil {
+			t.Fatalf("server connect: %v", err)
+		}
+		cs, err := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil).Connect(ctx, clientTransport, nil)
+		if err != nil {
+			t.Fatalf("client connect: %v", err)
+		}
+		return ss, cs
+	}
+	call := func(cs *mcp.ClientSession) string {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "counter", Arguments: map[string]any{}})
+		if err != nil || res.IsError {
+			t.Fatalf("CallTool: %v %+v", err, res)
+		}
+		return res.Content[0].(*mcp.TextContent).Text
+	}
+
+	ssA, a := connect()
+	_, b := connect()
+	defer b.Close()
+	for _, want := range []string{"1", "2", "3"} {
+		if got := call(a); got != want {
+			t.Errorf("session a call = %s, want %s", got, want)
+		}
+	}
+	if got := call(b); got != "1" {
+		t.Errorf("session b call = %s, want 1: sessions must not share state", got)
+	}
+
+	_ = a.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sessionData.Lock()
+		_, live := sessionData.m[ssA]
+		sessionData.Unlock()
+		if !live {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("session data kept after the session closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSessionData(t *testing.T) {
+	d := SessionStore(context.Background())
+	if err := d.Set("cursor", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := d.Get("cursor"); !ok || v != "abc" {
+		t.Errorf("Get(cursor) = %v, %v", v, ok)
+	}
+	for i := 1; i < MaxSessionValues; i++ {
+		if err := d.Set(fmt.Sprint(i), i); err != nil {
+			t.Fatalf("Set(%d) error = %v", i, err)
+		}
+	}
+	if err := d.Set("one-too-many", 0); AsError(err).Code != CodeResourceExhausted {
+		t.Errorf("Set past the limit error = %v, want resource_exhausted", err)
+	}
+	if err := d.Set("cursor", "def"); err != nil {
+		t.Errorf("overwriting at the limit error = %v", err)
+	}
+	if !d.Delete("cursor") || d.Delete("cursor") {
+		t.Error("Delete(cursor) should report true once")
+	}
+	if keys := d.Keys(); len(keys) != MaxSessionValues-1 || keys[0] != "1" {
+		t.Errorf("Keys() = %d keys starting %q", len(keys), keys[0])
+	}
+}
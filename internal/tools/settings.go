@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Settings is the typed section of the tool config file (TOOL_CONFIG_FILE)
+// under one key: a tool name, or a namespace for settings its tools share.
+// Tool packages declare one per section,
+//
+//	var settings = tools.NewSettings[Settings]("http_fetch")
+//
+// and call Get on each use, so a reloaded file applies to the next call:
+//
+//	http_fetch:
+//	  allowed_hosts: [api.example.com]
+//
+// Sections override the tool's environment settings; an absent section
+// leaves them in effect.
+type Settings[T any] struct {
+	key string
+	cur atomic.Pointer[T]
+}
+
+// section is the untyped side of a Settings, for LoadSettings.
+type section interface {
+	// decode checks node and returns a func applying it; a nil node
+	// removes the section.
+	decode(node *yaml.Node) (func(), error)
+}
+
+// settingsFile holds the sections by key and the last file loaded.
+var settingsFile = struct {
+	sync.Mutex
+	sections map[string]section
+	nodes    map[string]yaml.Node
+	version  string // fileVersion of the file loaded
+}{sections: make(map[string]section)}
+
+// NewSettings returns the Settings of key, applying the section already
+// loaded, if any.
+func NewSettings[T any](key string) *Settings[T] {
+	s := &Settings[T]{key: key}
+	settingsFile.Lock()
+	defer settingsFile.Unlock()
+	settingsFile.sections[key] = s
+	if node, ok := settingsFile.nodes[key]; ok {
+		apply, err := s.decode(&node)
+		if err != nil {
+			logger.Error("invalid tool config section", "section", key, "error", err)
+		} else {
+			apply()
+		}
+	}
+	return s
+}
+
+// Get returns the section, or false when the file has none.
+func (s *Settings[T]) Get() (T, bool) {
+	if v := s.cur.Load(); v != nil {
+		return *v, true
+	}
+	var zero T
+	return zero, false
+}
+
+func (s *Settings[T]) decode(node *yaml.Node) (func(), error) {
+	if node == nil {
+		return func() { s.cur.Store(nil) }, nil
+	}
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	v := new(T)
+	if err := dec.Decode(v); err != nil {
+		return nil, err
+	}
+	return func() { s.cur.Store(v) }, nil
+}
+
+// LoadSettings reads the tool config file at path, a YAML map of sections,
+// and applies every section at once. If the file or any section is
+// invalid, it returns the error and the sections loaded before stay in
+// effect.
+func LoadSettings(path string) error {
+	version := fileVersion(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var nodes map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	settingsFile.Lock()
+	defer settingsFile.Unlock()
+	var applies []func()
+	var errs []error
+	keys := make([]string, 0, len(settingsFile.sections))
+	for key := range settingsFile.sections {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var node *yaml.Node
+		if n, ok := nodes[key]; ok {
+			node = &n
+		}
+		apply, err := settingsFile.sections[key].decode(node)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: section %s: %w", path, key, err))
+			continue
+		}
+		applies = append(applies, apply)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	for key := range nodes {
+		if _, ok := settingsFile.sections[key]; !ok {
+			logger.Warn("tool config section matches no tool", "section", key)
+		}
+	}
+	for _, apply := range applies {
+		apply()
+	}
+	settingsFile.nodes, settingsFile.version = nodes, version
+	return nil
+}
+
+// WatchSettings polls the tool config file at path every interval and
+// reloads it when its modification time or size changes from the file
+// LoadSettings last loaded, calling onReload with the result. It returns
+// when ctx is done.
+func WatchSettings(ctx context.Context, path string, interval time.Duration, onReload func(err error)) {
+	if interval <= 0 {
+		return
+	}
+	settingsFile.Lock()
+	loaded := settingsFile.version
+	settingsFile.Unlock()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			v := fileVersion(path)
+			if v == loaded || v == "" {
+				continue
+			}
+			loaded = v
+			err := LoadSettings(path)
+			if onReload != nil {
+				onReload(err)
+			}
+		}
+	}
+}
+
+// fileVersion identifies the contents of path by modification time and
+// size, or is "" if path cannot be read.
+func fileVersion(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testSettings struct {
+	Hosts []string `yaml:"hosts"`
+	Limit int      `yaml:"limit"`
+}
+
+func TestLoadSettings(t *testing.T) {
+	s := NewSettings[testSettings]("test_settings")
+	path := filepath.Join(t.TempDir(), "tools.yaml")
+	t.Cleanup(func() {
+		settingsFile.Lock()
+		delete(settingsFile.sections, "test_settings")
+		settingsFile.nodes = nil
+		settingsFile.Unlock()
+	})
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+		want    *testSettings
+	}{
+		{
+			name: "section",
+			file: "test_settings:\n  hosts: [a.example.com]\n  limit: 3\n",
+			want: &testSettings{Hosts: []string{"a.example.com"}, Limit: 3},
+		},
+		{
+			name:    "unknown field keeps previous",
+			file:    "test_settings:\n  hostz: [b.example.com]\n",
+			wantErr: true,
+			want:    &testSettings{Hosts: []string{"a.example.com"}, Limit: 3},
+		},
+		{
+			name:    "invalid yaml keeps previous",
+			file:    "test_settings: [",
+			wantErr: true,
+			want:    &testSettings{Hosts: []string{"a.example.com"}, Limit: 3},
+		},
+		{
+			name: "section removed",
+			file: "other_tool:\n  x: 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			err := LoadSettings(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got, ok := s.Get()
+			if ok != (tt.want != nil) {
+				t.Fatalf("Get() ok = %v, want %v", ok, tt.want != nil)
+			}
+			if ok && (!slices.Equal(got.Hosts, tt.want.Hosts) || got.Limit != tt.want.Limit) {
+				t.Errorf("Get() = %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSettings_AfterLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.yaml")
+	if err := os.WriteFile(path, []byte("test_late:\n  limit: 7\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadSettings(path); err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	s := NewSettings[testSettings]("test_late")
+	t.Cleanup(func() {
+		settingsFile.Lock()
+		delete(settingsFile.sections, "test_late")
+		settingsFile.nodes = nil
+		settingsFile.Unlock()
+	})
+	if got, ok := s.Get(); !ok || got.Limit != 7 {
+		t.Errorf("Get() = %+v, %v, want the loaded section", got, ok)
+	}
+}
+
+func TestWatchSettings(t *testing.T) {
+	s := NewSettings[testSettings]("test_watch")
+	path := filepath.Join(t.TempDir(), "tools.yaml")
+	t.Cleanup(func() {
+		settingsFile.Lock()
+		delete(settingsFile.sections, "test_watch")
+		settingsFile.nodes = nil
+		settingsFile.Unlock()
+	})
+	if err := os.WriteFile(path, []byte("test_watch:\n  limit: 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadSettings(path); err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan error, 1)
+	go WatchSettings(ctx, path, 10*time.Millisecond, func(err error) { reloaded <- err })
+
+	// A different size is a change even within the mtime granularity
+	if err := os.WriteFile(path, []byte("test_watch:\n  limit: 20\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatalf("reload error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("file change not reloaded")
+	}
+	if got, _ := s.Get(); got.Limit != 20 {
+		t.Errorf("Get().Limit = %d, want 20", got.Limit)
+	}
+}
@@ -0,0 +1,93 @@
+package sqlquery
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Database is an operator-configured connection target.
+type Database struct {
+	Name string
+	// Driver is the database/sql driver name: pgx, mysql, or sqlite.
+	Driver string
+	// DSN is the driver-specific connection string.
+	DSN string
+}
+
+// ParseDatabase parses a "name=url" entry. Supported URL schemes are
+// postgres://, postgresql://, mysql://, and sqlite:// (or sqlite:path).
+// When readOnly is set, SQLite databases are opened in read-only mode.
+func ParseDatabase(entry string, readOnly bool) (Database, error) {
+	name, raw, ok := strings.Cut(entry, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return Database{}, fmt.Errorf("database entry must be name=url")
+	}
+	if strings.ContainsFunc(name, func(r rune) bool {
+		return !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		return Database{}, fmt.Errorf("invalid database name %q: use letters, digits, '_' or '-'", name)
+	}
+	raw = strings.TrimSpace(raw)
+
+	scheme, _, _ := strings.Cut(raw, ":")
+	switch strings.ToLower(scheme) {
+	case "postgres", "postgresql":
+		return Database{Name: name, Driver: "pgx", DSN: raw}, nil
+	case "mysql":
+		dsn, err := mysqlDSN(raw)
+		if err != nil {
+			return Database{}, fmt.Errorf("database %q: %w", name, err)
+		}
+		return Database{Name: name, Driver: "mysql", DSN: dsn}, nil
+	case "sqlite":
+		return Database{Name: name, Driver: "sqlite", DSN: sqliteDSN(raw, readOnly)}, nil
+	default:
+		return Database{}, fmt.Errorf("database %q: unsupported scheme %q: use postgres, mysql, or sqlite", name, scheme)
+	}
+}
+
+// mysqlDSN converts a mysql:// URL into the go-sql-driver DSN format.
+func mysqlDSN(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = u.Host
+	cfg.DBName = strings.TrimPrefix(u.Path, "/")
+	if u.User != nil {
+		cfg.User = u.User.Username()
+		cfg.Passwd, _ = u.User.Password()
+	}
+	params := u.Query()
+	if len(params) > 0 {
+		cfg.Params = make(map[string]string, len(params))
+		for k := range params {
+			cfg.Params[k] = params.Get(k)
+		}
+	}
+	cfg.ParseTime = true
+	return cfg.FormatDSN(), nil
+}
+
+// sqliteDSN converts a sqlite URL into a file: URI for the sqlite driver.
+func sqliteDSN(raw string, readOnly bool) string {
+	path := strings.TrimPrefix(raw, "sqlite:")
+	path = strings.TrimPrefix(path, "//")
+	path, query, _ := strings.Cut(path, "?")
+
+	params, _ := url.ParseQuery(query)
+	if readOnly {
+		params.Set("mode", "ro")
+	}
+	dsn := "file:" + path
+	if len(params) > 0 {
+		dsn += "?" + params.Encode()
+	}
+	return dsn
+}
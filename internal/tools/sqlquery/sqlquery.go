@@ -0,0 +1,425 @@
+package sqlquery
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	_ "modernc.org/sqlite"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+	"github.com/lkendrickd/mcp-server/internal/tools/jobs"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// MaxQueryBytes caps the length of SQL text accepted by the tool.
+const MaxQueryBytes = 64 << 10
+
+// readOnlyKeywords are the leading keywords permitted in read-only mode.
+var readOnlyKeywords = []string{"SELECT", "WITH", "SHOW", "EXPLAIN", "DESCRIBE", "DESC", "VALUES", "TABLE"}
+
+// Config controls which databases the tool may query and how much it may return.
+type Config struct {
+	// Databases lists "name=url" entries; an empty list disables the tool.
+	Databases []string
+	// ReadOnly rejects non-query statements and runs queries in read-only
+	// transactions. It is on by default.
+	ReadOnly bool
+	MaxRows  int
+	MaxBytes int
+	Timeout  time.Duration
+}
+
+// LoadConfig reads the SQL configuration from environment variables.
+func LoadConfig() Config {
+	return Config{
+		Databases: config.GetEnvList("SQL_DATABASES"),
+		ReadOnly:  config.GetEnvBool("SQL_READ_ONLY", true),
+		MaxRows:   config.GetEnvInt("SQL_MAX_ROWS", 1000),
+		MaxBytes:  config.GetEnvInt("SQL_MAX_BYTES", 1<<20),
+		Timeout:   config.GetEnvDuration("SQL_TIMEOUT", 30*time.Second),
+	}
+}
+
+// Input is the input for the sql_query tool.
+type Input struct {
+	Database string `json:"database,omitempty" jsonschema:"the configured database name; optional when only one is configured"`
+	Query    string `json:"query" jsonschema:"the SQL statement; use placeholders ($1 for Postgres, ? for MySQL and SQLite) for values"`
+	Params   []any  `json:"params,omitempty" jsonschema:"values bound to the query placeholders in order"`
+	MaxRows  int    `json:"max_rows,omitempty" jsonschema:"maximum rows to return; capped by the server limit"`
+}
+
+// Output is the output of the sql_query tool.
+type Output struct {
+	Columns   []string `json:"columns" jsonschema:"the result column names"`
+	Rows      [][]any  `json:"rows" jsonschema:"result rows as arrays of values in column order"`
+	RowCount  int      `json:"row_count" jsonschema:"number of rows returned"`
+	Truncated bool     `json:"truncated" jsonschema:"whether rows were omitted due to the row or byte limit"`
+}
+
+// Querier runs queries against a set of configured databases.
+type Querier struct {
+	cfg   Config
+	dbs   map[string]*sql.DB
+	kinds map[string]string
+}
+
+// NewQuerier opens a connection pool for each database. Pools connect
+// lazily, so unreachable databases surface as errors on first query.
+func NewQuerier(cfg Config, databases []Database) (*Querier, error) {
+	q := &Querier{cfg: cfg, dbs: make(map[string]*sql.DB), kinds: make(map[string]string)}
+	for _, d := range databases {
+		if _, dup := q.dbs[d.Name]; dup {
+			_ = q.Close()
+			return nil, fmt.Errorf("duplicate database name %q", d.Name)
+		}
+		db, err := sql.Open(d.Driver, d.DSN)
+		if err != nil {
+			_ = q.Close()
+			return nil, fmt.Errorf("database %q: %w", d.Name, err)
+		}
+		db.SetMaxOpenConns(4)
+		db.SetConnMaxIdleTime(5 * time.Minute)
+		q.dbs[d.Name] = db
+		q.kinds[d.Name] = d.Driver
+	}
+	return q, nil
+}
+
+// Close closes all connection pools.
+func (q *Querier) Close() error {
+	for _, db := range q.dbs {
+		_ = db.Close()
+	}
+	return nil
+}
+
+// Names returns the configured database names in sorted order.
+func (q *Querier) Names() []string {
+	names := make([]string, 0, len(q.dbs))
+	for name := range q.dbs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Query executes a statement and returns its results as structured rows.
+func (q *Querier) Query(ctx context.Context, req *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	st, err := q.prepare(input)
+	if err != nil {
+		return nil, Output{}, err
+	}
+	out, err := q.execute(ctx, st)
+	if err != nil {
+		return nil, Output{}, err
+	}
+	logger.Info("tool called", "tool", "sql_query", "database", st.name, "rows", out.RowCount, "truncated", out.Truncated)
+	return nil, out, nil
+}
+
+// QueryAsync checks a statement like Query, then runs it as a background
+// job and returns the job at once; job_result returns the rows.
+func (q *Querier) QueryAsync(ctx context.Context, req *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, jobs.Status, error) {
+	st, err := q.prepare(input)
+	if err != nil {
+		return nil, jobs.Status{}, err
+	}
+	status, err := jobs.Start(req, "sql_query_async", func(ctx context.Context) (*mcp.CallToolResult, error) {
+		out, err := q.execute(ctx, st)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(out)
+		if err != nil {
+			return nil, tools.WrapError(tools.CodeInternal, err, "encoding the rows")
+		}
+		logger.Info("job finished", "tool", "sql_query_async", "database", st.name, "rows", out.RowCount, "truncated", out.Truncated)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(data)}}, StructuredContent: out}, nil
+	})
+	if err != nil {
+		return nil, jobs.Status{}, err
+	}
+	return nil, status, nil
+}
+
+// statement is a checked query, ready to run.
+type statement struct {
+	name    string
+	db      *sql.DB
+	query   string
+	params  []any
+	maxRows int
+}
+
+// prepare selects the database for input and checks its query.
+func (q *Querier) prepare(input Input) (statement, error) {
+	name, db, err := q.database(input.Database)
+	if err != nil {
+		return statement{}, err
+	}
+	query := strings.TrimSpace(input.Query)
+	if query == "" {
+		return statement{}, tools.NewError(tools.CodeInvalidArgument, "query is required")
+	}
+	if len(query) > MaxQueryBytes {
+		return statement{}, tools.NewError(tools.CodeResourceExhausted, "query exceeds %d bytes", MaxQueryBytes).
+			WithDetail("max_bytes", MaxQueryBytes)
+	}
+	if q.cfg.ReadOnly {
+		if err := checkReadOnly(query); err != nil {
+			return statement{}, err
+		}
+	}
+
+	maxRows := q.cfg.MaxRows
+	if input.MaxRows > 0 && input.MaxRows < maxRows {
+		maxRows = input.MaxRows
+	}
+	return statement{name: name, db: db, query: query, params: input.Params, maxRows: maxRows}, nil
+}
+
+// execute runs st in a transaction, read-only unless writes are allowed.
+func (q *Querier) execute(ctx context.Context, st statement) (Output, error) {
+	ctx, cancel := context.WithTimeout(ctx, q.cfg.Timeout)
+	defer cancel()
+
+	// SQLite read-only mode is enforced by opening the file with mode=ro;
+	// the driver does not support read-only transactions.
+	tx, err := st.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: q.cfg.ReadOnly && q.kinds[st.name] != "sqlite"})
+	if err != nil {
+		return Output{}, tools.WrapError(tools.CodeUnavailable, err, "database "+st.name)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	out, err := q.run(ctx, tx, st.query, st.params, st.maxRows)
+	if err != nil {
+		return Output{}, queryError(st.name, err)
+	}
+	if !q.cfg.ReadOnly {
+		if err := tx.Commit(); err != nil {
+			return Output{}, tools.WrapError(tools.CodeUnavailable, err, "database "+st.name)
+		}
+	}
+	return out, nil
+}
+
+// run executes the query within tx, collecting rows up to the row and byte
+// limits.
+func (q *Querier) run(ctx context.Context, tx *sql.Tx, query string, params []any, maxRows int) (Output, error) {
+	rows, err := tx.QueryContext(ctx, query, params...)
+	if err != nil {
+		return Output{}, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return Output{}, err
+	}
+	out := Output{Columns: columns, Rows: [][]any{}}
+
+	size := 0
+	for rows.Next() {
+		if out.RowCount >= maxRows {
+			out.Truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return Output{}, err
+		}
+		for i, v := range values {
+			values[i] = normalize(v)
+		}
+
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			return Output{}, err
+		}
+		if size+len(encoded) > q.cfg.MaxBytes {
+			out.Truncated = true
+			break
+		}
+		size += len(encoded)
+		out.Rows = append(out.Rows, values)
+		out.RowCount++
+	}
+	if err := rows.Err(); err != nil {
+		return Output{}, err
+	}
+	return out, nil
+}
+
+// queryError classifies a failed query: timeouts and lost connections are
+// reported as such, and anything else as the database rejecting the query.
+func queryError(name string, err error) *tools.Error {
+	code := tools.CodeInvalidArgument
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		code = tools.CodeDeadlineExceeded
+	case errors.Is(err, driver.ErrBadConn), errors.As(err, &netErr):
+		code = tools.CodeUnavailable
+	}
+	return tools.WrapError(code, err, "database "+name)
+}
+
+// database selects the named database, defaulting to the only one configured.
+func (q *Querier) database(name string) (string, *sql.DB, error) {
+	if name == "" {
+		if len(q.dbs) == 1 {
+			for n, db := range q.dbs {
+				return n, db, nil
+			}
+		}
+		return "", nil, tools.NewError(tools.CodeInvalidArgument, "database is required: choose one of %s", strings.Join(q.Names(), ", "))
+	}
+	db, ok := q.dbs[name]
+	if !ok {
+		return "", nil, tools.NewError(tools.CodeNotFound, "unknown database %q: choose one of %s", name, strings.Join(q.Names(), ", "))
+	}
+	return name, db, nil
+}
+
+// checkReadOnly rejects statements that are not queries and inputs that
+// contain more than one statement. It is a first line of defence; the
+// read-only transaction is what the database itself enforces.
+func checkReadOnly(query string) error {
+	stripped, err := stripSQL(query)
+	if err != nil {
+		return err
+	}
+	stripped = strings.TrimRight(strings.TrimSpace(stripped), ";")
+	if strings.Contains(stripped, ";") {
+		return tools.NewError(tools.CodePermissionDenied, "multiple statements are not allowed in read-only mode")
+	}
+
+	fields := strings.Fields(stripped)
+	if len(fields) == 0 {
+		return tools.NewError(tools.CodeInvalidArgument, "query is empty")
+	}
+	keyword := strings.ToUpper(strings.TrimLeft(fields[0], "("))
+	if !slices.Contains(readOnlyKeywords, keyword) {
+		return tools.NewError(tools.CodePermissionDenied, "%s statements are not allowed in read-only mode", keyword)
+	}
+	return nil
+}
+
+// stripSQL blanks out comments and quoted literals so that keywords and
+// semicolons inside them are not mistaken for statement structure.
+func stripSQL(query string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			b.WriteByte(' ')
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return "", tools.NewError(tools.CodeInvalidArgument, "unterminated comment")
+			}
+			i += end + 3
+			b.WriteByte(' ')
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for ; j < len(query); j++ {
+				if query[j] == c {
+					// A doubled quote is an escaped quote within the literal.
+					if j+1 < len(query) && query[j+1] == c {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(query) {
+				return "", tools.NewError(tools.CodeInvalidArgument, "unterminated quoted string")
+			}
+			i = j
+			b.WriteString(" x ")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// normalize converts driver values into JSON-friendly forms.
+func normalize(v any) any {
+	switch t := v.(type) {
+	case []byte:
+		if utf8.Valid(t) {
+			return string(t)
+		}
+		return base64.StdEncoding.EncodeToString(t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+func init() {
+	tools.RegisterConfig(func() { LoadConfig() })
+	tools.Register(tools.Namespace("sql", tools.Checked(func(server *mcp.Server) error {
+		cfg := LoadConfig()
+		if len(cfg.Databases) == 0 {
+			return nil
+		}
+
+		var databases []Database
+		for _, entry := range cfg.Databases {
+			d, err := ParseDatabase(entry, cfg.ReadOnly)
+			if err != nil {
+				return fmt.Errorf("sql_query disabled: %w", err)
+			}
+			databases = append(databases, d)
+		}
+		q, err := NewQuerier(cfg, databases)
+		if err != nil {
+			return fmt.Errorf("sql_query disabled: %w", err)
+		}
+
+		// Without SQL_READ_ONLY queries may update or drop data
+		annotations := tools.Destructive(false, false)
+		if cfg.ReadOnly {
+			annotations = tools.ReadOnly(false)
+		}
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "sql_query",
+			Description: "Run a parameterized SQL query against a configured database (" + strings.Join(q.Names(), ", ") + ") and return rows as JSON",
+			Annotations: annotations,
+		}, q.Query)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "sql_query_async",
+			Description: "Run a long SQL query like sql_query as a background job; follow it with job_status and fetch the rows with job_result",
+			Annotations: annotations,
+		}, q.QueryAsync)
+		return nil
+	})))
+}
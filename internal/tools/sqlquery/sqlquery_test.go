@@ -0,0 +1,326 @@
+package sqlquery
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func newTestQuerier(t *testing.T, readOnly bool) *Querier {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	setup, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, data BLOB)",
+		"INSERT INTO users (name, data) VALUES ('alice', x'68656c6c6f'), ('bob', x'fffe'), ('carol', NULL)",
+	} {
+		if _, err := setup.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = setup.Close()
+
+	db, err := ParseDatabase("test=sqlite://"+path, readOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := NewQuerier(Config{ReadOnly: readOnly, MaxRows: 2, MaxBytes: 1 << 10, Timeout: 5 * time.Second}, []Database{db})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = q.Close() })
+	return q
+}
+
+func TestQuery(t *testing.T) {
+	q := newTestQuerier(t, true)
+
+	tests := []struct {
+		name          string
+		input         Input
+		wantRows      [][]any
+		wantTruncated bool
+		wantErr       string
+		wantCode      tools.Code
+	}{
+		{
+			name:     "parameterized",
+			input:    Input{Query: "SELECT id, name FROM users WHERE name = ?", Params: []any{"bob"}},
+			wantRows: [][]any{{int64(2), "bob"}},
+		},
+		{
+			name:     "blob values",
+			input:    Input{Database: "test", Query: "SELECT data FROM users ORDER BY id"},
+			wantRows: [][]any{{"hello"}, {"//4="}},
+			// Limited to MaxRows.
+			wantTruncated: true,
+		},
+		{
+			name:     "max rows below limit",
+			input:    Input{Query: "SELECT name FROM users ORDER BY id", MaxRows: 1},
+			wantRows: [][]any{{"alice"}},
+			// One row remains.
+			wantTruncated: true,
+		},
+		{
+			name:     "semicolon in literal",
+			input:    Input{Query: "SELECT ';' AS s -- DELETE FROM users;\n"},
+			wantRows: [][]any{{";"}},
+		},
+		{
+			name:     "write rejected",
+			input:    Input{Query: "DELETE FROM users"},
+			wantErr:  "DELETE statements are not allowed",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name:     "stacked statements rejected",
+			input:    Input{Query: "SELECT 1; DROP TABLE users"},
+			wantErr:  "multiple statements",
+			wantCode: tools.CodePermissionDenied,
+		},
+		{
+			name:     "unknown database",
+			input:    Input{Database: "prod", Query: "SELECT 1"},
+			wantErr:  "unknown database",
+			wantCode: tools.CodeNotFound,
+		},
+		{
+			name:     "empty query",
+			input:    Input{Query: "  "},
+			wantErr:  "query is required",
+			wantCode: tools.CodeInvalidArgument,
+		},
+		{
+			name:     "sql error",
+			input:    Input{Query: "SELECT * FROM missing"},
+			wantErr:  "no such table",
+			wantCode: tools.CodeInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := q.Query(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(out.Rows) != len(tt.wantRows) {
+				t.Fatalf("got %d rows, want %d: %v", len(out.Rows), len(tt.wantRows), out.Rows)
+			}
+			for i, row := range tt.wantRows {
+				for j, v := range row {
+					if out.Rows[i][j] != v {
+						t.Errorf("row %d col %d = %#v, want %#v", i, j, out.Rows[i][j], v)
+					}
+				}
+			}
+			if out.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", out.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestQueryAsync(t *testing.T) {
+	q := newTestQuerier(t, true)
+
+	// Statements are checked before a job is started
+	if _, _, err := q.QueryAsync(context.Background(), &mcp.CallToolRequest{}, Input{Query: "DELETE FROM users"}); err == nil || tools.AsError(err).Code != tools.CodePermissionDenied {
+		t.Errorf("QueryAsync() of a write error = %v, want permission_denied", err)
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	if err := tools.RegisterAll(server); err != nil {
+		t.Fatal(err)
+	}
+	tools.AddTool(server, &mcp.Tool{Name: "sql_query_async"}, q.QueryAsync)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "sql_query_async", Arguments: map[string]any{"query": "SELECT name FROM users ORDER BY id"}})
+	if err != nil || res.IsError {
+		t.Fatalf("sql_query_async = %+v, %v", res, err)
+	}
+	id := res.StructuredContent.(map[string]any)["id"]
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		res, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "job_result", Arguments: map[string]any{"id": id}})
+		if err != nil {
+			t.Fatalf("job_result error = %v", err)
+		}
+		if !res.IsError {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job_result = %+v", res.Content[0])
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, `"rows":[["alice"],["bob"]]`) {
+		t.Errorf("job_result = %s, want the first two rows", text)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want tools.Code
+	}{
+		{name: "timeout", err: context.DeadlineExceeded, want: tools.CodeDeadlineExceeded},
+		{name: "lost connection", err: driver.ErrBadConn, want: tools.CodeUnavailable},
+		{name: "rejected query", err: errors.New(`syntax error at or near "SELEC"`), want: tools.CodeInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryError("main", tt.err); got.Code != tt.want || !errors.Is(got, tt.err) {
+				t.Errorf("queryError = %+v, want code %s wrapping %v", got, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestQuery_ReadOnlyDatabase(t *testing.T) {
+	q := newTestQuerier(t, true)
+
+	// A data-modifying CTE passes the keyword check but the database,
+	// opened read-only, must still refuse it.
+	_, _, err := q.Query(context.Background(), &mcp.CallToolRequest{}, Input{
+		Query: "WITH x AS (SELECT 1) INSERT INTO users (name) SELECT 'eve' FROM x",
+	})
+	if err == nil {
+		t.Fatal("expected write to fail on a read-only database")
+	}
+}
+
+func TestQuery_Writable(t *testing.T) {
+	q := newTestQuerier(t, false)
+
+	_, _, err := q.Query(context.Background(), &mcp.CallToolRequest{}, Input{Query: "INSERT INTO users (name) VALUES (?)", Params: []any{"dave"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, out, err := q.Query(context.Background(), &mcp.CallToolRequest{}, Input{Query: "SELECT COUNT(*) FROM users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Rows[0][0] != int64(4) {
+		t.Errorf("count = %v, want 4", out.Rows[0][0])
+	}
+}
+
+func TestParseDatabase(t *testing.T) {
+	tests := []struct {
+		entry      string
+		readOnly   bool
+		wantDriver string
+		wantDSN    string
+		wantErr    string
+	}{
+		{
+			entry:      "pg=postgres://u:p@db:5432/app?sslmode=disable",
+			wantDriver: "pgx",
+			wantDSN:    "postgres://u:p@db:5432/app?sslmode=disable",
+		},
+		{
+			entry:      "my=mysql://u:p@db:3306/app",
+			wantDriver: "mysql",
+			wantDSN:    "u:p@tcp(db:3306)/app?parseTime=true",
+		},
+		{
+			entry:      "lite=sqlite:///var/data/app.db",
+			readOnly:   true,
+			wantDriver: "sqlite",
+			wantDSN:    "file:/var/data/app.db?mode=ro",
+		},
+		{
+			entry:      "lite=sqlite:app.db",
+			wantDriver: "sqlite",
+			wantDSN:    "file:app.db",
+		},
+		{entry: "postgres://db/app", wantErr: "name=url"},
+		{entry: "postgres://db/app?sslmode=disable", wantErr: "invalid database name"},
+		{entry: "x=oracle://db", wantErr: "unsupported scheme"},
+		{entry: "=sqlite:a.db", wantErr: "name=url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			got, err := ParseDatabase(tt.entry, tt.readOnly)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Driver != tt.wantDriver || got.DSN != tt.wantDSN {
+				t.Errorf("got %s %q, want %s %q", got.Driver, got.DSN, tt.wantDriver, tt.wantDSN)
+			}
+		})
+	}
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{"SELECT 1", false},
+		{"  with t as (select 1) select * from t;", false},
+		{"/* comment */ SELECT 1", false},
+		{"(SELECT 1)", false},
+		{"EXPLAIN SELECT 1", false},
+		{"SELECT 'it''s'", false},
+		{"UPDATE t SET a = 1", true},
+		{"SELECT 1; SELECT 2", true},
+		{"SELECT 'unterminated", true},
+		{"/* unterminated", true},
+		{"-- only a comment", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			err := checkReadOnly(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkReadOnly(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Limits on how often a Stream notifies the client.
+const (
+	// StreamChunkBytes is the most output held back before it is sent.
+	StreamChunkBytes = 4096
+	// StreamInterval is the longest output is held back once written.
+	StreamInterval = 250 * time.Millisecond
+)
+
+// Stream sends a tool's output to the client while the tool is still
+// running, as notifications/progress whose message is the next chunk of
+// text and whose progress is the number of bytes sent. _meta.offset holds
+// the byte offset of the chunk. MCP has no other way to send partial
+// results, so only clients that asked for progress with a progress token
+// see the chunks; the tool's result must still hold the whole output,
+// which Result builds from what the Stream kept.
+//
+// Output is sent once StreamChunkBytes are pending or StreamInterval has
+// passed since the last send, on the next Write or on Flush; Write never
+// waits for a slow client, leaving the output pending while a previous
+// notification is still being sent. A Stream is
+// safe for concurrent use, so a command's stdout and stderr can share one.
+type Stream struct {
+	ctx     context.Context
+	session *mcp.ServerSession
+	token   any
+	keep    int
+	now     func() time.Time
+
+	// sendMu orders notifications; mu is never held while sending, so a
+	// slow client does not block Write
+	sendMu  sync.Mutex
+	mu      sync.Mutex
+	kept    strings.Builder
+	written int
+	pending []byte
+	sent    int
+	last    time.Time
+}
+
+// NewStream creates a Stream for the call req. The first keep bytes
+// written are kept for Text and Result; keep <= 0 keeps nothing, for tools
+// that build their result another way.
+func NewStream(ctx context.Context, req *mcp.CallToolRequest, keep int) *Stream {
+	s := &Stream{ctx: ctx, keep: keep, now: time.Now}
+	if req != nil && req.Session != nil && req.Params != nil {
+		if token := req.Params.GetProgressToken(); token != nil {
+			s.session, s.token = req.Session, token
+		}
+	}
+	s.last = s.now()
+	return s
+}
+
+// Streaming reports whether the client receives output as it is written.
+func (s *Stream) Streaming() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session != nil
+}
+
+// Write keeps p and sends the pending output if enough has built up. It
+// never fails: if a notification cannot be sent, the Stream stops sending
+// and the client gets the output in the result only.
+func (s *Stream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	s.written += len(p)
+	if room := s.keep - s.kept.Len(); room > 0 {
+		s.kept.Write(p[:min(len(p), room)])
+	}
+	due := false
+	if s.session != nil {
+		s.pending = append(s.pending, p...)
+		due = len(s.pending) >= StreamChunkBytes || s.now().Sub(s.last) >= StreamInterval
+	}
+	s.mu.Unlock()
+	if due {
+		s.send(false)
+	}
+	return len(p), nil
+}
+
+// WriteString is Write for strings.
+func (s *Stream) WriteString(str string) (int, error) {
+	return s.Write([]byte(str))
+}
+
+// Flush sends any pending output, after any notification being sent.
+func (s *Stream) Flush() {
+	s.send(true)
+}
+
+// send notifies the client of the pending output, holding back an
+// incomplete UTF-8 sequence at its end unless final. The output is taken
+// under s.mu and sent after unlocking it. A Write finding a notification
+// already being sent leaves its output pending for a later Write or Flush.
+func (s *Stream) send(final bool) {
+	if final {
+		s.sendMu.Lock()
+	} else if !s.sendMu.TryLock() {
+		return
+	}
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	if s.session == nil {
+		s.mu.Unlock()
+		return
+	}
+	n := len(s.pending)
+	if !final {
+		// Back up to the start of the last rune and keep it if it is cut
+		i := n - 1
+		for i > 0 && n-i < utf8.UTFMax && !utf8.RuneStart(s.pending[i]) {
+			i--
+		}
+		if i >= 0 && !utf8.FullRune(s.pending[i:]) {
+			n = i
+		}
+	}
+	if n == 0 {
+		s.mu.Unlock()
+		return
+	}
+	chunk := strings.ToValidUTF8(string(s.pending[:n]), "�")
+	session, offset := s.session, s.sent
+	s.sent += n
+	s.pending = append(s.pending[:0], s.pending[n:]...)
+	s.last = s.now()
+	s.mu.Unlock()
+
+	err := session.NotifyProgress(s.ctx, &mcp.ProgressNotificationParams{
+		Meta:          mcp.Meta{"offset": offset},
+		ProgressToken: s.token,
+		Progress:      float64(offset + n),
+		Message:       chunk,
+	})
+	if err != nil {
+		logger.Debug("stream notification failed; buffering the rest", "error", err)
+		s.mu.Lock()
+		s.session, s.pending = nil, nil
+		s.mu.Unlock()
+	}
+}
+
+// Text returns the output kept, and whether more was written than kept.
+func (s *Stream) Text() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.ToValidUTF8(s.kept.String(), "�"), s.written > s.kept.Len()
+}
+
+// Result flushes the Stream and returns a result holding the output kept,
+// for tools whose output is their text. If more was written than kept,
+// _meta.truncated holds the bytes written and kept.
+func (s *Stream) Result() *mcp.CallToolResult {
+	s.Flush()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: strings.ToValidUTF8(s.kept.String(), "�")}}}
+	if s.written > s.kept.Len() {
+		res.Meta = mcp.Meta{"truncated": map[string]any{"bytes": s.written, "limit": s.keep}}
+	}
+	return res
+}
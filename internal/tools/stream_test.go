@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestStream(t *testing.T) {
+	// The output crosses chunk boundaries inside multi-byte runes
+	output := strings.Repeat("ab€", 3*StreamChunkBytes)
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	AddTool(server, &mcp.Tool{Name: "produce"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, struct{}, error) {
+		s := NewStream(ctx, req, 1<<20)
+		for i := 0; i < len(output); i += 1000 {
+			_, _ = s.WriteString(output[i:min(i+1000, len(output))])
+		}
+		return s.Result(), struct{}{}, nil
+	})
+
+	var mu sync.Mutex
+	var chunks []*mcp.ProgressNotificationParams
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			mu.Lock()
+			defer mu.Unlock()
+			chunks = append(chunks, req.Params)
+		},
+	})
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	tests := []struct {
+		name       string
+		token      any
+		wantChunks bool
+	}{
+		{name: "without a progress token", token: nil},
+		{name: "with a progress token", token: "p1", wantChunks: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			chunks = nil
+			mu.Unlock()
+			params := &mcp.CallToolParams{Name: "produce", Arguments: map[string]any{}}
+			if tt.token != nil {
+				params.Meta = mcp.Meta{"progressToken": tt.token}
+			}
+			res, err := session.CallTool(ctx, params)
+			if err != nil {
+				t.Fatalf("CallTool: %v", err)
+			}
+			if text := res.Content[0].(*mcp.TextContent).Text; text != output {
+				t.Errorf("result holds %d bytes, want the whole %d", len(text), len(output))
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !tt.wantChunks {
+				if len(chunks) != 0 {
+					t.Errorf("got %d progress notifications without a token", len(chunks))
+				}
+				return
+			}
+			// Notifications are delivered asynchronously but in order
+			var got strings.Builder
+			last := 0.0
+			for _, c := range chunks {
+				if c.Progress <= last {
+					t.Errorf("progress %v after %v, want increasing", c.Progress, last)
+				}
+				if offset, _ := c.Meta["offset"].(float64); int(offset) != got.Len() {
+					t.Errorf("_meta.offset = %v, want %d", c.Meta["offset"], got.Len())
+				}
+				last = c.Progress
+				got.WriteString(c.Message)
+			}
+			if got.String() != output[:got.Len()] || len(chunks) < 2 {
+				t.Errorf("%d chunks holding %d bytes do not match the output", len(chunks), got.Len())
+			}
+		})
+	}
+}
+
+func TestStreamKeep(t *testing.T) {
+	s := NewStream(context.Background(), nil, 5)
+	if s.Streaming() {
+		t.Error("Streaming() = true without a session")
+	}
+	_, _ = s.WriteString("hello ")
+	_, _ = s.WriteString("world")
+	if text, truncated := s.Text(); text != "hello" || !truncated {
+		t.Errorf("Text() = %q, %v; want hello, true", text, truncated)
+	}
+	res := s.Result()
+	if meta, _ := res.Meta["truncated"].(map[string]any); meta["bytes"] != 11 || meta["limit"] != 5 {
+		t.Errorf("_meta.truncated = %v", res.Meta["truncated"])
+	}
+}
@@ -0,0 +1,186 @@
+package template
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// Limits on template and output size.
+const (
+	MaxTemplateBytes = 64 << 10
+	MaxOutputBytes   = 1 << 20
+	MaxRepeat        = 10000
+)
+
+// errOutputLimit stops execution once the output limit is reached.
+var errOutputLimit = fmt.Errorf("output exceeds %d bytes", MaxOutputBytes)
+
+// funcs is the complete function set available to templates. It is
+// deliberately limited to pure string, math, and encoding helpers: nothing
+// touches the filesystem, network, environment, or clock, so rendering is
+// deterministic.
+var funcs = template.FuncMap{
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      title,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":       join,
+	"repeat":     repeat,
+	"indent":     indent,
+	"quote":      func(s string) string { return fmt.Sprintf("%q", s) },
+	"default":    defaultValue,
+	"toJSON":     toJSON,
+	"toPrettyJSON": func(v any) (string, error) {
+		b, err := json.MarshalIndent(v, "", "  ")
+		return string(b), err
+	},
+	"add": func(a, b float64) float64 { return a + b },
+	"sub": func(a, b float64) float64 { return a - b },
+	"mul": func(a, b float64) float64 { return a * b },
+	"div": func(a, b float64) (float64, error) {
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	},
+}
+
+// Input is the input for the render_template tool.
+type Input struct {
+	Template string `json:"template" jsonschema:"a Go text/template, e.g. Hello {{.name | upper}}"`
+	Data     any    `json:"data,omitempty" jsonschema:"JSON data available to the template as dot"`
+	Strict   bool   `json:"strict,omitempty" jsonschema:"fail on missing map keys instead of rendering <no value>"`
+}
+
+// Output is the output of the render_template tool.
+type Output struct {
+	Result string `json:"result" jsonschema:"the rendered text"`
+}
+
+// Render executes a template against JSON data.
+func Render(ctx context.Context, req *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	if len(input.Template) > MaxTemplateBytes {
+		return nil, Output{}, tools.NewError(tools.CodeResourceExhausted, "template exceeds %d bytes", MaxTemplateBytes).
+			WithDetail("max_bytes", MaxTemplateBytes)
+	}
+
+	tmpl := template.New("template").Funcs(funcs)
+	if input.Strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+	tmpl, err := tmpl.Parse(input.Template)
+	if err != nil {
+		return nil, Output{}, tools.WrapError(tools.CodeInvalidArgument, err, "parsing template")
+	}
+
+	var out limitedBuilder
+	if err := tmpl.Execute(&out, input.Data); err != nil {
+		if errors.Is(err, errOutputLimit) {
+			return nil, Output{}, tools.WrapError(tools.CodeResourceExhausted, errOutputLimit, "rendering template").
+				WithDetail("max_bytes", MaxOutputBytes)
+		}
+		return nil, Output{}, tools.WrapError(tools.CodeInvalidArgument, err, "rendering template")
+	}
+
+	logger.Info("tool called", "tool", "render_template", "template_bytes", len(input.Template), "output_bytes", out.Len())
+	return nil, Output{Result: out.String()}, nil
+}
+
+// limitedBuilder is a strings.Builder that refuses to grow past MaxOutputBytes.
+type limitedBuilder struct {
+	strings.Builder
+}
+
+func (b *limitedBuilder) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > MaxOutputBytes {
+		return 0, errOutputLimit
+	}
+	return b.Builder.Write(p)
+}
+
+func title(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+// join accepts the []any produced by decoding JSON arrays as well as []string.
+func join(sep string, v any) (string, error) {
+	switch items := v.(type) {
+	case []string:
+		return strings.Join(items, sep), nil
+	case []any:
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, sep), nil
+	default:
+		return "", fmt.Errorf("join expects a list, got %T", v)
+	}
+}
+
+func repeat(count int, s string) (string, error) {
+	if count < 0 || count > MaxRepeat {
+		return "", fmt.Errorf("repeat count must be between 0 and %d", MaxRepeat)
+	}
+	if count*len(s) > MaxOutputBytes {
+		return "", errOutputLimit
+	}
+	return strings.Repeat(s, count), nil
+}
+
+func indent(spaces int, s string) (string, error) {
+	if spaces < 0 || spaces > 100 {
+		return "", fmt.Errorf("indent must be between 0 and 100 spaces")
+	}
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad), nil
+}
+
+// defaultValue returns def when v is nil or an empty string.
+func defaultValue(def, v any) any {
+	if v == nil || v == "" {
+		return def
+	}
+	return v
+}
+
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func init() {
+	tools.Register(tools.Namespace("text", func(server *mcp.Server) {
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "render_template",
+			Description: "Render a Go text/template against JSON data using a sandboxed function set (upper, lower, title, trim, replace, split, join, repeat, indent, quote, default, toJSON, toPrettyJSON, add, sub, mul, div)",
+			Annotations: tools.ReadOnly(false),
+		}, Render)
+	}))
+}
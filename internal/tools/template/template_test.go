@@ -0,0 +1,66 @@
+package template
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestRender(t *testing.T) {
+	data := map[string]any{
+		"name":  "ada lovelace",
+		"tags":  []any{"math", "computing"},
+		"count": 3.0,
+		"empty": "",
+	}
+
+	tests := []struct {
+		name     string
+		input    Input
+		want     string
+		wantErr  string
+		wantCode tools.Code
+	}{
+		{name: "field", input: Input{Template: "Hello {{.name}}", Data: data}, want: "Hello ada lovelace"},
+		{name: "pipeline", input: Input{Template: "{{.name | title}}", Data: data}, want: "Ada Lovelace"},
+		{name: "range", input: Input{Template: "{{range .tags}}[{{.}}]{{end}}", Data: data}, want: "[math][computing]"},
+		{name: "join", input: Input{Template: `{{join ", " .tags}}`, Data: data}, want: "math, computing"},
+		{name: "default", input: Input{Template: `{{default "n/a" .empty}}`, Data: data}, want: "n/a"},
+		{name: "math", input: Input{Template: "{{add .count 2}}", Data: data}, want: "5"},
+		{name: "toJSON", input: Input{Template: "{{toJSON .tags}}", Data: data}, want: `["math","computing"]`},
+		{name: "indent", input: Input{Template: `{{indent 2 "a\nb"}}`}, want: "  a\n  b"},
+		{name: "missing key lenient", input: Input{Template: "{{.missing}}", Data: data}, want: "<no value>"},
+		{name: "missing key strict", input: Input{Template: "{{.missing}}", Data: data, Strict: true}, wantErr: "map has no entry", wantCode: tools.CodeInvalidArgument},
+		{name: "parse error", input: Input{Template: "{{.name"}, wantErr: "parsing template", wantCode: tools.CodeInvalidArgument},
+		{name: "unknown function", input: Input{Template: `{{env "HOME"}}`}, wantErr: `function "env" not defined`, wantCode: tools.CodeInvalidArgument},
+		{name: "division by zero", input: Input{Template: "{{div 1 0}}"}, wantErr: "division by zero", wantCode: tools.CodeInvalidArgument},
+		{name: "repeat limit", input: Input{Template: `{{repeat 100000 "x"}}`}, wantErr: "repeat count", wantCode: tools.CodeInvalidArgument},
+		{name: "output limit", input: Input{Template: `{{range .}}{{repeat 10000 "xxxxxxxxxx"}}{{end}}`, Data: make([]any, 20)}, wantErr: "output exceeds", wantCode: tools.CodeResourceExhausted},
+		{name: "template too large", input: Input{Template: strings.Repeat("x", MaxTemplateBytes+1)}, wantErr: "template exceeds", wantCode: tools.CodeResourceExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Render(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Result != tt.want {
+				t.Errorf("Result = %q, want %q", out.Result, tt.want)
+			}
+		})
+	}
+}
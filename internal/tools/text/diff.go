@@ -0,0 +1,85 @@
+package text
+
+import (
+	"strings"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// MaxDiffCells bounds the LCS table size (lines before x lines after).
+const MaxDiffCells = 4_000_000
+
+// diffOp is one line of diff output.
+type diffOp struct {
+	kind byte // '+', '-', or ' '
+	line string
+}
+
+// splitLines splits s into lines without trailing newline characters.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes a minimal line diff using a longest common subsequence
+// table, after trimming the common prefix and suffix.
+func diffLines(a, b []string) ([]diffOp, error) {
+	var prefix []diffOp
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		prefix = append(prefix, diffOp{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	suffix := a[len(a)-n:]
+	a, b = a[:len(a)-n], b[:len(b)-n]
+
+	if (len(a)+1)*(len(b)+1) > MaxDiffCells {
+		return nil, tools.NewError(tools.CodeResourceExhausted, "inputs too large to diff: %d x %d changed lines", len(a), len(b)).
+			WithDetail("max_cells", MaxDiffCells)
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	cols := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*cols)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*cols+j] = lcs[(i+1)*cols+j+1] + 1
+			} else {
+				lcs[i*cols+j] = max(lcs[(i+1)*cols+j], lcs[i*cols+j+1])
+			}
+		}
+	}
+
+	ops := prefix
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[(i+1)*cols+j] >= lcs[i*cols+j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	for _, line := range suffix {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops, nil
+}
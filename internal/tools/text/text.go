@@ -0,0 +1,458 @@
+package text
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// Limits applied to text tool inputs. Go's regexp package is RE2-based and
+// runs in linear time, so bounding input and pattern size bounds the work.
+const (
+	MaxInputBytes   = 1 << 20
+	MaxPatternBytes = 1024
+	MaxMatches      = 1000
+)
+
+// RegexMatchInput is the input for the regex_match tool.
+type RegexMatchInput struct {
+	Text       string `json:"text" jsonschema:"the text to search"`
+	Pattern    string `json:"pattern" jsonschema:"RE2 regular expression; lookarounds and backreferences are not supported"`
+	MaxMatches int    `json:"max_matches,omitempty" jsonschema:"maximum matches to return (default and max: 1000)"`
+}
+
+// Match is a single regular expression match.
+type Match struct {
+	Text   string   `json:"text" jsonschema:"the matched text"`
+	Start  int      `json:"start" jsonschema:"byte offset where the match starts"`
+	End    int      `json:"end" jsonschema:"byte offset where the match ends"`
+	Groups []string `json:"groups,omitempty" jsonschema:"capture group values, in order"`
+}
+
+// RegexMatchOutput is the output of the regex_match tool.
+type RegexMatchOutput struct {
+	Matched bool    `json:"matched" jsonschema:"whether the pattern matched at least once"`
+	Matches []Match `json:"matches" jsonschema:"the matches found"`
+}
+
+// RegexReplaceInput is the input for the regex_replace tool.
+type RegexReplaceInput struct {
+	Text        string `json:"text" jsonschema:"the text to transform"`
+	Pattern     string `json:"pattern" jsonschema:"RE2 regular expression"`
+	Replacement string `json:"replacement" jsonschema:"replacement text; $1 or ${name} expand capture groups"`
+	Literal     bool   `json:"literal,omitempty" jsonschema:"treat replacement literally without expanding $ references"`
+}
+
+// RegexReplaceOutput is the output of the regex_replace tool.
+type RegexReplaceOutput struct {
+	Text         string `json:"text" jsonschema:"the transformed text"`
+	Replacements int    `json:"replacements" jsonschema:"the number of matches replaced"`
+}
+
+// CaseInput is the input for the text_case tool.
+type CaseInput struct {
+	Text string `json:"text" jsonschema:"the text to transform"`
+	Case string `json:"case" jsonschema:"target case: upper, lower, title, sentence, snake, kebab, camel, pascal, or constant"`
+}
+
+// SlugifyInput is the input for the slugify tool.
+type SlugifyInput struct {
+	Text      string `json:"text" jsonschema:"the text to slugify"`
+	Separator string `json:"separator,omitempty" jsonschema:"word separator (default: -)"`
+	MaxLength int    `json:"max_length,omitempty" jsonschema:"truncate the slug to this many characters at a word boundary"`
+}
+
+// TextOutput is the output of tools that produce a single string.
+type TextOutput struct {
+	Text string `json:"text" jsonschema:"the resulting text"`
+}
+
+// StatsInput is the input for the text_stats tool.
+type StatsInput struct {
+	Text string `json:"text" jsonschema:"the text to analyze"`
+}
+
+// StatsOutput is the output of the text_stats tool.
+type StatsOutput struct {
+	Bytes      int `json:"bytes" jsonschema:"length in bytes"`
+	Characters int `json:"characters" jsonschema:"number of Unicode code points"`
+	Words      int `json:"words" jsonschema:"number of whitespace-separated words"`
+	Lines      int `json:"lines" jsonschema:"number of lines"`
+	Sentences  int `json:"sentences" jsonschema:"approximate number of sentences"`
+}
+
+// DiffInput is the input for the text_diff tool.
+type DiffInput struct {
+	Before string `json:"before" jsonschema:"the original text"`
+	After  string `json:"after" jsonschema:"the changed text"`
+}
+
+// DiffOutput is the output of the text_diff tool.
+type DiffOutput struct {
+	Diff    string `json:"diff" jsonschema:"line diff where each line is prefixed with '+' (added), '-' (removed), or ' ' (unchanged)"`
+	Added   int    `json:"added" jsonschema:"number of added lines"`
+	Removed int    `json:"removed" jsonschema:"number of removed lines"`
+	Equal   bool   `json:"equal" jsonschema:"whether the texts are identical"`
+}
+
+// RegexMatch finds all matches of a pattern in text.
+func RegexMatch(_ context.Context, _ *mcp.CallToolRequest, input RegexMatchInput) (*mcp.CallToolResult, RegexMatchOutput, error) {
+	re, err := compile(input.Pattern, input.Text)
+	if err != nil {
+		return nil, RegexMatchOutput{}, err
+	}
+
+	limit := input.MaxMatches
+	if limit <= 0 || limit > MaxMatches {
+		limit = MaxMatches
+	}
+
+	matches := []Match{}
+	for _, loc := range re.FindAllStringSubmatchIndex(input.Text, limit) {
+		m := Match{Text: input.Text[loc[0]:loc[1]], Start: loc[0], End: loc[1]}
+		for g := 2; g < len(loc); g += 2 {
+			group := ""
+			if loc[g] >= 0 {
+				group = input.Text[loc[g]:loc[g+1]]
+			}
+			m.Groups = append(m.Groups, group)
+		}
+		matches = append(matches, m)
+	}
+
+	logger.Info("tool called", "tool", "regex_match", "matches", len(matches))
+	return nil, RegexMatchOutput{Matched: len(matches) > 0, Matches: matches}, nil
+}
+
+// RegexReplace replaces all matches of a pattern in text.
+func RegexReplace(_ context.Context, _ *mcp.CallToolRequest, input RegexReplaceInput) (*mcp.CallToolResult, RegexReplaceOutput, error) {
+	re, err := compile(input.Pattern, input.Text)
+	if err != nil {
+		return nil, RegexReplaceOutput{}, err
+	}
+
+	if len(input.Replacement) > MaxPatternBytes {
+		return nil, RegexReplaceOutput{}, tools.NewError(tools.CodeResourceExhausted, "replacement exceeds %d bytes", MaxPatternBytes).
+			WithDetail("max_bytes", MaxPatternBytes)
+	}
+
+	locs := re.FindAllStringIndex(input.Text, -1)
+	if replacedSize(re, input, locs) > MaxInputBytes {
+		return nil, RegexReplaceOutput{}, tools.NewError(tools.CodeResourceExhausted, "result would exceed %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+
+	count := len(locs)
+	var result string
+	if input.Literal {
+		result = re.ReplaceAllLiteralString(input.Text, input.Replacement)
+	} else {
+		result = re.ReplaceAllString(input.Text, input.Replacement)
+	}
+
+	logger.Info("tool called", "tool", "regex_replace", "replacements", count)
+	return nil, RegexReplaceOutput{Text: result, Replacements: count}, nil
+}
+
+// replacedSize returns an upper bound on the length of the text once every
+// match in locs is replaced, without building it. A template expands to its
+// literal bytes plus, for each group reference, at most the whole match.
+func replacedSize(re *regexp.Regexp, input RegexReplaceInput, locs [][]int) int {
+	literal, refs := len(input.Replacement), 0
+	if !input.Literal {
+		// Expanding against groups of length 0 and then 1 separates the
+		// template's literal bytes from its group references.
+		empty := make([]int, 2*(re.NumSubexp()+1))
+		one := make([]int, len(empty))
+		for i := 1; i < len(one); i += 2 {
+			one[i] = 1
+		}
+		literal = len(re.ExpandString(nil, input.Replacement, "x", empty))
+		refs = len(re.ExpandString(nil, input.Replacement, "x", one)) - literal
+	}
+
+	size := len(input.Text)
+	for _, loc := range locs {
+		n := loc[1] - loc[0]
+		size += literal + refs*n - n
+	}
+	return size
+}
+
+// ChangeCase converts text to the requested case style.
+func ChangeCase(_ context.Context, _ *mcp.CallToolRequest, input CaseInput) (*mcp.CallToolResult, TextOutput, error) {
+	if len(input.Text) > MaxInputBytes {
+		return nil, TextOutput{}, tools.NewError(tools.CodeResourceExhausted, "text exceeds %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+
+	style := strings.ToLower(input.Case)
+	var result string
+	switch style {
+	case "upper":
+		result = strings.ToUpper(input.Text)
+	case "lower":
+		result = strings.ToLower(input.Text)
+	case "title":
+		result = mapWordsInPlace(input.Text, capitalize)
+	case "sentence":
+		result = capitalize(strings.ToLower(input.Text))
+	case "snake":
+		result = strings.Join(lowerAll(splitWords(input.Text)), "_")
+	case "kebab":
+		result = strings.Join(lowerAll(splitWords(input.Text)), "-")
+	case "constant":
+		result = strings.ToUpper(strings.Join(splitWords(input.Text), "_"))
+	case "camel", "pascal":
+		words := lowerAll(splitWords(input.Text))
+		for i, w := range words {
+			if i > 0 || style == "pascal" {
+				words[i] = capitalize(w)
+			}
+		}
+		result = strings.Join(words, "")
+	default:
+		return nil, TextOutput{}, tools.NewError(tools.CodeInvalidArgument, "unsupported case %q: use upper, lower, title, sentence, snake, kebab, camel, pascal, or constant", input.Case)
+	}
+
+	logger.Info("tool called", "tool", "text_case", "case", style)
+	return nil, TextOutput{Text: result}, nil
+}
+
+// Slugify converts text into a URL-friendly ASCII slug.
+func Slugify(_ context.Context, _ *mcp.CallToolRequest, input SlugifyInput) (*mcp.CallToolResult, TextOutput, error) {
+	if len(input.Text) > MaxInputBytes {
+		return nil, TextOutput{}, tools.NewError(tools.CodeResourceExhausted, "text exceeds %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+	sep := input.Separator
+	if sep == "" {
+		sep = "-"
+	}
+
+	// Decompose accented characters and drop the combining marks so that
+	// "café" becomes "cafe" rather than "caf".
+	var words []string
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			words = append(words, b.String())
+			b.Reset()
+		}
+	}
+	for _, r := range norm.NFKD.String(input.Text) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	slug := strings.Join(words, sep)
+	if input.MaxLength > 0 && len(slug) > input.MaxLength {
+		slug = slug[:input.MaxLength]
+		if i := strings.LastIndex(slug, sep); i > 0 {
+			slug = slug[:i]
+		}
+	}
+
+	logger.Info("tool called", "tool", "slugify", "length", len(slug))
+	return nil, TextOutput{Text: slug}, nil
+}
+
+// Stats counts bytes, characters, words, lines, and sentences.
+func Stats(_ context.Context, _ *mcp.CallToolRequest, input StatsInput) (*mcp.CallToolResult, StatsOutput, error) {
+	if len(input.Text) > MaxInputBytes {
+		return nil, StatsOutput{}, tools.NewError(tools.CodeResourceExhausted, "text exceeds %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+
+	out := StatsOutput{
+		Bytes:      len(input.Text),
+		Characters: utf8.RuneCountInString(input.Text),
+		Words:      len(strings.Fields(input.Text)),
+	}
+	if input.Text != "" {
+		out.Lines = strings.Count(strings.TrimSuffix(input.Text, "\n"), "\n") + 1
+	}
+	for _, sentence := range strings.FieldsFunc(input.Text, func(r rune) bool { return r == '.' || r == '!' || r == '?' }) {
+		if strings.TrimSpace(sentence) != "" {
+			out.Sentences++
+		}
+	}
+
+	logger.Info("tool called", "tool", "text_stats", "bytes", out.Bytes)
+	return nil, out, nil
+}
+
+// Diff produces a line-based diff between two texts.
+func Diff(_ context.Context, _ *mcp.CallToolRequest, input DiffInput) (*mcp.CallToolResult, DiffOutput, error) {
+	if len(input.Before) > MaxInputBytes || len(input.After) > MaxInputBytes {
+		return nil, DiffOutput{}, tools.NewError(tools.CodeResourceExhausted, "inputs must not exceed %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+
+	ops, err := diffLines(splitLines(input.Before), splitLines(input.After))
+	if err != nil {
+		return nil, DiffOutput{}, err
+	}
+
+	out := DiffOutput{Equal: input.Before == input.After}
+	var b strings.Builder
+	for _, op := range ops {
+		b.WriteByte(op.kind)
+		b.WriteString(op.line)
+		b.WriteByte('\n')
+		switch op.kind {
+		case '+':
+			out.Added++
+		case '-':
+			out.Removed++
+		}
+	}
+	out.Diff = b.String()
+
+	logger.Info("tool called", "tool", "text_diff", "added", out.Added, "removed", out.Removed)
+	return nil, out, nil
+}
+
+// compile validates limits and compiles an RE2 pattern.
+func compile(pattern, text string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, tools.NewError(tools.CodeInvalidArgument, "pattern is required")
+	}
+	if len(pattern) > MaxPatternBytes {
+		return nil, tools.NewError(tools.CodeResourceExhausted, "pattern exceeds %d bytes", MaxPatternBytes).
+			WithDetail("max_bytes", MaxPatternBytes)
+	}
+	if len(text) > MaxInputBytes {
+		return nil, tools.NewError(tools.CodeResourceExhausted, "text exceeds %d bytes", MaxInputBytes).
+			WithDetail("max_bytes", MaxInputBytes)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, tools.WrapError(tools.CodeInvalidArgument, err, "invalid pattern")
+	}
+	return re, nil
+}
+
+// splitWords breaks text into words on non-alphanumeric characters and
+// lower-to-upper case transitions ("parseHTTPRequest" -> parse, HTTP, Request).
+func splitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// lowerAll lower-cases every word in place and returns the slice.
+func lowerAll(words []string) []string {
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return words
+}
+
+// capitalize upper-cases the first rune of s.
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// mapWordsInPlace applies fn to each whitespace-delimited word, preserving
+// the original spacing.
+func mapWordsInPlace(s string, fn func(string) string) string {
+	var b strings.Builder
+	word := strings.Builder{}
+	flush := func() {
+		if word.Len() > 0 {
+			b.WriteString(fn(strings.ToLower(word.String())))
+			word.Reset()
+		}
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			flush()
+			b.WriteRune(r)
+			continue
+		}
+		word.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+func init() {
+	tools.Register(tools.Namespace("text", func(server *mcp.Server) {
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "regex_match",
+			Description: "Find all matches and capture groups of an RE2 regular expression in text",
+			Annotations: tools.ReadOnly(false),
+		}, RegexMatch)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "regex_replace",
+			Description: "Replace all matches of an RE2 regular expression in text",
+			Annotations: tools.ReadOnly(false),
+		}, RegexReplace)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "text_case",
+			Description: "Convert text to upper, lower, title, sentence, snake, kebab, camel, pascal, or constant case",
+			Annotations: tools.ReadOnly(false),
+		}, ChangeCase)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "slugify",
+			Description: "Convert text into a URL-friendly ASCII slug",
+			Annotations: tools.ReadOnly(false),
+		}, Slugify)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "text_stats",
+			Description: "Count bytes, characters, words, lines, and sentences in text",
+			Annotations: tools.ReadOnly(false),
+		}, Stats)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "text_diff",
+			Description: "Show a line-by-line diff between two texts",
+			Annotations: tools.ReadOnly(false),
+		}, Diff)
+	}))
+}
@@ -0,0 +1,299 @@
+package text
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestRegexMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     RegexMatchInput
+		wantCount int
+		wantFirst Match
+		wantErr   string
+		wantCode  tools.Code
+	}{
+		{
+			name:      "groups",
+			input:     RegexMatchInput{Text: "a=1, b=22", Pattern: `(\w)=(\d+)`},
+			wantCount: 2,
+			wantFirst: Match{Text: "a=1", Start: 0, End: 3, Groups: []string{"a", "1"}},
+		},
+		{
+			name:      "max matches",
+			input:     RegexMatchInput{Text: "aaaa", Pattern: "a", MaxMatches: 2},
+			wantCount: 2,
+			wantFirst: Match{Text: "a", Start: 0, End: 1},
+		},
+		{name: "no match", input: RegexMatchInput{Text: "abc", Pattern: `\d`}, wantCount: 0},
+		{name: "invalid pattern", input: RegexMatchInput{Text: "abc", Pattern: `(`}, wantErr: "invalid pattern", wantCode: tools.CodeInvalidArgument},
+		{name: "backreference unsupported", input: RegexMatchInput{Text: "aa", Pattern: `(a)\1`}, wantErr: "invalid pattern", wantCode: tools.CodeInvalidArgument},
+		{name: "empty pattern", input: RegexMatchInput{Text: "abc"}, wantErr: "pattern is required", wantCode: tools.CodeInvalidArgument},
+		{name: "pattern too long", input: RegexMatchInput{Text: "abc", Pattern: strings.Repeat("a", MaxPatternBytes+1)}, wantErr: "pattern exceeds", wantCode: tools.CodeResourceExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := RegexMatch(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if code := tools.AsError(err).Code; code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(out.Matches) != tt.wantCount || out.Matched != (tt.wantCount > 0) {
+				t.Fatalf("got %d matches (matched=%v), want %d", len(out.Matches), out.Matched, tt.wantCount)
+			}
+			if tt.wantCount == 0 {
+				return
+			}
+			got := out.Matches[0]
+			if got.Text != tt.wantFirst.Text || got.Start != tt.wantFirst.Start || got.End != tt.wantFirst.End ||
+				strings.Join(got.Groups, ",") != strings.Join(tt.wantFirst.Groups, ",") {
+				t.Errorf("first match = %+v, want %+v", got, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestRegexReplace(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     RegexReplaceInput
+		wantText  string
+		wantCount int
+	}{
+		{name: "expand groups", input: RegexReplaceInput{Text: "john smith", Pattern: `(\w+) (\w+)`, Replacement: "$2, $1"}, wantText: "smith, john", wantCount: 1},
+		{name: "named group", input: RegexReplaceInput{Text: "2024-01-02", Pattern: `(?P<y>\d{4})-(?P<m>\d\d)-(?P<d>\d\d)`, Replacement: "${d}/${m}/${y}"}, wantText: "02/01/2024", wantCount: 1},
+		{name: "literal", input: RegexReplaceInput{Text: "a.b.c", Pattern: `\.`, Replacement: "$1", Literal: true}, wantText: "a$1b$1c", wantCount: 2},
+		{name: "no match", input: RegexReplaceInput{Text: "abc", Pattern: `x`, Replacement: "y"}, wantText: "abc", wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := RegexReplace(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Text != tt.wantText || out.Replacements != tt.wantCount {
+				t.Errorf("got (%q, %d), want (%q, %d)", out.Text, out.Replacements, tt.wantText, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRegexReplace_TooLarge(t *testing.T) {
+	big := strings.Repeat("a", MaxInputBytes/2)
+	tests := []struct {
+		name  string
+		input RegexReplaceInput
+	}{
+		{name: "long replacement", input: RegexReplaceInput{Text: "abc", Pattern: `b`, Replacement: strings.Repeat("x", MaxPatternBytes+1)}},
+		{name: "empty matches", input: RegexReplaceInput{Text: big, Pattern: `x*`, Replacement: "xyz"}},
+		{name: "repeated group", input: RegexReplaceInput{Text: big, Pattern: `a+`, Replacement: "$0$0$0"}},
+		{name: "literal", input: RegexReplaceInput{Text: big, Pattern: `a`, Replacement: "xyz", Literal: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := RegexReplace(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if err == nil || tools.AsError(err).Code != tools.CodeResourceExhausted {
+				t.Fatalf("error = %v, want %s", err, tools.CodeResourceExhausted)
+			}
+		})
+	}
+
+	// Literal "$0" is two bytes, not a copy of the match.
+	input := RegexReplaceInput{Text: big, Pattern: `a+`, Replacement: "$0$0$0", Literal: true}
+	if _, out, err := RegexReplace(context.Background(), &mcp.CallToolRequest{}, input); err != nil || out.Text != "$0$0$0" {
+		t.Errorf("literal replacement = (%q, %v), want (\"$0$0$0\", nil)", out.Text, err)
+	}
+}
+
+func TestChangeCase(t *testing.T) {
+	tests := []struct {
+		text    string
+		style   string
+		want    string
+		wantErr bool
+	}{
+		{text: "Hello World", style: "upper", want: "HELLO WORLD"},
+		{text: "Hello World", style: "lower", want: "hello world"},
+		{text: "hello  wORLD", style: "title", want: "Hello  World"},
+		{text: "HELLO THERE. friend", style: "sentence", want: "Hello there. friend"},
+		{text: "parseHTTPRequest now", style: "snake", want: "parse_http_request_now"},
+		{text: "Hello World_again", style: "kebab", want: "hello-world-again"},
+		{text: "user id v2", style: "constant", want: "USER_ID_V2"},
+		{text: "user_id value", style: "camel", want: "userIdValue"},
+		{text: "user-id value", style: "PASCAL", want: "UserIdValue"},
+		{text: "x", style: "sponge", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style+"/"+tt.text, func(t *testing.T) {
+			_, out, err := ChangeCase(context.Background(), &mcp.CallToolRequest{}, CaseInput{Text: tt.text, Case: tt.style})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ChangeCase() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && out.Text != tt.want {
+				t.Errorf("ChangeCase(%q, %q) = %q, want %q", tt.text, tt.style, out.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name  string
+		input SlugifyInput
+		want  string
+	}{
+		{name: "basic", input: SlugifyInput{Text: "Hello, World!"}, want: "hello-world"},
+		{name: "accents", input: SlugifyInput{Text: "Café Crème brûlée"}, want: "cafe-creme-brulee"},
+		{name: "custom separator", input: SlugifyInput{Text: "a b c", Separator: "_"}, want: "a_b_c"},
+		{name: "non latin dropped", input: SlugifyInput{Text: "go 語言 2024"}, want: "go-2024"},
+		{name: "max length at boundary", input: SlugifyInput{Text: "the quick brown fox", MaxLength: 12}, want: "the-quick"},
+		{name: "empty", input: SlugifyInput{Text: "!!!"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Slugify(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Text != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.input.Text, out.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want StatsOutput
+	}{
+		{name: "empty", text: "", want: StatsOutput{}},
+		{name: "ascii", text: "Hello world. How are you?\nFine!\n", want: StatsOutput{Bytes: 32, Characters: 32, Words: 6, Lines: 2, Sentences: 3}},
+		{name: "unicode", text: "héllo", want: StatsOutput{Bytes: 6, Characters: 5, Words: 1, Lines: 1, Sentences: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Stats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Text: tt.text})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != tt.want {
+				t.Errorf("Stats(%q) = %+v, want %+v", tt.text, out, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       DiffInput
+		wantDiff    string
+		wantAdded   int
+		wantRemoved int
+		wantEqual   bool
+	}{
+		{
+			name:      "identical",
+			input:     DiffInput{Before: "a\nb\n", After: "a\nb\n"},
+			wantDiff:  " a\n b\n",
+			wantEqual: true,
+		},
+		{
+			name:        "change middle line",
+			input:       DiffInput{Before: "a\nb\nc", After: "a\nB\nc"},
+			wantDiff:    " a\n-b\n+B\n c\n",
+			wantAdded:   1,
+			wantRemoved: 1,
+		},
+		{
+			name:      "append",
+			input:     DiffInput{Before: "a", After: "a\nb"},
+			wantDiff:  " a\n+b\n",
+			wantAdded: 1,
+		},
+		{
+			name:        "from empty",
+			input:       DiffInput{Before: "", After: "x"},
+			wantDiff:    "+x\n",
+			wantAdded:   1,
+			wantRemoved: 0,
+		},
+		{
+			name:        "interleaved",
+			input:       DiffInput{Before: "a\nx\nb\ny\nc", After: "a\nb\nc\nz"},
+			wantDiff:    " a\n-x\n b\n-y\n c\n+z\n",
+			wantAdded:   1,
+			wantRemoved: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := Diff(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Diff != tt.wantDiff {
+				t.Errorf("Diff = %q, want %q", out.Diff, tt.wantDiff)
+			}
+			if out.Added != tt.wantAdded || out.Removed != tt.wantRemoved || out.Equal != tt.wantEqual {
+				t.Errorf("counts = (+%d, -%d, equal=%v), want (+%d, -%d, equal=%v)",
+					out.Added, out.Removed, out.Equal, tt.wantAdded, tt.wantRemoved, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestDiffLines_TooLarge(t *testing.T) {
+	a := make([]string, 3000)
+	b := make([]string, 3000)
+	for i := range a {
+		a[i] = "a" + strings.Repeat("x", i%7)
+		b[i] = "b" + strings.Repeat("y", i%5)
+	}
+	if _, err := diffLines(a, b); err == nil {
+		t.Fatal("expected error for oversized diff")
+	}
+}
+
+func TestDiffLines_LongCommonSuffix(t *testing.T) {
+	same := make([]string, 500_000)
+	for i := range same {
+		same[i] = "line"
+	}
+	a := append([]string{"old"}, same...)
+	b := append([]string{"new"}, same...)
+
+	ops, err := diffLines(a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ops) != len(same)+2 {
+		t.Fatalf("got %d ops, want %d", len(ops), len(same)+2)
+	}
+	if ops[0] != (diffOp{'-', "old"}) || ops[1] != (diffOp{'+', "new"}) || ops[len(ops)-1] != (diffOp{' ', "line"}) {
+		t.Errorf("unexpected ops: %v ... %v", ops[:2], ops[len(ops)-1])
+	}
+}
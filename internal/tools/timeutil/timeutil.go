@@ -0,0 +1,415 @@
+package timeutil
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// Embed the IANA database so zone lookups work in distroless images.
+	_ "time/tzdata"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// namedFormats maps friendly format names to Go time layouts.
+var namedFormats = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"rfc1123":     time.RFC1123,
+	"rfc822":      time.RFC822,
+	"kitchen":     time.Kitchen,
+	"date":        time.DateOnly,
+	"time":        time.TimeOnly,
+	"datetime":    time.DateTime,
+}
+
+// formatNames returns the named formats and Unix variants, sorted.
+func formatNames() []string {
+	names := []string{"unix", "unix_milli"}
+	for name := range namedFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CurrentTimeInput is the input for the current_time tool.
+type CurrentTimeInput struct {
+	Timezone string `json:"timezone,omitempty" jsonschema:"IANA timezone name such as America/New_York (default: UTC)"`
+	Format   string `json:"format,omitempty" jsonschema:"output format: rfc3339 (default), rfc3339nano, rfc1123, rfc822, kitchen, date, time, datetime, unix, unix_milli, or a Go layout string"`
+}
+
+// TimeOutput describes a single point in time.
+type TimeOutput struct {
+	Time     string `json:"time" jsonschema:"the formatted time"`
+	Timezone string `json:"timezone" jsonschema:"the IANA timezone the time is expressed in"`
+	Offset   string `json:"offset" jsonschema:"the UTC offset of the timezone at that instant, e.g. -05:00"`
+	Unix     int64  `json:"unix" jsonschema:"seconds since the Unix epoch"`
+}
+
+// ConvertTimeInput is the input for the convert_time tool.
+type ConvertTimeInput struct {
+	Time   string `json:"time" jsonschema:"the time to convert, as RFC 3339, a datetime (2006-01-02 15:04:05), a date, or Unix seconds"`
+	From   string `json:"from,omitempty" jsonschema:"IANA timezone used when the input carries no offset (default: UTC)"`
+	To     string `json:"to" jsonschema:"IANA timezone to convert into"`
+	Format string `json:"format,omitempty" jsonschema:"output format, same options as current_time"`
+}
+
+// AddDurationInput is the input for the add_duration tool.
+type AddDurationInput struct {
+	Time     string `json:"time,omitempty" jsonschema:"the starting time (default: now); same formats as convert_time"`
+	Duration string `json:"duration" jsonschema:"duration to add, e.g. 90m, 1h30m, 2d, -3d4h; days are 24 hours"`
+	Timezone string `json:"timezone,omitempty" jsonschema:"IANA timezone for parsing and output (default: UTC)"`
+	Format   string `json:"format,omitempty" jsonschema:"output format, same options as current_time"`
+}
+
+// TimeDiffInput is the input for the time_diff tool.
+type TimeDiffInput struct {
+	Start    string `json:"start" jsonschema:"the start time; same formats as convert_time"`
+	End      string `json:"end,omitempty" jsonschema:"the end time (default: now)"`
+	Timezone string `json:"timezone,omitempty" jsonschema:"IANA timezone used when inputs carry no offset (default: UTC)"`
+}
+
+// TimeDiffOutput is the output of the time_diff tool.
+type TimeDiffOutput struct {
+	Duration string  `json:"duration" jsonschema:"the difference as a Go duration string; negative when end is before start"`
+	Seconds  float64 `json:"seconds" jsonschema:"the difference in seconds"`
+	Hours    float64 `json:"hours" jsonschema:"the difference in hours"`
+	Days     float64 `json:"days" jsonschema:"the difference in days"`
+}
+
+// CurrentTime returns the current time in the requested timezone and format.
+func CurrentTime(_ context.Context, _ *mcp.CallToolRequest, input CurrentTimeInput) (*mcp.CallToolResult, TimeOutput, error) {
+	loc, err := loadLocation(input.Timezone)
+	if err != nil {
+		return nil, TimeOutput{}, err
+	}
+
+	logger.Info("tool called", "tool", "current_time", "timezone", loc.String())
+	return nil, newTimeOutput(time.Now().In(loc), input.Format), nil
+}
+
+// ConvertTime converts a time from one timezone to another.
+func ConvertTime(_ context.Context, _ *mcp.CallToolRequest, input ConvertTimeInput) (*mcp.CallToolResult, TimeOutput, error) {
+	if input.To == "" {
+		return nil, TimeOutput{}, tools.NewError(tools.CodeInvalidArgument, "to is required")
+	}
+
+	from, err := loadLocation(input.From)
+	if err != nil {
+		return nil, TimeOutput{}, err
+	}
+	to, err := loadLocation(input.To)
+	if err != nil {
+		return nil, TimeOutput{}, err
+	}
+
+	t, err := parseTime(input.Time, from)
+	if err != nil {
+		return nil, TimeOutput{}, err
+	}
+
+	logger.Info("tool called", "tool", "convert_time", "from", from.String(), "to", to.String())
+	return nil, newTimeOutput(t.In(to), input.Format), nil
+}
+
+// AddDuration adds a (possibly negative) duration to a time.
+func AddDuration(_ context.Context, _ *mcp.CallToolRequest, input AddDurationInput) (*mcp.CallToolResult, TimeOutput, error) {
+	loc, err := loadLocation(input.Timezone)
+	if err != nil {
+		return nil, TimeOutput{}, err
+	}
+
+	start := time.Now().In(loc)
+	if input.Time != "" {
+		if start, err = parseTime(input.Time, loc); err != nil {
+			return nil, TimeOutput{}, err
+		}
+	}
+
+	d, err := parseDuration(input.Duration)
+	if err != nil {
+		return nil, TimeOutput{}, err
+	}
+
+	logger.Info("tool called", "tool", "add_duration", "duration", d.String())
+	return nil, newTimeOutput(start.Add(d).In(loc), input.Format), nil
+}
+
+// TimeDiff computes the duration between two times.
+func TimeDiff(_ context.Context, _ *mcp.CallToolRequest, input TimeDiffInput) (*mcp.CallToolResult, TimeDiffOutput, error) {
+	loc, err := loadLocation(input.Timezone)
+	if err != nil {
+		return nil, TimeDiffOutput{}, err
+	}
+
+	start, err := parseTime(input.Start, loc)
+	if err != nil {
+		return nil, TimeDiffOutput{}, err
+	}
+
+	end := time.Now()
+	if input.End != "" {
+		if end, err = parseTime(input.End, loc); err != nil {
+			return nil, TimeDiffOutput{}, err
+		}
+	}
+
+	d := end.Sub(start)
+	logger.Info("tool called", "tool", "time_diff", "duration", d.String())
+	return nil, TimeDiffOutput{
+		Duration: d.String(),
+		Seconds:  d.Seconds(),
+		Hours:    d.Hours(),
+		Days:     d.Hours() / 24,
+	}, nil
+}
+
+// newTimeOutput builds a TimeOutput for t using the requested format.
+func newTimeOutput(t time.Time, format string) TimeOutput {
+	return TimeOutput{
+		Time:     formatTime(t, format),
+		Timezone: t.Location().String(),
+		Offset:   t.Format("-07:00"),
+		Unix:     t.Unix(),
+	}
+}
+
+// loadLocation resolves an IANA timezone name, defaulting to UTC.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, tools.NewError(tools.CodeInvalidArgument, "unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// formatTime renders t using a named format, a Unix variant, or a Go layout.
+func formatTime(t time.Time, format string) string {
+	switch strings.ToLower(format) {
+	case "":
+		return t.Format(time.RFC3339)
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unix_milli":
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	if layout, ok := namedFormats[strings.ToLower(format)]; ok {
+		return t.Format(layout)
+	}
+	return t.Format(format)
+}
+
+// parseTime parses value in one of the supported input formats. Inputs
+// without an explicit offset are interpreted in loc.
+func parseTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, tools.NewError(tools.CodeInvalidArgument, "time is required")
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{time.DateTime, "2006-01-02T15:04:05", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0).In(loc), nil
+	}
+
+	return time.Time{}, tools.NewError(tools.CodeInvalidArgument, "unrecognized time %q: use RFC 3339, 2006-01-02 15:04:05, 2006-01-02, or Unix seconds", value)
+}
+
+// parseDuration extends time.ParseDuration with a leading day component,
+// e.g. "2d", "1d12h" or "-3d4h".
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, tools.NewError(tools.CodeInvalidArgument, "duration is required")
+	}
+
+	sign := time.Duration(1)
+	rest := value
+	if strings.HasPrefix(rest, "-") {
+		sign, rest = -1, rest[1:]
+	} else if strings.HasPrefix(rest, "+") {
+		rest = rest[1:]
+	}
+
+	var days time.Duration
+	if i := strings.Index(rest, "d"); i > 0 {
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return 0, tools.NewError(tools.CodeInvalidArgument, "invalid duration %q", value)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		rest = rest[i+1:]
+	}
+
+	var d time.Duration
+	if rest != "" {
+		var err error
+		if d, err = time.ParseDuration(rest); err != nil || d < 0 {
+			return 0, tools.NewError(tools.CodeInvalidArgument, "invalid duration %q", value)
+		}
+	}
+
+	return sign * (days + d), nil
+}
+
+// zoneDirs are where zone names are listed from; the embedded database
+// cannot be listed.
+var zoneDirs = []string{"/usr/share/zoneinfo", "/usr/lib/zoneinfo", "/usr/share/lib/zoneinfo"}
+
+// commonZones are suggested when no zone directory is installed.
+var commonZones = []string{
+	"UTC",
+	"Africa/Cairo", "Africa/Johannesburg", "Africa/Lagos", "Africa/Nairobi",
+	"America/Anchorage", "America/Bogota", "America/Chicago", "America/Denver",
+	"America/Los_Angeles", "America/Mexico_City", "America/New_York",
+	"America/Phoenix", "America/Sao_Paulo", "America/Toronto",
+	"Asia/Bangkok", "Asia/Dubai", "Asia/Hong_Kong", "Asia/Jakarta",
+	"Asia/Kolkata", "Asia/Seoul", "Asia/Shanghai", "Asia/Singapore",
+	"Asia/Tokyo", "Atlantic/Reykjavik", "Australia/Adelaide",
+	"Australia/Brisbane", "Australia/Perth", "Australia/Sydney",
+	"Europe/Amsterdam", "Europe/Berlin", "Europe/Istanbul", "Europe/London",
+	"Europe/Madrid", "Europe/Moscow", "Europe/Paris", "Europe/Rome",
+	"Pacific/Auckland", "Pacific/Honolulu",
+}
+
+// zoneNames lists the IANA zone names installed on the host, sorted, or
+// commonZones if there are none.
+var zoneNames = sync.OnceValue(func() []string {
+	dirs := zoneDirs
+	if dir := os.Getenv("ZONEINFO"); dir != "" {
+		dirs = append([]string{dir}, dirs...)
+	}
+	for _, dir := range dirs {
+		if names := listZones(dir); len(names) > 0 {
+			return names
+		}
+	}
+	return commonZones
+})
+
+// listZones returns the zone names in the zoneinfo directory dir, sorted.
+func listZones(dir string) []string {
+	var names []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return nil
+		}
+		name := filepath.ToSlash(strings.TrimPrefix(path, dir+string(filepath.Separator)))
+		// Zones are capitalized; posix/ and right/ are alternative copies,
+		// and files with extensions are tables
+		if r := name[0]; r < 'A' || r > 'Z' || strings.Contains(name, ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names
+}
+
+// completeZone suggests installed zone names for the value typed.
+func completeZone(_ context.Context, value string, _ map[string]string) ([]string, error) {
+	return matchZones(zoneNames(), value), nil
+}
+
+// matchZones returns the names that start with value, ignoring case. A
+// value without a slash also matches the city, so "tok" finds Asia/Tokyo.
+func matchZones(names []string, value string) []string {
+	matches := tools.MatchPrefix(names, value)
+	if value == "" || strings.Contains(value, "/") {
+		return matches
+	}
+	lower := strings.ToLower(value)
+	for _, name := range names {
+		_, city, ok := strings.Cut(name, "/")
+		if ok && strings.HasPrefix(strings.ToLower(city), lower) && !strings.HasPrefix(strings.ToLower(name), lower) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+
+// ConvertTimePrompt asks the model to convert a time between timezones
+// with the convert_time tool.
+func ConvertTimePrompt(_ context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := req.Params.Arguments
+	from := args["from"]
+	if from == "" {
+		from = "UTC"
+	}
+	text := fmt.Sprintf("Use the convert_time tool to convert %s from %s to %s", args["time"], from, args["to"])
+	if args["format"] != "" {
+		text += " in the " + args["format"] + " format"
+	}
+	return &mcp.GetPromptResult{
+		Description: "Convert a time between timezones",
+		Messages:    []*mcp.PromptMessage{{Role: "user", Content: &mcp.TextContent{Text: text + "."}}},
+	}, nil
+}
+
+func init() {
+	tools.Register(tools.Namespace("util", func(server *mcp.Server) {
+		server.AddPrompt(&mcp.Prompt{
+			Name:        "convert_time",
+			Description: "Convert a time from one IANA timezone to another",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "time", Description: "the time to convert", Required: true},
+				{Name: "from", Description: "IANA timezone of the time (default: UTC)"},
+				{Name: "to", Description: "IANA timezone to convert into", Required: true},
+				{Name: "format", Description: "output format, e.g. rfc3339 or kitchen"},
+			},
+		}, ConvertTimePrompt)
+		tools.CompletePrompt("convert_time", "from", completeZone)
+		tools.CompletePrompt("convert_time", "to", completeZone)
+		tools.CompletePrompt("convert_time", "format", tools.Values(formatNames()...))
+
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "current_time",
+			Description: "Get the current time in a given IANA timezone and format",
+			Annotations: tools.ReadOnly(false),
+		}, CurrentTime)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "convert_time",
+			Description: "Convert a time from one IANA timezone to another",
+			Annotations: tools.ReadOnly(false),
+		}, ConvertTime)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "add_duration",
+			Description: "Add or subtract a duration (e.g. 1h30m, 2d, -45m) to a time",
+			Annotations: tools.ReadOnly(false),
+		}, AddDuration)
+		tools.AddTool(server, &mcp.Tool{
+			Name:        "time_diff",
+			Description: "Compute the duration between two times",
+			Annotations: tools.ReadOnly(false),
+		}, TimeDiff)
+	}))
+}
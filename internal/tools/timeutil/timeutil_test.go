@@ -0,0 +1,302 @@
+package timeutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+func TestCurrentTime(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        CurrentTimeInput
+		wantTimezone string
+		wantErr      bool
+	}{
+		{name: "default UTC", input: CurrentTimeInput{}, wantTimezone: "UTC"},
+		{name: "named timezone", input: CurrentTimeInput{Timezone: "Asia/Tokyo"}, wantTimezone: "Asia/Tokyo"},
+		{name: "unix format", input: CurrentTimeInput{Format: "unix"}, wantTimezone: "UTC"},
+		{name: "unknown timezone", input: CurrentTimeInput{Timezone: "Mars/Olympus"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := CurrentTime(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CurrentTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if out.Timezone != tt.wantTimezone {
+				t.Errorf("Timezone = %q, want %q", out.Timezone, tt.wantTimezone)
+			}
+			if out.Time == "" || out.Unix == 0 {
+				t.Errorf("expected populated output, got %+v", out)
+			}
+		})
+	}
+}
+
+func TestConvertTime(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      ConvertTimeInput
+		wantTime   string
+		wantOffset string
+		wantErr    bool
+	}{
+		{
+			name:       "rfc3339 to new york",
+			input:      ConvertTimeInput{Time: "2024-01-15T12:00:00Z", To: "America/New_York"},
+			wantTime:   "2024-01-15T07:00:00-05:00",
+			wantOffset: "-05:00",
+		},
+		{
+			name:       "naive datetime with from zone",
+			input:      ConvertTimeInput{Time: "2024-07-01 09:30:00", From: "Europe/Berlin", To: "UTC"},
+			wantTime:   "2024-07-01T07:30:00Z",
+			wantOffset: "+00:00",
+		},
+		{
+			name:       "unix seconds with custom format",
+			input:      ConvertTimeInput{Time: "0", To: "UTC", Format: "date"},
+			wantTime:   "1970-01-01",
+			wantOffset: "+00:00",
+		},
+		{name: "missing to", input: ConvertTimeInput{Time: "2024-01-15T12:00:00Z"}, wantErr: true},
+		{name: "bad time", input: ConvertTimeInput{Time: "yesterday", To: "UTC"}, wantErr: true},
+		{name: "bad zone", input: ConvertTimeInput{Time: "2024-01-15T12:00:00Z", To: "Nowhere/City"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := ConvertTime(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if code := tools.AsError(err).Code; code != tools.CodeInvalidArgument {
+					t.Errorf("ConvertTime() error code = %s, want %s", code, tools.CodeInvalidArgument)
+				}
+				return
+			}
+			if out.Time != tt.wantTime {
+				t.Errorf("Time = %q, want %q", out.Time, tt.wantTime)
+			}
+			if out.Offset != tt.wantOffset {
+				t.Errorf("Offset = %q, want %q", out.Offset, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestAddDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    AddDurationInput
+		wantTime string
+		wantErr  bool
+	}{
+		{
+			name:     "add hours and minutes",
+			input:    AddDurationInput{Time: "2024-01-15T12:00:00Z", Duration: "1h30m"},
+			wantTime: "2024-01-15T13:30:00Z",
+		},
+		{
+			name:     "add days",
+			input:    AddDurationInput{Time: "2024-02-28T00:00:00Z", Duration: "2d"},
+			wantTime: "2024-03-01T00:00:00Z",
+		},
+		{
+			name:     "subtract days and hours",
+			input:    AddDurationInput{Time: "2024-01-15T12:00:00Z", Duration: "-1d2h"},
+			wantTime: "2024-01-14T10:00:00Z",
+		},
+		{name: "missing duration", input: AddDurationInput{Time: "2024-01-15T12:00:00Z"}, wantErr: true},
+		{name: "invalid duration", input: AddDurationInput{Duration: "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := AddDuration(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddDuration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && out.Time != tt.wantTime {
+				t.Errorf("Time = %q, want %q", out.Time, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestTimeDiff(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        TimeDiffInput
+		wantDuration string
+		wantDays     float64
+		wantErr      bool
+	}{
+		{
+			name:         "two days",
+			input:        TimeDiffInput{Start: "2024-01-01", End: "2024-01-03"},
+			wantDuration: "48h0m0s",
+			wantDays:     2,
+		},
+		{
+			name:         "negative difference",
+			input:        TimeDiffInput{Start: "2024-01-01T01:00:00Z", End: "2024-01-01T00:00:00Z"},
+			wantDuration: "-1h0m0s",
+			wantDays:     -1.0 / 24,
+		},
+		{name: "missing start", input: TimeDiffInput{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, out, err := TimeDiff(context.Background(), &mcp.CallToolRequest{}, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TimeDiff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if out.Duration != tt.wantDuration {
+				t.Errorf("Duration = %q, want %q", out.Duration, tt.wantDuration)
+			}
+			if out.Days != tt.wantDays {
+				t.Errorf("Days = %v, want %v", out.Days, tt.wantDays)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "90m", want: 90 * time.Minute},
+		{value: "1d", want: 24 * time.Hour},
+		{value: "+1d1h", want: 25 * time.Hour},
+		{value: "-2d", want: -48 * time.Hour},
+		{value: "", wantErr: true},
+		{value: "xd", wantErr: true},
+		{value: "1d-1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDuration(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDuration(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInit_RegistersTools(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	tools.RegisterAll(server)
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	res, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+
+	registered := make(map[string]bool)
+	for _, tool := range res.Tools {
+		registered[tool.Name] = true
+	}
+	for _, name := range []string{"current_time", "convert_time", "add_duration", "time_diff"} {
+		if !registered[name] {
+			t.Errorf("tool %q not registered", name)
+		}
+	}
+
+	prompts, err := session.ListPrompts(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListPrompts: %v", err)
+	}
+	if len(prompts.Prompts) != 1 || prompts.Prompts[0].Name != "convert_time" {
+		t.Errorf("prompts = %+v, want convert_time", prompts.Prompts)
+	}
+}
+
+func TestListZones(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"UTC", "Asia/Tokyo", "America/Argentina/Salta", "posix/Asia/Tokyo", "zone.tab", "Etc/+VERSION.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"America/Argentina/Salta", "Asia/Tokyo", "UTC"}
+	if got := listZones(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("listZones() = %v, want %v", got, want)
+	}
+	if got := listZones(filepath.Join(dir, "missing")); got != nil {
+		t.Errorf("listZones(missing) = %v, want nil", got)
+	}
+}
+
+func TestMatchZones(t *testing.T) {
+	names := []string{"America/New_York", "Asia/Tokyo", "Europe/Amsterdam", "UTC"}
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: "a", want: []string{"America/New_York", "Asia/Tokyo", "Europe/Amsterdam"}},
+		{value: "tok", want: []string{"Asia/Tokyo"}},
+		{value: "am", want: []string{"America/New_York", "Europe/Amsterdam"}},
+		{value: "asia/t", want: []string{"Asia/Tokyo"}},
+		{value: "Europe/X", want: nil},
+		{value: "", want: names},
+	}
+	for _, tt := range tests {
+		if got := matchZones(names, tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchZones(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestConvertTimePrompt(t *testing.T) {
+	res, err := ConvertTimePrompt(context.Background(), &mcp.GetPromptRequest{Params: &mcp.GetPromptParams{
+		Name:      "convert_time",
+		Arguments: map[string]string{"time": "09:00", "to": "Asia/Tokyo", "format": "kitchen"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Use the convert_time tool to convert 09:00 from UTC to Asia/Tokyo in the kitchen format."
+	if len(res.Messages) != 1 || res.Messages[0].Content.(*mcp.TextContent).Text != want {
+		t.Errorf("messages = %+v, want %q", res.Messages, want)
+	}
+}
@@ -13,26 +13,42 @@ import (
 
 var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
 
-// Input is the input for the UUID generator (empty, no parameters needed).
-type Input struct{}
+// Input is the input for the UUID generator.
+type Input struct {
+	Version int `json:"version,omitempty" jsonschema:"UUID version: 4 (random, default) or 7 (time-ordered)"`
+}
 
 // Output is the output of the UUID generator.
 type Output struct {
-	UUID string `json:"uuid" jsonschema:"the generated UUID v4"`
+	UUID string `json:"uuid" jsonschema:"the generated UUID"`
 }
 
-// GenerateUUID generates a new UUID v4.
-func GenerateUUID(_ context.Context, _ *mcp.CallToolRequest, _ Input) (*mcp.CallToolResult, Output, error) {
-	result := uuid.New().String()
+// GenerateUUID generates a new UUID v4, or v7 when requested.
+func GenerateUUID(_ context.Context, _ *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+	var id uuid.UUID
+	switch input.Version {
+	case 0, 4:
+		id = uuid.New()
+	case 7:
+		var err error
+		if id, err = uuid.NewV7(); err != nil {
+			return nil, Output{}, tools.WrapError(tools.CodeInternal, err, "failed to generate UUID v7")
+		}
+	default:
+		return nil, Output{}, tools.NewError(tools.CodeInvalidArgument, "unsupported UUID version %d: use 4 or 7", input.Version)
+	}
+
+	result := id.String()
 	logger.Info("tool called", "tool", "generate_uuid", "uuid", result)
 	return nil, Output{UUID: result}, nil
 }
 
 func init() {
-	tools.Register(func(server *mcp.Server) {
-		mcp.AddTool(server, &mcp.Tool{
+	tools.Register(tools.Namespace("util", func(server *mcp.Server) {
+		tools.AddTool(server, &mcp.Tool{
 			Name:        "generate_uuid",
-			Description: "Generate a new UUID v4",
+			Description: "Generate a new UUID (v4 random by default, or v7 time-ordered)",
+			Annotations: tools.ReadOnly(false),
 		}, GenerateUUID)
-	})
+	}))
 }
@@ -86,6 +86,37 @@ func TestGenerateUUID_OutputStructure(t *testing.T) {
 	}
 }
 
+func TestGenerateUUID_Version(t *testing.T) {
+	uuidV7Regex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	tests := []struct {
+		name    string
+		version int
+		pattern *regexp.Regexp
+		wantErr bool
+	}{
+		{name: "default is v4", version: 0, pattern: uuidV4Regex},
+		{name: "explicit v4", version: 4, pattern: uuidV4Regex},
+		{name: "v7", version: 7, pattern: uuidV7Regex},
+		{name: "unsupported version", version: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, output, err := GenerateUUID(context.Background(), &mcp.CallToolRequest{}, Input{Version: tt.version})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateUUID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && tools.AsError(err).Code != tools.CodeInvalidArgument {
+				t.Errorf("GenerateUUID() error code = %s, want %s", tools.AsError(err).Code, tools.CodeInvalidArgument)
+			}
+			if !tt.wantErr && !tt.pattern.MatchString(output.UUID) {
+				t.Errorf("UUID %q does not match version %d format", output.UUID, tt.version)
+			}
+		})
+	}
+}
+
 func TestInit_RegistersTool(t *testing.T) {
 	// The init() function runs when the package is imported.
 	// We verify that it registered a tool by checking the Registry.
@@ -0,0 +1,270 @@
+// Package usage accounts tool calls per tenant, caller, and tool in daily
+// rollups, for chargeback. The Store is an events sink fed by tool_call.completed;
+// it can persist its rollups to a JSON file and serves them on the admin
+// API as JSON or CSV.
+package usage
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/events"
+)
+
+// dayLayout formats the day of a rollup.
+const dayLayout = "2006-01-02"
+
+// Anonymous is the caller of unauthenticated calls.
+const Anonymous = "anonymous"
+
+// Config configures usage accounting.
+type Config struct {
+	// Enabled turns accounting on.
+	Enabled bool
+	// File persists the rollups; empty keeps them in memory only.
+	File string
+	// RetentionDays is how many days of rollups are kept.
+	RetentionDays int
+	// FlushInterval is how often File is rewritten.
+	FlushInterval time.Duration
+}
+
+// LoadConfig reads the USAGE_* settings.
+func LoadConfig() Config {
+	return Config{
+		Enabled:       config.GetEnvBool("USAGE_ENABLED", false),
+		File:          config.GetEnv("USAGE_FILE", ""),
+		RetentionDays: config.GetEnvInt("USAGE_RETENTION_DAYS", 90),
+		FlushInterval: config.GetEnvDuration("USAGE_FLUSH_INTERVAL", time.Minute),
+	}
+}
+
+// Record is the usage of one tool by one caller on one day (UTC). Tenant
+// is empty unless tenants are configured.
+type Record struct {
+	Day       string `json:"day"`
+	Tenant    string `json:"tenant,omitempty"`
+	Caller    string `json:"caller"`
+	Tool      string `json:"tool"`
+	Calls     int64  `json:"calls"`
+	Errors    int64  `json:"errors"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+type key struct {
+	day, tenant, caller, tool string
+}
+
+// Store holds the daily rollups.
+type Store struct {
+	cfg    Config
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	records map[key]*Record
+	dirty   bool
+}
+
+// Open returns a Store for cfg, loading cfg.File when it exists, or nil
+// when accounting is disabled.
+func Open(cfg Config, logger *slog.Logger) (*Store, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	s := &Store{cfg: cfg, logger: logger, now: time.Now, records: make(map[key]*Record)}
+	if cfg.File == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(cfg.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("usage: %w", err)
+	}
+	var records []Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("usage: parsing %s: %w", cfg.File, err)
+	}
+	for _, r := range records {
+		s.records[key{r.Day, r.Tenant, r.Caller, r.Tool}] = &r
+	}
+	return s, nil
+}
+
+// Publish accounts tool_call.completed events.
+func (s *Store) Publish(e events.Event) {
+	if e.Type != events.ToolCallCompleted {
+		return
+	}
+	tool, _ := e.Data["tool"].(string)
+	tenant, _ := e.Data["tenant"].(string)
+	caller, _ := e.Data["caller"].(string)
+	ms, _ := e.Data["duration_ms"].(int64)
+	failed, _ := e.Data["is_error"].(bool)
+	s.Add(e.Time, tenant, caller, tool, time.Duration(ms)*time.Millisecond, failed)
+}
+
+// Add accounts a call of tool by caller of tenant at t that took d.
+func (s *Store) Add(t time.Time, tenant, caller, tool string, d time.Duration, failed bool) {
+	k := key{t.UTC().Format(dayLayout), tenant, cmp.Or(caller, Anonymous), tool}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[k]
+	if !ok {
+		r = &Record{Day: k.day, Tenant: k.tenant, Caller: k.caller, Tool: k.tool}
+		s.records[k] = r
+	}
+	r.Calls++
+	r.LatencyMS += d.Milliseconds()
+	if failed {
+		r.Errors++
+	}
+	s.dirty = true
+}
+
+// Query selects rollups. Empty fields match everything; From and To are
+// inclusive days.
+type Query struct {
+	From, To             string
+	Tenant, Caller, Tool string
+}
+
+// Records returns the rollups q selects, ordered by day, tenant, caller,
+// and tool.
+func (s *Store) Records(q Query) []Record {
+	s.mu.Lock()
+	list := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		if (q.From == "" || r.Day >= q.From) && (q.To == "" || r.Day <= q.To) &&
+			(q.Tenant == "" || r.Tenant == q.Tenant) && (q.Caller == "" || r.Caller == q.Caller) && (q.Tool == "" || r.Tool == q.Tool) {
+			list = append(list, *r)
+		}
+	}
+	s.mu.Unlock()
+
+	slices.SortFunc(list, func(a, b Record) int {
+		return cmp.Or(cmp.Compare(a.Day, b.Day), cmp.Compare(a.Tenant, b.Tenant), cmp.Compare(a.Caller, b.Caller), cmp.Compare(a.Tool, b.Tool))
+	})
+	return list
+}
+
+// prune drops rollups older than the retention. The caller must hold s.mu.
+func (s *Store) prune() {
+	if s.cfg.RetentionDays <= 0 {
+		return
+	}
+	oldest := s.now().UTC().AddDate(0, 0, 1-s.cfg.RetentionDays).Format(dayLayout)
+	for k := range s.records {
+		if k.day < oldest {
+			delete(s.records, k)
+			s.dirty = true
+		}
+	}
+}
+
+// Flush prunes expired rollups and, when they changed, rewrites the file.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	if s.cfg.File == "" || !s.dirty {
+		return nil
+	}
+
+	records := make([]*Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	// A temporary file renamed into place never leaves a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(s.cfg.File), ".usage-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.cfg.File); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// Run flushes the store every FlushInterval until ctx is done, and once
+// more before returning.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(cmp.Or(s.cfg.FlushInterval, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Flush(); err != nil {
+				s.logger.Error("usage flush failed", "file", s.cfg.File, "error", err)
+			}
+			return
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				s.logger.Warn("usage flush failed", "file", s.cfg.File, "error", err)
+			}
+		}
+	}
+}
+
+// Handler serves the rollups selected by the from, to, tenant, caller, and
+// tool query parameters, as JSON or, with format=csv, as CSV.
+func (s *Store) Handler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := Query{From: params.Get("from"), To: params.Get("to"), Tenant: params.Get("tenant"), Caller: params.Get("caller"), Tool: params.Get("tool")}
+	for _, day := range []string{q.From, q.To} {
+		if _, err := time.Parse(dayLayout, day); day != "" && err != nil {
+			http.Error(w, "from and to must be days like 2006-01-02", http.StatusBadRequest)
+			return
+		}
+	}
+	records := s.Records(q)
+
+	switch params.Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]any{"usage": records})
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="usage.csv"`)
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"day", "tenant", "caller", "tool", "calls", "errors", "latency_ms"})
+		for _, rec := range records {
+			_ = cw.Write([]string{rec.Day, rec.Tenant, rec.Caller, rec.Tool,
+				strconv.FormatInt(rec.Calls, 10), strconv.FormatInt(rec.Errors, 10), strconv.FormatInt(rec.LatencyMS, 10)})
+		}
+		cw.Flush()
+	default:
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,162 @@
+package usage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/events"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestOpenDisabled(t *testing.T) {
+	s, err := Open(Config{}, discard)
+	if s != nil || err != nil {
+		t.Errorf("Open(disabled) = %v, %v, want nil, nil", s, err)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	s, _ := Open(Config{Enabled: true}, discard)
+	day := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	for _, e := range []events.Event{
+		{Type: events.ToolCallCompleted, Time: day, Data: map[string]any{"tool": "uuid", "caller": "api_key:aaa", "duration_ms": int64(10), "is_error": false}},
+		{Type: events.ToolCallCompleted, Time: day, Data: map[string]any{"tool": "uuid", "caller": "api_key:aaa", "duration_ms": int64(30), "is_error": true}},
+		{Type: events.ToolCallCompleted, Time: day.Add(2 * time.Hour), Data: map[string]any{"tool": "uuid", "duration_ms": int64(5), "is_error": false}},
+		{Type: events.ToolCallCompleted, Time: day, Data: map[string]any{"tool": "uuid", "tenant": "ops", "caller": "api_key:aaa", "duration_ms": int64(7), "is_error": false}},
+		{Type: events.ToolCallStarted, Time: day, Data: map[string]any{"tool": "uuid", "caller": "api_key:aaa"}},
+	} {
+		s.Publish(e)
+	}
+
+	got := s.Records(Query{})
+	want := []Record{
+		{Day: "2026-03-01", Caller: "api_key:aaa", Tool: "uuid", Calls: 2, Errors: 1, LatencyMS: 40},
+		{Day: "2026-03-01", Tenant: "ops", Caller: "api_key:aaa", Tool: "uuid", Calls: 1, LatencyMS: 7},
+		{Day: "2026-03-02", Caller: Anonymous, Tool: "uuid", Calls: 1, LatencyMS: 5},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("records = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRecords(t *testing.T) {
+	s, _ := Open(Config{Enabled: true}, discard)
+	for _, c := range []struct {
+		day                  string
+		tenant, caller, tool string
+	}{
+		{"2026-03-01", "", "a", "uuid"},
+		{"2026-03-02", "", "a", "hash"},
+		{"2026-03-02", "ops", "b", "uuid"},
+		{"2026-03-03", "ops", "b", "uuid"},
+	} {
+		t0, _ := time.Parse(dayLayout, c.day)
+		s.Add(t0, c.tenant, c.caller, c.tool, time.Millisecond, false)
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want int
+	}{
+		{name: "all", q: Query{}, want: 4},
+		{name: "from", q: Query{From: "2026-03-02"}, want: 3},
+		{name: "range", q: Query{From: "2026-03-02", To: "2026-03-02"}, want: 2},
+		{name: "caller", q: Query{Caller: "b"}, want: 2},
+		{name: "tenant", q: Query{Tenant: "ops", From: "2026-03-03"}, want: 1},
+		{name: "tool", q: Query{Tool: "uuid", To: "2026-03-02"}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Records(tt.q); len(got) != tt.want {
+				t.Errorf("Records(%+v) = %+v, want %d records", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlushAndReopen(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "usage.json")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cfg := Config{Enabled: true, File: file, RetentionDays: 7}
+
+	s, err := Open(cfg, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return now }
+	s.Add(now, "", "a", "uuid", 20*time.Millisecond, false)
+	s.Add(now.AddDate(0, 0, -6), "", "a", "uuid", time.Millisecond, false)
+	s.Add(now.AddDate(0, 0, -7), "", "a", "uuid", time.Millisecond, false)
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(cfg, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reopened.Records(Query{})
+	if len(got) != 2 || got[0].Day != "2026-03-04" || got[1] != (Record{Day: "2026-03-10", Caller: "a", Tool: "uuid", Calls: 1, LatencyMS: 20}) {
+		t.Errorf("reopened records = %+v, want the two days within retention", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s, _ := Open(Config{Enabled: true}, discard)
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.Add(day, "ops", "api_key:aaa", "uuid", 15*time.Millisecond, true)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantType   string
+	}{
+		{name: "json", query: "", wantStatus: http.StatusOK, wantType: "application/json"},
+		{name: "csv", query: "?format=csv", wantStatus: http.StatusOK, wantType: "text/csv"},
+		{name: "bad format", query: "?format=xml", wantStatus: http.StatusBadRequest},
+		{name: "bad day", query: "?from=yesterday", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.Handler(rec, httptest.NewRequest(http.MethodGet, "/admin/usage"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.wantType)
+			}
+			switch tt.wantType {
+			case "application/json":
+				var body struct{ Usage []Record }
+				if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || len(body.Usage) != 1 || body.Usage[0].Errors != 1 {
+					t.Errorf("body = %+v, %v", body, err)
+				}
+			case "text/csv":
+				rows, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+				if err != nil || len(rows) != 2 || strings.Join(rows[1], ",") != "2026-03-01,ops,api_key:aaa,uuid,1,1,15" {
+					t.Errorf("csv = %q, %v", rows, err)
+				}
+			}
+		})
+	}
+}
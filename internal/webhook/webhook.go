@@ -0,0 +1,249 @@
+// Package webhook posts server events to configured URLs. The Dispatcher
+// is an events.Sink; deliveries are queued so that publishers never wait
+// on the network, signed with an HMAC
+// of the body when a secret is set, and retried with backoff on network
+// errors and 5xx or 429 responses.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/events"
+)
+
+// Headers of a delivery. HeaderSignature is "sha256=" and the hex
+// HMAC-SHA256, keyed with the secret, of HeaderTimestamp, a period, and
+// the body.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderID        = "X-Webhook-ID"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+var (
+	Deliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_deliveries_total",
+			Help: "Total number of webhook deliveries, by event type and result (delivered, failed, dropped).",
+		},
+		[]string{"event", "result"},
+	)
+
+	DeliveryAttempts = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "webhook_delivery_attempts_total",
+			Help: "Total number of webhook requests, including retries.",
+		},
+	)
+
+	DeliveryDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name: "webhook_delivery_duration_seconds",
+			Help: "Time from queueing a webhook delivery to its success or final failure.",
+		},
+	)
+)
+
+// Config selects where and which events are posted.
+type Config struct {
+	// URLs receive every event.
+	URLs []string
+	// Secret signs deliveries; empty sends them unsigned.
+	Secret string
+	// Events are the event types to subscribe to; empty sends all.
+	Events []string
+	// Retries is how many times a failed delivery is repeated.
+	Retries int
+	// Timeout bounds each request.
+	Timeout time.Duration
+	// QueueSize is how many events may wait for delivery; further events
+	// are dropped.
+	QueueSize int
+}
+
+// LoadConfig reads the WEBHOOK_* settings.
+func LoadConfig() Config {
+	return Config{
+		URLs:      config.GetEnvList("WEBHOOK_URLS"),
+		Secret:    config.GetEnv("WEBHOOK_SECRET", ""),
+		Events:    config.GetEnvList("WEBHOOK_EVENTS"),
+		Retries:   config.GetEnvInt("WEBHOOK_RETRIES", 3),
+		Timeout:   config.GetEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		QueueSize: config.GetEnvInt("WEBHOOK_QUEUE_SIZE", 1000),
+	}
+}
+
+type delivery struct {
+	event  events.Event
+	queued time.Time
+}
+
+// Dispatcher queues events and delivers them, as their JSON encoding, to
+// the configured URLs.
+type Dispatcher struct {
+	cfg     Config
+	client  *http.Client
+	logger  *slog.Logger
+	queue   chan delivery
+	backoff time.Duration
+}
+
+// New returns a Dispatcher for cfg, or nil when no URL is configured.
+func New(cfg Config, logger *slog.Logger) (*Dispatcher, error) {
+	if len(cfg.URLs) == 0 {
+		return nil, nil
+	}
+	if err := events.ValidateTypes(cfg.Events); err != nil {
+		return nil, fmt.Errorf("WEBHOOK_EVENTS: %w", err)
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	return &Dispatcher{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		logger:  logger,
+		queue:   make(chan delivery, max(cfg.QueueSize, 1)),
+		backoff: time.Second,
+	}, nil
+}
+
+// Events returns the event types d should be subscribed to.
+func (d *Dispatcher) Events() []string {
+	return d.cfg.Events
+}
+
+// Publish queues e for delivery. It never blocks: when the queue is full
+// the event is dropped and counted.
+func (d *Dispatcher) Publish(e events.Event) {
+	select {
+	case d.queue <- delivery{event: e, queued: time.Now()}:
+	default:
+		Deliveries.WithLabelValues(e.Type, "dropped").Inc()
+		d.logger.Warn("webhook queue full; event dropped", "event", e.Type)
+	}
+}
+
+// Run delivers queued events, one at a time, until ctx is done. It then
+// spends up to the request timeout delivering the events still queued. A
+// delivery under way when ctx is done gets up to the request timeout to
+// finish too, so the last event published before shutdown is not lost.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			d.flush(ctx)
+			return
+		case dl := <-d.queue:
+			dctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			stop := context.AfterFunc(ctx, func() { time.AfterFunc(d.cfg.Timeout, cancel) })
+			d.deliver(dctx, dl)
+			stop()
+			cancel()
+		}
+	}
+}
+
+func (d *Dispatcher) flush(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.cfg.Timeout)
+	defer cancel()
+	for {
+		select {
+		case dl := <-d.queue:
+			d.deliver(ctx, dl)
+		default:
+			return
+		}
+	}
+}
+
+// deliver posts dl to every URL.
+func (d *Dispatcher) deliver(ctx context.Context, dl delivery) {
+	body, err := json.Marshal(dl.event)
+	if err != nil {
+		d.logger.Error("webhook event not encodable", "event", dl.event.Type, "error", err)
+		return
+	}
+	for _, url := range d.cfg.URLs {
+		result := "delivered"
+		if err := d.post(ctx, url, dl.event, body); err != nil {
+			result = "failed"
+			d.logger.Warn("webhook delivery failed", "event", dl.event.Type, "id", dl.event.ID, "url", url, "error", err)
+		}
+		Deliveries.WithLabelValues(dl.event.Type, result).Inc()
+	}
+	DeliveryDuration.Observe(time.Since(dl.queued).Seconds())
+}
+
+// post sends body to url, retrying with exponential backoff.
+func (d *Dispatcher) post(ctx context.Context, url string, e events.Event, body []byte) error {
+	var err error
+	for attempt := 0; attempt <= d.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(d.backoff << (attempt - 1)):
+			}
+		}
+		var retry bool
+		if retry, err = d.attempt(ctx, url, e, body); err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, url string, e events.Event, body []byte) (retry bool, err error) {
+	DeliveryAttempts.Inc()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	ts := strconv.FormatInt(e.Time.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, e.Type)
+	req.Header.Set(HeaderID, e.ID)
+	req.Header.Set(HeaderTimestamp, ts)
+	if d.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(d.cfg.Secret, ts, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("status %d", resp.StatusCode)
+	}
+}
+
+// Sign returns the HeaderSignature value of body sent at timestamp ts.
+// Receivers recompute it and compare with hmac.Equal.
+func Sign(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lkendrickd/mcp-server/internal/events"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "no urls", cfg: Config{}, wantNil: true},
+		{name: "urls", cfg: Config{URLs: []string{"http://hooks.example.com"}}},
+		{name: "known events", cfg: Config{URLs: []string{"http://hooks.example.com"}, Events: []string{events.ServerStarted, events.SessionClosed}}},
+		{name: "unknown event", cfg: Config{URLs: []string{"http://hooks.example.com"}, Events: []string{"server.exploded"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := New(tt.cfg, discard)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (d == nil) != tt.wantNil {
+				t.Errorf("New = %v, want nil %v", d, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retries      int
+		wantAttempts int32
+		wantResult   string
+	}{
+		{name: "delivered", statuses: []int{http.StatusNoContent}, retries: 3, wantAttempts: 1, wantResult: "delivered"},
+		{name: "retried after 5xx", statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK}, retries: 3, wantAttempts: 3, wantResult: "delivered"},
+		{name: "retries exhausted", statuses: []int{http.StatusServiceUnavailable}, retries: 2, wantAttempts: 3, wantResult: "failed"},
+		{name: "4xx not retried", statuses: []int{http.StatusBadRequest}, retries: 3, wantAttempts: 1, wantResult: "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			var got events.Event
+			var header http.Header
+			hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				body, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &got)
+				header = r.Header.Clone()
+				if r.Header.Get(HeaderSignature) != Sign("s3cret", r.Header.Get(HeaderTimestamp), body) {
+					t.Errorf("signature %q does not match the body", r.Header.Get(HeaderSignature))
+				}
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer hook.Close()
+
+			d, _ := New(Config{URLs: []string{hook.URL}, Secret: "s3cret", Retries: tt.retries, Timeout: time.Second, QueueSize: 10}, discard)
+			d.backoff = time.Millisecond
+			before := testutil.ToFloat64(Deliveries.WithLabelValues(events.ToolCallFailed, tt.wantResult))
+
+			d.Publish(events.Event{ID: "1", Type: events.ToolCallFailed, Data: map[string]any{"tool": "exec"}})
+			d.deliver(context.Background(), <-d.queue)
+
+			if n := attempts.Load(); n != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", n, tt.wantAttempts)
+			}
+			if got.Type != events.ToolCallFailed || got.Data["tool"] != "exec" || got.ID != "1" {
+				t.Errorf("event = %+v", got)
+			}
+			if header.Get(HeaderEvent) != events.ToolCallFailed || header.Get(HeaderID) != got.ID {
+				t.Errorf("headers = %v", header)
+			}
+			if after := testutil.ToFloat64(Deliveries.WithLabelValues(events.ToolCallFailed, tt.wantResult)); after != before+1 {
+				t.Errorf("%s deliveries = %v, want %v", tt.wantResult, after, before+1)
+			}
+		})
+	}
+}
+
+func TestRunFlushesOnShutdown(t *testing.T) {
+	received := make(chan string, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(HeaderEvent)
+	}))
+	defer hook.Close()
+
+	d, _ := New(Config{URLs: []string{hook.URL}, Timeout: time.Second, QueueSize: 10}, discard)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	d.Publish(events.Event{Type: events.ServerStarted})
+	if got := <-received; got != events.ServerStarted {
+		t.Errorf("event = %q, want %q", got, events.ServerStarted)
+	}
+
+	cancel()
+	<-done
+	// Queued after Run stopped, so only a second flush delivers it
+	d.Publish(events.Event{Type: events.SessionClosed})
+	d.flush(ctx)
+	if got := <-received; got != events.SessionClosed {
+		t.Errorf("event = %q, want %q", got, events.SessionClosed)
+	}
+}
+
+func TestRunFinishesDeliveryOnShutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	defer hook.Close()
+
+	d, _ := New(Config{URLs: []string{hook.URL}, Timeout: 5 * time.Second, QueueSize: 10}, discard)
+	before := testutil.ToFloat64(Deliveries.WithLabelValues(events.ConfigReloaded, "delivered"))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	d.Publish(events.Event{Type: events.ConfigReloaded})
+	<-started
+	cancel()
+	close(release)
+	<-done
+	if after := testutil.ToFloat64(Deliveries.WithLabelValues(events.ConfigReloaded, "delivered")); after != before+1 {
+		t.Errorf("delivered = %v, want %v: the delivery under way at shutdown was cut off", after, before+1)
+	}
+}
+
+func TestPublishDrops(t *testing.T) {
+	d, _ := New(Config{URLs: []string{"http://hooks.example.com"}, QueueSize: 1}, discard)
+	before := testutil.ToFloat64(Deliveries.WithLabelValues(events.SessionOpened, "dropped"))
+
+	d.Publish(events.Event{Type: events.SessionOpened})
+	d.Publish(events.Event{Type: events.SessionOpened})
+
+	if n := len(d.queue); n != 1 {
+		t.Errorf("queued = %d, want 1", n)
+	}
+	if after := testutil.ToFloat64(Deliveries.WithLabelValues(events.SessionOpened, "dropped")); after != before+1 {
+		t.Errorf("dropped = %v, want %v", after, before+1)
+	}
+}
+
+func TestSign(t *testing.T) {
+	a := Sign("key", "1700000000", []byte(`{"id":"1"}`))
+	if a != Sign("key", "1700000000", []byte(`{"id":"1"}`)) {
+		t.Error("Sign is not deterministic")
+	}
+	for _, other := range []string{
+		Sign("other", "1700000000", []byte(`{"id":"1"}`)),
+		Sign("key", "1700000001", []byte(`{"id":"1"}`)),
+		Sign("key", "1700000000", []byte(`{"id":"2"}`)),
+	} {
+		if other == a {
+			t.Errorf("Sign collides: %s", a)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Connect opens an MCP session between client and s over an in-memory
+// transport, with no stdio or HTTP in between, for tests and for programs
+// that embed the server to run its tools locally. A nil client connects a
+// client named after the server. Each call opens a new session; closing
+// the returned session ends it.
+//
+// Calls go through the tool policy, approval, and event middleware, but
+// not the HTTP middleware, so they are neither authenticated nor rate
+// limited and have no caller identity. Connect does not start what Run
+// starts, such as webhook delivery, and can be used whether or not Run is
+// serving another transport.
+func (s *Server) Connect(ctx context.Context, client *mcp.Client) (*mcp.ClientSession, error) {
+	if client == nil {
+		client = mcp.NewClient(&mcp.Implementation{Name: s.cfg.Name + "-client", Version: s.cfg.Version}, nil)
+	}
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := s.mcp.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		return nil, fmt.Errorf("connecting server: %w", err)
+	}
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		_ = ss.Close()
+		return nil, fmt.Errorf("connecting client: %w", err)
+	}
+	return cs, nil
+}
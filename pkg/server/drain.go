@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/events"
+)
+
+// maxTerminationMessage is the size Kubernetes keeps of a termination
+// message.
+const maxTerminationMessage = 4096
+
+// Drain marks the server not ready, so /ready answers 503 and load
+// balancers stop routing new requests to it, then stops Run once
+// DRAIN_PERIOD has passed. Requests in flight then have SHUTDOWN_TIMEOUT
+// to finish. The returned channel is closed when the drain period is
+// over; calling Drain again returns the same channel.
+func (s *Server) Drain() <-chan struct{} {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+	if s.drained != nil {
+		return s.drained
+	}
+
+	s.drained = make(chan struct{})
+	s.draining.Store(true)
+	s.logger.Info("draining", "drain_period", s.drainPeriod.String())
+	time.AfterFunc(s.drainPeriod, func() {
+		s.drainMu.Lock()
+		defer s.drainMu.Unlock()
+		close(s.drained)
+		if s.stop != nil {
+			s.stop()
+		}
+	})
+	return s.drained
+}
+
+// Draining reports whether Drain has been called.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// setStop records how to stop the running server, stopping it at once if
+// the drain period is already over.
+func (s *Server) setStop(stop context.CancelFunc) {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+	s.stop = stop
+	if s.drained != nil {
+		select {
+		case <-s.drained:
+			stop()
+		default:
+		}
+	}
+}
+
+// drainHandler starts draining and answers once the drain period is over,
+// so a Kubernetes preStop httpGet hook holds off SIGTERM until load
+// balancers have stopped routing to the pod.
+func (s *Server) drainHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	select {
+	case <-s.Drain():
+	case <-r.Context().Done():
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{"drained": true, "drain_period": s.drainPeriod.String()})
+}
+
+// terminated logs why Run returned, with what the server did while it ran,
+// publishes the same as server.stopped, and writes the message to
+// TERMINATION_LOG, such as /dev/termination-log, where Kubernetes shows it
+// in the pod status.
+func (s *Server) terminated(err error, uptime time.Duration) {
+	uptime = uptime.Round(time.Second)
+	var msg, reason string
+	level := slog.LevelInfo
+	switch {
+	case err != nil:
+		msg = fmt.Sprintf("%s failed after %s: %v", s.cfg.Name, uptime, err)
+		reason, level = "error", slog.LevelError
+	case s.Draining():
+		msg = fmt.Sprintf("%s drained and stopped after %s", s.cfg.Name, uptime)
+		reason = "drained"
+	default:
+		msg = fmt.Sprintf("%s stopped after %s", s.cfg.Name, uptime)
+		reason = "shutdown"
+	}
+	attrs := []any{"reason", reason, "uptime", uptime.String()}
+	data := map[string]any{"name": s.cfg.Name, "version": s.cfg.Version, "reason": reason, "uptime_seconds": int64(uptime.Seconds())}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+		data["error"] = err.Error()
+	}
+	if s.summary != nil {
+		attrs = append(attrs, s.summary.attrs()...)
+		maps.Copy(data, s.summary.data())
+	}
+	s.logger.Log(context.Background(), level, "mcp server stopped", attrs...)
+	s.events.Publish(events.ServerStopped, data)
+
+	if s.terminationLog == "" {
+		return
+	}
+	if len(msg) > maxTerminationMessage {
+		msg = msg[:maxTerminationMessage]
+	}
+	if err := os.WriteFile(s.terminationLog, []byte(msg+"\n"), 0o644); err != nil {
+		s.logger.Warn("writing termination log failed", "path", s.terminationLog, "error", err)
+	}
+}
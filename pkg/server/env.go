@@ -0,0 +1,315 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/approval"
+	"github.com/lkendrickd/mcp-server/internal/capture"
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/events"
+	"github.com/lkendrickd/mcp-server/internal/httpclient"
+	"github.com/lkendrickd/mcp-server/internal/media"
+	"github.com/lkendrickd/mcp-server/internal/metrics"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+	"github.com/lkendrickd/mcp-server/internal/oauth"
+	"github.com/lkendrickd/mcp-server/internal/output"
+	"github.com/lkendrickd/mcp-server/internal/policy"
+	"github.com/lkendrickd/mcp-server/internal/schedule"
+	"github.com/lkendrickd/mcp-server/internal/secrets"
+	"github.com/lkendrickd/mcp-server/internal/session"
+	"github.com/lkendrickd/mcp-server/internal/tenant"
+	"github.com/lkendrickd/mcp-server/internal/usage"
+	"github.com/lkendrickd/mcp-server/internal/webhook"
+)
+
+// loadSecrets installs the settings of the configured secrets provider, if
+// any, before the rest of the configuration is read.
+func loadSecrets(ctx context.Context) (secrets.Provider, secrets.Config, error) {
+	cfg := secrets.LoadConfig()
+	provider, err := secrets.New(ctx, cfg)
+	if err != nil || provider == nil {
+		return nil, cfg, err
+	}
+	if _, err := secrets.Load(ctx, provider, cfg.Timeout); err != nil {
+		return nil, cfg, err
+	}
+	return provider, cfg, nil
+}
+
+// newClientIPResolver resolves client addresses, trusting X-Forwarded-For
+// from TRUSTED_PROXIES.
+func newClientIPResolver() (*middleware.ClientIPResolver, error) {
+	resolver, err := middleware.NewClientIPResolver(config.GetEnvList("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+	return resolver, nil
+}
+
+// newIPFilter builds the IP allow/deny filter from IP_ALLOWLIST and
+// IP_DENYLIST.
+func newIPFilter(resolver *middleware.ClientIPResolver) (*middleware.IPFilter, error) {
+	filter, err := middleware.NewIPFilter(config.GetEnvList("IP_ALLOWLIST"), config.GetEnvList("IP_DENYLIST"), resolver)
+	if err != nil {
+		return nil, fmt.Errorf("IP_ALLOWLIST/IP_DENYLIST: %w", err)
+	}
+	return filter, nil
+}
+
+// newProtocolVersions reads the MCP protocol versions served from
+// MCP_PROTOCOL_VERSIONS, all that the SDK supports by default.
+func newProtocolVersions() ([]string, error) {
+	versions := config.GetEnvList("MCP_PROTOCOL_VERSIONS")
+	if versions == nil {
+		return middleware.SupportedProtocolVersions, nil
+	}
+	if err := middleware.ValidateProtocolVersions(versions); err != nil {
+		return nil, fmt.Errorf("MCP_PROTOCOL_VERSIONS: %w", err)
+	}
+	return versions, nil
+}
+
+// newKeySources reads where API keys are accepted from AUTH_KEY_SOURCES,
+// AUTH_KEY_HEADER, and AUTH_KEY_QUERY_PARAM.
+func newKeySources() (middleware.KeySources, error) {
+	sources := middleware.DefaultKeySources
+	if list := config.GetEnvList("AUTH_KEY_SOURCES"); list != nil {
+		sources.Sources = list
+	}
+	sources.Header = config.GetEnv("AUTH_KEY_HEADER", sources.Header)
+	sources.QueryParam = config.GetEnv("AUTH_KEY_QUERY_PARAM", sources.QueryParam)
+	if err := sources.Validate(); err != nil {
+		return sources, fmt.Errorf("AUTH_KEY_SOURCES: %w", err)
+	}
+	return sources, nil
+}
+
+// newRateLimiter builds the per-client rate limiter from RATE_LIMIT_REQUESTS
+// per RATE_LIMIT_WINDOW using RATE_LIMIT_ALGORITHM. It returns nil when
+// rate limiting is disabled.
+func newRateLimiter() (middleware.Limiter, error) {
+	algorithm := config.GetEnv("RATE_LIMIT_ALGORITHM", middleware.AlgorithmTokenBucket)
+	limit := config.GetEnvInt("RATE_LIMIT_REQUESTS", 0)
+	window := config.GetEnvDuration("RATE_LIMIT_WINDOW", time.Minute)
+	burst := config.GetEnvInt("RATE_LIMIT_BURST", limit)
+	if limit <= 0 {
+		return nil, nil
+	}
+	limiter, err := middleware.NewLimiter(algorithm, limit, window, burst)
+	if err != nil {
+		return nil, fmt.Errorf("rate limit: %w", err)
+	}
+	return limiter, nil
+}
+
+// newLoadShedder caps concurrent MCP requests at MAX_INFLIGHT, queueing up
+// to MAX_INFLIGHT_QUEUE more for MAX_INFLIGHT_WAIT. It returns nil when
+// MAX_INFLIGHT is 0.
+func newLoadShedder() *middleware.LoadShedder {
+	limit := config.GetEnvInt("MAX_INFLIGHT", 0)
+	queue := config.GetEnvInt("MAX_INFLIGHT_QUEUE", limit)
+	wait := config.GetEnvDuration("MAX_INFLIGHT_WAIT", 250*time.Millisecond)
+	if limit <= 0 {
+		return nil
+	}
+	return middleware.NewLoadShedder(limit, queue, wait)
+}
+
+// envConfig is the configuration a Server reads from the environment.
+// loadEnvConfig reads and checks all of it but only builds what has no
+// effect outside the process: it resolves no secrets, opens no files for
+// writing, connects to nothing, and registers no tools, so that the config
+// commands can read it on its own. newServer builds the rest from it.
+type envConfig struct {
+	settings *config.Config
+
+	stdioStrict    bool
+	stdoutGuard    bool
+	basePath       string
+	reusePort      bool
+	conns          connConfig
+	drainPeriod    time.Duration
+	terminationLog string
+	metrics        metrics.Config
+	versions       []string
+
+	summary        bool
+	eventsLog      bool
+	eventsLogTypes []string
+	webhooks       *webhook.Dispatcher
+	usage          usage.Config
+
+	toolConfig         string
+	toolConfigInterval time.Duration
+	toolListCache      bool
+	schedule           schedule.Config
+	mediaCfg           media.Config
+	media              *media.Policy
+	outputCfg          output.Config
+	output             *output.Limiter
+	tracing            bool
+	approvalCfg        approval.Config
+	approver           *approval.Approver
+	policyFile         string
+	policy             *policy.Policy
+	tenantsFile        string
+	tenants            *tenant.Set
+	adminToken         string
+	pprof              bool
+
+	// Read with the http transport only
+	session         session.Config
+	reaper          session.ReaperConfig
+	capture         capture.Config
+	oauth           oauth.Config
+	verifier        *oauth.Verifier
+	keyInterval     time.Duration
+	keySources      *middleware.KeySources
+	shedder         *middleware.LoadShedder
+	resolver        *middleware.ClientIPResolver
+	limiter         middleware.Limiter
+	rateLimit       string
+	ipFilter        *middleware.IPFilter
+	rateLimitDebug  bool
+	rejectionAlert  int
+	middlewareOrder []string
+}
+
+// loadEnvConfig reads the configuration of a server for cfg. Secrets from
+// an external manager must already be in place, since settings may
+// reference them.
+func loadEnvConfig(cfg Config, logger *slog.Logger) (*envConfig, error) {
+	env := &envConfig{settings: config.New()}
+	if cfg.Port != "" {
+		env.settings.Port = cfg.Port
+	}
+	if cfg.MetricsPort == env.settings.Port {
+		return nil, fmt.Errorf("METRICS_PORT %s must differ from PORT", cfg.MetricsPort)
+	}
+	if cfg.AdminPort != "" && (cfg.AdminPort == env.settings.Port || cfg.AdminPort == cfg.MetricsPort) {
+		return nil, fmt.Errorf("ADMIN_PORT %s must differ from PORT and METRICS_PORT", cfg.AdminPort)
+	}
+	env.stdioStrict = config.GetEnvBool("STDIO_STRICT", false)
+	env.stdoutGuard = config.GetEnvBool("STDIO_STDOUT_GUARD", true)
+	var err error
+	if env.basePath, err = loadBasePath(); err != nil {
+		return nil, err
+	}
+	env.reusePort = config.GetEnvBool("HTTP_REUSEPORT", false)
+	env.conns = loadConnConfig(cfg.Transport)
+	env.drainPeriod = config.GetEnvDuration("DRAIN_PERIOD", 15*time.Second)
+	env.terminationLog = config.GetEnv("TERMINATION_LOG", "")
+	config.Strict()
+	if env.metrics, err = metrics.LoadConfig(); err != nil {
+		return nil, err
+	}
+	if env.versions, err = newProtocolVersions(); err != nil {
+		return nil, err
+	}
+
+	env.summary = config.GetEnvBool("SHUTDOWN_SUMMARY", true)
+	if env.eventsLog = config.GetEnvBool("EVENTS_LOG", false); env.eventsLog {
+		env.eventsLogTypes = config.GetEnvList("EVENTS_LOG_TYPES")
+		if err := events.ValidateTypes(env.eventsLogTypes); err != nil {
+			return nil, fmt.Errorf("EVENTS_LOG_TYPES: %w", err)
+		}
+	}
+	if env.webhooks, err = webhook.New(webhook.LoadConfig(), logger); err != nil {
+		return nil, err
+	}
+	env.usage = usage.LoadConfig()
+
+	env.toolConfig = config.GetEnv("TOOL_CONFIG_FILE", "")
+	env.toolConfigInterval = config.GetEnvDuration("TOOL_CONFIG_INTERVAL", 30*time.Second)
+	env.toolListCache = config.GetEnvBool("TOOL_LIST_CACHE", true)
+	// Tools build their HTTP clients lazily, so invalid proxy or CA
+	// settings are caught here rather than on a tool's first request
+	if _, err := httpclient.Default(); err != nil {
+		return nil, err
+	}
+	env.schedule = schedule.LoadConfig()
+	env.mediaCfg = media.LoadConfig()
+	if env.media, err = media.New(env.mediaCfg, logger); err != nil {
+		return nil, err
+	}
+	if env.media != nil && env.media.Offloading() && cfg.Transport == "stdio" {
+		return nil, fmt.Errorf("TOOL_BINARY_OFFLOAD_URL needs the http transport")
+	}
+	env.outputCfg = output.LoadConfig()
+	if env.output, err = output.New(env.outputCfg, logger); err != nil {
+		return nil, err
+	}
+	env.tracing = config.GetEnvBool("MCP_TRACING", false)
+	env.approvalCfg = approval.LoadConfig()
+	if env.approver, err = approval.New(env.approvalCfg, logger); err != nil {
+		return nil, err
+	}
+	if env.policyFile = config.GetEnv("POLICY_FILE", ""); env.policyFile != "" {
+		if env.policy, err = policy.Load(env.policyFile); err != nil {
+			return nil, err
+		}
+	}
+	if env.tenantsFile = config.GetEnv("TENANTS_FILE", ""); env.tenantsFile != "" {
+		if cfg.Transport == "stdio" {
+			return nil, fmt.Errorf("TENANTS_FILE needs the http transport")
+		}
+		if env.tenants, err = tenant.Load(env.tenantsFile); err != nil {
+			return nil, err
+		}
+	}
+	env.adminToken = config.GetEnv("ADMIN_TOKEN", "")
+	env.pprof = config.GetEnvBool("PPROF_ENABLED", false)
+	if cfg.Transport == "stdio" {
+		return env, nil
+	}
+
+	if env.adminToken == "" && cfg.AdminPort != "" {
+		return nil, fmt.Errorf("ADMIN_PORT requires ADMIN_TOKEN")
+	}
+	env.session = session.LoadConfig()
+	env.reaper = session.LoadReaperConfig()
+	env.capture = capture.LoadConfig()
+	if env.oauth = oauth.LoadConfig(); env.oauth.Enabled() {
+		if env.verifier, err = oauth.NewVerifier(env.oauth, &http.Client{Timeout: env.oauth.Timeout}); err != nil {
+			return nil, fmt.Errorf("oauth: %w", err)
+		}
+	}
+	env.keyInterval = config.GetEnvDuration("SECRETS_RELOAD_INTERVAL", 30*time.Second)
+	if env.settings.AuthEnabled && !env.oauth.Enabled() {
+		sources, err := newKeySources()
+		if err != nil {
+			return nil, err
+		}
+		env.keySources = &sources
+	}
+	env.shedder = newLoadShedder()
+	if env.resolver, err = newClientIPResolver(); err != nil {
+		return nil, err
+	}
+	if env.limiter, err = newRateLimiter(); err != nil {
+		return nil, err
+	}
+	env.rateLimit = "off"
+	if env.limiter != nil {
+		env.rateLimit = config.GetEnv("RATE_LIMIT_ALGORITHM", middleware.AlgorithmTokenBucket)
+	}
+	if env.ipFilter, err = newIPFilter(env.resolver); err != nil {
+		return nil, err
+	}
+	env.rateLimitDebug = config.GetEnvBool("RATE_LIMIT_DEBUG", false)
+	env.rejectionAlert = config.GetEnvInt("EVENTS_RATE_LIMIT_THRESHOLD", 100)
+	if env.middlewareOrder = config.GetEnvList("HTTP_MIDDLEWARE_ORDER"); len(env.middlewareOrder) > 0 {
+		stages := make([]middleware.Stage, len(defaultStages))
+		for i, name := range defaultStages {
+			stages[i] = middleware.Stage{Name: name}
+		}
+		if err := middleware.NewPipeline(stages...).Reorder(env.middlewareOrder); err != nil {
+			return nil, fmt.Errorf("HTTP_MIDDLEWARE_ORDER: %w", err)
+		}
+	}
+	return env, nil
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/events"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+	"github.com/lkendrickd/mcp-server/internal/usage"
+)
+
+// Event is something that happened to the server, published to event
+// sinks.
+type Event = events.Event
+
+// EventSink receives events. Publish must not block.
+type EventSink = events.Sink
+
+// EventSinkFunc adapts a function to EventSink.
+type EventSinkFunc = events.SinkFunc
+
+// Event types, for WithEventSink.
+const (
+	EventServerStarted     = events.ServerStarted
+	EventServerStopped     = events.ServerStopped
+	EventToolCallStarted   = events.ToolCallStarted
+	EventToolCallCompleted = events.ToolCallCompleted
+	EventToolCallFailed    = events.ToolCallFailed
+	EventAuthFailure       = events.AuthFailure
+	EventRateLimitExceeded = events.RateLimitExceeded
+	EventSessionOpened     = events.SessionOpened
+	EventSessionClosed     = events.SessionClosed
+	EventConfigReloaded    = events.ConfigReloaded
+	EventScheduleCompleted = events.ScheduleCompleted
+	EventScheduleFailed    = events.ScheduleFailed
+)
+
+// WithEventSink subscribes sink to the server's events of types, or to
+// every event when types is empty, for example to forward them to a
+// message broker.
+func WithEventSink(sink EventSink, types ...string) Option {
+	return func(s *Server) { s.events.Subscribe(sink, types...) }
+}
+
+// subscribeSinks subscribes the sinks configured by EVENTS_LOG,
+// WEBHOOK_URLS, USAGE_ENABLED, SHUTDOWN_SUMMARY, and ERROR_REPORTING_DSN.
+func (s *Server) subscribeSinks() error {
+	if sink := s.reporter.ToolErrors(); sink != nil {
+		s.events.Subscribe(sink, events.ToolCallFailed)
+	}
+	if s.env.summary {
+		s.summary = newSummary()
+		s.events.Subscribe(s.summary, events.ToolCallCompleted, events.SessionOpened)
+	}
+	if s.env.eventsLog {
+		s.events.Subscribe(events.LogSink(s.logger, slog.LevelInfo), s.env.eventsLogTypes...)
+	}
+	if s.webhooks = s.env.webhooks; s.webhooks != nil {
+		s.events.Subscribe(s.webhooks, s.webhooks.Events()...)
+	}
+	var err error
+	if s.usage, err = usage.Open(s.env.usage, s.logger); err != nil {
+		return err
+	}
+	if s.usage != nil {
+		s.events.Subscribe(s.usage, events.ToolCallCompleted)
+	}
+	return nil
+}
+
+// serverOptions answers completion/complete from the tools' Completers and
+// reports sessions to the event bus.
+func (s *Server) serverOptions() *mcp.ServerOptions {
+	opts := &mcp.ServerOptions{CompletionHandler: tools.Complete}
+	if s.events.Wants(events.SessionOpened) || s.events.Wants(events.SessionClosed) {
+		opts.InitializedHandler = s.sessionOpened
+	}
+	return opts
+}
+
+// sessionOpened publishes session.opened, and session.closed once the
+// session ends. Sessions shared through a store are stateless in this
+// process, so only their opening is reported, and the server's own
+// manifest session is not reported at all.
+func (s *Server) sessionOpened(_ context.Context, req *mcp.InitializedRequest) {
+	ss := req.Session
+	data := map[string]any{"session_id": ss.ID()}
+	if p := ss.InitializeParams(); p != nil && p.ClientInfo != nil {
+		if p.ClientInfo.Name == tools.ManifestClient {
+			return
+		}
+		data["client"] = p.ClientInfo.Name
+		data["client_version"] = p.ClientInfo.Version
+	}
+	s.events.Publish(events.SessionOpened, data)
+	if s.sessions != nil {
+		return
+	}
+	go func() {
+		_ = ss.Wait()
+		s.events.Publish(events.SessionClosed, map[string]any{"session_id": ss.ID()})
+	}()
+}
+
+// toolEvents publishes tool_call.started and tool_call.completed around
+// each tools/call, and tool_call.failed for calls that fail, whether the
+// tool reported the error or the call was rejected.
+func (s *Server) toolEvents(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method != "tools/call" {
+			return next(ctx, method, req)
+		}
+		data := map[string]any{}
+		if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok {
+			data["tool"] = params.Name
+		}
+		if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+			if caller := extra.Header.Get(middleware.HeaderCaller); caller != "" {
+				data["caller"] = caller
+			}
+			if tenant := extra.Header.Get(middleware.HeaderTenant); tenant != "" {
+				data["tenant"] = tenant
+			}
+		}
+		s.events.Publish(events.ToolCallStarted, data)
+
+		start := time.Now()
+		result, err := next(ctx, method, req)
+		res, _ := result.(*mcp.CallToolResult)
+		failed := err != nil || res == nil || res.IsError
+
+		// Sinks may hold on to published data, so each event gets a copy
+		completed := maps.Clone(data)
+		completed["duration_ms"] = time.Since(start).Milliseconds()
+		completed["is_error"] = failed
+		s.events.Publish(events.ToolCallCompleted, completed)
+		if !failed {
+			return result, err
+		}
+
+		failure := maps.Clone(data)
+		if err != nil {
+			failure["error"] = err.Error()
+		} else if res != nil {
+			if e, ok := res.Meta["error"].(error); ok {
+				te := tools.AsError(e)
+				failure["code"] = te.Code
+				failure["error"] = te.Message
+			}
+		}
+		s.events.Publish(events.ToolCallFailed, failure)
+		return result, err
+	}
+}
+
+// challengeError extracts the error parameter of a WWW-Authenticate
+// challenge.
+var challengeError = regexp.MustCompile(`error="([^"]*)"`)
+
+type authProbeKey struct{}
+
+// authProbe records whether a request got through an auth stage, and the
+// response writer the stage was given.
+type authProbe struct {
+	w      http.ResponseWriter
+	passed bool
+}
+
+// statusRecorder captures the status an auth stage responds with.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// authEvents wraps the auth stage wrap to publish auth.failure for the
+// requests it rejects. Only the stage's own response is recorded: requests
+// it lets through continue with the original response writer.
+func (s *Server) authEvents(method string, wrap func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		inner := wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if p, ok := r.Context().Value(authProbeKey{}).(*authProbe); ok {
+				p.passed = true
+				w = p.w
+			}
+			next.ServeHTTP(w, r)
+		}))
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			probe := &authProbe{w: w}
+			rec := &statusRecorder{ResponseWriter: w}
+			inner.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), authProbeKey{}, probe)))
+			if probe.passed || (rec.status != http.StatusUnauthorized && rec.status != http.StatusForbidden) {
+				return
+			}
+			reason := middleware.ReasonMissingCredentials
+			if m := challengeError.FindStringSubmatch(rec.Header().Get("WWW-Authenticate")); m != nil {
+				reason = m[1]
+			}
+			s.events.Publish(events.AuthFailure, map[string]any{
+				"method": method,
+				"status": rec.status,
+				"reason": reason,
+				"path":   r.URL.Path,
+				"remote": r.RemoteAddr,
+			})
+		})
+	}
+}
+
+// watchRejections publishes rate_limit.exceeded when the rate limiter
+// rejects at least threshold requests within an interval, naming the
+// most limited clients.
+func (s *Server) watchRejections(ctx context.Context, threshold int, interval time.Duration) {
+	if threshold <= 0 || !s.events.Wants(events.RateLimitExceeded) {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := s.rejections.Total()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			total := s.rejections.Total()
+			if n := total - last; n >= int64(threshold) {
+				s.events.Publish(events.RateLimitExceeded, map[string]any{
+					"rejected":  n,
+					"threshold": threshold,
+					"interval":  interval.String(),
+					"clients":   s.rejections.Top(5),
+				})
+			}
+			last = total
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+// listenFDsStart is the first file descriptor systemd passes with socket
+// activation (SD_LISTEN_FDS_START).
+const listenFDsStart = 3
+
+// inheritedListeners returns the sockets passed by systemd socket
+// activation, or a process manager following the same protocol, keyed by
+// name: LISTEN_FDNAMES names them "http", "metrics", or "admin", and an
+// unnamed single socket is "http". The variables are cleared so that
+// child processes do not claim the sockets.
+func inheritedListeners(firstFD int) (map[string]net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	var names []string
+	if v := os.Getenv("LISTEN_FDNAMES"); v != "" {
+		names = strings.Split(v, ":")
+	}
+	for _, key := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		_ = os.Unsetenv(key)
+	}
+
+	listeners := make(map[string]net.Listener, n)
+	for i := range n {
+		name := "http"
+		if i < len(names) {
+			name = names[i]
+		} else if i > 0 {
+			return nil, fmt.Errorf("socket activation: LISTEN_FDNAMES must name each of the %d sockets", n)
+		}
+		f := os.NewFile(uintptr(firstFD+i), name)
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("socket activation: socket %q: %w", name, err)
+		}
+		listeners[name] = ln
+	}
+	return listeners, nil
+}
+
+// listen returns the listener called name: the inherited socket when there
+// is one, or a new socket on port, with SO_REUSEPORT when s.reusePort is
+// set so that a new process can bind the port while this one drains.
+// Inherited sockets keep the TCP keep-alive settings they were made with.
+func (s *Server) listen(name, port string) (net.Listener, error) {
+	if ln, ok := s.inherited[name]; ok {
+		s.logger.Info("using inherited socket", "listener", name, "addr", ln.Addr().String())
+		return ln, nil
+	}
+	lc := net.ListenConfig{KeepAlive: s.conns.tcpKeepAlive}
+	if s.reusePort {
+		lc.Control = reusePort
+	}
+	return lc.Listen(context.Background(), "tcp", ":"+port)
+}
+
+// connConfig holds the connection settings of the HTTP listeners.
+type connConfig struct {
+	// shutdownTimeout bounds the wait for requests in flight on shutdown
+	shutdownTimeout time.Duration
+	// readHeaderTimeout bounds the time a client has to send the request
+	// headers, so slow clients cannot hold connections open
+	readHeaderTimeout time.Duration
+	maxHeaderBytes    int
+	// maxConnsPerIP caps the open connections of each client address on
+	// the http listener; 0 is unlimited
+	maxConnsPerIP int
+	// idleTimeout closes keep-alive connections idle this long
+	idleTimeout time.Duration
+	keepAlives  bool
+	// tcpKeepAlive is the TCP keep-alive probe interval of accepted
+	// connections; negative disables the probes
+	tcpKeepAlive time.Duration
+	// h2c serves unencrypted HTTP/2 alongside HTTP/1.1
+	h2c bool
+}
+
+// loadConnConfig reads the HTTP connection settings. The idle timeout
+// defaults per transport: with the http transport it outlasts the 60s
+// upstream idle timeout common to load balancers, so the proxy rather
+// than the server closes idle connections and never sends a request on
+// one being closed; with stdio the listener only serves probes and
+// scrapes, which need no long-lived connections.
+func loadConnConfig(transport string) connConfig {
+	idle := 120 * time.Second
+	if transport == "stdio" {
+		idle = 30 * time.Second
+	}
+	return connConfig{
+		shutdownTimeout:   config.GetEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		readHeaderTimeout: config.GetEnvDuration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+		maxHeaderBytes:    config.GetEnvInt("HTTP_MAX_HEADER_BYTES", http.DefaultMaxHeaderBytes),
+		maxConnsPerIP:     config.GetEnvInt("HTTP_MAX_CONNS_PER_IP", 0),
+		idleTimeout:       config.GetEnvDuration("HTTP_IDLE_TIMEOUT", idle),
+		keepAlives:        config.GetEnvBool("HTTP_KEEPALIVE", true),
+		tcpKeepAlive:      config.GetEnvDuration("HTTP_TCP_KEEPALIVE", 15*time.Second),
+		h2c:               config.GetEnvBool("HTTP_H2C", false),
+	}
+}
+
+// serve serves handler on the listener called name until ctx is done.
+func (s *Server) serve(ctx context.Context, name, port string, handler http.Handler) error {
+	ln, err := s.listen(name, port)
+	if err != nil {
+		return err
+	}
+	// The metrics and admin listeners are not exposed to clients
+	if name == "http" && s.conns.maxConnsPerIP > 0 {
+		ln = middleware.LimitConnsPerIP(ln, s.conns.maxConnsPerIP)
+	}
+	s.logger.Info(name+" server starting", "addr", ln.Addr().String())
+	return serveListener(ctx, ln, handler, s.conns)
+}
+
+// serveListener serves handler on ln with the settings of conns until ctx
+// is done, then stops accepting connections and waits up to
+// conns.shutdownTimeout for requests in flight before closing the rest.
+func serveListener(ctx context.Context, ln net.Listener, handler http.Handler, conns connConfig) error {
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: conns.readHeaderTimeout,
+		MaxHeaderBytes:    conns.maxHeaderBytes,
+		IdleTimeout:       conns.idleTimeout,
+	}
+	srv.SetKeepAlivesEnabled(conns.keepAlives)
+	if conns.h2c {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	stopped := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(stopped)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), conns.shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			_ = srv.Close()
+		}
+	})
+	defer stop()
+
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-stopped
+	return nil
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestReusePort(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SO_REUSEPORT is not available on windows")
+	}
+	lc := net.ListenConfig{Control: reusePort}
+	first, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := lc.Listen(context.Background(), "tcp", first.Addr().String())
+	if err != nil {
+		t.Fatalf("second listener on %s: %v", first.Addr(), err)
+	}
+	second.Close()
+}
+
+func TestServeListenerDrains(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = io.WriteString(w, "done")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serveListener(ctx, ln, handler, connConfig{shutdownTimeout: 5 * time.Second}) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	resp := make(chan result, 1)
+	go func() {
+		r, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			resp <- result{err: err}
+			return
+		}
+		defer r.Body.Close()
+		b, err := io.ReadAll(r.Body)
+		resp <- result{string(b), err}
+	}()
+
+	<-started
+	cancel()
+	if got := <-resp; got.err != nil || got.body != "done" {
+		t.Errorf("in-flight request = %q, %v; want it to complete", got.body, got.err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("serveListener() error = %v", err)
+	}
+}
+
+func TestServeListenerReadHeaderTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conns := connConfig{shutdownTimeout: time.Second, readHeaderTimeout: 50 * time.Millisecond}
+	go func() { _ = serveListener(ctx, ln, http.NotFoundHandler(), conns) }()
+
+	// A client that never finishes its headers is disconnected
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := io.WriteString(c, "GET / HTTP/1.1\r\nHost: x\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	start := time.Now()
+	_, _ = io.ReadAll(c)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("connection held for %v, want it closed after the header timeout", elapsed)
+	}
+}
+
+func TestServeListenerH2C(t *testing.T) {
+	tests := []struct {
+		name      string
+		h2c       bool
+		wantProto int
+	}{
+		{name: "http/1.1 only", wantProto: 1},
+		{name: "h2c", h2c: true, wantProto: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			proto := make(chan int, 1)
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { proto <- r.ProtoMajor })
+			conns := connConfig{shutdownTimeout: time.Second, keepAlives: true, h2c: tt.h2c}
+			go func() { _ = serveListener(ctx, ln, handler, conns) }()
+
+			// The client speaks HTTP/2 with prior knowledge if the server
+			// accepts it, and falls back to HTTP/1.1 otherwise
+			transport := &http.Transport{Protocols: new(http.Protocols)}
+			transport.Protocols.SetHTTP1(!tt.h2c)
+			transport.Protocols.SetUnencryptedHTTP2(tt.h2c)
+			defer transport.CloseIdleConnections()
+			resp, err := (&http.Client{Transport: transport}).Get("http://" + ln.Addr().String())
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			resp.Body.Close()
+			if got := <-proto; got != tt.wantProto {
+				t.Errorf("protocol = HTTP/%d, want HTTP/%d", got, tt.wantProto)
+			}
+		})
+	}
+}
+
+func TestLoadConnConfig(t *testing.T) {
+	if got := loadConnConfig("http").idleTimeout; got != 120*time.Second {
+		t.Errorf("http idle timeout = %v, want 2m", got)
+	}
+	if got := loadConnConfig("stdio").idleTimeout; got != 30*time.Second {
+		t.Errorf("stdio idle timeout = %v, want 30s", got)
+	}
+	t.Setenv("HTTP_IDLE_TIMEOUT", "5s")
+	if got := loadConnConfig("http").idleTimeout; got != 5*time.Second {
+		t.Errorf("HTTP_IDLE_TIMEOUT=5s idle timeout = %v", got)
+	}
+}
@@ -0,0 +1,73 @@
+//go:build unix
+
+package server
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestInheritedListeners(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name     string
+		pid      string
+		names    string
+		wantName string
+	}{
+		{name: "another process", pid: "1", wantName: ""},
+		{name: "unnamed", pid: strconv.Itoa(os.Getpid()), wantName: "http"},
+		{name: "named", pid: strconv.Itoa(os.Getpid()), names: "admin", wantName: "admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// inheritedListeners takes ownership of the descriptor and
+			// closes it, so it must not belong to an *os.File, whose
+			// finalizer would close it again after it has been reused
+			dup, err := syscall.Dup(int(f.Fd()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("LISTEN_PID", tt.pid)
+			t.Setenv("LISTEN_FDS", "1")
+			t.Setenv("LISTEN_FDNAMES", tt.names)
+
+			got, err := inheritedListeners(dup)
+			if err != nil {
+				t.Fatalf("inheritedListeners() error = %v", err)
+			}
+			if tt.wantName == "" {
+				_ = syscall.Close(dup)
+				if len(got) != 0 {
+					t.Fatalf("inheritedListeners() = %v, want none", got)
+				}
+				return
+			}
+			inherited, ok := got[tt.wantName]
+			if !ok || len(got) != 1 {
+				t.Fatalf("inheritedListeners() = %v, want only %q", got, tt.wantName)
+			}
+			defer inherited.Close()
+			if inherited.Addr().String() != ln.Addr().String() {
+				t.Errorf("addr = %s, want %s", inherited.Addr(), ln.Addr())
+			}
+			if os.Getenv("LISTEN_FDS") != "" {
+				t.Error("LISTEN_FDS was not cleared")
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// negotiateVersion settles initialize on one of the configured protocol
+// versions, offering the newest when the client asks for another, and lists
+// them all in the result's _meta.supportedProtocolVersions so that clients
+// can tell why they were offered a different one.
+func (s *Server) negotiateVersion(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method != "initialize" {
+			return next(ctx, method, req)
+		}
+		if params, ok := req.GetParams().(*mcp.InitializeParams); ok && !slices.Contains(s.versions, params.ProtocolVersion) {
+			params.ProtocolVersion = s.versions[0]
+		}
+		result, err := next(ctx, method, req)
+		if res, ok := result.(*mcp.InitializeResult); ok {
+			if res.Meta == nil {
+				res.Meta = mcp.Meta{}
+			}
+			res.Meta["supportedProtocolVersions"] = s.versions
+		}
+		return result, err
+	}
+}
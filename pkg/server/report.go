@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/tools"
+)
+
+// Report summarizes how a server is set up so operators can verify a
+// deployment at a glance. It is logged when Run starts and served at
+// /version alongside /health.
+type Report struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	Bundle    string `json:"bundle"`
+	Transport string `json:"transport"`
+	// Ports maps each listener ("http", "metrics", "admin") to its port.
+	Ports map[string]string `json:"ports"`
+	// Middleware lists the enabled HTTP middleware stages, outermost first.
+	Middleware []string `json:"middleware"`
+	// Auth lists the enabled credentials: "api_key", "oauth", and
+	// "admin_token".
+	Auth []string `json:"auth"`
+	// RateLimit is the rate limit algorithm, or "off".
+	RateLimit string `json:"rate_limit"`
+	// Telemetry maps each telemetry export to where it can be collected.
+	Telemetry map[string]string `json:"telemetry"`
+	Tools     []string          `json:"tools"`
+}
+
+// Report describes the server, listing the tools registered so far.
+func (s *Server) Report(ctx context.Context) (*Report, error) {
+	manifest, err := tools.BuildManifest(ctx, &mcp.Implementation{Name: s.cfg.Name, Version: s.cfg.Version}, s.mcp)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{
+		Name:       s.cfg.Name,
+		Version:    s.cfg.Version,
+		GoVersion:  runtime.Version(),
+		Bundle:     tools.Bundle,
+		Transport:  s.cfg.Transport,
+		Ports:      map[string]string{},
+		Middleware: append([]string{}, s.stages...),
+		Auth:       append([]string{}, s.auth...),
+		RateLimit:  s.rateLimit,
+		Telemetry:  map[string]string{},
+		Tools:      make([]string, 0, len(manifest.Tools)),
+	}
+	for _, t := range manifest.Tools {
+		r.Tools = append(r.Tools, t.Name)
+	}
+
+	// Health and metrics are on the stdio transport's only listener, or
+	// the metrics listener when there is one. Routes on the http
+	// transport's PORT are under BASE_PATH.
+	metrics := ":" + s.cfg.Port + s.basePath
+	if s.cfg.Transport == "stdio" {
+		r.Ports["metrics"] = s.cfg.Port
+		metrics = ":" + s.cfg.Port
+	} else {
+		r.Ports["http"] = s.cfg.Port
+		if s.internal != nil {
+			r.Ports["metrics"] = s.cfg.MetricsPort
+			metrics = ":" + s.cfg.MetricsPort
+		}
+		if s.admin != nil {
+			r.Ports["admin"] = s.cfg.AdminPort
+		}
+	}
+	r.Telemetry["prometheus"] = metrics + "/metrics"
+	if s.tracing {
+		r.Telemetry["traces"] = "log"
+	}
+	if s.pprof {
+		admin := ":" + s.cfg.Port + s.basePath
+		if s.cfg.AdminPort != "" {
+			admin = ":" + s.cfg.AdminPort
+		}
+		r.Telemetry["pprof"] = admin + "/admin/debug/pprof/"
+	}
+	return r, nil
+}
+
+// logReport logs the report as a single record.
+func (s *Server) logReport(ctx context.Context) {
+	r, err := s.Report(ctx)
+	if err != nil {
+		s.logger.Warn("building startup report failed", "error", err)
+		return
+	}
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "mcp server configuration",
+		slog.String("name", r.Name),
+		slog.String("version", r.Version),
+		slog.String("bundle", r.Bundle),
+		slog.String("transport", r.Transport),
+		slog.Any("ports", r.Ports),
+		slog.Any("middleware", r.Middleware),
+		slog.Any("auth", r.Auth),
+		slog.String("rate_limit", r.RateLimit),
+		slog.Any("telemetry", r.Telemetry),
+		slog.Int("tool_count", len(r.Tools)),
+		slog.Any("tools", r.Tools),
+	)
+}
+
+// versionHandler serves the report as JSON.
+func (s *Server) versionHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := s.Report(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(report)
+}
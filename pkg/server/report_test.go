@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestReport(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("API_KEYS", "client-key")
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+	t.Setenv("RATE_LIMIT_REQUESTS", "10")
+
+	s, err := New(Config{Name: "reported", Version: "2.0.0", Transport: "http", Port: "8080", MetricsPort: "9090"}, quiet, WithToolRegistry())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	RegisterTool(s, &mcp.Tool{Name: "ping"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, struct{}, error) {
+		return nil, struct{}{}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /version status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var got Report
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding /version: %v", err)
+	}
+
+	if got.Name != "reported" || got.Version != "2.0.0" || got.Transport != "http" {
+		t.Errorf("identity = %s %s %s, want reported 2.0.0 http", got.Name, got.Version, got.Transport)
+	}
+	if got.Ports["http"] != "8080" || got.Ports["metrics"] != "9090" {
+		t.Errorf("Ports = %v, want http 8080 and metrics 9090", got.Ports)
+	}
+	if want := []string{StageMetrics, StageRateLimit, StageAuth}; !slices.Equal(got.Middleware, want) {
+		t.Errorf("Middleware = %v, want %v", got.Middleware, want)
+	}
+	if want := []string{"api_key", "admin_token"}; !slices.Equal(got.Auth, want) {
+		t.Errorf("Auth = %v, want %v", got.Auth, want)
+	}
+	if got.RateLimit != "token-bucket" {
+		t.Errorf("RateLimit = %q, want token-bucket", got.RateLimit)
+	}
+	if got.Telemetry["prometheus"] != ":9090/metrics" {
+		t.Errorf("Telemetry = %v, want prometheus on :9090/metrics", got.Telemetry)
+	}
+	if !slices.Equal(got.Tools, []string{"ping"}) {
+		t.Errorf("Tools = %v, want [ping]", got.Tools)
+	}
+}
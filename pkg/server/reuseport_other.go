@@ -0,0 +1,13 @@
+//go:build !unix
+
+package server
+
+import (
+	"errors"
+	"syscall"
+)
+
+// reusePort reports that SO_REUSEPORT is unavailable on this platform.
+func reusePort(_, _ string, _ syscall.RawConn) error {
+	return errors.New("HTTP_REUSEPORT is not supported on this platform")
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+// minTokenLength is the shortest ADMIN_TOKEN not reported as weak.
+const minTokenLength = 16
+
+// SecurityFinding is a risky setting reported by the startup security
+// check.
+type SecurityFinding struct {
+	// Check names the check, e.g. "auth_disabled".
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// securityFindings checks the configuration of a server using transport
+// for settings that are risky in production.
+func securityFindings(cfg Config, env *envConfig, transport string) []SecurityFinding {
+	var findings []SecurityFinding
+	add := func(check, format string, args ...any) {
+		findings = append(findings, SecurityFinding{Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if transport != "stdio" {
+		if env.keySources == nil && env.verifier == nil {
+			add("auth_disabled", "/mcp accepts unauthenticated requests; set AUTH_ENABLED=true or OAUTH_ISSUER")
+		}
+		if env.adminToken != "" && env.pprof && cfg.AdminPort == "" {
+			add("pprof_public", "pprof profiles are served on the public port; set ADMIN_PORT")
+		}
+		if slices.Contains(config.GetEnvList("AUTH_KEY_SOURCES"), middleware.KeySourceQuery) {
+			add("query_api_keys", "API keys are accepted in URLs, which proxies and access logs record")
+		}
+		if resource := config.GetEnv("OAUTH_RESOURCE", ""); strings.HasPrefix(resource, "http://") {
+			add("plaintext_oauth", "OAUTH_RESOURCE %s is not https, so bearer tokens cross the network in clear text", resource)
+		}
+		for _, proxy := range config.GetEnvList("TRUSTED_PROXIES") {
+			if p, err := netip.ParsePrefix(proxy); err == nil && p.Bits() == 0 {
+				add("trusted_proxies_any", "TRUSTED_PROXIES %s trusts X-Forwarded-For from every client, so IP filters and rate limits can be evaded", proxy)
+			}
+		}
+	}
+	if token := env.adminToken; token != "" && len(token) < minTokenLength {
+		add("weak_admin_token", "ADMIN_TOKEN is shorter than %d characters", minTokenLength)
+	}
+	if config.GetEnvBool("EXEC_ENABLED", false) {
+		add("exec_enabled", "the command tool can run programs from EXEC_ALLOWED_COMMANDS")
+	}
+	if config.GetEnvBool("FETCH_ALLOW_PRIVATE", false) {
+		add("fetch_private", "http_fetch may reach private and loopback addresses, such as cloud metadata endpoints")
+	}
+	return findings
+}
+
+// checkSecurity logs the security findings, and fails when there are any
+// and SECURITY_STRICT is set.
+func (s *Server) checkSecurity() error {
+	findings := securityFindings(s.cfg, s.env, s.cfg.Transport)
+	for _, f := range findings {
+		s.logger.Warn("security check: "+f.Message, "check", f.Check)
+	}
+	if len(findings) > 0 && config.GetEnvBool("SECURITY_STRICT", false) {
+		return fmt.Errorf("security check failed (SECURITY_STRICT is set):\n%w", findingsError(findings))
+	}
+	return nil
+}
+
+// findingsError joins findings into one error, one per line.
+func findingsError(findings []SecurityFinding) error {
+	errs := make([]error, len(findings))
+	for i, f := range findings {
+		errs[i] = fmt.Errorf("%s: %s", f.Check, f.Message)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,905 @@
+// Package server runs the MCP server as a library, so that other binaries
+// can embed it and add their own tools. The mcp-server command is a thin
+// wrapper around it.
+//
+// Settings not covered by Config, such as authentication, rate limiting,
+// and metrics, are read from the environment as described in the README.
+// The built-in tools registered are those whose packages are linked into
+// the binary.
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lkendrickd/mcp-server/internal/approval"
+	"github.com/lkendrickd/mcp-server/internal/capabilities"
+	"github.com/lkendrickd/mcp-server/internal/capture"
+	"github.com/lkendrickd/mcp-server/internal/config"
+	"github.com/lkendrickd/mcp-server/internal/crashreport"
+	"github.com/lkendrickd/mcp-server/internal/events"
+	"github.com/lkendrickd/mcp-server/internal/handlers"
+	"github.com/lkendrickd/mcp-server/internal/media"
+	"github.com/lkendrickd/mcp-server/internal/metrics"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+	"github.com/lkendrickd/mcp-server/internal/oauth"
+	"github.com/lkendrickd/mcp-server/internal/policy"
+	"github.com/lkendrickd/mcp-server/internal/schedule"
+	"github.com/lkendrickd/mcp-server/internal/secrets"
+	"github.com/lkendrickd/mcp-server/internal/session"
+	"github.com/lkendrickd/mcp-server/internal/stdio"
+	"github.com/lkendrickd/mcp-server/internal/tenant"
+	"github.com/lkendrickd/mcp-server/internal/tools"
+	"github.com/lkendrickd/mcp-server/internal/usage"
+	"github.com/lkendrickd/mcp-server/internal/webhook"
+)
+
+// Config configures a Server.
+type Config struct {
+	// Name and Version identify the server to MCP clients. They default to
+	// "mcp-server" and "devel".
+	Name    string
+	Version string
+	// Transport is "stdio" (the default) or "http" ("sse" is an alias).
+	Transport string
+	// Port is the HTTP port: the MCP endpoint for the http transport, or
+	// health and metrics for stdio. Empty uses $PORT or 8080.
+	Port string
+	// MetricsPort, when set, moves /health and /metrics to a separate
+	// listener so they need not be exposed alongside the MCP endpoint.
+	MetricsPort string
+	// AdminPort, when set, serves the admin API on a separate listener
+	// instead of under /admin on Port. The admin API is only served when
+	// ADMIN_TOKEN is set.
+	AdminPort string
+}
+
+// ConfigFromEnv returns the Config selected by MCP_TRANSPORT, METRICS_PORT,
+// and ADMIN_PORT.
+func ConfigFromEnv() Config {
+	return Config{
+		Transport:   config.GetEnv("MCP_TRANSPORT", "stdio"),
+		MetricsPort: config.GetEnv("METRICS_PORT", ""),
+		AdminPort:   config.GetEnv("ADMIN_PORT", ""),
+	}
+}
+
+// Server is an MCP server with its HTTP surface.
+type Server struct {
+	cfg      Config
+	logger   *slog.Logger
+	settings *config.Config
+	mcp      *mcp.Server
+	registry *prometheus.Registry
+	handler  http.Handler
+	internal http.Handler
+	admin    http.Handler
+
+	env         *envConfig
+	provider    secrets.Provider
+	secretsCfg  secrets.Config
+	limiter     middleware.Limiter
+	keyInterval time.Duration
+	sessions    session.Store
+	approver    *approval.Approver
+	events      *events.Bus
+	webhooks    *webhook.Dispatcher
+	usage       *usage.Store
+	rejections  *middleware.RejectionTracker
+	versions    []string
+	media       *media.Policy
+	reaper      *session.Reaper
+	tenants     *tenant.Set
+	summary     *summary
+	reporter    *crashreport.Reporter
+	scheduler   *schedule.Scheduler
+
+	// toolConfig is the tool config file, reloaded every toolConfigInterval
+	toolConfig         string
+	toolConfigInterval time.Duration
+
+	inherited map[string]net.Listener
+	reusePort bool
+	conns     connConfig
+	// basePath prefixes every route on PORT with the http transport
+	basePath string
+
+	drainPeriod    time.Duration
+	terminationLog string
+	drainMu        sync.Mutex
+	draining       atomic.Bool
+	drained        chan struct{}
+	stop           context.CancelFunc
+
+	stages    []string
+	auth      []string
+	rateLimit string
+	pprof     bool
+	tracing   bool
+
+	middleware   []func(http.Handler) http.Handler
+	inserts      []stageInsert
+	registrars   []tools.Registrar
+	healthChecks []HealthCheck
+	transport    mcp.Transport
+	// stdio configures the transport over stdin and stdout, used when no
+	// transport is given; stdoutGuard diverts other writes to stdout to
+	// the log while it runs.
+	stdio       stdio.Config
+	stdoutGuard bool
+}
+
+// Names of the built-in HTTP middleware stages, for HTTP_MIDDLEWARE_ORDER,
+// WithStageBefore, and WithStageAfter.
+const (
+	StageMetrics   = "metrics"
+	StageIPFilter  = "ip_filter"
+	StageRateLimit = "rate_limit"
+	StageLoadShed  = "load_shed"
+	StageAuth      = "auth"
+	StageOAuth     = "oauth"
+	StageTenant    = "tenant"
+	StageTracing   = "tracing"
+)
+
+// defaultStages is the default middleware order, outermost first. Stages
+// that are not configured stay in the order but do nothing.
+var defaultStages = []string{StageMetrics, StageIPFilter, StageRateLimit, StageLoadShed, StageAuth, StageOAuth, StageTenant, StageTracing}
+
+// stageInsert is a stage added by WithStageBefore or WithStageAfter.
+type stageInsert struct {
+	at     string
+	before bool
+	stage  middleware.Stage
+}
+
+// HealthCheck is a named check run by the /health endpoint. When any check
+// returns an error, /health answers 503 Service Unavailable.
+type HealthCheck = handlers.HealthCheck
+
+// Option customizes a Server.
+type Option func(*Server)
+
+// WithLogger sets the logger for server events. The default writes JSON
+// to stderr, which stays clear of the stdio transport.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) { s.logger = logger }
+}
+
+// WithMiddleware wraps the routes of the http transport in mws, the first
+// outermost. They run after the built-in middleware, so requests reaching
+// them have passed IP filtering, rate limiting, and authentication.
+func WithMiddleware(mws ...func(http.Handler) http.Handler) Option {
+	return func(s *Server) { s.middleware = append(s.middleware, mws...) }
+}
+
+// WithStageBefore adds mw as a middleware stage called name just outside
+// stage, so it sees requests before stage does. stage is a built-in stage
+// or one added by an earlier option.
+func WithStageBefore(stage, name string, mw func(http.Handler) http.Handler) Option {
+	return func(s *Server) {
+		s.inserts = append(s.inserts, stageInsert{at: stage, before: true, stage: middleware.Stage{Name: name, Wrap: mw}})
+	}
+}
+
+// WithStageAfter adds mw as a middleware stage called name just inside
+// stage, so it sees requests stage has let through.
+func WithStageAfter(stage, name string, mw func(http.Handler) http.Handler) Option {
+	return func(s *Server) {
+		s.inserts = append(s.inserts, stageInsert{at: stage, stage: middleware.Stage{Name: name, Wrap: mw}})
+	}
+}
+
+// WithToolRegistry registers tools with registrars instead of the built-in
+// tools linked into the binary.
+func WithToolRegistry(registrars ...func(*mcp.Server)) Option {
+	return func(s *Server) {
+		s.registrars = make([]tools.Registrar, 0, len(registrars))
+		for _, r := range registrars {
+			s.registrars = append(s.registrars, r)
+		}
+	}
+}
+
+// WithHealthChecks adds checks to the /health endpoint.
+func WithHealthChecks(checks ...HealthCheck) Option {
+	return func(s *Server) { s.healthChecks = append(s.healthChecks, checks...) }
+}
+
+// WithTransport serves MCP over t instead of stdin and stdout when the
+// configured transport is stdio, for example to run the server over a
+// socket or in memory.
+func WithTransport(t mcp.Transport) Option {
+	return func(s *Server) { s.transport = t }
+}
+
+// New creates a server with the built-in tools registered. It reads the
+// whole configuration and fails if it is invalid and CONFIG_STRICT is set;
+// otherwise invalid values are logged and replaced by their defaults.
+func New(cfg Config, options ...Option) (*Server, error) {
+	// The reporter is set up first so that startup failures reach it
+	reporter, err := crashreport.New(crashreport.LoadConfig(), cmp.Or(cfg.Name, "mcp-server"), cmp.Or(cfg.Version, "devel"))
+	if err != nil {
+		return nil, err
+	}
+	s, err := newServer(cfg, append([]Option{func(s *Server) { s.reporter = reporter }}, options...)...)
+	if err != nil {
+		reporter.Fatal("startup failed", err)
+		reporter.Flush()
+		return nil, err
+	}
+
+	// Every configuration value has been read at this point.
+	if err := config.Validate(); err != nil {
+		if config.Strict() {
+			return nil, fmt.Errorf("invalid configuration (CONFIG_STRICT is set):\n%w", err)
+		}
+		s.logger.Warn("invalid configuration values replaced by defaults", "error", err.Error())
+	}
+	if err := s.checkSecurity(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ReadEnv reads every setting a Server with the http transport reads,
+// including those of the linked tool packages, without building the
+// server: it contacts no secrets provider, opens no roots, pools, or
+// stores, and registers no tools. Settings a secrets provider would
+// supply are read as the environment holds them. The mcp-server config
+// commands use it so that validation and dumps cover the whole
+// configuration. It returns the findings of the security check for the
+// configured transport.
+func ReadEnv() ([]SecurityFinding, error) {
+	cfg := ConfigFromEnv()
+	transport := cmp.Or(cfg.Transport, "stdio")
+	cfg.Transport = "http"
+	// Read by New and newServer before the rest
+	crashreport.LoadConfig()
+	secrets.LoadConfig()
+	env, err := loadEnvConfig(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		return nil, err
+	}
+	tools.LoadConfigs()
+	return securityFindings(cfg, env, transport), nil
+}
+
+// newServer is New without configuration validation.
+func newServer(cfg Config, options ...Option) (*Server, error) {
+	s := &Server{
+		cfg:        cfg,
+		logger:     slog.New(slog.NewJSONHandler(os.Stderr, nil)),
+		registrars: tools.Registry,
+		events:     events.NewBus(),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	if s.cfg.Name == "" {
+		s.cfg.Name = "mcp-server"
+	}
+	if s.cfg.Version == "" {
+		s.cfg.Version = "devel"
+	}
+	if s.cfg.Transport == "" {
+		s.cfg.Transport = "stdio"
+	}
+	if s.reporter != nil {
+		s.reporter.SetLogger(s.logger)
+		tools.ReportPanics(s.reporter.Panic)
+	}
+
+	// Secrets from an external manager must be in place before the
+	// configuration that may reference them is read
+	var err error
+	s.provider, s.secretsCfg, err = loadSecrets(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("secrets provider: %w", err)
+	}
+	if s.provider != nil {
+		s.logger.Info("secrets loaded", "provider", s.provider.Name())
+	}
+
+	// Load configuration from environment
+	if s.env, err = loadEnvConfig(s.cfg, s.logger); err != nil {
+		return nil, err
+	}
+	env := s.env
+	s.settings = env.settings
+	s.cfg.Port = s.settings.Port
+	s.stdio = stdio.Config{Strict: env.stdioStrict, Logger: s.logger}
+	s.stdoutGuard = env.stdoutGuard
+	s.basePath = env.basePath
+	s.reusePort = env.reusePort
+	s.conns = env.conns
+	s.drainPeriod = env.drainPeriod
+	s.terminationLog = env.terminationLog
+	s.versions = env.versions
+	s.toolConfig, s.toolConfigInterval = env.toolConfig, env.toolConfigInterval
+	if s.inherited, err = inheritedListeners(listenFDsStart); err != nil {
+		return nil, err
+	}
+
+	// Register prometheus metrics on the server's own registry
+	if s.registry, err = metrics.Setup(env.metrics); err != nil {
+		return nil, err
+	}
+
+	if err := s.subscribeSinks(); err != nil {
+		return nil, err
+	}
+
+	// Create MCP server with capabilities
+	s.mcp = mcp.NewServer(&mcp.Implementation{Name: s.cfg.Name, Version: s.cfg.Version}, s.serverOptions())
+	if s.toolConfig != "" {
+		if err := tools.LoadSettings(s.toolConfig); err != nil {
+			return nil, fmt.Errorf("TOOL_CONFIG_FILE: %w", err)
+		}
+	}
+	deps := *tools.DefaultDeps()
+	deps.Config, deps.Logger = s.settings, tools.Logger()
+	if err := tools.RegisterEachWith(s.mcp, s.registrars, &deps); err != nil {
+		return nil, err
+	}
+	if len(tools.Health()) > 0 {
+		// A tool dependency or registrar that fails degrades the server
+		// but leaves the other tools serving
+		s.healthChecks = append(s.healthChecks, HealthCheck{
+			Name:     "tools",
+			Check:    tools.CheckHealth,
+			Optional: true,
+			Details:  func() any { return tools.Health() },
+		})
+	}
+	if s.scheduler, err = schedule.Load(env.schedule, s.logger, s.events.Publish); err != nil {
+		return nil, err
+	}
+	if s.scheduler != nil {
+		s.scheduler.Register(s.mcp)
+		s.logger.Info("schedules loaded", "file", env.schedule.File, "schedules", s.scheduler.Len())
+	}
+	s.mcp.AddReceivingMiddleware(s.negotiateVersion, capabilities.Middleware)
+	if s.media = env.media; s.media != nil {
+		s.mcp.AddReceivingMiddleware(s.media.Middleware)
+		s.logger.Info("binary content policy enabled", "max_bytes", env.mediaCfg.MaxBytes, "mime_types", env.mediaCfg.MIMETypes, "offload", s.media.Offloading())
+	}
+	// The output limit applies to content after binary blocks are offloaded
+	if limiter := env.output; limiter != nil {
+		limiter.Register(s.mcp)
+		s.mcp.AddReceivingMiddleware(limiter.Middleware)
+		s.logger.Info("tool output limit enabled", "max_bytes", env.outputCfg.MaxBytes, "policy", env.outputCfg.Policy)
+	}
+	// Traces are info logs: with info disabled the tracing layers are not
+	// installed at all, rather than run on every request to log nothing
+	if env.tracing {
+		if s.tracing = s.logger.Enabled(context.Background(), slog.LevelInfo); s.tracing {
+			s.mcp.AddReceivingMiddleware(media.Trace(s.logger))
+		} else {
+			s.logger.Warn("MCP_TRACING has no effect: info logs are disabled")
+		}
+	}
+	s.logger.Info("tools registered", "bundle", tools.Bundle, "packages", len(s.registrars))
+	if s.approver = env.approver; s.approver != nil {
+		s.mcp.AddReceivingMiddleware(approval.Middleware(s.approver))
+		s.logger.Info("tool approval enabled", "tools", env.approvalCfg.Tools, "timeout", env.approvalCfg.Timeout)
+		if env.approvalCfg.WebhookURL == "" && (s.cfg.Transport == "stdio" || env.adminToken == "") {
+			s.logger.Warn("APPROVAL_TOOLS needs APPROVAL_WEBHOOK_URL or the admin API; their calls will time out")
+		}
+	}
+	// Added after the approver so the policy runs first and denied calls
+	// are never held for approval
+	if p := env.policy; p != nil {
+		s.mcp.AddReceivingMiddleware(policy.Middleware(p, s.logger))
+		s.logger.Info("tool policy loaded", "file", env.policyFile, "rules", len(p.Rules), "default", p.Default)
+	}
+
+	// Added after the policy so tools outside the tenant are refused first
+	if s.tenants = env.tenants; s.tenants != nil {
+		s.mcp.AddReceivingMiddleware(tenant.Middleware(s.tenants))
+		s.logger.Info("tenants loaded", "file", env.tenantsFile, "tenants", s.tenants.Len())
+	}
+
+	// Outermost for tools/call, to report calls the policy or approver rejected too
+	if s.events.Wants(events.ToolCallStarted) || s.events.Wants(events.ToolCallCompleted) || s.events.Wants(events.ToolCallFailed) {
+		s.mcp.AddReceivingMiddleware(s.toolEvents)
+	}
+	if s.summary != nil {
+		s.mcp.AddReceivingMiddleware(s.summary.count)
+	}
+	// Cached lists are serialized already, so nothing may wrap them
+	if env.toolListCache {
+		s.mcp.AddReceivingMiddleware(tools.CacheToolList())
+	}
+
+	switch s.cfg.Transport {
+	case "sse", "http":
+		s.handler, err = s.httpHandler()
+		if err != nil {
+			return nil, err
+		}
+		if s.cfg.MetricsPort != "" {
+			s.internal = s.observabilityHandler()
+		}
+	case "stdio":
+		// Health and metrics are served alongside stdio
+		s.handler = s.observabilityHandler()
+		if s.cfg.MetricsPort != "" {
+			s.cfg.Port = s.cfg.MetricsPort
+		}
+	default:
+		return nil, fmt.Errorf("unknown transport %q: use stdio or http", s.cfg.Transport)
+	}
+	return s, nil
+}
+
+// observabilityHandler serves /health, /metrics, and /version.
+func (s *Server) observabilityHandler() http.Handler {
+	mux := http.NewServeMux()
+	s.observabilityRoutes(mux)
+	return middleware.MetricsMiddleware(mux)
+}
+
+func (s *Server) observabilityRoutes(mux *http.ServeMux) {
+	health := handlers.NewHealthHandler(s.healthChecks)
+	mux.HandleFunc("GET /health", health)
+	mux.HandleFunc("GET /ready", handlers.NewReadyHandler(s.draining.Load, health))
+	mux.Handle("GET /metrics", metrics.Handler(s.registry))
+	mux.HandleFunc("GET /version", s.versionHandler)
+}
+
+// httpHandler builds the handler of the http transport.
+func (s *Server) httpHandler() (http.Handler, error) {
+	// HTTP transport - Streamable HTTP handler for MCP
+	mux := http.NewServeMux()
+	if s.cfg.MetricsPort == "" {
+		s.observabilityRoutes(mux)
+	}
+
+	// Streamable HTTP handler for MCP. A shared session store takes over
+	// session validation so that any replica can serve a session.
+	env := s.env
+	sessionCfg := env.session
+	var err error
+	if s.sessions, err = session.NewStore(sessionCfg); err != nil {
+		return nil, err
+	}
+	var httpHandler http.Handler = mcp.NewStreamableHTTPHandler(func(_ *http.Request) *mcp.Server {
+		return s.mcp
+	}, &mcp.StreamableHTTPOptions{Stateless: s.sessions != nil})
+	if sessionCfg.Affinity || s.sessions != nil {
+		httpHandler = session.Middleware(sessionCfg, s.sessions, s.logger)(httpHandler)
+	}
+	// Shared sessions are stateless here: no process holds them open
+	reaperCfg := env.reaper
+	if s.reaper = session.NewReaper(reaperCfg, s.logger); s.reaper != nil {
+		if s.sessions != nil {
+			s.logger.Warn("SESSION_PING_INTERVAL and SESSION_IDLE_TIMEOUT are ignored with SESSION_STORE")
+			s.reaper = nil
+		} else {
+			s.mcp.AddReceivingMiddleware(s.reaper.Middleware)
+			s.logger.Info("session reaping enabled", "ping_interval", reaperCfg.PingInterval, "idle_timeout", reaperCfg.IdleTimeout)
+		}
+	}
+	// Only requests that pass every middleware stage are recorded
+	recorder, err := capture.New(env.capture, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	if recorder != nil {
+		httpHandler = recorder.Middleware(httpHandler)
+		s.logger.Warn("recording MCP traffic", "dir", env.capture.Dir)
+	}
+	httpHandler = middleware.ProtocolMiddleware(s.versions)(httpHandler)
+	mux.Handle("/mcp", httpHandler)
+	mux.Handle("/mcp/", httpHandler)
+	// Offload URLs carry their own signature instead of credentials
+	if s.media != nil && s.media.Offloading() {
+		mux.Handle(media.Path, s.media.Handler())
+	}
+
+	// Each stage is built even when disabled so that HTTP_MIDDLEWARE_ORDER
+	// and embedders can position stages relative to it
+	stages := make(map[string]func(http.Handler) http.Handler)
+	protectedPrefixes := []string{"/mcp"}
+	if verifier := env.verifier; verifier != nil {
+		oauthCfg := env.oauth
+		// Clients discover the authorization server from this metadata
+		metadata := auth.ProtectedResourceMetadataHandler(verifier.Metadata())
+		mux.Handle(oauth.MetadataPath, metadata)
+		if u, err := url.Parse(oauthCfg.MetadataURL()); err == nil && u.Path != oauth.MetadataPath {
+			mux.Handle(u.Path, metadata)
+		}
+
+		// Bearer tokens replace API keys on /mcp
+		stages[StageOAuth] = oauth.Middleware(verifier, []string{"/mcp"})
+		protectedPrefixes = nil
+		s.auth = append(s.auth, "oauth")
+		s.logger.Info("OAuth bearer token authentication enabled", "issuer", oauthCfg.Issuer, "resource", oauthCfg.Resource)
+	}
+	s.keyInterval = env.keyInterval
+	if sources := env.keySources; sources != nil {
+		// Protect the endpoints with API key authentication
+		stages[StageAuth] = middleware.AuthMiddleware(s.settings, protectedPrefixes, *sources)
+		s.auth = append(s.auth, "api_key")
+		s.logger.Info("API key authentication enabled", "key_count", s.settings.APIKeyCount(), "sources", sources.Sources)
+	} else if s.settings.AuthEnabled {
+		s.logger.Warn("API keys are not used: OAuth bearer tokens protect /mcp")
+	}
+	// Shed load beyond MAX_INFLIGHT before it reaches the tools
+	if shedder := env.shedder; shedder != nil {
+		stages[StageLoadShed] = middleware.LoadShedMiddleware(shedder, []string{"/mcp"})
+		s.logger.Info("load shedding enabled")
+	}
+
+	var rejections *middleware.RejectionTracker
+	s.limiter, s.rateLimit = env.limiter, env.rateLimit
+	if s.limiter != nil {
+		rejections = middleware.NewRejectionTracker(1000)
+		s.rejections = rejections
+		stages[StageRateLimit] = middleware.RateLimitMiddleware(s.limiter, env.resolver, rejections)
+		s.logger.Info("rate limiting enabled", "algorithm", s.rateLimit)
+	}
+
+	// Rejected addresses are turned away before any other work
+	if ipFilter := env.ipFilter; ipFilter.Enabled() {
+		stages[StageIPFilter] = middleware.IPFilterMiddleware(ipFilter)
+		s.logger.Info("IP filtering enabled")
+	}
+	stages[StageMetrics] = middleware.MetricsMiddleware
+
+	// Tenants are assigned from the caller the auth stages record
+	if s.tenants != nil {
+		stages[StageTenant] = tenant.HTTPMiddleware(s.tenants, []string{"/mcp"}, s.logger)
+	}
+
+	// Only authenticated requests are worth parsing for tracing
+	if s.tracing {
+		stages[StageTracing] = middleware.MCPTracingMiddleware(s.logger, []string{"/mcp"})
+	}
+
+	if s.events.Wants(events.AuthFailure) {
+		for stage, method := range map[string]string{StageAuth: "api_key", StageOAuth: "oauth"} {
+			if wrap := stages[stage]; wrap != nil {
+				stages[stage] = s.authEvents(method, wrap)
+			}
+		}
+	}
+
+	if err := s.adminHandler(mux, rejections); err != nil {
+		return nil, err
+	}
+
+	pipeline, err := s.pipeline(stages)
+	if err != nil {
+		return nil, err
+	}
+	s.stages = pipeline.Enabled()
+
+	var handler http.Handler = mux
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		handler = s.middleware[i](handler)
+	}
+	return s.underBasePath(middleware.StripCaller(pipeline.Then(handler))), nil
+}
+
+// loadBasePath reads BASE_PATH, for deployments behind a proxy that
+// forwards a sub-path such as /api unchanged.
+func loadBasePath() (string, error) {
+	p := strings.TrimSuffix(config.GetEnv("BASE_PATH", ""), "/")
+	if p != "" && (!strings.HasPrefix(p, "/") || strings.ContainsAny(p, "?#")) {
+		return "", fmt.Errorf("BASE_PATH %q must be a path starting with /", p)
+	}
+	return p, nil
+}
+
+// underBasePath serves h under s.basePath with the prefix removed, so
+// that routing, the middleware stages, and the metrics path labels see
+// the same paths as without one. OAuth protected resource metadata is
+// served at the host root too, where RFC 9728 clients look for it.
+func (s *Server) underBasePath(h http.Handler) http.Handler {
+	if s.basePath == "" {
+		return h
+	}
+	mux := http.NewServeMux()
+	mux.Handle(s.basePath+"/", http.StripPrefix(s.basePath, h))
+	mux.Handle(oauth.MetadataPath, h)
+	mux.Handle(oauth.MetadataPath+"/", h)
+	return mux
+}
+
+// adminHandler builds the admin API, guarded by ADMIN_TOKEN, and mounts it
+// under /admin on mux unless it has its own listener. Without a token the
+// admin API is not served at all.
+func (s *Server) adminHandler(mux *http.ServeMux, rejections *middleware.RejectionTracker) error {
+	token, debug, profiling := s.env.adminToken, s.env.rateLimitDebug, s.env.pprof
+	if token == "" {
+		if profiling {
+			s.logger.Warn("PPROF_ENABLED has no effect without ADMIN_TOKEN")
+		}
+		return nil
+	}
+
+	admin := http.NewServeMux()
+	admin.HandleFunc("GET /admin/config", handlers.ConfigHandler)
+	admin.HandleFunc("/admin/drain", s.drainHandler)
+	if s.approver != nil {
+		admin.HandleFunc("GET /admin/approvals", s.approver.ListHandler)
+		admin.HandleFunc("POST /admin/approvals/{id}/{decision}", s.approver.DecideHandler)
+	}
+	if s.usage != nil {
+		admin.HandleFunc("GET /admin/usage", s.usage.Handler)
+	}
+	if s.scheduler != nil {
+		admin.HandleFunc("GET /admin/schedules", s.scheduler.ListHandler)
+		admin.HandleFunc("POST /admin/schedules/{name}/{action}", s.scheduler.ActionHandler)
+	}
+	if rejections != nil && debug {
+		admin.Handle("GET /admin/ratelimit", handlers.RateLimitHandler(rejections))
+	}
+	if profiling {
+		admin.Handle("/admin/debug/pprof/", http.StripPrefix("/admin", handlers.PprofHandler()))
+		s.pprof = true
+	}
+	handler := middleware.TokenAuthMiddleware(token)(admin)
+	s.auth = append(s.auth, "admin_token")
+
+	if s.cfg.AdminPort != "" {
+		s.admin = middleware.MetricsMiddleware(handler)
+		s.logger.Info("admin API enabled", "port", s.cfg.AdminPort)
+		return nil
+	}
+	mux.Handle("/admin/", handler)
+	s.logger.Info("admin API enabled", "path", "/admin/")
+	return nil
+}
+
+// pipeline orders the built-in stages by HTTP_MIDDLEWARE_ORDER and adds
+// the stages inserted with WithStageBefore and WithStageAfter.
+func (s *Server) pipeline(wraps map[string]func(http.Handler) http.Handler) (*middleware.Pipeline, error) {
+	stages := make([]middleware.Stage, len(defaultStages))
+	for i, name := range defaultStages {
+		stages[i] = middleware.Stage{Name: name, Wrap: wraps[name]}
+	}
+	p := middleware.NewPipeline(stages...)
+	if order := s.env.middlewareOrder; len(order) > 0 {
+		if err := p.Reorder(order); err != nil {
+			return nil, fmt.Errorf("HTTP_MIDDLEWARE_ORDER: %w", err)
+		}
+	}
+	for _, ins := range s.inserts {
+		insert := p.InsertAfter
+		if ins.before {
+			insert = p.InsertBefore
+		}
+		if err := insert(ins.at, ins.stage); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// RegisterTool adds a tool to s, wrapped in the same middleware as the
+// built-in tools.
+func RegisterTool[In, Out any](s *Server, tool *mcp.Tool, h mcp.ToolHandlerFor[In, Out]) {
+	tools.AddTool(s.mcp, tool, h)
+}
+
+// Handler returns the HTTP handler Run serves on Port: the MCP endpoint and
+// everything around it for the http transport, or health and metrics for
+// stdio. Embedders with their own listener can serve it instead of Run.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// MetricsHandler returns the handler Run serves on MetricsPort with the
+// http transport, or nil when health and metrics are served by Handler.
+func (s *Server) MetricsHandler() http.Handler {
+	return s.internal
+}
+
+// AdminHandler returns the handler Run serves on AdminPort with the http
+// transport, or nil when the admin API is disabled or served by Handler.
+func (s *Server) AdminHandler() http.Handler {
+	return s.admin
+}
+
+// Run serves the configured transport until ctx is done or the transport
+// fails. The stdio transport also ends when the client closes stdin.
+func (s *Server) Run(ctx context.Context) error {
+	start := time.Now()
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	s.setStop(stop)
+
+	// Webhooks outlive run, so that server.stopped is delivered too
+	if s.webhooks != nil {
+		hookCtx, stopHooks := context.WithCancel(context.WithoutCancel(ctx))
+		flushed := make(chan struct{})
+		go func() {
+			s.webhooks.Run(hookCtx)
+			close(flushed)
+		}()
+		defer func() {
+			stopHooks()
+			<-flushed
+		}()
+	}
+
+	err := s.run(ctx)
+	s.terminated(err, time.Since(start))
+	if err != nil {
+		s.reporter.Fatal("server failed", err)
+	}
+	s.reporter.Flush()
+	return err
+}
+
+// run is Run with the context that Drain cancels.
+func (s *Server) run(ctx context.Context) error {
+	s.logReport(ctx)
+	if s.reaper != nil {
+		go s.reaper.Run(ctx)
+	}
+	if s.rejections != nil {
+		go s.watchRejections(ctx, s.env.rejectionAlert, time.Minute)
+	}
+	if s.usage != nil {
+		// Usage is written out once more before run returns
+		usageCtx, stopUsage := context.WithCancel(ctx)
+		flushed := make(chan struct{})
+		go func() {
+			s.usage.Run(usageCtx)
+			close(flushed)
+		}()
+		defer func() {
+			stopUsage()
+			<-flushed
+		}()
+	}
+	if s.scheduler != nil {
+		// Scheduled runs call tools like any client, through the tool
+		// middleware, and finish before run returns
+		session, err := s.Connect(ctx, mcp.NewClient(&mcp.Implementation{Name: s.cfg.Name + "-scheduler", Version: s.cfg.Version}, nil))
+		if err != nil {
+			return fmt.Errorf("scheduler: %w", err)
+		}
+		defer func() { _ = session.Close() }()
+		scheduleCtx, stopSchedules := context.WithCancel(ctx)
+		finished := make(chan struct{})
+		go func() {
+			s.scheduler.Run(scheduleCtx, func(ctx context.Context, tool string, args map[string]any) (*mcp.CallToolResult, error) {
+				return session.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: args})
+			})
+			close(finished)
+		}()
+		defer func() {
+			stopSchedules()
+			<-finished
+		}()
+	}
+	s.events.Publish(events.ServerStarted, map[string]any{"name": s.cfg.Name, "version": s.cfg.Version, "transport": s.cfg.Transport})
+	if s.provider != nil {
+		go secrets.Refresh(ctx, s.provider, s.secretsCfg.RefreshInterval, s.secretsCfg.Timeout, func(_ int, err error) {
+			if err != nil {
+				s.logger.Warn("secrets refresh failed; keeping previous values", "provider", s.provider.Name(), "error", err)
+				return
+			}
+			n := s.settings.ReloadAPIKeys()
+			s.logger.Info("secrets refreshed", "provider", s.provider.Name(), "key_count", n)
+			s.events.Publish(events.ConfigReloaded, map[string]any{"source": "secrets_provider", "provider": s.provider.Name(), "key_count": n})
+		})
+	}
+
+	if s.toolConfig != "" {
+		go tools.WatchSettings(ctx, s.toolConfig, s.toolConfigInterval, func(err error) {
+			if err != nil {
+				s.logger.Error("tool config not reloaded; keeping the previous settings", "file", s.toolConfig, "error", err)
+				return
+			}
+			s.logger.Info("tool config reloaded", "file", s.toolConfig)
+			s.events.Publish(events.ConfigReloaded, map[string]any{"source": "tool_config", "file": s.toolConfig})
+		})
+	}
+
+	if s.cfg.Transport == "stdio" {
+		// Start HTTP server for health/metrics in background
+		go func() {
+			s.logger.Info("http server starting", "port", s.cfg.Port)
+			if err := s.serve(ctx, "http", s.cfg.Port, s.handler); err != nil {
+				s.logger.Error("http server error", "error", err)
+			}
+		}()
+
+		transport := s.transport
+		if transport == nil {
+			out := os.Stdout
+			if s.stdoutGuard {
+				var restore func()
+				var err error
+				out, restore, err = stdio.GuardStdout(s.logger)
+				if err != nil {
+					return fmt.Errorf("stdout guard: %w", err)
+				}
+				defer restore()
+			}
+			transport = stdio.NewTransport(os.Stdin, out, s.stdio)
+		}
+
+		s.logger.Info("mcp server running with stdio transport")
+		if err := s.mcp.Run(ctx, transport); err != nil && ctx.Err() == nil {
+			return fmt.Errorf("mcp server: %w", err)
+		}
+		return nil
+	}
+
+	if s.settings.AuthEnabled {
+		// Pick up rotated keys when they are mounted from a secret file
+		go s.settings.WatchAPIKeys(ctx, s.keyInterval, func(n int) {
+			s.logger.Info("API keys reloaded", "key_count", n)
+			s.events.Publish(events.ConfigReloaded, map[string]any{"source": "api_keys_file", "key_count": n})
+		})
+	}
+	if s.limiter != nil {
+		go middleware.RunCleanup(ctx, s.limiter, time.Minute)
+	}
+	if s.sessions != nil {
+		go session.RunCleanup(ctx, s.sessions, time.Minute, s.logger)
+	}
+
+	listeners := []struct {
+		key     string
+		port    string
+		handler http.Handler
+	}{
+		{"http", s.cfg.Port, s.handler},
+		{"metrics", s.cfg.MetricsPort, s.internal},
+		{"admin", s.cfg.AdminPort, s.admin},
+	}
+
+	// All listeners stop when any fails, and Run returns once every one
+	// has drained
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, len(listeners))
+	running := 0
+	for _, l := range listeners {
+		if l.handler == nil {
+			continue
+		}
+		running++
+		go func() {
+			errs <- wrapErr(l.key+" server", s.serve(ctx, l.key, l.port, l.handler))
+			cancel()
+		}()
+	}
+	var err error
+	for range running {
+		err = errors.Join(err, <-errs)
+	}
+	return err
+}
+
+// wrapErr prefixes a non-nil err with what failed.
+func wrapErr(what string, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	return nil
+}
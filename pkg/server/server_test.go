@@ -0,0 +1,1030 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/crashreport"
+	"github.com/lkendrickd/mcp-server/internal/events"
+	"github.com/lkendrickd/mcp-server/internal/media"
+	"github.com/lkendrickd/mcp-server/internal/middleware"
+)
+
+var quiet = WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "stdio by default", cfg: Config{}},
+		{name: "http", cfg: Config{Transport: "http"}},
+		{name: "sse alias", cfg: Config{Transport: "sse"}},
+		{name: "unknown transport", cfg: Config{Transport: "carrier-pigeon"}, wantErr: "unknown transport"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.cfg, quiet)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("New() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if s.Handler() == nil {
+				t.Fatal("Handler() = nil")
+			}
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("GET /health status = %d, want %d", rec.Code, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestHTTPHandlerAuth(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("API_KEYS", "secret")
+	s, err := New(Config{Transport: "http"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader("{}")))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST /mcp without key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRegisterTool(t *testing.T) {
+	s, err := New(Config{Name: "embedded", Version: "1.2.3"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	type input struct {
+		Name string `json:"name"`
+	}
+	type output struct {
+		Greeting string `json:"greeting"`
+	}
+	RegisterTool(s, &mcp.Tool{Name: "greet", Description: "Greet someone"},
+		func(_ context.Context, _ *mcp.CallToolRequest, in input) (*mcp.CallToolResult, output, error) {
+			return nil, output{Greeting: "hello " + in.Name}, nil
+		})
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := s.mcp.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client Connect() error = %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	if got := session.InitializeResult().ServerInfo; got.Name != "embedded" || got.Version != "1.2.3" {
+		t.Errorf("ServerInfo = %+v, want embedded 1.2.3", got)
+	}
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "greet", Arguments: map[string]any{"name": "gopher"}})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	if out, _ := res.StructuredContent.(map[string]any); out["greeting"] != "hello gopher" {
+		t.Errorf("StructuredContent = %v, want greeting hello gopher", res.StructuredContent)
+	}
+}
+
+func TestRunStopsWithContext(t *testing.T) {
+	s, err := New(Config{Transport: "http", Port: "0"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after the context was canceled")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	var registered bool
+	s, err := New(Config{Transport: "http"}, quiet,
+		WithToolRegistry(func(*mcp.Server) { registered = true }),
+		WithHealthChecks(HealthCheck{Name: "db", Check: func(context.Context) error { return errors.New("down") }}),
+		WithMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Embedded", "yes")
+				next.ServeHTTP(w, r)
+			})
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !registered {
+		t.Error("WithToolRegistry registrar was not called")
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /health status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("X-Embedded"); got != "yes" {
+		t.Errorf("X-Embedded = %q, want middleware to run", got)
+	}
+}
+
+func TestRegistrarFailure(t *testing.T) {
+	s, err := New(Config{Transport: "http"}, quiet,
+		WithToolRegistry(func(*mcp.Server) { panic("broken tool") }),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v, want the failed registrar skipped", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /health status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"registrars":{"registrar 0":"panic: broken tool"}`) {
+		t.Errorf("GET /health body = %s, want the registrar failure in details", body)
+	}
+}
+
+func TestWithTransport(t *testing.T) {
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	s, err := New(Config{Port: "0"}, quiet, WithToolRegistry(), WithTransport(serverTransport))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	RegisterTool(s, &mcp.Tool{Name: "ping"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, struct{}, error) {
+		return nil, struct{}{}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client Connect() error = %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	res, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if len(res.Tools) != 1 || res.Tools[0].Name != "ping" {
+		t.Errorf("ListTools() = %v, want only ping", res.Tools)
+	}
+}
+
+func TestConnect(t *testing.T) {
+	received := make(chan Event, 10)
+	s, err := New(Config{}, quiet, WithToolRegistry(), WithEventSink(EventSinkFunc(func(e Event) { received <- e }), EventToolCallCompleted))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	RegisterTool(s, &mcp.Tool{Name: "echo"}, func(_ context.Context, _ *mcp.CallToolRequest, in struct{ Text string }) (*mcp.CallToolResult, struct{ Text string }, error) {
+		return nil, in, nil
+	})
+
+	// Sessions are independent of each other and of Run
+	for i := range 2 {
+		session, err := s.Connect(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+		res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "echo", Arguments: map[string]any{"Text": "hi"}})
+		if err != nil || res.IsError {
+			t.Fatalf("session %d CallTool() = %v, %v", i, res, err)
+		}
+		if got := res.StructuredContent.(map[string]any)["Text"]; got != "hi" {
+			t.Errorf("session %d echo = %v, want hi", i, got)
+		}
+		if e := <-received; e.Data["tool"] != "echo" {
+			t.Errorf("event = %+v, want a completed echo call", e)
+		}
+		if err := session.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	}
+}
+
+func TestMiddlewareStages(t *testing.T) {
+	header := func(value string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Stage", value)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	tests := []struct {
+		name    string
+		order   string
+		options []Option
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "inserted around auth",
+			options: []Option{WithStageAfter(StageAuth, "inner", header("inner")), WithStageBefore(StageAuth, "outer", header("outer"))},
+			want:    "outer,inner",
+		},
+		{
+			name:    "relative to an inserted stage",
+			options: []Option{WithStageBefore(StageMetrics, "first", header("first")), WithStageAfter("first", "second", header("second"))},
+			want:    "first,second",
+		},
+		{
+			name:    "unknown stage",
+			options: []Option{WithStageAfter("compression", "x", header("x"))},
+			wantErr: `unknown middleware stage "compression"`,
+		},
+		{
+			name:    "configured order",
+			order:   "tracing,tenant,oauth,auth,load_shed,rate_limit,ip_filter,metrics",
+			options: []Option{WithStageBefore(StageAuth, "custom", header("custom"))},
+			want:    "custom",
+		},
+		{
+			name:    "incomplete configured order",
+			order:   "auth,metrics",
+			wantErr: "HTTP_MIDDLEWARE_ORDER",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HTTP_MIDDLEWARE_ORDER", tt.order)
+			s, err := New(Config{Transport: "http"}, append([]Option{quiet}, tt.options...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("New() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+			if got := strings.Join(rec.Header().Values("X-Stage"), ","); got != tt.want {
+				t.Errorf("stages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricsPort(t *testing.T) {
+	s, err := New(Config{Transport: "http", Port: "8080", MetricsPort: "9090"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if s.MetricsHandler() == nil {
+		t.Fatal("MetricsHandler() = nil with MetricsPort set")
+	}
+
+	for _, path := range []string{"/health", "/metrics"} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("public GET %s status = %d, want %d", path, rec.Code, http.StatusNotFound)
+		}
+		rec = httptest.NewRecorder()
+		s.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("internal GET %s status = %d, want %d", path, rec.Code, http.StatusOK)
+		}
+	}
+
+	if _, err := New(Config{Transport: "http", Port: "8080", MetricsPort: "8080"}, quiet); err == nil {
+		t.Error("New() with METRICS_PORT equal to PORT succeeded")
+	}
+	if s, err := New(Config{Transport: "http"}, quiet); err != nil || s.MetricsHandler() != nil {
+		t.Errorf("New() without MetricsPort: MetricsHandler() = %v, error = %v; want nil, nil", s.MetricsHandler(), err)
+	}
+}
+
+func TestAdminAPI(t *testing.T) {
+	get := func(h http.Handler, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("API_KEYS", "client-key")
+
+	// Without a token there is no admin API
+	s, err := New(Config{Transport: "http"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if code := get(s.Handler(), "admin-secret"); code != http.StatusNotFound {
+		t.Errorf("GET /admin/config without ADMIN_TOKEN status = %d, want %d", code, http.StatusNotFound)
+	}
+	if _, err := New(Config{Transport: "http", AdminPort: "9091"}, quiet); err == nil {
+		t.Error("New() with ADMIN_PORT but no ADMIN_TOKEN succeeded")
+	}
+
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+	s, err = New(Config{Transport: "http"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if code := get(s.Handler(), "admin-secret"); code != http.StatusOK {
+		t.Errorf("GET /admin/config with admin token status = %d, want %d", code, http.StatusOK)
+	}
+	if code := get(s.Handler(), "client-key"); code != http.StatusUnauthorized {
+		t.Errorf("GET /admin/config with API key status = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	s, err = New(Config{Transport: "http", AdminPort: "9091"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if code := get(s.Handler(), "admin-secret"); code != http.StatusNotFound {
+		t.Errorf("public GET /admin/config with ADMIN_PORT status = %d, want %d", code, http.StatusNotFound)
+	}
+	if code := get(s.AdminHandler(), "admin-secret"); code != http.StatusOK {
+		t.Errorf("admin GET /admin/config status = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestPprof(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+	t.Setenv("PPROF_ENABLED", "true")
+	s, err := New(Config{Transport: "http"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for token, want := range map[string]int{"admin-secret": http.StatusOK, "": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/debug/pprof/heap?debug=1", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("GET heap profile with token %q status = %d, want %d", token, rec.Code, want)
+		}
+	}
+}
+
+func TestSharedSessions(t *testing.T) {
+	t.Setenv("SESSION_STORE", "dir")
+	t.Setenv("SESSION_STORE_DIR", t.TempDir())
+	t.Setenv("SESSION_AFFINITY", "true")
+	t.Setenv("SESSION_REPLICA_ID", "replica-a")
+	a, err := New(Config{Transport: "http"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Setenv("SESSION_REPLICA_ID", "replica-b")
+	b, err := New(Config{Transport: "http"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	post := func(h http.Handler, id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if id != "" {
+			req.Header.Set("Mcp-Session-Id", id)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(a.Handler(), "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	id := rec.Header().Get("Mcp-Session-Id")
+	if rec.Code != http.StatusOK || id == "" {
+		t.Fatalf("initialize on replica a = %d, session %q", rec.Code, id)
+	}
+	if got := rec.Header().Get("X-MCP-Replica"); got != "replica-a" {
+		t.Errorf("X-MCP-Replica = %q, want replica-a", got)
+	}
+
+	rec = post(b.Handler(), id, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"tools"`) {
+		t.Errorf("tools/list on replica b = %d %s, want the session accepted", rec.Code, rec.Body)
+	}
+	if rec := post(b.Handler(), "unknown", `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown session status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	logPath := t.TempDir() + "/termination-log"
+	t.Setenv("DRAIN_PERIOD", "100ms")
+	t.Setenv("TERMINATION_LOG", logPath)
+	s, err := New(Config{Transport: "http", Port: "0"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		return rec.Code
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+	if got := ready(); got != http.StatusOK {
+		t.Errorf("/ready before draining = %d, want %d", got, http.StatusOK)
+	}
+
+	drained := s.Drain()
+	if got := ready(); got != http.StatusServiceUnavailable {
+		t.Errorf("/ready while draining = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+	if s.Drain() != drained {
+		t.Error("second Drain() returned a different channel")
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after the drain period")
+	}
+	msg, err := os.ReadFile(logPath)
+	if err != nil || !strings.Contains(string(msg), "drained") {
+		t.Errorf("termination log = %q, %v; want a drained message", msg, err)
+	}
+}
+
+func TestSecurityCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport string
+		env       map[string]string
+		want      []string
+	}{
+		{name: "stdio defaults", transport: "stdio"},
+		{name: "http without auth", transport: "http", want: []string{"auth_disabled"}},
+		{name: "http with api keys", transport: "http", env: map[string]string{"AUTH_ENABLED": "true", "API_KEYS": "k"}},
+		{
+			name:      "risky settings",
+			transport: "http",
+			env: map[string]string{
+				"AUTH_ENABLED":     "true",
+				"API_KEYS":         "k",
+				"AUTH_KEY_SOURCES": "header,query",
+				"ADMIN_TOKEN":      "short",
+				"PPROF_ENABLED":    "true",
+				"TRUSTED_PROXIES":  "0.0.0.0/0",
+				"EXEC_ENABLED":     "true",
+			},
+			want: []string{"pprof_public", "query_api_keys", "trusted_proxies_any", "weak_admin_token", "exec_enabled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			s, err := New(Config{Transport: tt.transport}, quiet)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			var got []string
+			for _, f := range securityFindings(s.cfg, s.env, tt.transport) {
+				got = append(got, f.Check)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("findings = %v, want %v", got, tt.want)
+			}
+
+			if len(tt.want) > 0 {
+				t.Setenv("SECURITY_STRICT", "true")
+				if _, err := New(Config{Transport: tt.transport}, quiet); err == nil || !strings.Contains(err.Error(), tt.want[0]) {
+					t.Errorf("New() with SECURITY_STRICT error = %v, want %s", err, tt.want[0])
+				}
+			}
+		})
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	received := make(chan events.Event, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e events.Event
+		_ = json.NewDecoder(r.Body).Decode(&e)
+		received <- e
+	}))
+	defer hook.Close()
+	t.Setenv("WEBHOOK_URLS", hook.URL)
+	t.Setenv("WEBHOOK_EVENTS", "server.started,session.opened,tool_call.failed")
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	s, err := New(Config{Port: "0"}, quiet, WithToolRegistry(), WithTransport(serverTransport))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	RegisterTool(s, &mcp.Tool{Name: "fail"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, struct{}, error) {
+		return nil, struct{}{}, errors.New("boom")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client Connect() error = %v", err)
+	}
+	defer func() { _ = session.Close() }()
+	if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "fail", Arguments: map[string]any{}}); err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+
+	got := map[string]events.Event{}
+	for len(got) < 3 {
+		select {
+		case e := <-received:
+			got[e.Type] = e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received events %v, want server started, session opened, and tool failure", got)
+		}
+	}
+	if name := got[events.ServerStarted].Data["name"]; name != "mcp-server" {
+		t.Errorf("server.started name = %v, want mcp-server", name)
+	}
+	if client := got[events.SessionOpened].Data["client"]; client != "test" {
+		t.Errorf("session.opened client = %v, want test", client)
+	}
+	if tool := got[events.ToolCallFailed].Data["tool"]; tool != "fail" {
+		t.Errorf("tool_call.failed tool = %v, want fail", tool)
+	}
+}
+
+func TestEventSink(t *testing.T) {
+	received := make(chan events.Event, 20)
+	sink := EventSinkFunc(func(e Event) { received <- e })
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("API_KEYS", "secret")
+	s, err := New(Config{Transport: "http"}, quiet, WithToolRegistry(),
+		WithEventSink(sink, EventToolCallStarted, EventToolCallCompleted, EventAuthFailure))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	RegisterTool(s, &mcp.Tool{Name: "ping"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, struct{}, error) {
+		return nil, struct{}{}, nil
+	})
+
+	t.Run("auth failure", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader("{}"))
+		req.Header.Set("X-API-Key", "wrong")
+		s.Handler().ServeHTTP(httptest.NewRecorder(), req)
+		e := <-received
+		if e.Type != events.AuthFailure || e.Data["reason"] != "invalid_token" || e.Data["method"] != "api_key" || e.Data["status"] != http.StatusUnauthorized {
+			t.Errorf("event = %+v, want api_key auth.failure with reason invalid_token", e)
+		}
+	})
+
+	t.Run("tool call", func(t *testing.T) {
+		srv := httptest.NewServer(s.Handler())
+		defer srv.Close()
+		transport := &mcp.StreamableClientTransport{Endpoint: srv.URL + "/mcp", HTTPClient: &http.Client{Transport: apiKeyTransport{"secret"}}}
+		session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(context.Background(), transport, nil)
+		if err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+		defer func() { _ = session.Close() }()
+		if _, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "ping", Arguments: map[string]any{}}); err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+
+		started, completed := <-received, <-received
+		if started.Type != events.ToolCallStarted || started.Data["tool"] != "ping" || started.Data["caller"] == nil {
+			t.Errorf("first event = %+v, want tool_call.started of ping with a caller", started)
+		}
+		if completed.Type != events.ToolCallCompleted || completed.Data["is_error"] != false {
+			t.Errorf("second event = %+v, want successful tool_call.completed", completed)
+		}
+		if len(received) != 0 {
+			t.Errorf("unexpected event %+v", <-received)
+		}
+	})
+}
+
+func TestUsage(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("API_KEYS", "secret")
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+	t.Setenv("USAGE_ENABLED", "true")
+	s, err := New(Config{Transport: "http"}, quiet, WithToolRegistry())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	RegisterTool(s, &mcp.Tool{Name: "ping"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, struct{}, error) {
+		return nil, struct{}{}, nil
+	})
+
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+	transport := &mcp.StreamableClientTransport{Endpoint: srv.URL + "/mcp", HTTPClient: &http.Client{Transport: apiKeyTransport{"secret"}}}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(context.Background(), transport, nil)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = session.Close() }()
+	for range 2 {
+		if _, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "ping", Arguments: map[string]any{}}); err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/usage?format=csv&tool=ping", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /admin/usage status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	caller := middleware.KeyCaller("secret")
+	if !strings.Contains(rec.Body.String(), ","+caller+",ping,2,0,") {
+		t.Errorf("usage = %q, want two ping calls by %s", rec.Body.String(), caller)
+	}
+}
+
+// apiKeyTransport adds an API key to each request.
+type apiKeyTransport struct{ key string }
+
+func (t apiKeyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("X-API-Key", t.key)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestProtocolVersions(t *testing.T) {
+	t.Setenv("MCP_PROTOCOL_VERSIONS", "2025-06-18,2025-03-26")
+	s, err := New(Config{Transport: "http"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	post := func(version, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if version != "" {
+			req.Header.Set(middleware.HeaderProtocolVersion, version)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// An older client is offered the newest configured version
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"old","version":"1"}}}`)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	for _, want := range []string{`"protocolVersion":"2025-06-18"`, `"supportedProtocolVersions":["2025-06-18","2025-03-26"]`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("initialize = %s, want %s", body, want)
+		}
+	}
+
+	resp = post("2024-11-05", `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	defer func() { _ = resp.Body.Close() }()
+	var rpcErr middleware.RPCError
+	if err := json.NewDecoder(resp.Body).Decode(&rpcErr); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || rpcErr.Error.Data.Reason != middleware.ReasonUnsupportedVersion {
+		t.Errorf("unsupported version = %d %+v, want 400 %s", resp.StatusCode, rpcErr.Error, middleware.ReasonUnsupportedVersion)
+	}
+
+	t.Setenv("MCP_PROTOCOL_VERSIONS", "2025-06-18,1999-01-01")
+	if _, err := New(Config{Transport: "http"}, quiet); err == nil || !strings.Contains(err.Error(), "MCP_PROTOCOL_VERSIONS") {
+		t.Errorf("New() with an unknown version error = %v", err)
+	}
+}
+
+func TestBinaryOffload(t *testing.T) {
+	t.Setenv("TOOL_BINARY_MAX_BYTES", "1024")
+	t.Setenv("TOOL_BINARY_OFFLOAD_URL", "https://mcp.example.com")
+	if _, err := New(Config{Transport: "stdio"}, quiet); err == nil {
+		t.Error("New() with stdio succeeded, want an error: offload URLs need the http transport")
+	}
+
+	s, err := New(Config{Transport: "http"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, media.Path+"abc?expires=1&sig=00", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("unsigned offload URL status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestTenants(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tenants.yaml")
+	tenants := "tenants:\n" +
+		"  - name: search\n    callers: [\"" + middleware.KeyCaller("search-key") + "\"]\n    tools: [ping]\n" +
+		"  - name: ops\n    callers: [\"" + middleware.KeyCaller("ops-key") + "\"]\n"
+	if err := os.WriteFile(file, []byte(tenants), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("API_KEYS", "search-key,ops-key,stray-key")
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+	t.Setenv("USAGE_ENABLED", "true")
+	t.Setenv("TENANTS_FILE", file)
+	if _, err := New(Config{Transport: "stdio"}, quiet); err == nil {
+		t.Error("New() with stdio succeeded, want an error: tenants need the http transport")
+	}
+	s, err := New(Config{Transport: "http"}, quiet, WithToolRegistry())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, name := range []string{"ping", "pong"} {
+		RegisterTool(s, &mcp.Tool{Name: name}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, struct{}, error) {
+			return nil, struct{}{}, nil
+		})
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+	connect := func(key string) (*mcp.ClientSession, error) {
+		transport := &mcp.StreamableClientTransport{Endpoint: srv.URL + "/mcp", HTTPClient: &http.Client{Transport: apiKeyTransport{key}}}
+		return mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(context.Background(), transport, nil)
+	}
+
+	if _, err := connect("stray-key"); err == nil {
+		t.Error("Connect() with a key of no tenant succeeded")
+	}
+
+	tests := []struct {
+		key       string
+		wantTools string
+	}{
+		{key: "search-key", wantTools: "ping"},
+		{key: "ops-key", wantTools: "ping,pong"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			session, err := connect(tt.key)
+			if err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+			defer func() { _ = session.Close() }()
+			list, err := session.ListTools(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("ListTools() error = %v", err)
+			}
+			var names []string
+			for _, tool := range list.Tools {
+				names = append(names, tool.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.wantTools {
+				t.Errorf("tools = %q, want %q", got, tt.wantTools)
+			}
+			res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "pong", Arguments: map[string]any{}})
+			if err != nil {
+				t.Fatalf("CallTool() error = %v", err)
+			}
+			if res.IsError != !strings.Contains(tt.wantTools, "pong") {
+				t.Errorf("CallTool(pong) IsError = %v", res.IsError)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/usage?format=csv&tenant=ops", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if caller := middleware.KeyCaller("ops-key"); !strings.Contains(rec.Body.String(), ",ops,"+caller+",pong,1,0,") {
+		t.Errorf("usage = %q, want a pong call by ops", rec.Body.String())
+	}
+}
+
+func TestTracing(t *testing.T) {
+	t.Setenv("MCP_TRACING", "true")
+	tests := []struct {
+		name  string
+		level slog.Level
+		want  bool
+	}{
+		{name: "info", level: slog.LevelInfo, want: true},
+		{name: "warn skips the tracing layers", level: slog.LevelWarn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: tt.level}))
+			s, err := New(Config{Transport: "http"}, WithLogger(logger))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := slices.Contains(s.stages, StageTracing); s.tracing != tt.want || got != tt.want {
+				t.Errorf("tracing = %v, stage installed = %v, want %v", s.tracing, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("API_KEYS", "secret")
+	t.Setenv("BASE_PATH", "/api/")
+	s, err := New(Config{Transport: "http"}, quiet)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{method: http.MethodGet, path: "/api/health", wantStatus: http.StatusOK},
+		{method: http.MethodGet, path: "/health", wantStatus: http.StatusNotFound},
+		{method: http.MethodPost, path: "/api/mcp", wantStatus: http.StatusUnauthorized},
+		{method: http.MethodPost, path: "/mcp", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}")))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	transport := &mcp.StreamableClientTransport{Endpoint: srv.URL + "/api/mcp", HTTPClient: &http.Client{Transport: apiKeyTransport{"secret"}}}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(context.Background(), transport, nil)
+	if err != nil {
+		t.Fatalf("Connect() under BASE_PATH error = %v", err)
+	}
+	_ = session.Close()
+
+	t.Setenv("BASE_PATH", "api")
+	if _, err := New(Config{Transport: "http"}, quiet); err == nil || !strings.Contains(err.Error(), "BASE_PATH") {
+		t.Errorf("New() with a relative BASE_PATH error = %v, want a BASE_PATH error", err)
+	}
+}
+
+func TestShutdownSummary(t *testing.T) {
+	received := make(chan events.Event, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e events.Event
+		_ = json.NewDecoder(r.Body).Decode(&e)
+		received <- e
+	}))
+	defer hook.Close()
+	t.Setenv("WEBHOOK_URLS", hook.URL)
+	t.Setenv("WEBHOOK_EVENTS", "server.stopped")
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	s, err := New(Config{Port: "0"}, quiet, WithToolRegistry(), WithTransport(serverTransport))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	RegisterTool(s, &mcp.Tool{Name: "ping"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, struct{}, error) {
+		return nil, struct{}{}, nil
+	})
+	RegisterTool(s, &mcp.Tool{Name: "fail"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, struct{}, error) {
+		return nil, struct{}{}, errors.New("boom")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client Connect() error = %v", err)
+	}
+	for _, name := range []string{"ping", "ping", "fail"} {
+		if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: name, Arguments: map[string]any{}}); err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+	}
+	_ = session.Close()
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Run returns once the report is delivered
+	var e events.Event
+	select {
+	case e = <-received:
+	default:
+		t.Fatal("server.stopped not delivered before Run returned")
+	}
+	calls, _ := e.Data["tool_calls"].(map[string]any)
+	failures, _ := e.Data["tool_errors"].(map[string]any)
+	if e.Type != events.ServerStopped || e.Data["reason"] != "shutdown" || e.Data["sessions"] != 1.0 || e.Data["errors"] != 1.0 {
+		t.Errorf("event = %+v, want server.stopped after shutdown with 1 session and 1 error", e)
+	}
+	if calls["ping"] != 2.0 || calls["fail"] != 1.0 || failures["fail"] != 1.0 {
+		t.Errorf("tool_calls = %v, tool_errors = %v, want ping 2 and fail 1 failed", calls, failures)
+	}
+	if requests, _ := e.Data["requests"].(float64); requests < 4 {
+		t.Errorf("requests = %v, want initialize and three calls at least", requests)
+	}
+}
+
+func TestErrorReporting(t *testing.T) {
+	reports := make(chan crashreport.Event, 10)
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e crashreport.Event
+		_ = json.NewDecoder(r.Body).Decode(&e)
+		reports <- e
+	}))
+	defer tracker.Close()
+	t.Setenv("ERROR_REPORTING_DSN", tracker.URL)
+
+	if _, err := New(Config{Transport: "carrier-pigeon"}, quiet); err == nil {
+		t.Fatal("New() with an unknown transport succeeded")
+	}
+	if e := <-reports; e.Tags["kind"] != crashreport.KindFatal || !strings.Contains(e.Message, "startup failed") {
+		t.Errorf("startup report = %+v, want a fatal startup failure", e)
+	}
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	s, err := New(Config{Name: "reported", Version: "2.0.0", Port: "0"}, quiet, WithToolRegistry(), WithTransport(serverTransport))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	RegisterTool(s, &mcp.Tool{Name: "explode"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, struct{}, error) {
+		panic("boom")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client Connect() error = %v", err)
+	}
+	defer func() { _ = session.Close() }()
+	if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "explode", Arguments: map[string]any{}}); err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	select {
+	case e := <-reports:
+		if e.Tags["kind"] != crashreport.KindPanic || e.Tags["tool"] != "explode" || e.Release != "reported@2.0.0" {
+			t.Errorf("panic report = %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tool panic not reported")
+	}
+}
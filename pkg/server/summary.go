@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"sync"
+	"sync/atomic"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/lkendrickd/mcp-server/internal/events"
+)
+
+// summary counts what the server did while it ran, for the shutdown
+// report. It is an events.Sink of tool_call.completed and session.opened.
+type summary struct {
+	requests atomic.Int64
+
+	mu       sync.Mutex
+	calls    map[string]int64
+	failures map[string]int64
+	sessions int64
+}
+
+func newSummary() *summary {
+	return &summary{calls: make(map[string]int64), failures: make(map[string]int64)}
+}
+
+// Publish counts a completed tool call or an opened session.
+func (m *summary) Publish(e events.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch e.Type {
+	case events.ToolCallCompleted:
+		tool, _ := e.Data["tool"].(string)
+		m.calls[tool]++
+		if failed, _ := e.Data["is_error"].(bool); failed {
+			m.failures[tool]++
+		}
+	case events.SessionOpened:
+		m.sessions++
+	}
+}
+
+// count is receiving middleware counting every MCP request.
+func (m *summary) count(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		m.requests.Add(1)
+		return next(ctx, method, req)
+	}
+}
+
+// data returns the counts as event data: requests, tool_calls and
+// tool_errors by tool, errors in all, and sessions.
+func (m *summary) data() map[string]any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs int64
+	for _, n := range m.failures {
+		errs += n
+	}
+	return map[string]any{
+		"requests":    m.requests.Load(),
+		"tool_calls":  maps.Clone(m.calls),
+		"tool_errors": maps.Clone(m.failures),
+		"errors":      errs,
+		"sessions":    m.sessions,
+	}
+}
+
+// attrs returns data as log attributes, in a fixed order.
+func (m *summary) attrs() []any {
+	data := m.data()
+	var attrs []any
+	for _, key := range []string{"requests", "tool_calls", "tool_errors", "errors", "sessions"} {
+		attrs = append(attrs, slog.Any(key, data[key]))
+	}
+	return attrs
+}
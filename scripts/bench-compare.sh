@@ -0,0 +1,63 @@
+#!/bin/sh
+# bench-compare.sh runs the hot-path benchmarks on a git ref and on the
+# working tree, compares them with benchstat, and fails when a benchmark
+# got slower or allocates more by over BENCH_THRESHOLD percent. benchstat
+# only reports a change when it is statistically significant, so noise
+# within the runs' variance does not fail the comparison.
+#
+# Usage: scripts/bench-compare.sh [ref]
+#
+# BENCH, BENCH_COUNT, BENCH_PKGS, BENCH_THRESHOLD, and BENCHSTAT override
+# the defaults below; make bench-compare sets them from its variables.
+set -eu
+
+ref=${1:-main}
+bench=${BENCH:-.}
+count=${BENCH_COUNT:-10}
+pkgs=${BENCH_PKGS:-./internal/middleware ./internal/jsonrpc ./internal/tools}
+threshold=${BENCH_THRESHOLD:-10}
+benchstat=${BENCHSTAT:-go run golang.org/x/perf/cmd/benchstat@latest}
+
+tmp=$(mktemp -d)
+cleanup() {
+	git worktree remove --force "$tmp/base" >/dev/null 2>&1 || true
+	rm -rf "$tmp"
+}
+trap cleanup EXIT
+
+run() {
+	# shellcheck disable=SC2086 # pkgs is a list
+	go test -run='^$' -bench="$bench" -benchmem -count="$count" $pkgs
+}
+
+git worktree add --detach "$tmp/base" "$ref" >/dev/null
+echo "Running benchmarks on $ref" >&2
+(cd "$tmp/base" && run) >"$tmp/base.txt"
+echo "Running benchmarks on the working tree" >&2
+run >"$tmp/head.txt"
+
+$benchstat "$ref=$tmp/base.txt" "head=$tmp/head.txt" | tee "$tmp/benchstat.txt"
+
+# Each benchstat table starts with a header naming its unit and a
+# "vs base" column; significant changes read like "+12.34% (p=0.002 n=10)"
+awk -v max="$threshold" '
+	/vs base/ {
+		unit = "other"
+		if ($0 ~ /sec\/op/) unit = "sec/op"
+		else if ($0 ~ /allocs\/op/) unit = "allocs/op"
+		next
+	}
+	unit != "other" && match($0, /\+[0-9.]+% \(p=/) {
+		pct = substr($0, RSTART + 1, RLENGTH - 6) + 0
+		if (pct > max) {
+			printf "regression: %s %s +%.2f%%\n", $1, unit, pct
+			failed = 1
+		}
+	}
+	END {
+		if (failed) {
+			printf "benchmarks regressed by more than %s%% against the base\n", max
+			exit 1
+		}
+	}
+' "$tmp/benchstat.txt"